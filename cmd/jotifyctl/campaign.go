@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/JrMarcco/jotify/internal/service/notification"
+	"github.com/spf13/pflag"
+)
+
+// runCampaign 群发活动管理。
+//
+// jotifyctl campaign list --biz-id 1 [--offset 0] [--limit 100]
+// jotifyctl campaign get | pause | resume | cancel --biz-id 1 <biz_key>
+//
+// 活动的创建、接收者上传与开始通过网关完成。
+func runCampaign(args []string) error {
+	name, args, err := subcommand(args, "list", "get", "pause", "resume", "cancel")
+	if err != nil {
+		return err
+	}
+
+	fs := pflag.NewFlagSet("campaign "+name, pflag.ExitOnError)
+	bizId := fs.Uint64("biz-id", 0, "业务 id")
+	offset := fs.Int("offset", 0, "分页偏移")
+	limit := fs.Int("limit", 100, "分页大小")
+	timeout := fs.Duration("timeout", defaultTimeout, "超时时间")
+	_ = fs.Parse(args)
+
+	if *bizId == 0 {
+		return errors.New("usage: campaign " + name + " --biz-id <id>")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	ctx = adminContext(ctx, *bizId)
+
+	var campaignSvc notification.CampaignService
+	if err = populate(&campaignSvc); err != nil {
+		return err
+	}
+
+	if name == "list" {
+		cs, err := campaignSvc.List(ctx, *bizId, *offset, *limit)
+		if err != nil {
+			return err
+		}
+		return printJson(cs)
+	}
+
+	if fs.NArg() != 1 {
+		return errors.New("usage: campaign " + name + " --biz-id <id> <biz_key>")
+	}
+	bizKey := fs.Arg(0)
+
+	switch name {
+	case "get":
+		c, err := campaignSvc.Get(ctx, *bizId, bizKey)
+		if err != nil {
+			return err
+		}
+		return printJson(c)
+	case "pause":
+		err = campaignSvc.Pause(ctx, *bizId, bizKey)
+	case "resume":
+		err = campaignSvc.Resume(ctx, *bizId, bizKey)
+	default:
+		err = campaignSvc.Cancel(ctx, *bizId, bizKey)
+	}
+	if err != nil {
+		return err
+	}
+	return printJson(map[string]any{"biz_id": *bizId, "biz_key": bizKey, name: true})
+}
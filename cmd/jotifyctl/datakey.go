@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/JrMarcco/jotify/internal/pkg/envelope"
+	"github.com/spf13/pflag"
+)
+
+// runDataKey 业务方数据密钥管理。
+//
+// jotifyctl datakey rotate --biz-id 1
+// jotifyctl datakey rewrap [--batch-size 100]
+//
+// rotate 为业务方生成新版本的数据密钥，各实例在 encryption.latest_key_ttl 内切换到新版本，旧版本继续用于解密。
+// rewrap 在切换 encryption.current_key_id 后使用新的主密钥重新加密全部数据密钥，完成后即可下线旧主密钥。
+func runDataKey(args []string) error {
+	name, args, err := subcommand(args, "rotate", "rewrap")
+	if err != nil {
+		return err
+	}
+
+	fs := pflag.NewFlagSet("datakey "+name, pflag.ExitOnError)
+	bizId := fs.Uint64("biz-id", 0, "业务 id")
+	batchSize := fs.Int("batch-size", 100, "每批重新加密的数据密钥数")
+	timeout := fs.Duration("timeout", defaultTimeout, "超时时间")
+	_ = fs.Parse(args)
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	var cipher *envelope.Cipher
+	if err = populate(&cipher); err != nil {
+		return err
+	}
+
+	if name == "rotate" {
+		if *bizId == 0 {
+			return errors.New("usage: datakey rotate --biz-id <id>")
+		}
+		version, err := cipher.Rotate(ctx, *bizId)
+		if err != nil {
+			return err
+		}
+		return printJson(map[string]any{"biz_id": *bizId, "version": version})
+	}
+
+	cnt, err := cipher.Rewrap(ctx, *batchSize)
+	if err != nil {
+		return err
+	}
+	return printJson(map[string]any{"rewrapped": cnt})
+}
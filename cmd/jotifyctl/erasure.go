@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/JrMarcco/jotify/internal/service/notification"
+	"github.com/spf13/pflag"
+)
+
+// runErasure 个人数据擦除。
+//
+// jotifyctl erasure run --receiver 13800000000 [--biz-id 1]
+// jotifyctl erasure receipt <id> [--biz-id 1]
+//
+// 未指定 --biz-id 时擦除全部业务方的消息，查询回执时不限制业务方。
+func runErasure(args []string) error {
+	name, args, err := subcommand(args, "run", "receipt")
+	if err != nil {
+		return err
+	}
+
+	fs := pflag.NewFlagSet("erasure "+name, pflag.ExitOnError)
+	bizId := fs.Uint64("biz-id", 0, "业务 id，0 表示全部业务方")
+	receiver := fs.String("receiver", "", "接收者（手机号、邮箱等）")
+	timeout := fs.Duration("timeout", defaultTimeout, "超时时间")
+	_ = fs.Parse(args)
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	var erasureSvc notification.ErasureService
+	if err = populate(&erasureSvc); err != nil {
+		return err
+	}
+
+	if name == "run" {
+		receipt, err := erasureSvc.Erase(adminContext(ctx, *bizId), *bizId, *receiver)
+		if err != nil {
+			return err
+		}
+		return printJson(receipt)
+	}
+
+	if fs.NArg() != 1 {
+		return errors.New("usage: erasure receipt <id>")
+	}
+	id, err := parseId(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	receipt, err := erasureSvc.GetReceipt(adminContext(ctx, *bizId), *bizId, id)
+	if err != nil {
+		return err
+	}
+	return printJson(receipt)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/JrMarcco/jotify/internal/ioc"
+	"github.com/JrMarcco/jotify/internal/pkg/sharding"
+	"github.com/JrMarcco/jotify/internal/pkg/snowflake"
+	"github.com/spf13/pflag"
+)
+
+func runId(args []string) error {
+	_, args, err := subcommand(args, "decode")
+	if err != nil {
+		return err
+	}
+	return decodeId(args)
+}
+
+// decodeId 解析消息 id 并输出所在的分库分表
+//
+// jotifyctl id decode <id>
+func decodeId(args []string) error {
+	fs := pflag.NewFlagSet("id decode", pflag.ExitOnError)
+	_ = fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return errors.New("usage: id decode <id>")
+	}
+	id, err := parseId(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	var syncer *sharding.RoutingSyncer
+	if err = populate(&syncer); err != nil {
+		return err
+	}
+
+	res := map[string]any{
+		"id":           strconv.FormatUint(id, 10),
+		"timestamp":    snowflake.ExtractTimestamp(id).Format(time.RFC3339Nano),
+		"hash":         snowflake.ExtractHash(id),
+		"sequence":     snowflake.ExtractSequence(id),
+		"notification": dstView(syncer.Strategy(ioc.NotifTablePrefix).ShardWithId(id)),
+		"callback_log": dstView(syncer.Strategy(ioc.CbLogTablePrefix).ShardWithId(id)),
+	}
+	// 迁移期间同时输出旧布局下的分片
+	if dst, ok := syncer.Strategy(ioc.NotifTablePrefix).PreviousShardWithId(id); ok {
+		res["previous_notification"] = dstView(dst)
+	}
+	if dst, ok := syncer.Strategy(ioc.CbLogTablePrefix).PreviousShardWithId(id); ok {
+		res["previous_callback_log"] = dstView(dst)
+	}
+	return printJson(res)
+}
+
+func dstView(dst sharding.Dst) map[string]string {
+	return map[string]string{"db": dst.DB, "table": dst.Table}
+}
+
+func parseId(str string) (uint64, error) {
+	id, err := strconv.ParseUint(str, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid id %q: %w", str, err)
+	}
+	return id, nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+
+	jwtpkg "github.com/JrMarcco/jotify/internal/api/grpc/interceptor/jwt"
+	"github.com/spf13/pflag"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// runKey 管理 etcd 中的 jwt 密钥。
+//
+// 轮换流程（首次迁移到 etcd 时，需要先以配置文件中的 kid 发布配置文件中的密钥）：
+//  1. key publish --kid k1 --private k1.pem --public k1.pub --signing   发布新密钥并切换为签名密钥
+//  2. 等待旧 token 过期（默认 24h），期间旧密钥继续用于验证
+//  3. key retire --kid k0                                               移除旧密钥
+func runKey(args []string) error {
+	name, args, err := subcommand(args, "publish", "retire")
+	if err != nil {
+		return err
+	}
+
+	fs := pflag.NewFlagSet("key "+name, pflag.ExitOnError)
+	kid := fs.String("kid", "", "密钥 id")
+	priFile := fs.String("private", "", "私钥 PEM 文件，只用于验证的密钥可以不指定")
+	pubFile := fs.String("public", "", "公钥 PEM 文件")
+	signing := fs.Bool("signing", false, "是否设置为签名密钥")
+	timeout := fs.Duration("timeout", defaultTimeout, "超时时间")
+	_ = fs.Parse(args)
+
+	if *kid == "" {
+		return errors.New("--kid is required")
+	}
+
+	var syncer *jwtpkg.EtcdSyncer
+	var etcdClient *clientv3.Client
+	if err = populate(&syncer, &etcdClient); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	if name == "retire" {
+		if signingKid, _, err := syncer.KeyRing().SigningKey(); err == nil && signingKid == *kid {
+			return errors.New("can not retire the current signing key")
+		}
+		_, err = etcdClient.Delete(ctx, syncer.EtcdKeyOfKey(*kid))
+		return err
+	}
+
+	return publishKey(ctx, etcdClient, syncer, *kid, *priFile, *pubFile, *signing)
+}
+
+func publishKey(
+	ctx context.Context, etcdClient *clientv3.Client, syncer *jwtpkg.EtcdSyncer,
+	kid, priFile, pubFile string, signing bool,
+) error {
+	if pubFile == "" {
+		return errors.New("--public is required")
+	}
+	if signing && priFile == "" {
+		return errors.New("--private is required for signing key")
+	}
+
+	val := jwtpkg.EtcdKeyValue{}
+	pubPem, err := os.ReadFile(pubFile)
+	if err != nil {
+		return err
+	}
+	if _, err = jwtpkg.ParsePublicKey(string(pubPem)); err != nil {
+		return err
+	}
+	val.Public = string(pubPem)
+
+	if priFile != "" {
+		priPem, err := os.ReadFile(priFile)
+		if err != nil {
+			return err
+		}
+		if _, err = jwtpkg.ParsePrivateKey(string(priPem)); err != nil {
+			return err
+		}
+		val.Private = string(priPem)
+	}
+
+	bs, err := json.Marshal(val)
+	if err != nil {
+		return err
+	}
+
+	ops := []clientv3.Op{clientv3.OpPut(syncer.EtcdKeyOfKey(kid), string(bs))}
+	if signing {
+		ops = append(ops, clientv3.OpPut(syncer.EtcdKeyOfSigningKid(), kid))
+	}
+	// 密钥与签名 kid 在同一个事务中写入，避免服务加载到不存在的签名密钥
+	_, err = etcdClient.Txn(ctx).Then(ops...).Commit()
+	return err
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/JrMarcco/jotify/internal/ioc"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"go.uber.org/fx"
+)
+
+// command 子命令
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+var commands = []command{
+	{name: "token", usage: "issue | inspect | revoke    签发、解析、吊销 jwt token", run: runToken},
+	{name: "key", usage: "publish | retire   发布、移除 jwt 签名密钥", run: runKey},
+	{name: "id", usage: "decode             解析消息 id 及其所在的分库分表", run: runId},
+	{name: "notification", usage: "get | cancel | resend | backlog   查询、取消、重新发送消息、统计待发送积压", run: runNotification},
+	{name: "callback", usage: "get                查询消息的回调记录", run: runCallback},
+	{name: "erasure", usage: "run | receipt      擦除接收者的个人数据、查询擦除回执", run: runErasure},
+	{name: "suppression", usage: "import | remove | list   管理退订名单", run: runSuppression},
+	{name: "recurring", usage: "list | pause | resume | delete   管理周期发送计划", run: runRecurring},
+	{name: "campaign", usage: "list | get | pause | resume | cancel   管理群发活动", run: runCampaign},
+	{name: "sensitive", usage: "add | remove | list | check   管理模板参数敏感词", run: runSensitive},
+	{name: "template", usage: "submit | audit | queue | approve | reject | history   提交语言变体、审核模板版本与语言变体、查询审核记录", run: runTemplate},
+	{name: "org", usage: "create | get | join | leave | topup | cap | allocate | reclaim | usage   管理组织、成员业务方与组织配额", run: runOrganization},
+	{name: "datakey", usage: "rotate | rewrap    轮换业务方数据密钥、使用新主密钥重新加密数据密钥", run: runDataKey},
+	{name: "shard", usage: "show | begin | backfill | cutover   在线扩容分库分表", run: runShard},
+	{name: "migrate", usage: "status | up        版本化数据库迁移", run: runMigrate},
+}
+
+// jotifyctl 运维命令行工具，与服务使用相同的配置文件。
+//
+// 用法：jotifyctl [--config etc/config.yaml] <command> <subcommand> [flags]
+func main() {
+	fs := pflag.NewFlagSet("jotifyctl", pflag.ExitOnError)
+	configFile := fs.String("config", "etc/config.yaml", "配置文件路径")
+	fs.SetInterspersed(false)
+	fs.Usage = usage
+	_ = fs.Parse(os.Args[1:])
+
+	args := fs.Args()
+	if len(args) == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+
+		initViper(*configFile)
+		if err := cmd.run(args[1:]); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "jotifyctl %s: %v\n", cmd.name, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	_, _ = fmt.Fprintf(os.Stderr, "jotifyctl: unknown command %q\n", args[0])
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	_, _ = fmt.Fprintln(os.Stderr, "usage: jotifyctl [--config etc/config.yaml] <command> <subcommand> [flags]")
+	_, _ = fmt.Fprintln(os.Stderr, "\ncommands:")
+	for _, cmd := range commands {
+		_, _ = fmt.Fprintf(os.Stderr, "  %-14s %s\n", cmd.name, cmd.usage)
+	}
+}
+
+func initViper(configFile string) {
+	viper.SetConfigFile(configFile)
+	viper.SetConfigType("yaml")
+	if err := viper.ReadInConfig(); err != nil {
+		panic(err)
+	}
+}
+
+// subcommand 解析子命令名称，剩余参数交给子命令的 FlagSet 处理
+func subcommand(args []string, names ...string) (string, []string, error) {
+	if len(args) == 0 {
+		return "", nil, fmt.Errorf("missing subcommand, available: %v", names)
+	}
+	for _, name := range names {
+		if args[0] == name {
+			return name, args[1:], nil
+		}
+	}
+	return "", nil, fmt.Errorf("unknown subcommand %q, available: %v", args[0], names)
+}
+
+// printJson 以缩进 json 格式输出结果
+func printJson(val any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(val)
+}
+
+// populate 使用与服务相同的依赖注入配置创建所需组件，只会初始化 targets 依赖到的部分
+func populate(targets ...any) error {
+	app := fx.New(
+		ioc.LoggerFxOpt,
+		ioc.IdFxOpt,
+		ioc.RedisFxOpt,
+		ioc.DBFxOpt,
+		ioc.EtcdFxOpt,
+		ioc.RepoFxOpt,
+		ioc.ServiceFxOpt,
+		ioc.RegistryFxOpt,
+		ioc.GrpcFxOpt,
+
+		fx.NopLogger,
+		fx.Populate(targets...),
+	)
+	return app.Err()
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/JrMarcco/easy-kit/xsync"
+	"github.com/JrMarcco/jotify/internal/ioc"
+	"github.com/JrMarcco/jotify/internal/migration"
+	"github.com/JrMarcco/jotify/internal/pkg/sharding"
+	"github.com/spf13/pflag"
+	"gorm.io/gorm"
+)
+
+// runMigrate 执行嵌入的版本化数据库迁移，分库分表按当前路由表展开到每个分表。
+//
+// jotifyctl migrate status
+// jotifyctl migrate up [--dry-run]
+func runMigrate(args []string) error {
+	name, args, err := subcommand(args, "status", "up")
+	if err != nil {
+		return err
+	}
+
+	fs := pflag.NewFlagSet("migrate "+name, pflag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "只输出将要执行的迁移及展开后的 sql，不实际执行")
+	timeout := fs.Duration("timeout", 10*time.Minute, "超时时间")
+	_ = fs.Parse(args)
+
+	var baseDB *gorm.DB
+	var dbs *xsync.Map[string, *gorm.DB]
+	var syncer *sharding.RoutingSyncer
+	if err = populate(&baseDB, &dbs, &syncer); err != nil {
+		return err
+	}
+
+	migrator, err := migration.NewMigrator(
+		baseDB,
+		dbs,
+		syncer.Strategy(ioc.NotifTablePrefix),
+		syncer.Strategy(ioc.CbLogTablePrefix),
+	)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	if name == "status" {
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		return printJson(status)
+	}
+
+	steps, err := migrator.Up(ctx, *dryRun)
+	if printErr := printJson(steps); printErr != nil {
+		return printErr
+	}
+	return err
+}
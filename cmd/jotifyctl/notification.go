@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/ioc"
+	"github.com/JrMarcco/jotify/internal/pkg/auth"
+	"github.com/JrMarcco/jotify/internal/pkg/client"
+	"github.com/JrMarcco/jotify/internal/pkg/sharding"
+	"github.com/JrMarcco/jotify/internal/repository"
+	"github.com/JrMarcco/jotify/internal/service/notification"
+	"github.com/spf13/pflag"
+)
+
+const defaultTimeout = 10 * time.Second
+
+func runNotification(args []string) error {
+	name, args, err := subcommand(args, "get", "cancel", "resend", "backlog")
+	if err != nil {
+		return err
+	}
+	if name == "backlog" {
+		return notificationBacklog(args)
+	}
+
+	fs := pflag.NewFlagSet("notification "+name, pflag.ExitOnError)
+	timeout := fs.Duration("timeout", defaultTimeout, "超时时间")
+	_ = fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return errors.New("usage: notification " + name + " <id>")
+	}
+	id, err := parseId(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	switch name {
+	case "get":
+		var notifRepo repository.NotificationRepo
+		var syncer *sharding.RoutingSyncer
+		if err = populate(&notifRepo, &syncer); err != nil {
+			return err
+		}
+
+		n, err := notifRepo.GetById(ctx, id)
+		if err != nil {
+			return err
+		}
+		return printJson(notificationView(n, syncer.Strategy(ioc.NotifTablePrefix).ShardWithId(n.Id)))
+	case "cancel":
+		var notifRepo repository.NotificationRepo
+		var cancelSvc notification.CancelService
+		if err = populate(&notifRepo, &cancelSvc); err != nil {
+			return err
+		}
+
+		n, err := notifRepo.GetById(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err = cancelSvc.Cancel(adminContext(ctx, n.BizId), n.BizId, n.BizKey); err != nil {
+			return err
+		}
+		return printJson(map[string]string{"id": strconv.FormatUint(id, 10), "status": domain.SendStatusCancel.String()})
+	default:
+		var resendSvc notification.ResendService
+		if err = populate(&resendSvc); err != nil {
+			return err
+		}
+
+		resp, err := resendSvc.Resend(ctx, id)
+		if err != nil {
+			return err
+		}
+		return printJson(map[string]string{"id": strconv.FormatUint(id, 10), "status": resp.Result.Status.String()})
+	}
+}
+
+// notificationBacklog 按业务方与优先级统计所有分表中处于发送时间窗口中的待发送消息数，积压多的在前
+//
+// jotifyctl notification backlog [--biz-id 1]
+func notificationBacklog(args []string) error {
+	fs := pflag.NewFlagSet("notification backlog", pflag.ExitOnError)
+	bizId := fs.Uint64("biz-id", 0, "只统计该业务方，0 表示全部")
+	timeout := fs.Duration("timeout", defaultTimeout, "超时时间")
+	_ = fs.Parse(args)
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	var notifRepo repository.NotificationRepo
+	var syncer *sharding.RoutingSyncer
+	if err := populate(&notifRepo, &syncer); err != nil {
+		return err
+	}
+
+	type key struct {
+		bizId    uint64
+		priority domain.Priority
+	}
+	counts := make(map[key]int)
+	for _, dst := range syncer.Strategy(ioc.NotifTablePrefix).BroadCast() {
+		backlogs, err := notifRepo.CountReady(sharding.ContextWitDst(ctx, dst))
+		if err != nil {
+			return err
+		}
+		for _, b := range backlogs {
+			if *bizId == 0 || b.BizId == *bizId {
+				counts[key{bizId: b.BizId, priority: b.Priority}] += b.Count
+			}
+		}
+	}
+
+	keys := slices.Collect(maps.Keys(counts))
+	slices.SortFunc(keys, func(a, b key) int {
+		return counts[b] - counts[a]
+	})
+
+	res := make([]map[string]any, 0, len(keys))
+	for _, k := range keys {
+		res = append(res, map[string]any{
+			"biz_id":   k.bizId,
+			"priority": k.priority.String(),
+			"count":    counts[k],
+		})
+	}
+	return printJson(res)
+}
+
+// runCallback 按消息 id 所在的 callback_log 分片查询回调记录，迁移期间新布局中未找到时查找旧布局
+func runCallback(args []string) error {
+	_, args, err := subcommand(args, "get")
+	if err != nil {
+		return err
+	}
+
+	fs := pflag.NewFlagSet("callback get", pflag.ExitOnError)
+	timeout := fs.Duration("timeout", defaultTimeout, "超时时间")
+	_ = fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return errors.New("usage: callback get <notification id>")
+	}
+	id, err := parseId(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	var cbLogRepo repository.CallbackLogRepo
+	if err = populate(&cbLogRepo); err != nil {
+		return err
+	}
+
+	logs, err := cbLogRepo.FindByNotificationIds(ctx, []uint64{id})
+	if err != nil {
+		return err
+	}
+
+	res := make([]map[string]any, 0, len(logs))
+	for _, log := range logs {
+		res = append(res, map[string]any{
+			"notification_id": strconv.FormatUint(log.Notification.Id, 10),
+			"status":          log.Status,
+			"retried_times":   log.RetriedTimes,
+			"next_retry_at":   time.Unix(log.NextRetryAt, 0).Format(time.RFC3339),
+		})
+	}
+	return printJson(res)
+}
+
+// adminContext 运维操作以 admin 授权范围代表业务方执行
+func adminContext(ctx context.Context, bizId uint64) context.Context {
+	return auth.WithScopes(client.WithBizId(ctx, bizId), []auth.Scope{auth.ScopeAdmin})
+}
+
+func notificationView(n domain.Notification, dst sharding.Dst) map[string]any {
+	return map[string]any{
+		"id":              strconv.FormatUint(n.Id, 10),
+		"biz_id":          n.BizId,
+		"biz_key":         n.BizKey,
+		"receivers":       n.Receivers,
+		"channel":         n.Channel,
+		"template_id":     n.Template.Id,
+		"template_params": n.Template.Params,
+		"status":          n.Status,
+		"priority":        n.Priority.String(),
+		"scheduled_start": n.ScheduledStart.Format(time.RFC3339),
+		"scheduled_end":   n.ScheduledEnd.Format(time.RFC3339),
+		"version":         n.Version,
+		"shard":           dstView(dst),
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/service/organization"
+	"github.com/spf13/pflag"
+)
+
+// runOrganization 组织管理。
+//
+// jotifyctl org create --name dept [--rate-limit 100] [--channel-conf '{...}'] [--delivery-conf '{...}']
+// jotifyctl org get --org-id 1
+// jotifyctl org join | leave --org-id 1 --biz-id 2
+// jotifyctl org topup --org-id 1 --channel sms --quota 10000
+// jotifyctl org cap --org-id 1 --biz-id 2 --channel sms --quota 2000
+// jotifyctl org allocate | reclaim --org-id 1 --biz-id 2 --channel sms --quota 1000
+// jotifyctl org usage --org-id 1 --channel sms
+//
+// 成员业务方未配置渠道、限流与投递配置时使用组织的配置，在业务方配置缓存过期后生效。
+// 配额先充值到组织配额池，再分配给成员业务方，分配后业务方配额余额不超过 cap 设置的上限。
+func runOrganization(args []string) error {
+	name, args, err := subcommand(args, "create", "get", "join", "leave", "topup", "cap", "allocate", "reclaim", "usage")
+	if err != nil {
+		return err
+	}
+
+	fs := pflag.NewFlagSet("org "+name, pflag.ExitOnError)
+	orgId := fs.Uint64("org-id", 0, "组织 id")
+	bizId := fs.Uint64("biz-id", 0, "业务 id")
+	orgName := fs.String("name", "", "组织名称")
+	rateLimit := fs.Int32("rate-limit", 0, "默认限流")
+	channelConf := fs.String("channel-conf", "", "默认渠道配置，json 格式")
+	deliveryConf := fs.String("delivery-conf", "", "默认投递配置，json 格式")
+	channel := fs.String("channel", "", "渠道")
+	quota := fs.Int64("quota", 0, "配额，cap 子命令为配额余额上限，0 表示不限")
+	timeout := fs.Duration("timeout", defaultTimeout, "超时时间")
+	_ = fs.Parse(args)
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	var orgSvc organization.Service
+	if err = populate(&orgSvc); err != nil {
+		return err
+	}
+
+	if name == "create" {
+		o := domain.Organization{Name: *orgName, RateLimit: *rateLimit}
+		if *channelConf != "" {
+			if err = json.Unmarshal([]byte(*channelConf), &o.ChannelConf); err != nil {
+				return err
+			}
+		}
+		if *deliveryConf != "" {
+			if err = json.Unmarshal([]byte(*deliveryConf), &o.DeliveryConf); err != nil {
+				return err
+			}
+		}
+		if o, err = orgSvc.Create(ctx, o); err != nil {
+			return err
+		}
+		return printJson(o)
+	}
+
+	if *orgId == 0 {
+		return errors.New("usage: org " + name + " --org-id <id>")
+	}
+	ch := domain.Channel(*channel)
+
+	switch name {
+	case "get":
+		o, err := orgSvc.Get(ctx, *orgId)
+		if err != nil {
+			return err
+		}
+		return printJson(o)
+	case "topup":
+		pool, err := orgSvc.TopUp(ctx, *orgId, ch, *quota)
+		if err != nil {
+			return err
+		}
+		return printJson(map[string]any{"org_id": *orgId, "channel": ch, "pool": pool})
+	case "usage":
+		usage, err := orgSvc.Usage(ctx, *orgId, ch)
+		if err != nil {
+			return err
+		}
+		return printJson(usage)
+	}
+
+	if *bizId == 0 {
+		return errors.New("usage: org " + name + " --org-id <id> --biz-id <id>")
+	}
+
+	switch name {
+	case "join":
+		err = orgSvc.Join(ctx, *orgId, *bizId)
+	case "leave":
+		err = orgSvc.Leave(ctx, *orgId, *bizId)
+	case "cap":
+		err = orgSvc.SetCap(ctx, *orgId, *bizId, ch, *quota)
+	case "allocate":
+		err = orgSvc.Allocate(ctx, *orgId, *bizId, ch, *quota)
+	default:
+		err = orgSvc.Reclaim(ctx, *orgId, *bizId, ch, *quota)
+	}
+	if err != nil {
+		return err
+	}
+	return printJson(map[string]any{"org_id": *orgId, "biz_id": *bizId, name: true})
+}
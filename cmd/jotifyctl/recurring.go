@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/JrMarcco/jotify/internal/service/notification"
+	"github.com/spf13/pflag"
+)
+
+// runRecurring 周期发送计划管理。
+//
+// jotifyctl recurring list --biz-id 1 [--offset 0] [--limit 100]
+// jotifyctl recurring pause | resume | delete --biz-id 1 <biz_key>
+//
+// 暂停、恢复与删除只影响之后的触发，已创建的消息通过 notification cancel 取消。
+func runRecurring(args []string) error {
+	name, args, err := subcommand(args, "list", "pause", "resume", "delete")
+	if err != nil {
+		return err
+	}
+
+	fs := pflag.NewFlagSet("recurring "+name, pflag.ExitOnError)
+	bizId := fs.Uint64("biz-id", 0, "业务 id")
+	offset := fs.Int("offset", 0, "分页偏移")
+	limit := fs.Int("limit", 100, "分页大小")
+	timeout := fs.Duration("timeout", defaultTimeout, "超时时间")
+	_ = fs.Parse(args)
+
+	if *bizId == 0 {
+		return errors.New("usage: recurring " + name + " --biz-id <id>")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	ctx = adminContext(ctx, *bizId)
+
+	var recurringSvc notification.RecurringService
+	if err = populate(&recurringSvc); err != nil {
+		return err
+	}
+
+	if name == "list" {
+		schedules, err := recurringSvc.List(ctx, *bizId, *offset, *limit)
+		if err != nil {
+			return err
+		}
+		return printJson(schedules)
+	}
+
+	if fs.NArg() != 1 {
+		return errors.New("usage: recurring " + name + " --biz-id <id> <biz_key>")
+	}
+	bizKey := fs.Arg(0)
+
+	switch name {
+	case "pause":
+		err = recurringSvc.Pause(ctx, *bizId, bizKey)
+	case "resume":
+		err = recurringSvc.Resume(ctx, *bizId, bizKey)
+	default:
+		err = recurringSvc.Delete(ctx, *bizId, bizKey)
+	}
+	if err != nil {
+		return err
+	}
+	return printJson(map[string]any{"biz_id": *bizId, "biz_key": bizKey, name: true})
+}
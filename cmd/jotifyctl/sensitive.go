@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/JrMarcco/jotify/internal/pkg/sensitive"
+	"github.com/spf13/pflag"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// runSensitive 管理 etcd 中的敏感词，各实例监听变更后自动重新加载。
+//
+// jotifyctl sensitive add | remove <word>...
+// jotifyctl sensitive list
+// jotifyctl sensitive check <text>
+//
+// check 使用 etcd 中当前的敏感词检查文本，输出命中的敏感词与替换后的文本。
+func runSensitive(args []string) error {
+	name, args, err := subcommand(args, "add", "remove", "list", "check")
+	if err != nil {
+		return err
+	}
+
+	fs := pflag.NewFlagSet("sensitive "+name, pflag.ExitOnError)
+	timeout := fs.Duration("timeout", defaultTimeout, "超时时间")
+	_ = fs.Parse(args)
+
+	var syncer *sensitive.EtcdSyncer
+	var etcdClient *clientv3.Client
+	if err = populate(&syncer, &etcdClient); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	switch name {
+	case "list":
+		resp, err := etcdClient.Get(ctx, syncer.Prefix(), clientv3.WithPrefix(), clientv3.WithKeysOnly())
+		if err != nil {
+			return err
+		}
+		words := make([]string, 0, len(resp.Kvs))
+		for _, kv := range resp.Kvs {
+			words = append(words, strings.TrimPrefix(string(kv.Key), syncer.Prefix()))
+		}
+		return printJson(words)
+	case "check":
+		if fs.NArg() != 1 {
+			return errors.New("usage: sensitive check <text>")
+		}
+		if err = syncer.Load(ctx); err != nil {
+			return err
+		}
+		masked, words := syncer.Filter().Matcher().Mask(fs.Arg(0), '*')
+		return printJson(map[string]any{"words": words, "masked": masked})
+	}
+
+	if fs.NArg() == 0 {
+		return errors.New("usage: sensitive " + name + " <word>...")
+	}
+	for _, word := range fs.Args() {
+		if word = strings.TrimSpace(word); word == "" {
+			continue
+		}
+		if name == "add" {
+			_, err = etcdClient.Put(ctx, syncer.EtcdKeyOfWord(word), "")
+		} else {
+			_, err = etcdClient.Delete(ctx, syncer.EtcdKeyOfWord(word))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return printJson(map[string]any{name: fs.Args()})
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/JrMarcco/easy-kit/xsync"
+	"github.com/JrMarcco/jotify/internal/ioc"
+	"github.com/JrMarcco/jotify/internal/pkg/sharding"
+	"github.com/JrMarcco/jotify/internal/repository/dao"
+	"github.com/spf13/pflag"
+	"gorm.io/gorm"
+)
+
+// runShard 在线扩容 notification 与 callback_log 分库分表。
+//
+// 扩容流程：
+//  1. 在新库中建好 notification_* 与 callback_log_* 表及其归档表，并将新库加入所有实例的 db.sharding 配置
+//  2. shard begin 发布新布局，新消息写入新布局，读取时新布局未命中再读旧布局
+//  3. 等待所有实例加载新路由表后执行 shard backfill，将旧布局中的记录（包括归档记录）迁移到新布局
+//  4. shard cutover 确认旧布局中没有需要迁移的记录后移除旧布局
+func runShard(args []string) error {
+	name, args, err := subcommand(args, "show", "begin", "backfill", "cutover")
+	if err != nil {
+		return err
+	}
+
+	switch name {
+	case "show":
+		var syncer *sharding.RoutingSyncer
+		if err = populate(&syncer); err != nil {
+			return err
+		}
+		return printJson(syncer.RoutingTable())
+	case "begin":
+		return beginResharding(args)
+	case "backfill":
+		return backfillResharding(args, false)
+	default:
+		return backfillResharding(args, true)
+	}
+}
+
+// beginResharding 开始迁移，发布新布局
+//
+// jotifyctl shard begin --db-sharding 4 --table-sharding 4
+func beginResharding(args []string) error {
+	fs := pflag.NewFlagSet("shard begin", pflag.ExitOnError)
+	dbSharding := fs.Uint64("db-sharding", 0, "新布局分库数")
+	tableSharding := fs.Uint64("table-sharding", 0, "新布局每个库的分表数")
+	timeout := fs.Duration("timeout", defaultTimeout, "超时时间")
+	_ = fs.Parse(args)
+
+	var syncer *sharding.RoutingSyncer
+	var dbs *xsync.Map[string, *gorm.DB]
+	if err := populate(&syncer, &dbs); err != nil {
+		return err
+	}
+
+	rt := syncer.RoutingTable()
+	if rt.Migrating() {
+		return fmt.Errorf("resharding to version %d is in progress", rt.Current.Version)
+	}
+
+	current := rt.Current
+	next := sharding.Layout{
+		Version:       current.Version + 1,
+		DBSharding:    *dbSharding,
+		TableSharding: *tableSharding,
+	}
+	if next.DBSharding == current.DBSharding && next.TableSharding == current.TableSharding {
+		return errors.New("new layout is the same as current")
+	}
+
+	// 新布局中的库必须已经配置，否则写入新布局会失败
+	for i := uint64(0); i < next.DBSharding; i++ {
+		dbName := fmt.Sprintf("%s_%d", ioc.ShardingDBPrefix, i)
+		if _, ok := dbs.Load(dbName); !ok {
+			return fmt.Errorf("db %s is not configured in db.sharding", dbName)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	if err := syncer.Publish(ctx, sharding.RoutingTable{Current: next, Previous: &current}); err != nil {
+		return err
+	}
+	return printJson(syncer.RoutingTable())
+}
+
+// backfillResharding 回填旧布局中的记录，cutover 为 true 时回填完成后移除旧布局。
+//
+// cutover 时回填过程中仍迁移了记录，说明还有实例在向旧布局写入（未加载新路由表），此时拒绝切换，需要稍后重试。
+//
+// jotifyctl shard backfill --batch-size 500
+// jotifyctl shard cutover
+func backfillResharding(args []string, cutover bool) error {
+	name := "shard backfill"
+	if cutover {
+		name = "shard cutover"
+	}
+
+	fs := pflag.NewFlagSet(name, pflag.ExitOnError)
+	batchSize := fs.Int("batch-size", 500, "每批扫描的记录数")
+	timeout := fs.Duration("timeout", time.Hour, "超时时间")
+	_ = fs.Parse(args)
+
+	var syncer *sharding.RoutingSyncer
+	var dbs *xsync.Map[string, *gorm.DB]
+	if err := populate(&syncer, &dbs); err != nil {
+		return err
+	}
+
+	rt := syncer.RoutingTable()
+	if !rt.Migrating() {
+		return errors.New("routing table is not migrating")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	reshardingDAO := dao.NewReshardingDAO(
+		dbs,
+		syncer.Strategy(ioc.NotifTablePrefix),
+		syncer.Strategy(ioc.CbLogTablePrefix),
+	)
+	results, err := reshardingDAO.Backfill(ctx, *batchSize)
+	if err != nil {
+		_ = printJson(results)
+		return err
+	}
+
+	if !cutover {
+		return printJson(results)
+	}
+
+	for _, res := range results {
+		if res.Moved > 0 {
+			_ = printJson(results)
+			return errors.New("records were still moved during cutover, make sure all instances have loaded the new routing table and retry")
+		}
+	}
+
+	if err = syncer.Publish(ctx, sharding.RoutingTable{Current: rt.Current}); err != nil {
+		return err
+	}
+	return printJson(syncer.RoutingTable())
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/service/notification"
+	"github.com/spf13/pflag"
+)
+
+// runSuppression 退订名单管理。
+//
+// jotifyctl suppression import --file list.csv [--biz-id 1] [--channel sms] [--reason manual]
+// jotifyctl suppression remove --receiver 13800000000 --channel sms [--biz-id 1]
+// jotifyctl suppression list [--receiver 13800000000] [--biz-id 1] [--offset 0] [--limit 100]
+//
+// 未指定 --biz-id 时操作全局名单。导入文件为 csv，每行格式为 receiver[,channel[,reason[,expire_at]]]，
+// 省略的字段使用命令行参数，expire_at 为毫秒时间戳。
+func runSuppression(args []string) error {
+	name, args, err := subcommand(args, "import", "remove", "list")
+	if err != nil {
+		return err
+	}
+
+	fs := pflag.NewFlagSet("suppression "+name, pflag.ExitOnError)
+	bizId := fs.Uint64("biz-id", 0, "业务 id，0 表示全局名单")
+	file := fs.String("file", "", "导入的 csv 文件，- 表示标准输入")
+	receiver := fs.String("receiver", "", "接收者（手机号、邮箱等）")
+	channel := fs.String("channel", domain.ChannelSMS.String(), "渠道")
+	reason := fs.String("reason", domain.SuppressionReasonManual.String(), "原因")
+	offset := fs.Int("offset", 0, "分页偏移")
+	limit := fs.Int("limit", 100, "分页大小")
+	batchSize := fs.Int("batch-size", 1000, "每批导入的记录数")
+	timeout := fs.Duration("timeout", defaultTimeout, "超时时间")
+	_ = fs.Parse(args)
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	ctx = adminContext(ctx, *bizId)
+
+	var suppressionSvc notification.SuppressionService
+	if err = populate(&suppressionSvc); err != nil {
+		return err
+	}
+
+	switch name {
+	case "import":
+		ss, err := readSuppressions(*file, domain.Channel(*channel), domain.SuppressionReason(*reason))
+		if err != nil {
+			return err
+		}
+		for start := 0; start < len(ss); start += *batchSize {
+			end := min(start+*batchSize, len(ss))
+			if err = suppressionSvc.Import(ctx, *bizId, ss[start:end]); err != nil {
+				return fmt.Errorf("failed to import lines %d-%d: %w", start+1, end, err)
+			}
+		}
+		return printJson(map[string]any{"biz_id": *bizId, "imported": len(ss)})
+	case "remove":
+		if err = suppressionSvc.Remove(ctx, *bizId, domain.Channel(*channel), *receiver); err != nil {
+			return err
+		}
+		return printJson(map[string]any{"biz_id": *bizId, "removed": true})
+	default:
+		ss, err := suppressionSvc.List(ctx, *bizId, *receiver, *offset, *limit)
+		if err != nil {
+			return err
+		}
+		return printJson(ss)
+	}
+}
+
+// readSuppressions 读取 csv 格式的退订名单
+func readSuppressions(file string, channel domain.Channel, reason domain.SuppressionReason) ([]domain.Suppression, error) {
+	if file == "" {
+		return nil, errors.New("usage: suppression import --file <csv>")
+	}
+
+	var r io.Reader = os.Stdin
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = f.Close() }()
+		r = f
+	}
+
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	ss := make([]domain.Suppression, 0, len(records))
+	for i, record := range records {
+		s := domain.Suppression{
+			Receiver: strings.TrimSpace(record[0]),
+			Channel:  channel,
+			Reason:   reason,
+		}
+		if len(record) > 1 && strings.TrimSpace(record[1]) != "" {
+			s.Channel = domain.Channel(strings.TrimSpace(record[1]))
+		}
+		if len(record) > 2 && strings.TrimSpace(record[2]) != "" {
+			s.Reason = domain.SuppressionReason(strings.TrimSpace(record[2]))
+		}
+		if len(record) > 3 && strings.TrimSpace(record[3]) != "" {
+			if s.ExpireAt, err = strconv.ParseInt(strings.TrimSpace(record[3]), 10, 64); err != nil {
+				return nil, fmt.Errorf("invalid expire_at at line %d: %w", i+1, err)
+			}
+		}
+		if s.Receiver == "" {
+			continue
+		}
+		ss = append(ss, s)
+	}
+	return ss, nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/service/audit"
+	"github.com/spf13/pflag"
+)
+
+// runTemplate 模板审核。
+//
+// jotifyctl template submit --locale en [--signature "..."] --content "..." <version_id>
+// jotifyctl template audit [--variant] <version_id | variant_id>
+// jotifyctl template queue [--variant] [--start-id 0] [--limit 100]
+// jotifyctl template approve | reject [--variant] --auditor-id 1 [--reason "..."] <version_id | variant_id>
+// jotifyctl template history <version_id>
+//
+// submit 提交版本的语言变体，已有该语言的变体时替换并重新审核；--variant 时操作语言变体。
+// audit 立即执行自动审核，不等待自动审核任务；拒绝时必须填写原因。
+func runTemplate(args []string) error {
+	name, args, err := subcommand(args, "submit", "audit", "queue", "approve", "reject", "history")
+	if err != nil {
+		return err
+	}
+
+	fs := pflag.NewFlagSet("template "+name, pflag.ExitOnError)
+	startId := fs.Uint64("start-id", 0, "上一页最后一个版本的 id")
+	limit := fs.Int("limit", 100, "分页大小")
+	auditorId := fs.Uint64("auditor-id", 0, "审核人 id")
+	reason := fs.String("reason", "", "审核原因")
+	variant := fs.Bool("variant", false, "操作语言变体")
+	locale := fs.String("locale", "", "语言变体的语言，例如 zh-HK")
+	signature := fs.String("signature", "", "语言变体的签名，为空时使用版本的签名")
+	content := fs.String("content", "", "语言变体的内容")
+	timeout := fs.Duration("timeout", defaultTimeout, "超时时间")
+	_ = fs.Parse(args)
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	var auditSvc audit.Service
+	if err = populate(&auditSvc); err != nil {
+		return err
+	}
+
+	if name == "queue" {
+		if *variant {
+			variants, err := auditSvc.VariantQueue(ctx, *startId, *limit)
+			if err != nil {
+				return err
+			}
+			return printJson(variants)
+		}
+		versions, err := auditSvc.Queue(ctx, *startId, *limit)
+		if err != nil {
+			return err
+		}
+		return printJson(versions)
+	}
+
+	if fs.NArg() != 1 {
+		return errors.New("usage: template " + name + " <version_id | variant_id>")
+	}
+	id, err := strconv.ParseUint(fs.Arg(0), 10, 64)
+	if err != nil {
+		return err
+	}
+
+	switch name {
+	case "submit":
+		v, err := auditSvc.SubmitVariant(ctx, domain.ChannelTplVariant{
+			TplVersionId: id,
+			Locale:       *locale,
+			Signature:    *signature,
+			Content:      *content,
+		})
+		if err != nil {
+			return err
+		}
+		return printJson(v)
+	case "audit":
+		run := auditSvc.Audit
+		if *variant {
+			run = auditSvc.AuditVariant
+		}
+		a, err := run(ctx, id)
+		if err != nil {
+			return err
+		}
+		return printJson(a)
+	case "history":
+		audits, err := auditSvc.History(ctx, id)
+		if err != nil {
+			return err
+		}
+		return printJson(audits)
+	}
+
+	if *auditorId == 0 {
+		return errors.New("usage: template " + name + " --auditor-id <id> <version_id | variant_id>")
+	}
+	review := auditSvc.Review
+	if *variant {
+		review = auditSvc.ReviewVariant
+	}
+	a, err := review(ctx, id, *auditorId, name == "approve", *reason)
+	if err != nil {
+		return err
+	}
+	return printJson(a)
+}
@@ -15,6 +15,8 @@ func main() {
 	fx.New(
 		// 初始化 zap.Logger
 		ioc.LoggerFxOpt,
+		// 初始化链路追踪
+		ioc.TraceFxOpt,
 
 		// 初始化雪花算法 id 生成器
 		ioc.IdFxOpt,
@@ -42,13 +44,26 @@ func main() {
 
 		// 初始化 ioc.App
 		ioc.AppFxOpt,
+		// 初始化 HTTP/JSON 网关
+		ioc.GatewayFxOpt,
+
+		// 初始化监控指标服务
+		ioc.MetricsFxOpt,
 
 		fx.WithLogger(func(logger *zap.Logger) fxevent.Logger {
 			return &fxevent.ZapLogger{Logger: logger}
 		}),
 
+		// 设置全局 TracerProvider，需要在 grpc.Server 等组件初始化之前调用
+		ioc.TraceFxInvoke,
 		// 实际运行方法，即调用 ioc.AppLifecycle 方法
 		ioc.AppFxInvoke,
+		// 启动 HTTP/JSON 网关
+		ioc.GatewayFxInvoke,
+		// 启动归档任务
+		ioc.SchedulerFxInvoke,
+		// 注册监控指标并启动 /metrics 服务
+		ioc.MetricsFxInvoke,
 		// 确保日志缓冲区被刷新
 		ioc.LoggerFxInvoke,
 	).Run()
@@ -0,0 +1,404 @@
+package gateway
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	notificationv1 "github.com/JrMarcco/jotify-api/api/notification/v1"
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/errs"
+	"github.com/JrMarcco/jotify/internal/pkg/client"
+)
+
+// sendResultResp 发送结果，id 使用字符串避免 js 客户端精度丢失
+type sendResultResp struct {
+	NotificationId uint64                   `json:"notification_id,string"`
+	Status         string                   `json:"status"`
+	Suppressed     []suppressedReceiverResp `json:"suppressed,omitempty"`
+	ScheduleId     uint64                   `json:"schedule_id,omitempty,string"`
+}
+
+// suppressedReceiverResp 因在退订名单中被移除的接收者
+type suppressedReceiverResp struct {
+	BizKey   string `json:"biz_key"`
+	Receiver string `json:"receiver"`
+	Reason   string `json:"reason"`
+}
+
+type batchSendResp struct {
+	Results []sendResultResp `json:"results"`
+}
+
+type batchAsyncSendResp struct {
+	NotificationIds []string                 `json:"notification_ids"`
+	Suppressed      []suppressedReceiverResp `json:"suppressed,omitempty"`
+}
+
+type notificationResp struct {
+	Id             uint64            `json:"id,string"`
+	BizKey         string            `json:"biz_key"`
+	Receivers      []string          `json:"receivers"`
+	Channel        string            `json:"channel"`
+	TplId          uint64            `json:"tpl_id,string"`
+	TplParams      map[string]string `json:"tpl_params"`
+	Locale         string            `json:"locale"` // 实际使用的模板语言变体，空表示默认内容
+	Status         string            `json:"status"`
+	Priority       string            `json:"priority"`
+	ScheduledStart int64             `json:"scheduled_start"` // 毫秒时间戳
+	ScheduledEnd   int64             `json:"scheduled_end"`   // 毫秒时间戳
+}
+
+type batchQueryResp struct {
+	Notifications []notificationResp `json:"notifications"`
+}
+
+// erasureReceiptResp 擦除回执，id 使用字符串避免 js 客户端精度丢失
+type erasureReceiptResp struct {
+	Id              uint64   `json:"id,string"`
+	ReceiverHash    string   `json:"receiver_hash"`
+	NotificationIds []string `json:"notification_ids"`
+	CreatedAt       int64    `json:"created_at"` // 毫秒时间戳
+}
+
+type receiverLocaleReq struct {
+	Receiver string `json:"receiver"`
+	Locale   string `json:"locale"`
+}
+
+func (r receiverLocaleReq) toDomain() domain.ReceiverLocale {
+	return domain.ReceiverLocale{
+		Receiver: r.Receiver,
+		Locale:   r.Locale,
+	}
+}
+
+type suppressionReq struct {
+	Receiver string `json:"receiver"`
+	Channel  string `json:"channel"`
+	Reason   string `json:"reason"`
+	ExpireAt int64  `json:"expire_at"` // 毫秒时间戳，0 表示永久
+}
+
+func (r suppressionReq) toDomain() domain.Suppression {
+	return domain.Suppression{
+		Receiver: r.Receiver,
+		Channel:  domain.Channel(r.Channel),
+		Reason:   domain.SuppressionReason(r.Reason),
+		ExpireAt: r.ExpireAt,
+	}
+}
+
+// suppressionResp 退订名单记录，只返回接收者哈希
+type suppressionResp struct {
+	Id           uint64 `json:"id,string"`
+	ReceiverHash string `json:"receiver_hash"`
+	Channel      string `json:"channel"`
+	Reason       string `json:"reason"`
+	ExpireAt     int64  `json:"expire_at"`  // 毫秒时间戳，0 表示永久
+	CreatedAt    int64  `json:"created_at"` // 毫秒时间戳
+	UpdatedAt    int64  `json:"updated_at"` // 毫秒时间戳
+}
+
+type listSuppressionsResp struct {
+	Suppressions []suppressionResp `json:"suppressions"`
+}
+
+// recurringScheduleResp 周期发送计划，时间均为毫秒时间戳，0 表示不限或已结束
+type recurringScheduleResp struct {
+	Id             uint64            `json:"id,string"`
+	BizKey         string            `json:"biz_key"`
+	Receivers      []string          `json:"receivers"`
+	Channel        string            `json:"channel"`
+	TplId          uint64            `json:"tpl_id,string"`
+	TplParams      map[string]string `json:"tpl_params"`
+	Priority       string            `json:"priority"`
+	Cron           string            `json:"cron"`
+	Timezone       string            `json:"timezone"`
+	EndAt          int64             `json:"end_at"`
+	MaxOccurrences int32             `json:"max_occurrences"`
+	Occurrences    int32             `json:"occurrences"`
+	NextRunAt      int64             `json:"next_run_at"`
+	Status         string            `json:"status"`
+	CreatedAt      int64             `json:"created_at"`
+	UpdatedAt      int64             `json:"updated_at"`
+}
+
+type listRecurringSchedulesResp struct {
+	Schedules []recurringScheduleResp `json:"schedules"`
+}
+
+// campaignResp 群发活动，时间均为毫秒时间戳
+type campaignResp struct {
+	Id        uint64                  `json:"id,string"`
+	BizKey    string                  `json:"biz_key"`
+	Channel   string                  `json:"channel"`
+	TplId     uint64                  `json:"tpl_id,string"`
+	TplParams map[string]string       `json:"tpl_params"`
+	Priority  string                  `json:"priority"`
+	Strategy  string                  `json:"strategy"`
+	Pacing    domain.PacingConf       `json:"pacing"`
+	Status    string                  `json:"status"`
+	Progress  domain.CampaignProgress `json:"progress"`
+	CreatedAt int64                   `json:"created_at"`
+	UpdatedAt int64                   `json:"updated_at"`
+}
+
+type listCampaignsResp struct {
+	Campaigns []campaignResp `json:"campaigns"`
+}
+
+// tplVersionResp 模板版本，时间均为毫秒时间戳
+type tplVersionResp struct {
+	Id           uint64 `json:"id,string"`
+	TplId        uint64 `json:"tpl_id,string"`
+	Name         string `json:"name"`
+	Signature    string `json:"signature"`
+	Content      string `json:"content"`
+	Remark       string `json:"remark"`
+	AuditStatus  string `json:"audit_status"`
+	RejectReason string `json:"reject_reason"`
+	CreatedAt    int64  `json:"created_at"`
+	UpdatedAt    int64  `json:"updated_at"`
+}
+
+type listTplReviewsResp struct {
+	Versions []tplVersionResp `json:"versions"`
+}
+
+// tplVariantResp 模板版本语言变体
+type tplVariantResp struct {
+	Id           uint64 `json:"id,string"`
+	TplId        uint64 `json:"tpl_id,string"`
+	TplVersionId uint64 `json:"tpl_version_id,string"`
+	Locale       string `json:"locale"`
+	Signature    string `json:"signature"`
+	Content      string `json:"content"`
+	AuditStatus  string `json:"audit_status"`
+	RejectReason string `json:"reject_reason"`
+	CreatedAt    int64  `json:"created_at"`
+	UpdatedAt    int64  `json:"updated_at"`
+}
+
+type listTplVariantReviewsResp struct {
+	Variants []tplVariantResp `json:"variants"`
+}
+
+// tplAuditResp 模板版本审核记录，审核语言变体时 tpl_variant_id 为变体 id
+type tplAuditResp struct {
+	Id           uint64   `json:"id,string"`
+	TplId        uint64   `json:"tpl_id,string"`
+	TplVersionId uint64   `json:"tpl_version_id,string"`
+	TplVariantId uint64   `json:"tpl_variant_id,string"`
+	Stage        string   `json:"stage"`
+	Status       string   `json:"status"`
+	Reasons      []string `json:"reasons"`
+	AuditorId    uint64   `json:"auditor_id,string"`
+	CreatedAt    int64    `json:"created_at"`
+}
+
+type listTplAuditsResp struct {
+	Audits []tplAuditResp `json:"audits"`
+}
+
+type errorResp struct {
+	Message string `json:"message"`
+}
+
+// toDomainNotification 转换为领域对象，业务 id 取自 jwt token，优先级、匀速发送与语言取自请求头
+func toDomainNotification(r *http.Request, pn *notificationv1.Notification) (domain.Notification, error) {
+	n, err := domain.NotificationFromApi(pn)
+	if err != nil {
+		return domain.Notification{}, err
+	}
+
+	if val := r.Header.Get(priorityHeader); val != "" {
+		if n.Priority, err = domain.ParsePriority(val); err != nil {
+			return domain.Notification{}, err
+		}
+	}
+	if val := r.Header.Get(pacingHeader); val != "" {
+		if n.StrategyConfig.Pacing, err = parsePacing(val); err != nil {
+			return domain.Notification{}, err
+		}
+	}
+	if val := r.Header.Get(localeHeader); val != "" {
+		if n.Locale, err = domain.ParseLocale(val); err != nil {
+			return domain.Notification{}, err
+		}
+	}
+
+	n.BizId, _ = client.BizIdFromContext(r.Context())
+	return n, nil
+}
+
+// parsePacing 解析匀速发送请求头，格式为 even 或 curve:<权重,...>
+func parsePacing(val string) (domain.PacingConf, error) {
+	mode, curve, _ := strings.Cut(val, ":")
+	conf := domain.PacingConf{Mode: domain.PacingMode(strings.TrimSpace(mode))}
+	if conf.Mode != domain.PacingModeCurve {
+		return conf, nil
+	}
+
+	for _, str := range strings.Split(curve, ",") {
+		w, err := strconv.Atoi(strings.TrimSpace(str))
+		if err != nil {
+			return domain.PacingConf{}, fmt.Errorf("%w: invalid pacing curve weight %q", errs.ErrInvalidParam, str)
+		}
+		conf.Curve = append(conf.Curve, w)
+	}
+	return conf, nil
+}
+
+func toSendResultResp(res domain.SendResult) sendResultResp {
+	return sendResultResp{
+		NotificationId: res.NotificationId,
+		Status:         res.Status.String(),
+		Suppressed:     toSuppressedReceiverResps(res.Suppressed),
+		ScheduleId:     res.ScheduleId,
+	}
+}
+
+func toSuppressedReceiverResps(srs []domain.SuppressedReceiver) []suppressedReceiverResp {
+	if len(srs) == 0 {
+		return nil
+	}
+	res := make([]suppressedReceiverResp, 0, len(srs))
+	for _, sr := range srs {
+		res = append(res, suppressedReceiverResp{
+			BizKey:   sr.BizKey,
+			Receiver: sr.Receiver,
+			Reason:   sr.Reason.String(),
+		})
+	}
+	return res
+}
+
+func toSuppressionResp(s domain.Suppression) suppressionResp {
+	return suppressionResp{
+		Id:           s.Id,
+		ReceiverHash: s.ReceiverHash,
+		Channel:      s.Channel.String(),
+		Reason:       s.Reason.String(),
+		ExpireAt:     s.ExpireAt,
+		CreatedAt:    s.CreatedAt,
+		UpdatedAt:    s.UpdatedAt,
+	}
+}
+
+func toNotificationResp(n domain.Notification) notificationResp {
+	return notificationResp{
+		Id:             n.Id,
+		BizKey:         n.BizKey,
+		Receivers:      n.Receivers,
+		Channel:        n.Channel.String(),
+		TplId:          n.Template.Id,
+		TplParams:      n.Template.Params,
+		Locale:         n.Locale,
+		Status:         n.Status.String(),
+		Priority:       n.Priority.String(),
+		ScheduledStart: n.ScheduledStart.UnixMilli(),
+		ScheduledEnd:   n.ScheduledEnd.UnixMilli(),
+	}
+}
+
+func toRecurringScheduleResp(s domain.RecurringSchedule) recurringScheduleResp {
+	res := recurringScheduleResp{
+		Id:             s.Id,
+		BizKey:         s.BizKey,
+		Receivers:      s.Receivers,
+		Channel:        s.Channel.String(),
+		TplId:          s.Template.Id,
+		TplParams:      s.Template.Params,
+		Priority:       s.Priority.String(),
+		Cron:           s.Cron,
+		Timezone:       s.Timezone,
+		MaxOccurrences: s.MaxOccurrences,
+		Occurrences:    s.Occurrences,
+		Status:         s.Status.String(),
+		CreatedAt:      s.CreatedAt,
+		UpdatedAt:      s.UpdatedAt,
+	}
+	if !s.EndAt.IsZero() {
+		res.EndAt = s.EndAt.UnixMilli()
+	}
+	if !s.NextRunAt.IsZero() {
+		res.NextRunAt = s.NextRunAt.UnixMilli()
+	}
+	return res
+}
+
+func toCampaignResp(c domain.Campaign) campaignResp {
+	return campaignResp{
+		Id:        c.Id,
+		BizKey:    c.BizKey,
+		Channel:   c.Channel.String(),
+		TplId:     c.Template.Id,
+		TplParams: c.Template.Params,
+		Priority:  c.Priority.String(),
+		Strategy:  string(c.StrategyConfig.Type),
+		Pacing:    c.StrategyConfig.Pacing,
+		Status:    c.Status.String(),
+		Progress:  c.Progress,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+	}
+}
+
+func toTplVersionResp(v domain.ChannelTplVersion) tplVersionResp {
+	return tplVersionResp{
+		Id:           v.Id,
+		TplId:        v.ChannelTplId,
+		Name:         v.Name,
+		Signature:    v.Signature,
+		Content:      v.Content,
+		Remark:       v.Remark,
+		AuditStatus:  v.AuditStatus.String(),
+		RejectReason: v.RejectReason,
+		CreatedAt:    v.CreateAt,
+		UpdatedAt:    v.UpdateAt,
+	}
+}
+
+func toTplVariantResp(v domain.ChannelTplVariant) tplVariantResp {
+	return tplVariantResp{
+		Id:           v.Id,
+		TplId:        v.TplId,
+		TplVersionId: v.TplVersionId,
+		Locale:       v.Locale,
+		Signature:    v.Signature,
+		Content:      v.Content,
+		AuditStatus:  v.AuditStatus.String(),
+		RejectReason: v.RejectReason,
+		CreatedAt:    v.CreateAt,
+		UpdatedAt:    v.UpdateAt,
+	}
+}
+
+func toTplAuditResp(a domain.TplAudit) tplAuditResp {
+	return tplAuditResp{
+		Id:           a.Id,
+		TplId:        a.TplId,
+		TplVersionId: a.TplVersionId,
+		TplVariantId: a.TplVariantId,
+		Stage:        a.Stage.String(),
+		Status:       a.Status.String(),
+		Reasons:      a.Reasons,
+		AuditorId:    a.AuditorId,
+		CreatedAt:    a.CreatedAt,
+	}
+}
+
+func toErasureReceiptResp(receipt domain.ErasureReceipt) erasureReceiptResp {
+	ids := make([]string, 0, len(receipt.NotificationIds))
+	for _, id := range receipt.NotificationIds {
+		ids = append(ids, strconv.FormatUint(id, 10))
+	}
+	return erasureReceiptResp{
+		Id:              receipt.Id,
+		ReceiverHash:    receipt.ReceiverHash,
+		NotificationIds: ids,
+		CreatedAt:       receipt.CreatedAt,
+	}
+}
@@ -0,0 +1,67 @@
+package gateway
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/JrMarcco/jotify/internal/errs"
+)
+
+// errStatuses 错误与 http 状态码的映射，按顺序匹配。
+//
+// 发送失败的错误通常包装了具体原因，所以具体原因放在前面优先匹配。
+var errStatuses = []struct {
+	err  error
+	code int
+}{
+	{errs.ErrInvalidParam, http.StatusBadRequest},
+	{errs.ErrInvalidChannel, http.StatusBadRequest},
+	{errs.ErrInvalidSendStrategy, http.StatusBadRequest},
+
+	{errs.ErrPermissionDenied, http.StatusForbidden},
+
+	{errs.ErrBizIdNotFound, http.StatusNotFound},
+	{errs.ErrBizConfNotFound, http.StatusNotFound},
+	{errs.ErrChannelTplNotFound, http.StatusNotFound},
+	{errs.ErrChannelTplVersionNotFound, http.StatusNotFound},
+	{errs.ErrChannelTplVariantNotFound, http.StatusNotFound},
+	{errs.ErrNotificationNotFound, http.StatusNotFound},
+	{errs.ErrErasureReceiptNotFound, http.StatusNotFound},
+	{errs.ErrRecurringScheduleNotFound, http.StatusNotFound},
+	{errs.ErrCampaignNotFound, http.StatusNotFound},
+	{errs.ErrOrganizationNotFound, http.StatusNotFound},
+
+	{errs.ErrDuplicateNotificationId, http.StatusConflict},
+	{errs.ErrNotificationVersionConflict, http.StatusConflict},
+	{errs.ErrNotificationNotCancelable, http.StatusConflict},
+	{errs.ErrNotificationNotResendable, http.StatusConflict},
+	{errs.ErrDuplicateRecurringSchedule, http.StatusConflict},
+	{errs.ErrRecurringScheduleConflict, http.StatusConflict},
+	{errs.ErrDuplicateCampaign, http.StatusConflict},
+	{errs.ErrCampaignConflict, http.StatusConflict},
+	{errs.ErrCampaignVersionConflict, http.StatusConflict},
+	{errs.ErrTplAuditConflict, http.StatusConflict},
+	{errs.ErrDuplicateOrganization, http.StatusConflict},
+	{errs.ErrNotOrgMember, http.StatusConflict},
+
+	{errs.ErrNotApprovedTplVersion, http.StatusUnprocessableEntity},
+	{errs.ErrSensitiveContent, http.StatusUnprocessableEntity},
+	{errs.ErrInsufficientQuota, http.StatusTooManyRequests},
+	{errs.ErrOrgQuotaCapExceeded, http.StatusTooManyRequests},
+
+	{errs.ErrNotAvailableProvider, http.StatusServiceUnavailable},
+	{errs.ErrAcquireExceedLimit, http.StatusServiceUnavailable},
+
+	{errs.ErrFailedSendNotification, http.StatusBadGateway},
+	{errs.ErrFailedToSendNotification, http.StatusBadGateway},
+}
+
+// statusOf 根据 errs 中定义的错误获取 http 状态码，未定义的错误返回 500
+func statusOf(err error) int {
+	for _, es := range errStatuses {
+		if errors.Is(err, es.err) {
+			return es.code
+		}
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,47 @@
+package gateway
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/JrMarcco/jotify/internal/errs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusOf(t *testing.T) {
+	tcs := []struct {
+		name     string
+		err      error
+		wantCode int
+	}{
+		{
+			name:     "invalid param",
+			err:      fmt.Errorf("%w: biz key should not be empty", errs.ErrInvalidParam),
+			wantCode: http.StatusBadRequest,
+		}, {
+			name:     "not found",
+			err:      fmt.Errorf("%w: id = 1", errs.ErrNotificationNotFound),
+			wantCode: http.StatusNotFound,
+		}, {
+			name:     "failed send wraps specific cause",
+			err:      fmt.Errorf("%w: cause of: %w", errs.ErrFailedSendNotification, errs.ErrInsufficientQuota),
+			wantCode: http.StatusTooManyRequests,
+		}, {
+			name:     "failed send",
+			err:      fmt.Errorf("%w: cause of: %w", errs.ErrFailedSendNotification, errors.New("timeout")),
+			wantCode: http.StatusBadGateway,
+		}, {
+			name:     "unknown error",
+			err:      errors.New("unknown"),
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.wantCode, statusOf(tc.err))
+		})
+	}
+}
@@ -0,0 +1,1015 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	notificationv1 "github.com/JrMarcco/jotify-api/api/notification/v1"
+	"github.com/JrMarcco/jotify/internal/api/grpc/interceptor/jwt"
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/errs"
+	"github.com/JrMarcco/jotify/internal/pkg/auth"
+	"github.com/JrMarcco/jotify/internal/pkg/client"
+	"github.com/JrMarcco/jotify/internal/service/audit"
+	"github.com/JrMarcco/jotify/internal/service/notification"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// maxBodyBytes 请求体大小上限
+const maxBodyBytes = 4 << 20
+
+// inboundTokenHeader 上行回复回调携带 inbound token 的请求头
+const inboundTokenHeader = "X-Jotify-Inbound-Token"
+
+// priorityHeader 指定调度优先级（high、normal、low）的请求头，对请求中的全部消息生效，未指定时按模板的业务类型确定
+const priorityHeader = "X-Jotify-Priority"
+
+// pacingHeader 开启匀速发送的请求头，取值为 even 或 curve:<权重,...>（例如 curve:1,2,4,2,1），
+// 对请求中的全部消息生效，只支持 time_window 与 deadline 发送策略
+const pacingHeader = "X-Jotify-Pacing"
+
+// localeHeader 指定模板语言（BCP 47 语言标签，例如 zh-HK）的请求头，对请求中的全部消息生效，
+// 未指定时使用接收者的语言偏好
+const localeHeader = "X-Jotify-Locale"
+
+// Server HTTP/JSON 网关，为无法使用 gRPC 的客户端提供相同的消息发送、查询和取消能力，
+// 以及个人数据擦除、退订名单、接收者语言偏好、周期发送计划、群发活动管理与模板人工审核接口。
+//
+// 请求体中的消息使用 protojson 解析为 notificationv1.Notification，与 gRPC 接口保持一致。
+// notificationv1 中没有周期发送策略、优先级、匀速发送与语言，周期发送计划与群发活动只能通过网关创建，
+// 优先级、匀速发送与语言分别通过请求头 X-Jotify-Priority、X-Jotify-Pacing 与 X-Jotify-Locale 指定。
+// 退订链接与上行回复回调不使用 jwt，分别通过链接中的签名 token 与 inbound token 校验。
+type Server struct {
+	sendSvc        notification.SendService
+	querySvc       notification.QueryService
+	cancelSvc      notification.CancelService
+	erasureSvc     notification.ErasureService
+	suppressionSvc notification.SuppressionService
+	unsubscribeSvc notification.UnsubscribeService
+	recurringSvc   notification.RecurringService
+	campaignSvc    notification.CampaignService
+	localeSvc      notification.ReceiverLocaleService
+	auditSvc       audit.Service
+
+	jwtBuilder *jwt.InterceptorBuilder
+	logger     *zap.Logger
+}
+
+// Handler 返回注册好路由的 http.Handler
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.Handle("POST /v1/notifications/send", s.scope(auth.ScopeSend, s.send))
+	mux.Handle("POST /v1/notifications/async-send", s.scope(auth.ScopeSend, s.asyncSend))
+	mux.Handle("POST /v1/notifications/batch-send", s.scope(auth.ScopeSend, s.batchSend))
+	mux.Handle("POST /v1/notifications/batch-async-send", s.scope(auth.ScopeSend, s.batchAsyncSend))
+	mux.Handle("GET /v1/notifications", s.scope(auth.ScopeQuery, s.batchQuery))
+	mux.Handle("GET /v1/notifications/{biz_key}", s.scope(auth.ScopeQuery, s.query))
+	mux.Handle("POST /v1/notifications/{biz_key}/cancel", s.scope(auth.ScopeSend, s.cancel))
+	mux.Handle("POST /v1/erasures", s.scope(auth.ScopeErasure, s.erase))
+	mux.Handle("GET /v1/erasures/{id}", s.scope(auth.ScopeErasure, s.erasureReceipt))
+	mux.Handle("POST /v1/suppressions", s.scope(auth.ScopeSuppression, s.importSuppressions))
+	mux.Handle("DELETE /v1/suppressions", s.scope(auth.ScopeSuppression, s.removeSuppression))
+	mux.Handle("GET /v1/suppressions", s.scope(auth.ScopeSuppression, s.listSuppressions))
+	mux.Handle("PUT /v1/receivers/locales", s.scope(auth.ScopeSend, s.importReceiverLocales))
+	mux.Handle("DELETE /v1/receivers/locales", s.scope(auth.ScopeSend, s.removeReceiverLocale))
+	mux.Handle("POST /v1/recurring-schedules", s.scope(auth.ScopeSend, s.createRecurring))
+	mux.Handle("GET /v1/recurring-schedules", s.scope(auth.ScopeQuery, s.listRecurring))
+	mux.Handle("POST /v1/recurring-schedules/{biz_key}/pause", s.scope(auth.ScopeSend, s.pauseRecurring))
+	mux.Handle("POST /v1/recurring-schedules/{biz_key}/resume", s.scope(auth.ScopeSend, s.resumeRecurring))
+	mux.Handle("DELETE /v1/recurring-schedules/{biz_key}", s.scope(auth.ScopeSend, s.deleteRecurring))
+	mux.Handle("POST /v1/campaigns", s.scope(auth.ScopeSend, s.createCampaign))
+	mux.Handle("GET /v1/campaigns", s.scope(auth.ScopeQuery, s.listCampaigns))
+	mux.Handle("GET /v1/campaigns/{biz_key}", s.scope(auth.ScopeQuery, s.getCampaign))
+	mux.Handle("POST /v1/campaigns/{biz_key}/receivers", s.scope(auth.ScopeSend, s.uploadCampaignReceivers))
+	mux.Handle("POST /v1/campaigns/{biz_key}/start", s.scope(auth.ScopeSend, s.campaignAction(notification.CampaignService.Start)))
+	mux.Handle("POST /v1/campaigns/{biz_key}/pause", s.scope(auth.ScopeSend, s.campaignAction(notification.CampaignService.Pause)))
+	mux.Handle("POST /v1/campaigns/{biz_key}/resume", s.scope(auth.ScopeSend, s.campaignAction(notification.CampaignService.Resume)))
+	mux.Handle("POST /v1/campaigns/{biz_key}/cancel", s.scope(auth.ScopeSend, s.campaignAction(notification.CampaignService.Cancel)))
+	mux.Handle("GET /v1/templates/reviews", s.scope(auth.ScopeTplReview, s.listTplReviews))
+	mux.Handle("POST /v1/templates/versions/{version_id}/review", s.scope(auth.ScopeTplReview, s.reviewTplVersion))
+	mux.Handle("GET /v1/templates/versions/{version_id}/audits", s.scope(auth.ScopeTplReview, s.listTplAudits))
+	mux.Handle("GET /v1/templates/variants/reviews", s.scope(auth.ScopeTplReview, s.listTplVariantReviews))
+	mux.Handle("POST /v1/templates/variants/{variant_id}/review", s.scope(auth.ScopeTplReview, s.reviewTplVariant))
+
+	public := http.NewServeMux()
+	// GET 只展示确认页面，避免邮件安全网关等预取链接时误退订
+	public.HandleFunc("GET /v1/unsubscribe", s.unsubscribeConfirm)
+	// 确认页面与邮件客户端的一键退订（RFC 8058）使用 POST
+	public.HandleFunc("POST /v1/unsubscribe", s.unsubscribe)
+	public.HandleFunc("POST /v1/inbound/replies", s.inboundReply)
+	public.Handle("/", s.auth(mux))
+	return public
+}
+
+// auth 校验 jwt token，与 gRPC jwt 拦截器使用相同的解码逻辑
+func (s *Server) auth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenStr := r.Header.Get("Authorization")
+		if tokenStr == "" {
+			writeJson(w, http.StatusUnauthorized, errorResp{Message: "missing authorization token"})
+			return
+		}
+
+		mc, err := s.jwtBuilder.Decode(tokenStr)
+		if err != nil {
+			writeJson(w, http.StatusUnauthorized, errorResp{Message: "invalid token"})
+			return
+		}
+
+		ctx := jwt.ContextWithClaims(r.Context(), mc)
+		if _, ok := client.BizIdFromContext(ctx); !ok {
+			writeJson(w, http.StatusUnauthorized, errorResp{Message: "missing biz id in token"})
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// scope 校验 token 是否拥有路由需要的授权范围
+func (s *Server) scope(scope auth.Scope, next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := auth.CheckScope(r.Context(), scope); err != nil {
+			s.writeError(w, err)
+			return
+		}
+		next(w, r)
+	})
+}
+
+func (s *Server) send(w http.ResponseWriter, r *http.Request) {
+	n, err := s.readNotification(r)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	resp, err := s.sendSvc.Send(r.Context(), n)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	writeJson(w, http.StatusOK, toSendResultResp(resp.Result))
+}
+
+func (s *Server) asyncSend(w http.ResponseWriter, r *http.Request) {
+	n, err := s.readNotification(r)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	resp, err := s.sendSvc.AsyncSend(r.Context(), n)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	writeJson(w, http.StatusAccepted, toSendResultResp(resp.Result))
+}
+
+func (s *Server) batchSend(w http.ResponseWriter, r *http.Request) {
+	ns, err := s.readNotifications(r)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	resp, err := s.sendSvc.BatchSend(r.Context(), ns)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	results := make([]sendResultResp, 0, len(resp.Results))
+	for _, res := range resp.Results {
+		results = append(results, toSendResultResp(res))
+	}
+	writeJson(w, http.StatusOK, batchSendResp{Results: results})
+}
+
+func (s *Server) batchAsyncSend(w http.ResponseWriter, r *http.Request) {
+	ns, err := s.readNotifications(r)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	resp, err := s.sendSvc.BatchAsyncSend(r.Context(), ns)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	ids := make([]string, 0, len(resp.NotificationIds))
+	for _, id := range resp.NotificationIds {
+		ids = append(ids, strconv.FormatUint(id, 10))
+	}
+	writeJson(w, http.StatusAccepted, batchAsyncSendResp{
+		NotificationIds: ids,
+		Suppressed:      toSuppressedReceiverResps(resp.Suppressed),
+	})
+}
+
+func (s *Server) query(w http.ResponseWriter, r *http.Request) {
+	bizId, _ := client.BizIdFromContext(r.Context())
+
+	n, err := s.querySvc.GetByKey(r.Context(), bizId, r.PathValue("biz_key"))
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	writeJson(w, http.StatusOK, toNotificationResp(n))
+}
+
+// batchQuery 按消息 id 批量查询，id 通过 query 参数 ids 传入，多个 id 以逗号分隔
+func (s *Server) batchQuery(w http.ResponseWriter, r *http.Request) {
+	bizId, _ := client.BizIdFromContext(r.Context())
+
+	var ids []uint64
+	for _, str := range strings.Split(r.URL.Query().Get("ids"), ",") {
+		if str = strings.TrimSpace(str); str == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(str, 10, 64)
+		if err != nil {
+			s.writeError(w, fmt.Errorf("%w: invalid notification id %q", errs.ErrInvalidParam, str))
+			return
+		}
+		ids = append(ids, id)
+	}
+
+	m, err := s.querySvc.BatchGetByIds(r.Context(), bizId, ids)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	res := make([]notificationResp, 0, len(m))
+	for _, id := range ids {
+		if n, ok := m[id]; ok {
+			res = append(res, toNotificationResp(n))
+		}
+	}
+	writeJson(w, http.StatusOK, batchQueryResp{Notifications: res})
+}
+
+func (s *Server) cancel(w http.ResponseWriter, r *http.Request) {
+	bizId, _ := client.BizIdFromContext(r.Context())
+
+	if err := s.cancelSvc.Cancel(r.Context(), bizId, r.PathValue("biz_key")); err != nil {
+		s.writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// erase 擦除接收者在当前业务方下的个人数据，请求体格式为 {"receiver": "..."}
+func (s *Server) erase(w http.ResponseWriter, r *http.Request) {
+	bizId, _ := client.BizIdFromContext(r.Context())
+
+	body, err := readBody(r)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	req := struct {
+		Receiver string `json:"receiver"`
+	}{}
+	if err = json.Unmarshal(body, &req); err != nil {
+		s.writeError(w, fmt.Errorf("%w: %w", errs.ErrInvalidParam, err))
+		return
+	}
+
+	receipt, err := s.erasureSvc.Erase(r.Context(), bizId, req.Receiver)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	writeJson(w, http.StatusOK, toErasureReceiptResp(receipt))
+}
+
+func (s *Server) erasureReceipt(w http.ResponseWriter, r *http.Request) {
+	bizId, _ := client.BizIdFromContext(r.Context())
+
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		s.writeError(w, fmt.Errorf("%w: invalid erasure receipt id %q", errs.ErrInvalidParam, r.PathValue("id")))
+		return
+	}
+
+	receipt, err := s.erasureSvc.GetReceipt(r.Context(), bizId, id)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	writeJson(w, http.StatusOK, toErasureReceiptResp(receipt))
+}
+
+// importSuppressions 批量导入退订名单，请求体格式为 {"global": false, "suppressions": [{"receiver": "...", "channel": "sms", "reason": "manual", "expire_at": 0}]}
+//
+// global 为 true 时导入全局名单，需要 admin 授权范围。
+func (s *Server) importSuppressions(w http.ResponseWriter, r *http.Request) {
+	body, err := readBody(r)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	req := struct {
+		Global       bool             `json:"global"`
+		Suppressions []suppressionReq `json:"suppressions"`
+	}{}
+	if err = json.Unmarshal(body, &req); err != nil {
+		s.writeError(w, fmt.Errorf("%w: %w", errs.ErrInvalidParam, err))
+		return
+	}
+
+	ss := make([]domain.Suppression, 0, len(req.Suppressions))
+	for _, sr := range req.Suppressions {
+		ss = append(ss, sr.toDomain())
+	}
+	if err = s.suppressionSvc.Import(r.Context(), suppressionBizId(r, req.Global), ss); err != nil {
+		s.writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// removeSuppression 移出退订名单，请求体格式为 {"global": false, "receiver": "...", "channel": "sms"}
+func (s *Server) removeSuppression(w http.ResponseWriter, r *http.Request) {
+	body, err := readBody(r)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	req := struct {
+		Global   bool   `json:"global"`
+		Receiver string `json:"receiver"`
+		Channel  string `json:"channel"`
+	}{}
+	if err = json.Unmarshal(body, &req); err != nil {
+		s.writeError(w, fmt.Errorf("%w: %w", errs.ErrInvalidParam, err))
+		return
+	}
+
+	err = s.suppressionSvc.Remove(r.Context(), suppressionBizId(r, req.Global), domain.Channel(req.Channel), req.Receiver)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listSuppressions 分页查询退订名单，query 参数为 global、receiver、offset、limit
+func (s *Server) listSuppressions(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	offset, limit, err := readPage(r)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	bizId := suppressionBizId(r, query.Get("global") == "true")
+	ss, err := s.suppressionSvc.List(r.Context(), bizId, query.Get("receiver"), offset, limit)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	res := make([]suppressionResp, 0, len(ss))
+	for _, sup := range ss {
+		res = append(res, toSuppressionResp(sup))
+	}
+	writeJson(w, http.StatusOK, listSuppressionsResp{Suppressions: res})
+}
+
+// importReceiverLocales 导入接收者的语言偏好，请求体格式为 {"locales": [{"receiver": "...", "locale": "zh-HK"}]}
+func (s *Server) importReceiverLocales(w http.ResponseWriter, r *http.Request) {
+	bizId, _ := client.BizIdFromContext(r.Context())
+
+	body, err := readBody(r)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	req := struct {
+		Locales []receiverLocaleReq `json:"locales"`
+	}{}
+	if err = json.Unmarshal(body, &req); err != nil {
+		s.writeError(w, fmt.Errorf("%w: %w", errs.ErrInvalidParam, err))
+		return
+	}
+
+	ls := make([]domain.ReceiverLocale, 0, len(req.Locales))
+	for _, lr := range req.Locales {
+		ls = append(ls, lr.toDomain())
+	}
+	if err = s.localeSvc.Import(r.Context(), bizId, ls); err != nil {
+		s.writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// removeReceiverLocale 删除接收者的语言偏好，请求体格式为 {"receiver": "..."}
+func (s *Server) removeReceiverLocale(w http.ResponseWriter, r *http.Request) {
+	bizId, _ := client.BizIdFromContext(r.Context())
+
+	body, err := readBody(r)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	req := struct {
+		Receiver string `json:"receiver"`
+	}{}
+	if err = json.Unmarshal(body, &req); err != nil {
+		s.writeError(w, fmt.Errorf("%w: %w", errs.ErrInvalidParam, err))
+		return
+	}
+
+	if err = s.localeSvc.Remove(r.Context(), bizId, req.Receiver); err != nil {
+		s.writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// createRecurring 创建周期发送计划，请求体格式为
+// {"notification": notificationv1.Notification, "cron": "0 9 * * *", "timezone": "Asia/Shanghai", "end_at": 0, "max_occurrences": 0}
+//
+// 消息中的发送策略被忽略，end_at 为毫秒时间戳，end_at 与 max_occurrences 为 0 表示不限。
+func (s *Server) createRecurring(w http.ResponseWriter, r *http.Request) {
+	body, err := readBody(r)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	req := struct {
+		Notification   json.RawMessage `json:"notification"`
+		Cron           string          `json:"cron"`
+		Timezone       string          `json:"timezone"`
+		EndAt          int64           `json:"end_at"`
+		MaxOccurrences int32           `json:"max_occurrences"`
+	}{}
+	if err = json.Unmarshal(body, &req); err != nil {
+		s.writeError(w, fmt.Errorf("%w: %w", errs.ErrInvalidParam, err))
+		return
+	}
+
+	pn := &notificationv1.Notification{}
+	if err = unmarshalOpts.Unmarshal(req.Notification, pn); err != nil {
+		s.writeError(w, fmt.Errorf("%w: %w", errs.ErrInvalidParam, err))
+		return
+	}
+	n, err := toDomainNotification(r, pn)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	n.StrategyConfig = domain.SendStrategyConf{
+		Type:           domain.SendStrategyRecurring,
+		Cron:           req.Cron,
+		Timezone:       req.Timezone,
+		MaxOccurrences: req.MaxOccurrences,
+	}
+	if req.EndAt > 0 {
+		n.StrategyConfig.EndAt = time.UnixMilli(req.EndAt)
+	}
+
+	resp, err := s.sendSvc.Send(r.Context(), n)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	writeJson(w, http.StatusCreated, toSendResultResp(resp.Result))
+}
+
+// listRecurring 分页查询周期发送计划，query 参数为 offset、limit
+func (s *Server) listRecurring(w http.ResponseWriter, r *http.Request) {
+	bizId, _ := client.BizIdFromContext(r.Context())
+
+	offset, limit, err := readPage(r)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	schedules, err := s.recurringSvc.List(r.Context(), bizId, offset, limit)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	res := make([]recurringScheduleResp, 0, len(schedules))
+	for _, schedule := range schedules {
+		res = append(res, toRecurringScheduleResp(schedule))
+	}
+	writeJson(w, http.StatusOK, listRecurringSchedulesResp{Schedules: res})
+}
+
+func (s *Server) pauseRecurring(w http.ResponseWriter, r *http.Request) {
+	bizId, _ := client.BizIdFromContext(r.Context())
+
+	if err := s.recurringSvc.Pause(r.Context(), bizId, r.PathValue("biz_key")); err != nil {
+		s.writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) resumeRecurring(w http.ResponseWriter, r *http.Request) {
+	bizId, _ := client.BizIdFromContext(r.Context())
+
+	if err := s.recurringSvc.Resume(r.Context(), bizId, r.PathValue("biz_key")); err != nil {
+		s.writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) deleteRecurring(w http.ResponseWriter, r *http.Request) {
+	bizId, _ := client.BizIdFromContext(r.Context())
+
+	if err := s.recurringSvc.Delete(r.Context(), bizId, r.PathValue("biz_key")); err != nil {
+		s.writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// createCampaign 创建群发活动，请求体格式为 {"biz_key": "...", "notification": notificationv1.Notification}
+//
+// 消息中的接收者被忽略，接收者在创建后通过 /v1/campaigns/{biz_key}/receivers 上传。
+func (s *Server) createCampaign(w http.ResponseWriter, r *http.Request) {
+	body, err := readBody(r)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	req := struct {
+		BizKey       string          `json:"biz_key"`
+		Notification json.RawMessage `json:"notification"`
+	}{}
+	if err = json.Unmarshal(body, &req); err != nil {
+		s.writeError(w, fmt.Errorf("%w: %w", errs.ErrInvalidParam, err))
+		return
+	}
+
+	pn := &notificationv1.Notification{}
+	if err = unmarshalOpts.Unmarshal(req.Notification, pn); err != nil {
+		s.writeError(w, fmt.Errorf("%w: %w", errs.ErrInvalidParam, err))
+		return
+	}
+	n, err := toDomainNotification(r, pn)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	c, err := s.campaignSvc.Create(r.Context(), domain.Campaign{
+		BizId:          n.BizId,
+		BizKey:         req.BizKey,
+		Channel:        n.Channel,
+		Template:       n.Template,
+		Priority:       n.Priority,
+		StrategyConfig: n.StrategyConfig,
+	})
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	writeJson(w, http.StatusCreated, toCampaignResp(c))
+}
+
+// uploadCampaignReceivers 为 draft 状态的活动追加接收者，可以多次上传，请求体按 Content-Type 解析：
+//   - text/csv：首行为表头，第一列为 receiver，其余列为模板参数名
+//   - 其它：每行一个 json 对象 {"receiver": "...", "params": {"name": "..."}}
+//
+// 请求体不受 maxBodyBytes 限制，按 receiverChunkSize 分批保存，中途失败时已保存的接收者不会回滚，
+// 响应与错误信息中的 total 为已保存的接收者总数。
+func (s *Server) uploadCampaignReceivers(w http.ResponseWriter, r *http.Request) {
+	const receiverChunkSize = 1000
+
+	bizId, _ := client.BizIdFromContext(r.Context())
+	bizKey := r.PathValue("biz_key")
+
+	var total int64
+	flush := func(rs []domain.CampaignReceiver) error {
+		if len(rs) == 0 {
+			return nil
+		}
+		var err error
+		total, err = s.campaignSvc.AppendReceivers(r.Context(), bizId, bizKey, rs)
+		return err
+	}
+
+	var err error
+	if mediaType, _, _ := strings.Cut(r.Header.Get("Content-Type"), ";"); strings.TrimSpace(mediaType) == "text/csv" {
+		err = readCsvReceivers(r.Body, receiverChunkSize, flush)
+	} else {
+		err = readJsonReceivers(r.Body, receiverChunkSize, flush)
+	}
+	if err != nil {
+		s.writeError(w, fmt.Errorf("%w, total = %d", err, total))
+		return
+	}
+	if total == 0 {
+		s.writeError(w, fmt.Errorf("%w: receivers should not be empty", errs.ErrInvalidParam))
+		return
+	}
+	writeJson(w, http.StatusOK, map[string]int64{"total": total})
+}
+
+func (s *Server) getCampaign(w http.ResponseWriter, r *http.Request) {
+	bizId, _ := client.BizIdFromContext(r.Context())
+
+	c, err := s.campaignSvc.Get(r.Context(), bizId, r.PathValue("biz_key"))
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	writeJson(w, http.StatusOK, toCampaignResp(c))
+}
+
+// listCampaigns 分页查询群发活动，query 参数为 offset、limit，列表中的发送进度为最近一次保存的进度
+func (s *Server) listCampaigns(w http.ResponseWriter, r *http.Request) {
+	bizId, _ := client.BizIdFromContext(r.Context())
+
+	offset, limit, err := readPage(r)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	cs, err := s.campaignSvc.List(r.Context(), bizId, offset, limit)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	res := make([]campaignResp, 0, len(cs))
+	for _, c := range cs {
+		res = append(res, toCampaignResp(c))
+	}
+	writeJson(w, http.StatusOK, listCampaignsResp{Campaigns: res})
+}
+
+// campaignAction 变更群发活动状态
+func (s *Server) campaignAction(
+	action func(svc notification.CampaignService, ctx context.Context, bizId uint64, bizKey string) error,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		bizId, _ := client.BizIdFromContext(r.Context())
+
+		if err := action(s.campaignSvc, r.Context(), bizId, r.PathValue("biz_key")); err != nil {
+			s.writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// listTplReviews 按 id 升序查询等待人工审核的模板版本，query 参数为 start_id（上一页最后一个版本的 id）、limit
+func (s *Server) listTplReviews(w http.ResponseWriter, r *http.Request) {
+	_, limit, err := readPage(r)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	var startId uint64
+	if str := r.URL.Query().Get("start_id"); str != "" {
+		if startId, err = strconv.ParseUint(str, 10, 64); err != nil {
+			s.writeError(w, fmt.Errorf("%w: invalid start id %q", errs.ErrInvalidParam, str))
+			return
+		}
+	}
+
+	versions, err := s.auditSvc.Queue(r.Context(), startId, limit)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	res := make([]tplVersionResp, 0, len(versions))
+	for _, version := range versions {
+		res = append(res, toTplVersionResp(version))
+	}
+	writeJson(w, http.StatusOK, listTplReviewsResp{Versions: res})
+}
+
+// reviewTplVersion 人工审核模板版本，请求体格式为 {"approved": false, "reason": "..."}，审核人为 token 的业务方
+func (s *Server) reviewTplVersion(w http.ResponseWriter, r *http.Request) {
+	auditorId, _ := client.BizIdFromContext(r.Context())
+
+	versionId, err := strconv.ParseUint(r.PathValue("version_id"), 10, 64)
+	if err != nil {
+		s.writeError(w, fmt.Errorf("%w: invalid version id %q", errs.ErrInvalidParam, r.PathValue("version_id")))
+		return
+	}
+
+	body, err := readBody(r)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	req := struct {
+		Approved bool   `json:"approved"`
+		Reason   string `json:"reason"`
+	}{}
+	if err = json.Unmarshal(body, &req); err != nil {
+		s.writeError(w, fmt.Errorf("%w: %w", errs.ErrInvalidParam, err))
+		return
+	}
+
+	a, err := s.auditSvc.Review(r.Context(), versionId, auditorId, req.Approved, req.Reason)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	writeJson(w, http.StatusOK, toTplAuditResp(a))
+}
+
+// listTplAudits 查询模板版本的审核记录
+func (s *Server) listTplAudits(w http.ResponseWriter, r *http.Request) {
+	versionId, err := strconv.ParseUint(r.PathValue("version_id"), 10, 64)
+	if err != nil {
+		s.writeError(w, fmt.Errorf("%w: invalid version id %q", errs.ErrInvalidParam, r.PathValue("version_id")))
+		return
+	}
+
+	audits, err := s.auditSvc.History(r.Context(), versionId)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	res := make([]tplAuditResp, 0, len(audits))
+	for _, a := range audits {
+		res = append(res, toTplAuditResp(a))
+	}
+	writeJson(w, http.StatusOK, listTplAuditsResp{Audits: res})
+}
+
+// listTplVariantReviews 查询等待人工审核的模板语言变体，query 参数为 start_id、limit
+func (s *Server) listTplVariantReviews(w http.ResponseWriter, r *http.Request) {
+	_, limit, err := readPage(r)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	var startId uint64
+	if str := r.URL.Query().Get("start_id"); str != "" {
+		if startId, err = strconv.ParseUint(str, 10, 64); err != nil {
+			s.writeError(w, fmt.Errorf("%w: invalid start id %q", errs.ErrInvalidParam, str))
+			return
+		}
+	}
+
+	variants, err := s.auditSvc.VariantQueue(r.Context(), startId, limit)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	res := make([]tplVariantResp, 0, len(variants))
+	for _, variant := range variants {
+		res = append(res, toTplVariantResp(variant))
+	}
+	writeJson(w, http.StatusOK, listTplVariantReviewsResp{Variants: res})
+}
+
+// reviewTplVariant 人工审核模板语言变体，请求体格式为 {"approved": false, "reason": "..."}，审核人为 token 的业务方
+func (s *Server) reviewTplVariant(w http.ResponseWriter, r *http.Request) {
+	auditorId, _ := client.BizIdFromContext(r.Context())
+
+	variantId, err := strconv.ParseUint(r.PathValue("variant_id"), 10, 64)
+	if err != nil {
+		s.writeError(w, fmt.Errorf("%w: invalid variant id %q", errs.ErrInvalidParam, r.PathValue("variant_id")))
+		return
+	}
+
+	body, err := readBody(r)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	req := struct {
+		Approved bool   `json:"approved"`
+		Reason   string `json:"reason"`
+	}{}
+	if err = json.Unmarshal(body, &req); err != nil {
+		s.writeError(w, fmt.Errorf("%w: %w", errs.ErrInvalidParam, err))
+		return
+	}
+
+	a, err := s.auditSvc.ReviewVariant(r.Context(), variantId, auditorId, req.Approved, req.Reason)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	writeJson(w, http.StatusOK, toTplAuditResp(a))
+}
+
+// unsubscribeConfirmPage 退订确认页面，确认后以 POST 提交同一个 token
+var unsubscribeConfirmPage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Unsubscribe</title></head>
+<body>
+<form method="post">
+<input type="hidden" name="token" value="{{.}}">
+<p>确认不再接收此类邮件？</p>
+<button type="submit">退订</button>
+</form>
+</body>
+</html>
+`))
+
+// unsubscribeConfirm 退订链接的确认页面，不修改退订名单
+func (s *Server) unsubscribeConfirm(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		writeJson(w, http.StatusBadRequest, errorResp{Message: "missing unsubscribe token"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	if err := unsubscribeConfirmPage.Execute(w, token); err != nil {
+		s.logger.Error("[jotify] failed to render unsubscribe confirm page", zap.Error(err))
+	}
+}
+
+// unsubscribe 退订，token 由发送营销邮件时签发，位于链接的查询参数或确认页面提交的表单中
+func (s *Server) unsubscribe(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+	if err := s.unsubscribeSvc.Unsubscribe(r.Context(), r.FormValue("token")); err != nil {
+		s.writeError(w, err)
+		return
+	}
+	writeJson(w, http.StatusOK, map[string]string{"message": "unsubscribed"})
+}
+
+// inboundReply 上行回复回调，请求体格式为 {"channel": "sms", "receiver": "...", "content": "TD"}
+//
+// 供应商的上行回调需要转换为该格式，并在请求头中携带 inbound token，业务方由 inbound token 绑定的业务方确定。
+func (s *Server) inboundReply(w http.ResponseWriter, r *http.Request) {
+	body, err := readBody(r)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	reply := domain.InboundReply{}
+	if err = json.Unmarshal(body, &reply); err != nil {
+		s.writeError(w, fmt.Errorf("%w: %w", errs.ErrInvalidParam, err))
+		return
+	}
+
+	suppressed, err := s.unsubscribeSvc.HandleReply(r.Context(), r.Header.Get(inboundTokenHeader), reply)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	writeJson(w, http.StatusOK, map[string]bool{"suppressed": suppressed})
+}
+
+// suppressionBizId 全局名单使用业务 id 0，否则使用 token 中的业务 id
+func suppressionBizId(r *http.Request, global bool) uint64 {
+	if global {
+		return 0
+	}
+	bizId, _ := client.BizIdFromContext(r.Context())
+	return bizId
+}
+
+// readNotification 解析单条消息请求，请求体为 notificationv1.SendRequest 的 json 格式
+func (s *Server) readNotification(r *http.Request) (domain.Notification, error) {
+	body, err := readBody(r)
+	if err != nil {
+		return domain.Notification{}, err
+	}
+
+	req := &notificationv1.SendRequest{}
+	if err = unmarshalOpts.Unmarshal(body, req); err != nil {
+		return domain.Notification{}, fmt.Errorf("%w: %w", errs.ErrInvalidParam, err)
+	}
+	return toDomainNotification(r, req.Notification)
+}
+
+// readNotifications 解析批量消息请求，请求体格式为 {"notifications": [notificationv1.Notification...]}
+func (s *Server) readNotifications(r *http.Request) ([]domain.Notification, error) {
+	body, err := readBody(r)
+	if err != nil {
+		return nil, err
+	}
+
+	req := struct {
+		Notifications []json.RawMessage `json:"notifications"`
+	}{}
+	if err = json.Unmarshal(body, &req); err != nil {
+		return nil, fmt.Errorf("%w: %w", errs.ErrInvalidParam, err)
+	}
+
+	ns := make([]domain.Notification, 0, len(req.Notifications))
+	for _, raw := range req.Notifications {
+		pn := &notificationv1.Notification{}
+		if err = unmarshalOpts.Unmarshal(raw, pn); err != nil {
+			return nil, fmt.Errorf("%w: %w", errs.ErrInvalidParam, err)
+		}
+
+		n, err := toDomainNotification(r, pn)
+		if err != nil {
+			return nil, err
+		}
+		ns = append(ns, n)
+	}
+	return ns, nil
+}
+
+func (s *Server) writeError(w http.ResponseWriter, err error) {
+	code := statusOf(err)
+	if code >= http.StatusInternalServerError {
+		s.logger.Error("[jotify] gateway request failed", zap.Int("status", code), zap.Error(err))
+	}
+	writeJson(w, code, errorResp{Message: err.Error()})
+}
+
+var unmarshalOpts = protojson.UnmarshalOptions{DiscardUnknown: true}
+
+// readPage 解析分页 query 参数 offset 与 limit，默认为 0 与 100
+func readPage(r *http.Request) (int, int, error) {
+	query := r.URL.Query()
+
+	offset, limit := 0, 100
+	var err error
+	if str := query.Get("offset"); str != "" {
+		if offset, err = strconv.Atoi(str); err != nil {
+			return 0, 0, fmt.Errorf("%w: invalid offset %q", errs.ErrInvalidParam, str)
+		}
+	}
+	if str := query.Get("limit"); str != "" {
+		if limit, err = strconv.Atoi(str); err != nil {
+			return 0, 0, fmt.Errorf("%w: invalid limit %q", errs.ErrInvalidParam, str)
+		}
+	}
+	return offset, limit, nil
+}
+
+func readBody(r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodyBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read request body: %w", errs.ErrInvalidParam, err)
+	}
+	if len(body) > maxBodyBytes {
+		return nil, fmt.Errorf("%w: request body too large", errs.ErrInvalidParam)
+	}
+	return body, nil
+}
+
+func writeJson(w http.ResponseWriter, code int, val any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(val)
+}
+
+func NewServer(
+	sendSvc notification.SendService,
+	querySvc notification.QueryService,
+	cancelSvc notification.CancelService,
+	erasureSvc notification.ErasureService,
+	suppressionSvc notification.SuppressionService,
+	unsubscribeSvc notification.UnsubscribeService,
+	recurringSvc notification.RecurringService,
+	campaignSvc notification.CampaignService,
+	localeSvc notification.ReceiverLocaleService,
+	auditSvc audit.Service,
+	jwtBuilder *jwt.InterceptorBuilder,
+	logger *zap.Logger,
+) *Server {
+	return &Server{
+		sendSvc:        sendSvc,
+		querySvc:       querySvc,
+		cancelSvc:      cancelSvc,
+		erasureSvc:     erasureSvc,
+		suppressionSvc: suppressionSvc,
+		unsubscribeSvc: unsubscribeSvc,
+		recurringSvc:   recurringSvc,
+		campaignSvc:    campaignSvc,
+		localeSvc:      localeSvc,
+		auditSvc:       auditSvc,
+		jwtBuilder:     jwtBuilder,
+		logger:         logger,
+	}
+}
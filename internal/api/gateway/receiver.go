@@ -0,0 +1,90 @@
+package gateway
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/errs"
+)
+
+// readCsvReceivers 流式解析 csv 格式的接收者，每解析 chunkSize 个调用一次 flush。
+//
+// 首行为表头，第一列必须为 receiver，其余列为模板参数名，空值不覆盖活动的默认参数。
+func readCsvReceivers(r io.Reader, chunkSize int, flush func(rs []domain.CampaignReceiver) error) error {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%w: invalid csv header: %w", errs.ErrInvalidParam, err)
+	}
+	if len(header) == 0 || strings.TrimSpace(strings.TrimPrefix(header[0], "\ufeff")) != "receiver" {
+		return fmt.Errorf("%w: first column of csv header should be receiver", errs.ErrInvalidParam)
+	}
+
+	rs := make([]domain.CampaignReceiver, 0, chunkSize)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("%w: invalid csv record: %w", errs.ErrInvalidParam, err)
+		}
+
+		receiver := domain.CampaignReceiver{Receiver: record[0]}
+		for i := 1; i < len(record); i++ {
+			if record[i] == "" {
+				continue
+			}
+			if receiver.Params == nil {
+				receiver.Params = make(map[string]string, len(record)-1)
+			}
+			receiver.Params[strings.TrimSpace(header[i])] = record[i]
+		}
+
+		if rs = append(rs, receiver); len(rs) == chunkSize {
+			if err = flush(rs); err != nil {
+				return err
+			}
+			rs = make([]domain.CampaignReceiver, 0, chunkSize)
+		}
+	}
+	return flush(rs)
+}
+
+// readJsonReceivers 流式解析每行一个 json 对象的接收者，每解析 chunkSize 个调用一次 flush
+func readJsonReceivers(r io.Reader, chunkSize int, flush func(rs []domain.CampaignReceiver) error) error {
+	decoder := json.NewDecoder(r)
+
+	rs := make([]domain.CampaignReceiver, 0, chunkSize)
+	for row := 1; ; row++ {
+		var req struct {
+			Receiver string            `json:"receiver"`
+			Params   map[string]string `json:"params"`
+		}
+		err := decoder.Decode(&req)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("%w: invalid receiver at row %d: %w", errs.ErrInvalidParam, row, err)
+		}
+
+		if rs = append(rs, domain.CampaignReceiver{Receiver: req.Receiver, Params: req.Params}); len(rs) == chunkSize {
+			if err = flush(rs); err != nil {
+				return err
+			}
+			rs = make([]domain.CampaignReceiver, 0, chunkSize)
+		}
+	}
+	return flush(rs)
+}
@@ -0,0 +1,149 @@
+package gateway
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/errs"
+	"github.com/stretchr/testify/assert"
+)
+
+// collect 收集每次 flush 的接收者，返回的函数在第 failAt 次 flush 时返回错误，failAt 为 0 时不返回错误
+func collect(chunks *[][]domain.CampaignReceiver, failAt int) func(rs []domain.CampaignReceiver) error {
+	return func(rs []domain.CampaignReceiver) error {
+		*chunks = append(*chunks, rs)
+		if len(*chunks) == failAt {
+			return errors.New("flush failed")
+		}
+		return nil
+	}
+}
+
+func TestReadCsvReceivers(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name       string
+		input      string
+		chunkSize  int
+		wantChunks [][]domain.CampaignReceiver
+		wantErr    error
+	}{
+		{
+			name:      "chunked with params",
+			input:     "\ufeffreceiver, name,code\n13800000001,a,1\n13800000002,,2\n13800000003,c,\n",
+			chunkSize: 2,
+			wantChunks: [][]domain.CampaignReceiver{
+				{
+					{Receiver: "13800000001", Params: map[string]string{"name": "a", "code": "1"}},
+					{Receiver: "13800000002", Params: map[string]string{"code": "2"}},
+				},
+				{
+					{Receiver: "13800000003", Params: map[string]string{"name": "c"}},
+				},
+			},
+		}, {
+			name:      "receiver only",
+			input:     "receiver\n13800000001\n13800000002\n",
+			chunkSize: 2,
+			wantChunks: [][]domain.CampaignReceiver{
+				{{Receiver: "13800000001"}, {Receiver: "13800000002"}},
+				{},
+			},
+		}, {
+			name:       "empty input",
+			input:      "",
+			chunkSize:  2,
+			wantChunks: nil,
+		}, {
+			name:      "invalid header",
+			input:     "phone,name\n13800000001,a\n",
+			chunkSize: 2,
+			wantErr:   errs.ErrInvalidParam,
+		}, {
+			name:       "field count mismatch",
+			input:      "receiver,name\n13800000001,a,extra\n",
+			chunkSize:  2,
+			wantChunks: nil,
+			wantErr:    errs.ErrInvalidParam,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var chunks [][]domain.CampaignReceiver
+			err := readCsvReceivers(strings.NewReader(tc.input), tc.chunkSize, collect(&chunks, 0))
+			assert.ErrorIs(t, err, tc.wantErr)
+			if tc.wantErr == nil {
+				assert.Equal(t, tc.wantChunks, chunks)
+			}
+		})
+	}
+}
+
+func TestReadJsonReceivers(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name       string
+		input      string
+		chunkSize  int
+		wantChunks [][]domain.CampaignReceiver
+		wantErr    error
+	}{
+		{
+			name: "chunked with params",
+			input: `{"receiver":"a@jotify.com","params":{"name":"a"}}
+{"receiver":"b@jotify.com"}
+{"receiver":"c@jotify.com","params":{"name":"c"}}
+`,
+			chunkSize: 2,
+			wantChunks: [][]domain.CampaignReceiver{
+				{
+					{Receiver: "a@jotify.com", Params: map[string]string{"name": "a"}},
+					{Receiver: "b@jotify.com"},
+				},
+				{
+					{Receiver: "c@jotify.com", Params: map[string]string{"name": "c"}},
+				},
+			},
+		}, {
+			name:       "empty input",
+			input:      "",
+			chunkSize:  2,
+			wantChunks: [][]domain.CampaignReceiver{{}},
+		}, {
+			name:      "invalid row",
+			input:     "{\"receiver\":\"a@jotify.com\"}\n{\"receiver\":\n",
+			chunkSize: 2,
+			wantErr:   errs.ErrInvalidParam,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var chunks [][]domain.CampaignReceiver
+			err := readJsonReceivers(strings.NewReader(tc.input), tc.chunkSize, collect(&chunks, 0))
+			assert.ErrorIs(t, err, tc.wantErr)
+			if tc.wantErr == nil {
+				assert.Equal(t, tc.wantChunks, chunks)
+			}
+		})
+	}
+}
+
+func TestReadReceivers_FlushError(t *testing.T) {
+	t.Parallel()
+
+	var chunks [][]domain.CampaignReceiver
+	err := readCsvReceivers(strings.NewReader("receiver\n1\n2\n3\n"), 1, collect(&chunks, 2))
+	assert.EqualError(t, err, "flush failed")
+	// flush 失败后不再继续解析
+	assert.Len(t, chunks, 2)
+}
@@ -0,0 +1,90 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/JrMarcco/jotify/internal/service/notification"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// fakeUnsubscribeSvc 记录退订的 token
+type fakeUnsubscribeSvc struct {
+	notification.UnsubscribeService
+	tokens []string
+}
+
+func (s *fakeUnsubscribeSvc) Unsubscribe(_ context.Context, token string) error {
+	s.tokens = append(s.tokens, token)
+	return nil
+}
+
+func TestServer_Unsubscribe(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name       string
+		req        func() *http.Request
+		wantCode   int
+		wantTokens []string
+		wantBody   string
+	}{
+		{
+			name: "get only shows confirm page",
+			req: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "/v1/unsubscribe?token=a%22b", nil)
+			},
+			wantCode: http.StatusOK,
+			wantBody: `value="a&#34;b"`,
+		}, {
+			name: "get without token",
+			req: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "/v1/unsubscribe", nil)
+			},
+			wantCode: http.StatusBadRequest,
+		}, {
+			name: "one click post",
+			req: func() *http.Request {
+				req := httptest.NewRequest(
+					http.MethodPost, "/v1/unsubscribe?token=abc", strings.NewReader("List-Unsubscribe=One-Click"),
+				)
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				return req
+			},
+			wantCode:   http.StatusOK,
+			wantTokens: []string{"abc"},
+		}, {
+			name: "confirm form post",
+			req: func() *http.Request {
+				req := httptest.NewRequest(
+					http.MethodPost, "/v1/unsubscribe", strings.NewReader(url.Values{"token": {"abc"}}.Encode()),
+				)
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				return req
+			},
+			wantCode:   http.StatusOK,
+			wantTokens: []string{"abc"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := &fakeUnsubscribeSvc{}
+			s := NewServer(nil, nil, nil, nil, nil, svc, nil, nil, nil, nil, nil, zap.NewNop())
+
+			w := httptest.NewRecorder()
+			s.Handler().ServeHTTP(w, tc.req())
+
+			assert.Equal(t, tc.wantCode, w.Code)
+			assert.Equal(t, tc.wantTokens, svc.tokens)
+			assert.Contains(t, w.Body.String(), tc.wantBody)
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package grpc
+
+import (
+	"context"
+
+	notificationv1 "github.com/JrMarcco/jotify-api/api/notification/v1"
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/pkg/client"
+)
+
+// toDomainNotification 转换为领域对象，业务 id 取自 jwt token
+func toDomainNotification(ctx context.Context, pn *notificationv1.Notification) (domain.Notification, error) {
+	n, err := domain.NotificationFromApi(pn)
+	if err != nil {
+		return domain.Notification{}, err
+	}
+
+	n.BizId, _ = client.BizIdFromContext(ctx)
+	return n, nil
+}
+
+func toDomainNotifications(ctx context.Context, pns []*notificationv1.Notification) ([]domain.Notification, error) {
+	ns := make([]domain.Notification, 0, len(pns))
+	for _, pn := range pns {
+		n, err := toDomainNotification(ctx, pn)
+		if err != nil {
+			return nil, err
+		}
+		ns = append(ns, n)
+	}
+	return ns, nil
+}
+
+func toApiSendResult(res domain.SendResult) *notificationv1.SendResult {
+	return &notificationv1.SendResult{
+		NotificationId: res.NotificationId,
+		Status:         toApiStatus(res.Status),
+	}
+}
+
+func toApiStatus(status domain.SendStatus) notificationv1.SendStatus {
+	switch status {
+	case domain.SendStatusPrepare:
+		return notificationv1.SendStatus_PREPARE
+	case domain.SendStatusCancel:
+		return notificationv1.SendStatus_CANCEL
+	case domain.SendStatusPending:
+		return notificationv1.SendStatus_PENDING
+	case domain.SendStatusSuccess:
+		return notificationv1.SendStatus_SUCCESS
+	case domain.SendStatusFailure, domain.SendStatusExpired:
+		// api 中没有过期状态，过期视为发送失败
+		return notificationv1.SendStatus_FAILURE
+	default:
+		return notificationv1.SendStatus_STATUS_UNSPECIFIED
+	}
+}
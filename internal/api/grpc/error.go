@@ -0,0 +1,64 @@
+package grpc
+
+import (
+	"errors"
+
+	"github.com/JrMarcco/jotify/internal/errs"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errCodes 错误与 grpc 状态码的映射，按顺序匹配，与 HTTP 网关的映射保持一致。
+var errCodes = []struct {
+	err  error
+	code codes.Code
+}{
+	{errs.ErrInvalidParam, codes.InvalidArgument},
+	{errs.ErrInvalidChannel, codes.InvalidArgument},
+	{errs.ErrInvalidSendStrategy, codes.InvalidArgument},
+
+	{errs.ErrPermissionDenied, codes.PermissionDenied},
+
+	{errs.ErrBizIdNotFound, codes.NotFound},
+	{errs.ErrBizConfNotFound, codes.NotFound},
+	{errs.ErrChannelTplNotFound, codes.NotFound},
+	{errs.ErrChannelTplVersionNotFound, codes.NotFound},
+	{errs.ErrChannelTplVariantNotFound, codes.NotFound},
+	{errs.ErrNotificationNotFound, codes.NotFound},
+
+	{errs.ErrDuplicateNotificationId, codes.AlreadyExists},
+	{errs.ErrNotificationVersionConflict, codes.Aborted},
+	{errs.ErrNotificationNotCancelable, codes.FailedPrecondition},
+
+	{errs.ErrNotApprovedTplVersion, codes.FailedPrecondition},
+	{errs.ErrSensitiveContent, codes.FailedPrecondition},
+	{errs.ErrInsufficientQuota, codes.ResourceExhausted},
+	{errs.ErrOrgQuotaCapExceeded, codes.ResourceExhausted},
+
+	{errs.ErrNotAvailableProvider, codes.Unavailable},
+	{errs.ErrAcquireExceedLimit, codes.Unavailable},
+
+	{errs.ErrFailedSendNotification, codes.Unavailable},
+	{errs.ErrFailedToSendNotification, codes.Unavailable},
+}
+
+// codeOf 根据 errs 中定义的错误获取 grpc 状态码，未定义的错误返回 Internal
+func codeOf(err error) codes.Code {
+	for _, ec := range errCodes {
+		if errors.Is(err, ec.err) {
+			return ec.code
+		}
+	}
+	return codes.Internal
+}
+
+// toStatusErr 转换为 grpc 状态错误，内部错误只返回状态码，具体原因记录到日志
+func (s *NotificationServer) toStatusErr(err error) error {
+	code := codeOf(err)
+	if code == codes.Internal {
+		s.logger.Error("[jotify] grpc request failed", zap.Error(err))
+		return status.Error(code, "internal error")
+	}
+	return status.Error(code, err.Error())
+}
@@ -0,0 +1,78 @@
+package accesslog
+
+import (
+	"context"
+	"time"
+
+	"github.com/JrMarcco/jotify/internal/pkg/client"
+	grpcpkg "github.com/JrMarcco/jotify/internal/pkg/grpc"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// InterceptorBuilder 访问日志拦截器构造器。
+//
+// 每个请求结束后输出一条结构化日志，包含方法、业务 id、状态码、耗时和对端地址。
+// 放在 jwt 拦截器之前，认证失败的请求同样会记录；业务 id 由 jwt 拦截器通过 client.BizIdRecorder 回传，认证失败时不记录。
+type InterceptorBuilder struct {
+	logger *zap.Logger
+}
+
+func Builder(logger *zap.Logger) *InterceptorBuilder {
+	return &InterceptorBuilder{
+		logger: logger,
+	}
+}
+
+// Build 实际创建 grpc.UnaryServerInterceptor
+func (b *InterceptorBuilder) Build() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		start := time.Now()
+		ctx, recorder := client.WithBizIdRecorder(ctx)
+		defer func() {
+			b.log(ctx, recorder, info.FullMethod, start, err)
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// BuildStream 实际创建 grpc.StreamServerInterceptor
+func (b *InterceptorBuilder) BuildStream() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		start := time.Now()
+		ctx, recorder := client.WithBizIdRecorder(ss.Context())
+		defer func() {
+			b.log(ctx, recorder, info.FullMethod, start, err)
+		}()
+		return handler(srv, grpcpkg.WrapServerStream(ss, ctx))
+	}
+}
+
+func (b *InterceptorBuilder) log(
+	ctx context.Context, recorder *client.BizIdRecorder, fullMethod string, start time.Time, err error,
+) {
+	code := status.Code(err)
+	fields := []zap.Field{
+		zap.String("method", fullMethod),
+		zap.String("code", code.String()),
+		zap.Duration("duration", time.Since(start)),
+	}
+	if bizId, ok := recorder.Load(); ok {
+		fields = append(fields, zap.Uint64("biz_id", bizId))
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		fields = append(fields, zap.String("peer", p.Addr.String()))
+	}
+
+	switch code {
+	case codes.OK:
+		b.logger.Info("[jotify] grpc access", fields...)
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable:
+		b.logger.Error("[jotify] grpc access", append(fields, zap.Error(err))...)
+	default:
+		b.logger.Warn("[jotify] grpc access", append(fields, zap.Error(err))...)
+	}
+}
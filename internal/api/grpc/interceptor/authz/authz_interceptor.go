@@ -0,0 +1,83 @@
+package authz
+
+import (
+	"context"
+	"strings"
+
+	"github.com/JrMarcco/jotify/internal/pkg/auth"
+	"github.com/JrMarcco/jotify/internal/pkg/client"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// InterceptorBuilder 授权拦截器构造器。
+//
+// 要求请求必须携带业务 id，并按方法校验 token 的授权范围。
+// 业务 id 与授权范围从 context.Context 中获取，所以需要放在 jwt 拦截器之后。
+type InterceptorBuilder struct {
+	// key 为方法全名前缀，例如 "/notification.v1.NotificationService/"
+	scopes map[string]auth.Scope
+}
+
+func Builder() *InterceptorBuilder {
+	return &InterceptorBuilder{
+		scopes: make(map[string]auth.Scope),
+	}
+}
+
+// Service 设置服务下所有方法需要的授权范围
+func (b *InterceptorBuilder) Service(serviceName string, scope auth.Scope) *InterceptorBuilder {
+	b.scopes["/"+serviceName+"/"] = scope
+	return b
+}
+
+// Method 设置单个方法需要的授权范围，优先级高于 Service
+func (b *InterceptorBuilder) Method(fullMethod string, scope auth.Scope) *InterceptorBuilder {
+	b.scopes[fullMethod] = scope
+	return b
+}
+
+// Build 实际创建 grpc.UnaryServerInterceptor
+func (b *InterceptorBuilder) Build() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		if err = b.authorize(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// BuildStream 实际创建 grpc.StreamServerInterceptor
+func (b *InterceptorBuilder) BuildStream() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := b.authorize(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+func (b *InterceptorBuilder) authorize(ctx context.Context, fullMethod string) error {
+	if _, ok := client.BizIdFromContext(ctx); !ok {
+		return status.Error(codes.PermissionDenied, "missing biz id in token")
+	}
+
+	scope := b.requiredScope(fullMethod)
+	if !auth.HasScope(ctx, scope) {
+		return status.Errorf(codes.PermissionDenied, "scope %q required", scope)
+	}
+	return nil
+}
+
+// requiredScope 按最长前缀匹配方法需要的授权范围，未配置的方法需要 admin
+func (b *InterceptorBuilder) requiredScope(fullMethod string) auth.Scope {
+	scope := auth.ScopeAdmin
+	matched := 0
+	for prefix, s := range b.scopes {
+		if strings.HasPrefix(fullMethod, prefix) && len(prefix) > matched {
+			scope, matched = s, len(prefix)
+		}
+	}
+	return scope
+}
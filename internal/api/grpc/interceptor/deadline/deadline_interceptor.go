@@ -0,0 +1,66 @@
+package deadline
+
+import (
+	"context"
+	"time"
+
+	grpcpkg "github.com/JrMarcco/jotify/internal/pkg/grpc"
+	"google.golang.org/grpc"
+)
+
+// InterceptorBuilder 请求超时拦截器构造器。
+//
+// 客户端未设置超时或超时大于方法允许的最大超时时，使用方法的最大超时，避免慢请求长期占用资源。
+// 方法未单独配置时使用默认超时，默认超时为 0 时不做限制。
+type InterceptorBuilder struct {
+	defaultTimeout time.Duration
+	// key 为方法全名，例如 "/notification.v1.NotificationService/Send"
+	methodTimeouts map[string]time.Duration
+}
+
+func Builder(defaultTimeout time.Duration) *InterceptorBuilder {
+	return &InterceptorBuilder{
+		defaultTimeout: defaultTimeout,
+		methodTimeouts: make(map[string]time.Duration),
+	}
+}
+
+// Method 设置单个方法的最大超时，0 表示不做限制
+func (b *InterceptorBuilder) Method(fullMethod string, timeout time.Duration) *InterceptorBuilder {
+	b.methodTimeouts[fullMethod] = timeout
+	return b
+}
+
+// Build 实际创建 grpc.UnaryServerInterceptor
+func (b *InterceptorBuilder) Build() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		ctx, cancel := b.withDeadline(ctx, info.FullMethod)
+		defer cancel()
+		return handler(ctx, req)
+	}
+}
+
+// BuildStream 实际创建 grpc.StreamServerInterceptor，超时针对整个流
+func (b *InterceptorBuilder) BuildStream() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, cancel := b.withDeadline(ss.Context(), info.FullMethod)
+		defer cancel()
+		return handler(srv, grpcpkg.WrapServerStream(ss, ctx))
+	}
+}
+
+func (b *InterceptorBuilder) withDeadline(ctx context.Context, fullMethod string) (context.Context, context.CancelFunc) {
+	timeout, ok := b.methodTimeouts[fullMethod]
+	if !ok {
+		timeout = b.defaultTimeout
+	}
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+
+	// 客户端设置的超时更短时保持不变
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= timeout {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
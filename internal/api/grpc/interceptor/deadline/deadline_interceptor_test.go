@@ -0,0 +1,75 @@
+package deadline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+func TestDeadline_Build(t *testing.T) {
+	const method = "/test.v1.TestService/Slow"
+	interceptor := Builder(time.Second).
+		Method(method, 10*time.Second).
+		Method("/test.v1.TestService/Unlimited", 0).
+		Build()
+
+	tcs := []struct {
+		name          string
+		method        string
+		clientTimeout time.Duration
+		wantDeadline  bool
+		wantTimeout   time.Duration
+	}{
+		{
+			name:         "default timeout",
+			method:       "/test.v1.TestService/Fast",
+			wantDeadline: true,
+			wantTimeout:  time.Second,
+		}, {
+			name:         "method timeout",
+			method:       method,
+			wantDeadline: true,
+			wantTimeout:  10 * time.Second,
+		}, {
+			name:          "shorter client timeout",
+			method:        method,
+			clientTimeout: 100 * time.Millisecond,
+			wantDeadline:  true,
+			wantTimeout:   100 * time.Millisecond,
+		}, {
+			name:          "longer client timeout",
+			method:        "/test.v1.TestService/Fast",
+			clientTimeout: time.Minute,
+			wantDeadline:  true,
+			wantTimeout:   time.Second,
+		}, {
+			name:         "unlimited",
+			method:       "/test.v1.TestService/Unlimited",
+			wantDeadline: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.clientTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tc.clientTimeout)
+				defer cancel()
+			}
+
+			_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tc.method}, func(ctx context.Context, req any) (any, error) {
+				deadline, ok := ctx.Deadline()
+				assert.Equal(t, tc.wantDeadline, ok)
+				if ok {
+					assert.InDelta(t, tc.wantTimeout, time.Until(deadline), float64(50*time.Millisecond))
+				}
+				return nil, nil
+			})
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package jwt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.uber.org/zap"
+)
+
+// etcd 中的 key 布局（均以 prefix 开头）：
+//
+//	signing_kid           当前签名密钥的 kid
+//	keys/<kid>            密钥，值为 {"private": "<pem>", "public": "<pem>"}，只用于验证的密钥不需要 private
+//	revoked/jti/<jti>     吊销的 token，建议绑定与 token 剩余有效期一致的 lease 以自动清理
+//	revoked/biz/<biz_id>  吊销业务方的全部 token
+const (
+	etcdKeySigningKid    = "signing_kid"
+	etcdKeyKeysPrefix    = "keys/"
+	etcdKeyRevokedJti    = "revoked/jti/"
+	etcdKeyRevokedBizId  = "revoked/biz/"
+	defaultEtcdKeyPrefix = "/jotify/jwt/"
+)
+
+// EtcdKeyValue etcd 中密钥的存储格式
+type EtcdKeyValue struct {
+	Private string `json:"private,omitempty"`
+	Public  string `json:"public"`
+}
+
+// EtcdSyncer 从 etcd 加载并监听密钥环与吊销列表。
+//
+// etcd 中没有任何密钥时使用 fallback（配置文件中的密钥对），保证未迁移到 etcd 前的部署可以正常工作。
+type EtcdSyncer struct {
+	client *clientv3.Client
+	prefix string
+
+	keyRing    *KeyRing
+	revocation *RevocationList
+
+	fallbackKid string
+	fallbackKey Key
+
+	logger *zap.Logger
+}
+
+// Load 从 etcd 全量加载密钥与吊销列表
+func (s *EtcdSyncer) Load(ctx context.Context) error {
+	resp, err := s.client.Get(ctx, s.prefix, clientv3.WithPrefix())
+	if err != nil {
+		return err
+	}
+
+	signingKid := ""
+	keys := make(map[string]Key)
+	var jtis []string
+	var bizIds []uint64
+
+	for _, kv := range resp.Kvs {
+		key := strings.TrimPrefix(string(kv.Key), s.prefix)
+		switch {
+		case key == etcdKeySigningKid:
+			signingKid = string(kv.Value)
+		case strings.HasPrefix(key, etcdKeyKeysPrefix):
+			kid := strings.TrimPrefix(key, etcdKeyKeysPrefix)
+			k, err := parseEtcdKey(kv.Value)
+			if err != nil {
+				return fmt.Errorf("failed to parse key %q: %w", kid, err)
+			}
+			keys[kid] = k
+		case strings.HasPrefix(key, etcdKeyRevokedJti):
+			jtis = append(jtis, strings.TrimPrefix(key, etcdKeyRevokedJti))
+		case strings.HasPrefix(key, etcdKeyRevokedBizId):
+			bizId, err := strconv.ParseUint(strings.TrimPrefix(key, etcdKeyRevokedBizId), 10, 64)
+			if err != nil {
+				s.logger.Warn("[jotify] invalid revoked biz id in etcd", zap.String("key", string(kv.Key)))
+				continue
+			}
+			bizIds = append(bizIds, bizId)
+		}
+	}
+
+	if len(keys) == 0 {
+		keys[s.fallbackKid] = s.fallbackKey
+		signingKid = s.fallbackKid
+	}
+
+	// 先校验密钥环，密钥环配置错误时不更新吊销列表，保持上一次的有效状态
+	if err = s.keyRing.Reset(signingKid, keys); err != nil {
+		return err
+	}
+	s.revocation.Reset(jtis, bizIds)
+	return nil
+}
+
+// Watch 监听 etcd 变更，任意变更都会触发全量重新加载
+func (s *EtcdSyncer) Watch(ctx context.Context) {
+	watchChan := s.client.Watch(clientv3.WithRequireLeader(ctx), s.prefix, clientv3.WithPrefix())
+	for watchResp := range watchChan {
+		if watchResp.Err() != nil {
+			s.logger.Error("[jotify] jwt key watch error", zap.Error(watchResp.Err()))
+			continue
+		}
+
+		if err := s.Load(ctx); err != nil {
+			s.logger.Error("[jotify] failed to reload jwt keys from etcd", zap.Error(err))
+		}
+	}
+}
+
+func parseEtcdKey(val []byte) (Key, error) {
+	var ev EtcdKeyValue
+	if err := json.Unmarshal(val, &ev); err != nil {
+		return Key{}, err
+	}
+
+	pubKey, err := ParsePublicKey(ev.Public)
+	if err != nil {
+		return Key{}, err
+	}
+
+	k := Key{PubKey: pubKey}
+	if ev.Private != "" {
+		if k.PriKey, err = ParsePrivateKey(ev.Private); err != nil {
+			return Key{}, err
+		}
+	}
+	return k, nil
+}
+
+func (s *EtcdSyncer) KeyRing() *KeyRing {
+	return s.keyRing
+}
+
+func (s *EtcdSyncer) Revocation() *RevocationList {
+	return s.revocation
+}
+
+// EtcdKeyOfKey 密钥在 etcd 中的完整 key
+func (s *EtcdSyncer) EtcdKeyOfKey(kid string) string {
+	return s.prefix + etcdKeyKeysPrefix + kid
+}
+
+// EtcdKeyOfSigningKid 签名密钥 kid 在 etcd 中的完整 key
+func (s *EtcdSyncer) EtcdKeyOfSigningKid() string {
+	return s.prefix + etcdKeySigningKid
+}
+
+// EtcdKeyOfRevokedJti 吊销 jti 在 etcd 中的完整 key
+func (s *EtcdSyncer) EtcdKeyOfRevokedJti(jti string) string {
+	return s.prefix + etcdKeyRevokedJti + jti
+}
+
+// EtcdKeyOfRevokedBizId 吊销业务 id 在 etcd 中的完整 key
+func (s *EtcdSyncer) EtcdKeyOfRevokedBizId(bizId uint64) string {
+	return s.prefix + etcdKeyRevokedBizId + strconv.FormatUint(bizId, 10)
+}
+
+func NewEtcdSyncer(
+	client *clientv3.Client,
+	prefix string,
+	keyRing *KeyRing,
+	revocation *RevocationList,
+	fallbackKid string,
+	fallbackKey Key,
+	logger *zap.Logger,
+) *EtcdSyncer {
+	if prefix == "" {
+		prefix = defaultEtcdKeyPrefix
+	}
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	return &EtcdSyncer{
+		client:      client,
+		prefix:      prefix,
+		keyRing:     keyRing,
+		revocation:  revocation,
+		fallbackKid: fallbackKid,
+		fallbackKey: fallbackKey,
+		logger:      logger,
+	}
+}
@@ -3,13 +3,18 @@ package jwt
 import (
 	"context"
 	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"maps"
 	"strings"
 	"time"
 
+	"github.com/JrMarcco/jotify/internal/errs"
+	"github.com/JrMarcco/jotify/internal/pkg/auth"
 	"github.com/JrMarcco/jotify/internal/pkg/client"
+	grpcpkg "github.com/JrMarcco/jotify/internal/pkg/grpc"
 	"github.com/golang-jwt/jwt/v5"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -20,23 +25,44 @@ import (
 const (
 	paramNameBizId  = "biz_id"
 	paramNameBizKey = "biz_key"
+	paramNameJti    = "jti"
+	paramNameScopes = "scopes"
+
+	headerKid = "kid"
 )
 
 // InterceptorBuilder Interceptor 构造器，
 type InterceptorBuilder struct {
-	priKey ed25519.PrivateKey // 私钥
-	pubKey ed25519.PublicKey  // 公钥
+	keyRing    *KeyRing        // 密钥环
+	revocation *RevocationList // 吊销列表，为 nil 时不检查
 }
 
 // Builder 创建构造器，这里使用 ed25519 密钥对进行加解密
+//
+// 只使用一对密钥，签发的 token 不带 kid。
 func Builder(privateKey ed25519.PrivateKey, publicKey ed25519.PublicKey) *InterceptorBuilder {
+	kr := NewKeyRing("")
+	// 签名密钥一定存在，不会返回错误
+	_ = kr.Reset("", map[string]Key{"": {PriKey: privateKey, PubKey: publicKey}})
+	return NewBuilder(kr)
+}
+
+// NewBuilder 基于密钥环创建构造器，密钥环中的密钥可以在运行时轮换
+func NewBuilder(keyRing *KeyRing) *InterceptorBuilder {
 	return &InterceptorBuilder{
-		priKey: privateKey,
-		pubKey: publicKey,
+		keyRing: keyRing,
 	}
 }
 
+// Revocation 设置吊销列表
+func (b *InterceptorBuilder) Revocation(rl *RevocationList) *InterceptorBuilder {
+	b.revocation = rl
+	return b
+}
+
 // Encode 编码 jwt token
+//
+// 使用密钥环当前的签名密钥签名并在 header 中写入 kid，未指定 jti 时生成随机 jti 用于吊销。
 func (b *InterceptorBuilder) Encode(customClaims jwt.MapClaims) (string, error) {
 	claims := jwt.MapClaims{
 		"iat": time.Now().Unix(),
@@ -48,12 +74,29 @@ func (b *InterceptorBuilder) Encode(customClaims jwt.MapClaims) (string, error)
 	if _, ok := claims["exp"]; !ok {
 		claims["exp"] = time.Now().Add(24 * time.Hour).Unix()
 	}
+	if _, ok := claims[paramNameJti]; !ok {
+		jti, err := newJti()
+		if err != nil {
+			return "", err
+		}
+		claims[paramNameJti] = jti
+	}
+
+	kid, priKey, err := b.keyRing.SigningKey()
+	if err != nil {
+		return "", err
+	}
 
 	token := jwt.NewWithClaims(&jwt.SigningMethodEd25519{}, claims)
-	return token.SignedString(b.priKey)
+	if kid != "" {
+		token.Header[headerKid] = kid
+	}
+	return token.SignedString(priKey)
 }
 
 // Decode 解码 jwt token
+//
+// 根据 header 中的 kid 从密钥环选择验证公钥，验证通过后检查吊销列表。
 func (b *InterceptorBuilder) Decode(tokenStr string) (jwt.MapClaims, error) {
 	tokenStr = strings.TrimPrefix(tokenStr, "Bearer ")
 
@@ -61,55 +104,111 @@ func (b *InterceptorBuilder) Decode(tokenStr string) (jwt.MapClaims, error) {
 		if _, ok := t.Method.(*jwt.SigningMethodEd25519); !ok {
 			return nil, fmt.Errorf("unsupport sign algorithm: %v", t.Header["alg"])
 		}
-		return b.pubKey, nil
+
+		kid, _ := t.Header[headerKid].(string)
+		pubKey, ok := b.keyRing.PublicKey(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown key id: %q", kid)
+		}
+		return pubKey, nil
 	})
 
 	if err != nil {
 		return nil, fmt.Errorf("fail to decode token: %w", err)
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		return claims, nil
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("invalid token")
 	}
 
-	return nil, fmt.Errorf("invalid token")
+	if b.revocation != nil && b.revocation.IsRevoked(claims) {
+		return nil, fmt.Errorf("%w", errs.ErrTokenRevoked)
+	}
+	return claims, nil
 }
 
 // Build 实际创建 grpc.UnaryServerInterceptor
 func (b *InterceptorBuilder) Build() grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
-		md, ok := metadata.FromIncomingContext(ctx)
-		if !ok {
-			return nil, status.Error(codes.Unauthenticated, "missing metadata")
-		}
-
-		authHeaders := md.Get("Authorization")
-		if len(authHeaders) == 0 {
-			return nil, status.Error(codes.Unauthenticated, "missing authorization token")
+		ctx, err = b.authenticate(ctx)
+		if err != nil {
+			return nil, err
 		}
-		tokenStr := authHeaders[0]
+		return handler(ctx, req)
+	}
+}
 
-		mc, err := b.Decode(tokenStr)
+// BuildStream 实际创建 grpc.StreamServerInterceptor
+func (b *InterceptorBuilder) BuildStream() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := b.authenticate(ss.Context())
 		if err != nil {
-			if errors.Is(err, jwt.ErrTokenExpired) {
-				return nil, status.Error(codes.Unauthenticated, "token expired")
-			}
-			if errors.Is(err, jwt.ErrTokenSignatureInvalid) {
-				return nil, status.Error(codes.Unauthenticated, "invalid signature")
-			}
-			return nil, status.Errorf(codes.Unauthenticated, "invalid token: %s", err.Error())
+			return err
 		}
+		return handler(srv, grpcpkg.WrapServerStream(ss, ctx))
+	}
+}
+
+// authenticate 从 metadata 中获取并解码 token，返回写入了 claims 的 context
+func (b *InterceptorBuilder) authenticate(ctx context.Context) (context.Context, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "missing metadata")
+	}
 
-		if val, ok := mc[paramNameBizId]; ok {
-			// 设置业务 id 到 context
-			bizId := uint64(val.(float64))
-			ctx = client.WithBizId(ctx, bizId)
+	authHeaders := md.Get("Authorization")
+	if len(authHeaders) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "missing authorization token")
+	}
+	tokenStr := authHeaders[0]
+
+	mc, err := b.Decode(tokenStr)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, status.Error(codes.Unauthenticated, "token expired")
 		}
-		if val, ok := mc[paramNameBizKey]; ok {
-			bizKey := val.(string)
-			ctx = client.WithBizKey(ctx, bizKey)
+		if errors.Is(err, jwt.ErrTokenSignatureInvalid) {
+			return nil, status.Error(codes.Unauthenticated, "invalid signature")
+		}
+		if errors.Is(err, errs.ErrTokenRevoked) {
+			return nil, status.Error(codes.Unauthenticated, "token revoked")
+		}
+		return nil, status.Errorf(codes.Unauthenticated, "invalid token: %s", err.Error())
+	}
+
+	return ContextWithClaims(ctx, mc), nil
+}
+
+// newJti 生成随机 jti
+func newJti() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// ContextWithClaims 将 jwt claims 中的业务 id、业务 key 以及授权范围写入 context
+//
+// 不带 scopes 声明的 token 使用 auth.DefaultScopes。
+func ContextWithClaims(ctx context.Context, mc jwt.MapClaims) context.Context {
+	if val, ok := mc[paramNameBizId].(float64); ok {
+		// 设置业务 id 到 context
+		ctx = client.WithBizId(ctx, uint64(val))
+	}
+	if val, ok := mc[paramNameBizKey].(string); ok {
+		ctx = client.WithBizKey(ctx, val)
+	}
+
+	scopes := auth.DefaultScopes
+	if val, ok := mc[paramNameScopes].([]any); ok {
+		scopes = make([]auth.Scope, 0, len(val))
+		for _, s := range val {
+			if str, ok := s.(string); ok {
+				scopes = append(scopes, auth.Scope(str))
+			}
 		}
-		
-		return handler(ctx, req)
 	}
+	return auth.WithScopes(ctx, scopes)
 }
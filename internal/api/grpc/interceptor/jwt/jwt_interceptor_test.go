@@ -1,6 +1,7 @@
 package jwt
 
 import (
+	"context"
 	"crypto/ed25519"
 	"crypto/x509"
 	"encoding/pem"
@@ -8,6 +9,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/JrMarcco/jotify/internal/errs"
+	"github.com/JrMarcco/jotify/internal/pkg/auth"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
 )
@@ -144,3 +147,128 @@ func loadKeypair() (ed25519.PrivateKey, ed25519.PublicKey) {
 
 	return priKey.(ed25519.PrivateKey), publicKey.(ed25519.PublicKey)
 }
+
+func TestJwtAuth_KeyRotation(t *testing.T) {
+	oldPriKey, oldPubKey := loadKeypair()
+	newPubKey, newPriKey, err := ed25519.GenerateKey(nil)
+	assert.NoError(t, err)
+
+	kr := NewKeyRing("k0")
+	err = kr.Reset("k0", map[string]Key{"k0": {PriKey: oldPriKey, PubKey: oldPubKey}})
+	assert.NoError(t, err)
+
+	jwtAuth := NewBuilder(kr)
+	oldToken, err := jwtAuth.Encode(jwt.MapClaims{})
+	assert.NoError(t, err)
+	// 不带 kid 的 token 使用 legacy kid 验证
+	legacyToken, err := Builder(oldPriKey, oldPubKey).Encode(jwt.MapClaims{})
+	assert.NoError(t, err)
+
+	// 发布新签名密钥，旧密钥只用于验证
+	err = kr.Reset("k1", map[string]Key{
+		"k0": {PubKey: oldPubKey},
+		"k1": {PriKey: newPriKey, PubKey: newPubKey},
+	})
+	assert.NoError(t, err)
+
+	newToken, err := jwtAuth.Encode(jwt.MapClaims{})
+	assert.NoError(t, err)
+
+	for _, token := range []string{oldToken, legacyToken, newToken} {
+		_, err = jwtAuth.Decode(token)
+		assert.NoError(t, err)
+	}
+
+	// 移除旧密钥
+	err = kr.Reset("k1", map[string]Key{"k1": {PriKey: newPriKey, PubKey: newPubKey}})
+	assert.NoError(t, err)
+
+	_, err = jwtAuth.Decode(oldToken)
+	assert.Error(t, err)
+	_, err = jwtAuth.Decode(legacyToken)
+	assert.Error(t, err)
+	_, err = jwtAuth.Decode(newToken)
+	assert.NoError(t, err)
+
+	// 签名密钥必须带私钥
+	err = kr.Reset("k1", map[string]Key{"k1": {PubKey: newPubKey}})
+	assert.Error(t, err)
+}
+
+func TestJwtAuth_Revocation(t *testing.T) {
+	priKey, pubKey := loadKeypair()
+	rl := NewRevocationList()
+	jwtAuth := Builder(priKey, pubKey).Revocation(rl)
+
+	tcs := []struct {
+		name    string
+		claims  jwt.MapClaims
+		jtis    []string
+		bizIds  []uint64
+		wantErr error
+	}{
+		{
+			name:    "not revoked",
+			claims:  jwt.MapClaims{paramNameJti: "jti-1", paramNameBizId: float64(1)},
+			jtis:    []string{"jti-2"},
+			bizIds:  []uint64{2},
+			wantErr: nil,
+		}, {
+			name:    "revoked by jti",
+			claims:  jwt.MapClaims{paramNameJti: "jti-1", paramNameBizId: float64(1)},
+			jtis:    []string{"jti-1"},
+			wantErr: errs.ErrTokenRevoked,
+		}, {
+			name:    "revoked by biz id",
+			claims:  jwt.MapClaims{paramNameBizId: float64(1)},
+			bizIds:  []uint64{1},
+			wantErr: errs.ErrTokenRevoked,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			token, err := jwtAuth.Encode(tc.claims)
+			assert.NoError(t, err)
+
+			rl.Reset(tc.jtis, tc.bizIds)
+			_, err = jwtAuth.Decode(token)
+			assert.ErrorIs(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestContextWithClaims_Scopes(t *testing.T) {
+	priKey, pubKey := loadKeypair()
+	jwtAuth := Builder(priKey, pubKey)
+
+	tcs := []struct {
+		name      string
+		claims    jwt.MapClaims
+		wantScope []auth.Scope
+	}{
+		{
+			name:      "default scopes",
+			claims:    jwt.MapClaims{paramNameBizId: float64(1)},
+			wantScope: auth.DefaultScopes,
+		}, {
+			name:      "query only",
+			claims:    jwt.MapClaims{paramNameBizId: float64(1), paramNameScopes: []string{"query"}},
+			wantScope: []auth.Scope{auth.ScopeQuery},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			token, err := jwtAuth.Encode(tc.claims)
+			assert.NoError(t, err)
+
+			mc, err := jwtAuth.Decode(token)
+			assert.NoError(t, err)
+
+			scopes, ok := auth.ScopesFromContext(ContextWithClaims(context.Background(), mc))
+			assert.True(t, ok)
+			assert.Equal(t, tc.wantScope, scopes)
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Key ed25519 密钥对，只用于验证的密钥 PriKey 为 nil
+type Key struct {
+	PriKey ed25519.PrivateKey
+	PubKey ed25519.PublicKey
+}
+
+// KeyRing 以 kid 区分的密钥环。
+//
+// 同一时刻只有一个签名密钥，其余密钥只用于验证。
+// 轮换密钥时先发布新的签名密钥，旧密钥保留一段时间用于验证已签发的 token，之后再移除。
+type KeyRing struct {
+	mu sync.RWMutex
+
+	legacyKid  string // 不带 kid 的 token（密钥环引入之前签发）使用的 kid
+	signingKid string
+	keys       map[string]Key
+}
+
+// SigningKey 获取当前签名密钥
+func (kr *KeyRing) SigningKey() (string, ed25519.PrivateKey, error) {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+
+	key, ok := kr.keys[kr.signingKid]
+	if !ok || key.PriKey == nil {
+		return "", nil, fmt.Errorf("signing key not found, kid = %q", kr.signingKid)
+	}
+	return kr.signingKid, key.PriKey, nil
+}
+
+// PublicKey 根据 kid 获取验证公钥，kid 为空时使用 legacyKid
+func (kr *KeyRing) PublicKey(kid string) (ed25519.PublicKey, bool) {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+
+	if kid == "" {
+		kid = kr.legacyKid
+	}
+	key, ok := kr.keys[kid]
+	if !ok {
+		return nil, false
+	}
+	return key.PubKey, true
+}
+
+// Reset 整体替换密钥环中的密钥
+func (kr *KeyRing) Reset(signingKid string, keys map[string]Key) error {
+	key, ok := keys[signingKid]
+	if !ok || key.PriKey == nil {
+		return fmt.Errorf("signing key not found or without private key, kid = %q", signingKid)
+	}
+
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+
+	kr.signingKid = signingKid
+	kr.keys = keys
+	return nil
+}
+
+func NewKeyRing(legacyKid string) *KeyRing {
+	return &KeyRing{
+		legacyKid: legacyKid,
+		keys:      make(map[string]Key),
+	}
+}
+
+// RevocationList token 吊销列表，支持按 jti 吊销单个 token 或按业务 id 吊销该业务方的全部 token
+type RevocationList struct {
+	mu sync.RWMutex
+
+	jtis   map[string]struct{}
+	bizIds map[uint64]struct{}
+}
+
+// IsRevoked 判断 token 是否已被吊销
+func (rl *RevocationList) IsRevoked(mc jwt.MapClaims) bool {
+	rl.mu.RLock()
+	defer rl.mu.RUnlock()
+
+	if jti, ok := mc[paramNameJti].(string); ok {
+		if _, revoked := rl.jtis[jti]; revoked {
+			return true
+		}
+	}
+	if bizId, ok := mc[paramNameBizId].(float64); ok {
+		if _, revoked := rl.bizIds[uint64(bizId)]; revoked {
+			return true
+		}
+	}
+	return false
+}
+
+// Reset 整体替换吊销列表
+func (rl *RevocationList) Reset(jtis []string, bizIds []uint64) {
+	jtiSet := make(map[string]struct{}, len(jtis))
+	for _, jti := range jtis {
+		jtiSet[jti] = struct{}{}
+	}
+	bizIdSet := make(map[uint64]struct{}, len(bizIds))
+	for _, bizId := range bizIds {
+		bizIdSet[bizId] = struct{}{}
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	rl.jtis = jtiSet
+	rl.bizIds = bizIdSet
+}
+
+func NewRevocationList() *RevocationList {
+	return &RevocationList{
+		jtis:   make(map[string]struct{}),
+		bizIds: make(map[uint64]struct{}),
+	}
+}
+
+// ParsePrivateKey 解析 PKCS8 格式的 ed25519 私钥。
+//
+// PEM 块本身标注的是密钥对，而不是具体的 ed25519 密钥对。
+// 所有标准公钥格式都需要先由 x509 包处理进行转换后类型断言才能获得 ed25519 密钥对。
+func ParsePrivateKey(priPem string) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(priPem))
+	if block == nil {
+		return nil, errors.New("failed to decode private key PEM")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	priKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key is not ed25519")
+	}
+	return priKey, nil
+}
+
+// ParsePublicKey 解析 PKIX 格式的 ed25519 公钥
+func ParsePublicKey(pubPem string) (ed25519.PublicKey, error) {
+	block, _ := pem.Decode([]byte(pubPem))
+	if block == nil {
+		return nil, errors.New("failed to decode public key PEM")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	pubKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, errors.New("public key is not ed25519")
+	}
+	return pubKey, nil
+}
@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/JrMarcco/jotify/internal/pkg/client"
+	grpcpkg "github.com/JrMarcco/jotify/internal/pkg/grpc"
+	metricspkg "github.com/JrMarcco/jotify/internal/pkg/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// InterceptorBuilder 监控指标拦截器构造器。
+//
+// 放在 jwt 拦截器之前，认证失败的请求同样会统计；业务 id 由 jwt 拦截器通过 client.BizIdRecorder 回传，认证失败时为 unknown。
+type InterceptorBuilder struct{}
+
+func Builder() *InterceptorBuilder {
+	return &InterceptorBuilder{}
+}
+
+// Build 实际创建 grpc.UnaryServerInterceptor
+func (b *InterceptorBuilder) Build() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		start := time.Now()
+		ctx, recorder := client.WithBizIdRecorder(ctx)
+		defer func() {
+			observe(recorder, info.FullMethod, start, err)
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+// BuildStream 实际创建 grpc.StreamServerInterceptor，耗时为整个流的持续时间
+func (b *InterceptorBuilder) BuildStream() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		start := time.Now()
+		ctx, recorder := client.WithBizIdRecorder(ss.Context())
+		defer func() {
+			observe(recorder, info.FullMethod, start, err)
+		}()
+
+		return handler(srv, grpcpkg.WrapServerStream(ss, ctx))
+	}
+}
+
+func observe(recorder *client.BizIdRecorder, fullMethod string, start time.Time, err error) {
+	bizId := "unknown"
+	if val, ok := recorder.Load(); ok {
+		bizId = strconv.FormatUint(val, 10)
+	}
+
+	metricspkg.GrpcRequestTotal.WithLabelValues(fullMethod, bizId, status.Code(err).String()).Inc()
+	metricspkg.GrpcRequestDuration.WithLabelValues(fullMethod, bizId).Observe(time.Since(start).Seconds())
+}
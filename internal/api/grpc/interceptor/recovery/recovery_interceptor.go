@@ -0,0 +1,59 @@
+package recovery
+
+import (
+	"context"
+	"runtime/debug"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// InterceptorBuilder panic 恢复拦截器构造器。
+//
+// 捕获处理请求时发生的 panic，记录堆栈后返回 INTERNAL，避免单个请求导致整个进程退出。
+// 需要放在拦截器链的最外层，以便捕获其余拦截器中发生的 panic。
+type InterceptorBuilder struct {
+	logger *zap.Logger
+}
+
+func Builder(logger *zap.Logger) *InterceptorBuilder {
+	return &InterceptorBuilder{
+		logger: logger,
+	}
+}
+
+// Build 实际创建 grpc.UnaryServerInterceptor
+func (b *InterceptorBuilder) Build() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = b.recovered(info.FullMethod, r)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// BuildStream 实际创建 grpc.StreamServerInterceptor
+func (b *InterceptorBuilder) BuildStream() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = b.recovered(info.FullMethod, r)
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+func (b *InterceptorBuilder) recovered(fullMethod string, r any) error {
+	b.logger.Error(
+		"[jotify] panic recovered in grpc handler",
+		zap.String("method", fullMethod),
+		zap.Any("panic", r),
+		zap.ByteString("stack", debug.Stack()),
+	)
+	return status.Error(codes.Internal, "internal server error")
+}
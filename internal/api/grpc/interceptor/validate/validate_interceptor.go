@@ -0,0 +1,62 @@
+package validate
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// validator 请求校验接口，与 protoc-gen-validate 生成的方法一致
+type validator interface {
+	Validate() error
+}
+
+// InterceptorBuilder 请求校验拦截器构造器。
+//
+// 请求实现了 Validate() error 时调用校验，校验失败返回 INVALID_ARGUMENT，未实现的请求直接放行。
+type InterceptorBuilder struct{}
+
+func Builder() *InterceptorBuilder {
+	return &InterceptorBuilder{}
+}
+
+// Build 实际创建 grpc.UnaryServerInterceptor
+func (b *InterceptorBuilder) Build() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		if err = validate(req); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// BuildStream 实际创建 grpc.StreamServerInterceptor，对流中接收到的每条消息进行校验
+func (b *InterceptorBuilder) BuildStream() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &serverStream{ServerStream: ss})
+	}
+}
+
+type serverStream struct {
+	grpc.ServerStream
+}
+
+func (s *serverStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return validate(m)
+}
+
+func validate(req any) error {
+	v, ok := req.(validator)
+	if !ok {
+		return nil
+	}
+	if err := v.Validate(); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return nil
+}
@@ -1,14 +1,145 @@
 package grpc
 
 import (
+	"context"
+
 	notificationv1 "github.com/JrMarcco/jotify-api/api/notification/v1"
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/pkg/client"
+	"github.com/JrMarcco/jotify/internal/service/notification"
+	"go.uber.org/zap"
 )
 
+// NotificationServer 消息发送与查询的 grpc 服务。
+//
+// 业务 id 取自 jwt token，租户授权由 notification 包中的 Authz* 装饰器完成。
 type NotificationServer struct {
 	notificationv1.UnimplementedNotificationServiceServer
 	notificationv1.UnimplementedNotificationQueryServiceServer
+
+	sendSvc   notification.SendService
+	querySvc  notification.QueryService
+	cancelSvc notification.CancelService
+
+	logger *zap.Logger
+}
+
+func (s *NotificationServer) Send(ctx context.Context, req *notificationv1.SendRequest) (*notificationv1.SendResponse, error) {
+	n, err := toDomainNotification(ctx, req.Notification)
+	if err != nil {
+		return nil, s.toStatusErr(err)
+	}
+
+	resp, err := s.sendSvc.Send(ctx, n)
+	if err != nil {
+		return nil, s.toStatusErr(err)
+	}
+	return &notificationv1.SendResponse{Result: toApiSendResult(resp.Result)}, nil
+}
+
+func (s *NotificationServer) AsyncSend(ctx context.Context, req *notificationv1.SendRequest) (*notificationv1.SendResponse, error) {
+	n, err := toDomainNotification(ctx, req.Notification)
+	if err != nil {
+		return nil, s.toStatusErr(err)
+	}
+
+	resp, err := s.sendSvc.AsyncSend(ctx, n)
+	if err != nil {
+		return nil, s.toStatusErr(err)
+	}
+	return &notificationv1.SendResponse{Result: toApiSendResult(resp.Result)}, nil
+}
+
+func (s *NotificationServer) BatchSend(
+	ctx context.Context, req *notificationv1.BatchSendRequest,
+) (*notificationv1.BatchSendResponse, error) {
+	ns, err := toDomainNotifications(ctx, req.Notifications)
+	if err != nil {
+		return nil, s.toStatusErr(err)
+	}
+
+	resp, err := s.sendSvc.BatchSend(ctx, ns)
+	if err != nil {
+		return nil, s.toStatusErr(err)
+	}
+
+	results := make([]*notificationv1.SendResult, 0, len(resp.Results))
+	for _, res := range resp.Results {
+		results = append(results, toApiSendResult(res))
+	}
+	return &notificationv1.BatchSendResponse{Results: results}, nil
+}
+
+func (s *NotificationServer) BatchAsyncSend(
+	ctx context.Context, req *notificationv1.BatchSendRequest,
+) (*notificationv1.BatchAsyncSendResponse, error) {
+	ns, err := toDomainNotifications(ctx, req.Notifications)
+	if err != nil {
+		return nil, s.toStatusErr(err)
+	}
+
+	resp, err := s.sendSvc.BatchAsyncSend(ctx, ns)
+	if err != nil {
+		return nil, s.toStatusErr(err)
+	}
+	return &notificationv1.BatchAsyncSendResponse{NotificationIds: resp.NotificationIds}, nil
+}
+
+func (s *NotificationServer) Cancel(ctx context.Context, req *notificationv1.CancelRequest) (*notificationv1.CancelResponse, error) {
+	bizId, _ := client.BizIdFromContext(ctx)
+
+	if err := s.cancelSvc.Cancel(ctx, bizId, req.BizKey); err != nil {
+		return nil, s.toStatusErr(err)
+	}
+	return &notificationv1.CancelResponse{}, nil
+}
+
+func (s *NotificationServer) Query(ctx context.Context, req *notificationv1.QueryRequest) (*notificationv1.QueryResponse, error) {
+	bizId, _ := client.BizIdFromContext(ctx)
+
+	n, err := s.querySvc.GetByKey(ctx, bizId, req.BizKey)
+	if err != nil {
+		return nil, s.toStatusErr(err)
+	}
+	return &notificationv1.QueryResponse{Result: toApiSendResult(domain.SendResult{
+		NotificationId: n.Id,
+		Status:         n.Status,
+	})}, nil
+}
+
+// BatchQuery 按消息 id 批量查询，不存在的 id 不出现在结果中，结果顺序与请求一致
+func (s *NotificationServer) BatchQuery(
+	ctx context.Context, req *notificationv1.BatchQueryRequest,
+) (*notificationv1.BatchQueryResponse, error) {
+	bizId, _ := client.BizIdFromContext(ctx)
+
+	m, err := s.querySvc.BatchGetByIds(ctx, bizId, req.NotificationIds)
+	if err != nil {
+		return nil, s.toStatusErr(err)
+	}
+
+	results := make([]*notificationv1.SendResult, 0, len(m))
+	for _, id := range req.NotificationIds {
+		if n, ok := m[id]; ok {
+			results = append(results, toApiSendResult(domain.SendResult{
+				NotificationId: n.Id,
+				Status:         n.Status,
+			}))
+		}
+	}
+	return &notificationv1.BatchQueryResponse{Results: results}, nil
 }
 
-func NewNotificationServer() *NotificationServer {
-	return &NotificationServer{}
+func NewNotificationServer(
+	sendSvc notification.SendService,
+	querySvc notification.QueryService,
+	cancelSvc notification.CancelService,
+	logger *zap.Logger,
+) *NotificationServer {
+	return &NotificationServer{
+		sendSvc:   sendSvc,
+		querySvc:  querySvc,
+		cancelSvc: cancelSvc,
+		logger:    logger,
+	}
 }
@@ -1,5 +1,7 @@
 package domain
 
+import "strings"
+
 // ResourceType 资源类型
 type ResourceType string
 
@@ -32,3 +34,47 @@ type AuditContent struct {
 	Remark        string   `json:"remark"`
 	ProviderNames []string `json:"provider_names"`
 }
+
+// AuditStage 审核阶段
+type AuditStage string
+
+const (
+	AuditStageRule   AuditStage = "rule"   // 自动审核
+	AuditStageManual AuditStage = "manual" // 人工审核
+)
+
+func (s AuditStage) String() string {
+	return string(s)
+}
+
+// TplAudit 模板版本审核记录领域对象。
+//
+// 待审核（pending）的版本先经过自动审核，结果为 approved、rejected 或 in_preview（转人工审核）；
+// in_preview 的版本由审核人审核为 approved 或 rejected。每次审核都会记录一条审核记录。
+// 版本的语言变体与默认内容分别审核，审核记录通过 TplVariantId 区分。
+type TplAudit struct {
+	Id           uint64      `json:"id"`
+	TplId        uint64      `json:"tpl_id"`
+	TplVersionId uint64      `json:"tpl_version_id"`
+	TplVariantId uint64      `json:"tpl_variant_id"` // 审核语言变体时为变体 id，审核版本的默认内容时为 0
+	Stage        AuditStage  `json:"stage"`
+	Status       AuditStatus `json:"status"`
+	Reasons      []string    `json:"reasons"`    // 拒绝或转人工审核的原因
+	AuditorId    uint64      `json:"auditor_id"` // 自动审核为 0
+	CreatedAt    int64       `json:"created_at"`
+}
+
+// maxRejectReasonLen 模板版本 reject_reason 字段的长度上限
+const maxRejectReasonLen = 512
+
+// RejectReason 拒绝原因，保存在模板版本上，完整的原因见审核记录
+func (a TplAudit) RejectReason() string {
+	if a.Status != AuditStatusRejected {
+		return ""
+	}
+	reason := []rune(strings.Join(a.Reasons, "; "))
+	if len(reason) > maxRejectReasonLen {
+		reason = reason[:maxRejectReasonLen]
+	}
+	return string(reason)
+}
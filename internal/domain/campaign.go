@@ -0,0 +1,151 @@
+package domain
+
+import (
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/JrMarcco/jotify/internal/errs"
+)
+
+// CampaignStatus 群发活动状态
+type CampaignStatus string
+
+const (
+	CampaignStatusDraft     CampaignStatus = "draft"     // 已创建，可以上传接收者
+	CampaignStatusRunning   CampaignStatus = "running"   // 正在展开接收者并发送
+	CampaignStatusPaused    CampaignStatus = "paused"    // 暂停展开，已创建的消息照常发送
+	CampaignStatusCanceling CampaignStatus = "canceling" // 已取消，正在取消已创建的待发送消息
+	CampaignStatusCanceled  CampaignStatus = "canceled"
+	CampaignStatusFinished  CampaignStatus = "finished" // 全部接收者已展开，且消息都已结束
+)
+
+func (s CampaignStatus) String() string {
+	return string(s)
+}
+
+// Terminal 是否为终态，终态的活动不再统计进度
+func (s CampaignStatus) Terminal() bool {
+	return s == CampaignStatusCanceled || s == CampaignStatusFinished
+}
+
+// CampaignProgress 群发活动进度，均为接收者数
+type CampaignProgress struct {
+	Total      int64 `json:"total"`      // 已上传
+	Expanded   int64 `json:"expanded"`   // 已展开，即已处理的最大接收者序号
+	Accepted   int64 `json:"accepted"`   // 已创建消息
+	Suppressed int64 `json:"suppressed"` // 在退订名单中或达到频控上限，未创建消息
+	Skipped    int64 `json:"skipped"`    // 发送时间窗口结束时仍未展开，未创建消息
+	Sent       int64 `json:"sent"`
+	Failed     int64 `json:"failed"` // 发送失败或过期
+	Canceled   int64 `json:"canceled"`
+}
+
+// Apply 按已创建消息的状态统计更新发送进度，返回尚未结束的消息数
+func (p *CampaignProgress) Apply(counts map[SendStatus]int64) int64 {
+	p.Sent = counts[SendStatusSuccess]
+	p.Failed = counts[SendStatusFailure] + counts[SendStatusExpired]
+	p.Canceled = counts[SendStatusCancel]
+	return counts[SendStatusPrepare] + counts[SendStatusPending] + counts[SendStatusSending]
+}
+
+// Campaign 群发活动领域对象。
+//
+// 接收者分多次上传后开始发送，每个接收者展开为一条消息，BizKey 由活动的 BizKey 与接收者序号派生。
+// 模板参数为全部接收者的默认参数，上传接收者时可以按行覆盖。
+type Campaign struct {
+	Id             uint64           `json:"id"`
+	BizId          uint64           `json:"biz_id"`
+	BizKey         string           `json:"biz_key"`
+	Channel        Channel          `json:"channel"`
+	Template       Template         `json:"template"`
+	Priority       Priority         `json:"priority"`
+	StrategyConfig SendStrategyConf `json:"strategy_config"`
+	Status         CampaignStatus   `json:"status"`
+	Progress       CampaignProgress `json:"progress"`
+	Version        int32            `json:"version"`
+	CreatedAt      int64            `json:"created_at"`
+	UpdatedAt      int64            `json:"updated_at"`
+}
+
+func (c Campaign) Validate() error {
+	if c.BizId <= 0 {
+		return fmt.Errorf("%w: biz id should not be negative or zero", errs.ErrInvalidParam)
+	}
+
+	if c.BizKey == "" {
+		return fmt.Errorf("%w: biz key should not be empty", errs.ErrInvalidParam)
+	}
+
+	if !c.Channel.Validate() {
+		return fmt.Errorf("%w: invalid channel", errs.ErrInvalidParam)
+	}
+
+	if c.Template.Id <= 0 {
+		return fmt.Errorf("%w: template id should not be negative or zero", errs.ErrInvalidParam)
+	}
+
+	if !c.Priority.Validate() {
+		return fmt.Errorf("%w: invalid priority", errs.ErrInvalidParam)
+	}
+
+	if c.StrategyConfig.Type == SendStrategyRecurring {
+		return fmt.Errorf("%w: campaign does not support recurring strategy", errs.ErrInvalidParam)
+	}
+	return c.StrategyConfig.Validate()
+}
+
+// WindowClosed 发送时间窗口是否已在 now 之前结束，结束后未展开的接收者不再发送
+func (c Campaign) WindowClosed(now time.Time) bool {
+	switch c.StrategyConfig.Type {
+	case SendStrategyScheduled, SendStrategyTimeWindow, SendStrategyDeadline:
+		_, end := c.StrategyConfig.CalcTimeWindow()
+		return !end.After(now)
+	default:
+		return false
+	}
+}
+
+// ReceiverKey 返回序号为 seq 的接收者对应消息的 BizKey
+func (c Campaign) ReceiverKey(seq int64) string {
+	return fmt.Sprintf("%s:%d", c.BizKey, seq)
+}
+
+// Notification 将接收者展开为消息，now 为展开时间。
+//
+// 定时发送的活动在计划时间之后展开时，消息在计划时间对应的发送时间窗口内发送。
+// 活动的匀速发送由展开的节奏实现，展开后的消息不再匀速发送。
+func (c Campaign) Notification(r CampaignReceiver, now time.Time) Notification {
+	params := make(map[string]string, len(c.Template.Params)+len(r.Params))
+	maps.Copy(params, c.Template.Params)
+	maps.Copy(params, r.Params)
+
+	strategy := c.StrategyConfig
+	strategy.Pacing = PacingConf{}
+	if strategy.Type == SendStrategyScheduled && strategy.ScheduleAt.Before(now) {
+		start, end := strategy.CalcTimeWindow()
+		strategy = SendStrategyConf{Type: SendStrategyTimeWindow, Start: start, End: end}
+	}
+
+	return Notification{
+		BizId:      c.BizId,
+		BizKey:     c.ReceiverKey(r.Seq),
+		Receivers:  []string{r.Receiver},
+		Channel:    c.Channel,
+		CampaignId: c.Id,
+		Template: Template{
+			Id:        c.Template.Id,
+			VersionId: c.Template.VersionId,
+			Params:    params,
+		},
+		Priority:       c.Priority,
+		StrategyConfig: strategy,
+	}
+}
+
+// CampaignReceiver 群发活动接收者，Params 覆盖活动的默认模板参数
+type CampaignReceiver struct {
+	Seq      int64             `json:"seq"` // 从 1 开始按上传顺序连续编号
+	Receiver string            `json:"receiver"`
+	Params   map[string]string `json:"params"`
+}
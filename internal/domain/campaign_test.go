@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCampaignProgress_Apply(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name        string
+		counts      map[SendStatus]int64
+		want        CampaignProgress
+		wantPending int64
+	}{
+		{
+			name: "mixed statuses",
+			counts: map[SendStatus]int64{
+				SendStatusPrepare: 1,
+				SendStatusPending: 2,
+				SendStatusSending: 3,
+				SendStatusSuccess: 10,
+				SendStatusFailure: 4,
+				SendStatusExpired: 5,
+				SendStatusCancel:  6,
+			},
+			want:        CampaignProgress{Total: 100, Expanded: 50, Accepted: 31, Sent: 10, Failed: 9, Canceled: 6},
+			wantPending: 6,
+		}, {
+			name:        "all finished",
+			counts:      map[SendStatus]int64{SendStatusSuccess: 31},
+			want:        CampaignProgress{Total: 100, Expanded: 50, Accepted: 31, Sent: 31},
+			wantPending: 0,
+		}, {
+			name:        "no notifications",
+			counts:      map[SendStatus]int64{},
+			want:        CampaignProgress{Total: 100, Expanded: 50, Accepted: 31},
+			wantPending: 0,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			// 上传与展开的进度不受消息状态影响，发送进度按最新的统计覆盖
+			p := CampaignProgress{Total: 100, Expanded: 50, Accepted: 31, Sent: 1, Failed: 1, Canceled: 1}
+			pending := p.Apply(tc.counts)
+			assert.Equal(t, tc.wantPending, pending)
+			assert.Equal(t, tc.want, p)
+		})
+	}
+}
+
+func TestCampaign_ReceiverKey(t *testing.T) {
+	t.Parallel()
+
+	c := Campaign{BizKey: "campaign-1"}
+	assert.Equal(t, "campaign-1:1", c.ReceiverKey(1))
+	assert.Equal(t, "campaign-1:1024", c.ReceiverKey(1024))
+	assert.NotEqual(t, c.ReceiverKey(1), Campaign{BizKey: "campaign-2"}.ReceiverKey(1))
+}
+
+func TestCampaign_Notification(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	c := Campaign{
+		Id:       7,
+		BizId:    1,
+		BizKey:   "campaign-1",
+		Channel:  ChannelSMS,
+		Template: Template{Id: 2, VersionId: 3, Params: map[string]string{"name": "default", "code": "0000"}},
+		Priority: PriorityHigh,
+		StrategyConfig: SendStrategyConf{
+			Type:       SendStrategyScheduled,
+			ScheduleAt: now.Add(-time.Hour),
+			Pacing:     PacingConf{Mode: PacingModeEven},
+		},
+	}
+
+	n := c.Notification(CampaignReceiver{Seq: 3, Receiver: "13800000000", Params: map[string]string{"name": "jotify"}}, now)
+	assert.Equal(t, "campaign-1:3", n.BizKey)
+	assert.Equal(t, []string{"13800000000"}, n.Receivers)
+	assert.Equal(t, uint64(7), n.CampaignId)
+	assert.Equal(t, map[string]string{"name": "jotify", "code": "0000"}, n.Template.Params)
+	// 接收者的参数不修改活动的默认参数
+	assert.Equal(t, "default", c.Template.Params["name"])
+	// 计划时间之后展开的定时消息在计划时间对应的窗口内发送，且不再匀速发送
+	start, end := c.StrategyConfig.CalcTimeWindow()
+	assert.Equal(t, SendStrategyConf{Type: SendStrategyTimeWindow, Start: start, End: end}, n.StrategyConfig)
+	assert.False(t, n.StrategyConfig.Pacing.Enabled())
+}
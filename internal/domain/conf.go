@@ -1,19 +1,56 @@
 package domain
 
-import "github.com/JrMarcco/jotify/internal/pkg/retry"
+import (
+	"strings"
+	"time"
+
+	"github.com/JrMarcco/jotify/internal/pkg/calendar"
+	"github.com/JrMarcco/jotify/internal/pkg/retry"
+)
 
 // BizConf 业务配置领域对象
 type BizConf struct {
-	Id           uint64
-	OwnerId      uint64
-	OwnerType    string
-	ChannelConf  *ChannelConf
-	TxNotifConf  *TxNotifConf
-	RateLimit    int32
-	QuotaConf    *QuotaConf
-	CallbackConf *CallbackConf
-	CreateAt     int64
-	UpdateAt     int64
+	Id               uint64
+	OwnerId          uint64
+	OwnerType        string
+	ChannelConf      *ChannelConf
+	TxNotifConf      *TxNotifConf
+	RateLimit        int32
+	QuotaConf        *QuotaConf
+	CallbackConf     *CallbackConf
+	RetentionDays    int32 // 消息保留天数，0 表示使用默认保留天数
+	DeliveryConf     *DeliveryConf
+	SchedulingWeight int32 // 调度权重，0 表示使用默认权重
+	ContentConf      *ContentConf
+	CreateAt         int64
+	UpdateAt         int64
+}
+
+// DefaultSchedulingWeight 未配置调度权重的业务方使用的权重
+const DefaultSchedulingWeight = 1
+
+// Weight 返回业务方的调度权重
+func (bc BizConf) Weight() int {
+	if bc.SchedulingWeight > 0 {
+		return int(bc.SchedulingWeight)
+	}
+	return DefaultSchedulingWeight
+}
+
+// OrgId 返回业务方所属的组织 id，不属于组织时返回 false
+func (bc BizConf) OrgId() (uint64, bool) {
+	if OwnerType(bc.OwnerType) != OwnerTypeOrganization || bc.OwnerId == 0 {
+		return 0, false
+	}
+	return bc.OwnerId, true
+}
+
+// SensitivePolicyOr 返回业务方的敏感词处理策略，未配置时返回 def
+func (bc BizConf) SensitivePolicyOr(def SensitivePolicy) SensitivePolicy {
+	if bc.ContentConf != nil && bc.ContentConf.SensitivePolicy.Validate() {
+		return bc.ContentConf.SensitivePolicy
+	}
+	return def
 }
 
 // ChannelConf 渠道配置领域对象
@@ -59,3 +96,95 @@ type MonthlyQuotaConf struct {
 	SMS   int32 `json:"sms"`
 	Email int32 `json:"email"`
 }
+
+// DeliveryConf 接收者维度的投递配置领域对象，验证码消息不受限制
+type DeliveryConf struct {
+	Timezone          string            `json:"timezone"`           // 业务方时区，为空时使用 UTC
+	ReceiverTimezones map[string]string `json:"receiver_timezones"` // 接收者前缀（如 +1）对应的时区，最长前缀优先
+	FrequencyCaps     []FrequencyCap    `json:"frequency_caps"`
+	QuietHours        *QuietHours       `json:"quiet_hours"`
+}
+
+// LocationOf 返回接收者所在的时区，时区配置无效时使用 UTC
+func (c *DeliveryConf) LocationOf(receiver string) *time.Location {
+	tz, matched := c.Timezone, 0
+	for prefix, name := range c.ReceiverTimezones {
+		if len(prefix) > matched && strings.HasPrefix(receiver, prefix) {
+			tz, matched = name, len(prefix)
+		}
+	}
+
+	loc, err := calendar.LoadLocation(tz)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// CapsOf 返回适用于业务类型与渠道的频控规则
+func (c *DeliveryConf) CapsOf(bizType BizType, channel Channel) []FrequencyCap {
+	if bizType == BizTypeVerifyCode {
+		return nil
+	}
+
+	var caps []FrequencyCap
+	for _, fc := range c.FrequencyCaps {
+		if fc.BizType == bizType && (fc.Channel == "" || fc.Channel == channel) && fc.Period.Validate() && fc.Limit > 0 {
+			caps = append(caps, fc)
+		}
+	}
+	return caps
+}
+
+// FrequencyCap 频控规则，同一接收者每个周期内最多收到 Limit 条消息
+type FrequencyCap struct {
+	BizType BizType         `json:"biz_type"`
+	Channel Channel         `json:"channel"` // 为空时适用于全部渠道
+	Period  calendar.Period `json:"period"`
+	Limit   int32           `json:"limit"`
+}
+
+// QuietHours 免打扰时段，按接收者时区计算，Start 大于 End 时跨越零点
+type QuietHours struct {
+	Start    string    `json:"start"`     // HH:MM
+	End      string    `json:"end"`       // HH:MM
+	BizTypes []BizType `json:"biz_types"` // 为空时适用于除验证码以外的全部业务类型
+}
+
+// Applies 判断业务类型是否受免打扰时段限制
+func (q *QuietHours) Applies(bizType BizType) bool {
+	if bizType == BizTypeVerifyCode {
+		return false
+	}
+	if len(q.BizTypes) == 0 {
+		return true
+	}
+	for _, bt := range q.BizTypes {
+		if bt == bizType {
+			return true
+		}
+	}
+	return false
+}
+
+// SensitivePolicy 模板参数命中敏感词时的处理策略
+type SensitivePolicy string
+
+const (
+	SensitivePolicyReject SensitivePolicy = "reject" // 拒绝发送
+	SensitivePolicyMask   SensitivePolicy = "mask"   // 将敏感词替换为 * 后发送
+	SensitivePolicyFlag   SensitivePolicy = "flag"   // 照常发送，记录日志与指标
+)
+
+func (p SensitivePolicy) String() string {
+	return string(p)
+}
+
+func (p SensitivePolicy) Validate() bool {
+	return p == SensitivePolicyReject || p == SensitivePolicyMask || p == SensitivePolicyFlag
+}
+
+// ContentConf 消息内容配置领域对象
+type ContentConf struct {
+	SensitivePolicy SensitivePolicy `json:"sensitive_policy"` // 为空时使用 sensitive.default_policy
+}
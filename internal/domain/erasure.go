@@ -0,0 +1,11 @@
+package domain
+
+// ErasureReceipt 个人数据擦除回执领域对象，只保存接收者哈希
+type ErasureReceipt struct {
+	Id              uint64   `json:"id"`
+	BizId           uint64   `json:"biz_id"` // 擦除范围，0 表示全部业务方
+	ReceiverHash    string   `json:"receiver_hash"`
+	NotificationIds []uint64 `json:"notification_ids"`
+	OperatorBizId   uint64   `json:"operator_biz_id"` // 发起擦除的业务方
+	CreatedAt       int64    `json:"created_at"`
+}
@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/JrMarcco/jotify/internal/errs"
+)
+
+// maxLocaleLen 语言标签的长度上限
+const maxLocaleLen = 35
+
+// ParseLocale 解析并规范化 BCP 47 语言标签，例如 zh_hk 规范化为 zh-HK，zh-hant-hk 规范化为 zh-Hant-HK。
+//
+// 语言子标签为 2 ~ 3 个字母，之后可以有 4 个字母的文字子标签与 2 个字母或 3 个数字的地区子标签，不支持其他子标签。
+func ParseLocale(val string) (string, error) {
+	val = strings.TrimSpace(val)
+	if val == "" || len(val) > maxLocaleLen {
+		return "", fmt.Errorf("%w: invalid locale %q", errs.ErrInvalidParam, val)
+	}
+
+	subtags := strings.Split(strings.ReplaceAll(val, "_", "-"), "-")
+	if !isAlpha(subtags[0]) || len(subtags[0]) < 2 || len(subtags[0]) > 3 {
+		return "", fmt.Errorf("%w: invalid locale %q", errs.ErrInvalidParam, val)
+	}
+
+	res := []string{strings.ToLower(subtags[0])}
+	rest := subtags[1:]
+	if len(rest) > 0 && len(rest[0]) == 4 && isAlpha(rest[0]) {
+		res = append(res, strings.ToUpper(rest[0][:1])+strings.ToLower(rest[0][1:]))
+		rest = rest[1:]
+	}
+	if len(rest) > 0 && (len(rest[0]) == 2 && isAlpha(rest[0]) || len(rest[0]) == 3 && isDigit(rest[0])) {
+		res = append(res, strings.ToUpper(rest[0]))
+		rest = rest[1:]
+	}
+	if len(rest) > 0 {
+		return "", fmt.Errorf("%w: invalid locale %q", errs.ErrInvalidParam, val)
+	}
+	return strings.Join(res, "-"), nil
+}
+
+// LocaleChain 规范化后的语言标签的回退链，从具体到宽泛依次去掉最后一个子标签，例如 zh-Hant-HK → zh-Hant → zh。
+//
+// 回退链不包含默认内容，调用方在回退链中都没有匹配时使用默认内容。
+func LocaleChain(locale string) []string {
+	if locale == "" {
+		return nil
+	}
+
+	chain := []string{locale}
+	for {
+		idx := strings.LastIndexByte(locale, '-')
+		if idx < 0 {
+			return chain
+		}
+		locale = locale[:idx]
+		chain = append(chain, locale)
+	}
+}
+
+// ReceiverLocale 接收者的语言偏好，发送请求没有指定语言时使用。
+//
+// Receiver 只在写入时使用，保存的只有接收者哈希。
+type ReceiverLocale struct {
+	BizId        uint64 `json:"biz_id"`
+	Receiver     string `json:"-"`
+	ReceiverHash string `json:"receiver_hash"`
+	Locale       string `json:"locale"`
+	CreatedAt    int64  `json:"created_at"`
+	UpdatedAt    int64  `json:"updated_at"`
+}
+
+// Normalize 校验并规范化接收者的语言
+func (l *ReceiverLocale) Normalize() error {
+	if strings.TrimSpace(l.Receiver) == "" {
+		return fmt.Errorf("%w: receiver should not be empty", errs.ErrInvalidParam)
+	}
+	locale, err := ParseLocale(l.Locale)
+	if err != nil {
+		return err
+	}
+	l.Locale = locale
+	return nil
+}
+
+func isAlpha(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i] | 0x20; c < 'a' || c > 'z' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func isDigit(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/JrMarcco/jotify/internal/errs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLocale(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name    string
+		val     string
+		want    string
+		wantErr error
+	}{
+		{name: "language", val: "EN", want: "en"},
+		{name: "language and region", val: "zh_cn", want: "zh-CN"},
+		{name: "language, script and region", val: "zh-hant-hk", want: "zh-Hant-HK"},
+		{name: "language and script", val: "ZH-HANT", want: "zh-Hant"},
+		{name: "numeric region", val: "es-419", want: "es-419"},
+		{name: "three letter language", val: "yue-HK", want: "yue-HK"},
+		{name: "trim spaces", val: " en-us ", want: "en-US"},
+		{name: "empty", val: "", wantErr: errs.ErrInvalidParam},
+		{name: "single letter language", val: "e", wantErr: errs.ErrInvalidParam},
+		{name: "numeric language", val: "12-CN", wantErr: errs.ErrInvalidParam},
+		{name: "unsupported subtag", val: "en-US-posix", wantErr: errs.ErrInvalidParam},
+		{name: "invalid region", val: "en-U1", wantErr: errs.ErrInvalidParam},
+		{name: "empty subtag", val: "zh--CN", wantErr: errs.ErrInvalidParam},
+		{name: "too long", val: "zh-Hant-HK-aaaaaaaaaaaaaaaaaaaaaaaaa", wantErr: errs.ErrInvalidParam},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseLocale(tc.val)
+			assert.ErrorIs(t, err, tc.wantErr)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestLocaleChain(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name   string
+		locale string
+		want   []string
+	}{
+		{name: "default", locale: "", want: nil},
+		{name: "language", locale: "en", want: []string{"en"}},
+		{name: "language and region", locale: "zh-HK", want: []string{"zh-HK", "zh"}},
+		{name: "language, script and region", locale: "zh-Hant-HK", want: []string{"zh-Hant-HK", "zh-Hant", "zh"}},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.want, LocaleChain(tc.locale))
+		})
+	}
+}
@@ -19,6 +19,11 @@ const (
 	SendStatusSending SendStatus = "sending"
 	SendStatusSuccess SendStatus = "success"
 	SendStatusFailure SendStatus = "failure"
+	// SendStatusExpired 超过发送时间窗口仍未发出，终态，不会再被调度发送
+	SendStatusExpired SendStatus = "expired"
+
+	// SendStatusSuppressed 全部接收者都在退订名单中，消息未创建，只出现在发送结果中
+	SendStatusSuppressed SendStatus = "suppressed"
 )
 
 func (s SendStatus) String() string {
@@ -34,17 +39,23 @@ type Template struct {
 
 // Notification 消息领域对象
 type Notification struct {
-	Id             uint64           `json:"id"`
-	BizId          uint64           `json:"biz_id"`
-	BizKey         string           `json:"biz_key"`
-	Receivers      []string         `json:"receivers"`
-	Channel        Channel          `json:"channel"`
-	Template       Template         `json:"template"`
-	Status         SendStatus       `json:"status"`
-	ScheduledStart time.Time        `json:"scheduled_start"`
-	ScheduledEnd   time.Time        `json:"scheduled_end"`
-	Version        int32            `json:"version"`
-	StrategyConfig SendStrategyConf `json:"strategy_config"`
+	Id             uint64            `json:"id"`
+	BizId          uint64            `json:"biz_id"`
+	BizKey         string            `json:"biz_key"`
+	Receivers      []string          `json:"receivers"`
+	Channel        Channel           `json:"channel"`
+	Template       Template          `json:"template"`
+	Locale         string            `json:"locale"` // 请求指定的语言，创建消息时替换为实际使用的语言变体，空表示默认内容
+	Status         SendStatus        `json:"status"`
+	Priority       Priority          `json:"priority"`
+	CampaignId     uint64            `json:"campaign_id"` // 由群发活动展开时为活动 id，否则为 0
+	ScheduledStart time.Time         `json:"scheduled_start"`
+	ScheduledEnd   time.Time         `json:"scheduled_end"`
+	EndFixed       bool              `json:"end_fixed"` // 窗口结束时间由调用方指定，推迟发送时不超出该时间
+	Version        int32             `json:"version"`
+	CreatedAt      time.Time         `json:"created_at"` // 创建时间，只在读取已创建的消息时设置
+	StrategyConfig SendStrategyConf  `json:"strategy_config"`
+	TraceCtx       map[string]string `json:"trace_ctx"` // 创建消息时的链路信息，异步发送时用于关联回原始请求
 }
 
 func (n *Notification) Validate() error {
@@ -76,6 +87,10 @@ func (n *Notification) Validate() error {
 		return fmt.Errorf("%w: template params should not be empty", errs.ErrInvalidParam)
 	}
 
+	if !n.Priority.Validate() {
+		return fmt.Errorf("%w: invalid priority", errs.ErrInvalidParam)
+	}
+
 	if err := n.StrategyConfig.Validate(); err != nil {
 		return err
 	}
@@ -98,6 +113,7 @@ func (n *Notification) SetSendTime() {
 	start, end := n.StrategyConfig.CalcTimeWindow()
 	n.ScheduledStart = start
 	n.ScheduledEnd = end
+	n.EndFixed = n.StrategyConfig.HasFixedEnd()
 }
 
 func (n *Notification) IsImmediate() bool {
@@ -109,6 +125,7 @@ func (n *Notification) ReplaceAsyncImmediate() {
 	if n.IsImmediate() {
 		n.StrategyConfig.Deadline = time.Now().Add(time.Minute)
 		n.StrategyConfig.Type = SendStrategyDeadline
+		n.StrategyConfig.replacedImmediate = true
 	}
 }
 
@@ -120,6 +137,13 @@ func (n *Notification) MarshalTemplateParams() (string, error) {
 	return n.marshal(n.Template.Params)
 }
 
+func (n *Notification) MarshalTraceCtx() (string, error) {
+	if len(n.TraceCtx) == 0 {
+		return "", nil
+	}
+	return n.marshal(n.TraceCtx)
+}
+
 func (n *Notification) marshal(val any) (string, error) {
 	jsonBytes, err := json.Marshal(val)
 	if err != nil {
@@ -223,8 +247,8 @@ func getDomainStrategyConfig(n *notificationv1.Notification) (SendStrategyConf,
 		Type:       strategy,
 		Delay:      time.Duration(delaySeconds) * time.Second,
 		ScheduleAt: scheduleAt,
-		Start:      time.Unix(startTime, 0),
-		End:        time.Unix(endTime, 0),
+		Start:      time.UnixMilli(startTime),
+		End:        time.UnixMilli(endTime),
 		Deadline:   deadline,
 	}, nil
 }
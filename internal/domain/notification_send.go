@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/JrMarcco/jotify/internal/errs"
+	"github.com/JrMarcco/jotify/internal/pkg/calendar"
 )
 
 // SendStrategy 发送策略
@@ -20,6 +21,8 @@ const (
 	SendStrategyScheduled  SendStrategy = "scheduled"
 	SendStrategyTimeWindow SendStrategy = "time_window"
 	SendStrategyDeadline   SendStrategy = "deadline"
+	// SendStrategyRecurring 周期发送，保存为周期发送计划，按 cron 表达式为每次触发创建一条消息
+	SendStrategyRecurring SendStrategy = "recurring"
 )
 
 // SendStrategyConf 发送策略配置
@@ -30,6 +33,18 @@ type SendStrategyConf struct {
 	Start      time.Time     `json:"start"`
 	End        time.Time     `json:"end"`
 	Deadline   time.Time     `json:"deadline"`
+
+	// 匀速发送，只支持 time_window 与 deadline
+	Pacing PacingConf `json:"pacing"`
+
+	// 周期发送
+	Cron           string    `json:"cron"`            // 5 段 cron 表达式
+	Timezone       string    `json:"timezone"`        // cron 表达式的时区，为空时使用 UTC
+	EndAt          time.Time `json:"end_at"`          // 结束时间，零值表示不限
+	MaxOccurrences int32     `json:"max_occurrences"` // 最多触发次数，0 表示不限
+
+	// 由异步立即发送转换而来的 deadline，结束时间不是调用方指定的
+	replacedImmediate bool
 }
 
 // Validate 校验发送策略配置
@@ -53,24 +68,125 @@ func (c SendStrategyConf) Validate() error {
 		if c.Deadline.IsZero() || c.Deadline.Before(time.Now()) {
 			return fmt.Errorf("%w: deadline should not be zero or before now", errs.ErrInvalidParam)
 		}
+	case SendStrategyRecurring:
+		if _, err := calendar.ParseCron(c.Cron, c.Timezone); err != nil {
+			return fmt.Errorf("%w: invalid cron or timezone: %w", errs.ErrInvalidParam, err)
+		}
+		if !c.EndAt.IsZero() && c.EndAt.Before(time.Now()) {
+			return fmt.Errorf("%w: end_at should be after now", errs.ErrInvalidParam)
+		}
+		if c.MaxOccurrences < 0 {
+			return fmt.Errorf("%w: max_occurrences should not be negative", errs.ErrInvalidParam)
+		}
 	default:
 		return fmt.Errorf("%w: unknown strategy", errs.ErrInvalidParam)
 	}
 
+	switch c.Type {
+	case SendStrategyTimeWindow, SendStrategyDeadline:
+		return c.Pacing.Validate()
+	default:
+		if c.Pacing.Enabled() {
+			return fmt.Errorf("%w: pacing only supports time_window and deadline strategy", errs.ErrInvalidParam)
+		}
+	}
 	return nil
 }
 
-// CalcTimeWindow 计算发送时间窗口
+// PacingMode 匀速发送方式
+type PacingMode string
+
+const (
+	PacingModeNone  PacingMode = ""      // 窗口开始后尽快发送
+	PacingModeEven  PacingMode = "even"  // 在窗口内匀速发送
+	PacingModeCurve PacingMode = "curve" // 将窗口等分为若干时段，各时段的发送量与曲线的权重成正比
+)
+
+// maxPacingCurveLen 曲线最多的时段数
+const maxPacingCurveLen = 288
+
+// PacingConf 匀速发送配置，批量发送或群发活动的消息分散在发送时间窗口内发送，而不是在窗口开始时集中发出。
+//
+// 发送速率不超过业务方的每秒限流，也不超过渠道全部可用供应商的 QPS 上限之和。
+type PacingConf struct {
+	Mode  PacingMode `json:"mode"`
+	Curve []int      `json:"curve"` // curve 方式各时段的权重
+}
+
+func (c PacingConf) Enabled() bool {
+	return c.Mode != PacingModeNone
+}
+
+// Weights 返回各时段的权重，匀速发送时只有一个时段
+func (c PacingConf) Weights() []int {
+	if c.Mode == PacingModeCurve {
+		return c.Curve
+	}
+	return []int{1}
+}
+
+func (c PacingConf) Validate() error {
+	switch c.Mode {
+	case PacingModeNone, PacingModeEven:
+		return nil
+	case PacingModeCurve:
+		if len(c.Curve) == 0 || len(c.Curve) > maxPacingCurveLen {
+			return fmt.Errorf("%w: pacing curve should have 1 to %d segments", errs.ErrInvalidParam, maxPacingCurveLen)
+		}
+		var sum int
+		for _, w := range c.Curve {
+			if w < 0 {
+				return fmt.Errorf("%w: pacing curve weight should not be negative", errs.ErrInvalidParam)
+			}
+			sum += w
+		}
+		if sum == 0 {
+			return fmt.Errorf("%w: pacing curve should have at least one positive weight", errs.ErrInvalidParam)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown pacing mode %q", errs.ErrInvalidParam, c.Mode)
+	}
+}
+
+// PacingRate 返回匀速发送的每秒发送量上限，bizRate 为业务方的每秒限流，channelQps 为渠道供应商的 QPS 上限之和，
+// 不大于 0 表示不限，都不限时返回 0
+func PacingRate(bizRate int32, channelQps int64) float64 {
+	rate := float64(0)
+	if bizRate > 0 {
+		rate = float64(bizRate)
+	}
+	if channelQps > 0 && (rate == 0 || float64(channelQps) < rate) {
+		rate = float64(channelQps)
+	}
+	return rate
+}
+
+// defaultSendWindow 未指定结束时间的发送策略的默认窗口长度，超过窗口结束时间仍未发出的消息会被标记为过期
+const defaultSendWindow = 30 * time.Minute
+
+// HasFixedEnd 发送时间窗口的结束时间是否由调用方指定，只有 time_window 与 deadline 指定了结束时间
+func (c SendStrategyConf) HasFixedEnd() bool {
+	switch c.Type {
+	case SendStrategyTimeWindow:
+		return true
+	case SendStrategyDeadline:
+		return !c.replacedImmediate
+	default:
+		return false
+	}
+}
+
+// CalcTimeWindow 计算发送时间窗口，调度器只在 [start, end) 内发送消息
 func (c SendStrategyConf) CalcTimeWindow() (start, end time.Time) {
 	switch c.Type {
 	case SendStrategyImmediate:
 		// immediately send
 		now := time.Now()
-		const defaultEndDuration = 30 * time.Minute
-		return now, now.Add(defaultEndDuration)
+		return now, now.Add(defaultSendWindow)
 	case SendStrategyDelayed:
-		now := time.Now()
-		return now, now.Add(c.Delay)
+		start := time.Now().Add(c.Delay)
+		return start, start.Add(defaultSendWindow)
 	case SendStrategyDeadline:
 		now := time.Now()
 		return now, c.Deadline
@@ -78,7 +194,7 @@ func (c SendStrategyConf) CalcTimeWindow() (start, end time.Time) {
 		return c.Start, c.End
 	case SendStrategyScheduled:
 		const scheduledTimeTolerance = 3 * time.Second
-		return c.Start.Add(-scheduledTimeTolerance), c.Deadline
+		return c.ScheduleAt.Add(-scheduledTimeTolerance), c.ScheduleAt.Add(defaultSendWindow)
 	default:
 		now := time.Now()
 		return now, now
@@ -87,8 +203,10 @@ func (c SendStrategyConf) CalcTimeWindow() (start, end time.Time) {
 
 // SendResult 发送结果
 type SendResult struct {
-	NotificationId uint64     // notification 实体的 id
-	Status         SendStatus // 发送状态
+	NotificationId uint64               // notification 实体的 id
+	Status         SendStatus           // 发送状态
+	Suppressed     []SuppressedReceiver // 因在退订名单中被移除的接收者
+	ScheduleId     uint64               // 周期发送计划的 id，只在 recurring 策略中出现
 }
 
 // SendResp 发送请求的响应
@@ -104,4 +222,5 @@ type BatchSendResp struct {
 // BatchAsyncSendResp 批量异步发送请求的响应
 type BatchAsyncSendResp struct {
 	NotificationIds []uint64
+	Suppressed      []SuppressedReceiver // 因在退订名单中被移除的接收者
 }
@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	notificationv1 "github.com/JrMarcco/jotify-api/api/notification/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestNotification_SetSendTime_EndFixed(t *testing.T) {
+	t.Parallel()
+
+	n := Notification{StrategyConfig: SendStrategyConf{Type: SendStrategyDeadline, Deadline: time.Now().Add(time.Hour)}}
+	n.SetSendTime()
+	assert.True(t, n.EndFixed)
+
+	// 异步立即发送转换的 deadline 不是调用方指定的结束时间
+	n = Notification{StrategyConfig: SendStrategyConf{Type: SendStrategyImmediate}}
+	n.ReplaceAsyncImmediate()
+	n.SetSendTime()
+	assert.False(t, n.EndFixed)
+
+	n = Notification{StrategyConfig: SendStrategyConf{Type: SendStrategyDelayed, Delay: time.Minute}}
+	n.SetSendTime()
+	assert.False(t, n.EndFixed)
+}
+
+func TestSendStrategyConf_CalcTimeWindow(t *testing.T) {
+	t.Parallel()
+
+	scheduleAt := time.Now().Add(time.Hour)
+	start, end := SendStrategyConf{Type: SendStrategyScheduled, ScheduleAt: scheduleAt}.CalcTimeWindow()
+	// 定时发送按 ScheduleAt 计算窗口，而不是 Start 与 Deadline
+	assert.Equal(t, scheduleAt.Add(-3*time.Second), start)
+	assert.Equal(t, scheduleAt.Add(defaultSendWindow), end)
+
+	before := time.Now()
+	start, end = SendStrategyConf{Type: SendStrategyDelayed, Delay: time.Minute}.CalcTimeWindow()
+	assert.False(t, start.Before(before.Add(time.Minute)))
+	assert.Equal(t, start.Add(defaultSendWindow), end)
+}
+
+func TestNotificationFromApi_StrategyWindow(t *testing.T) {
+	t.Parallel()
+
+	startAt := time.Date(2026, 10, 19, 8, 0, 0, 123_000_000, time.UTC)
+	endAt := startAt.Add(2 * time.Hour)
+	scheduleAt := time.Date(2026, 10, 20, 9, 30, 0, 0, time.UTC)
+
+	tcs := []struct {
+		name      string
+		strategy  *notificationv1.SendStrategy
+		wantStart time.Time
+		wantEnd   time.Time
+	}{
+		{
+			name: "time window in millis",
+			strategy: &notificationv1.SendStrategy{StrategyType: &notificationv1.SendStrategy_TimeWindow{
+				TimeWindow: &notificationv1.TimeWindowStrategy{
+					StartTimeMillis: startAt.UnixMilli(),
+					EndTimeMillis:   endAt.UnixMilli(),
+				},
+			}},
+			wantStart: startAt,
+			wantEnd:   endAt,
+		}, {
+			name: "scheduled at send time",
+			strategy: &notificationv1.SendStrategy{StrategyType: &notificationv1.SendStrategy_Scheduled{
+				Scheduled: &notificationv1.ScheduledStrategy{SendTime: timestamppb.New(scheduleAt)},
+			}},
+			wantStart: scheduleAt.Add(-3 * time.Second),
+			wantEnd:   scheduleAt.Add(defaultSendWindow),
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			n, err := NotificationFromApi(&notificationv1.Notification{
+				BizKey:    "biz_key",
+				Receivers: []string{"13800000000"},
+				Channel:   notificationv1.Channel_SMS,
+				TplId:     "1",
+				Strategy:  tc.strategy,
+			})
+			require.NoError(t, err)
+
+			n.SetSendTime()
+			assert.True(t, tc.wantStart.Equal(n.ScheduledStart), "start %s", n.ScheduledStart)
+			assert.True(t, tc.wantEnd.Equal(n.ScheduledEnd), "end %s", n.ScheduledEnd)
+			assert.True(t, n.EndFixed == (n.StrategyConfig.Type == SendStrategyTimeWindow))
+		})
+	}
+}
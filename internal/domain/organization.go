@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"fmt"
+
+	"github.com/JrMarcco/jotify/internal/errs"
+)
+
+// Organization 组织领域对象。
+//
+// owner_type 为 organization 的业务方属于 owner_id 对应的组织，成员业务方：
+//   - 共享组织所有的渠道模板；
+//   - 未配置渠道、限流与投递配置时使用组织的配置；
+//   - 配额由组织配额池分配，每个业务方在每个渠道的配额余额不超过上限。
+type Organization struct {
+	Id           uint64        `json:"id"`
+	Name         string        `json:"name"`
+	ChannelConf  *ChannelConf  `json:"channel_conf"`
+	RateLimit    int32         `json:"rate_limit"`
+	DeliveryConf *DeliveryConf `json:"delivery_conf"`
+	CreatedAt    int64         `json:"created_at"`
+	UpdatedAt    int64         `json:"updated_at"`
+}
+
+func (o Organization) Validate() error {
+	if o.Name == "" {
+		return fmt.Errorf("%w: organization name should not be empty", errs.ErrInvalidParam)
+	}
+	if o.RateLimit < 0 {
+		return fmt.Errorf("%w: rate limit should not be negative", errs.ErrInvalidParam)
+	}
+	return nil
+}
+
+// Inherit 为成员业务方补全未配置的渠道、限流与投递配置
+func (o Organization) Inherit(bc *BizConf) {
+	if bc.ChannelConf == nil {
+		bc.ChannelConf = o.ChannelConf
+	}
+	if bc.RateLimit <= 0 {
+		bc.RateLimit = o.RateLimit
+	}
+	if bc.DeliveryConf == nil {
+		bc.DeliveryConf = o.DeliveryConf
+	}
+}
+
+// OrgQuotaAlloc 组织配额池向成员业务方在某个渠道上的分配
+type OrgQuotaAlloc struct {
+	OrgId     uint64  `json:"org_id"`
+	BizId     uint64  `json:"biz_id"`
+	Channel   Channel `json:"channel"`
+	Cap       int64   `json:"cap"`       // 配额余额上限，0 表示不限
+	Allocated int64   `json:"allocated"` // 累计分配的配额，扣除已收回的部分
+	CreatedAt int64   `json:"created_at"`
+	UpdatedAt int64   `json:"updated_at"`
+}
+
+// OrgBizUsage 成员业务方在某个渠道上的配额使用情况
+type OrgBizUsage struct {
+	BizId     uint64 `json:"biz_id"`
+	Cap       int64  `json:"cap"`
+	Allocated int64  `json:"allocated"`
+	Remaining int64  `json:"remaining"` // 配额余额
+	Used      int64  `json:"used"`      // 累计分配的配额减去余额
+}
+
+// OrgUsage 组织在某个渠道上的配额使用汇总
+type OrgUsage struct {
+	OrgId     uint64        `json:"org_id"`
+	Channel   Channel       `json:"channel"`
+	Pool      int64         `json:"pool"`      // 配额池中尚未分配的配额
+	Allocated int64         `json:"allocated"` // 各成员累计分配的配额之和
+	Remaining int64         `json:"remaining"` // 各成员配额余额之和
+	Used      int64         `json:"used"`      // 各成员已使用的配额之和
+	Bizs      []OrgBizUsage `json:"bizs"`
+}
+
+// Add 汇总成员业务方的使用情况
+func (u *OrgUsage) Add(bu OrgBizUsage) {
+	u.Allocated += bu.Allocated
+	u.Remaining += bu.Remaining
+	u.Used += bu.Used
+	u.Bizs = append(u.Bizs, bu)
+}
@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"fmt"
+
+	"github.com/JrMarcco/jotify/internal/errs"
+)
+
+// Priority 消息调度优先级，取值越大越先发送
+type Priority int8
+
+const (
+	// PriorityUnspecified 未指定，创建消息时按模板的业务类型确定
+	PriorityUnspecified Priority = 0
+
+	PriorityLow    Priority = 1
+	PriorityNormal Priority = 2
+	PriorityHigh   Priority = 3
+)
+
+// Priorities 全部优先级，从高到低排列，调度器按该顺序依次拉取
+var Priorities = []Priority{PriorityHigh, PriorityNormal, PriorityLow}
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityLow:
+		return "low"
+	case PriorityNormal:
+		return "normal"
+	case PriorityHigh:
+		return "high"
+	default:
+		return ""
+	}
+}
+
+// Validate 校验优先级，未指定也是合法取值
+func (p Priority) Validate() bool {
+	return p >= PriorityUnspecified && p <= PriorityHigh
+}
+
+// ParsePriority 解析优先级名称（high、normal、low）
+func ParsePriority(name string) (Priority, error) {
+	for _, p := range Priorities {
+		if p.String() == name {
+			return p, nil
+		}
+	}
+	return PriorityUnspecified, fmt.Errorf("%w: unknown priority %q", errs.ErrInvalidParam, name)
+}
+
+// PriorityOf 业务类型的默认优先级，验证码优先于通知，营销类最后发送
+func PriorityOf(bizType BizType) Priority {
+	switch bizType {
+	case BizTypeVerifyCode:
+		return PriorityHigh
+	case BizTypePromotion:
+		return PriorityLow
+	default:
+		return PriorityNormal
+	}
+}
+
+// ReadyBacklog 业务方在一个优先级下处于发送时间窗口中的待发送消息数
+type ReadyBacklog struct {
+	BizId    uint64
+	Priority Priority
+	Count    int
+}
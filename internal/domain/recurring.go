@@ -0,0 +1,104 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/JrMarcco/jotify/internal/pkg/calendar"
+)
+
+// RecurringStatus 周期发送计划状态
+type RecurringStatus string
+
+const (
+	RecurringStatusActive   RecurringStatus = "active"
+	RecurringStatusPaused   RecurringStatus = "paused"
+	RecurringStatusFinished RecurringStatus = "finished" // 到达结束时间或最多触发次数
+)
+
+func (s RecurringStatus) String() string {
+	return string(s)
+}
+
+// RecurringSchedule 周期发送计划领域对象。
+//
+// 由 recurring 策略的消息创建，BizKey 与创建时的消息相同。每次触发时创建一条消息，
+// 消息的 BizKey 由计划的 BizKey 与触发时间派生，重复触发时不会重复创建。
+type RecurringSchedule struct {
+	Id             uint64          `json:"id"`
+	BizId          uint64          `json:"biz_id"`
+	BizKey         string          `json:"biz_key"`
+	Receivers      []string        `json:"receivers"`
+	Channel        Channel         `json:"channel"`
+	Template       Template        `json:"template"`
+	Priority       Priority        `json:"priority"`
+	Cron           string          `json:"cron"`
+	Timezone       string          `json:"timezone"`
+	EndAt          time.Time       `json:"end_at"`          // 零值表示不限
+	MaxOccurrences int32           `json:"max_occurrences"` // 0 表示不限
+	Occurrences    int32           `json:"occurrences"`     // 已触发次数
+	NextRunAt      time.Time       `json:"next_run_at"`     // 下一次触发时间，结束后为零值
+	Status         RecurringStatus `json:"status"`
+	Version        int32           `json:"version"`
+	CreatedAt      int64           `json:"created_at"`
+	UpdatedAt      int64           `json:"updated_at"`
+}
+
+// RecurringScheduleFrom 由 recurring 策略的消息构造周期发送计划，n 需要已通过校验
+func RecurringScheduleFrom(n Notification) RecurringSchedule {
+	s := RecurringSchedule{
+		Id:             n.Id,
+		BizId:          n.BizId,
+		BizKey:         n.BizKey,
+		Receivers:      n.Receivers,
+		Channel:        n.Channel,
+		Template:       n.Template,
+		Priority:       n.Priority,
+		Cron:           n.StrategyConfig.Cron,
+		Timezone:       n.StrategyConfig.Timezone,
+		EndAt:          n.StrategyConfig.EndAt,
+		MaxOccurrences: n.StrategyConfig.MaxOccurrences,
+		Status:         RecurringStatusActive,
+	}
+	s.Advance(time.Now())
+	return s
+}
+
+// Advance 将下一次触发时间设置为 t 之后的第一次触发，没有下一次触发时状态变为 finished
+func (s *RecurringSchedule) Advance(t time.Time) {
+	var next time.Time
+	if c, err := calendar.ParseCron(s.Cron, s.Timezone); err == nil {
+		next = c.Next(t)
+	}
+
+	if next.IsZero() ||
+		(!s.EndAt.IsZero() && next.After(s.EndAt)) ||
+		(s.MaxOccurrences > 0 && s.Occurrences >= s.MaxOccurrences) {
+		s.NextRunAt = time.Time{}
+		s.Status = RecurringStatusFinished
+		return
+	}
+	s.NextRunAt = next
+}
+
+// OccurrenceKey 返回触发时间为 at 的消息的 BizKey
+func (s RecurringSchedule) OccurrenceKey(at time.Time) string {
+	return fmt.Sprintf("%s:%s", s.BizKey, at.UTC().Format("20060102T1504Z"))
+}
+
+// Occurrence 返回触发时间为 at 的消息，消息在 [at, at + window] 内发送
+func (s RecurringSchedule) Occurrence(at time.Time, window time.Duration) Notification {
+	return Notification{
+		BizId:     s.BizId,
+		BizKey:    s.OccurrenceKey(at),
+		Receivers: s.Receivers,
+		Channel:   s.Channel,
+		Template:  s.Template,
+		Priority:  s.Priority,
+		StrategyConfig: SendStrategyConf{
+			Type:  SendStrategyTimeWindow,
+			Start: at,
+			End:   at.Add(window),
+		},
+	}
+}
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/JrMarcco/jotify/internal/errs"
+)
+
+// SuppressionReason 接收者进入退订名单的原因
+type SuppressionReason string
+
+const (
+	SuppressionReasonUnsubscribe SuppressionReason = "unsubscribe" // 接收者主动退订（回复退订关键字、点击退订链接）
+	SuppressionReasonComplaint   SuppressionReason = "complaint"   // 接收者投诉
+	SuppressionReasonBounce      SuppressionReason = "bounce"      // 地址无效
+	SuppressionReasonManual      SuppressionReason = "manual"      // 业务方手动添加
+
+	// SuppressionReasonFrequencyCap 接收者在周期内达到频控上限，只出现在发送结果中，不能写入退订名单
+	SuppressionReasonFrequencyCap SuppressionReason = "frequency_cap"
+	// SuppressionReasonSensitiveContent 模板参数命中敏感词被拒绝，只出现在批量发送结果中，不能写入退订名单
+	SuppressionReasonSensitiveContent SuppressionReason = "sensitive_content"
+)
+
+func (r SuppressionReason) String() string {
+	return string(r)
+}
+
+func (r SuppressionReason) Validate() bool {
+	return r == SuppressionReasonUnsubscribe ||
+		r == SuppressionReasonComplaint ||
+		r == SuppressionReasonBounce ||
+		r == SuppressionReasonManual
+}
+
+// Suppression 退订名单领域对象，营销类消息不会发送给名单中的接收者。
+//
+// Receiver 只在写入时使用，保存与查询结果中只有接收者哈希。
+type Suppression struct {
+	Id           uint64            `json:"id"`
+	BizId        uint64            `json:"biz_id"` // 0 表示对全部业务方生效
+	Receiver     string            `json:"-"`
+	ReceiverHash string            `json:"receiver_hash"`
+	Channel      Channel           `json:"channel"`
+	Reason       SuppressionReason `json:"reason"`
+	ExpireAt     int64             `json:"expire_at"` // 毫秒时间戳，0 表示永久
+	CreatedAt    int64             `json:"created_at"`
+	UpdatedAt    int64             `json:"updated_at"`
+}
+
+func (s Suppression) Validate() error {
+	if strings.TrimSpace(s.Receiver) == "" {
+		return fmt.Errorf("%w: receiver should not be empty", errs.ErrInvalidParam)
+	}
+	if !s.Channel.Validate() {
+		return fmt.Errorf("%w: invalid channel", errs.ErrInvalidParam)
+	}
+	if !s.Reason.Validate() {
+		return fmt.Errorf("%w: invalid suppression reason", errs.ErrInvalidParam)
+	}
+	if s.ExpireAt < 0 {
+		return fmt.Errorf("%w: expire at should not be negative", errs.ErrInvalidParam)
+	}
+	return nil
+}
+
+// SuppressedReceiver 因在退订名单中被拦截的接收者
+type SuppressedReceiver struct {
+	BizKey   string            `json:"biz_key"`
+	Receiver string            `json:"receiver"`
+	Reason   SuppressionReason `json:"reason"`
+}
+
+// InboundReply 接收者的上行回复（如短信回复 TD）
+type InboundReply struct {
+	Channel  Channel `json:"channel"`
+	Receiver string  `json:"receiver"`
+	Content  string  `json:"content"`
+}
@@ -120,6 +120,17 @@ func (ct ChannelTpl) Validate() error {
 	return nil
 }
 
+// AccessibleBy 业务方是否可以使用模板。
+//
+// 组织模板由组织的全部成员业务方共享；个人模板只能由 owner_id 对应的业务方，或所有者相同的个人业务方使用。
+func (ct ChannelTpl) AccessibleBy(bc BizConf) bool {
+	if ct.OwnerType == OwnerTypeOrganization {
+		orgId, ok := bc.OrgId()
+		return ok && orgId == ct.OwnerId
+	}
+	return ct.OwnerId == bc.Id || (OwnerType(bc.OwnerType) == OwnerTypePerson && bc.OwnerId == ct.OwnerId)
+}
+
 func (ct ChannelTpl) Published() bool {
 	return ct.ActivatedVersionId > 0
 }
@@ -195,6 +206,112 @@ type ChannelTplVersion struct {
 	CreateAt     int64
 	UpdateAt     int64
 	Providers    []ChannelTplProvider
+	Variants     []ChannelTplVariant // 语言变体，按需加载
+}
+
+// ResolveLocale 按 locale 的回退链选择可用的语言变体，返回实际使用的语言，没有可用的变体时返回空字符串（默认内容）。
+//
+// 变体审核通过且供应商侧有对应语言的模板时可用，需要先加载版本的 Providers 与 Variants。
+func (v ChannelTplVersion) ResolveLocale(locale string) string {
+	if variant := v.variantOf(locale); variant != nil {
+		return variant.Locale
+	}
+	return ""
+}
+
+// Localize 返回替换为 locale 对应的审核通过的语言变体签名与内容的版本，locale 为空时返回版本本身。
+//
+// locale 为 ResolveLocale 选定的语言，不再回退，变体不存在或未审核通过时返回 false。
+func (v ChannelTplVersion) Localize(locale string) (ChannelTplVersion, bool) {
+	if locale == "" {
+		return v, true
+	}
+	for _, variant := range v.Variants {
+		if variant.Locale == locale && variant.AuditStatus.IsApproved() {
+			return v.WithVariant(variant), true
+		}
+	}
+	return v, false
+}
+
+// WithVariant 返回替换为变体签名与内容的版本，变体没有签名时使用版本的签名
+func (v ChannelTplVersion) WithVariant(variant ChannelTplVariant) ChannelTplVersion {
+	if variant.Signature != "" {
+		v.Signature = variant.Signature
+	}
+	v.Content = variant.Content
+	return v
+}
+
+// HasApprovedVariant 是否有审核通过的语言变体
+func (v ChannelTplVersion) HasApprovedVariant() bool {
+	for _, variant := range v.Variants {
+		if variant.AuditStatus.IsApproved() {
+			return true
+		}
+	}
+	return false
+}
+
+func (v ChannelTplVersion) variantOf(locale string) *ChannelTplVariant {
+	for _, candidate := range LocaleChain(locale) {
+		if len(v.ProvidersOf(candidate)) == 0 {
+			continue
+		}
+		for i := range v.Variants {
+			if v.Variants[i].Locale == candidate && v.Variants[i].AuditStatus.IsApproved() {
+				return &v.Variants[i]
+			}
+		}
+	}
+	return nil
+}
+
+// ProvidersOf 版本在供应商侧对应语言的模板，locale 为空时为默认内容的模板
+func (v ChannelTplVersion) ProvidersOf(locale string) []ChannelTplProvider {
+	var res []ChannelTplProvider
+	for _, p := range v.Providers {
+		if p.Locale == locale {
+			res = append(res, p)
+		}
+	}
+	return res
+}
+
+// ChannelTplVariant 渠道模板版本的语言变体领域对象。
+//
+// 变体与版本的默认内容一样需要审核，审核通过后随版本生效；发送时按语言回退链选择变体，都没有匹配时使用默认内容。
+type ChannelTplVariant struct {
+	Id           uint64
+	TplId        uint64
+	TplVersionId uint64
+	Locale       string // 规范化的 BCP 47 语言标签
+	Signature    string // 为空时使用版本的签名
+	Content      string
+	AuditId      uint64
+	AuditorId    uint64
+	AuditAt      int64
+	AuditStatus  AuditStatus
+	RejectReason string
+	CreateAt     int64
+	UpdateAt     int64
+}
+
+// Validate 校验并规范化变体的语言
+func (v *ChannelTplVariant) Validate() error {
+	if v.TplVersionId <= 0 {
+		return fmt.Errorf("%w: template version id should not be negative or zero", errs.ErrInvalidParam)
+	}
+	locale, err := ParseLocale(v.Locale)
+	if err != nil {
+		return err
+	}
+	v.Locale = locale
+
+	if v.Content == "" {
+		return fmt.Errorf("%w: variant content should not be empty", errs.ErrInvalidParam)
+	}
+	return nil
 }
 
 // ChannelTplProvider 渠道模板供应商领域对象
@@ -202,6 +319,7 @@ type ChannelTplProvider struct {
 	Id              uint64
 	TplId           uint64
 	TplVersionId    uint64
+	Locale          string // 对应的语言变体，空表示默认内容
 	ProviderId      uint64
 	ProviderName    string
 	ProviderChannel Channel
@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChannelTplVersion_ResolveLocale(t *testing.T) {
+	t.Parallel()
+
+	variant := func(locale string, status AuditStatus) ChannelTplVariant {
+		return ChannelTplVariant{Locale: locale, Content: locale, AuditStatus: status}
+	}
+	provider := func(locale string) ChannelTplProvider {
+		return ChannelTplProvider{Locale: locale, ProviderTplId: "tpl-" + locale}
+	}
+
+	tcs := []struct {
+		name      string
+		variants  []ChannelTplVariant
+		providers []ChannelTplProvider
+		locale    string
+		want      string
+	}{
+		{
+			name:      "exact match",
+			variants:  []ChannelTplVariant{variant("zh-Hant-HK", AuditStatusApproved), variant("zh", AuditStatusApproved)},
+			providers: []ChannelTplProvider{provider(""), provider("zh-Hant-HK"), provider("zh")},
+			locale:    "zh-Hant-HK",
+			want:      "zh-Hant-HK",
+		}, {
+			name:      "fallback to script",
+			variants:  []ChannelTplVariant{variant("zh-Hant", AuditStatusApproved), variant("zh", AuditStatusApproved)},
+			providers: []ChannelTplProvider{provider(""), provider("zh-Hant"), provider("zh")},
+			locale:    "zh-Hant-HK",
+			want:      "zh-Hant",
+		}, {
+			name:      "fallback to language",
+			variants:  []ChannelTplVariant{variant("zh", AuditStatusApproved)},
+			providers: []ChannelTplProvider{provider(""), provider("zh")},
+			locale:    "zh-Hant-HK",
+			want:      "zh",
+		}, {
+			name:      "fallback to default",
+			variants:  []ChannelTplVariant{variant("en", AuditStatusApproved)},
+			providers: []ChannelTplProvider{provider(""), provider("en")},
+			locale:    "zh-Hant-HK",
+			want:      "",
+		}, {
+			name:      "skip variant not approved",
+			variants:  []ChannelTplVariant{variant("zh-Hant", AuditStatusPending), variant("zh", AuditStatusApproved)},
+			providers: []ChannelTplProvider{provider(""), provider("zh-Hant"), provider("zh")},
+			locale:    "zh-Hant-HK",
+			want:      "zh",
+		}, {
+			name:      "skip variant without provider template",
+			variants:  []ChannelTplVariant{variant("zh-Hant", AuditStatusApproved), variant("zh", AuditStatusApproved)},
+			providers: []ChannelTplProvider{provider(""), provider("zh")},
+			locale:    "zh-Hant-HK",
+			want:      "zh",
+		}, {
+			name:      "no locale",
+			variants:  []ChannelTplVariant{variant("zh", AuditStatusApproved)},
+			providers: []ChannelTplProvider{provider(""), provider("zh")},
+			locale:    "",
+			want:      "",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			v := ChannelTplVersion{Content: "default", Variants: tc.variants, Providers: tc.providers}
+			assert.Equal(t, tc.want, v.ResolveLocale(tc.locale))
+		})
+	}
+}
+
+func TestChannelTplVersion_Localize(t *testing.T) {
+	t.Parallel()
+
+	v := ChannelTplVersion{
+		Signature: "jotify",
+		Content:   "default",
+		Variants: []ChannelTplVariant{
+			{Locale: "zh-Hant", Signature: "jotify-hant", Content: "hant", AuditStatus: AuditStatusApproved},
+			{Locale: "zh", Content: "zh", AuditStatus: AuditStatusApproved},
+			{Locale: "en", Content: "en", AuditStatus: AuditStatusRejected},
+		},
+	}
+
+	got, ok := v.Localize("zh-Hant")
+	assert.True(t, ok)
+	assert.Equal(t, "jotify-hant", got.Signature)
+	assert.Equal(t, "hant", got.Content)
+
+	// 变体没有签名时使用版本的签名
+	got, ok = v.Localize("zh")
+	assert.True(t, ok)
+	assert.Equal(t, "jotify", got.Signature)
+	assert.Equal(t, "zh", got.Content)
+
+	got, ok = v.Localize("")
+	assert.True(t, ok)
+	assert.Equal(t, "default", got.Content)
+
+	// 选定的语言不再回退
+	_, ok = v.Localize("zh-Hant-HK")
+	assert.False(t, ok)
+	_, ok = v.Localize("en")
+	assert.False(t, ok)
+}
@@ -13,18 +13,40 @@ var (
 	ErrBizConfNotFound           = errors.New("[jotify] biz config not found")
 	ErrChannelTplNotFound        = errors.New("[jotify] channel template not found")
 	ErrChannelTplVersionNotFound = errors.New("[jotify] channel template version not found")
+	ErrChannelTplVariantNotFound = errors.New("[jotify] channel template variant not found")
 	ErrNotificationNotFound      = errors.New("[jotify] notification not found")
+	ErrErasureReceiptNotFound    = errors.New("[jotify] erasure receipt not found")
+	ErrRecurringScheduleNotFound = errors.New("[jotify] recurring schedule not found")
+	ErrCampaignNotFound          = errors.New("[jotify] campaign not found")
+	ErrOrganizationNotFound      = errors.New("[jotify] organization not found")
 	ErrFailedSendNotification    = errors.New("[jotify] failed to send notification")
 
 	ErrNotApprovedTplVersion = errors.New("[jotify] channel template version is not approved")
 	ErrNotAvailableProvider  = errors.New("[jotify] not available provider")
+	ErrSensitiveContent      = errors.New("[jotify] template params contain sensitive words")
 
-	ErrInsufficientQuota = errors.New("[jotify] insufficient quota")
+	ErrInsufficientQuota   = errors.New("[jotify] insufficient quota")
+	ErrOrgQuotaCapExceeded = errors.New("[jotify] organization quota cap exceeded")
+	ErrNotOrgMember        = errors.New("[jotify] biz is not a member of the organization")
 
 	ErrFailedToCreateCallbackLog = errors.New("[jotify] failed to create callback log")
 	ErrFailedToSendNotification  = errors.New("[jotify] failed to send notification")
 
-	ErrDuplicateNotificationId = errors.New("[jotify] duplicate notification id")
+	ErrDuplicateNotificationId    = errors.New("[jotify] duplicate notification id")
+	ErrDuplicateRecurringSchedule = errors.New("[jotify] duplicate recurring schedule")
+	ErrDuplicateCampaign          = errors.New("[jotify] duplicate campaign")
+	ErrDuplicateOrganization      = errors.New("[jotify] duplicate organization")
+
+	ErrNotificationVersionConflict = errors.New("[jotify] notification version conflict")
+	ErrCampaignVersionConflict     = errors.New("[jotify] campaign version conflict")
+	ErrNotificationNotCancelable   = errors.New("[jotify] notification can not be canceled")
+	ErrNotificationNotResendable   = errors.New("[jotify] notification can not be resent")
+	ErrRecurringScheduleConflict   = errors.New("[jotify] recurring schedule status conflict")
+	ErrCampaignConflict            = errors.New("[jotify] campaign status conflict")
+	ErrTplAuditConflict            = errors.New("[jotify] channel template version audit status conflict")
+
+	ErrTokenRevoked     = errors.New("[jotify] token revoked")
+	ErrPermissionDenied = errors.New("[jotify] permission denied")
 
 	ErrAcquireExceedLimit = errors.New("[jotify] acquire resource exceed the limit")
 
@@ -1,12 +1,16 @@
 package ioc
 
 import (
+	"context"
 	"sync"
+	"time"
 
 	"github.com/JrMarcco/easy-kit/xsync"
 	"github.com/JrMarcco/jotify/internal/pkg/sharding"
 	"github.com/spf13/viper"
+	clientv3 "go.etcd.io/etcd/client/v3"
 	"go.uber.org/fx"
+	"go.uber.org/zap"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
@@ -14,6 +18,7 @@ import (
 var DBFxOpt = fx.Provide(
 	InitBaseDB,
 	InitShardingDB,
+	InitRoutingSyncer,
 	fx.Annotate(
 		InitNotifShardingStrategy,
 		fx.As(new(sharding.Strategy)),
@@ -75,14 +80,57 @@ func InitShardingDB() *xsync.Map[string, *gorm.DB] {
 	return &dbs
 }
 
-func InitNotifShardingStrategy() sharding.Strategy {
-	return sharding.NewHashStrategy(
-		"jotify", "notification", 2, 4,
-	)
+const (
+	ShardingDBPrefix = "jotify"
+	NotifTablePrefix = "notification"
+	CbLogTablePrefix = "callback_log"
+)
+
+// InitRoutingSyncer 初始化分库分表路由表，从 etcd 加载并持续监听变更。
+//
+// etcd 中没有路由表时使用配置文件中的布局（版本号为 1）。
+func InitRoutingSyncer(etcdClient *clientv3.Client, logger *zap.Logger) *sharding.RoutingSyncer {
+	type Config struct {
+		EtcdKey       string `mapstructure:"etcd_key"`
+		DBSharding    uint64 `mapstructure:"db_sharding"`
+		TableSharding uint64 `mapstructure:"table_sharding"`
+	}
+
+	cfg := &Config{}
+	if err := viper.UnmarshalKey("sharding", cfg); err != nil {
+		panic(err)
+	}
+
+	fallback := sharding.RoutingTable{
+		Current: sharding.Layout{Version: 1, DBSharding: cfg.DBSharding, TableSharding: cfg.TableSharding},
+	}
+
+	strategies := make([]*sharding.VersionedStrategy, 0, 2)
+	for _, tablePrefix := range []string{NotifTablePrefix, CbLogTablePrefix} {
+		strategy, err := sharding.NewVersionedStrategy(ShardingDBPrefix, tablePrefix, fallback)
+		if err != nil {
+			panic(err)
+		}
+		strategies = append(strategies, strategy)
+	}
+
+	syncer := sharding.NewRoutingSyncer(etcdClient, cfg.EtcdKey, fallback, strategies, logger)
+
+	const loadTimeout = 5 * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), loadTimeout)
+	defer cancel()
+	if err := syncer.Load(ctx); err != nil {
+		panic(err)
+	}
+
+	go syncer.Watch(context.Background())
+	return syncer
+}
+
+func InitNotifShardingStrategy(syncer *sharding.RoutingSyncer) *sharding.VersionedStrategy {
+	return syncer.Strategy(NotifTablePrefix)
 }
 
-func InitCbLogShardingStrategy() sharding.Strategy {
-	return sharding.NewHashStrategy(
-		"jotify", "callback_log", 2, 4,
-	)
+func InitCbLogShardingStrategy(syncer *sharding.RoutingSyncer) *sharding.VersionedStrategy {
+	return syncer.Strategy(CbLogTablePrefix)
 }
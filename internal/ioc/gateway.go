@@ -0,0 +1,65 @@
+package ioc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/JrMarcco/jotify/internal/api/gateway"
+	"github.com/spf13/viper"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+var GatewayFxOpt = fx.Provide(
+	gateway.NewServer,
+	InitGatewayServer,
+)
+
+var GatewayFxInvoke = fx.Invoke(
+	GatewayLifecycle,
+)
+
+// GatewayServer HTTP/JSON 网关服务
+type GatewayServer struct {
+	*http.Server
+}
+
+func InitGatewayServer(gw *gateway.Server) *GatewayServer {
+	type config struct {
+		Addr         string `mapstructure:"addr"`
+		ReadTimeout  int    `mapstructure:"read_timeout"`
+		WriteTimeout int    `mapstructure:"write_timeout"`
+	}
+	cfg := &config{}
+	if err := viper.UnmarshalKey("gateway", cfg); err != nil {
+		panic(err)
+	}
+
+	return &GatewayServer{
+		Server: &http.Server{
+			Addr:              cfg.Addr,
+			Handler:           gw.Handler(),
+			ReadHeaderTimeout: 3 * time.Second,
+			ReadTimeout:       time.Duration(cfg.ReadTimeout) * time.Millisecond,
+			WriteTimeout:      time.Duration(cfg.WriteTimeout) * time.Millisecond,
+		},
+	}
+}
+
+func GatewayLifecycle(lc fx.Lifecycle, server *GatewayServer, logger *zap.Logger) {
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			go func() {
+				if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					logger.Error("[jotify] gateway server stopped", zap.Error(err))
+				}
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			return server.Shutdown(ctx)
+		},
+	})
+}
@@ -3,35 +3,52 @@ package ioc
 import (
 	"context"
 	"crypto/ed25519"
-	"crypto/x509"
-	"encoding/pem"
 	"time"
 
 	clientv1 "github.com/JrMarcco/jotify-api/api/client/v1"
 	notificationv1 "github.com/JrMarcco/jotify-api/api/notification/v1"
 	grpcapi "github.com/JrMarcco/jotify/internal/api/grpc"
+	"github.com/JrMarcco/jotify/internal/api/grpc/interceptor/accesslog"
+	"github.com/JrMarcco/jotify/internal/api/grpc/interceptor/authz"
+	"github.com/JrMarcco/jotify/internal/api/grpc/interceptor/deadline"
 	"github.com/JrMarcco/jotify/internal/api/grpc/interceptor/jwt"
+	"github.com/JrMarcco/jotify/internal/api/grpc/interceptor/metrics"
+	"github.com/JrMarcco/jotify/internal/api/grpc/interceptor/recovery"
+	"github.com/JrMarcco/jotify/internal/api/grpc/interceptor/validate"
+	"github.com/JrMarcco/jotify/internal/pkg/auth"
+	"github.com/JrMarcco/jotify/internal/pkg/certs"
 	balancerpkg "github.com/JrMarcco/jotify/internal/pkg/client/balancer"
 	clientpkg "github.com/JrMarcco/jotify/internal/pkg/client/resolver"
 	grpcpkg "github.com/JrMarcco/jotify/internal/pkg/grpc"
 	"github.com/JrMarcco/jotify/internal/pkg/registry"
 	"github.com/spf13/viper"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"go.uber.org/fx"
+	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/balancer"
 	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/credentials"
 )
 
 var GrpcFxOpt = fx.Provide(
+	InitJwtEtcdSyncer,
+	InitJwtBuilder,
 	InitNotificationGrpcServer,
 	InitCallbackGrpcClients,
 	grpcapi.NewNotificationServer,
 )
 
-func InitNotificationGrpcServer(server *grpcapi.NotificationServer) *grpc.Server {
+// InitJwtEtcdSyncer 初始化 jwt 密钥环与吊销列表，从 etcd 加载并持续监听变更。
+//
+// etcd 中没有密钥时使用配置文件中的密钥对，kid 为配置中的 kid。
+func InitJwtEtcdSyncer(etcdClient *clientv3.Client, logger *zap.Logger) *jwt.EtcdSyncer {
 	type Config struct {
-		PriPem string `mapstructure:"private"`
-		PubPem string `mapstructure:"public"`
+		Kid        string `mapstructure:"kid"`
+		EtcdPrefix string `mapstructure:"etcd_prefix"`
+		PriPem     string `mapstructure:"private"`
+		PubPem     string `mapstructure:"public"`
 	}
 
 	cfg := &Config{}
@@ -40,42 +57,91 @@ func InitNotificationGrpcServer(server *grpcapi.NotificationServer) *grpc.Server
 	}
 	priKey, pubKey := loadJwtKeypair(cfg.PriPem, cfg.PubPem)
 
-	grpcSvr := grpc.NewServer(
-		// 注册拦截器
+	syncer := jwt.NewEtcdSyncer(
+		etcdClient,
+		cfg.EtcdPrefix,
+		// 密钥环引入之前签发的 token 不带 kid，使用配置文件中密钥的 kid 验证
+		jwt.NewKeyRing(cfg.Kid),
+		jwt.NewRevocationList(),
+		cfg.Kid,
+		jwt.Key{PriKey: priKey, PubKey: pubKey},
+		logger,
+	)
+
+	const loadTimeout = 5 * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), loadTimeout)
+	defer cancel()
+	if err := syncer.Load(ctx); err != nil {
+		panic(err)
+	}
+
+	go syncer.Watch(context.Background())
+	return syncer
+}
+
+// InitJwtBuilder 初始化 jwt 构造器，gRPC 拦截器与 HTTP 网关共用同一个密钥环与吊销列表
+func InitJwtBuilder(syncer *jwt.EtcdSyncer) *jwt.InterceptorBuilder {
+	return jwt.NewBuilder(syncer.KeyRing()).Revocation(syncer.Revocation())
+}
+
+func InitNotificationGrpcServer(
+	server *grpcapi.NotificationServer, jwtBuilder *jwt.InterceptorBuilder, logger *zap.Logger,
+) *grpc.Server {
+	opts := make([]grpc.ServerOption, 0, 3)
+	if creds := loadServerCredentials(logger); creds != nil {
+		opts = append(opts, grpc.Creds(creds))
+	}
+
+	authzBuilder := authz.Builder().
+		Service(notificationv1.NotificationService_ServiceDesc.ServiceName, auth.ScopeSend).
+		Service(notificationv1.NotificationQueryService_ServiceDesc.ServiceName, auth.ScopeQuery)
+	deadlineBuilder := initDeadlineBuilder()
+	recoveryBuilder := recovery.Builder(logger)
+	accessLogBuilder := accesslog.Builder(logger)
+
+	grpcSvr := grpc.NewServer(append(
+		opts,
+		// 链路追踪，从请求 metadata 中提取上游链路信息
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		// 注册拦截器，一元与流式拦截器保持相同的顺序：
+		// panic 恢复放在最外层；访问日志与监控指标放在 jwt 拦截器之前，认证失败的请求同样会记录，
+		// 业务 id 由 jwt 拦截器回传；授权需要从 context 获取业务 id，放在 jwt 拦截器之后
 		grpc.UnaryInterceptor(InterceptorOf(
-			jwt.Builder(priKey, pubKey).Build(),
+			recoveryBuilder.Build(),
+			accessLogBuilder.Build(),
+			metrics.Builder().Build(),
+			jwtBuilder.Build(),
+			authzBuilder.Build(),
+			deadlineBuilder.Build(),
+			validate.Builder().Build(),
 		)),
-	)
+		grpc.StreamInterceptor(StreamInterceptorOf(
+			recoveryBuilder.BuildStream(),
+			accessLogBuilder.BuildStream(),
+			metrics.Builder().BuildStream(),
+			jwtBuilder.BuildStream(),
+			authzBuilder.BuildStream(),
+			deadlineBuilder.BuildStream(),
+			validate.Builder().BuildStream(),
+		)),
+	)...)
 	notificationv1.RegisterNotificationServiceServer(grpcSvr, server)
 	notificationv1.RegisterNotificationQueryServiceServer(grpcSvr, server)
 
 	return grpcSvr
 }
 
-// loadJwtKeypair 加载 jwt 密钥对。
-//
-// PEM 块本身标注的是密钥对，而不是具体的 ed25519 密钥对。
-// 所有标准公钥格式都需要先由 x509 包处理进行转换后类型断言才能获得 ed25519 密钥对。
+// loadJwtKeypair 加载配置文件中的 jwt 密钥对。
 func loadJwtKeypair(priPem, pubPem string) (ed25519.PrivateKey, ed25519.PublicKey) {
-	priKeyBlock, _ := pem.Decode([]byte(priPem))
-	if priKeyBlock == nil {
-		panic("failed to decode private key PEM")
-	}
-	priKey, err := x509.ParsePKCS8PrivateKey(priKeyBlock.Bytes)
+	priKey, err := jwt.ParsePrivateKey(priPem)
 	if err != nil {
 		panic(err)
 	}
-
-	pubKeyBlock, _ := pem.Decode([]byte(pubPem))
-	if pubKeyBlock == nil {
-		panic("failed to decode public key PEM")
-	}
-	publicKey, err := x509.ParsePKIXPublicKey(pubKeyBlock.Bytes)
+	pubKey, err := jwt.ParsePublicKey(pubPem)
 	if err != nil {
 		panic(err)
 	}
-
-	return priKey.(ed25519.PrivateKey), publicKey.(ed25519.PublicKey)
+	return priKey, pubKey
 }
 
 // InterceptorOf 自定义拦截器链，grpc 官方只允许一次 grpc.UnaryInterceptor 调用
@@ -94,7 +160,44 @@ func InterceptorOf(interceptors ...grpc.UnaryServerInterceptor) grpc.UnaryServer
 	}
 }
 
-func InitCallbackGrpcClients(r registry.Registry) *grpcpkg.Clients[clientv1.CallbackServiceClient] {
+// StreamInterceptorOf 自定义流式拦截器链，与 InterceptorOf 相同按顺序嵌套调用
+func StreamInterceptorOf(interceptors ...grpc.StreamServerInterceptor) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		chainedHandler := handler
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			thisInterceptor := interceptors[i]
+			next := chainedHandler
+			chainedHandler = func(srv any, ss grpc.ServerStream) error {
+				return thisInterceptor(srv, ss, info, next)
+			}
+		}
+		return chainedHandler(srv, ss)
+	}
+}
+
+// initDeadlineBuilder 按配置初始化请求超时拦截器，方法单独配置的超时优先于默认超时
+func initDeadlineBuilder() *deadline.InterceptorBuilder {
+	type Config struct {
+		Default int `mapstructure:"default"`
+		Methods []struct {
+			Method  string `mapstructure:"method"`
+			Timeout int    `mapstructure:"timeout"`
+		} `mapstructure:"methods"`
+	}
+
+	cfg := &Config{}
+	if err := viper.UnmarshalKey("app.deadline", cfg); err != nil {
+		panic(err)
+	}
+
+	builder := deadline.Builder(time.Duration(cfg.Default) * time.Millisecond)
+	for _, m := range cfg.Methods {
+		builder.Method(m.Method, time.Duration(m.Timeout)*time.Millisecond)
+	}
+	return builder
+}
+
+func InitCallbackGrpcClients(r registry.Registry, logger *zap.Logger) *grpcpkg.Clients[clientv1.CallbackServiceClient] {
 	type Config struct {
 		Name    string `mapstructure:"name"`
 		Timeout int    `mapstructure:"timeout"`
@@ -120,5 +223,83 @@ func InitCallbackGrpcClients(r registry.Registry) *grpcpkg.Clients[clientv1.Call
 		func(conn *grpc.ClientConn) clientv1.CallbackServiceClient {
 			return clientv1.NewCallbackServiceClient(conn)
 		},
-	)
+		// 链路追踪，回调请求携带链路信息
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+	).WithCredentials(loadCallbackCredentials(logger))
+}
+
+// tlsConfig 单端 tls 配置，ca_file 在服务端为客户端证书的 CA（非空即开启 mTLS），在客户端为服务端证书的 CA
+type tlsConfig struct {
+	Enabled    bool   `mapstructure:"enabled"`
+	CertFile   string `mapstructure:"cert_file"`
+	KeyFile    string `mapstructure:"key_file"`
+	CaFile     string `mapstructure:"ca_file"`
+	ServerName string `mapstructure:"server_name"`
+}
+
+func tlsReloadInterval() time.Duration {
+	interval := time.Duration(viper.GetInt("tls.reload_interval")) * time.Millisecond
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return interval
+}
+
+// newCertReloader 加载证书并在后台监听证书文件变更
+func newCertReloader(cfg tlsConfig, logger *zap.Logger) *certs.Reloader {
+	reloader, err := certs.NewReloader(cfg.CertFile, cfg.KeyFile, cfg.CaFile, logger)
+	if err != nil {
+		panic(err)
+	}
+	go reloader.Watch(context.Background(), tlsReloadInterval())
+	return reloader
+}
+
+// loadServerCredentials 加载 gRPC 服务端 tls 凭证，未开启时返回 nil 使用明文
+func loadServerCredentials(logger *zap.Logger) credentials.TransportCredentials {
+	cfg := tlsConfig{}
+	if err := viper.UnmarshalKey("tls.server", &cfg); err != nil {
+		panic(err)
+	}
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.CertFile == "" {
+		panic("tls.server.cert_file is required when tls is enabled")
+	}
+	return credentials.NewTLS(newCertReloader(cfg, logger).ServerConfig())
+}
+
+// loadCallbackCredentials 加载回调客户端 tls 凭证。
+//
+// tls.callback.services 中按服务名单独配置，未单独配置的服务使用 tls.callback.default。
+func loadCallbackCredentials(logger *zap.Logger) func(serviceName string) credentials.TransportCredentials {
+	type Config struct {
+		Default  tlsConfig            `mapstructure:"default"`
+		Services map[string]tlsConfig `mapstructure:"services"`
+	}
+	cfg := &Config{}
+	if err := viper.UnmarshalKey("tls.callback", cfg); err != nil {
+		panic(err)
+	}
+
+	newCreds := func(c tlsConfig) credentials.TransportCredentials {
+		if !c.Enabled {
+			return nil
+		}
+		return credentials.NewTLS(newCertReloader(c, logger).ClientConfig(c.ServerName))
+	}
+
+	defaultCreds := newCreds(cfg.Default)
+	serviceCreds := make(map[string]credentials.TransportCredentials, len(cfg.Services))
+	for name, c := range cfg.Services {
+		serviceCreds[name] = newCreds(c)
+	}
+
+	return func(serviceName string) credentials.TransportCredentials {
+		if creds, ok := serviceCreds[serviceName]; ok {
+			return creds
+		}
+		return defaultCreds
+	}
 }
@@ -0,0 +1,121 @@
+package ioc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/JrMarcco/jotify/internal/pkg/metrics"
+	"github.com/JrMarcco/jotify/internal/repository"
+	"github.com/JrMarcco/jotify/internal/repository/cache"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/spf13/viper"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+var MetricsFxOpt = fx.Provide(
+	InitMetricsServer,
+)
+
+var MetricsFxInvoke = fx.Invoke(
+	fx.Annotate(
+		RegisterRepoMetrics,
+		fx.ParamTags(`name:"quota_redis_cache"`),
+	),
+	MetricsLifecycle,
+)
+
+// MetricsServer 暴露 /metrics 的 http 服务
+type MetricsServer struct {
+	*http.Server
+}
+
+func InitMetricsServer() *MetricsServer {
+	type config struct {
+		Addr string `mapstructure:"addr"`
+		Path string `mapstructure:"path"`
+	}
+	cfg := &config{}
+	if err := viper.UnmarshalKey("metrics", cfg); err != nil {
+		panic(err)
+	}
+
+	if cfg.Path == "" {
+		cfg.Path = "/metrics"
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(cfg.Path, promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{}))
+
+	return &MetricsServer{
+		Server: &http.Server{
+			Addr:              cfg.Addr,
+			Handler:           mux,
+			ReadHeaderTimeout: 3 * time.Second,
+		},
+	}
+}
+
+// RegisterRepoMetrics 注册需要在采集时实时查询的指标（剩余配额、回调重试积压）。
+func RegisterRepoMetrics(quotaCache cache.QuotaCache, cbLogRepo repository.CallbackLogRepo, logger *zap.Logger) {
+	const collectTimeout = 3 * time.Second
+
+	metrics.MustRegister(
+		metrics.NewGaugeVecFunc(
+			"quota", "remaining", "Remaining quota by biz id and channel.",
+			[]string{"biz_id", "channel"},
+			func() []metrics.LabeledValue {
+				ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+				defer cancel()
+
+				quotas, err := quotaCache.List(ctx)
+				if err != nil {
+					logger.Error("[jotify] failed to list quota for metrics", zap.Error(err))
+					return nil
+				}
+
+				res := make([]metrics.LabeledValue, 0, len(quotas))
+				for _, q := range quotas {
+					res = append(res, metrics.LabeledValue{
+						Labels: []string{strconv.FormatUint(q.BizId, 10), q.Channel.String()},
+						Value:  float64(q.Quota),
+					})
+				}
+				return res
+			},
+		),
+		metrics.NewGaugeFunc(
+			"callback", "retry_backlog", "Number of pending callbacks waiting to be sent or retried.",
+			func() float64 {
+				ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+				defer cancel()
+
+				cnt, err := cbLogRepo.CountPending(ctx)
+				if err != nil {
+					logger.Error("[jotify] failed to count pending callback logs for metrics", zap.Error(err))
+					return 0
+				}
+				return float64(cnt)
+			},
+		),
+	)
+}
+
+func MetricsLifecycle(lc fx.Lifecycle, server *MetricsServer, logger *zap.Logger) {
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			go func() {
+				if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					logger.Error("[jotify] metrics server stopped", zap.Error(err))
+				}
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			return server.Shutdown(ctx)
+		},
+	})
+}
@@ -1,15 +1,33 @@
 package ioc
 
 import (
+	"fmt"
+	"time"
+
+	"github.com/JrMarcco/jotify/internal/pkg/envelope"
+	"github.com/JrMarcco/jotify/internal/pkg/privacy"
 	"github.com/JrMarcco/jotify/internal/repository"
 	"github.com/JrMarcco/jotify/internal/repository/cache"
 	"github.com/JrMarcco/jotify/internal/repository/cache/local"
 	"github.com/JrMarcco/jotify/internal/repository/cache/redis"
 	"github.com/JrMarcco/jotify/internal/repository/dao"
+	"github.com/spf13/viper"
 	"go.uber.org/fx"
 )
 
 var RepoFxOpt = fx.Options(
+	// 接收者哈希与退订链接签名
+	fx.Provide(InitReceiverHasher, InitUnsubscribeSigner),
+
+	// 信封加密
+	fx.Provide(
+		fx.Annotate(
+			InitKeySource,
+			fx.As(new(envelope.KeySource)),
+		),
+		InitCipher,
+	),
+
 	// cache
 	fx.Provide(
 		fx.Annotate(
@@ -27,6 +45,14 @@ var RepoFxOpt = fx.Options(
 			fx.As(new(cache.QuotaCache)),
 			fx.ResultTags(`name:"quota_redis_cache"`),
 		),
+		fx.Annotate(
+			redis.NewFrequencyRedisCache,
+			fx.As(new(cache.FrequencyCache)),
+		),
+		fx.Annotate(
+			redis.NewOrgQuotaRedisCache,
+			fx.As(new(cache.OrgQuotaCache)),
+		),
 	),
 
 	// dao
@@ -41,6 +67,53 @@ var RepoFxOpt = fx.Options(
 			dao.NewNotifShardingDAO,
 			fx.As(new(dao.NotificationDAO)),
 			fx.ParamTags(`name:"notification_sharding_strategy"`, `name:"callback_log_sharding_strategy"`),
+			fx.ResultTags(`name:"notification_sharding_dao"`),
+		),
+		fx.Annotate(
+			InitEncryptedNotifDAO,
+			fx.As(new(dao.NotificationDAO)),
+			fx.ParamTags(`name:"notification_sharding_dao"`),
+		),
+		// notification archive dao
+		fx.Annotate(
+			dao.NewNotifArchiveShardingDAO,
+			fx.As(new(dao.NotifArchiveDAO)),
+			fx.ParamTags(``, `name:"callback_log_sharding_strategy"`),
+		),
+		// erasure dao
+		fx.Annotate(
+			dao.NewErasureShardingDAO,
+			fx.As(new(dao.ErasureDAO)),
+			fx.ParamTags(``, `name:"notification_sharding_strategy"`),
+		),
+		fx.Annotate(
+			dao.NewDefaultErasureReceiptDAO,
+			fx.As(new(dao.ErasureReceiptDAO)),
+		),
+		// suppression dao
+		fx.Annotate(
+			dao.NewDefaultSuppressionDAO,
+			fx.As(new(dao.SuppressionDAO)),
+		),
+		// receiver locale dao
+		fx.Annotate(
+			dao.NewDefaultReceiverLocaleDAO,
+			fx.As(new(dao.ReceiverLocaleDAO)),
+		),
+		// recurring schedule dao
+		fx.Annotate(
+			dao.NewDefaultRecurringScheduleDAO,
+			fx.As(new(dao.RecurringScheduleDAO)),
+		),
+		// campaign dao
+		fx.Annotate(
+			dao.NewDefaultCampaignDAO,
+			fx.As(new(dao.CampaignDAO)),
+		),
+		// data key dao
+		fx.Annotate(
+			dao.NewDefaultDataKeyDAO,
+			fx.As(new(dao.DataKeyDAO)),
 		),
 		// channel template dao
 		fx.Annotate(
@@ -56,6 +129,12 @@ var RepoFxOpt = fx.Options(
 		fx.Annotate(
 			dao.NewDefaultCallbackLogDAO,
 			fx.As(new(dao.CallbackLogDAO)),
+			fx.ParamTags(``, `name:"callback_log_sharding_strategy"`),
+		),
+		// organization dao
+		fx.Annotate(
+			dao.NewDefaultOrganizationDAO,
+			fx.As(new(dao.OrganizationDAO)),
 		),
 	),
 
@@ -71,6 +150,41 @@ var RepoFxOpt = fx.Options(
 			repository.NewDefaultNotifRepo,
 			fx.As(new(repository.NotificationRepo)),
 		),
+		// suppression repository
+		fx.Annotate(
+			repository.NewDefaultSuppressionRepo,
+			fx.As(new(repository.SuppressionRepo)),
+		),
+		// receiver locale repository
+		fx.Annotate(
+			repository.NewDefaultReceiverLocaleRepo,
+			fx.As(new(repository.ReceiverLocaleRepo)),
+		),
+		// frequency repository
+		fx.Annotate(
+			repository.NewDefaultFrequencyRepo,
+			fx.As(new(repository.FrequencyRepo)),
+		),
+		// recurring schedule repository
+		fx.Annotate(
+			InitRecurringRepo,
+			fx.As(new(repository.RecurringRepo)),
+		),
+		// campaign repository
+		fx.Annotate(
+			InitCampaignRepo,
+			fx.As(new(repository.CampaignRepo)),
+		),
+		// data key repository
+		fx.Annotate(
+			repository.NewDefaultDataKeyRepo,
+			fx.As(new(envelope.DataKeyStore)),
+		),
+		// erasure repository
+		fx.Annotate(
+			repository.NewDefaultErasureRepo,
+			fx.As(new(repository.ErasureRepo)),
+		),
 		// channel template repository
 		fx.Annotate(
 			repository.NewDefaultChannelTplRepo,
@@ -86,5 +200,68 @@ var RepoFxOpt = fx.Options(
 			repository.NewDefaultCallbackLogRepo,
 			fx.As(new(repository.CallbackLogRepo)),
 		),
+		// organization repository
+		fx.Annotate(
+			repository.NewDefaultOrganizationRepo,
+			fx.As(new(repository.OrganizationRepo)),
+		),
 	),
 )
+
+func InitReceiverHasher() *privacy.Hasher {
+	hasher, err := privacy.NewHasher(viper.GetString("privacy.receiver_hash_key"))
+	if err != nil {
+		panic(err)
+	}
+	return hasher
+}
+
+func InitUnsubscribeSigner() *privacy.Signer {
+	signer, err := privacy.NewSigner(viper.GetString("suppression.token_key"))
+	if err != nil {
+		panic(err)
+	}
+	return signer
+}
+
+func InitKeySource() *envelope.StaticKeySource {
+	// 未开启加密时不需要当前主密钥，只加载已有主密钥用于解密
+	currentId := ""
+	if viper.GetBool("encryption.enabled") {
+		currentId = viper.GetString("encryption.current_key_id")
+	}
+
+	var (
+		source *envelope.StaticKeySource
+		err    error
+	)
+	switch name := viper.GetString("encryption.key_source"); name {
+	case "env":
+		source, err = envelope.NewEnvKeySource(viper.GetString("encryption.env_prefix"), currentId)
+	case "file":
+		source, err = envelope.NewFileKeySource(viper.GetString("encryption.key_dir"), currentId)
+	default:
+		err = fmt.Errorf("unsupported master key source: %s", name)
+	}
+	if err != nil {
+		panic(err)
+	}
+	return source
+}
+
+func InitCipher(store envelope.DataKeyStore, keySource envelope.KeySource) *envelope.Cipher {
+	latestTTL := time.Duration(viper.GetInt("encryption.latest_key_ttl")) * time.Millisecond
+	return envelope.NewCipher(store, keySource, latestTTL)
+}
+
+func InitEncryptedNotifDAO(notifDAO dao.NotificationDAO, cipher *envelope.Cipher) *dao.EncryptedNotifDAO {
+	return dao.NewEncryptedNotifDAO(notifDAO, cipher, viper.GetBool("encryption.enabled"))
+}
+
+func InitRecurringRepo(recurringDAO dao.RecurringScheduleDAO, cipher *envelope.Cipher) *repository.DefaultRecurringRepo {
+	return repository.NewDefaultRecurringRepo(recurringDAO, cipher, viper.GetBool("encryption.enabled"))
+}
+
+func InitCampaignRepo(campaignDAO dao.CampaignDAO, cipher *envelope.Cipher) *repository.DefaultCampaignRepo {
+	return repository.NewDefaultCampaignRepo(campaignDAO, cipher, viper.GetBool("encryption.enabled"))
+}
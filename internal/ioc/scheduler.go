@@ -6,14 +6,25 @@ import (
 	"time"
 
 	"github.com/JrMarcco/dlock"
+	"github.com/JrMarcco/jotify/internal/domain"
 	"github.com/JrMarcco/jotify/internal/pkg/batch/slidewindow"
 	"github.com/JrMarcco/jotify/internal/pkg/bitring"
 	"github.com/JrMarcco/jotify/internal/pkg/job"
+	"github.com/JrMarcco/jotify/internal/pkg/metrics"
 	shardingpkg "github.com/JrMarcco/jotify/internal/pkg/sharding"
 	"github.com/JrMarcco/jotify/internal/repository"
+	"github.com/JrMarcco/jotify/internal/repository/dao"
+	"github.com/JrMarcco/jotify/internal/service/archive"
+	"github.com/JrMarcco/jotify/internal/service/audit"
+	"github.com/JrMarcco/jotify/internal/service/campaign"
+	"github.com/JrMarcco/jotify/internal/service/expiry"
+	"github.com/JrMarcco/jotify/internal/service/notification"
+	"github.com/JrMarcco/jotify/internal/service/notification/callback"
+	"github.com/JrMarcco/jotify/internal/service/recurring"
 	"github.com/JrMarcco/jotify/internal/service/schedule"
 	shardingsvc "github.com/JrMarcco/jotify/internal/service/schedule/sharding"
 	"github.com/JrMarcco/jotify/internal/service/sender"
+	"github.com/JrMarcco/jotify/internal/service/sendstrategy"
 	"github.com/spf13/viper"
 	clientv3 "go.etcd.io/etcd/client/v3"
 	"go.uber.org/fx"
@@ -26,26 +37,65 @@ var SchedulerFxOpt = fx.Options(
 		fx.Annotate(
 			InitNotificationScheduler,
 			fx.As(new(schedule.NotifScheduler)),
-			fx.ParamTags(`name:"notification_sharding_strategy"`),
+			fx.ParamTags(``, ``, ``, `name:"notification_sharding_strategy"`),
+		),
+		// notification archiver
+		fx.Annotate(
+			InitArchiver,
+			fx.As(new(archive.Archiver)),
+			fx.ParamTags(``, ``, ``, `name:"notification_sharding_strategy"`),
+		),
+		// notification expiry sweeper
+		fx.Annotate(
+			InitExpirySweeper,
+			fx.As(new(expiry.Sweeper)),
+			fx.ParamTags(``, ``, ``, `name:"notification_sharding_strategy"`),
+		),
+		// recurring schedule spawner
+		fx.Annotate(
+			InitRecurringSpawner,
+			fx.As(new(recurring.Spawner)),
+			fx.ParamTags(``, ``, ``, `name:"sensitive_send_service"`),
+		),
+		// campaign runner
+		fx.Annotate(
+			InitCampaignRunner,
+			fx.As(new(campaign.Runner)),
+			fx.ParamTags(``, ``, ``, `name:"sensitive_send_service"`),
+		),
+		// template moderator
+		fx.Annotate(
+			InitTemplateModerator,
+			fx.As(new(audit.Moderator)),
 		),
 	),
 )
 
+var SchedulerFxInvoke = fx.Invoke(
+	NotifSchedulerLifecycle,
+	ArchiverLifecycle,
+	ExpirySweeperLifecycle,
+	RecurringSpawnerLifecycle,
+	CampaignRunnerLifecycle,
+	TemplateModeratorLifecycle,
+)
+
 func InitNotificationScheduler(
 	dclient dlock.Dclient,
 	notifRepo repository.NotificationRepo,
 	notifSender sender.Sender,
 	shardingStrategy shardingpkg.Strategy,
+	bizConfRepo repository.BizConfRepo,
 	etcdClient *clientv3.Client,
 	logger *zap.Logger,
 ) schedule.NotifScheduler {
 	type AdjusterConfig struct {
-		BuffSize          int           `mapstructure:"buff_size"`
-		InitSize          uint64        `mapstructure:"init_size"`
-		MinSize           uint64        `mapstructure:"min_size"`
-		MaxSize           uint64        `mapstructure:"max_size"`
-		AdjustStep        uint64        `mapstructure:"adjust_step"`
-		MinAdjustInterval time.Duration `mapstructure:"min_adjust_interval"`
+		BuffSize          int    `mapstructure:"buff_size"`
+		InitSize          uint64 `mapstructure:"init_size"`
+		MinSize           uint64 `mapstructure:"min_size"`
+		MaxSize           uint64 `mapstructure:"max_size"`
+		AdjustStep        uint64 `mapstructure:"adjust_step"`
+		MinAdjustInterval int    `mapstructure:"min_adjust_interval"` // millisecond
 	}
 
 	type ErrEventConfig struct {
@@ -57,8 +107,9 @@ func InitNotificationScheduler(
 	type ShardingSchedulerConfig struct {
 		MaxLockedTableCntKey string         `mapstructure:"max_locked_table_cnt_key"` // 最大锁定表数量配置中心 key
 		MaxLockedTableCnt    int            `mapstructure:"max_locked_table_cnt"`     // 最大锁定表数量
-		MinScheduleInterval  time.Duration  `mapstructure:"min_schedule_interval"`    // 最小调度间隔
+		MinScheduleInterval  int            `mapstructure:"min_schedule_interval"`    // 最小调度间隔，millisecond
 		BatchSize            uint64         `mapstructure:"batch_size"`               // 批量大小
+		PriorityWeights      map[string]int `mapstructure:"priority_weights"`         // 各优先级在每批中的权重
 		AdjusterConfig       AdjusterConfig `mapstructure:"adjuster_config"`          // 调整器配置
 		ErrEventConfig       ErrEventConfig `mapstructure:"err_event_config"`         // 错误事件配置
 	}
@@ -68,6 +119,15 @@ func InitNotificationScheduler(
 		panic(err)
 	}
 
+	weights := make(map[domain.Priority]int, len(cfg.PriorityWeights))
+	for name, weight := range cfg.PriorityWeights {
+		priority, err := domain.ParsePriority(name)
+		if err != nil {
+			panic(err)
+		}
+		weights[priority] = weight
+	}
+
 	resourceSemaphore := job.NewMaxCntResourceSemaphore(cfg.MaxLockedTableCnt)
 	// 处理最大锁定表数量表更时间
 	go func() {
@@ -88,7 +148,7 @@ func InitNotificationScheduler(
 		cfg.AdjusterConfig.MinSize,
 		cfg.AdjusterConfig.MaxSize,
 		cfg.AdjusterConfig.AdjustStep,
-		cfg.AdjusterConfig.MinAdjustInterval,
+		time.Duration(cfg.AdjusterConfig.MinAdjustInterval)*time.Millisecond,
 	)
 	if err != nil {
 		panic(err)
@@ -100,16 +160,282 @@ func InitNotificationScheduler(
 		cfg.ErrEventConfig.EventRateThreshold,
 	)
 
-	return shardingsvc.NewNotifShardingScheduler(
+	scheduler := shardingsvc.NewNotifShardingScheduler(
 		dclient,
 		notifRepo,
+		bizConfRepo,
 		notifSender,
 		shardingStrategy,
 		resourceSemaphore,
-		cfg.MinScheduleInterval,
+		time.Duration(cfg.MinScheduleInterval)*time.Millisecond,
+		weights,
 		cfg.BatchSize,
 		adjuster,
 		errEvents,
 		logger,
 	)
+
+	metrics.MustRegister(
+		metrics.NewGaugeFunc(
+			"scheduler", "batch_size", "Current batch size of the sharding scheduler.",
+			func() float64 { return float64(scheduler.BatchSize()) },
+		),
+		metrics.NewGaugeFunc(
+			"scheduler", "locked_shards", "Number of shard tables currently locked by this instance.",
+			func() float64 { return float64(resourceSemaphore.CurrCnt()) },
+		),
+	)
+	return scheduler
+}
+
+func NotifSchedulerLifecycle(lc fx.Lifecycle, scheduler schedule.NotifScheduler) {
+	ctx, cancel := context.WithCancel(context.Background())
+	lc.Append(fx.Hook{
+		OnStart: func(_ context.Context) error {
+			if !viper.GetBool("sharding_scheduler.enabled") {
+				return nil
+			}
+			return scheduler.Start(ctx)
+		},
+		OnStop: func(_ context.Context) error {
+			cancel()
+			return nil
+		},
+	})
+}
+
+func InitArchiver(
+	dclient dlock.Dclient,
+	archiveDAO dao.NotifArchiveDAO,
+	bizConfRepo repository.BizConfRepo,
+	shardingStrategy shardingpkg.Strategy,
+	logger *zap.Logger,
+) *archive.ShardingArchiver {
+	type config struct {
+		MaxLockedTableCnt    int   `mapstructure:"max_locked_table_cnt"`
+		Interval             int   `mapstructure:"interval"` // millisecond
+		BatchSize            int   `mapstructure:"batch_size"`
+		DefaultRetentionDays int32 `mapstructure:"default_retention_days"`
+		MinRetentionDays     int32 `mapstructure:"min_retention_days"`
+	}
+
+	var cfg config
+	if err := viper.UnmarshalKey("archive", &cfg); err != nil {
+		panic(err)
+	}
+
+	const day = 24 * time.Hour
+	return archive.NewShardingArchiver(
+		dclient,
+		archiveDAO,
+		bizConfRepo,
+		shardingStrategy,
+		job.NewMaxCntResourceSemaphore(cfg.MaxLockedTableCnt),
+		time.Duration(cfg.Interval)*time.Millisecond,
+		cfg.BatchSize,
+		time.Duration(cfg.DefaultRetentionDays)*day,
+		time.Duration(cfg.MinRetentionDays)*day,
+		logger,
+	)
+}
+
+func ArchiverLifecycle(lc fx.Lifecycle, archiver archive.Archiver) {
+	ctx, cancel := context.WithCancel(context.Background())
+	lc.Append(fx.Hook{
+		OnStart: func(_ context.Context) error {
+			// 未开启归档时不抢占分表
+			if !viper.GetBool("archive.enabled") {
+				return nil
+			}
+			return archiver.Start(ctx)
+		},
+		OnStop: func(_ context.Context) error {
+			cancel()
+			return nil
+		},
+	})
+}
+
+func InitExpirySweeper(
+	dclient dlock.Dclient,
+	notifRepo repository.NotificationRepo,
+	callbackSvc callback.Service,
+	shardingStrategy shardingpkg.Strategy,
+	logger *zap.Logger,
+) *expiry.ShardingSweeper {
+	type config struct {
+		MaxLockedTableCnt int `mapstructure:"max_locked_table_cnt"`
+		Interval          int `mapstructure:"interval"` // millisecond
+		BatchSize         int `mapstructure:"batch_size"`
+	}
+
+	var cfg config
+	if err := viper.UnmarshalKey("expiry", &cfg); err != nil {
+		panic(err)
+	}
+
+	return expiry.NewShardingSweeper(
+		dclient,
+		notifRepo,
+		callbackSvc,
+		shardingStrategy,
+		job.NewMaxCntResourceSemaphore(cfg.MaxLockedTableCnt),
+		time.Duration(cfg.Interval)*time.Millisecond,
+		cfg.BatchSize,
+		logger,
+	)
+}
+
+func ExpirySweeperLifecycle(lc fx.Lifecycle, sweeper expiry.Sweeper) {
+	ctx, cancel := context.WithCancel(context.Background())
+	lc.Append(fx.Hook{
+		OnStart: func(_ context.Context) error {
+			if !viper.GetBool("expiry.enabled") {
+				return nil
+			}
+			return sweeper.Start(ctx)
+		},
+		OnStop: func(_ context.Context) error {
+			cancel()
+			return nil
+		},
+	})
+}
+
+func InitRecurringSpawner(
+	dclient dlock.Dclient,
+	recurringRepo repository.RecurringRepo,
+	notifRepo repository.NotificationRepo,
+	sendSvc notification.SendService,
+	logger *zap.Logger,
+) *recurring.DefaultSpawner {
+	type config struct {
+		Interval   int `mapstructure:"interval"` // millisecond
+		BatchSize  int `mapstructure:"batch_size"`
+		SendWindow int `mapstructure:"send_window"` // millisecond
+	}
+
+	var cfg config
+	if err := viper.UnmarshalKey("recurring", &cfg); err != nil {
+		panic(err)
+	}
+
+	return recurring.NewDefaultSpawner(
+		dclient,
+		recurringRepo,
+		notifRepo,
+		sendSvc,
+		time.Duration(cfg.Interval)*time.Millisecond,
+		time.Duration(cfg.SendWindow)*time.Millisecond,
+		cfg.BatchSize,
+		logger,
+	)
+}
+
+func RecurringSpawnerLifecycle(lc fx.Lifecycle, spawner recurring.Spawner) {
+	ctx, cancel := context.WithCancel(context.Background())
+	lc.Append(fx.Hook{
+		OnStart: func(_ context.Context) error {
+			if !viper.GetBool("recurring.enabled") {
+				return nil
+			}
+			return spawner.Start(ctx)
+		},
+		OnStop: func(_ context.Context) error {
+			cancel()
+			return nil
+		},
+	})
+}
+
+func InitCampaignRunner(
+	dclient dlock.Dclient,
+	campaignRepo repository.CampaignRepo,
+	notifRepo repository.NotificationRepo,
+	sendSvc notification.SendService,
+	pacer *sendstrategy.Pacer,
+	logger *zap.Logger,
+) *campaign.DefaultRunner {
+	type config struct {
+		Interval    int   `mapstructure:"interval"` // millisecond
+		ChunkSize   int   `mapstructure:"chunk_size"`
+		MaxInflight int64 `mapstructure:"max_inflight"`
+		BatchSize   int   `mapstructure:"batch_size"`
+	}
+
+	var cfg config
+	if err := viper.UnmarshalKey("campaign", &cfg); err != nil {
+		panic(err)
+	}
+
+	return campaign.NewDefaultRunner(
+		dclient,
+		campaignRepo,
+		notifRepo,
+		sendSvc,
+		pacer,
+		time.Duration(cfg.Interval)*time.Millisecond,
+		cfg.ChunkSize,
+		cfg.MaxInflight,
+		cfg.BatchSize,
+		logger,
+	)
+}
+
+func CampaignRunnerLifecycle(lc fx.Lifecycle, runner campaign.Runner) {
+	ctx, cancel := context.WithCancel(context.Background())
+	lc.Append(fx.Hook{
+		OnStart: func(_ context.Context) error {
+			if !viper.GetBool("campaign.enabled") {
+				return nil
+			}
+			return runner.Start(ctx)
+		},
+		OnStop: func(_ context.Context) error {
+			cancel()
+			return nil
+		},
+	})
+}
+
+func InitTemplateModerator(
+	dclient dlock.Dclient,
+	tplRepo repository.ChannelTplRepo,
+	auditSvc audit.Service,
+	logger *zap.Logger,
+) *audit.DefaultModerator {
+	type config struct {
+		Interval  int `mapstructure:"interval"` // millisecond
+		BatchSize int `mapstructure:"batch_size"`
+	}
+
+	var cfg config
+	if err := viper.UnmarshalKey("template_audit", &cfg); err != nil {
+		panic(err)
+	}
+
+	return audit.NewDefaultModerator(
+		dclient,
+		tplRepo,
+		auditSvc,
+		time.Duration(cfg.Interval)*time.Millisecond,
+		cfg.BatchSize,
+		logger,
+	)
+}
+
+func TemplateModeratorLifecycle(lc fx.Lifecycle, moderator audit.Moderator) {
+	ctx, cancel := context.WithCancel(context.Background())
+	lc.Append(fx.Hook{
+		OnStart: func(_ context.Context) error {
+			if !viper.GetBool("template_audit.enabled") {
+				return nil
+			}
+			return moderator.Start(ctx)
+		},
+		OnStop: func(_ context.Context) error {
+			cancel()
+			return nil
+		},
+	})
 }
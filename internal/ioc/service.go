@@ -1,12 +1,19 @@
 package ioc
 
 import (
+	"context"
+	"time"
+
 	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/pkg/privacy"
+	"github.com/JrMarcco/jotify/internal/pkg/sensitive"
 	"github.com/JrMarcco/jotify/internal/repository"
+	"github.com/JrMarcco/jotify/internal/service/audit"
 	"github.com/JrMarcco/jotify/internal/service/channel"
 	"github.com/JrMarcco/jotify/internal/service/conf"
 	"github.com/JrMarcco/jotify/internal/service/notification"
 	"github.com/JrMarcco/jotify/internal/service/notification/callback"
+	"github.com/JrMarcco/jotify/internal/service/organization"
 	"github.com/JrMarcco/jotify/internal/service/provider"
 	"github.com/JrMarcco/jotify/internal/service/provider/selector"
 	"github.com/JrMarcco/jotify/internal/service/provider/sms"
@@ -14,16 +21,26 @@ import (
 	"github.com/JrMarcco/jotify/internal/service/sender"
 	"github.com/JrMarcco/jotify/internal/service/sendstrategy"
 	"github.com/spf13/viper"
+	clientv3 "go.etcd.io/etcd/client/v3"
 	"go.uber.org/fx"
+	"go.uber.org/zap"
 )
 
 var ServiceFxOpt = fx.Options(
+	// 敏感词
+	fx.Provide(
+		InitSensitiveEtcdSyncer,
+		InitSensitiveFilter,
+	),
+
 	fx.Provide(
 		// biz config service
 		fx.Annotate(
 			conf.NewDefaultBizConfService,
 			fx.As(new(conf.BizConfService)),
 		),
+		// pacer
+		sendstrategy.NewPacer,
 		// default send strategy
 		fx.Annotate(
 			sendstrategy.NewDefaultSendStrategy,
@@ -36,17 +53,138 @@ var ServiceFxOpt = fx.Options(
 			fx.As(new(sendstrategy.SendStrategy)),
 			fx.ResultTags(`name:"immediate_send_strategy"`),
 		),
+		// recurring send strategy
+		fx.Annotate(
+			sendstrategy.NewRecurringSendStrategy,
+			fx.As(new(sendstrategy.SendStrategy)),
+			fx.ResultTags(`name:"recurring_send_strategy"`),
+		),
 		fx.Annotate(
 			sendstrategy.NewDispatcher,
 			fx.As(new(sendstrategy.SendStrategy)),
-			fx.ParamTags(`name:"default_send_strategy"`, `name:"immediate_send_strategy"`),
+			fx.ParamTags(`name:"default_send_strategy"`, `name:"immediate_send_strategy"`, `name:"recurring_send_strategy"`),
 			fx.ResultTags(`name:"send_strategy_dispatcher"`),
 		),
 		// notification sends service
 		fx.Annotate(
 			notification.NewDefaultSendService,
 			fx.As(new(notification.SendService)),
-			fx.ParamTags(`name:"send_strategy_dispatcher"`),
+			fx.ParamTags(``, `name:"send_strategy_dispatcher"`),
+			fx.ResultTags(`name:"default_send_service"`),
+		),
+		fx.Annotate(
+			notification.NewFrequencySendService,
+			fx.As(new(notification.SendService)),
+			fx.ParamTags(`name:"default_send_service"`),
+			fx.ResultTags(`name:"frequency_send_service"`),
+		),
+		fx.Annotate(
+			InitSuppressionSendService,
+			fx.As(new(notification.SendService)),
+			fx.ParamTags(`name:"frequency_send_service"`),
+			fx.ResultTags(`name:"suppression_send_service"`),
+		),
+		fx.Annotate(
+			InitSensitiveSendService,
+			fx.As(new(notification.SendService)),
+			fx.ParamTags(`name:"suppression_send_service"`),
+			fx.ResultTags(`name:"sensitive_send_service"`),
+		),
+		fx.Annotate(
+			notification.NewAuthzSendService,
+			fx.As(new(notification.SendService)),
+			fx.ParamTags(`name:"sensitive_send_service"`),
+		),
+		// notification query service
+		fx.Annotate(
+			notification.NewDefaultQueryService,
+			fx.As(new(notification.QueryService)),
+			fx.ResultTags(`name:"default_query_service"`),
+		),
+		fx.Annotate(
+			notification.NewAuthzQueryService,
+			fx.As(new(notification.QueryService)),
+			fx.ParamTags(`name:"default_query_service"`),
+		),
+		// notification cancel service
+		fx.Annotate(
+			notification.NewDefaultCancelService,
+			fx.As(new(notification.CancelService)),
+			fx.ResultTags(`name:"default_cancel_service"`),
+		),
+		fx.Annotate(
+			notification.NewAuthzCancelService,
+			fx.As(new(notification.CancelService)),
+			fx.ParamTags(`name:"default_cancel_service"`),
+		),
+		// notification erasure service
+		fx.Annotate(
+			InitErasureService,
+			fx.As(new(notification.ErasureService)),
+			fx.ResultTags(`name:"default_erasure_service"`),
+		),
+		fx.Annotate(
+			notification.NewAuthzErasureService,
+			fx.As(new(notification.ErasureService)),
+			fx.ParamTags(`name:"default_erasure_service"`),
+		),
+		// suppression service
+		fx.Annotate(
+			notification.NewDefaultSuppressionService,
+			fx.As(new(notification.SuppressionService)),
+			fx.ResultTags(`name:"default_suppression_service"`),
+		),
+		fx.Annotate(
+			notification.NewAuthzSuppressionService,
+			fx.As(new(notification.SuppressionService)),
+			fx.ParamTags(`name:"default_suppression_service"`),
+		),
+		fx.Annotate(
+			InitUnsubscribeService,
+			fx.As(new(notification.UnsubscribeService)),
+		),
+		// receiver locale service
+		fx.Annotate(
+			notification.NewDefaultReceiverLocaleService,
+			fx.As(new(notification.ReceiverLocaleService)),
+		),
+		// recurring schedule service
+		fx.Annotate(
+			notification.NewDefaultRecurringService,
+			fx.As(new(notification.RecurringService)),
+			fx.ResultTags(`name:"default_recurring_service"`),
+		),
+		fx.Annotate(
+			notification.NewAuthzRecurringService,
+			fx.As(new(notification.RecurringService)),
+			fx.ParamTags(`name:"default_recurring_service"`),
+		),
+		// campaign service
+		fx.Annotate(
+			notification.NewDefaultCampaignService,
+			fx.As(new(notification.CampaignService)),
+			fx.ResultTags(`name:"default_campaign_service"`),
+		),
+		fx.Annotate(
+			notification.NewAuthzCampaignService,
+			fx.As(new(notification.CampaignService)),
+			fx.ParamTags(`name:"default_campaign_service"`),
+		),
+		// notification resend service
+		fx.Annotate(
+			notification.NewDefaultResendService,
+			fx.As(new(notification.ResendService)),
+		),
+		// template audit service
+		InitAuditPipeline,
+		fx.Annotate(
+			audit.NewDefaultService,
+			fx.As(new(audit.Service)),
+		),
+		// organization service
+		fx.Annotate(
+			organization.NewDefaultService,
+			fx.As(new(organization.Service)),
 		),
 		// callback service
 		fx.Annotate(
@@ -76,10 +214,148 @@ var ServiceFxOpt = fx.Options(
 		fx.Annotate(
 			sender.NewDefaultSender,
 			fx.As(new(sender.Sender)),
+			fx.ResultTags(`name:"default_sender"`),
+		),
+		fx.Annotate(
+			sender.NewQuietHoursSender,
+			fx.As(new(sender.Sender)),
+			fx.ParamTags(`name:"default_sender"`),
 		),
 	),
 )
 
+func InitErasureService(
+	notifRepo repository.NotificationRepo,
+	erasureRepo repository.ErasureRepo,
+	localeRepo repository.ReceiverLocaleRepo,
+	hasher *privacy.Hasher,
+	logger *zap.Logger,
+) *notification.DefaultErasureService {
+	return notification.NewDefaultErasureService(
+		notifRepo, erasureRepo, localeRepo, hasher, viper.GetStringSlice("privacy.sensitive_params"), logger,
+	)
+}
+
+func InitSuppressionSendService(
+	svc notification.SendService,
+	suppressionRepo repository.SuppressionRepo,
+	tplRepo repository.ChannelTplRepo,
+	hasher *privacy.Hasher,
+	signer *privacy.Signer,
+) *notification.SuppressionSendService {
+	return notification.NewSuppressionSendService(
+		svc,
+		suppressionRepo,
+		tplRepo,
+		hasher,
+		signer,
+		viper.GetString("suppression.unsubscribe_url"),
+	)
+}
+
+// InitSensitiveEtcdSyncer 初始化敏感词列表，从 etcd 加载并持续监听变更。
+//
+// etcd 中没有敏感词时使用配置文件中的敏感词，未开启时不加载任何敏感词。
+func InitSensitiveEtcdSyncer(etcdClient *clientv3.Client, logger *zap.Logger) *sensitive.EtcdSyncer {
+	type config struct {
+		Enabled    bool     `mapstructure:"enabled"`
+		EtcdPrefix string   `mapstructure:"etcd_prefix"`
+		Words      []string `mapstructure:"words"`
+	}
+
+	var cfg config
+	if err := viper.UnmarshalKey("sensitive", &cfg); err != nil {
+		panic(err)
+	}
+
+	syncer := sensitive.NewEtcdSyncer(etcdClient, cfg.EtcdPrefix, sensitive.NewFilter(nil), cfg.Words, logger)
+	if !cfg.Enabled {
+		return syncer
+	}
+
+	const loadTimeout = 5 * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), loadTimeout)
+	defer cancel()
+	if err := syncer.Load(ctx); err != nil {
+		panic(err)
+	}
+
+	go syncer.Watch(context.Background())
+	return syncer
+}
+
+func InitSensitiveFilter(syncer *sensitive.EtcdSyncer) *sensitive.Filter {
+	return syncer.Filter()
+}
+
+func InitSensitiveSendService(
+	svc notification.SendService,
+	filter *sensitive.Filter,
+	bizConfRepo repository.BizConfRepo,
+	logger *zap.Logger,
+) *notification.SensitiveSendService {
+	return notification.NewSensitiveSendService(
+		svc,
+		filter,
+		bizConfRepo,
+		domain.SensitivePolicy(viper.GetString("sensitive.default_policy")),
+		logger,
+	)
+}
+
+func InitUnsubscribeService(
+	suppressionRepo repository.SuppressionRepo, signer *privacy.Signer, logger *zap.Logger,
+) *notification.DefaultUnsubscribeService {
+	type inboundToken struct {
+		Token string `mapstructure:"token"`
+		BizId uint64 `mapstructure:"biz_id"`
+	}
+
+	var tokens []inboundToken
+	if err := viper.UnmarshalKey("suppression.inbound_tokens", &tokens); err != nil {
+		panic(err)
+	}
+
+	bindings := make(map[string]uint64, len(tokens))
+	for _, t := range tokens {
+		bindings[t.Token] = t.BizId
+	}
+	return notification.NewDefaultUnsubscribeService(
+		suppressionRepo,
+		signer,
+		bindings,
+		viper.GetStringSlice("suppression.inbound_keywords"),
+		logger,
+	)
+}
+
+func InitAuditPipeline(filter *sensitive.Filter) *audit.Pipeline {
+	type config struct {
+		BannedWords      []string       `mapstructure:"banned_words"`
+		ReviewWords      []string       `mapstructure:"review_words"`
+		UrlAllowHosts    []string       `mapstructure:"url_allow_hosts"`
+		SignaturePattern string         `mapstructure:"signature_pattern"`
+		MaxLength        map[string]int `mapstructure:"max_length"`
+	}
+
+	var cfg config
+	if err := viper.UnmarshalKey("template_audit.rule", &cfg); err != nil {
+		panic(err)
+	}
+
+	rule, err := audit.NewRuleStage(audit.RuleConf{
+		BannedWords:      cfg.BannedWords,
+		ReviewWords:      cfg.ReviewWords,
+		UrlAllowHosts:    cfg.UrlAllowHosts,
+		SignaturePattern: cfg.SignaturePattern,
+		MaxLength:        cfg.MaxLength,
+	}, filter)
+	if err != nil {
+		panic(err)
+	}
+	return audit.NewPipeline(rule)
+}
+
 func InitChannelMap(sms *channel.SmsChannel) map[domain.Channel]channel.Channel {
 	return map[domain.Channel]channel.Channel{
 		domain.ChannelSMS: sms,
@@ -104,11 +380,11 @@ func InitTencentSmsClient() *client.TencentSmsClient {
 
 func InitTencentSmsProvider(
 	client client.SmsClient, tplRepo repository.ChannelTplRepo, providerRepo repository.ProviderRepo,
-) *sms.Provider {
-	return sms.NewProvider(
-		"tencent_sms_provider",
-		client,
-		tplRepo,
-		providerRepo,
+) *provider.MetricsProvider {
+	const name = "tencent_sms_provider"
+	return provider.NewMetricsProvider(
+		name,
+		domain.ChannelSMS,
+		sms.NewProvider(name, client, tplRepo, providerRepo),
 	)
 }
@@ -0,0 +1,104 @@
+package ioc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+var TraceFxOpt = fx.Provide(
+	InitTracerProvider,
+)
+
+var TraceFxInvoke = fx.Invoke(
+	TraceLifecycle,
+)
+
+// InitTracerProvider 初始化链路追踪并设置为全局 TracerProvider。
+//
+// exporter 支持 stdout、otlp 和 none，none 时只在进程内传播链路信息不做导出。
+func InitTracerProvider() *sdktrace.TracerProvider {
+	type config struct {
+		ServiceName string  `mapstructure:"service_name"`
+		Exporter    string  `mapstructure:"exporter"`
+		Endpoint    string  `mapstructure:"endpoint"`
+		Insecure    bool    `mapstructure:"insecure"`
+		SampleRatio float64 `mapstructure:"sample_ratio"`
+	}
+	cfg := &config{}
+	if err := viper.UnmarshalKey("trace", cfg); err != nil {
+		panic(err)
+	}
+
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = "jotify"
+	}
+
+	res, err := resource.Merge(
+		resource.Default(),
+		resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(cfg.ServiceName)),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		// 上游已采样时跟随上游，否则按比例采样
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	}
+
+	switch cfg.Exporter {
+	case "stdout":
+		exporter, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		if err != nil {
+			panic(err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	case "otlp":
+		exporterOpts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(cfg.Endpoint)}
+		if cfg.Insecure {
+			exporterOpts = append(exporterOpts, otlptracegrpc.WithInsecure())
+		}
+		exporter, err := otlptracegrpc.New(context.Background(), exporterOpts...)
+		if err != nil {
+			panic(err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	case "", "none":
+	default:
+		panic(fmt.Sprintf("unsupported trace exporter: %s", cfg.Exporter))
+	}
+
+	tp := sdktrace.NewTracerProvider(opts...)
+
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+	return tp
+}
+
+func TraceLifecycle(lc fx.Lifecycle, tp *sdktrace.TracerProvider, logger *zap.Logger) {
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			// 关闭前导出缓冲区中剩余的 span
+			if err := tp.Shutdown(ctx); err != nil {
+				logger.Error("[jotify] failed to shutdown tracer provider", zap.Error(err))
+				return err
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,314 @@
+package migration
+
+import (
+	"cmp"
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JrMarcco/easy-kit/xsync"
+	"github.com/JrMarcco/jotify/internal/pkg/sharding"
+	"gorm.io/gorm"
+)
+
+//go:embed sql
+var sqlFS embed.FS
+
+const (
+	baseDir     = "sql/base"
+	shardingDir = "sql/sharding"
+
+	// BaseDBName 非分库分表的基础库在迁移记录中的名称
+	BaseDBName = "base"
+
+	migrationTable = "schema_migration"
+)
+
+// Migration 一次版本化的数据库变更。
+//
+// 文件名格式为 <version>_<name>.sql，例如 0001_init.sql，只支持向前迁移。
+// 分库分表的变更中使用 ${<表前缀>} 引用分表，执行时按每个分表展开。
+type Migration struct {
+	Version uint64
+	Name    string
+	SQL     string
+}
+
+// Target 迁移的执行目标，基础库的 Shard 为空，分库分表的 Shard 为分表后缀
+type Target struct {
+	DB    string `json:"db"`
+	Shard string `json:"shard"`
+
+	db     *gorm.DB
+	tables map[string]string // 表前缀 -> 实际表名
+}
+
+// render 将迁移中的分表引用替换为目标的实际表名
+func (t Target) render(sql string) string {
+	if len(t.tables) == 0 {
+		return sql
+	}
+
+	pairs := make([]string, 0, 2*len(t.tables))
+	for prefix, table := range t.tables {
+		pairs = append(pairs, "${"+prefix+"}", table)
+	}
+	return strings.NewReplacer(pairs...).Replace(sql)
+}
+
+// Step 一次待执行或已执行的迁移
+type Step struct {
+	Target
+	Version uint64 `json:"version"`
+	Name    string `json:"name"`
+	SQL     string `json:"sql,omitempty"`
+}
+
+// Status 执行目标的迁移状态
+type Status struct {
+	Target
+	Applied []uint64 `json:"applied"`
+	Pending []uint64 `json:"pending"`
+}
+
+// SchemaMigration 已执行的迁移记录，每个库一张表
+type SchemaMigration struct {
+	Version   uint64 `gorm:"primaryKey;autoIncrement:false"`
+	Shard     string `gorm:"primaryKey;size:32"`
+	Name      string `gorm:"size:128"`
+	AppliedAt int64
+}
+
+func (SchemaMigration) TableName() string {
+	return migrationTable
+}
+
+// Migrator 将嵌入的迁移应用到基础库与所有分库分表。
+//
+// 分库分表的目标由各分库分表策略的 BroadCast() 展开，在线扩容期间同时包含新旧布局，
+// 迁移记录按（版本，分表后缀）记录，扩容新增的分表会在下一次迁移时补齐。
+type Migrator struct {
+	baseDB     *gorm.DB
+	dbs        *xsync.Map[string, *gorm.DB]
+	strategies []sharding.Strategy
+
+	baseMigrations     []Migration
+	shardingMigrations []Migration
+}
+
+// Status 返回所有目标的迁移状态
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	targets, err := m.targets()
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]Status, 0, len(targets))
+	for _, target := range targets {
+		applied, err := m.applied(ctx, target)
+		if err != nil {
+			return nil, err
+		}
+
+		status := Status{Target: target, Applied: make([]uint64, 0), Pending: make([]uint64, 0)}
+		for _, migration := range m.migrationsOf(target) {
+			if _, ok := applied[migration.Version]; ok {
+				status.Applied = append(status.Applied, migration.Version)
+			} else {
+				status.Pending = append(status.Pending, migration.Version)
+			}
+		}
+		res = append(res, status)
+	}
+	return res, nil
+}
+
+// Up 按版本顺序执行所有未执行的迁移，dryRun 为 true 时只返回将要执行的迁移及展开后的 sql
+func (m *Migrator) Up(ctx context.Context, dryRun bool) ([]Step, error) {
+	targets, err := m.targets()
+	if err != nil {
+		return nil, err
+	}
+
+	steps := make([]Step, 0)
+	for _, target := range targets {
+		applied, err := m.applied(ctx, target)
+		if err != nil {
+			return steps, err
+		}
+
+		for _, migration := range m.migrationsOf(target) {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+
+			step := Step{Target: target, Version: migration.Version, Name: migration.Name}
+			sql := target.render(migration.SQL)
+			if dryRun {
+				step.SQL = sql
+				steps = append(steps, step)
+				continue
+			}
+
+			// mysql 的 DDL 无法回滚，执行成功后再写入迁移记录，迁移中的 DDL 需要可以重复执行
+			if err = target.db.WithContext(ctx).Exec(sql).Error; err != nil {
+				return steps, fmt.Errorf("failed to apply migration %d to %s[%s]: %w", migration.Version, target.DB, target.Shard, err)
+			}
+			record := SchemaMigration{
+				Version:   migration.Version,
+				Shard:     target.Shard,
+				Name:      migration.Name,
+				AppliedAt: time.Now().UnixMilli(),
+			}
+			if err = target.db.WithContext(ctx).Create(&record).Error; err != nil {
+				return steps, err
+			}
+			steps = append(steps, step)
+		}
+	}
+	return steps, nil
+}
+
+func (m *Migrator) migrationsOf(target Target) []Migration {
+	if target.tables == nil {
+		return m.baseMigrations
+	}
+	return m.shardingMigrations
+}
+
+// applied 获取目标已执行的迁移版本，迁移记录表不存在时创建
+func (m *Migrator) applied(ctx context.Context, target Target) (map[uint64]struct{}, error) {
+	db := target.db.WithContext(ctx)
+	if !db.Migrator().HasTable(migrationTable) {
+		if err := db.Migrator().CreateTable(&SchemaMigration{}); err != nil {
+			return nil, fmt.Errorf("failed to create %s in %s: %w", migrationTable, target.DB, err)
+		}
+	}
+
+	var records []SchemaMigration
+	if err := db.Where("shard = ?", target.Shard).Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	res := make(map[uint64]struct{}, len(records))
+	for _, record := range records {
+		res[record.Version] = struct{}{}
+	}
+	return res, nil
+}
+
+// targets 展开所有执行目标，分库分表按（库，分表后缀）分组，同一分组内的各逻辑表一起执行
+func (m *Migrator) targets() ([]Target, error) {
+	targets := []Target{{DB: BaseDBName, db: m.baseDB}}
+
+	type key struct {
+		db    string
+		shard uint64
+	}
+	grouped := make(map[key]map[string]string)
+	for _, strategy := range m.strategies {
+		prefixer, ok := strategy.(interface{ TablePrefix() string })
+		if !ok {
+			return nil, fmt.Errorf("sharding strategy %T without table prefix", strategy)
+		}
+
+		for _, dst := range strategy.BroadCast() {
+			k := key{db: dst.DB, shard: dst.TableSuffix}
+			if grouped[k] == nil {
+				grouped[k] = make(map[string]string)
+			}
+			grouped[k][prefixer.TablePrefix()] = dst.Table
+		}
+	}
+
+	keys := make([]key, 0, len(grouped))
+	for k := range grouped {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, func(a, b key) int {
+		if c := strings.Compare(a.db, b.db); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.shard, b.shard)
+	})
+
+	for _, k := range keys {
+		db, ok := m.dbs.Load(k.db)
+		if !ok {
+			return nil, fmt.Errorf("failed to load db: %s", k.db)
+		}
+		targets = append(targets, Target{
+			DB:     k.db,
+			Shard:  strconv.FormatUint(k.shard, 10),
+			db:     db,
+			tables: grouped[k],
+		})
+	}
+	return targets, nil
+}
+
+// loadMigrations 加载目录下的迁移并按版本排序
+func loadMigrations(dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(sqlFS, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]Migration, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+
+		versionStr, name, ok := strings.Cut(strings.TrimSuffix(entry.Name(), ".sql"), "_")
+		if !ok {
+			return nil, fmt.Errorf("invalid migration file name: %s", entry.Name())
+		}
+		version, err := strconv.ParseUint(versionStr, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version: %s", entry.Name())
+		}
+
+		content, err := fs.ReadFile(sqlFS, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, Migration{Version: version, Name: name, SQL: string(content)})
+	}
+
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, fmt.Errorf("duplicate migration version %d in %s", migrations[i].Version, dir)
+		}
+	}
+	return migrations, nil
+}
+
+// NewMigrator 创建迁移器，strategies 为需要展开的分库分表策略（需要实现 TablePrefix()）
+func NewMigrator(baseDB *gorm.DB, dbs *xsync.Map[string, *gorm.DB], strategies ...sharding.Strategy) (*Migrator, error) {
+	baseMigrations, err := loadMigrations(baseDir)
+	if err != nil {
+		return nil, err
+	}
+	shardingMigrations, err := loadMigrations(shardingDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		baseDB:             baseDB,
+		dbs:                dbs,
+		strategies:         strategies,
+		baseMigrations:     baseMigrations,
+		shardingMigrations: shardingMigrations,
+	}, nil
+}
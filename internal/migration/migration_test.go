@@ -0,0 +1,68 @@
+package migration
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	t.Parallel()
+
+	for _, dir := range []string{baseDir, shardingDir} {
+		migrations, err := loadMigrations(dir)
+		require.NoError(t, err)
+		require.NotEmpty(t, migrations)
+
+		for i, m := range migrations {
+			assert.NotEmpty(t, m.Name)
+			assert.NotEmpty(t, m.SQL)
+			if i > 0 {
+				assert.Greater(t, m.Version, migrations[i-1].Version)
+			}
+		}
+	}
+}
+
+func TestTarget_Render(t *testing.T) {
+	t.Parallel()
+
+	migrations, err := loadMigrations(shardingDir)
+	require.NoError(t, err)
+
+	target := Target{
+		DB:    "jotify_1",
+		Shard: "3",
+		tables: map[string]string{
+			"notification": "notification_3",
+			"callback_log": "callback_log_3",
+		},
+	}
+	for _, m := range migrations {
+		sql := target.render(m.SQL)
+		assert.NotContains(t, sql, "${")
+	}
+	assert.Contains(t, target.render(migrations[0].SQL), "CREATE TABLE IF NOT EXISTS `notification_3`")
+	assert.Contains(t, target.render(migrations[0].SQL), "CREATE TABLE IF NOT EXISTS `callback_log_3`")
+	assert.Contains(t, target.render(migrations[1].SQL), "CREATE TABLE IF NOT EXISTS `notification_3_archive` LIKE `notification_3`")
+}
+
+func TestShardingMigrations_Rerunnable(t *testing.T) {
+	t.Parallel()
+
+	migrations, err := loadMigrations(shardingDir)
+	require.NoError(t, err)
+
+	// mysql 的 DDL 无法回滚，一个迁移中最多只能有一条未加检查的 ALTER，否则部分执行失败后无法重复执行
+	for _, m := range migrations {
+		unguarded := 0
+		for _, line := range strings.Split(m.SQL, "\n") {
+			if strings.HasPrefix(line, "ALTER TABLE") {
+				unguarded++
+			}
+		}
+		assert.LessOrEqual(t, unguarded, 1, "migration %d_%s", m.Version, m.Name)
+	}
+}
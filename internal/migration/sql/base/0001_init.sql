@@ -0,0 +1,92 @@
+-- 业务配置
+CREATE TABLE IF NOT EXISTS `biz_conf` (
+    `id`            BIGINT UNSIGNED NOT NULL COMMENT '业务 id',
+    `owner_id`      BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '业务方 id',
+    `owner_type`    VARCHAR(32)     NOT NULL DEFAULT '' COMMENT '业务方类型',
+    `channel_conf`  JSON                     DEFAULT NULL COMMENT '渠道配置',
+    `tx_notif_conf` JSON                     DEFAULT NULL COMMENT '事务消息配置',
+    `rate_limit`    INT             NOT NULL DEFAULT 0 COMMENT '每秒限流',
+    `quota_conf`    JSON                     DEFAULT NULL COMMENT '配额配置',
+    `callback_conf` JSON                     DEFAULT NULL COMMENT '回调配置',
+    `created_at`    BIGINT          NOT NULL DEFAULT 0,
+    `updated_at`    BIGINT          NOT NULL DEFAULT 0,
+    PRIMARY KEY (`id`),
+    KEY `idx_owner` (`owner_type`, `owner_id`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_general_ci COMMENT '业务配置';
+
+-- 渠道模板
+CREATE TABLE IF NOT EXISTS `channel_tpl` (
+    `id`                   BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `owner_id`             BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '业务方 id',
+    `owner_type`           VARCHAR(32)     NOT NULL DEFAULT '' COMMENT '业务方类型',
+    `name`                 VARCHAR(128)    NOT NULL DEFAULT '',
+    `description`          VARCHAR(512)    NOT NULL DEFAULT '',
+    `channel`              VARCHAR(32)     NOT NULL DEFAULT '' COMMENT '渠道',
+    `biz_type`             VARCHAR(32)     NOT NULL DEFAULT '' COMMENT '业务类型',
+    `activated_version_id` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '当前生效的版本 id',
+    `created_at`           BIGINT          NOT NULL DEFAULT 0,
+    `updated_at`           BIGINT          NOT NULL DEFAULT 0,
+    PRIMARY KEY (`id`),
+    KEY `idx_owner` (`owner_type`, `owner_id`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_general_ci COMMENT '渠道模板';
+
+-- 渠道模板版本
+CREATE TABLE IF NOT EXISTS `channel_tpl_version` (
+    `id`             BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `channel_tpl_id` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '模板 id',
+    `name`           VARCHAR(128)    NOT NULL DEFAULT '',
+    `signature`      VARCHAR(64)     NOT NULL DEFAULT '' COMMENT '签名',
+    `content`        TEXT            NOT NULL COMMENT '模板内容',
+    `remark`         VARCHAR(512)    NOT NULL DEFAULT '',
+    `audit_id`       BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '审核记录 id',
+    `auditor_id`     BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '审核人 id',
+    `audit_at`       BIGINT          NOT NULL DEFAULT 0,
+    `audit_status`   VARCHAR(32)     NOT NULL DEFAULT 'pending' COMMENT '审核状态',
+    `reject_reason`  VARCHAR(512)    NOT NULL DEFAULT '',
+    `last_review_at` BIGINT          NOT NULL DEFAULT 0,
+    `created_at`     BIGINT          NOT NULL DEFAULT 0,
+    `updated_at`     BIGINT          NOT NULL DEFAULT 0,
+    PRIMARY KEY (`id`),
+    KEY `idx_channel_tpl_id` (`channel_tpl_id`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_general_ci COMMENT '渠道模板版本';
+
+-- 渠道模板在供应商侧的信息
+CREATE TABLE IF NOT EXISTS `channel_tpl_provider` (
+    `id`               BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `tpl_id`           BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '模板 id',
+    `tpl_version_id`   BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '模板版本 id',
+    `provider_id`      BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '供应商 id',
+    `provider_name`    VARCHAR(64)     NOT NULL DEFAULT '',
+    `provider_channel` VARCHAR(32)     NOT NULL DEFAULT '',
+    `request_id`       VARCHAR(128)    NOT NULL DEFAULT '' COMMENT '供应商审核请求 id',
+    `provider_tpl_id`  VARCHAR(128)    NOT NULL DEFAULT '' COMMENT '供应商侧模板 id',
+    `audit_status`     VARCHAR(32)     NOT NULL DEFAULT 'pending' COMMENT '供应商审核状态',
+    `reject_reason`    VARCHAR(512)    NOT NULL DEFAULT '',
+    `last_review_at`   BIGINT          NOT NULL DEFAULT 0,
+    `created_at`       BIGINT          NOT NULL DEFAULT 0,
+    `updated_at`       BIGINT          NOT NULL DEFAULT 0,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_tpl_version_provider` (`tpl_version_id`, `provider_id`),
+    KEY `idx_provider_tpl` (`provider_name`, `tpl_id`, `tpl_version_id`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_general_ci COMMENT '渠道模板供应商信息';
+
+-- 供应商
+CREATE TABLE IF NOT EXISTS `provider` (
+    `id`           BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `name`         VARCHAR(64)     NOT NULL DEFAULT '',
+    `channel`      VARCHAR(32)     NOT NULL DEFAULT '',
+    `endpoint`     VARCHAR(256)    NOT NULL DEFAULT '',
+    `region_id`    VARCHAR(64)     NOT NULL DEFAULT '',
+    `app_id`       VARCHAR(128)    NOT NULL DEFAULT '',
+    `api_key`      VARCHAR(256)    NOT NULL DEFAULT '',
+    `api_secret`   VARCHAR(256)    NOT NULL DEFAULT '',
+    `weight`       INT             NOT NULL DEFAULT 0 COMMENT '权重',
+    `qps_limit`    INT             NOT NULL DEFAULT 0,
+    `daily_limit`  INT             NOT NULL DEFAULT 0,
+    `callback_url` VARCHAR(256)    NOT NULL DEFAULT '',
+    `status`       VARCHAR(32)     NOT NULL DEFAULT 'active',
+    `created_at`   BIGINT          NOT NULL DEFAULT 0,
+    `updated_at`   BIGINT          NOT NULL DEFAULT 0,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_name_channel` (`name`, `channel`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_general_ci COMMENT '供应商';
@@ -0,0 +1,3 @@
+-- 业务方消息保留天数，0 表示使用 archive.default_retention_days
+ALTER TABLE `biz_conf`
+    ADD COLUMN `retention_days` INT NOT NULL DEFAULT 0 COMMENT '消息保留天数' AFTER `callback_conf`;
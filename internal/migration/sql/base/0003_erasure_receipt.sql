@@ -0,0 +1,11 @@
+-- 个人数据擦除回执，只保存接收者哈希
+CREATE TABLE IF NOT EXISTS `erasure_receipt` (
+    `id`               BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `biz_id`           BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '擦除范围，0 表示全部业务方',
+    `receiver_hash`    CHAR(64)        NOT NULL DEFAULT '',
+    `notification_ids` JSON                     DEFAULT NULL COMMENT '被擦除的消息 id',
+    `operator_biz_id`  BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '发起擦除的业务方',
+    `created_at`       BIGINT          NOT NULL DEFAULT 0,
+    PRIMARY KEY (`id`),
+    KEY `idx_biz_receiver` (`biz_id`, `receiver_hash`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_general_ci COMMENT '个人数据擦除回执';
@@ -0,0 +1,11 @@
+-- 业务方数据密钥，使用主密钥加密后保存，用于加密消息的接收者与模板参数
+CREATE TABLE IF NOT EXISTS `biz_data_key` (
+    `biz_id`        BIGINT UNSIGNED NOT NULL COMMENT '业务 id',
+    `version`       INT UNSIGNED    NOT NULL COMMENT '数据密钥版本，轮换时递增',
+    `master_key_id` VARCHAR(64)     NOT NULL DEFAULT '' COMMENT '加密数据密钥的主密钥 id',
+    `wrapped_key`   VARBINARY(128)  NOT NULL COMMENT '主密钥加密后的数据密钥',
+    `created_at`    BIGINT          NOT NULL DEFAULT 0,
+    `updated_at`    BIGINT          NOT NULL DEFAULT 0,
+    PRIMARY KEY (`biz_id`, `version`),
+    KEY `idx_master_key_id` (`master_key_id`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_general_ci COMMENT '业务方数据密钥';
@@ -0,0 +1,14 @@
+-- 退订名单，只保存接收者哈希，biz_id 为 0 的记录对全部业务方生效
+CREATE TABLE IF NOT EXISTS `suppression` (
+    `id`            BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `biz_id`        BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '业务 id，0 表示全局',
+    `receiver_hash` CHAR(64)        NOT NULL DEFAULT '' COMMENT '规范化接收者的 HMAC-SHA256',
+    `channel`       VARCHAR(32)     NOT NULL DEFAULT '' COMMENT '渠道',
+    `reason`        VARCHAR(32)     NOT NULL DEFAULT '' COMMENT '原因',
+    `expire_at`     BIGINT          NOT NULL DEFAULT 0 COMMENT '过期时间，0 表示永久',
+    `created_at`    BIGINT          NOT NULL DEFAULT 0,
+    `updated_at`    BIGINT          NOT NULL DEFAULT 0,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_receiver_channel_biz` (`receiver_hash`, `channel`, `biz_id`),
+    KEY `idx_biz_id` (`biz_id`, `id`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_general_ci COMMENT '退订名单';
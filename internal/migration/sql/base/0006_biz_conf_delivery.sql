@@ -0,0 +1,3 @@
+-- 业务方投递配置：时区、频控规则与免打扰时段
+ALTER TABLE `biz_conf`
+    ADD COLUMN `delivery_conf` JSON DEFAULT NULL COMMENT '投递配置' AFTER `retention_days`;
@@ -0,0 +1,24 @@
+-- 周期发送计划，每次触发时创建一条消息
+CREATE TABLE IF NOT EXISTS `recurring_schedule` (
+    `id`              BIGINT UNSIGNED NOT NULL COMMENT '与创建计划的消息 id 相同',
+    `biz_id`          BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    `biz_key`         VARCHAR(200)    NOT NULL DEFAULT '' COMMENT '派生消息的 biz_key 为 <biz_key>:<触发时间>',
+    `receivers`       TEXT            NOT NULL COMMENT '接收者，json 数组',
+    `channel`         VARCHAR(32)     NOT NULL DEFAULT '',
+    `tpl_id`          BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    `tpl_version_id`  BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    `tpl_params`      TEXT            NOT NULL COMMENT '模板参数，json 对象',
+    `cron`            VARCHAR(128)    NOT NULL DEFAULT '',
+    `timezone`        VARCHAR(64)     NOT NULL DEFAULT '',
+    `end_at`          BIGINT          NOT NULL DEFAULT 0 COMMENT '结束时间，0 表示不限',
+    `max_occurrences` INT             NOT NULL DEFAULT 0 COMMENT '最多触发次数，0 表示不限',
+    `occurrences`     INT             NOT NULL DEFAULT 0 COMMENT '已触发次数',
+    `next_run_at`     BIGINT          NOT NULL DEFAULT 0 COMMENT '下一次触发时间，0 表示已结束',
+    `status`          VARCHAR(16)     NOT NULL DEFAULT 'active' COMMENT 'active、paused、finished',
+    `version`         INT             NOT NULL DEFAULT 1,
+    `created_at`      BIGINT          NOT NULL DEFAULT 0,
+    `updated_at`      BIGINT          NOT NULL DEFAULT 0,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_biz_id_biz_key` (`biz_id`, `biz_key`),
+    KEY `idx_status_next_run_at` (`status`, `next_run_at`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_general_ci COMMENT '周期发送计划';
@@ -0,0 +1,3 @@
+-- 周期发送计划的调度优先级，每次触发创建的消息沿用该优先级
+ALTER TABLE `recurring_schedule`
+    ADD COLUMN `priority` TINYINT NOT NULL DEFAULT 0 COMMENT '调度优先级，1 低 2 普通 3 高，0 表示按模板业务类型确定' AFTER `tpl_params`;
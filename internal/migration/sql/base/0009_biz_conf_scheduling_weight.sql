@@ -0,0 +1,3 @@
+-- 业务方调度权重，同一分表中多个业务方都有积压时按权重分配每批次的发送份额
+ALTER TABLE `biz_conf`
+    ADD COLUMN `scheduling_weight` INT NOT NULL DEFAULT 0 COMMENT '调度权重，0 表示使用默认权重 1' AFTER `delivery_conf`;
@@ -0,0 +1,36 @@
+-- 群发活动，接收者展开为消息后按 campaign_id 统计发送进度
+CREATE TABLE IF NOT EXISTS `campaign` (
+    `id`            BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `biz_id`        BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    `biz_key`       VARCHAR(180)    NOT NULL DEFAULT '' COMMENT '派生消息的 biz_key 为 <biz_key>:<接收者序号>',
+    `channel`       VARCHAR(32)     NOT NULL DEFAULT '',
+    `tpl_id`        BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    `tpl_params`    TEXT            NOT NULL COMMENT '默认模板参数，json 对象',
+    `priority`      TINYINT         NOT NULL DEFAULT 0 COMMENT '调度优先级，0 表示按模板业务类型确定',
+    `strategy_conf` TEXT            NOT NULL COMMENT '发送策略，json 对象',
+    `status`        VARCHAR(16)     NOT NULL DEFAULT 'draft' COMMENT 'draft、running、paused、canceling、canceled、finished',
+    `total`         BIGINT          NOT NULL DEFAULT 0 COMMENT '已上传的接收者数',
+    `expanded`      BIGINT          NOT NULL DEFAULT 0 COMMENT '已展开的最大接收者序号',
+    `accepted`      BIGINT          NOT NULL DEFAULT 0,
+    `suppressed`    BIGINT          NOT NULL DEFAULT 0,
+    `skipped`       BIGINT          NOT NULL DEFAULT 0,
+    `sent`          BIGINT          NOT NULL DEFAULT 0,
+    `failed`        BIGINT          NOT NULL DEFAULT 0,
+    `canceled`      BIGINT          NOT NULL DEFAULT 0,
+    `version`       INT             NOT NULL DEFAULT 1,
+    `created_at`    BIGINT          NOT NULL DEFAULT 0,
+    `updated_at`    BIGINT          NOT NULL DEFAULT 0,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_biz_id_biz_key` (`biz_id`, `biz_key`),
+    KEY `idx_status` (`status`, `id`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_general_ci COMMENT '群发活动';
+
+-- 群发活动接收者，活动结束或取消后删除
+CREATE TABLE IF NOT EXISTS `campaign_receiver` (
+    `campaign_id` BIGINT UNSIGNED NOT NULL,
+    `seq`         BIGINT          NOT NULL COMMENT '从 1 开始按上传顺序连续编号',
+    `receiver`    TEXT            NOT NULL COMMENT '接收者，开启加密时为密文',
+    `params`      TEXT            NOT NULL COMMENT '覆盖默认值的模板参数，json 对象',
+    `created_at`  BIGINT          NOT NULL DEFAULT 0,
+    PRIMARY KEY (`campaign_id`, `seq`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_general_ci COMMENT '群发活动接收者';
@@ -0,0 +1,25 @@
+-- 组织，owner_type 为 organization 的业务方与渠道模板的 owner_id 为组织 id
+CREATE TABLE IF NOT EXISTS `organization` (
+    `id`            BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `name`          VARCHAR(128)    NOT NULL DEFAULT '',
+    `channel_conf`  JSON                     DEFAULT NULL COMMENT '成员业务方未配置时使用的渠道配置',
+    `rate_limit`    INT             NOT NULL DEFAULT 0 COMMENT '成员业务方未配置时使用的每秒限流',
+    `delivery_conf` JSON                     DEFAULT NULL COMMENT '成员业务方未配置时使用的投递配置',
+    `created_at`    BIGINT          NOT NULL DEFAULT 0,
+    `updated_at`    BIGINT          NOT NULL DEFAULT 0,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_name` (`name`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_general_ci COMMENT '组织';
+
+-- 组织配额池向成员业务方的分配，配额余额保存在 redis
+CREATE TABLE IF NOT EXISTS `org_quota_alloc` (
+    `biz_id`     BIGINT UNSIGNED NOT NULL,
+    `channel`    VARCHAR(32)     NOT NULL,
+    `org_id`     BIGINT UNSIGNED NOT NULL,
+    `cap`        BIGINT          NOT NULL DEFAULT 0 COMMENT '业务方配额余额上限，0 表示不限',
+    `allocated`  BIGINT          NOT NULL DEFAULT 0 COMMENT '累计分配的配额，扣除已收回的部分',
+    `created_at` BIGINT          NOT NULL DEFAULT 0,
+    `updated_at` BIGINT          NOT NULL DEFAULT 0,
+    PRIMARY KEY (`biz_id`, `channel`),
+    KEY `idx_org_id` (`org_id`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_general_ci COMMENT '组织配额分配';
@@ -0,0 +1,17 @@
+-- 模板版本审核记录，channel_tpl_version.audit_id 为最近一次审核记录
+CREATE TABLE IF NOT EXISTS `channel_tpl_audit` (
+    `id`             BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `tpl_id`         BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '模板 id',
+    `tpl_version_id` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '模板版本 id',
+    `stage`          VARCHAR(32)     NOT NULL DEFAULT '' COMMENT '审核阶段，rule 为自动审核，manual 为人工审核',
+    `status`         VARCHAR(32)     NOT NULL DEFAULT '' COMMENT '审核结果，in_preview 表示转人工审核',
+    `reasons`        TEXT            NOT NULL COMMENT '拒绝或转人工审核的原因，json 数组',
+    `auditor_id`     BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '审核人 id，自动审核为 0',
+    `created_at`     BIGINT          NOT NULL DEFAULT 0,
+    PRIMARY KEY (`id`),
+    KEY `idx_tpl_version_id` (`tpl_version_id`, `id`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_general_ci COMMENT '渠道模板版本审核记录';
+
+-- 按审核状态查询待自动审核与待人工审核的版本
+ALTER TABLE `channel_tpl_version`
+    ADD KEY `idx_audit_status` (`audit_status`, `id`);
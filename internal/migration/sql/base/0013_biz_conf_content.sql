@@ -0,0 +1,3 @@
+-- 业务方消息内容配置：模板参数命中敏感词时的处理策略
+ALTER TABLE `biz_conf`
+    ADD COLUMN `content_conf` JSON DEFAULT NULL COMMENT '消息内容配置' AFTER `scheduling_weight`;
@@ -0,0 +1,40 @@
+-- 模板版本的语言变体，与版本的默认内容分别审核，发送时按接收者的语言选择
+CREATE TABLE IF NOT EXISTS `channel_tpl_variant` (
+    `id`             BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `tpl_id`         BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '模板 id',
+    `tpl_version_id` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '模板版本 id',
+    `locale`         VARCHAR(35)     NOT NULL DEFAULT '' COMMENT '规范化的 BCP 47 语言标签',
+    `signature`      VARCHAR(64)     NOT NULL DEFAULT '' COMMENT '签名，为空时使用版本的签名',
+    `content`        TEXT            NOT NULL COMMENT '模板内容',
+    `audit_id`       BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '审核记录 id',
+    `auditor_id`     BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '审核人 id',
+    `audit_at`       BIGINT          NOT NULL DEFAULT 0,
+    `audit_status`   VARCHAR(32)     NOT NULL DEFAULT 'pending' COMMENT '审核状态',
+    `reject_reason`  VARCHAR(512)    NOT NULL DEFAULT '',
+    `created_at`     BIGINT          NOT NULL DEFAULT 0,
+    `updated_at`     BIGINT          NOT NULL DEFAULT 0,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_tpl_version_locale` (`tpl_version_id`, `locale`),
+    KEY `idx_audit_status` (`audit_status`, `id`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_general_ci COMMENT '渠道模板版本语言变体';
+
+-- 审核记录区分版本的默认内容与语言变体
+ALTER TABLE `channel_tpl_audit`
+    ADD COLUMN `tpl_variant_id` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '语言变体 id，0 表示版本的默认内容' AFTER `tpl_version_id`;
+
+-- 语言变体在供应商侧有各自的模板
+ALTER TABLE `channel_tpl_provider`
+    ADD COLUMN `locale` VARCHAR(35) NOT NULL DEFAULT '' COMMENT '语言变体，空表示默认内容' AFTER `tpl_version_id`,
+    DROP KEY `uk_tpl_version_provider`,
+    ADD UNIQUE KEY `uk_tpl_version_provider` (`tpl_version_id`, `provider_id`, `locale`);
+
+-- 接收者的语言偏好，只保存接收者哈希，发送请求没有指定语言时使用
+CREATE TABLE IF NOT EXISTS `receiver_locale` (
+    `biz_id`        BIGINT UNSIGNED NOT NULL COMMENT '业务 id',
+    `receiver_hash` CHAR(64)        NOT NULL COMMENT '规范化接收者的 HMAC-SHA256',
+    `locale`        VARCHAR(35)     NOT NULL DEFAULT '' COMMENT '规范化的 BCP 47 语言标签',
+    `created_at`    BIGINT          NOT NULL DEFAULT 0,
+    `updated_at`    BIGINT          NOT NULL DEFAULT 0,
+    PRIMARY KEY (`biz_id`, `receiver_hash`),
+    KEY `idx_receiver_hash` (`receiver_hash`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_general_ci COMMENT '接收者语言偏好';
@@ -0,0 +1,34 @@
+-- ${notification} 与 ${callback_log} 在执行时替换为实际的分表名
+CREATE TABLE IF NOT EXISTS `${notification}` (
+    `id`             BIGINT UNSIGNED NOT NULL COMMENT '雪花算法 id，包含分库分表 hash',
+    `biz_id`         BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    `biz_key`        VARCHAR(256)    NOT NULL DEFAULT '',
+    `receivers`      TEXT            NOT NULL COMMENT '接收者，json 数组',
+    `channel`        VARCHAR(32)     NOT NULL DEFAULT '',
+    `tpl_id`         BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    `tpl_version_id` BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    `tpl_params`     TEXT            NOT NULL COMMENT '模板参数，json 对象',
+    `status`         VARCHAR(32)     NOT NULL DEFAULT 'prepare',
+    `schedule_strat` BIGINT          NOT NULL DEFAULT 0 COMMENT '计划发送开始时间',
+    `schedule_end`   BIGINT          NOT NULL DEFAULT 0 COMMENT '计划发送结束时间',
+    `version`        INT             NOT NULL DEFAULT 1 COMMENT '乐观锁版本',
+    `trace_ctx`      VARCHAR(512)    NOT NULL DEFAULT '' COMMENT '链路追踪上下文',
+    `created_at`     BIGINT          NOT NULL DEFAULT 0,
+    `updated_at`     BIGINT          NOT NULL DEFAULT 0,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_biz_id_biz_key` (`biz_id`, `biz_key`),
+    KEY `idx_status_schedule` (`status`, `schedule_strat`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_general_ci COMMENT '消息';
+
+CREATE TABLE IF NOT EXISTS `${callback_log}` (
+    `id`              BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `notification_id` BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    `retried_times`   INT             NOT NULL DEFAULT 0,
+    `next_retry_at`   BIGINT          NOT NULL DEFAULT 0,
+    `status`          VARCHAR(32)     NOT NULL DEFAULT 'init',
+    `created_at`      BIGINT          NOT NULL DEFAULT 0,
+    `updated_at`      BIGINT          NOT NULL DEFAULT 0,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_notification_id` (`notification_id`),
+    KEY `idx_status_next_retry_at` (`status`, `next_retry_at`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_general_ci COMMENT '回调记录';
@@ -0,0 +1,4 @@
+-- 归档表与分表位于同一个库中，表名为分表名加 _archive 后缀
+CREATE TABLE IF NOT EXISTS `${notification}_archive` LIKE `${notification}`;
+
+CREATE TABLE IF NOT EXISTS `${callback_log}_archive` LIKE `${callback_log}`;
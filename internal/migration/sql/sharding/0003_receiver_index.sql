@@ -0,0 +1,9 @@
+-- 接收者索引，与分表位于同一个库中，用于按接收者查找消息（个人数据擦除）
+CREATE TABLE IF NOT EXISTS `${notification}_receiver` (
+    `receiver_hash`   CHAR(64)        NOT NULL COMMENT '规范化接收者的 HMAC-SHA256',
+    `notification_id` BIGINT UNSIGNED NOT NULL,
+    `biz_id`          BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    `created_at`      BIGINT          NOT NULL DEFAULT 0,
+    PRIMARY KEY (`receiver_hash`, `notification_id`),
+    KEY `idx_notification_id` (`notification_id`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_general_ci COMMENT '接收者索引';
@@ -0,0 +1,22 @@
+-- 接收者加密后无法按取值查询，增加接收者的 HMAC-SHA256 用于等值查询
+-- 归档通过 INSERT ... SELECT * 迁移数据，归档表需要保持相同的字段顺序
+-- 每条 ALTER 执行前检查字段是否已存在，部分执行失败后可以重复执行
+SET @ddl = IF(
+    (SELECT COUNT(*) FROM information_schema.COLUMNS
+     WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = '${notification}' AND COLUMN_NAME = 'receivers_hash') = 0,
+    'ALTER TABLE `${notification}` ADD COLUMN `receivers_hash` CHAR(64) NOT NULL DEFAULT '''' COMMENT ''规范化接收者的 HMAC-SHA256'' AFTER `receivers`, ADD KEY `idx_receivers_hash` (`receivers_hash`)',
+    'DO 0'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @ddl = IF(
+    (SELECT COUNT(*) FROM information_schema.COLUMNS
+     WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = '${notification}_archive' AND COLUMN_NAME = 'receivers_hash') = 0,
+    'ALTER TABLE `${notification}_archive` ADD COLUMN `receivers_hash` CHAR(64) NOT NULL DEFAULT '''' COMMENT ''规范化接收者的 HMAC-SHA256'' AFTER `receivers`, ADD KEY `idx_receivers_hash` (`receivers_hash`)',
+    'DO 0'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
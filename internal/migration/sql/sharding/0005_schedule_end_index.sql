@@ -0,0 +1,3 @@
+-- 过期任务按 (status, schedule_end) 查找发送时间窗口已结束的待发送消息
+ALTER TABLE `${notification}`
+    ADD KEY `idx_status_schedule_end` (`status`, `schedule_end`);
@@ -0,0 +1,22 @@
+-- 调度优先级，调度器按优先级从高到低拉取待发送消息
+-- 归档通过 INSERT ... SELECT * 迁移数据，归档表需要保持相同的字段顺序
+-- 每条 ALTER 执行前检查字段是否已存在，部分执行失败后可以重复执行
+SET @ddl = IF(
+    (SELECT COUNT(*) FROM information_schema.COLUMNS
+     WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = '${notification}' AND COLUMN_NAME = 'priority') = 0,
+    'ALTER TABLE `${notification}` ADD COLUMN `priority` TINYINT NOT NULL DEFAULT 2 COMMENT ''调度优先级，1 低 2 普通 3 高'' AFTER `status`, ADD KEY `idx_status_priority_schedule` (`status`, `priority`, `schedule_strat`)',
+    'DO 0'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @ddl = IF(
+    (SELECT COUNT(*) FROM information_schema.COLUMNS
+     WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = '${notification}_archive' AND COLUMN_NAME = 'priority') = 0,
+    'ALTER TABLE `${notification}_archive` ADD COLUMN `priority` TINYINT NOT NULL DEFAULT 2 COMMENT ''调度优先级，1 低 2 普通 3 高'' AFTER `status`',
+    'DO 0'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
@@ -0,0 +1,5 @@
+-- 调度器在每个优先级内按业务方统计积压并分别拉取待发送消息
+-- 新索引以 (status, priority) 为前缀，可以替代 idx_status_priority_schedule
+ALTER TABLE `${notification}`
+    ADD KEY `idx_status_priority_biz_schedule` (`status`, `priority`, `biz_id`, `schedule_strat`),
+    DROP KEY `idx_status_priority_schedule`;
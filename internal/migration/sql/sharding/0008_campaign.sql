@@ -0,0 +1,22 @@
+-- 群发活动展开的消息记录活动 id，按活动统计发送进度
+-- 归档通过 INSERT ... SELECT * 迁移数据，归档表需要保持相同的字段顺序
+-- 每条 ALTER 执行前检查字段是否已存在，部分执行失败后可以重复执行
+SET @ddl = IF(
+    (SELECT COUNT(*) FROM information_schema.COLUMNS
+     WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = '${notification}' AND COLUMN_NAME = 'campaign_id') = 0,
+    'ALTER TABLE `${notification}` ADD COLUMN `campaign_id` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT ''群发活动 id，0 表示不属于活动'' AFTER `priority`, ADD KEY `idx_campaign_status` (`campaign_id`, `status`)',
+    'DO 0'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @ddl = IF(
+    (SELECT COUNT(*) FROM information_schema.COLUMNS
+     WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = '${notification}_archive' AND COLUMN_NAME = 'campaign_id') = 0,
+    'ALTER TABLE `${notification}_archive` ADD COLUMN `campaign_id` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT ''群发活动 id，0 表示不属于活动'' AFTER `priority`',
+    'DO 0'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
@@ -0,0 +1,22 @@
+-- 消息记录实际使用的模板语言变体，空表示默认内容
+-- 归档通过 INSERT ... SELECT * 迁移数据，归档表需要保持相同的字段顺序
+-- 每条 ALTER 执行前检查字段是否已存在，部分执行失败后可以重复执行
+SET @ddl = IF(
+    (SELECT COUNT(*) FROM information_schema.COLUMNS
+     WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = '${notification}' AND COLUMN_NAME = 'locale') = 0,
+    'ALTER TABLE `${notification}` ADD COLUMN `locale` VARCHAR(35) NOT NULL DEFAULT '''' COMMENT ''模板语言变体，空表示默认内容'' AFTER `tpl_params`',
+    'DO 0'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @ddl = IF(
+    (SELECT COUNT(*) FROM information_schema.COLUMNS
+     WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = '${notification}_archive' AND COLUMN_NAME = 'locale') = 0,
+    'ALTER TABLE `${notification}_archive` ADD COLUMN `locale` VARCHAR(35) NOT NULL DEFAULT '''' COMMENT ''模板语言变体，空表示默认内容'' AFTER `tpl_params`',
+    'DO 0'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
@@ -0,0 +1,22 @@
+-- 发送时间窗口的结束时间是否由调用方指定（time_window 或 deadline），免打扰推迟发送时不超出该时间
+-- 归档通过 INSERT ... SELECT * 迁移数据，归档表需要保持相同的字段顺序
+-- 每条 ALTER 执行前检查字段是否已存在，部分执行失败后可以重复执行
+SET @ddl = IF(
+    (SELECT COUNT(*) FROM information_schema.COLUMNS
+     WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = '${notification}' AND COLUMN_NAME = 'end_fixed') = 0,
+    'ALTER TABLE `${notification}` ADD COLUMN `end_fixed` TINYINT(1) NOT NULL DEFAULT 0 COMMENT ''窗口结束时间是否由调用方指定'' AFTER `schedule_end`',
+    'DO 0'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @ddl = IF(
+    (SELECT COUNT(*) FROM information_schema.COLUMNS
+     WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = '${notification}_archive' AND COLUMN_NAME = 'end_fixed') = 0,
+    'ALTER TABLE `${notification}_archive` ADD COLUMN `end_fixed` TINYINT(1) NOT NULL DEFAULT 0 COMMENT ''窗口结束时间是否由调用方指定'' AFTER `schedule_end`',
+    'DO 0'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
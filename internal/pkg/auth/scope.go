@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/JrMarcco/jotify/internal/errs"
+)
+
+// Scope token 授权范围
+type Scope string
+
+const (
+	ScopeSend        Scope = "send"            // 发送、取消消息
+	ScopeQuery       Scope = "query"           // 查询消息
+	ScopeTplReview   Scope = "template_review" // 审核模板
+	ScopeErasure     Scope = "erasure"         // 擦除接收者的个人数据
+	ScopeSuppression Scope = "suppression"     // 管理退订名单
+	ScopeAdmin       Scope = "admin"           // 管理操作，拥有全部权限，并允许跨业务方访问
+)
+
+func (s Scope) String() string {
+	return string(s)
+}
+
+// DefaultScopes 不带 scopes 声明的 token（引入授权范围之前签发）拥有的授权范围
+var DefaultScopes = []Scope{ScopeSend, ScopeQuery}
+
+type contextKeyScopes struct{}
+
+// WithScopes 在 context.Context 内写入授权范围
+func WithScopes(ctx context.Context, scopes []Scope) context.Context {
+	return context.WithValue(ctx, contextKeyScopes{}, scopes)
+}
+
+// ScopesFromContext 从 context.Context 获取授权范围
+func ScopesFromContext(ctx context.Context) ([]Scope, bool) {
+	scopes, ok := ctx.Value(contextKeyScopes{}).([]Scope)
+	return scopes, ok
+}
+
+// HasScope 判断 context 中是否拥有指定授权范围，admin 拥有全部授权范围
+func HasScope(ctx context.Context, scope Scope) bool {
+	scopes, ok := ScopesFromContext(ctx)
+	if !ok {
+		return false
+	}
+	return slices.Contains(scopes, scope) || slices.Contains(scopes, ScopeAdmin)
+}
+
+// CheckScope 校验授权范围，不满足时返回 errs.ErrPermissionDenied
+func CheckScope(ctx context.Context, scope Scope) error {
+	if !HasScope(ctx, scope) {
+		return fmt.Errorf("%w: scope %q required", errs.ErrPermissionDenied, scope)
+	}
+	return nil
+}
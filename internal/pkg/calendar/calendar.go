@@ -0,0 +1,109 @@
+package calendar
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+var locations sync.Map // map[string]*time.Location
+
+// LoadLocation 与 time.LoadLocation 相同，结果缓存在本地，name 为空时返回 UTC
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
+
+// Period 计数周期
+type Period string
+
+const (
+	PeriodHour Period = "hour"
+	PeriodDay  Period = "day"
+)
+
+func (p Period) Validate() bool {
+	return p == PeriodHour || p == PeriodDay
+}
+
+// Start 返回 t 在其时区中所在周期的开始时间
+func (p Period) Start(t time.Time) time.Time {
+	switch p {
+	case PeriodHour:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	}
+}
+
+// Duration 周期的长度，夏令时切换当天按 24 小时计算
+func (p Period) Duration() time.Duration {
+	if p == PeriodHour {
+		return time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// Window 每天重复的时段，按分钟计算，Start 大于 End 时跨越零点（如 22:00-08:00）
+type Window struct {
+	start int // 距零点的分钟数
+	end   int
+}
+
+// ParseWindow 解析 HH:MM 格式的开始与结束时间
+func ParseWindow(start string, end string) (Window, error) {
+	s, err := parseClock(start)
+	if err != nil {
+		return Window{}, err
+	}
+	e, err := parseClock(end)
+	if err != nil {
+		return Window{}, err
+	}
+	if s == e {
+		return Window{}, fmt.Errorf("window start and end should not be equal: %s", start)
+	}
+	return Window{start: s, end: e}, nil
+}
+
+// Contains 判断 t 在其时区中是否位于时段内，包含开始时间不包含结束时间
+func (w Window) Contains(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	if w.start < w.end {
+		return minute >= w.start && minute < w.end
+	}
+	return minute >= w.start || minute < w.end
+}
+
+// End t 位于时段内时返回时段的结束时间，否则返回 false
+func (w Window) End(t time.Time) (time.Time, bool) {
+	if !w.Contains(t) {
+		return time.Time{}, false
+	}
+
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	// 跨越零点的时段在零点之前时，结束时间在第二天
+	if w.start > w.end && t.Hour()*60+t.Minute() >= w.start {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day.Add(time.Duration(w.end) * time.Minute), true
+}
+
+func parseClock(clock string) (int, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, fmt.Errorf("invalid clock %q, should be HH:MM: %w", clock, err)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
@@ -0,0 +1,108 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWindow(t *testing.T) {
+	t.Parallel()
+
+	loc, err := LoadLocation("Asia/Shanghai")
+	require.NoError(t, err)
+
+	tcs := []struct {
+		name    string
+		start   string
+		end     string
+		at      time.Time
+		wantEnd time.Time
+		wantIn  bool
+	}{
+		{
+			name:    "overnight before midnight",
+			start:   "22:00",
+			end:     "08:00",
+			at:      time.Date(2025, 1, 1, 23, 30, 0, 0, loc),
+			wantEnd: time.Date(2025, 1, 2, 8, 0, 0, 0, loc),
+			wantIn:  true,
+		}, {
+			name:    "overnight after midnight",
+			start:   "22:00",
+			end:     "08:00",
+			at:      time.Date(2025, 1, 1, 3, 0, 0, 0, loc),
+			wantEnd: time.Date(2025, 1, 1, 8, 0, 0, 0, loc),
+			wantIn:  true,
+		}, {
+			name:   "overnight outside",
+			start:  "22:00",
+			end:    "08:00",
+			at:     time.Date(2025, 1, 1, 8, 0, 0, 0, loc),
+			wantIn: false,
+		}, {
+			name:    "same day",
+			start:   "12:00",
+			end:     "14:00",
+			at:      time.Date(2025, 1, 1, 12, 0, 0, 0, loc),
+			wantEnd: time.Date(2025, 1, 1, 14, 0, 0, 0, loc),
+			wantIn:  true,
+		}, {
+			name:   "same day outside",
+			start:  "12:00",
+			end:    "14:00",
+			at:     time.Date(2025, 1, 1, 14, 0, 0, 0, loc),
+			wantIn: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			w, err := ParseWindow(tc.start, tc.end)
+			require.NoError(t, err)
+
+			end, in := w.End(tc.at)
+			assert.Equal(t, tc.wantIn, in)
+			if tc.wantIn {
+				assert.True(t, tc.wantEnd.Equal(end), "want %s, got %s", tc.wantEnd, end)
+			}
+		})
+	}
+
+	_, err = ParseWindow("25:00", "08:00")
+	assert.Error(t, err)
+	_, err = ParseWindow("08:00", "08:00")
+	assert.Error(t, err)
+}
+
+func TestPeriod_Start(t *testing.T) {
+	t.Parallel()
+
+	loc, err := LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	at := time.Date(2025, 3, 1, 15, 42, 10, 0, loc)
+	assert.True(t, time.Date(2025, 3, 1, 0, 0, 0, 0, loc).Equal(PeriodDay.Start(at)))
+	assert.True(t, time.Date(2025, 3, 1, 15, 0, 0, 0, loc).Equal(PeriodHour.Start(at)))
+}
+
+func TestCron_Next(t *testing.T) {
+	t.Parallel()
+
+	c, err := ParseCron("0 9 * * 1-5", "Asia/Shanghai")
+	require.NoError(t, err)
+
+	loc, err := LoadLocation("Asia/Shanghai")
+	require.NoError(t, err)
+
+	// 周五 10:00 之后的下一次触发时间是下周一 09:00
+	next := c.Next(time.Date(2025, 1, 3, 10, 0, 0, 0, loc).UTC())
+	assert.True(t, time.Date(2025, 1, 6, 9, 0, 0, 0, loc).Equal(next), "got %s", next)
+
+	_, err = ParseCron("0 9 * *", "")
+	assert.Error(t, err)
+	_, err = ParseCron("@daily", "Mars/Base")
+	assert.Error(t, err)
+}
@@ -0,0 +1,35 @@
+package calendar
+
+import (
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// cronParser 标准 5 段 cron 表达式（分 时 日 月 周），支持 @daily 等描述符
+var cronParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// Cron 按指定时区计算触发时间的 cron 表达式
+type Cron struct {
+	schedule cron.Schedule
+	loc      *time.Location
+}
+
+// ParseCron 解析 cron 表达式，tz 为空时使用 UTC
+func ParseCron(expr string, tz string) (Cron, error) {
+	schedule, err := cronParser.Parse(expr)
+	if err != nil {
+		return Cron{}, err
+	}
+
+	loc, err := LoadLocation(tz)
+	if err != nil {
+		return Cron{}, err
+	}
+	return Cron{schedule: schedule, loc: loc}, nil
+}
+
+// Next 返回 t 之后的下一次触发时间，没有下一次触发时间时返回零值
+func (c Cron) Next(t time.Time) time.Time {
+	return c.schedule.Next(t.In(c.loc))
+}
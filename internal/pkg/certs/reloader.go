@@ -0,0 +1,214 @@
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Reloader 从磁盘加载证书与 CA 证书，并在文件变更后自动重新加载，无需重启进程。
+//
+// certFile / keyFile 为本端证书，caFile 为验证对端证书使用的 CA 证书包，均可为空：
+//   - 作为服务端时 caFile 非空即开启 mTLS，要求并验证客户端证书
+//   - 作为客户端时 certFile 非空即向服务端出示客户端证书，caFile 为空时使用系统 CA 验证服务端证书
+type Reloader struct {
+	certFile string
+	keyFile  string
+	caFile   string
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	caPool  *x509.CertPool
+	modTime time.Time // 所有文件中最新的修改时间
+
+	logger *zap.Logger
+}
+
+// Reload 重新加载证书，加载失败时保留上一次的有效证书
+func (r *Reloader) Reload() error {
+	var cert *tls.Certificate
+	if r.certFile != "" {
+		c, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load key pair: %w", err)
+		}
+		cert = &c
+	}
+
+	var caPool *x509.CertPool
+	if r.caFile != "" {
+		pem, err := os.ReadFile(r.caFile)
+		if err != nil {
+			return fmt.Errorf("failed to read ca file: %w", err)
+		}
+		caPool = x509.NewCertPool()
+		if !caPool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no valid certificate found in ca file %s", r.caFile)
+		}
+	}
+
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cert = cert
+	r.caPool = caPool
+	r.modTime = modTime
+	return nil
+}
+
+// Watch 按 interval 轮询文件修改时间，有变更时重新加载。
+//
+// 证书通常由 cert-manager 等工具以替换文件的方式更新，轮询修改时间比监听文件事件更可靠。
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			modTime, err := r.latestModTime()
+			if err != nil {
+				r.logger.Error("[jotify] failed to stat certificate files", zap.Error(err))
+				continue
+			}
+
+			r.mu.RLock()
+			changed := modTime.After(r.modTime)
+			r.mu.RUnlock()
+			if !changed {
+				continue
+			}
+
+			if err = r.Reload(); err != nil {
+				r.logger.Error("[jotify] failed to reload certificates", zap.Error(err))
+				continue
+			}
+			r.logger.Info("[jotify] certificates reloaded",
+				zap.String("cert_file", r.certFile),
+				zap.String("ca_file", r.caFile),
+			)
+		}
+	}
+}
+
+func (r *Reloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, file := range []string{r.certFile, r.keyFile, r.caFile} {
+		if file == "" {
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+func (r *Reloader) certificate() (*tls.Certificate, *x509.CertPool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, r.caPool
+}
+
+// ServerConfig 服务端 tls 配置，每次握手使用当前加载的证书与 CA
+func (r *Reloader) ServerConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cert, caPool := r.certificate()
+			if cert == nil {
+				return nil, errors.New("server certificate not loaded")
+			}
+
+			cfg := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*cert},
+				NextProtos:   []string{"h2"},
+			}
+			if caPool != nil {
+				cfg.ClientAuth = tls.RequireAndVerifyClientCert
+				cfg.ClientCAs = caPool
+			}
+			return cfg, nil
+		},
+	}
+}
+
+// ClientConfig 客户端 tls 配置，serverName 为空时使用连接地址中的主机名。
+//
+// 配置了 CA 证书包时跳过默认的证书验证，改为在 VerifyConnection 中使用当前加载的 CA 验证，
+// 以便 CA 轮换后新建的连接无需重启即可生效。
+func (r *Reloader) ClientConfig(serverName string) *tls.Config {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: serverName,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, _ := r.certificate()
+			if cert == nil {
+				// 未配置客户端证书时不出示证书
+				return &tls.Certificate{}, nil
+			}
+			return cert, nil
+		},
+	}
+
+	if r.caFile == "" {
+		return cfg
+	}
+
+	cfg.InsecureSkipVerify = true
+	cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+		_, caPool := r.certificate()
+		if len(cs.PeerCertificates) == 0 {
+			return errors.New("no server certificate presented")
+		}
+
+		intermediates := x509.NewCertPool()
+		for _, cert := range cs.PeerCertificates[1:] {
+			intermediates.AddCert(cert)
+		}
+		_, err := cs.PeerCertificates[0].Verify(x509.VerifyOptions{
+			Roots:         caPool,
+			Intermediates: intermediates,
+			DNSName:       cs.ServerName,
+		})
+		return err
+	}
+	return cfg
+}
+
+// NewReloader 创建并立即加载证书，加载失败时返回错误
+func NewReloader(certFile, keyFile, caFile string, logger *zap.Logger) (*Reloader, error) {
+	if (certFile == "") != (keyFile == "") {
+		return nil, errors.New("cert file and key file should be set together")
+	}
+
+	r := &Reloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		caFile:   caFile,
+		logger:   logger,
+	}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
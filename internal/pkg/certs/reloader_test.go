@@ -0,0 +1,114 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestReloader_MutualTLS(t *testing.T) {
+	dir := t.TempDir()
+
+	ca, caKey := newCert(t, nil, nil, "test-ca")
+	writeCert(t, dir, "ca", ca, nil)
+	srvCert, srvKey := newCert(t, ca, caKey, "localhost")
+	writeCert(t, dir, "server", srvCert, srvKey)
+	cliCert, cliKey := newCert(t, ca, caKey, "client")
+	writeCert(t, dir, "client", cliCert, cliKey)
+
+	server, err := NewReloader(
+		filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key"), filepath.Join(dir, "ca.crt"), zap.NewNop(),
+	)
+	require.NoError(t, err)
+	client, err := NewReloader(
+		filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key"), filepath.Join(dir, "ca.crt"), zap.NewNop(),
+	)
+	require.NoError(t, err)
+	assert.NoError(t, handshake(server.ServerConfig(), client.ClientConfig("localhost")))
+
+	// 客户端不出示证书
+	noCert, err := NewReloader("", "", filepath.Join(dir, "ca.crt"), zap.NewNop())
+	require.NoError(t, err)
+	assert.Error(t, handshake(server.ServerConfig(), noCert.ClientConfig("localhost")))
+
+	// 服务端轮换为其他 CA 签发的证书，客户端重新加载前验证失败
+	otherCa, otherCaKey := newCert(t, nil, nil, "other-ca")
+	srvCert, srvKey = newCert(t, otherCa, otherCaKey, "localhost")
+	writeCert(t, dir, "server", srvCert, srvKey)
+	require.NoError(t, server.Reload())
+	assert.Error(t, handshake(server.ServerConfig(), client.ClientConfig("localhost")))
+}
+
+func handshake(serverCfg, clientCfg *tls.Config) error {
+	sc, cc := net.Pipe()
+	defer func() { _ = sc.Close() }()
+	defer func() { _ = cc.Close() }()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- tls.Server(sc, serverCfg).Handshake()
+		_ = sc.Close()
+	}()
+
+	cliErr := tls.Client(cc, clientCfg).Handshake()
+	_ = cc.Close()
+	srvErr := <-errCh
+	if cliErr != nil {
+		return cliErr
+	}
+	return srvErr
+}
+
+// newCert 创建证书，parent 为 nil 时创建自签名 CA 证书
+func newCert(t *testing.T, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, cn string) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{cn},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		tpl.IsCA = true
+		tpl.BasicConstraintsValid = true
+		tpl.KeyUsage |= x509.KeyUsageCertSign
+		parent, parentKey = tpl, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tpl, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key
+}
+
+func writeCert(t *testing.T, dir, name string, cert *x509.Certificate, key *ecdsa.PrivateKey) {
+	certPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name+".crt"), certPem, 0o600))
+
+	if key != nil {
+		der, err := x509.MarshalECPrivateKey(key)
+		require.NoError(t, err)
+		keyPem := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name+".key"), keyPem, 0o600))
+	}
+}
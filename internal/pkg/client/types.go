@@ -1,6 +1,9 @@
 package client
 
-import "context"
+import (
+	"context"
+	"sync/atomic"
+)
 
 const (
 	AttrWeight      = "attr_weight"
@@ -41,8 +44,12 @@ func ReqTypeFromContext(ctx context.Context) (uint8, bool) {
 
 type contextKeyBizId struct{}
 
-// WithBizId 在 context.Context 内写入 business id
+// WithBizId 在 context.Context 内写入 business id，context.Context 中有 BizIdRecorder 时同时记录到 recorder 中
 func WithBizId(ctx context.Context, bizId uint64) context.Context {
+	if recorder, ok := ctx.Value(contextKeyBizIdRecorder{}).(*BizIdRecorder); ok {
+		recorder.bizId.Store(bizId)
+		recorder.recorded.Store(true)
+	}
 	return context.WithValue(ctx, contextKeyBizId{}, bizId)
 }
 
@@ -53,6 +60,34 @@ func BizIdFromContext(ctx context.Context) (uint64, bool) {
 	return bizId, ok
 }
 
+type contextKeyBizIdRecorder struct{}
+
+// BizIdRecorder 记录内层通过 WithBizId 写入的 business id。
+//
+// 外层拦截器（例如位于 jwt 拦截器之前的访问日志）无法获取内层写入的 context.Context，
+// 通过 recorder 在请求结束后读取 business id。
+type BizIdRecorder struct {
+	bizId    atomic.Uint64
+	recorded atomic.Bool
+}
+
+// Load 获取记录的 business id，未写入时返回 false
+func (r *BizIdRecorder) Load() (uint64, bool) {
+	if !r.recorded.Load() {
+		return 0, false
+	}
+	return r.bizId.Load(), true
+}
+
+// WithBizIdRecorder 在 context.Context 内写入 BizIdRecorder，已有 recorder 时复用
+func WithBizIdRecorder(ctx context.Context) (context.Context, *BizIdRecorder) {
+	if recorder, ok := ctx.Value(contextKeyBizIdRecorder{}).(*BizIdRecorder); ok {
+		return ctx, recorder
+	}
+	recorder := new(BizIdRecorder)
+	return context.WithValue(ctx, contextKeyBizIdRecorder{}, recorder), recorder
+}
+
 type contextKeyBizKey struct{}
 
 // WithBizKey 在 context.Context 写入 business key
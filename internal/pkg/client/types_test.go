@@ -0,0 +1,33 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBizIdRecorder(t *testing.T) {
+	t.Parallel()
+
+	ctx, recorder := WithBizIdRecorder(context.Background())
+	_, ok := recorder.Load()
+	assert.False(t, ok)
+
+	// 外层已有 recorder 时复用，内层写入的业务 id 对所有外层可见
+	nested, nestedRecorder := WithBizIdRecorder(ctx)
+	assert.Same(t, recorder, nestedRecorder)
+
+	inner := WithBizId(nested, 42)
+	bizId, ok := recorder.Load()
+	assert.True(t, ok)
+	assert.Equal(t, uint64(42), bizId)
+
+	bizId, ok = BizIdFromContext(inner)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(42), bizId)
+
+	// 外层 context 本身不包含业务 id
+	_, ok = BizIdFromContext(ctx)
+	assert.False(t, ok)
+}
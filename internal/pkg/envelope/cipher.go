@@ -0,0 +1,342 @@
+package envelope
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// prefix 密文前缀，格式为 enc:v1:<数据密钥版本>:<base64(nonce + 密文)>，没有该前缀的值视为未加密的历史数据
+	prefix  = "enc:v1:"
+	keySize = 32
+)
+
+var (
+	ErrMasterKeyNotFound = errors.New("[jotify] master key not found")
+	ErrDataKeyNotFound   = errors.New("[jotify] data key not found")
+	ErrDataKeyConflict   = errors.New("[jotify] data key version already exists")
+	ErrInvalidCiphertext = errors.New("[jotify] invalid ciphertext")
+)
+
+// DataKey 业务方数据密钥，WrappedKey 为主密钥加密后的数据密钥
+type DataKey struct {
+	BizId       uint64
+	Version     uint32
+	MasterKeyId string
+	WrappedKey  []byte
+}
+
+// DataKeyStore 数据密钥存储
+type DataKeyStore interface {
+	// Latest 获取业务方最新版本的数据密钥，不存在时返回 ErrDataKeyNotFound
+	Latest(ctx context.Context, bizId uint64) (DataKey, error)
+	// Get 获取业务方指定版本的数据密钥，不存在时返回 ErrDataKeyNotFound
+	Get(ctx context.Context, bizId uint64, version uint32) (DataKey, error)
+	// Create 保存数据密钥，版本已存在时返回 ErrDataKeyConflict
+	Create(ctx context.Context, key DataKey) error
+	// FindNotWrappedBy 查找不是由指定主密钥加密的数据密钥
+	FindNotWrappedBy(ctx context.Context, masterKeyId string, limit int) ([]DataKey, error)
+	// Rewrap 更新数据密钥的加密结果，只有仍由 prevMasterKeyId 加密时才会更新
+	Rewrap(ctx context.Context, key DataKey, prevMasterKeyId string) error
+}
+
+type keyRef struct {
+	bizId   uint64
+	version uint32
+}
+
+type latestVersion struct {
+	version  uint32
+	expireAt time.Time
+}
+
+// Cipher 信封加密，每个业务方使用各自的数据密钥加密数据，数据密钥由主密钥加密后保存。
+//
+// 解密后的数据密钥缓存在本地，不会变化。
+// 最新版本号缓存 latestTTL，轮换数据密钥后各实例在 latestTTL 内陆续切换到新版本，
+// 旧版本的数据密钥继续用于解密，轮换期间无需停机。
+type Cipher struct {
+	store     DataKeyStore
+	keySource KeySource
+	latestTTL time.Duration
+
+	mu     sync.RWMutex
+	latest map[uint64]latestVersion
+	keys   map[keyRef][]byte
+}
+
+// Encrypt 使用业务方最新版本的数据密钥加密
+func (c *Cipher) Encrypt(ctx context.Context, bizId uint64, plaintext string) (string, error) {
+	version, key, err := c.latestKey(ctx, bizId)
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := seal(key, []byte(plaintext), dataAad(bizId))
+	if err != nil {
+		return "", err
+	}
+	return prefix + strconv.FormatUint(uint64(version), 10) + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt 解密 Encrypt 的结果，未加密的值原样返回
+func (c *Cipher) Decrypt(ctx context.Context, bizId uint64, val string) (string, error) {
+	if !IsEncrypted(val) {
+		return val, nil
+	}
+
+	versionStr, payload, ok := strings.Cut(strings.TrimPrefix(val, prefix), ":")
+	if !ok {
+		return "", ErrInvalidCiphertext
+	}
+	version, err := strconv.ParseUint(versionStr, 10, 32)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidCiphertext, err)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidCiphertext, err)
+	}
+
+	key, err := c.dataKey(ctx, bizId, uint32(version))
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(key, sealed, dataAad(bizId))
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// Rotate 为业务方生成新版本的数据密钥，返回新版本号
+func (c *Cipher) Rotate(ctx context.Context, bizId uint64) (uint32, error) {
+	var version uint32 = 1
+	latest, err := c.store.Latest(ctx, bizId)
+	switch {
+	case err == nil:
+		version = latest.Version + 1
+	case !errors.Is(err, ErrDataKeyNotFound):
+		return 0, err
+	}
+
+	if _, err = c.create(ctx, bizId, version); err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	delete(c.latest, bizId)
+	c.mu.Unlock()
+	return version, nil
+}
+
+// Rewrap 使用当前主密钥重新加密其他主密钥加密的数据密钥，返回处理的数量
+func (c *Cipher) Rewrap(ctx context.Context, batchSize int) (int, error) {
+	currentId := c.keySource.CurrentId()
+	master, err := c.keySource.Get(currentId)
+	if err != nil {
+		return 0, err
+	}
+
+	cnt := 0
+	for {
+		dks, err := c.store.FindNotWrappedBy(ctx, currentId, batchSize)
+		if err != nil {
+			return cnt, err
+		}
+		if len(dks) == 0 {
+			return cnt, nil
+		}
+
+		for _, dk := range dks {
+			key, err := c.unwrap(dk)
+			if err != nil {
+				return cnt, err
+			}
+			wrapped, err := seal(master, key, wrapAad(dk.BizId, dk.Version))
+			if err != nil {
+				return cnt, err
+			}
+
+			prevMasterKeyId := dk.MasterKeyId
+			dk.MasterKeyId, dk.WrappedKey = currentId, wrapped
+			if err = c.store.Rewrap(ctx, dk, prevMasterKeyId); err != nil {
+				return cnt, err
+			}
+			cnt++
+		}
+	}
+}
+
+// latestKey 获取业务方最新版本的数据密钥，不存在时创建第一个版本
+func (c *Cipher) latestKey(ctx context.Context, bizId uint64) (uint32, []byte, error) {
+	c.mu.RLock()
+	lv, ok := c.latest[bizId]
+	key, found := c.keys[keyRef{bizId: bizId, version: lv.version}]
+	c.mu.RUnlock()
+	if ok && found && time.Now().Before(lv.expireAt) {
+		return lv.version, key, nil
+	}
+
+	dk, err := c.store.Latest(ctx, bizId)
+	if errors.Is(err, ErrDataKeyNotFound) {
+		dk, err = c.create(ctx, bizId, 1)
+		if errors.Is(err, ErrDataKeyConflict) {
+			// 其他实例已创建
+			dk, err = c.store.Latest(ctx, bizId)
+		}
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+
+	key, err = c.unwrap(dk)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	c.mu.Lock()
+	c.latest[bizId] = latestVersion{version: dk.Version, expireAt: time.Now().Add(c.latestTTL)}
+	c.keys[keyRef{bizId: bizId, version: dk.Version}] = key
+	c.mu.Unlock()
+	return dk.Version, key, nil
+}
+
+// dataKey 获取业务方指定版本的数据密钥
+func (c *Cipher) dataKey(ctx context.Context, bizId uint64, version uint32) ([]byte, error) {
+	ref := keyRef{bizId: bizId, version: version}
+
+	c.mu.RLock()
+	key, ok := c.keys[ref]
+	c.mu.RUnlock()
+	if ok {
+		return key, nil
+	}
+
+	dk, err := c.store.Get(ctx, bizId, version)
+	if err != nil {
+		return nil, err
+	}
+	key, err = c.unwrap(dk)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.keys[ref] = key
+	c.mu.Unlock()
+	return key, nil
+}
+
+func (c *Cipher) create(ctx context.Context, bizId uint64, version uint32) (DataKey, error) {
+	masterKeyId := c.keySource.CurrentId()
+	master, err := c.keySource.Get(masterKeyId)
+	if err != nil {
+		return DataKey{}, err
+	}
+
+	key := make([]byte, keySize)
+	if _, err = rand.Read(key); err != nil {
+		return DataKey{}, err
+	}
+	wrapped, err := seal(master, key, wrapAad(bizId, version))
+	if err != nil {
+		return DataKey{}, err
+	}
+
+	dk := DataKey{
+		BizId:       bizId,
+		Version:     version,
+		MasterKeyId: masterKeyId,
+		WrappedKey:  wrapped,
+	}
+	if err = c.store.Create(ctx, dk); err != nil {
+		return DataKey{}, err
+	}
+	return dk, nil
+}
+
+func (c *Cipher) unwrap(dk DataKey) ([]byte, error) {
+	master, err := c.keySource.Get(dk.MasterKeyId)
+	if err != nil {
+		return nil, err
+	}
+	key, err := open(master, dk.WrappedKey, wrapAad(dk.BizId, dk.Version))
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key of biz %d version %d: %w", dk.BizId, dk.Version, err)
+	}
+	return key, nil
+}
+
+// IsEncrypted 判断值是否为 Cipher 加密的结果
+func IsEncrypted(val string) bool {
+	return strings.HasPrefix(val, prefix)
+}
+
+// dataAad 数据密文绑定业务 id，避免密文被复制到其他业务方的消息中解密
+func dataAad(bizId uint64) []byte {
+	return []byte("biz:" + strconv.FormatUint(bizId, 10))
+}
+
+// wrapAad 数据密钥的密文绑定业务 id 与版本
+func wrapAad(bizId uint64, version uint32) []byte {
+	return []byte("data_key:" + strconv.FormatUint(bizId, 10) + ":" + strconv.FormatUint(uint64(version), 10))
+}
+
+// seal 使用 AES-256-GCM 加密，返回 nonce 与密文拼接的结果
+func seal(key []byte, plaintext []byte, aad []byte) ([]byte, error) {
+	aead, err := newAead(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func open(key []byte, sealed []byte, aad []byte) ([]byte, error) {
+	aead, err := newAead(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrInvalidCiphertext
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCiphertext, err)
+	}
+	return plaintext, nil
+}
+
+func newAead(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func NewCipher(store DataKeyStore, keySource KeySource, latestTTL time.Duration) *Cipher {
+	return &Cipher{
+		store:     store,
+		keySource: keySource,
+		latestTTL: latestTTL,
+		latest:    make(map[uint64]latestVersion),
+		keys:      make(map[keyRef][]byte),
+	}
+}
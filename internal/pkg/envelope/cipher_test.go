@@ -0,0 +1,151 @@
+package envelope
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memStore struct {
+	mu   sync.Mutex
+	keys map[keyRef]DataKey
+}
+
+func (m *memStore) Latest(_ context.Context, bizId uint64) (DataKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var latest DataKey
+	found := false
+	for ref, dk := range m.keys {
+		if ref.bizId == bizId && (!found || dk.Version > latest.Version) {
+			latest, found = dk, true
+		}
+	}
+	if !found {
+		return DataKey{}, ErrDataKeyNotFound
+	}
+	return latest, nil
+}
+
+func (m *memStore) Get(_ context.Context, bizId uint64, version uint32) (DataKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	dk, ok := m.keys[keyRef{bizId: bizId, version: version}]
+	if !ok {
+		return DataKey{}, ErrDataKeyNotFound
+	}
+	return dk, nil
+}
+
+func (m *memStore) Create(_ context.Context, key DataKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ref := keyRef{bizId: key.BizId, version: key.Version}
+	if _, ok := m.keys[ref]; ok {
+		return ErrDataKeyConflict
+	}
+	m.keys[ref] = key
+	return nil
+}
+
+func (m *memStore) FindNotWrappedBy(_ context.Context, masterKeyId string, limit int) ([]DataKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var res []DataKey
+	for _, dk := range m.keys {
+		if dk.MasterKeyId != masterKeyId && len(res) < limit {
+			res = append(res, dk)
+		}
+	}
+	return res, nil
+}
+
+func (m *memStore) Rewrap(_ context.Context, key DataKey, prevMasterKeyId string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ref := keyRef{bizId: key.BizId, version: key.Version}
+	if m.keys[ref].MasterKeyId == prevMasterKeyId {
+		m.keys[ref] = key
+	}
+	return nil
+}
+
+func newMasterKey(t *testing.T) string {
+	key := make([]byte, keySize)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+func TestCipher(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := &memStore{keys: make(map[keyRef]DataKey)}
+	k0 := newMasterKey(t)
+
+	source, err := NewStaticKeySource("k0", map[string]string{"k0": k0})
+	require.NoError(t, err)
+	c := NewCipher(store, source, time.Minute)
+
+	// 未加密的历史数据原样返回
+	plaintext, err := c.Decrypt(ctx, 1, `["13800000000"]`)
+	require.NoError(t, err)
+	assert.Equal(t, `["13800000000"]`, plaintext)
+
+	ciphertext, err := c.Encrypt(ctx, 1, `["13800000000"]`)
+	require.NoError(t, err)
+	assert.True(t, IsEncrypted(ciphertext))
+	assert.NotContains(t, ciphertext, "13800000000")
+
+	plaintext, err = c.Decrypt(ctx, 1, ciphertext)
+	require.NoError(t, err)
+	assert.Equal(t, `["13800000000"]`, plaintext)
+
+	// 密文绑定业务 id
+	_, err = c.Decrypt(ctx, 2, ciphertext)
+	assert.Error(t, err)
+
+	// 轮换后使用新版本加密，旧版本的密文仍可解密
+	version, err := c.Rotate(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(2), version)
+
+	rotated, err := c.Encrypt(ctx, 1, "otp")
+	require.NoError(t, err)
+	assert.Contains(t, rotated, prefix+"2:")
+
+	// 更换主密钥并重新加密数据密钥，其他实例仍可解密
+	source, err = NewStaticKeySource("k1", map[string]string{"k0": k0, "k1": newMasterKey(t)})
+	require.NoError(t, err)
+	cnt, err := NewCipher(store, source, time.Minute).Rewrap(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, cnt)
+
+	source, err = NewStaticKeySource("k1", map[string]string{"k1": mustGet(t, source, "k1")})
+	require.NoError(t, err)
+	other := NewCipher(store, source, time.Minute)
+	plaintext, err = other.Decrypt(ctx, 1, ciphertext)
+	require.NoError(t, err)
+	assert.Equal(t, `["13800000000"]`, plaintext)
+	plaintext, err = other.Decrypt(ctx, 1, rotated)
+	require.NoError(t, err)
+	assert.Equal(t, "otp", plaintext)
+}
+
+func mustGet(t *testing.T, source KeySource, id string) string {
+	key, err := source.Get(id)
+	require.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(key)
+}
@@ -0,0 +1,99 @@
+package envelope
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// KeySource 主密钥来源，主密钥只用于加密业务方的数据密钥。
+//
+// 更换主密钥时先在所有实例上发布新主密钥，再切换 CurrentId，
+// 最后通过 jotifyctl datakey rewrap 使用新主密钥重新加密数据密钥后下线旧主密钥。
+type KeySource interface {
+	// CurrentId 加密新数据密钥时使用的主密钥 id
+	CurrentId() string
+	// Get 根据 id 获取主密钥
+	Get(id string) ([]byte, error)
+}
+
+var _ KeySource = (*StaticKeySource)(nil)
+
+// StaticKeySource 启动时加载、运行期间不变的主密钥
+type StaticKeySource struct {
+	currentId string
+	keys      map[string][]byte
+}
+
+func (s *StaticKeySource) CurrentId() string {
+	return s.currentId
+}
+
+func (s *StaticKeySource) Get(id string) ([]byte, error) {
+	key, ok := s.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: id = %s", ErrMasterKeyNotFound, id)
+	}
+	return key, nil
+}
+
+// NewStaticKeySource keys 为主密钥 id 到 base64 编码的 32 字节主密钥，currentId 为空时只能解密不能创建数据密钥
+func NewStaticKeySource(currentId string, keys map[string]string) (*StaticKeySource, error) {
+	decoded := make(map[string][]byte, len(keys))
+	for id, val := range keys {
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(val))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode master key %s: %w", id, err)
+		}
+		if len(key) != keySize {
+			return nil, fmt.Errorf("master key %s should be %d bytes", id, keySize)
+		}
+		decoded[id] = key
+	}
+
+	if _, ok := decoded[currentId]; !ok && currentId != "" {
+		return nil, fmt.Errorf("%w: current id = %s", ErrMasterKeyNotFound, currentId)
+	}
+	return &StaticKeySource{
+		currentId: currentId,
+		keys:      decoded,
+	}, nil
+}
+
+// NewEnvKeySource 从环境变量加载主密钥，变量名为 prefix 加主密钥 id，如 JOTIFY_MASTER_KEY_k0
+func NewEnvKeySource(prefix string, currentId string) (*StaticKeySource, error) {
+	if prefix == "" {
+		return nil, errors.New("master key env prefix should not be empty")
+	}
+
+	keys := make(map[string]string)
+	for _, env := range os.Environ() {
+		name, val, ok := strings.Cut(env, "=")
+		if !ok || !strings.HasPrefix(name, prefix) || len(name) == len(prefix) {
+			continue
+		}
+		keys[strings.TrimPrefix(name, prefix)] = val
+	}
+	return NewStaticKeySource(currentId, keys)
+}
+
+// NewFileKeySource 从目录加载主密钥，文件名为主密钥 id 加 .key 后缀，内容为 base64 编码的主密钥
+func NewFileKeySource(dir string, currentId string) (*StaticKeySource, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.key"))
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]string, len(paths))
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read master key file %s: %w", path, err)
+		}
+		keys[strings.TrimSuffix(filepath.Base(path), ".key")] = string(content)
+	}
+	return NewStaticKeySource(currentId, keys)
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/JrMarcco/easy-kit/xsync"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/resolver"
 )
@@ -14,9 +15,12 @@ import (
 type Clients[T any] struct {
 	clientMap xsync.Map[string, T]
 
-	rb       resolver.Builder
-	bb       balancer.Builder
-	insecure bool
+	rb   resolver.Builder
+	bb   balancer.Builder
+	opts []grpc.DialOption
+
+	// credsFunc 按服务名获取传输层凭证，为 nil 或返回 nil 时使用明文连接
+	credsFunc func(serviceName string) credentials.TransportCredentials
 
 	creator func(conn *grpc.ClientConn) T
 }
@@ -41,24 +45,41 @@ func (c *Clients[T]) dial(serviceName string) (*grpc.ClientConn, error) {
 		grpc.WithNoProxy(),
 	}
 
-	if c.insecure {
-		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	var creds credentials.TransportCredentials
+	if c.credsFunc != nil {
+		creds = c.credsFunc(serviceName)
+	}
+	if creds == nil {
+		creds = insecure.NewCredentials()
 	}
+	opts = append(opts, grpc.WithTransportCredentials(creds))
 
 	if c.bb != nil {
 		opts = append(opts, grpc.WithDefaultServiceConfig(
 			fmt.Sprintf(`{"loadBalancingPolicy: %q"}`, c.bb.Name()),
 		))
 	}
+	opts = append(opts, c.opts...)
+
 	// "registry:///%s" 的 registry 对应 grpc resolver 的 scheme
 	addr := fmt.Sprintf("registry:///%s", serviceName)
 	return grpc.NewClient(addr, opts...)
 }
 
-func NewClients[T any](rb resolver.Builder, bb balancer.Builder, creator func(conn *grpc.ClientConn) T) *Clients[T] {
+// WithCredentials 设置按服务名获取传输层凭证的方法，用于为不同服务配置不同的 TLS
+func (c *Clients[T]) WithCredentials(fn func(serviceName string) credentials.TransportCredentials) *Clients[T] {
+	c.credsFunc = fn
+	return c
+}
+
+// NewClients 创建 grpc 客户端集合，opts 为创建连接时追加的额外参数（如链路追踪的 stats handler）。
+func NewClients[T any](
+	rb resolver.Builder, bb balancer.Builder, creator func(conn *grpc.ClientConn) T, opts ...grpc.DialOption,
+) *Clients[T] {
 	return &Clients[T]{
 		rb:      rb,
 		bb:      bb,
+		opts:    opts,
 		creator: creator,
 	}
 }
@@ -0,0 +1,27 @@
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// ServerStream 替换了 context 的 grpc.ServerStream。
+//
+// 流式拦截器无法像一元拦截器一样直接传递新的 context，需要包装 ServerStream。
+type ServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *ServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// WrapServerStream 包装 grpc.ServerStream，使 Context() 返回 ctx
+func WrapServerStream(ss grpc.ServerStream, ctx context.Context) *ServerStream {
+	return &ServerStream{
+		ServerStream: ss,
+		ctx:          ctx,
+	}
+}
@@ -0,0 +1,95 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/JrMarcco/dlock"
+	"go.uber.org/zap"
+)
+
+// LoopJob 单个分布式锁保护的循环任务，同一时间只有一个实例执行 bizFunc
+type LoopJob struct {
+	key            string
+	retryInterval  time.Duration
+	defaultTimeout time.Duration
+
+	dclient dlock.Dclient
+	logger  *zap.Logger
+
+	bizFunc func(ctx context.Context) error
+}
+
+// Run 抢占分布式锁并循环执行 bizFunc，ctx 被取消时退出
+func (lj *LoopJob) Run(ctx context.Context) error {
+	for {
+		if err := lj.runOnce(ctx); err != nil {
+			lj.logger.Error("[jotify] loop job failed, wait for retry", zap.String("key", lj.key), zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(lj.retryInterval):
+		}
+	}
+}
+
+func (lj *LoopJob) runOnce(ctx context.Context) error {
+	dl, err := lj.dclient.NewDlock(ctx, lj.key, lj.retryInterval)
+	if err != nil {
+		return fmt.Errorf("[jotify] failed to create distributed lock: %w", err)
+	}
+
+	lockCtx, cancel := context.WithTimeout(ctx, lj.defaultTimeout)
+	err = dl.TryLock(lockCtx)
+	cancel()
+	if err != nil {
+		// 锁被其他实例持有
+		return nil
+	}
+
+	defer func() {
+		unlockCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), lj.defaultTimeout)
+		defer cancel()
+		if err := dl.Unlock(unlockCtx); err != nil {
+			lj.logger.Error("[jotify] failed to release distributed lock", zap.String("key", lj.key), zap.Error(err))
+		}
+	}()
+
+	const bizTimeout = time.Minute
+	for {
+		bizCtx, cancel := context.WithTimeout(ctx, bizTimeout)
+		err = lj.bizFunc(bizCtx)
+		cancel()
+
+		if err != nil {
+			lj.logger.Error("[jotify] biz func failed", zap.String("key", lj.key), zap.Error(err))
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		// 分布式锁续约
+		refreshCtx, cancel := context.WithTimeout(ctx, lj.defaultTimeout)
+		err = dl.Refresh(refreshCtx)
+		cancel()
+
+		if err != nil {
+			return fmt.Errorf("[jotify] failed to refresh distributed lock: %w", err)
+		}
+	}
+}
+
+func NewLoopJob(key string, dclient dlock.Dclient, logger *zap.Logger, bizFunc func(ctx context.Context) error) *LoopJob {
+	const defaultTimeout = 3 * time.Second
+	return &LoopJob{
+		key:            key,
+		retryInterval:  time.Minute,
+		defaultTimeout: defaultTimeout,
+		dclient:        dclient,
+		logger:         logger,
+		bizFunc:        bizFunc,
+	}
+}
@@ -40,6 +40,13 @@ func (s *MaxCntResourceSemaphore) Release(_ context.Context) error {
 	return nil
 }
 
+// CurrCnt 返回当前已抢占的资源数量
+func (s *MaxCntResourceSemaphore) CurrCnt() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.currCnt
+}
+
 func (s *MaxCntResourceSemaphore) UpdateMaxCnt(maxCnt int) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -0,0 +1,17 @@
+package job
+
+import (
+	"context"
+	"time"
+)
+
+// WaitUntil 等待到 until 或 ctx 结束，用于循环任务两轮执行之间的间隔
+func WaitUntil(ctx context.Context, until time.Time) {
+	timer := time.NewTimer(time.Until(until))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
@@ -0,0 +1,81 @@
+package lane
+
+import "slices"
+
+// DRR 加权差额轮询（deficit round robin），在多个队列之间按权重分配份额。
+//
+// 每轮按 id 顺序访问有积压的队列，差额增加该队列的权重后按差额消费积压，队列积压耗尽时差额清零。
+// 差额与轮询位置在多次分配之间保留：份额在访问某个队列的中途用完时，下一次分配从该队列继续，且不再重复增加差额，
+// 因此即使每次分配的份额小于队列数，各队列长期得到的份额仍与权重成正比。
+// 单轮中一个队列最多连续拿到等于权重的份额，权重不宜过大。DRR 不是并发安全的。
+type DRR struct {
+	deficits map[uint64]int
+
+	// next 下一次分配从 id 不小于 next 的队列开始
+	next uint64
+	// resume 上一次分配在访问 next 的中途结束，继续访问时不增加差额
+	resume bool
+}
+
+// Allocate 将最多 total 的份额按权重分配给 backlogs 中有积压的队列，返回每个队列分到的份额。
+// weight 返回队列的权重，不大于 0 时按 1 处理。
+func (d *DRR) Allocate(total int, backlogs map[uint64]int, weight func(id uint64) int) map[uint64]int {
+	ids := make([]uint64, 0, len(backlogs))
+	for id, n := range backlogs {
+		if n > 0 {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+
+	// 积压耗尽的队列不保留差额
+	for id := range d.deficits {
+		if backlogs[id] <= 0 {
+			delete(d.deficits, id)
+		}
+	}
+
+	allocs := make(map[uint64]int, len(ids))
+	if total <= 0 || len(ids) == 0 {
+		return allocs
+	}
+
+	start, _ := slices.BinarySearch(ids, d.next)
+	start %= len(ids)
+	resume := d.resume && ids[start] == d.next
+
+	remaining, active := total, len(ids)
+	for k := 0; remaining > 0 && active > 0; k++ {
+		i := (start + k) % len(ids)
+		id := ids[i]
+		left := backlogs[id] - allocs[id]
+		if left <= 0 {
+			continue
+		}
+
+		if k > 0 || !resume {
+			d.deficits[id] += max(weight(id), 1)
+		}
+		n := min(d.deficits[id], left, remaining)
+		allocs[id] += n
+		d.deficits[id] -= n
+		remaining -= n
+
+		if n == left {
+			d.deficits[id] = 0
+			active--
+		}
+		if remaining == 0 {
+			if d.deficits[id] > 0 {
+				d.next, d.resume = id, true
+			} else {
+				d.next, d.resume = ids[(i+1)%len(ids)], false
+			}
+		}
+	}
+	return allocs
+}
+
+func NewDRR() *DRR {
+	return &DRR{deficits: make(map[uint64]int)}
+}
@@ -0,0 +1,67 @@
+package lane
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDRR_Allocate(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name     string
+		total    int
+		backlogs map[uint64]int
+		weights  map[uint64]int
+		want     []map[uint64]int // 连续多次分配的结果
+	}{
+		{
+			name:     "equal weights",
+			total:    10,
+			backlogs: map[uint64]int{1: 100, 2: 100},
+			want:     []map[uint64]int{{1: 5, 2: 5}},
+		}, {
+			name:     "weighted",
+			total:    8,
+			backlogs: map[uint64]int{1: 100, 2: 100},
+			weights:  map[uint64]int{1: 3},
+			want:     []map[uint64]int{{1: 6, 2: 2}},
+		}, {
+			name:     "spare to backlogged",
+			total:    10,
+			backlogs: map[uint64]int{1: 2, 2: 100},
+			want:     []map[uint64]int{{1: 2, 2: 8}},
+		}, {
+			name:     "not enough backlog",
+			total:    10,
+			backlogs: map[uint64]int{1: 2, 2: 3, 3: 0},
+			want:     []map[uint64]int{{1: 2, 2: 3}},
+		}, {
+			name:     "rotate across allocations",
+			total:    1,
+			backlogs: map[uint64]int{1: 100, 2: 100, 3: 100},
+			want:     []map[uint64]int{{1: 1}, {2: 1}, {3: 1}, {1: 1}},
+		}, {
+			name:     "resume without extra deficit",
+			total:    2,
+			backlogs: map[uint64]int{1: 100, 2: 100},
+			weights:  map[uint64]int{1: 3},
+			want:     []map[uint64]int{{1: 2}, {1: 1, 2: 1}, {1: 2}},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			drr := NewDRR()
+			for _, want := range tc.want {
+				got := drr.Allocate(tc.total, tc.backlogs, func(id uint64) int {
+					return tc.weights[id]
+				})
+				assert.Equal(t, want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package lane
+
+// Split 按权重将 total 分配给各个通道，返回每个通道的份额。
+//
+// 按最大余数法分配，余数相同时靠前的通道优先。分配后份额为 0 的正权重通道从份额最多的通道借 1，
+// 保证 total 足够时每个正权重通道至少分到 1。权重不大于 0 的通道份额为 0。
+func Split(total int, weights []int) []int {
+	quotas := make([]int, len(weights))
+	sum := 0
+	for _, w := range weights {
+		if w > 0 {
+			sum += w
+		}
+	}
+	if total <= 0 || sum == 0 {
+		return quotas
+	}
+
+	rems := make([]int, len(weights))
+	allocated := 0
+	for i, w := range weights {
+		if w <= 0 {
+			continue
+		}
+		share := total * w
+		quotas[i] = share / sum
+		rems[i] = share % sum
+		allocated += quotas[i]
+	}
+	for ; allocated < total; allocated++ {
+		best := -1
+		for i, w := range weights {
+			if w > 0 && (best < 0 || rems[i] > rems[best]) {
+				best = i
+			}
+		}
+		quotas[best]++
+		rems[best] = -1
+	}
+
+	for i, w := range weights {
+		if w <= 0 || quotas[i] > 0 {
+			continue
+		}
+		richest := 0
+		for j := range quotas {
+			if quotas[j] > quotas[richest] {
+				richest = j
+			}
+		}
+		if quotas[richest] <= 1 {
+			break
+		}
+		quotas[richest]--
+		quotas[i]++
+	}
+	return quotas
+}
+
+// FetchFunc 从第 lane 个通道中跳过 offset 条后拉取最多 limit 条数据
+type FetchFunc[T any] func(lane int, offset int, limit int) ([]T, error)
+
+// Drain 按通道顺序（优先级从高到低）拉取最多 total 条数据。
+//
+// 每个通道先拉取按权重分到的份额，因此低优先级通道不会被完全饿死；
+// 份额没有用完时，剩余份额按通道顺序分给份额已用满（可能还有积压）的通道，保证高优先级通道的积压先被消费。
+// 权重为 0 的通道只使用其他通道剩余的份额。
+func Drain[T any](total int, weights []int, fetch FetchFunc[T]) ([]T, error) {
+	quotas := Split(total, weights)
+	fetched := make([]int, len(weights))
+	full := make([]bool, len(weights))
+
+	res := make([]T, 0, total)
+	for i := range weights {
+		if quotas[i] == 0 {
+			full[i] = true
+			continue
+		}
+
+		items, err := fetch(i, 0, quotas[i])
+		if err != nil {
+			return res, err
+		}
+		res = append(res, items...)
+		fetched[i] = len(items)
+		full[i] = len(items) == quotas[i]
+	}
+
+	spare := total - len(res)
+	for i := range weights {
+		if spare <= 0 {
+			break
+		}
+		if !full[i] {
+			continue
+		}
+
+		items, err := fetch(i, fetched[i], spare)
+		if err != nil {
+			return res, err
+		}
+		res = append(res, items...)
+		spare -= len(items)
+	}
+	return res, nil
+}
@@ -0,0 +1,77 @@
+package lane
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplit(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name    string
+		total   int
+		weights []int
+		want    []int
+	}{
+		{name: "proportional", total: 100, weights: []int{6, 3, 1}, want: []int{60, 30, 10}},
+		{name: "largest remainder", total: 10, weights: []int{1, 1, 1}, want: []int{4, 3, 3}},
+		{name: "at least one", total: 10, weights: []int{98, 1, 1}, want: []int{8, 1, 1}},
+		{name: "total less than lanes", total: 2, weights: []int{6, 3, 1}, want: []int{1, 1, 0}},
+		{name: "zero weight", total: 10, weights: []int{6, 3, 0}, want: []int{7, 3, 0}},
+		{name: "no weight", total: 10, weights: []int{0, 0}, want: []int{0, 0}},
+		{name: "zero total", total: 0, weights: []int{6, 3, 1}, want: []int{0, 0, 0}},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := Split(tc.total, tc.weights)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestDrain(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name    string
+		total   int
+		weights []int
+		backlog []int // 每个通道的积压数
+		want    []int // 每个通道拉取的数量
+	}{
+		{name: "all lanes backlogged", total: 10, weights: []int{6, 3, 1}, backlog: []int{100, 100, 100}, want: []int{6, 3, 1}},
+		{name: "high lane first", total: 10, weights: []int{6, 3, 1}, backlog: []int{100, 0, 0}, want: []int{10, 0, 0}},
+		{name: "low lane not starved", total: 10, weights: []int{6, 3, 1}, backlog: []int{100, 0, 100}, want: []int{9, 0, 1}},
+		{name: "spare back to high lane", total: 10, weights: []int{6, 3, 1}, backlog: []int{100, 100, 0}, want: []int{7, 3, 0}},
+		{name: "zero weight uses spare", total: 10, weights: []int{6, 3, 0}, backlog: []int{2, 2, 100}, want: []int{2, 2, 6}},
+		{name: "zero weight starved", total: 10, weights: []int{6, 3, 0}, backlog: []int{100, 100, 100}, want: []int{7, 3, 0}},
+		{name: "not enough backlog", total: 10, weights: []int{6, 3, 1}, backlog: []int{2, 1, 1}, want: []int{2, 1, 1}},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := make([]int, len(tc.weights))
+			items, err := Drain(tc.total, tc.weights, func(lane int, offset int, limit int) ([]int, error) {
+				n := min(limit, max(tc.backlog[lane]-offset, 0))
+				got[lane] += n
+				return make([]int, n), nil
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			sum := 0
+			for _, n := range tc.want {
+				sum += n
+			}
+			assert.Len(t, items, sum)
+		})
+	}
+}
@@ -0,0 +1,236 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+const namespace = "jotify"
+
+// Registry jotify 指标注册中心。
+//
+// 不使用 prometheus 默认的全局注册中心，避免三方依赖注册的指标混入。
+var Registry = prometheus.NewRegistry()
+
+var (
+	// GrpcRequestTotal gRPC 请求数，按方法、业务 id 以及响应码区分
+	GrpcRequestTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "requests_total",
+		Help:      "Total number of gRPC requests by method, biz id and code.",
+	}, []string{"method", "biz_id", "code"})
+
+	// GrpcRequestDuration gRPC 请求耗时，按方法和业务 id 区分
+	GrpcRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of gRPC requests by method and biz id.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "biz_id"})
+
+	// SendStrategyTotal 各发送策略处理的消息数，按策略和结果区分
+	SendStrategyTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "send_strategy",
+		Name:      "notifications_total",
+		Help:      "Total number of notifications handled by send strategy and result.",
+	}, []string{"strategy", "result"})
+
+	// SendResultTotal 消息发送结果，按渠道、供应商和发送状态区分
+	SendResultTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "provider",
+		Name:      "send_results_total",
+		Help:      "Total number of send results by channel, provider and status.",
+	}, []string{"channel", "provider", "status"})
+
+	// ProviderSendDuration 供应商发送耗时，按渠道和供应商区分
+	ProviderSendDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "provider",
+		Name:      "send_duration_seconds",
+		Help:      "Latency of provider send calls by channel and provider.",
+		Buckets:   []float64{.05, .1, .25, .5, 1, 2, 5, 10},
+	}, []string{"channel", "provider"})
+
+	// SchedulerLoopDuration 调度器单次循环耗时，按分库分表区分
+	SchedulerLoopDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "scheduler",
+		Name:      "loop_duration_seconds",
+		Help:      "Latency of a single sharding scheduler loop by db and table.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"db", "table"})
+
+	// SchedulerErrEventTrips 调度器错误事件阈值触发次数，按分库分表区分
+	SchedulerErrEventTrips = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "scheduler",
+		Name:      "err_event_trips_total",
+		Help:      "Total number of times the scheduler error event threshold was exceeded by db and table.",
+	}, []string{"db", "table"})
+
+	// SchedulerReadyNotifications 调度器拉取的待发送消息数，按优先级区分
+	SchedulerReadyNotifications = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "scheduler",
+		Name:      "ready_notifications_total",
+		Help:      "Total number of ready notifications fetched by the scheduler by priority.",
+	}, []string{"priority"})
+
+	// SchedulerReadyBacklog 调度器最近一次统计的处于发送时间窗口中的待发送消息数，按分库分表和业务 id 区分
+	SchedulerReadyBacklog = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "scheduler",
+		Name:      "ready_backlog",
+		Help:      "Number of ready notifications waiting to be sent by db, table and biz id.",
+	}, []string{"db", "table", "biz_id"})
+
+	// ArchivedNotifications 归档的消息数，按分库分表区分
+	ArchivedNotifications = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "archive",
+		Name:      "notifications_total",
+		Help:      "Total number of notifications moved to archive tables by db and table.",
+	}, []string{"db", "table"})
+
+	// ExpiredNotifications 超过发送时间窗口被标记为过期的消息数，按分库分表区分
+	ExpiredNotifications = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "expiry",
+		Name:      "notifications_total",
+		Help:      "Total number of pending notifications expired after their send window by db and table.",
+	}, []string{"db", "table"})
+
+	// SuppressedReceivers 因在退订名单中或达到频控上限被移除的接收者数，按渠道与原因区分
+	SuppressedReceivers = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "suppression",
+		Name:      "suppressed_receivers_total",
+		Help:      "Total number of receivers removed by the suppression list and frequency caps by channel and reason.",
+	}, []string{"channel", "reason"})
+
+	// Unsubscribes 自动加入退订名单的次数，按来源区分（reply、link）
+	Unsubscribes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "suppression",
+		Name:      "unsubscribes_total",
+		Help:      "Total number of receivers added to the suppression list by inbound replies and unsubscribe links.",
+	}, []string{"source"})
+
+	// QuietHoursDeferred 因免打扰时段被推迟发送的消息数，按渠道区分
+	QuietHoursDeferred = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "delivery",
+		Name:      "quiet_hours_deferred_total",
+		Help:      "Total number of notifications deferred to the end of quiet hours by channel.",
+	}, []string{"channel"})
+
+	// RecurringOccurrences 周期发送计划的触发次数，按结果区分（spawned、missed、failure）
+	RecurringOccurrences = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "recurring",
+		Name:      "occurrences_total",
+		Help:      "Total number of recurring schedule occurrences by result.",
+	}, []string{"result"})
+
+	// CampaignReceivers 群发活动展开的接收者数，按结果区分（accepted、suppressed、skipped）
+	CampaignReceivers = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "campaign",
+		Name:      "receivers_total",
+		Help:      "Total number of campaign receivers expanded by result.",
+	}, []string{"result"})
+
+	// TplAudits 模板版本审核次数，按审核阶段（rule、manual）与结果（approved、rejected、in_preview）区分
+	TplAudits = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "template",
+		Name:      "audits_total",
+		Help:      "Total number of template version audits by stage and result.",
+	}, []string{"stage", "result"})
+
+	// SensitiveHits 模板参数命中敏感词的消息数，按处理策略区分（reject、mask、flag）
+	SensitiveHits = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "notification",
+		Name:      "sensitive_hits_total",
+		Help:      "Total number of notifications whose template params hit sensitive words by policy.",
+	}, []string{"policy"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+
+		GrpcRequestTotal,
+		GrpcRequestDuration,
+		SendStrategyTotal,
+		SendResultTotal,
+		ProviderSendDuration,
+		SchedulerLoopDuration,
+		SchedulerErrEventTrips,
+		SchedulerReadyNotifications,
+		SchedulerReadyBacklog,
+		ArchivedNotifications,
+		ExpiredNotifications,
+		SuppressedReceivers,
+		Unsubscribes,
+		QuietHoursDeferred,
+		RecurringOccurrences,
+		CampaignReceivers,
+		TplAudits,
+		SensitiveHits,
+	)
+}
+
+// MustRegister 注册额外的指标（例如运行时才能确定取值方式的 GaugeFunc）。
+func MustRegister(cs ...prometheus.Collector) {
+	Registry.MustRegister(cs...)
+}
+
+var _ prometheus.Collector = (*GaugeVecFunc)(nil)
+
+// GaugeVecFunc 采集时才通过回调函数获取取值的 GaugeVec。
+//
+// 适用于取值需要实时从外部（如 redis、数据库）查询的场景。
+type GaugeVecFunc struct {
+	desc *prometheus.Desc
+	fn   func() []LabeledValue
+}
+
+// LabeledValue 带标签值的指标取值，Labels 顺序与 GaugeVecFunc 的标签名顺序一致
+type LabeledValue struct {
+	Labels []string
+	Value  float64
+}
+
+func (g *GaugeVecFunc) Describe(ch chan<- *prometheus.Desc) {
+	ch <- g.desc
+}
+
+func (g *GaugeVecFunc) Collect(ch chan<- prometheus.Metric) {
+	for _, lv := range g.fn() {
+		ch <- prometheus.MustNewConstMetric(g.desc, prometheus.GaugeValue, lv.Value, lv.Labels...)
+	}
+}
+
+func NewGaugeVecFunc(subsystem, name, help string, labels []string, fn func() []LabeledValue) *GaugeVecFunc {
+	return &GaugeVecFunc{
+		desc: prometheus.NewDesc(prometheus.BuildFQName(namespace, subsystem, name), help, labels, nil),
+		fn:   fn,
+	}
+}
+
+// NewGaugeFunc 创建采集时通过回调函数获取取值的 Gauge
+func NewGaugeFunc(subsystem, name, help string, fn func() float64) prometheus.GaugeFunc {
+	return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      name,
+		Help:      help,
+	}, fn)
+}
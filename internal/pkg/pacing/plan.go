@@ -0,0 +1,188 @@
+package pacing
+
+import (
+	"time"
+)
+
+// Plan 匀速发送计划，将 total 个发送量按曲线分配到时间窗口 [start, end) 中。
+//
+// 曲线将窗口等分为若干时段，时段内匀速发送，各时段的发送量与权重成正比。
+// 指定速率上限时，超出上限的时段只保留上限内的发送量，超出的部分按权重转移到仍有余量的时段；
+// 全部时段都达到上限仍无法容纳时，超出的部分按各时段的上限等比分摊，此时无法在窗口内以限定速率完成发送。
+type Plan struct {
+	bounds  []time.Time // 各时段的边界，比时段数多一个
+	weights []float64
+	counts  []float64 // 各时段的发送量
+	maxRate float64
+}
+
+// NewPlan 创建发送计划，weights 为空时匀速发送，maxRate 为每秒发送量上限，不大于 0 表示不限
+func NewPlan(start time.Time, end time.Time, weights []int, total int, maxRate float64) Plan {
+	if len(weights) == 0 {
+		weights = []int{1}
+	}
+	if !end.After(start) {
+		return newPlan([]time.Time{start, start}, []float64{1}, total, maxRate)
+	}
+
+	bounds := make([]time.Time, 0, len(weights)+1)
+	ws := make([]float64, 0, len(weights))
+	step := end.Sub(start) / time.Duration(len(weights))
+	for i, w := range weights {
+		bounds = append(bounds, start.Add(step*time.Duration(i)))
+		ws = append(ws, float64(max(w, 0)))
+	}
+	bounds = append(bounds, end)
+	return newPlan(bounds, ws, total, maxRate)
+}
+
+func newPlan(bounds []time.Time, weights []float64, total int, maxRate float64) Plan {
+	p := Plan{
+		bounds:  bounds,
+		weights: weights,
+		counts:  make([]float64, len(weights)),
+		maxRate: maxRate,
+	}
+	p.fill(float64(total))
+	return p
+}
+
+// fill 按权重将 remaining 分配到各时段，超出速率上限的时段固定为上限后重新分配剩余的发送量
+func (p *Plan) fill(remaining float64) {
+	const epsilon = 1e-9
+
+	caps := make([]float64, len(p.counts))
+	open := make([]int, 0, len(p.counts))
+	for i := range p.counts {
+		caps[i] = -1
+		if p.maxRate > 0 {
+			caps[i] = p.maxRate * p.bounds[i+1].Sub(p.bounds[i]).Seconds()
+		}
+		open = append(open, i)
+	}
+
+	for remaining > epsilon && len(open) > 0 {
+		shares := p.shares(open, remaining)
+
+		kept := open[:0]
+		overflow := false
+		for k, i := range open {
+			if caps[i] >= 0 && shares[k] >= caps[i] {
+				remaining -= caps[i]
+				p.counts[i] = caps[i]
+				overflow = true
+				continue
+			}
+			kept = append(kept, i)
+		}
+		if !overflow {
+			for k, i := range open {
+				p.counts[i] = shares[k]
+			}
+			return
+		}
+		open = kept
+	}
+
+	if remaining <= epsilon {
+		return
+	}
+	// 全部时段都达到速率上限
+	var sumCaps float64
+	for _, c := range caps {
+		sumCaps += c
+	}
+	for i := range p.counts {
+		if sumCaps > 0 {
+			p.counts[i] += remaining * caps[i] / sumCaps
+		} else {
+			p.counts[i] += remaining / float64(len(p.counts))
+		}
+	}
+}
+
+// shares 按权重分配 remaining 到 open 中的时段，权重都为 0 时按时长分配
+func (p *Plan) shares(open []int, remaining float64) []float64 {
+	ws := make([]float64, len(open))
+	var sum float64
+	for k, i := range open {
+		ws[k] = p.weights[i]
+		sum += ws[k]
+	}
+	if sum <= 0 {
+		for k, i := range open {
+			ws[k] = p.bounds[i+1].Sub(p.bounds[i]).Seconds()
+			sum += ws[k]
+		}
+	}
+
+	shares := make([]float64, len(open))
+	for k := range open {
+		if sum > 0 {
+			shares[k] = remaining * ws[k] / sum
+		} else {
+			shares[k] = remaining / float64(len(open))
+		}
+	}
+	return shares
+}
+
+// Due 返回 t 之前应发送的发送量
+func (p Plan) Due(t time.Time) float64 {
+	var due float64
+	for i, cnt := range p.counts {
+		from, to := p.bounds[i], p.bounds[i+1]
+		if !t.Before(to) {
+			due += cnt
+			continue
+		}
+		if t.After(from) {
+			due += cnt * float64(t.Sub(from)) / float64(to.Sub(from))
+		}
+		break
+	}
+	return due
+}
+
+// At 返回第 i 个（从 0 开始）发送量的计划发送时间
+func (p Plan) At(i int) time.Time {
+	pos := float64(i)
+	last := 0
+	for k, cnt := range p.counts {
+		if cnt <= 0 {
+			continue
+		}
+		last = k
+		if pos < cnt {
+			from, to := p.bounds[k], p.bounds[k+1]
+			return from.Add(time.Duration(float64(to.Sub(from)) * pos / cnt))
+		}
+		pos -= cnt
+	}
+	// 超出计划的发送量在最后一个有发送量的时段开始时发送
+	return p.bounds[last]
+}
+
+// Rest 按原曲线在 [now, end) 中重新分配 total 个发送量，用于发送落后于计划时重新规划剩余的发送量
+func (p Plan) Rest(now time.Time, total int) Plan {
+	end := p.bounds[len(p.bounds)-1]
+	if !now.Before(end) {
+		return newPlan([]time.Time{end, end}, []float64{1}, total, p.maxRate)
+	}
+
+	bounds := []time.Time{now}
+	ws := make([]float64, 0, len(p.weights))
+	for i, w := range p.weights {
+		from, to := p.bounds[i], p.bounds[i+1]
+		if !to.After(now) {
+			continue
+		}
+		if from.Before(now) {
+			// 只保留时段中 now 之后的部分
+			w *= float64(to.Sub(now)) / float64(to.Sub(from))
+		}
+		bounds = append(bounds, to)
+		ws = append(ws, w)
+	}
+	return newPlan(bounds, ws, total, p.maxRate)
+}
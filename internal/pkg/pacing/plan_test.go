@@ -0,0 +1,108 @@
+package pacing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlan_Due(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	end := start.Add(100 * time.Second)
+
+	tcs := []struct {
+		name    string
+		weights []int
+		total   int
+		maxRate float64
+		at      []time.Duration // 相对 start
+		want    []float64
+	}{
+		{
+			name:  "even",
+			total: 1000,
+			at:    []time.Duration{-time.Second, 0, 25 * time.Second, 50 * time.Second, 100 * time.Second},
+			want:  []float64{0, 0, 250, 500, 1000},
+		}, {
+			name:    "curve",
+			weights: []int{1, 3},
+			total:   1000,
+			at:      []time.Duration{25 * time.Second, 50 * time.Second, 75 * time.Second},
+			want:    []float64{125, 250, 625},
+		}, {
+			name:    "rate limited segment spills over",
+			weights: []int{1, 3},
+			total:   1000,
+			maxRate: 10,
+			at:      []time.Duration{50 * time.Second, 100 * time.Second},
+			want:    []float64{500, 1000},
+		}, {
+			name:    "rate limited everywhere",
+			weights: []int{1, 3},
+			total:   2000,
+			maxRate: 10,
+			at:      []time.Duration{50 * time.Second, 100 * time.Second},
+			want:    []float64{1000, 2000},
+		}, {
+			name:    "zero weights",
+			weights: []int{0, 0},
+			total:   100,
+			at:      []time.Duration{50 * time.Second},
+			want:    []float64{50},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := NewPlan(start, end, tc.weights, tc.total, tc.maxRate)
+			for i, d := range tc.at {
+				assert.InDelta(t, tc.want[i], p.Due(start.Add(d)), 1e-6, "at %s", d)
+			}
+		})
+	}
+}
+
+func TestPlan_At(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	end := start.Add(100 * time.Second)
+
+	p := NewPlan(start, end, []int{1, 3}, 100, 0)
+	assert.Equal(t, start, p.At(0))
+	assert.Equal(t, start.Add(40*time.Second), p.At(20))
+	assert.Equal(t, start.Add(50*time.Second), p.At(25))
+	assert.Equal(t, start.Add(50*time.Second+2*time.Second/3), p.At(26))
+	assert.Equal(t, start.Add(50*time.Second), p.At(200))
+
+	p = NewPlan(start, start, nil, 10, 0)
+	assert.Equal(t, start, p.At(5))
+	assert.InDelta(t, 10, p.Due(start), 1e-6)
+}
+
+func TestPlan_Rest(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	end := start.Add(100 * time.Second)
+	p := NewPlan(start, end, []int{1, 3}, 1000, 0)
+
+	// 50 秒时只发送了 100 个，剩余 900 个按原曲线的后半段重新分配
+	rest := p.Rest(start.Add(50*time.Second), 900)
+	assert.InDelta(t, 0, rest.Due(start.Add(50*time.Second)), 1e-6)
+	assert.InDelta(t, 450, rest.Due(start.Add(75*time.Second)), 1e-6)
+	assert.InDelta(t, 900, rest.Due(end), 1e-6)
+
+	// 从时段中途开始时保留该时段剩余部分的权重
+	rest = p.Rest(start.Add(25*time.Second), 700)
+	assert.InDelta(t, 100, rest.Due(start.Add(50*time.Second)), 1e-6)
+	assert.InDelta(t, 700, rest.Due(end), 1e-6)
+
+	rest = p.Rest(end, 10)
+	assert.InDelta(t, 10, rest.Due(end), 1e-6)
+}
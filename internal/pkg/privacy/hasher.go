@@ -0,0 +1,54 @@
+package privacy
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strings"
+)
+
+// Redacted 擦除个人数据后的占位值
+const Redacted = "[redacted]"
+
+// Normalize 规范化接收者，同一接收者的不同写法（大小写、首尾空白）得到相同的结果
+func Normalize(receiver string) string {
+	return strings.ToLower(strings.TrimSpace(receiver))
+}
+
+// Hasher 接收者哈希，用于建立接收者索引而不保存明文。
+//
+// 使用 HMAC-SHA256 而不是直接哈希，避免手机号等取值空间较小的接收者被枚举还原。
+type Hasher struct {
+	key []byte
+}
+
+// Hash 返回规范化后接收者的十六进制 HMAC-SHA256
+func (h *Hasher) Hash(receiver string) string {
+	mac := hmac.New(sha256.New, h.key)
+	mac.Write([]byte(Normalize(receiver)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// HashAll 返回一组接收者的哈希，与接收者的顺序无关。
+//
+// 规范化后排序拼接再计算 HMAC-SHA256，只有一个接收者时与 Hash 的结果相同。
+func (h *Hasher) HashAll(receivers []string) string {
+	normalized := make([]string, 0, len(receivers))
+	for _, receiver := range receivers {
+		normalized = append(normalized, Normalize(receiver))
+	}
+	slices.Sort(normalized)
+
+	mac := hmac.New(sha256.New, h.key)
+	mac.Write([]byte(strings.Join(normalized, "\n")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func NewHasher(key string) (*Hasher, error) {
+	if key == "" {
+		return nil, errors.New("receiver hash key should not be empty")
+	}
+	return &Hasher{key: []byte(key)}, nil
+}
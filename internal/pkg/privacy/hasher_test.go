@@ -0,0 +1,37 @@
+package privacy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHasher_Hash(t *testing.T) {
+	t.Parallel()
+
+	h, err := NewHasher("key")
+	require.NoError(t, err)
+
+	assert.Len(t, h.Hash("13800000000"), 64)
+	assert.Equal(t, h.Hash("Foo@Example.com"), h.Hash(" foo@example.com "))
+	assert.NotEqual(t, h.Hash("13800000000"), h.Hash("13800000001"))
+
+	other, err := NewHasher("other")
+	require.NoError(t, err)
+	assert.NotEqual(t, h.Hash("13800000000"), other.Hash("13800000000"))
+
+	_, err = NewHasher("")
+	assert.Error(t, err)
+}
+
+func TestHasher_HashAll(t *testing.T) {
+	t.Parallel()
+
+	h, err := NewHasher("key")
+	require.NoError(t, err)
+
+	assert.Equal(t, h.Hash("foo@example.com"), h.HashAll([]string{"Foo@Example.com"}))
+	assert.Equal(t, h.HashAll([]string{"a@example.com", "b@example.com"}), h.HashAll([]string{"B@example.com", "a@example.com"}))
+	assert.NotEqual(t, h.HashAll([]string{"a@example.com", "b@example.com"}), h.HashAll([]string{"a@example.com"}))
+}
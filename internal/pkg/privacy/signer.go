@@ -0,0 +1,57 @@
+package privacy
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+var ErrInvalidSignature = errors.New("[jotify] invalid signature")
+
+// Signer 使用 HMAC-SHA256 签名，生成可以放在链接中的 token，格式为 base64url(payload).base64url(签名)。
+//
+// payload 不加密，不应包含接收者明文。
+type Signer struct {
+	key []byte
+}
+
+// Sign 返回 payload 的签名 token
+func (s *Signer) Sign(payload []byte) string {
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(s.mac(payload))
+}
+
+// Verify 校验 token 的签名并返回 payload
+func (s *Signer) Verify(token string) ([]byte, error) {
+	payloadStr, sigStr, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalidSignature
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(payloadStr)
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(sigStr)
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+	if !hmac.Equal(sig, s.mac(payload)) {
+		return nil, ErrInvalidSignature
+	}
+	return payload, nil
+}
+
+func (s *Signer) mac(payload []byte) []byte {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+func NewSigner(key string) (*Signer, error) {
+	if key == "" {
+		return nil, errors.New("signing key should not be empty")
+	}
+	return &Signer{key: []byte(key)}, nil
+}
@@ -0,0 +1,33 @@
+package privacy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigner(t *testing.T) {
+	t.Parallel()
+
+	s, err := NewSigner("key")
+	require.NoError(t, err)
+
+	token := s.Sign([]byte(`{"b":1}`))
+	payload, err := s.Verify(token)
+	require.NoError(t, err)
+	assert.Equal(t, `{"b":1}`, string(payload))
+
+	other, err := NewSigner("other")
+	require.NoError(t, err)
+	_, err = other.Verify(token)
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+
+	_, err = s.Verify(token[:len(token)-2])
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+	_, err = s.Verify("invalid")
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+
+	_, err = NewSigner("")
+	assert.Error(t, err)
+}
@@ -0,0 +1,84 @@
+package sensitive
+
+import (
+	"context"
+	"strings"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.uber.org/zap"
+)
+
+// EtcdSyncer 从 etcd 加载并监听敏感词列表。
+//
+// 每个敏感词是 prefix 下的一个 key（<prefix><word>），value 不使用。
+// etcd 中没有任何敏感词时使用 fallback（配置文件中的敏感词）。
+type EtcdSyncer struct {
+	client *clientv3.Client
+	prefix string
+	filter *Filter
+
+	fallback []string
+
+	logger *zap.Logger
+}
+
+// Load 从 etcd 全量加载敏感词
+func (s *EtcdSyncer) Load(ctx context.Context) error {
+	resp, err := s.client.Get(ctx, s.prefix, clientv3.WithPrefix(), clientv3.WithKeysOnly())
+	if err != nil {
+		return err
+	}
+
+	words := make([]string, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		words = append(words, strings.TrimPrefix(string(kv.Key), s.prefix))
+	}
+	if len(words) == 0 {
+		words = s.fallback
+	}
+
+	s.filter.Reset(words)
+	s.logger.Info("[jotify] sensitive words loaded", zap.Int("count", len(words)))
+	return nil
+}
+
+// Watch 监听 etcd 变更，任意变更都会触发全量重新加载
+func (s *EtcdSyncer) Watch(ctx context.Context) {
+	watchChan := s.client.Watch(clientv3.WithRequireLeader(ctx), s.prefix, clientv3.WithPrefix(), clientv3.WithKeysOnly())
+	for watchResp := range watchChan {
+		if watchResp.Err() != nil {
+			s.logger.Error("[jotify] sensitive word watch error", zap.Error(watchResp.Err()))
+			continue
+		}
+
+		if err := s.Load(ctx); err != nil {
+			s.logger.Error("[jotify] failed to reload sensitive words from etcd", zap.Error(err))
+		}
+	}
+}
+
+func (s *EtcdSyncer) Filter() *Filter {
+	return s.filter
+}
+
+// EtcdKeyOfWord 敏感词在 etcd 中的完整 key
+func (s *EtcdSyncer) EtcdKeyOfWord(word string) string {
+	return s.prefix + word
+}
+
+// Prefix 敏感词在 etcd 中的 key 前缀
+func (s *EtcdSyncer) Prefix() string {
+	return s.prefix
+}
+
+func NewEtcdSyncer(
+	client *clientv3.Client, prefix string, filter *Filter, fallback []string, logger *zap.Logger,
+) *EtcdSyncer {
+	return &EtcdSyncer{
+		client:   client,
+		prefix:   prefix,
+		filter:   filter,
+		fallback: fallback,
+		logger:   logger,
+	}
+}
@@ -0,0 +1,24 @@
+package sensitive
+
+import "sync/atomic"
+
+// Filter 可热更新的敏感词过滤器，Reset 原子替换匹配器，不影响正在进行的匹配
+type Filter struct {
+	matcher atomic.Pointer[Matcher]
+}
+
+// Reset 使用新的敏感词列表替换匹配器
+func (f *Filter) Reset(words []string) {
+	f.matcher.Store(NewMatcher(words))
+}
+
+// Matcher 返回当前的匹配器
+func (f *Filter) Matcher() *Matcher {
+	return f.matcher.Load()
+}
+
+func NewFilter(words []string) *Filter {
+	f := &Filter{}
+	f.Reset(words)
+	return f
+}
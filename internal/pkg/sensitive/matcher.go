@@ -0,0 +1,193 @@
+package sensitive
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Match 文本中命中的敏感词，Start 与 End 为命中位置的字符（rune）下标，左闭右开
+type Match struct {
+	Word  string
+	Start int
+	End   int
+}
+
+// Matcher 基于 Aho-Corasick 自动机的多模式匹配器，不区分大小写，构造后只读，可以并发使用。
+//
+// 匹配耗时与文本长度及命中次数成正比，与敏感词数量无关。
+type Matcher struct {
+	nodes []node
+	words []string
+}
+
+type node struct {
+	children map[rune]int32
+	fail     int32 // 失败指针：当前节点对应字符串的最长真后缀所在节点
+	dict     int32 // 失败链上最近的终止节点，-1 表示没有
+	word     int32 // 以当前节点结尾的敏感词下标，-1 表示不是终止节点
+	depth    int32 // 节点对应字符串的字符数
+}
+
+// NewMatcher 构造匹配器，忽略空白与重复的敏感词
+func NewMatcher(words []string) *Matcher {
+	m := &Matcher{
+		nodes: []node{{children: make(map[rune]int32), dict: -1, word: -1}},
+	}
+
+	for _, word := range words {
+		word = strings.TrimSpace(word)
+		if word == "" {
+			continue
+		}
+		m.insert(word)
+	}
+	m.build()
+	return m
+}
+
+// insert 将敏感词加入字典树
+func (m *Matcher) insert(word string) {
+	var cur int32
+	for _, r := range word {
+		r = unicode.ToLower(r)
+		next, ok := m.nodes[cur].children[r]
+		if !ok {
+			next = int32(len(m.nodes))
+			m.nodes = append(m.nodes, node{
+				children: make(map[rune]int32),
+				dict:     -1,
+				word:     -1,
+				depth:    m.nodes[cur].depth + 1,
+			})
+			m.nodes[cur].children[r] = next
+		}
+		cur = next
+	}
+
+	if m.nodes[cur].word < 0 {
+		m.nodes[cur].word = int32(len(m.words))
+		m.words = append(m.words, word)
+	}
+}
+
+// build 按层序计算失败指针与字典后缀指针
+func (m *Matcher) build() {
+	queue := make([]int32, 0, len(m.nodes))
+	for _, child := range m.nodes[0].children {
+		queue = append(queue, child)
+	}
+
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+
+		for r, child := range m.nodes[cur].children {
+			fail := m.nodes[cur].fail
+			for {
+				if next, ok := m.nodes[fail].children[r]; ok && next != child {
+					m.nodes[child].fail = next
+					break
+				}
+				if fail == 0 {
+					m.nodes[child].fail = 0
+					break
+				}
+				fail = m.nodes[fail].fail
+			}
+
+			failNode := m.nodes[m.nodes[child].fail]
+			if failNode.word >= 0 {
+				m.nodes[child].dict = m.nodes[child].fail
+			} else {
+				m.nodes[child].dict = failNode.dict
+			}
+			queue = append(queue, child)
+		}
+	}
+}
+
+// Empty 是否没有任何敏感词
+func (m *Matcher) Empty() bool {
+	return len(m.words) == 0
+}
+
+// Find 返回文本中全部命中的敏感词，按结束位置升序，同一位置结束的较长的词在前
+func (m *Matcher) Find(text string) []Match {
+	if m.Empty() {
+		return nil
+	}
+
+	var matches []Match
+	m.scan(text, func(n node, end int) bool {
+		matches = append(matches, Match{Word: m.words[n.word], Start: end - int(n.depth), End: end})
+		return true
+	})
+	return matches
+}
+
+// Contains 文本中是否命中任意敏感词
+func (m *Matcher) Contains(text string) bool {
+	if m.Empty() {
+		return false
+	}
+
+	found := false
+	m.scan(text, func(_ node, _ int) bool {
+		found = true
+		return false
+	})
+	return found
+}
+
+// Mask 将命中的敏感词逐字符替换为 mask，返回替换后的文本与命中的敏感词
+func (m *Matcher) Mask(text string, mask rune) (string, []string) {
+	matches := m.Find(text)
+	if len(matches) == 0 {
+		return text, nil
+	}
+
+	runes := []rune(text)
+	words := make([]string, 0, len(matches))
+	seen := make(map[string]struct{}, len(matches))
+	for _, match := range matches {
+		for i := match.Start; i < match.End; i++ {
+			runes[i] = mask
+		}
+		if _, ok := seen[match.Word]; !ok {
+			seen[match.Word] = struct{}{}
+			words = append(words, match.Word)
+		}
+	}
+	return string(runes), words
+}
+
+// scan 在文本上运行自动机，每次命中以敏感词的终止节点调用 hit（end 为命中位置的结束字符下标），hit 返回 false 时停止
+func (m *Matcher) scan(text string, hit func(n node, end int) bool) {
+	var cur int32
+	pos := 0
+	for _, r := range text {
+		r = unicode.ToLower(r)
+		pos++
+
+		for {
+			if next, ok := m.nodes[cur].children[r]; ok {
+				cur = next
+				break
+			}
+			if cur == 0 {
+				break
+			}
+			cur = m.nodes[cur].fail
+		}
+
+		out := cur
+		if m.nodes[out].word < 0 {
+			out = m.nodes[out].dict
+		}
+		for ; out > 0; out = m.nodes[out].dict {
+			if !hit(m.nodes[out], pos) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package sensitive
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatcher_Find(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name  string
+		words []string
+		text  string
+		want  []Match
+	}{
+		{
+			name:  "classic",
+			words: []string{"he", "she", "his", "hers"},
+			text:  "ushers",
+			want: []Match{
+				{Word: "she", Start: 1, End: 4},
+				{Word: "he", Start: 2, End: 4},
+				{Word: "hers", Start: 2, End: 6},
+			},
+		}, {
+			name:  "case insensitive",
+			words: []string{"Casino"},
+			text:  "visit CASINO now",
+			want:  []Match{{Word: "Casino", Start: 6, End: 12}},
+		}, {
+			name:  "rune offsets",
+			words: []string{"赌博", "博彩"},
+			text:  "网上赌博彩票",
+			want: []Match{
+				{Word: "赌博", Start: 2, End: 4},
+				{Word: "博彩", Start: 3, End: 5},
+			},
+		}, {
+			name:  "fail transition",
+			words: []string{"abcd", "bce"},
+			text:  "abce",
+			want:  []Match{{Word: "bce", Start: 1, End: 4}},
+		}, {
+			name:  "no words",
+			words: []string{"", "  "},
+			text:  "anything",
+		}, {
+			name:  "no match",
+			words: []string{"foo"},
+			text:  "bar",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			m := NewMatcher(tc.words)
+			assert.Equal(t, tc.want, m.Find(tc.text))
+			assert.Equal(t, len(tc.want) > 0, m.Contains(tc.text))
+		})
+	}
+}
+
+func TestMatcher_Mask(t *testing.T) {
+	t.Parallel()
+
+	m := NewMatcher([]string{"赌博", "博彩", "casino"})
+
+	masked, words := m.Mask("网上赌博彩票 Casino", '*')
+	assert.Equal(t, "网上***票 ******", masked)
+	assert.Equal(t, []string{"赌博", "博彩", "casino"}, words)
+
+	masked, words = m.Mask("正常内容", '*')
+	assert.Equal(t, "正常内容", masked)
+	assert.Nil(t, words)
+}
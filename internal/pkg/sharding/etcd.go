@@ -0,0 +1,142 @@
+package sharding
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.uber.org/zap"
+)
+
+// RoutingSyncer 从 etcd 加载并监听路由表，变更后更新所有分库分表策略。
+//
+// notification 与 callback_log 在业务上要求位于同一个库中，所以所有策略共用同一个路由表。
+// etcd 中没有路由表时使用 fallback（配置文件中的布局）。
+type RoutingSyncer struct {
+	client   *clientv3.Client
+	key      string
+	revision atomic.Int64 // 当前路由表在 etcd 中的修改版本，etcd 中没有路由表时为 0
+
+	fallback   RoutingTable
+	strategies map[string]*VersionedStrategy // key 为表前缀
+
+	logger *zap.Logger
+}
+
+// Load 从 etcd 加载路由表
+func (s *RoutingSyncer) Load(ctx context.Context) error {
+	resp, err := s.client.Get(ctx, s.key)
+	if err != nil {
+		return err
+	}
+
+	rt := s.fallback
+	revision := int64(0)
+	if len(resp.Kvs) > 0 {
+		if err = json.Unmarshal(resp.Kvs[0].Value, &rt); err != nil {
+			return fmt.Errorf("failed to parse routing table: %w", err)
+		}
+		revision = resp.Kvs[0].ModRevision
+	}
+
+	if err = s.reset(rt); err != nil {
+		return err
+	}
+	s.revision.Store(revision)
+	return nil
+}
+
+func (s *RoutingSyncer) reset(rt RoutingTable) error {
+	// 先校验，校验失败时所有策略保持上一次的有效路由表
+	if err := rt.Validate(); err != nil {
+		return err
+	}
+	for _, strategy := range s.strategies {
+		if err := strategy.Reset(rt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Watch 监听路由表变更
+func (s *RoutingSyncer) Watch(ctx context.Context) {
+	watchChan := s.client.Watch(clientv3.WithRequireLeader(ctx), s.key)
+	for watchResp := range watchChan {
+		if watchResp.Err() != nil {
+			s.logger.Error("[jotify] routing table watch error", zap.Error(watchResp.Err()))
+			continue
+		}
+
+		if err := s.Load(ctx); err != nil {
+			s.logger.Error("[jotify] failed to reload routing table from etcd", zap.Error(err))
+			continue
+		}
+		s.logger.Info("[jotify] routing table reloaded", zap.Any("routing_table", s.RoutingTable()))
+	}
+}
+
+// Publish 发布新的路由表。
+//
+// 以上一次 Load 时路由表的修改版本做 CAS，其间路由表被其他人修改时返回错误，需要重新 Load 后再发布。
+func (s *RoutingSyncer) Publish(ctx context.Context, rt RoutingTable) error {
+	if err := rt.Validate(); err != nil {
+		return err
+	}
+
+	val, err := json.Marshal(rt)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.ModRevision(s.key), "=", s.revision.Load())).
+		Then(clientv3.OpPut(s.key, string(val))).
+		Commit()
+	if err != nil {
+		return err
+	}
+	if !resp.Succeeded {
+		return errors.New("routing table has been modified concurrently")
+	}
+
+	s.revision.Store(resp.Header.Revision)
+	return s.reset(rt)
+}
+
+// RoutingTable 返回当前路由表
+func (s *RoutingSyncer) RoutingTable() RoutingTable {
+	for _, strategy := range s.strategies {
+		return strategy.RoutingTable()
+	}
+	return s.fallback
+}
+
+// Strategy 根据表前缀获取分库分表策略
+func (s *RoutingSyncer) Strategy(tablePrefix string) *VersionedStrategy {
+	return s.strategies[tablePrefix]
+}
+
+func NewRoutingSyncer(
+	client *clientv3.Client,
+	key string,
+	fallback RoutingTable,
+	strategies []*VersionedStrategy,
+	logger *zap.Logger,
+) *RoutingSyncer {
+	m := make(map[string]*VersionedStrategy, len(strategies))
+	for _, strategy := range strategies {
+		m[strategy.TablePrefix()] = strategy
+	}
+
+	return &RoutingSyncer{
+		client:     client,
+		key:        key,
+		fallback:   fallback,
+		strategies: m,
+		logger:     logger,
+	}
+}
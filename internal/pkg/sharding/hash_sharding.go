@@ -5,7 +5,6 @@ import (
 	"strings"
 
 	"github.com/JrMarcco/jotify/internal/pkg/snowflake"
-	"github.com/cespare/xxhash/v2"
 )
 
 var _ Strategy = (*HashStrategy)(nil)
@@ -20,23 +19,20 @@ type HashStrategy struct {
 }
 
 // Shard 根据 bizId 和 bizKey 进行分库分表
+//
+// 使用与 id 中相同的 hash 槽位计算，保证 Shard 与 ShardWithId 的结果一致。
 func (h HashStrategy) Shard(bizId uint64, bizKey string) Dst {
-	hashVal := xxhash.Sum64String(snowflake.HashKey(bizId, bizKey))
-	dbSuffix := hashVal % h.dbSharding
-	tableSuffix := (hashVal / h.dbSharding) % h.tableSharding
-	return Dst{
-		DBSuffix:    dbSuffix,
-		TableSuffix: tableSuffix,
-		DB:          fmt.Sprintf("%s_%d", h.dbPrefix, dbSuffix),
-		Table:       fmt.Sprintf("%s_%d", h.tablePrefix, tableSuffix),
-	}
+	return h.dstOf(snowflake.HashSlot(bizId, bizKey))
 }
 
 // ShardWithId 解析 id 获得分库分表信息
 func (h HashStrategy) ShardWithId(id uint64) Dst {
-	hashVal := snowflake.ExtractHash(id)
-	dbSuffix := hashVal % h.dbSharding
-	tableSuffix := (hashVal / h.dbSharding) % h.tableSharding
+	return h.dstOf(snowflake.ExtractHash(id))
+}
+
+func (h HashStrategy) dstOf(slot uint64) Dst {
+	dbSuffix := slot % h.dbSharding
+	tableSuffix := (slot / h.dbSharding) % h.tableSharding
 	return Dst{
 		DBSuffix:    dbSuffix,
 		TableSuffix: tableSuffix,
@@ -0,0 +1,185 @@
+package sharding
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/JrMarcco/jotify/internal/pkg/snowflake"
+)
+
+// Layout 分库分表布局
+type Layout struct {
+	Version       uint64 `json:"version"`
+	DBSharding    uint64 `json:"db_sharding"`
+	TableSharding uint64 `json:"table_sharding"`
+}
+
+func (l Layout) Validate() error {
+	if l.DBSharding == 0 || l.TableSharding == 0 {
+		return errors.New("db sharding and table sharding should be greater than 0")
+	}
+	if l.DBSharding*l.TableSharding > snowflake.HashSlots {
+		return fmt.Errorf("total shards should not exceed %d hash slots", snowflake.HashSlots)
+	}
+	return nil
+}
+
+// RoutingTable 版本化路由表。
+//
+// 扩容分为三个阶段：
+//  1. 开始迁移：发布新布局为 Current，原布局保留为 Previous，新消息写入新布局，读取时新布局未命中再读旧布局（双读）
+//  2. 回填：将旧布局中的记录复制到新布局并从旧布局删除
+//  3. 切换：回填完成后移除 Previous，所有 id 只按新布局路由
+//
+// 路由按 id 中的 hash 槽位计算，与 id 生成时的布局无关，所以回填完成后旧布局下生成的 id 仍能路由到正确的分片。
+type RoutingTable struct {
+	Current  Layout  `json:"current"`
+	Previous *Layout `json:"previous,omitempty"`
+}
+
+// Migrating 是否处于迁移中
+func (rt RoutingTable) Migrating() bool {
+	return rt.Previous != nil
+}
+
+func (rt RoutingTable) Validate() error {
+	if err := rt.Current.Validate(); err != nil {
+		return fmt.Errorf("invalid current layout: %w", err)
+	}
+	if rt.Previous == nil {
+		return nil
+	}
+	if err := rt.Previous.Validate(); err != nil {
+		return fmt.Errorf("invalid previous layout: %w", err)
+	}
+	if rt.Previous.Version >= rt.Current.Version {
+		return errors.New("previous layout version should be less than current")
+	}
+	return nil
+}
+
+// MigratingStrategy 支持在线扩容的分库分表策略，迁移期间可以获取旧布局下的目标用于双读
+type MigratingStrategy interface {
+	Strategy
+	// Migrating 是否处于迁移中
+	Migrating() bool
+	// PreviousShard 迁移期间返回旧布局下的目标，未在迁移或与新布局目标相同时返回 false
+	PreviousShard(bizId uint64, bizKey string) (Dst, bool)
+	// PreviousShardWithId 同 PreviousShard，根据 id 计算
+	PreviousShardWithId(id uint64) (Dst, bool)
+}
+
+var _ MigratingStrategy = (*VersionedStrategy)(nil)
+
+// VersionedStrategy 基于版本化路由表的分库分表策略，路由表可以在运行时整体替换。
+type VersionedStrategy struct {
+	dbPrefix    string
+	tablePrefix string
+
+	state atomic.Pointer[routingState]
+}
+
+type routingState struct {
+	table    RoutingTable
+	current  HashStrategy
+	previous *HashStrategy
+}
+
+func (v *VersionedStrategy) Shard(bizId uint64, bizKey string) Dst {
+	return v.state.Load().current.Shard(bizId, bizKey)
+}
+
+func (v *VersionedStrategy) ShardWithId(id uint64) Dst {
+	return v.state.Load().current.ShardWithId(id)
+}
+
+// BroadCast 迁移期间同时包含新旧布局的全部分片
+func (v *VersionedStrategy) BroadCast() []Dst {
+	state := v.state.Load()
+	dsts := state.current.BroadCast()
+	if state.previous == nil {
+		return dsts
+	}
+
+	seen := make(map[Dst]struct{}, len(dsts))
+	for _, dst := range dsts {
+		seen[dst] = struct{}{}
+	}
+	for _, dst := range state.previous.BroadCast() {
+		if _, ok := seen[dst]; !ok {
+			dsts = append(dsts, dst)
+		}
+	}
+	return dsts
+}
+
+func (v *VersionedStrategy) Migrating() bool {
+	return v.state.Load().previous != nil
+}
+
+func (v *VersionedStrategy) PreviousShard(bizId uint64, bizKey string) (Dst, bool) {
+	state := v.state.Load()
+	if state.previous == nil {
+		return Dst{}, false
+	}
+	dst := state.previous.Shard(bizId, bizKey)
+	return dst, dst != state.current.Shard(bizId, bizKey)
+}
+
+func (v *VersionedStrategy) PreviousShardWithId(id uint64) (Dst, bool) {
+	state := v.state.Load()
+	if state.previous == nil {
+		return Dst{}, false
+	}
+	dst := state.previous.ShardWithId(id)
+	return dst, dst != state.current.ShardWithId(id)
+}
+
+// PreviousBroadCast 迁移期间返回旧布局的全部分片，用于回填
+func (v *VersionedStrategy) PreviousBroadCast() []Dst {
+	state := v.state.Load()
+	if state.previous == nil {
+		return nil
+	}
+	return state.previous.BroadCast()
+}
+
+// RoutingTable 返回当前路由表
+func (v *VersionedStrategy) RoutingTable() RoutingTable {
+	return v.state.Load().table
+}
+
+// Reset 整体替换路由表
+func (v *VersionedStrategy) Reset(rt RoutingTable) error {
+	if err := rt.Validate(); err != nil {
+		return err
+	}
+
+	state := &routingState{
+		table:   rt,
+		current: NewHashStrategy(v.dbPrefix, v.tablePrefix, rt.Current.DBSharding, rt.Current.TableSharding),
+	}
+	if rt.Previous != nil {
+		previous := NewHashStrategy(v.dbPrefix, v.tablePrefix, rt.Previous.DBSharding, rt.Previous.TableSharding)
+		state.previous = &previous
+	}
+	v.state.Store(state)
+	return nil
+}
+
+func (v *VersionedStrategy) TablePrefix() string {
+	return v.tablePrefix
+}
+
+// NewVersionedStrategy 创建版本化分库分表策略，rt 为初始路由表
+func NewVersionedStrategy(dbPrefix, tablePrefix string, rt RoutingTable) (*VersionedStrategy, error) {
+	v := &VersionedStrategy{
+		dbPrefix:    dbPrefix,
+		tablePrefix: tablePrefix,
+	}
+	if err := v.Reset(rt); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
@@ -0,0 +1,113 @@
+package sharding
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/JrMarcco/jotify/internal/pkg/snowflake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionedStrategy_ShardConsistency(t *testing.T) {
+	t.Parallel()
+
+	g := snowflake.NewGenerator()
+	for _, layout := range []Layout{
+		{Version: 1, DBSharding: 2, TableSharding: 4},
+		{Version: 2, DBSharding: 3, TableSharding: 5},
+		{Version: 3, DBSharding: 8, TableSharding: 16},
+	} {
+		v, err := NewVersionedStrategy("jotify", "notification", RoutingTable{Current: layout})
+		require.NoError(t, err)
+
+		for i := 0; i < 1000; i++ {
+			bizId, bizKey := uint64(i%7), "biz_key_"+strconv.Itoa(i)
+			id := g.NextId(bizId, bizKey)
+			assert.Equal(t, v.Shard(bizId, bizKey), v.ShardWithId(id))
+		}
+	}
+}
+
+func TestVersionedStrategy_Migrating(t *testing.T) {
+	t.Parallel()
+
+	previous := Layout{Version: 1, DBSharding: 2, TableSharding: 4}
+	current := Layout{Version: 2, DBSharding: 4, TableSharding: 4}
+
+	v, err := NewVersionedStrategy("jotify", "notification", RoutingTable{Current: previous})
+	require.NoError(t, err)
+
+	g := snowflake.NewGenerator()
+	ids := make([]uint64, 0, 100)
+	for i := 0; i < 100; i++ {
+		ids = append(ids, g.NextId(uint64(i), "biz_key"))
+	}
+
+	oldDsts := make(map[uint64]Dst, len(ids))
+	for _, id := range ids {
+		oldDsts[id] = v.ShardWithId(id)
+		_, ok := v.PreviousShardWithId(id)
+		assert.False(t, ok)
+	}
+
+	require.NoError(t, v.Reset(RoutingTable{Current: current, Previous: &previous}))
+	assert.Len(t, v.BroadCast(), 16)
+	assert.Len(t, v.PreviousBroadCast(), 8)
+
+	moved := 0
+	for _, id := range ids {
+		prevDst, ok := v.PreviousShardWithId(id)
+		if !ok {
+			// 新旧布局路由到同一分片
+			assert.Equal(t, oldDsts[id], v.ShardWithId(id))
+			continue
+		}
+		moved++
+		assert.Equal(t, oldDsts[id], prevDst)
+		assert.NotEqual(t, prevDst, v.ShardWithId(id))
+	}
+	assert.Greater(t, moved, 0)
+
+	// 切换后不再有旧布局
+	require.NoError(t, v.Reset(RoutingTable{Current: current}))
+	assert.Len(t, v.BroadCast(), 16)
+	assert.Empty(t, v.PreviousBroadCast())
+}
+
+func TestRoutingTable_Validate(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name    string
+		rt      RoutingTable
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			rt:   RoutingTable{Current: Layout{Version: 1, DBSharding: 2, TableSharding: 4}},
+		}, {
+			name:    "zero sharding",
+			rt:      RoutingTable{Current: Layout{Version: 1, DBSharding: 0, TableSharding: 4}},
+			wantErr: true,
+		}, {
+			name:    "exceed hash slots",
+			rt:      RoutingTable{Current: Layout{Version: 1, DBSharding: 64, TableSharding: 32}},
+			wantErr: true,
+		}, {
+			name: "previous version not less than current",
+			rt: RoutingTable{
+				Current:  Layout{Version: 1, DBSharding: 4, TableSharding: 4},
+				Previous: &Layout{Version: 1, DBSharding: 2, TableSharding: 4},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.rt.Validate()
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
@@ -20,6 +20,9 @@ const (
 	hashMask      = (uint64(1) << hashBits) - 1
 	timestampMask = (uint64(1) << timestampBits) - 1
 
+	// HashSlots hash 值的取值个数，分库分表按 hash 槽位路由，分片总数不能超过槽位数
+	HashSlots = uint64(1) << hashBits
+
 	epochMillis   = uint64(1735689600000) // milliseconds of 2025-01-01 00:00:00
 	number1000    = uint64(1000)
 	number1000000 = uint64(1000000)
@@ -57,6 +60,11 @@ func (g *Generator) NextId(bizId uint64, bizKey string) uint64 {
 	return (timestamp&timestampMask)<<timestampShift | (hashVal&hashMask)<<hashShift | (seq & sequenceMask)
 }
 
+// HashSlot 返回业务 id 和业务 key 对应的 hash 槽位，与 id 中解析出的 hash 值一致
+func HashSlot(bizId uint64, bizKey string) uint64 {
+	return xxhash.Sum64String(HashKey(bizId, bizKey)) & hashMask
+}
+
 func HashKey(bizId uint64, bizKey string) string {
 	return strconv.FormatUint(bizId, 10) + ":" + bizKey
 }
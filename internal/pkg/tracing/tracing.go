@@ -0,0 +1,82 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/JrMarcco/jotify"
+
+// 通用 span 属性 key
+const (
+	AttrNotificationId = attribute.Key("jotify.notification_id")
+	AttrBizId          = attribute.Key("jotify.biz_id")
+	AttrBizKey         = attribute.Key("jotify.biz_key")
+	AttrChannel        = attribute.Key("jotify.channel")
+	AttrStrategy       = attribute.Key("jotify.strategy")
+	AttrProvider       = attribute.Key("jotify.provider")
+	AttrShardDB        = attribute.Key("jotify.shard.db")
+	AttrShardTable     = attribute.Key("jotify.shard.table")
+	AttrBatchSize      = attribute.Key("jotify.batch_size")
+)
+
+// Start 开启一个新的 span，调用方负责调用 End 结束 span。
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartWithLinks 开启一个带链接的 span。
+//
+// 用于异步场景：调度器发送时开启的 span 与原始请求不在同一条链路上，通过 link 关联回原始请求。
+func StartWithLinks(ctx context.Context, name string, links []trace.Link, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithLinks(links...), trace.WithAttributes(attrs...))
+}
+
+// End 结束 span，err 不为 nil 时记录错误并设置 span 状态。
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Inject 将 context 中的链路信息序列化为 carrier，用于随异步消息一起存储。
+func Inject(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return nil
+	}
+	return carrier
+}
+
+// Link 从存储的 carrier 中还原链路信息并生成 link，carrier 无效时返回 false。
+func Link(carrier map[string]string) (trace.Link, bool) {
+	if len(carrier) == 0 {
+		return trace.Link{}, false
+	}
+
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), propagation.MapCarrier(carrier))
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return trace.Link{}, false
+	}
+	return trace.Link{SpanContext: sc}, true
+}
+
+// Links 批量还原链路信息，忽略无效的 carrier。
+func Links(carriers ...map[string]string) []trace.Link {
+	links := make([]trace.Link, 0, len(carriers))
+	for _, carrier := range carriers {
+		if link, ok := Link(carrier); ok {
+			links = append(links, link)
+		}
+	}
+	return links
+}
@@ -0,0 +1,54 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestInjectAndLink(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	traceId, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanId, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceId,
+		SpanID:     spanId,
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+
+	tcs := []struct {
+		name    string
+		ctx     context.Context
+		wantOk  bool
+		wantSid trace.SpanID
+	}{
+		{
+			name:   "no span in context",
+			ctx:    context.Background(),
+			wantOk: false,
+		}, {
+			name:    "valid span context",
+			ctx:     trace.ContextWithSpanContext(context.Background(), sc),
+			wantOk:  true,
+			wantSid: spanId,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			carrier := Inject(tc.ctx)
+			link, ok := Link(carrier)
+			assert.Equal(t, tc.wantOk, ok)
+			if ok {
+				assert.Equal(t, traceId, link.SpanContext.TraceID())
+				assert.Equal(t, tc.wantSid, link.SpanContext.SpanID())
+			}
+		})
+	}
+}
@@ -17,6 +17,7 @@ var _ BizConfRepo = (*DefaultBizConfRepo)(nil)
 
 type DefaultBizConfRepo struct {
 	dao        dao.BizConfDAO
+	orgDAO     dao.OrganizationDAO
 	localCache cache.BizConfCache
 	redisCache cache.BizConfCache
 	logger     *zap.Logger
@@ -46,6 +47,22 @@ func (d *DefaultBizConfRepo) GetById(ctx context.Context, id uint64) (domain.Biz
 
 	bizConf = d.toDomain(bcEntity)
 
+	// 组织的成员业务方使用组织的默认配置补全，组织配置的变更在缓存过期后生效
+	if orgId, ok := bizConf.OrgId(); ok {
+		orgEntity, err := d.orgDAO.GetById(ctx, orgId)
+		if err != nil {
+			return domain.BizConf{}, err
+		}
+		org := domain.Organization{RateLimit: orgEntity.RateLimit}
+		if orgEntity.ChannelConf.Valid {
+			org.ChannelConf = &orgEntity.ChannelConf.Val
+		}
+		if orgEntity.DeliveryConf.Valid {
+			org.DeliveryConf = &orgEntity.DeliveryConf.Val
+		}
+		org.Inherit(&bizConf)
+	}
+
 	// 先刷新本地缓存（本地缓存几乎不会出错）
 	if lcErr := d.localCache.Set(ctx, id, bizConf); lcErr != nil {
 		d.logger.Error("[jotify] failed to refresh biz conf local cache", zap.Error(lcErr), zap.Uint64("biz_id", id))
@@ -60,12 +77,14 @@ func (d *DefaultBizConfRepo) GetById(ctx context.Context, id uint64) (domain.Biz
 
 func (d *DefaultBizConfRepo) toDomain(entity dao.BizConf) domain.BizConf {
 	bizConf := domain.BizConf{
-		Id:        entity.Id,
-		OwnerId:   entity.OwnerId,
-		OwnerType: entity.OwnerType,
-		RateLimit: entity.RateLimit,
-		CreateAt:  entity.CreatedAt,
-		UpdateAt:  entity.UpdatedAt,
+		Id:               entity.Id,
+		OwnerId:          entity.OwnerId,
+		OwnerType:        entity.OwnerType,
+		RateLimit:        entity.RateLimit,
+		RetentionDays:    entity.RetentionDays,
+		SchedulingWeight: entity.SchedulingWeight,
+		CreateAt:         entity.CreatedAt,
+		UpdateAt:         entity.UpdatedAt,
 	}
 
 	if entity.ChannelConf.Valid {
@@ -84,17 +103,27 @@ func (d *DefaultBizConfRepo) toDomain(entity dao.BizConf) domain.BizConf {
 		bizConf.CallbackConf = &entity.CallbackConf.Val
 	}
 
+	if entity.DeliveryConf.Valid {
+		bizConf.DeliveryConf = &entity.DeliveryConf.Val
+	}
+
+	if entity.ContentConf.Valid {
+		bizConf.ContentConf = &entity.ContentConf.Val
+	}
+
 	return bizConf
 }
 
 func NewDefaultBizConfRepo(
 	dao dao.BizConfDAO,
+	orgDAO dao.OrganizationDAO,
 	localCache cache.BizConfCache,
 	redisCache cache.BizConfCache,
 	logger *zap.Logger,
 ) *DefaultBizConfRepo {
 	return &DefaultBizConfRepo{
 		dao:        dao,
+		orgDAO:     orgDAO,
 		localCache: localCache,
 		redisCache: redisCache,
 		logger:     logger,
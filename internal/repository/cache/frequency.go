@@ -0,0 +1,20 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+type FrequencyCache interface {
+	// Acquire 按组校验并计数，同一组的计数全部未达到上限时才全部加一，返回每组是否通过
+	Acquire(ctx context.Context, groups [][]FrequencyParam) ([]bool, error)
+	// Release 释放 Acquire 的计数，计数不会小于 0
+	Release(ctx context.Context, params []FrequencyParam) error
+}
+
+// FrequencyParam 一个接收者在一条频控规则的一个周期内的计数
+type FrequencyParam struct {
+	Key   string        // 规则、周期与接收者哈希组成的 key
+	Limit int32         // 周期内的上限
+	TTL   time.Duration // 计数的过期时间，不短于周期的长度
+}
@@ -0,0 +1,20 @@
+package cache
+
+import (
+	"context"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+)
+
+// OrgQuotaCache 组织配额池，配额从配额池转移到成员业务方后按业务方配额扣减
+type OrgQuotaCache interface {
+	// TopUp 向配额池增加配额，返回增加后的余额
+	TopUp(ctx context.Context, orgId uint64, channel domain.Channel, quota int64) (int64, error)
+	// Pool 返回配额池余额
+	Pool(ctx context.Context, orgId uint64, channel domain.Channel) (int64, error)
+	// Transfer 从配额池向业务方转移 quota，quota 为负数时从业务方收回到配额池，
+	// limit 为业务方配额余额上限，0 表示不限
+	Transfer(ctx context.Context, orgId uint64, bizId uint64, channel domain.Channel, quota int64, limit int64) error
+	// Balances 返回业务方的配额余额
+	Balances(ctx context.Context, bizIds []uint64, channel domain.Channel) (map[uint64]int64, error)
+}
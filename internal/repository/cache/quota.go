@@ -11,6 +11,9 @@ type QuotaCache interface {
 	BatchIncr(ctx context.Context, params []QuotaParam) error
 	Decr(ctx context.Context, param QuotaParam) error
 	BatchDecr(ctx context.Context, params []QuotaParam) error
+
+	// List 列出所有业务在各渠道的剩余配额
+	List(ctx context.Context) ([]domain.Quota, error)
 }
 
 type QuotaParam struct {
@@ -0,0 +1,71 @@
+package redis
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+
+	"github.com/JrMarcco/easy-kit/slice"
+	"github.com/JrMarcco/jotify/internal/repository/cache"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	//go:embed lua/frequency_acquire.lua
+	frequencyAcquireLua string
+	//go:embed lua/frequency_release.lua
+	frequencyReleaseLua string
+)
+
+var _ cache.FrequencyCache = (*FrequencyRedisCache)(nil)
+
+type FrequencyRedisCache struct {
+	client redis.Cmdable
+}
+
+func (f *FrequencyRedisCache) Acquire(ctx context.Context, groups [][]cache.FrequencyParam) ([]bool, error) {
+	if len(groups) == 0 {
+		return nil, nil
+	}
+
+	var keys []string
+	args := make([]any, 0, 1+len(groups))
+	args = append(args, len(groups))
+	for _, group := range groups {
+		args = append(args, len(group))
+	}
+	for _, group := range groups {
+		for _, param := range group {
+			keys = append(keys, param.Key)
+			args = append(args, param.Limit, max(int64(param.TTL.Seconds()), 1))
+		}
+	}
+
+	res, err := f.client.Eval(ctx, frequencyAcquireLua, keys, args...).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+	if len(res) != len(groups) {
+		return nil, fmt.Errorf("[jotify] wrong size of redis eval result, want %d, got %d", len(groups), len(res))
+	}
+	return slice.Map(res, func(_ int, ok int64) bool {
+		return ok == 1
+	}), nil
+}
+
+func (f *FrequencyRedisCache) Release(ctx context.Context, params []cache.FrequencyParam) error {
+	if len(params) == 0 {
+		return nil
+	}
+
+	keys := slice.Map(params, func(_ int, param cache.FrequencyParam) string {
+		return param.Key
+	})
+	return f.client.Eval(ctx, frequencyReleaseLua, keys).Err()
+}
+
+func NewFrequencyRedisCache(rc redis.Cmdable) *FrequencyRedisCache {
+	return &FrequencyRedisCache{
+		client: rc,
+	}
+}
@@ -0,0 +1,64 @@
+//go:build e2e
+
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/JrMarcco/jotify/internal/repository/cache"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrequencyRedisCache_AcquireRelease(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     "192.168.3.3:6379",
+		Password: "<passwd>",
+	})
+	keys := []string{"test_freq_day", "test_freq_hour", "test_freq_other"}
+	defer func() {
+		client.Del(ctx, keys...)
+		_ = client.Close()
+	}()
+	client.Del(ctx, keys...)
+
+	fc := NewFrequencyRedisCache(client)
+	groups := [][]cache.FrequencyParam{
+		{
+			{Key: "test_freq_day", Limit: 2, TTL: 24 * time.Hour},
+			{Key: "test_freq_hour", Limit: 1, TTL: time.Hour},
+		},
+		{
+			{Key: "test_freq_other", Limit: 2, TTL: time.Hour},
+		},
+	}
+
+	oks, err := fc.Acquire(ctx, groups)
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, true}, oks)
+
+	// 小时规则达到上限，同一组的天规则不计数
+	oks, err = fc.Acquire(ctx, groups)
+	require.NoError(t, err)
+	assert.Equal(t, []bool{false, true}, oks)
+	assert.Equal(t, "1", client.Get(ctx, "test_freq_day").Val())
+	assert.Equal(t, "2", client.Get(ctx, "test_freq_other").Val())
+
+	ttl := client.TTL(ctx, "test_freq_hour").Val()
+	assert.True(t, ttl > 0 && ttl <= time.Hour)
+	ttl = client.TTL(ctx, "test_freq_day").Val()
+	assert.True(t, ttl > time.Hour && ttl <= 24*time.Hour)
+
+	// 释放后可再次计数，计数不会小于 0
+	require.NoError(t, fc.Release(ctx, append(groups[0], groups[0]...)))
+	assert.Equal(t, "0", client.Get(ctx, "test_freq_hour").Val())
+	oks, err = fc.Acquire(ctx, groups[:1])
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true}, oks)
+}
@@ -0,0 +1,84 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/JrMarcco/jotify/internal/repository/cache"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEvalClient 记录 Eval 的 keys 与 args，返回预设的结果
+type fakeEvalClient struct {
+	redis.Cmdable
+	keys []string
+	args []any
+	res  any
+}
+
+func (c *fakeEvalClient) Eval(ctx context.Context, _ string, keys []string, args ...any) *redis.Cmd {
+	c.keys, c.args = keys, args
+	return redis.NewCmdResult(c.res, nil)
+}
+
+// argv 按 lua 脚本中 1 开始的下标读取参数
+func (c *fakeEvalClient) argv(i int) any {
+	return c.args[i-1]
+}
+
+func TestFrequencyRedisCache_Acquire_Argv(t *testing.T) {
+	t.Parallel()
+
+	groups := [][]cache.FrequencyParam{
+		{
+			{Key: "k1", Limit: 2, TTL: 24 * time.Hour},
+			{Key: "k2", Limit: 1, TTL: time.Hour},
+		},
+		{
+			{Key: "k3", Limit: 3, TTL: time.Hour},
+		},
+		{
+			{Key: "k4", Limit: 4, TTL: 500 * time.Millisecond},
+			{Key: "k5", Limit: 5, TTL: time.Hour},
+		},
+	}
+	client := &fakeEvalClient{res: []any{int64(1), int64(0), int64(1)}}
+
+	oks, err := NewFrequencyRedisCache(client).Acquire(t.Context(), groups)
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, false, true}, oks)
+
+	// 与 frequency_acquire.lua 的约定一致：
+	// ARGV[1] 为分组数量 n，ARGV[g+1] 为第 g 组的 key 数量，
+	// 第 i 个 key 的上限为 ARGV[n+2*i]，过期时间为 ARGV[n+2*i+1]
+	assert.Equal(t, []string{"k1", "k2", "k3", "k4", "k5"}, client.keys)
+	n := len(groups)
+	assert.Equal(t, n, client.argv(1))
+	for g, group := range groups {
+		assert.Equal(t, len(group), client.argv(g+2))
+	}
+	i := 0
+	for _, group := range groups {
+		for _, param := range group {
+			i++
+			assert.Equal(t, param.Limit, client.argv(n+2*i), "limit of key %d", i)
+			// 过期时间至少为 1 秒
+			assert.Equal(t, max(int64(param.TTL.Seconds()), 1), client.argv(n+2*i+1), "ttl of key %d", i)
+		}
+	}
+	assert.Len(t, client.args, 1+n+2*i)
+}
+
+func TestFrequencyRedisCache_Acquire_WrongSize(t *testing.T) {
+	t.Parallel()
+
+	client := &fakeEvalClient{res: []any{int64(1)}}
+	_, err := NewFrequencyRedisCache(client).Acquire(t.Context(), [][]cache.FrequencyParam{
+		{{Key: "k1", Limit: 1, TTL: time.Hour}},
+		{{Key: "k2", Limit: 1, TTL: time.Hour}},
+	})
+	assert.Error(t, err)
+}
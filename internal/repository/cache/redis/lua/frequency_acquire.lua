@@ -0,0 +1,32 @@
+-- KEYS: 全部计数 key，按组依次排列
+-- ARGV[1]: 分组数量 n，ARGV[2..n+1]: 每组的 key 数量，随后按 KEYS 顺序依次为每个 key 的上限与过期时间（秒）
+local n = tonumber(ARGV[1])
+local res = {}
+local idx = 1
+
+for g = 1, n do
+    local size = tonumber(ARGV[g + 1])
+
+    local ok = 1
+    for i = idx, idx + size - 1 do
+        local limit = tonumber(ARGV[n + 2 * i])
+        local current = tonumber(redis.call('GET', KEYS[i]) or 0)
+        if current >= limit then
+            ok = 0
+            break
+        end
+    end
+
+    if ok == 1 then
+        for i = idx, idx + size - 1 do
+            if redis.call('INCR', KEYS[i]) == 1 then
+                redis.call('EXPIRE', KEYS[i], tonumber(ARGV[n + 2 * i + 1]))
+            end
+        end
+    end
+
+    res[g] = ok
+    idx = idx + size
+end
+
+return res
@@ -0,0 +1,8 @@
+for i = 1, #KEYS do
+    local current = tonumber(redis.call('GET', KEYS[i]) or 0)
+    if current > 0 then
+        redis.call('DECR', KEYS[i])
+    end
+end
+
+return 1
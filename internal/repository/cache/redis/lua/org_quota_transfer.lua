@@ -0,0 +1,29 @@
+-- KEYS[1] 组织配额池，KEYS[2] 业务方配额
+-- ARGV[1] 转移的配额，负数表示从业务方收回到配额池；ARGV[2] 业务方配额余额上限，0 表示不限
+local quota = tonumber(ARGV[1])
+local cap = tonumber(ARGV[2])
+local pool = tonumber(redis.call('GET', KEYS[1]) or 0)
+local balance = tonumber(redis.call('GET', KEYS[2]) or 0)
+
+-- 配额为负数时视为 0，与 quota_incr.lua 一致
+if balance < 0 then
+    balance = 0
+end
+
+if quota > 0 then
+    -- 配额池余额不足
+    if pool < quota then
+        return 1
+    end
+    -- 超出业务方配额余额上限
+    if cap > 0 and balance + quota > cap then
+        return 2
+    end
+elseif balance < -quota then
+    -- 业务方配额余额不足以收回
+    return 3
+end
+
+redis.call('DECRBY', KEYS[1], quota)
+redis.call('SET', KEYS[2], balance + quota)
+return 0
@@ -0,0 +1,97 @@
+package redis
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/errs"
+	"github.com/JrMarcco/jotify/internal/repository/cache"
+	"github.com/redis/go-redis/v9"
+)
+
+//go:embed lua/org_quota_transfer.lua
+var orgQuotaTransferLua string
+
+// orgQuotaKeyPrefix 与业务方配额使用不同的前缀，避免 QuotaRedisCache.List 把配额池当作业务方配额
+const orgQuotaKeyPrefix = "org_quota:"
+
+var _ cache.OrgQuotaCache = (*OrgQuotaRedisCache)(nil)
+
+type OrgQuotaRedisCache struct {
+	client redis.Cmdable
+}
+
+func (o *OrgQuotaRedisCache) TopUp(ctx context.Context, orgId uint64, channel domain.Channel, quota int64) (int64, error) {
+	return o.client.IncrBy(ctx, o.redisKey(orgId, channel), quota).Result()
+}
+
+func (o *OrgQuotaRedisCache) Pool(ctx context.Context, orgId uint64, channel domain.Channel) (int64, error) {
+	pool, err := o.client.Get(ctx, o.redisKey(orgId, channel)).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return pool, err
+}
+
+func (o *OrgQuotaRedisCache) Transfer(
+	ctx context.Context, orgId uint64, bizId uint64, channel domain.Channel, quota int64, limit int64,
+) error {
+	keys := []string{o.redisKey(orgId, channel), quotaKey(bizId, channel)}
+	code, err := o.client.Eval(ctx, orgQuotaTransferLua, keys, quota, limit).Int()
+	if err != nil {
+		return err
+	}
+
+	switch code {
+	case 0:
+		return nil
+	case 1:
+		return fmt.Errorf("%w: organization %d pool of %s", errs.ErrInsufficientQuota, orgId, channel)
+	case 2:
+		return fmt.Errorf("%w: biz id = %d, channel = %s, cap = %d", errs.ErrOrgQuotaCapExceeded, bizId, channel, limit)
+	default:
+		return fmt.Errorf("%w: biz %d of %s to reclaim", errs.ErrInsufficientQuota, bizId, channel)
+	}
+}
+
+func (o *OrgQuotaRedisCache) Balances(ctx context.Context, bizIds []uint64, channel domain.Channel) (map[uint64]int64, error) {
+	res := make(map[uint64]int64, len(bizIds))
+	if len(bizIds) == 0 {
+		return res, nil
+	}
+
+	keys := make([]string, 0, len(bizIds))
+	for _, bizId := range bizIds {
+		keys = append(keys, quotaKey(bizId, channel))
+	}
+	vals, err := o.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, bizId := range bizIds {
+		str, ok := vals[i].(string)
+		if !ok {
+			continue
+		}
+		// 配额为负数时视为 0
+		if balance, err := strconv.ParseInt(str, 10, 64); err == nil && balance > 0 {
+			res[bizId] = balance
+		}
+	}
+	return res, nil
+}
+
+func (o *OrgQuotaRedisCache) redisKey(orgId uint64, channel domain.Channel) string {
+	return fmt.Sprintf("%s%d:%s", orgQuotaKeyPrefix, orgId, channel)
+}
+
+func NewOrgQuotaRedisCache(rc redis.Cmdable) *OrgQuotaRedisCache {
+	return &OrgQuotaRedisCache{
+		client: rc,
+	}
+}
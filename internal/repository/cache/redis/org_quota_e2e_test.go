@@ -0,0 +1,66 @@
+//go:build e2e
+
+package redis
+
+import (
+	"testing"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/errs"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrgQuotaRedisCache_Transfer_Lua(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     "192.168.3.3:6379",
+		Password: "<passwd>",
+	})
+	const orgId, bizId = uint64(900001), uint64(900011)
+	oc := NewOrgQuotaRedisCache(client)
+	keys := []string{oc.redisKey(orgId, domain.ChannelSMS), quotaKey(bizId, domain.ChannelSMS)}
+	defer func() {
+		client.Del(ctx, keys...)
+		_ = client.Close()
+	}()
+	client.Del(ctx, keys...)
+
+	_, err := oc.TopUp(ctx, orgId, domain.ChannelSMS, 100)
+	require.NoError(t, err)
+
+	// 分配后余额超过上限
+	require.NoError(t, oc.Transfer(ctx, orgId, bizId, domain.ChannelSMS, 30, 50))
+	err = oc.Transfer(ctx, orgId, bizId, domain.ChannelSMS, 21, 50)
+	assert.ErrorIs(t, err, errs.ErrOrgQuotaCapExceeded)
+
+	// 配额池不足
+	err = oc.Transfer(ctx, orgId, bizId, domain.ChannelSMS, 71, 0)
+	assert.ErrorIs(t, err, errs.ErrInsufficientQuota)
+
+	// 收回超过余额
+	err = oc.Transfer(ctx, orgId, bizId, domain.ChannelSMS, -31, 0)
+	assert.ErrorIs(t, err, errs.ErrInsufficientQuota)
+
+	pool, err := oc.Pool(ctx, orgId, domain.ChannelSMS)
+	require.NoError(t, err)
+	assert.Equal(t, int64(70), pool)
+	balances, err := oc.Balances(ctx, []uint64{bizId}, domain.ChannelSMS)
+	require.NoError(t, err)
+	assert.Equal(t, map[uint64]int64{bizId: 30}, balances)
+
+	// 业务方余额为负数时按 0 计算，分配后覆盖为分配的配额
+	require.NoError(t, client.Set(ctx, keys[1], -5, 0).Err())
+	require.NoError(t, oc.Transfer(ctx, orgId, bizId, domain.ChannelSMS, 10, 50))
+	assert.Equal(t, "10", client.Get(ctx, keys[1]).Val())
+
+	// 全部收回
+	require.NoError(t, oc.Transfer(ctx, orgId, bizId, domain.ChannelSMS, -10, 0))
+	pool, err = oc.Pool(ctx, orgId, domain.ChannelSMS)
+	require.NoError(t, err)
+	assert.Equal(t, int64(70), pool)
+}
@@ -0,0 +1,86 @@
+package redis
+
+import (
+	"context"
+	"testing"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/errs"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMGetClient MGet 返回预设的值
+type fakeMGetClient struct {
+	redis.Cmdable
+	vals []any
+}
+
+func (c *fakeMGetClient) MGet(ctx context.Context, keys ...string) *redis.SliceCmd {
+	cmd := redis.NewSliceCmd(ctx)
+	cmd.SetVal(c.vals)
+	return cmd
+}
+
+func TestOrgQuotaRedisCache_Transfer(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name    string
+		quota   int64
+		cap     int64
+		code    int64
+		wantErr error
+	}{
+		{
+			name:  "allocate",
+			quota: 10,
+			cap:   50,
+			code:  0,
+		}, {
+			name:    "pool insufficient",
+			quota:   10,
+			code:    1,
+			wantErr: errs.ErrInsufficientQuota,
+		}, {
+			name:    "over cap",
+			quota:   10,
+			cap:     5,
+			code:    2,
+			wantErr: errs.ErrOrgQuotaCapExceeded,
+		}, {
+			name:    "reclaim over balance",
+			quota:   -10,
+			code:    3,
+			wantErr: errs.ErrInsufficientQuota,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			client := &fakeEvalClient{res: tc.code}
+			err := NewOrgQuotaRedisCache(client).Transfer(t.Context(), 1, 11, domain.ChannelSMS, tc.quota, tc.cap)
+			assert.ErrorIs(t, err, tc.wantErr)
+			if tc.wantErr == nil {
+				assert.NoError(t, err)
+			}
+
+			// 与 org_quota_transfer.lua 的约定一致：KEYS[1] 配额池，KEYS[2] 业务方配额，ARGV[1] 转移的配额，ARGV[2] 上限
+			assert.Equal(t, []string{"org_quota:1:sms", quotaKey(11, domain.ChannelSMS)}, client.keys)
+			assert.Equal(t, []any{tc.quota, tc.cap}, client.args)
+		})
+	}
+}
+
+func TestOrgQuotaRedisCache_Balances(t *testing.T) {
+	t.Parallel()
+
+	client := &fakeMGetClient{vals: []any{"30", "-5", nil, "0"}}
+	balances, err := NewOrgQuotaRedisCache(client).Balances(t.Context(), []uint64{11, 12, 13, 14}, domain.ChannelSMS)
+	require.NoError(t, err)
+	// 负数与不存在的余额视为 0
+	assert.Equal(t, map[uint64]int64{11: 30}, balances)
+}
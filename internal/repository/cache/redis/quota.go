@@ -4,6 +4,8 @@ import (
 	"context"
 	_ "embed"
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/JrMarcco/jotify/internal/domain"
 	"github.com/JrMarcco/jotify/internal/errs"
@@ -21,6 +23,8 @@ var (
 	quotaBatchDecrLua string
 )
 
+const quotaKeyPrefix = "quota:"
+
 var _ cache.QuotaCache = (*QuotaRedisCache)(nil)
 
 type QuotaRedisCache struct {
@@ -79,8 +83,73 @@ func (q *QuotaRedisCache) BatchDecr(ctx context.Context, params []cache.QuotaPar
 	return fmt.Errorf("[jotify] the quota of %s is not enough", resMsg)
 }
 
+func (q *QuotaRedisCache) List(ctx context.Context) ([]domain.Quota, error) {
+	const scanCount = 256
+
+	var keys []string
+	iter := q.client.Scan(ctx, 0, quotaKeyPrefix+"*", scanCount).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(keys) == 0 {
+		return []domain.Quota{}, nil
+	}
+
+	vals, err := q.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]domain.Quota, 0, len(keys))
+	for i, key := range keys {
+		bizId, channel, ok := q.parseRedisKey(key)
+		if !ok {
+			continue
+		}
+
+		str, ok := vals[i].(string)
+		if !ok {
+			// key 在 scan 之后被删除
+			continue
+		}
+		quota, err := strconv.ParseInt(str, 10, 32)
+		if err != nil {
+			continue
+		}
+
+		res = append(res, domain.Quota{
+			BizId:   bizId,
+			Quota:   int32(quota),
+			Channel: channel,
+		})
+	}
+	return res, nil
+}
+
 func (q *QuotaRedisCache) redisKey(bizId uint64, channel domain.Channel) string {
-	return fmt.Sprintf("quota:%d:%s", bizId, channel)
+	return quotaKey(bizId, channel)
+}
+
+func quotaKey(bizId uint64, channel domain.Channel) string {
+	return fmt.Sprintf("%s%d:%s", quotaKeyPrefix, bizId, channel)
+}
+
+// parseRedisKey 从 redis key 中解析业务 id 和渠道
+func (q *QuotaRedisCache) parseRedisKey(key string) (uint64, domain.Channel, bool) {
+	splits := strings.SplitN(strings.TrimPrefix(key, quotaKeyPrefix), ":", 2)
+	if len(splits) != 2 {
+		return 0, "", false
+	}
+
+	bizId, err := strconv.ParseUint(splits[0], 10, 64)
+	if err != nil {
+		return 0, "", false
+	}
+	return bizId, domain.Channel(splits[1]), true
 }
 
 func (q *QuotaRedisCache) redisKeysAndArgs(params []cache.QuotaParam) ([]string, []any) {
@@ -15,6 +15,9 @@ type CallbackLogRepo interface {
 	FindByNotificationIds(ctx context.Context, notificationIds []uint64) ([]domain.CallbackLog, error)
 
 	Update(ctx context.Context, logs []domain.CallbackLog) error
+
+	// CountPending 统计待发送（含待重试）的回调数量
+	CountPending(ctx context.Context) (int64, error)
 }
 
 var _ CallbackLogRepo = (*DefaultCallbackLogRepo)(nil)
@@ -62,6 +65,10 @@ func (d *DefaultCallbackLogRepo) Update(ctx context.Context, logs []domain.Callb
 	}))
 }
 
+func (d *DefaultCallbackLogRepo) CountPending(ctx context.Context) (int64, error) {
+	return d.callbackLogDAO.CountPending(ctx)
+}
+
 func (d *DefaultCallbackLogRepo) toDomain(cl dao.CallbackLog, notification dao.Notification) domain.CallbackLog {
 	return domain.CallbackLog{
 		Notification: d.toDomainNotif(notification),
@@ -78,6 +85,11 @@ func (d *DefaultCallbackLogRepo) toDomainNotif(entity dao.Notification) domain.N
 	var receivers []string
 	_ = json.Unmarshal([]byte(entity.Receivers), &receivers)
 
+	var traceCtx map[string]string
+	if entity.TraceCtx != "" {
+		_ = json.Unmarshal([]byte(entity.TraceCtx), &traceCtx)
+	}
+
 	return domain.Notification{
 		Id:        entity.Id,
 		BizId:     entity.BizId,
@@ -90,9 +102,11 @@ func (d *DefaultCallbackLogRepo) toDomainNotif(entity dao.Notification) domain.N
 			Params:    tplParams,
 		},
 		Status:         domain.SendStatus(entity.Status),
+		Priority:       domain.Priority(entity.Priority),
 		ScheduledStart: time.UnixMilli(entity.ScheduleStrat),
 		ScheduledEnd:   time.UnixMilli(entity.ScheduleEnd),
 		Version:        entity.Version,
+		TraceCtx:       traceCtx,
 	}
 }
 
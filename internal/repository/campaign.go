@@ -0,0 +1,233 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/pkg/envelope"
+	"github.com/JrMarcco/jotify/internal/repository/dao"
+)
+
+type CampaignRepo interface {
+	Create(ctx context.Context, c domain.Campaign) (domain.Campaign, error)
+	GetByKey(ctx context.Context, bizId uint64, bizKey string) (domain.Campaign, error)
+	List(ctx context.Context, bizId uint64, offset int, limit int) ([]domain.Campaign, error)
+	// FindActive 按 id 升序查询 id 大于 startId 的 running 与 canceling 的活动
+	FindActive(ctx context.Context, startId uint64, limit int) ([]domain.Campaign, error)
+	// CompareAndSwap 按版本号更新状态与进度，c.Version 为读取活动时的版本号
+	CompareAndSwap(ctx context.Context, c domain.Campaign) error
+
+	// AppendReceivers 为 draft 状态的活动追加接收者，返回追加后的接收者总数
+	AppendReceivers(ctx context.Context, bizId uint64, bizKey string, rs []domain.CampaignReceiver) (int64, error)
+	// FindReceivers 按序号升序查询序号大于 afterSeq 的接收者
+	FindReceivers(ctx context.Context, c domain.Campaign, afterSeq int64, limit int) ([]domain.CampaignReceiver, error)
+	// DeleteReceivers 删除最多 limit 个接收者，返回删除的数量
+	DeleteReceivers(ctx context.Context, campaignId uint64, limit int) (int64, error)
+}
+
+var _ CampaignRepo = (*DefaultCampaignRepo)(nil)
+
+// DefaultCampaignRepo 群发活动仓储，与消息相同，接收者与模板参数使用业务方的数据密钥加密保存
+type DefaultCampaignRepo struct {
+	campaignDAO dao.CampaignDAO
+	cipher      *envelope.Cipher
+	encrypt     bool
+}
+
+func (d *DefaultCampaignRepo) Create(ctx context.Context, c domain.Campaign) (domain.Campaign, error) {
+	entity, err := d.toEntity(ctx, c)
+	if err != nil {
+		return domain.Campaign{}, err
+	}
+	if entity, err = d.campaignDAO.Create(ctx, entity); err != nil {
+		return domain.Campaign{}, err
+	}
+	return d.toDomain(ctx, entity)
+}
+
+func (d *DefaultCampaignRepo) GetByKey(ctx context.Context, bizId uint64, bizKey string) (domain.Campaign, error) {
+	entity, err := d.campaignDAO.GetByKey(ctx, bizId, bizKey)
+	if err != nil {
+		return domain.Campaign{}, err
+	}
+	return d.toDomain(ctx, entity)
+}
+
+func (d *DefaultCampaignRepo) List(ctx context.Context, bizId uint64, offset int, limit int) ([]domain.Campaign, error) {
+	entities, err := d.campaignDAO.List(ctx, bizId, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return d.toDomains(ctx, entities)
+}
+
+func (d *DefaultCampaignRepo) FindActive(ctx context.Context, startId uint64, limit int) ([]domain.Campaign, error) {
+	entities, err := d.campaignDAO.FindActive(ctx, startId, limit)
+	if err != nil {
+		return nil, err
+	}
+	return d.toDomains(ctx, entities)
+}
+
+func (d *DefaultCampaignRepo) CompareAndSwap(ctx context.Context, c domain.Campaign) error {
+	return d.campaignDAO.CompareAndSwap(ctx, dao.Campaign{
+		Id:         c.Id,
+		Status:     c.Status.String(),
+		Expanded:   c.Progress.Expanded,
+		Accepted:   c.Progress.Accepted,
+		Suppressed: c.Progress.Suppressed,
+		Skipped:    c.Progress.Skipped,
+		Sent:       c.Progress.Sent,
+		Failed:     c.Progress.Failed,
+		Canceled:   c.Progress.Canceled,
+		Version:    c.Version,
+	})
+}
+
+func (d *DefaultCampaignRepo) AppendReceivers(
+	ctx context.Context, bizId uint64, bizKey string, rs []domain.CampaignReceiver,
+) (int64, error) {
+	entities := make([]dao.CampaignReceiver, 0, len(rs))
+	for _, r := range rs {
+		params, err := json.Marshal(r.Params)
+		if err != nil {
+			return 0, err
+		}
+
+		entity := dao.CampaignReceiver{Receiver: r.Receiver, Params: string(params)}
+		if d.encrypt {
+			if entity.Receiver, err = d.cipher.Encrypt(ctx, bizId, entity.Receiver); err != nil {
+				return 0, err
+			}
+			if entity.Params, err = d.cipher.Encrypt(ctx, bizId, entity.Params); err != nil {
+				return 0, err
+			}
+		}
+		entities = append(entities, entity)
+	}
+	return d.campaignDAO.AppendReceivers(ctx, bizId, bizKey, entities)
+}
+
+func (d *DefaultCampaignRepo) FindReceivers(
+	ctx context.Context, c domain.Campaign, afterSeq int64, limit int,
+) ([]domain.CampaignReceiver, error) {
+	entities, err := d.campaignDAO.FindReceivers(ctx, c.Id, afterSeq, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]domain.CampaignReceiver, 0, len(entities))
+	for _, entity := range entities {
+		receiver, err := d.cipher.Decrypt(ctx, c.BizId, entity.Receiver)
+		if err != nil {
+			return nil, err
+		}
+		paramsVal, err := d.cipher.Decrypt(ctx, c.BizId, entity.Params)
+		if err != nil {
+			return nil, err
+		}
+
+		var params map[string]string
+		_ = json.Unmarshal([]byte(paramsVal), &params)
+		res = append(res, domain.CampaignReceiver{
+			Seq:      entity.Seq,
+			Receiver: receiver,
+			Params:   params,
+		})
+	}
+	return res, nil
+}
+
+func (d *DefaultCampaignRepo) DeleteReceivers(ctx context.Context, campaignId uint64, limit int) (int64, error) {
+	return d.campaignDAO.DeleteReceivers(ctx, campaignId, limit)
+}
+
+func (d *DefaultCampaignRepo) toEntity(ctx context.Context, c domain.Campaign) (dao.Campaign, error) {
+	tplParams, err := json.Marshal(c.Template.Params)
+	if err != nil {
+		return dao.Campaign{}, err
+	}
+	strategyConf, err := json.Marshal(c.StrategyConfig)
+	if err != nil {
+		return dao.Campaign{}, err
+	}
+
+	entity := dao.Campaign{
+		BizId:        c.BizId,
+		BizKey:       c.BizKey,
+		Channel:      c.Channel.String(),
+		TplId:        c.Template.Id,
+		TplParams:    string(tplParams),
+		Priority:     int8(c.Priority),
+		StrategyConf: string(strategyConf),
+		Status:       c.Status.String(),
+	}
+	if !d.encrypt {
+		return entity, nil
+	}
+
+	if entity.TplParams, err = d.cipher.Encrypt(ctx, c.BizId, entity.TplParams); err != nil {
+		return dao.Campaign{}, err
+	}
+	return entity, nil
+}
+
+func (d *DefaultCampaignRepo) toDomains(ctx context.Context, entities []dao.Campaign) ([]domain.Campaign, error) {
+	res := make([]domain.Campaign, 0, len(entities))
+	for _, entity := range entities {
+		c, err := d.toDomain(ctx, entity)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, c)
+	}
+	return res, nil
+}
+
+func (d *DefaultCampaignRepo) toDomain(ctx context.Context, entity dao.Campaign) (domain.Campaign, error) {
+	paramsVal, err := d.cipher.Decrypt(ctx, entity.BizId, entity.TplParams)
+	if err != nil {
+		return domain.Campaign{}, err
+	}
+
+	var tplParams map[string]string
+	_ = json.Unmarshal([]byte(paramsVal), &tplParams)
+	var strategyConf domain.SendStrategyConf
+	_ = json.Unmarshal([]byte(entity.StrategyConf), &strategyConf)
+
+	return domain.Campaign{
+		Id:      entity.Id,
+		BizId:   entity.BizId,
+		BizKey:  entity.BizKey,
+		Channel: domain.Channel(entity.Channel),
+		Template: domain.Template{
+			Id:     entity.TplId,
+			Params: tplParams,
+		},
+		Priority:       domain.Priority(entity.Priority),
+		StrategyConfig: strategyConf,
+		Status:         domain.CampaignStatus(entity.Status),
+		Progress: domain.CampaignProgress{
+			Total:      entity.Total,
+			Expanded:   entity.Expanded,
+			Accepted:   entity.Accepted,
+			Suppressed: entity.Suppressed,
+			Skipped:    entity.Skipped,
+			Sent:       entity.Sent,
+			Failed:     entity.Failed,
+			Canceled:   entity.Canceled,
+		},
+		Version:   entity.Version,
+		CreatedAt: entity.CreatedAt,
+		UpdatedAt: entity.UpdatedAt,
+	}, nil
+}
+
+func NewDefaultCampaignRepo(campaignDAO dao.CampaignDAO, cipher *envelope.Cipher, encrypt bool) *DefaultCampaignRepo {
+	return &DefaultCampaignRepo{
+		campaignDAO: campaignDAO,
+		cipher:      cipher,
+		encrypt:     encrypt,
+	}
+}
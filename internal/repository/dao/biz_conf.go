@@ -9,16 +9,20 @@ import (
 )
 
 type BizConf struct {
-	Id           uint64
-	OwnerId      uint64
-	OwnerType    string
-	ChannelConf  xsql.JsonColumn[domain.ChannelConf]
-	TxNotifConf  xsql.JsonColumn[domain.TxNotifConf]
-	RateLimit    int32
-	QuotaConf    xsql.JsonColumn[domain.QuotaConf]
-	CallbackConf xsql.JsonColumn[domain.CallbackConf]
-	CreatedAt    int64
-	UpdatedAt    int64
+	Id               uint64
+	OwnerId          uint64
+	OwnerType        string
+	ChannelConf      xsql.JsonColumn[domain.ChannelConf]
+	TxNotifConf      xsql.JsonColumn[domain.TxNotifConf]
+	RateLimit        int32
+	QuotaConf        xsql.JsonColumn[domain.QuotaConf]
+	CallbackConf     xsql.JsonColumn[domain.CallbackConf]
+	RetentionDays    int32
+	DeliveryConf     xsql.JsonColumn[domain.DeliveryConf]
+	SchedulingWeight int32
+	ContentConf      xsql.JsonColumn[domain.ContentConf]
+	CreatedAt        int64
+	UpdatedAt        int64
 }
 
 func (bc BizConf) TableName() string {
@@ -1,10 +1,20 @@
 package dao
 
 import (
+	"cmp"
 	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/JrMarcco/easy-kit/slice"
+	"github.com/JrMarcco/easy-kit/xsync"
 	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/pkg/sharding"
+	"golang.org/x/sync/errgroup"
 	"gorm.io/gorm"
 )
 
@@ -21,69 +31,216 @@ type CallbackLogDAO interface {
 	Find(ctx context.Context, startTime int64, startId uint64, batchSize int) ([]CallbackLog, uint64, error)
 	Update(ctx context.Context, logs []CallbackLog) error
 	FindByNotificationIds(ctx context.Context, notificationIds []uint64) ([]CallbackLog, error)
+
+	CountPending(ctx context.Context) (int64, error)
 }
 
 var _ CallbackLogDAO = (*DefaultCallbackLogDAO)(nil)
 
+// DefaultCallbackLogDAO 回调记录与消息位于同一分片，按 callback_log 分库分表策略路由
 type DefaultCallbackLogDAO struct {
-	db *gorm.DB
+	dbs *xsync.Map[string, *gorm.DB]
+
+	cbLogShardingStrategy sharding.Strategy
 }
 
+// Find 在所有 callback_log 分表中按消息 id 顺序查找到期待发送的回调，各分表的结果合并后取前 batchSize 条，
+// 以最后一条的消息 id 作为下一批的起点。迁移期间同一条记录可能同时位于新旧布局中，只保留一条。
 func (d *DefaultCallbackLogDAO) Find(ctx context.Context, startTime int64, startId uint64, batchSize int) ([]CallbackLog, uint64, error) {
-	var logs []CallbackLog
-	var nextStartId uint64
+	mu := new(sync.Mutex)
+	logMap := make(map[uint64]CallbackLog)
+
+	var eg errgroup.Group
+	for _, dst := range d.cbLogShardingStrategy.BroadCast() {
+		eg.Go(func() error {
+			db, ok := d.dbs.Load(dst.DB)
+			if !ok {
+				return fmt.Errorf("failed to load db: %s", dst.DB)
+			}
 
-	res := d.db.WithContext(ctx).Model(&CallbackLog{}).
-		Where("next_retry_at <= ?", startTime).
-		Where("status = ?", domain.CallbackStatusPending).
-		Where("id > ?", startId).
-		Order("id ASC").
-		Limit(batchSize).
-		Find(&logs)
+			var logs []CallbackLog
+			err := db.WithContext(ctx).Table(dst.Table).
+				Where("next_retry_at <= ?", startTime).
+				Where("status = ?", domain.CallbackStatusPending).
+				Where("notification_id > ?", startId).
+				Order("notification_id ASC").
+				Limit(batchSize).
+				Find(&logs).Error
+			if err != nil {
+				return err
+			}
 
-	if res.Error != nil {
-		return nil, nextStartId, res.Error
+			mu.Lock()
+			for _, log := range logs {
+				logMap[log.NotificationId] = log
+			}
+			mu.Unlock()
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, 0, err
 	}
 
+	logs := slices.SortedFunc(maps.Values(logMap), func(a, b CallbackLog) int {
+		return cmp.Compare(a.NotificationId, b.NotificationId)
+	})
+	if len(logs) > batchSize {
+		logs = logs[:batchSize]
+	}
+
+	var nextStartId uint64
 	if len(logs) > 0 {
 		nextStartId = logs[len(logs)-1].NotificationId
 	}
-
 	return logs, nextStartId, nil
 }
 
+// Update 按消息 id 所在的分片分组更新，迁移期间同时更新旧布局中的记录，未回填的记录由回填迁移到新布局
 func (d *DefaultCallbackLogDAO) Update(ctx context.Context, logs []CallbackLog) error {
 	if len(logs) == 0 {
 		return nil
 	}
 
-	updateAt := time.Now().UnixMilli()
-	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		for _, log := range logs {
-			res := tx.Model(&CallbackLog{NotificationId: log.NotificationId}).
-				Updates(map[string]any{
-					"retried_times": log.RetriedTimes,
-					"next_retry_at": log.NextRetryAt,
-					"status":        log.Status,
-					"updated_at":    updateAt,
-				})
-
-			if res.Error != nil {
-				return res.Error
-			}
+	logMap := make(map[sharding.Dst][]CallbackLog, len(logs))
+	for _, log := range logs {
+		dst := d.cbLogShardingStrategy.ShardWithId(log.NotificationId)
+		logMap[dst] = append(logMap[dst], log)
+
+		if prevDst, ok := previousShardWithId(d.cbLogShardingStrategy, log.NotificationId); ok {
+			logMap[prevDst] = append(logMap[prevDst], log)
 		}
-		return nil
-	})
+	}
+
+	updatedAt := time.Now().UnixMilli()
+
+	var eg errgroup.Group
+	for dst, val := range logMap {
+		eg.Go(func() error {
+			db, ok := d.dbs.Load(dst.DB)
+			if !ok {
+				return fmt.Errorf("failed to load db: %s", dst.DB)
+			}
+
+			return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+				for _, log := range val {
+					err := tx.Table(dst.Table).Where("notification_id = ?", log.NotificationId).
+						Updates(map[string]any{
+							"retried_times": log.RetriedTimes,
+							"next_retry_at": log.NextRetryAt,
+							"status":        log.Status,
+							"updated_at":    updatedAt,
+						}).Error
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		})
+	}
+	return eg.Wait()
 }
 
+// FindByNotificationIds 按消息 id 所在的 callback_log 分片查找，迁移期间新布局中未命中的 id 继续在旧布局中查找
 func (d *DefaultCallbackLogDAO) FindByNotificationIds(ctx context.Context, notificationIds []uint64) ([]CallbackLog, error) {
-	var logs []CallbackLog
-	err := d.db.WithContext(ctx).Model(&CallbackLog{}).Where("notification_id IN (?)", notificationIds).Find(&logs).Error
-	return logs, err
+	current := func(id uint64) (sharding.Dst, bool) {
+		return d.cbLogShardingStrategy.ShardWithId(id), true
+	}
+	previous := func(id uint64) (sharding.Dst, bool) {
+		return previousShardWithId(d.cbLogShardingStrategy, id)
+	}
+
+	logMap := make(map[uint64]CallbackLog, len(notificationIds))
+	missing := notificationIds
+	for _, dstFn := range []func(id uint64) (sharding.Dst, bool){current, previous} {
+		if err := d.findByNotificationIds(ctx, missing, dstFn, logMap); err != nil {
+			return nil, err
+		}
+
+		missing = slice.FilterMap(missing, func(_ int, id uint64) (uint64, bool) {
+			_, ok := logMap[id]
+			return id, !ok
+		})
+		if len(missing) == 0 {
+			break
+		}
+	}
+	return slices.Collect(maps.Values(logMap)), nil
+}
+
+// findByNotificationIds 按 dstFn 计算的分片分组查询，结果写入 logMap，dstFn 返回 false 的 id 跳过
+func (d *DefaultCallbackLogDAO) findByNotificationIds(
+	ctx context.Context, ids []uint64, dstFn func(id uint64) (sharding.Dst, bool), logMap map[uint64]CallbackLog,
+) error {
+	idMap := make(map[sharding.Dst][]uint64, len(ids))
+	for _, id := range ids {
+		if dst, ok := dstFn(id); ok {
+			idMap[dst] = append(idMap[dst], id)
+		}
+	}
+
+	mu := new(sync.Mutex)
+
+	var eg errgroup.Group
+	for dst, val := range idMap {
+		eg.Go(func() error {
+			db, ok := d.dbs.Load(dst.DB)
+			if !ok {
+				return fmt.Errorf("failed to load db: %s", dst.DB)
+			}
+
+			var logs []CallbackLog
+			if err := db.WithContext(ctx).Table(dst.Table).Where("notification_id IN (?)", val).Find(&logs).Error; err != nil {
+				return err
+			}
+
+			mu.Lock()
+			for _, log := range logs {
+				logMap[log.NotificationId] = log
+			}
+			mu.Unlock()
+			return nil
+		})
+	}
+	return eg.Wait()
+}
+
+// CountPending 统计所有 callback_log 分表中待发送（含待重试）的回调数量
+func (d *DefaultCallbackLogDAO) CountPending(ctx context.Context) (int64, error) {
+	var total atomic.Int64
+
+	var eg errgroup.Group
+	for _, dst := range d.cbLogShardingStrategy.BroadCast() {
+		eg.Go(func() error {
+			db, ok := d.dbs.Load(dst.DB)
+			if !ok {
+				return fmt.Errorf("failed to load db: %s", dst.DB)
+			}
+
+			var cnt int64
+			err := db.WithContext(ctx).Table(dst.Table).
+				Where("status = ?", domain.CallbackStatusPending).
+				Count(&cnt).Error
+			if err != nil {
+				return err
+			}
+			total.Add(cnt)
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return 0, err
+	}
+	return total.Load(), nil
 }
 
-func NewDefaultCallbackLogDAO(db *gorm.DB) *DefaultCallbackLogDAO {
+func NewDefaultCallbackLogDAO(
+	dbs *xsync.Map[string, *gorm.DB],
+	cbLogShardingStrategy sharding.Strategy,
+) *DefaultCallbackLogDAO {
 	return &DefaultCallbackLogDAO{
-		db: db,
+		dbs:                   dbs,
+		cbLogShardingStrategy: cbLogShardingStrategy,
 	}
 }
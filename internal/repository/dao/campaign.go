@@ -0,0 +1,210 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/errs"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Campaign 群发活动实体
+type Campaign struct {
+	Id           uint64
+	BizId        uint64
+	BizKey       string
+	Channel      string
+	TplId        uint64
+	TplParams    string
+	Priority     int8
+	StrategyConf string
+	Status       string
+	Total        int64
+	Expanded     int64
+	Accepted     int64
+	Suppressed   int64
+	Skipped      int64
+	Sent         int64
+	Failed       int64
+	Canceled     int64
+	Version      int32
+	CreatedAt    int64
+	UpdatedAt    int64
+}
+
+func (c Campaign) TableName() string {
+	return "campaign"
+}
+
+// CampaignReceiver 群发活动接收者实体
+type CampaignReceiver struct {
+	CampaignId uint64
+	Seq        int64
+	Receiver   string
+	Params     string
+	CreatedAt  int64
+}
+
+func (r CampaignReceiver) TableName() string {
+	return "campaign_receiver"
+}
+
+type CampaignDAO interface {
+	// Create 业务方已存在相同 biz_key 的活动时返回 errs.ErrDuplicateCampaign
+	Create(ctx context.Context, c Campaign) (Campaign, error)
+	GetByKey(ctx context.Context, bizId uint64, bizKey string) (Campaign, error)
+	// List 按 id 降序分页查询业务方的活动
+	List(ctx context.Context, bizId uint64, offset int, limit int) ([]Campaign, error)
+	// FindActive 按 id 升序查询 id 大于 startId 的 running 与 canceling 的活动
+	FindActive(ctx context.Context, startId uint64, limit int) ([]Campaign, error)
+	// CompareAndSwap 按版本号更新状态与进度
+	CompareAndSwap(ctx context.Context, c Campaign) error
+
+	// AppendReceivers 为 draft 状态的活动追加接收者，从已上传的接收者数开始连续编号，返回追加后的接收者总数
+	AppendReceivers(ctx context.Context, bizId uint64, bizKey string, rs []CampaignReceiver) (int64, error)
+	// FindReceivers 按序号升序查询序号大于 afterSeq 的接收者
+	FindReceivers(ctx context.Context, campaignId uint64, afterSeq int64, limit int) ([]CampaignReceiver, error)
+	// DeleteReceivers 删除最多 limit 个接收者，返回删除的数量
+	DeleteReceivers(ctx context.Context, campaignId uint64, limit int) (int64, error)
+}
+
+var _ CampaignDAO = (*DefaultCampaignDAO)(nil)
+
+type DefaultCampaignDAO struct {
+	db *gorm.DB
+}
+
+func (d *DefaultCampaignDAO) Create(ctx context.Context, c Campaign) (Campaign, error) {
+	now := time.Now().UnixMilli()
+	c.Version, c.CreatedAt, c.UpdatedAt = 1, now, now
+
+	res := d.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&c)
+	if res.Error != nil {
+		return Campaign{}, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return Campaign{}, fmt.Errorf("%w: bizId = %d, bizKey = %s", errs.ErrDuplicateCampaign, c.BizId, c.BizKey)
+	}
+	return c, nil
+}
+
+func (d *DefaultCampaignDAO) GetByKey(ctx context.Context, bizId uint64, bizKey string) (Campaign, error) {
+	var c Campaign
+	err := d.db.WithContext(ctx).Where("biz_id = ? AND biz_key = ?", bizId, bizKey).First(&c).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return Campaign{}, fmt.Errorf("%w: bizId = %d, bizKey = %s", errs.ErrCampaignNotFound, bizId, bizKey)
+	}
+	return c, err
+}
+
+func (d *DefaultCampaignDAO) List(ctx context.Context, bizId uint64, offset int, limit int) ([]Campaign, error) {
+	var cs []Campaign
+	err := d.db.WithContext(ctx).
+		Where("biz_id = ?", bizId).
+		Order("id DESC").Offset(offset).Limit(limit).
+		Find(&cs).Error
+	return cs, err
+}
+
+func (d *DefaultCampaignDAO) FindActive(ctx context.Context, startId uint64, limit int) ([]Campaign, error) {
+	var cs []Campaign
+	err := d.db.WithContext(ctx).
+		Where("status IN ? AND id > ?", []domain.CampaignStatus{domain.CampaignStatusRunning, domain.CampaignStatusCanceling}, startId).
+		Order("id").Limit(limit).
+		Find(&cs).Error
+	return cs, err
+}
+
+func (d *DefaultCampaignDAO) CompareAndSwap(ctx context.Context, c Campaign) error {
+	res := d.db.WithContext(ctx).Model(&Campaign{}).
+		Where("id = ? AND version = ?", c.Id, c.Version).
+		Updates(map[string]any{
+			"status":     c.Status,
+			"expanded":   c.Expanded,
+			"accepted":   c.Accepted,
+			"suppressed": c.Suppressed,
+			"skipped":    c.Skipped,
+			"sent":       c.Sent,
+			"failed":     c.Failed,
+			"canceled":   c.Canceled,
+			"version":    gorm.Expr("`version` + 1"),
+			"updated_at": time.Now().UnixMilli(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("%w: campaign id = %d, version = %d", errs.ErrCampaignVersionConflict, c.Id, c.Version)
+	}
+	return nil
+}
+
+// AppendReceivers 在事务中锁定活动记录，保证并发上传时序号连续且不重复
+func (d *DefaultCampaignDAO) AppendReceivers(
+	ctx context.Context, bizId uint64, bizKey string, rs []CampaignReceiver,
+) (int64, error) {
+	const batchSize = 500
+
+	var total int64
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var c Campaign
+		err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+			Where("biz_id = ? AND biz_key = ?", bizId, bizKey).
+			First(&c).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: bizId = %d, bizKey = %s", errs.ErrCampaignNotFound, bizId, bizKey)
+		}
+		if err != nil {
+			return err
+		}
+		if c.Status != domain.CampaignStatusDraft.String() {
+			return fmt.Errorf("%w: receivers can only be uploaded to draft campaign, status = %s", errs.ErrCampaignConflict, c.Status)
+		}
+
+		now := time.Now().UnixMilli()
+		for i := range rs {
+			rs[i].CampaignId = c.Id
+			rs[i].Seq = c.Total + int64(i) + 1
+			rs[i].CreatedAt = now
+		}
+		if err = tx.CreateInBatches(rs, batchSize).Error; err != nil {
+			return err
+		}
+
+		total = c.Total + int64(len(rs))
+		return tx.Model(&Campaign{}).Where("id = ?", c.Id).Updates(map[string]any{
+			"total":      total,
+			"version":    gorm.Expr("`version` + 1"),
+			"updated_at": now,
+		}).Error
+	})
+	return total, err
+}
+
+func (d *DefaultCampaignDAO) FindReceivers(
+	ctx context.Context, campaignId uint64, afterSeq int64, limit int,
+) ([]CampaignReceiver, error) {
+	var rs []CampaignReceiver
+	err := d.db.WithContext(ctx).
+		Where("campaign_id = ? AND seq > ?", campaignId, afterSeq).
+		Order("seq").Limit(limit).
+		Find(&rs).Error
+	return rs, err
+}
+
+func (d *DefaultCampaignDAO) DeleteReceivers(ctx context.Context, campaignId uint64, limit int) (int64, error) {
+	res := d.db.WithContext(ctx).Exec(
+		"DELETE FROM `campaign_receiver` WHERE `campaign_id` = ? ORDER BY `seq` LIMIT ?", campaignId, limit,
+	)
+	return res.RowsAffected, res.Error
+}
+
+func NewDefaultCampaignDAO(db *gorm.DB) *DefaultCampaignDAO {
+	return &DefaultCampaignDAO{
+		db: db,
+	}
+}
@@ -0,0 +1,97 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BizDataKey 业务方数据密钥
+type BizDataKey struct {
+	BizId       uint64
+	Version     uint32
+	MasterKeyId string
+	WrappedKey  []byte
+	CreatedAt   int64
+	UpdatedAt   int64
+}
+
+func (k BizDataKey) TableName() string {
+	return "biz_data_key"
+}
+
+type DataKeyDAO interface {
+	Create(ctx context.Context, k BizDataKey) error
+	GetLatest(ctx context.Context, bizId uint64) (BizDataKey, error)
+	Get(ctx context.Context, bizId uint64, version uint32) (BizDataKey, error)
+	FindNotWrappedBy(ctx context.Context, masterKeyId string, limit int) ([]BizDataKey, error)
+	CompareAndSwapWrappedKey(ctx context.Context, k BizDataKey, prevMasterKeyId string) error
+}
+
+var _ DataKeyDAO = (*DefaultDataKeyDAO)(nil)
+
+type DefaultDataKeyDAO struct {
+	db *gorm.DB
+}
+
+// Create 版本已存在时返回 gorm.ErrDuplicatedKey
+func (d *DefaultDataKeyDAO) Create(ctx context.Context, k BizDataKey) error {
+	now := time.Now().UnixMilli()
+	k.CreatedAt, k.UpdatedAt = now, now
+
+	res := d.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&k)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrDuplicatedKey
+	}
+	return nil
+}
+
+func (d *DefaultDataKeyDAO) GetLatest(ctx context.Context, bizId uint64) (BizDataKey, error) {
+	var k BizDataKey
+	err := d.db.WithContext(ctx).
+		Where("biz_id = ?", bizId).
+		Order("version DESC").
+		First(&k).Error
+	return k, err
+}
+
+func (d *DefaultDataKeyDAO) Get(ctx context.Context, bizId uint64, version uint32) (BizDataKey, error) {
+	var k BizDataKey
+	err := d.db.WithContext(ctx).
+		Where("biz_id = ? AND version = ?", bizId, version).
+		First(&k).Error
+	return k, err
+}
+
+func (d *DefaultDataKeyDAO) FindNotWrappedBy(ctx context.Context, masterKeyId string, limit int) ([]BizDataKey, error) {
+	var ks []BizDataKey
+	err := d.db.WithContext(ctx).
+		Where("master_key_id <> ?", masterKeyId).
+		Order("biz_id, version").
+		Limit(limit).
+		Find(&ks).Error
+	return ks, err
+}
+
+// CompareAndSwapWrappedKey 仍由 prevMasterKeyId 加密时更新加密结果，已被其他进程更新时忽略
+func (d *DefaultDataKeyDAO) CompareAndSwapWrappedKey(ctx context.Context, k BizDataKey, prevMasterKeyId string) error {
+	return d.db.WithContext(ctx).
+		Model(&BizDataKey{}).
+		Where("biz_id = ? AND version = ? AND master_key_id = ?", k.BizId, k.Version, prevMasterKeyId).
+		Updates(map[string]any{
+			"master_key_id": k.MasterKeyId,
+			"wrapped_key":   k.WrappedKey,
+			"updated_at":    time.Now().UnixMilli(),
+		}).Error
+}
+
+func NewDefaultDataKeyDAO(db *gorm.DB) *DefaultDataKeyDAO {
+	return &DefaultDataKeyDAO{
+		db: db,
+	}
+}
@@ -0,0 +1,184 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/JrMarcco/easy-kit/xsync"
+	"github.com/JrMarcco/jotify/internal/errs"
+	"github.com/JrMarcco/jotify/internal/pkg/sharding"
+	"golang.org/x/sync/errgroup"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const receiverIndexTableSuffix = "_receiver"
+
+// ReceiverIndex 接收者索引，与消息位于同一个分库中，按消息 id 路由
+type ReceiverIndex struct {
+	ReceiverHash   string
+	NotificationId uint64
+	BizId          uint64
+	CreatedAt      int64
+}
+
+// receiverIndexTable 分表对应的接收者索引表，归档后的消息仍使用原分表的索引表
+func receiverIndexTable(table string) string {
+	return table + receiverIndexTableSuffix
+}
+
+func receiverIndexesOf(n Notification, now int64) []ReceiverIndex {
+	indexes := make([]ReceiverIndex, 0, len(n.ReceiverHashes))
+	seen := make(map[string]struct{}, len(n.ReceiverHashes))
+	for _, hash := range n.ReceiverHashes {
+		if _, ok := seen[hash]; ok {
+			continue
+		}
+		seen[hash] = struct{}{}
+		indexes = append(indexes, ReceiverIndex{
+			ReceiverHash:   hash,
+			NotificationId: n.Id,
+			BizId:          n.BizId,
+			CreatedAt:      now,
+		})
+	}
+	return indexes
+}
+
+type ErasureDAO interface {
+	// FindByReceiverHash 广播查找接收者的索引，bizId 为 0 时查找全部业务方
+	FindByReceiverHash(ctx context.Context, bizId uint64, hash string) ([]ReceiverIndex, error)
+	// Redact 锁定消息后通过 fn 修改接收者与模板参数，消息可能位于当前布局、迁移中的旧布局或归档表中
+	Redact(ctx context.Context, id uint64, fn func(n *Notification) error) error
+	// DeleteReceiverIndex 删除消息的接收者索引
+	DeleteReceiverIndex(ctx context.Context, hash string, ids []uint64) error
+}
+
+var _ ErasureDAO = (*ErasureShardingDAO)(nil)
+
+// ErasureShardingDAO ErasureDAO 的分库分表实现
+type ErasureShardingDAO struct {
+	dbs *xsync.Map[string, *gorm.DB]
+
+	notifShardingStrategy sharding.Strategy
+}
+
+func (d *ErasureShardingDAO) FindByReceiverHash(ctx context.Context, bizId uint64, hash string) ([]ReceiverIndex, error) {
+	mu := new(sync.Mutex)
+	res := make([]ReceiverIndex, 0)
+
+	var eg errgroup.Group
+	for _, dst := range d.notifShardingStrategy.BroadCast() {
+		eg.Go(func() error {
+			db, ok := d.dbs.Load(dst.DB)
+			if !ok {
+				return fmt.Errorf("failed to load db: %s", dst.DB)
+			}
+
+			query := db.WithContext(ctx).Table(receiverIndexTable(dst.Table)).Where("receiver_hash = ?", hash)
+			if bizId != 0 {
+				query = query.Where("biz_id = ?", bizId)
+			}
+
+			var indexes []ReceiverIndex
+			if err := query.Find(&indexes).Error; err != nil {
+				return err
+			}
+
+			mu.Lock()
+			res = append(res, indexes...)
+			mu.Unlock()
+			return nil
+		})
+	}
+	return res, eg.Wait()
+}
+
+func (d *ErasureShardingDAO) Redact(ctx context.Context, id uint64, fn func(n *Notification) error) error {
+	for _, dst := range d.candidates(id) {
+		db, ok := d.dbs.Load(dst.DB)
+		if !ok {
+			return fmt.Errorf("failed to load db: %s", dst.DB)
+		}
+
+		found := false
+		err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			var n Notification
+			err := tx.Table(dst.Table).
+				Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+				Where("id = ?", id).
+				First(&n).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			found = true
+			if err = fn(&n); err != nil {
+				return err
+			}
+			// 不修改 updated_at，避免影响归档时间
+			return tx.Table(dst.Table).Where("id = ?", id).Updates(map[string]any{
+				"receivers":      n.Receivers,
+				"receivers_hash": n.ReceiversHash,
+				"tpl_params":     n.TplParams,
+				"version":        gorm.Expr("`version` + 1"),
+			}).Error
+		})
+		if err != nil || found {
+			return err
+		}
+	}
+	return fmt.Errorf("%w: id = %d", errs.ErrNotificationNotFound, id)
+}
+
+// candidates 消息可能所在的分表，依次为当前布局、迁移中的旧布局以及两者的归档表。
+//
+// 归档与回填都会先锁定记录再移动，按该顺序查找不会遗漏移动中的记录。
+func (d *ErasureShardingDAO) candidates(id uint64) []sharding.Dst {
+	dsts := []sharding.Dst{d.notifShardingStrategy.ShardWithId(id)}
+	if prevDst, ok := previousShardWithId(d.notifShardingStrategy, id); ok {
+		dsts = append(dsts, prevDst)
+	}
+	for i := range len(dsts) {
+		dsts = append(dsts, archiveDst(dsts[i]))
+	}
+	return dsts
+}
+
+func (d *ErasureShardingDAO) DeleteReceiverIndex(ctx context.Context, hash string, ids []uint64) error {
+	// 索引随消息回填，迁移期间新旧布局中都可能存在
+	grouped := make(map[sharding.Dst][]uint64)
+	for _, id := range ids {
+		dst := d.notifShardingStrategy.ShardWithId(id)
+		grouped[dst] = append(grouped[dst], id)
+		if prevDst, ok := previousShardWithId(d.notifShardingStrategy, id); ok {
+			grouped[prevDst] = append(grouped[prevDst], id)
+		}
+	}
+
+	for dst, dstIds := range grouped {
+		db, ok := d.dbs.Load(dst.DB)
+		if !ok {
+			return fmt.Errorf("failed to load db: %s", dst.DB)
+		}
+		err := db.WithContext(ctx).Table(receiverIndexTable(dst.Table)).
+			Where("receiver_hash = ? AND notification_id IN ?", hash, dstIds).
+			Delete(&ReceiverIndex{}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func NewErasureShardingDAO(dbs *xsync.Map[string, *gorm.DB], notifShardingStrategy sharding.Strategy) *ErasureShardingDAO {
+	return &ErasureShardingDAO{
+		dbs:                   dbs,
+		notifShardingStrategy: notifShardingStrategy,
+	}
+}
@@ -0,0 +1,50 @@
+package dao
+
+import (
+	"context"
+
+	"github.com/JrMarcco/jotify/internal/pkg/xsql"
+	"gorm.io/gorm"
+)
+
+// ErasureReceipt 个人数据擦除回执
+type ErasureReceipt struct {
+	Id              uint64
+	BizId           uint64
+	ReceiverHash    string
+	NotificationIds xsql.JsonColumn[[]uint64]
+	OperatorBizId   uint64
+	CreatedAt       int64
+}
+
+func (r ErasureReceipt) TableName() string {
+	return "erasure_receipt"
+}
+
+type ErasureReceiptDAO interface {
+	Create(ctx context.Context, r ErasureReceipt) (ErasureReceipt, error)
+	GetById(ctx context.Context, id uint64) (ErasureReceipt, error)
+}
+
+var _ ErasureReceiptDAO = (*DefaultErasureReceiptDAO)(nil)
+
+type DefaultErasureReceiptDAO struct {
+	db *gorm.DB
+}
+
+func (d *DefaultErasureReceiptDAO) Create(ctx context.Context, r ErasureReceipt) (ErasureReceipt, error) {
+	err := d.db.WithContext(ctx).Create(&r).Error
+	return r, err
+}
+
+func (d *DefaultErasureReceiptDAO) GetById(ctx context.Context, id uint64) (ErasureReceipt, error) {
+	var r ErasureReceipt
+	err := d.db.WithContext(ctx).Where("id = ?", id).First(&r).Error
+	return r, err
+}
+
+func NewDefaultErasureReceiptDAO(db *gorm.DB) *DefaultErasureReceiptDAO {
+	return &DefaultErasureReceiptDAO{
+		db: db,
+	}
+}
@@ -15,8 +15,11 @@ import (
 	"github.com/JrMarcco/jotify/internal/errs"
 	"github.com/JrMarcco/jotify/internal/pkg/sharding"
 	"github.com/JrMarcco/jotify/internal/pkg/snowflake"
+	"github.com/JrMarcco/jotify/internal/pkg/tracing"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/sync/errgroup"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Notification 消息实体
@@ -25,16 +28,25 @@ type Notification struct {
 	BizId         uint64
 	BizKey        string
 	Receivers     string
+	ReceiversHash string
 	Channel       string
 	TplId         uint64
 	TplVersionId  uint64
 	TplParams     string
+	Locale        string
 	Status        string
+	Priority      int8
+	CampaignId    uint64
 	ScheduleStrat int64
 	ScheduleEnd   int64
+	EndFixed      bool
 	Version       int32
+	TraceCtx      string
 	CreatedAt     int64
 	UpdatedAt     int64
+
+	// ReceiverHashes 接收者哈希，创建消息时写入接收者索引
+	ReceiverHashes []string `gorm:"-"`
 }
 
 type NotificationDAO interface {
@@ -52,8 +64,26 @@ type NotificationDAO interface {
 	MarkFailure(ctx context.Context, n Notification) error
 
 	CompareAndSwapStatus(ctx context.Context, n Notification) error
-
-	FindReady(ctx context.Context, offset int, limit int) ([]Notification, error)
+	// Reschedule 按版本号更新状态与发送时间窗口
+	Reschedule(ctx context.Context, n Notification) error
+
+	// CountReady 按业务方与优先级统计上下文中分片内处于发送时间窗口中的待发送消息数，
+	// 分片通过 sharding.ContextWitDst 指定
+	CountReady(ctx context.Context) ([]ReadyBacklog, error)
+	// FindReady 按计划发送时间顺序查找上下文中分片内处于发送时间窗口中、优先级为 priority 且属于 bizId 的待发送消息，
+	// 分片通过 sharding.ContextWitDst 指定
+	FindReady(ctx context.Context, priority int8, bizId uint64, offset int, limit int) ([]Notification, error)
+	// FindExpired 按 id 顺序查找分片中 id 大于 startId 且发送时间窗口在 before 之前结束的待发送消息
+	FindExpired(ctx context.Context, dst sharding.Dst, startId uint64, before int64, limit int) ([]Notification, error)
+	// Expire 将分片中仍为待发送且窗口在 before 之前结束的消息标记为过期，并将其回调记录标记为待发送，返回被标记的消息
+	Expire(ctx context.Context, dst sharding.Dst, ids []uint64, before int64) ([]Notification, error)
+
+	// ExistingKeys 返回 bizKeys 中业务方已创建消息的 biz_key
+	ExistingKeys(ctx context.Context, bizId uint64, bizKeys []string) ([]string, error)
+	// CountByCampaign 按状态统计全部分片中群发活动的消息数
+	CountByCampaign(ctx context.Context, campaignId uint64) (map[string]int64, error)
+	// FindPendingByCampaign 查找全部分片中群发活动的待发送消息，每个分片最多 limit 条
+	FindPendingByCampaign(ctx context.Context, campaignId uint64, limit int) ([]Notification, error)
 }
 
 var _ NotificationDAO = (*NotifShardingDAO)(nil)
@@ -76,7 +106,7 @@ func (nd *NotifShardingDAO) CreateWithCallback(ctx context.Context, entity Notif
 	return nd.create(ctx, entity, true)
 }
 
-func (nd *NotifShardingDAO) create(ctx context.Context, n Notification, needCallback bool) (Notification, error) {
+func (nd *NotifShardingDAO) create(ctx context.Context, n Notification, needCallback bool) (_ Notification, err error) {
 	now := time.Now().UnixMilli()
 	n.CreatedAt, n.UpdatedAt, n.Version = now, now, 1
 
@@ -84,13 +114,16 @@ func (nd *NotifShardingDAO) create(ctx context.Context, n Notification, needCall
 	notifDst := nd.notifShardingStrategy.Shard(n.BizId, n.BizKey)
 	cbLogDst := nd.cbLogShardingStrategy.Shard(n.BizId, n.BizKey)
 
+	ctx, span := nd.startSpan(ctx, "NotificationDAO.Create", notifDst)
+	defer func() { tracing.End(span, err) }()
+
 	db, ok := nd.dbs.Load(notifDst.DB)
 	if !ok {
 		return Notification{}, fmt.Errorf("failed to load db: %s", notifDst.DB)
 	}
 
 	// 业务上指定 notification 和 callback_log 使用相同的分库规则，即在同一个库中
-	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		for {
 			n.Id = nd.idGenerator.NextId(n.BizId, n.BizKey)
 			if err := tx.Table(notifDst.Table).Create(&n).Error; err != nil {
@@ -101,6 +134,12 @@ func (nd *NotifShardingDAO) create(ctx context.Context, n Notification, needCall
 				return err
 			}
 
+			if indexes := receiverIndexesOf(n, now); len(indexes) > 0 {
+				if err := tx.Table(receiverIndexTable(notifDst.Table)).Create(&indexes).Error; err != nil {
+					return err
+				}
+			}
+
 			if needCallback {
 				cb := &CallbackLog{
 					NotificationId: n.Id,
@@ -166,11 +205,17 @@ func (nd *NotifShardingDAO) tryBatchInsert(ctx context.Context, ns []*Notificati
 			return []Notification{}, fmt.Errorf("failed to load db: %s", dbName)
 		}
 
-		eg.Go(func() error {
+		eg.Go(func() (err error) {
+			insertCtx, span := tracing.Start(ctx, "NotificationDAO.BatchCreate",
+				tracing.AttrShardDB.String(dbName),
+				tracing.AttrBatchSize.Int(len(dbNs)),
+			)
+			defer func() { tracing.End(span, err) }()
+
 			for {
 				sql, args, ids := nd.sqlGenerate(db, dbNs, needCallback)
 				if sql != "" {
-					if err := db.WithContext(ctx).Exec(sql, args...).Error; err != nil {
+					if err = db.WithContext(insertCtx).Exec(sql, args...).Error; err != nil {
 						if errors.Is(err, gorm.ErrDuplicatedKey) && IsIdDuplicateErr(ids, err) {
 							// 主键冲突，重新生成 id 再执行插入
 							continue
@@ -198,11 +243,12 @@ func (nd *NotifShardingDAO) sqlGenerate(db *gorm.DB, ns []*Notification, needCal
 	// 临时开启 gorm dry run 来生成 sql
 	gormSession := db.Session(&gorm.Session{DryRun: true})
 	ids := make([]uint64, 0, len(ns))
-	// 包含 callback log
-	sqls := make([]string, 0, 2*len(ns))
-	// Notification 14 个字段
+	// 包含接收者索引与 callback log
+	sqls := make([]string, 0, 3*len(ns))
+	// Notification 20 个字段
 	// CallbackLog  6  个字段
-	args := make([]any, 0, 20*len(ns))
+	// 接收者索引的字段数随接收者数量变化，由 append 扩容
+	args := make([]any, 0, 26*len(ns))
 
 	for _, n := range ns {
 		id := nd.idGenerator.NextId(n.BizId, n.BizKey)
@@ -214,6 +260,12 @@ func (nd *NotifShardingDAO) sqlGenerate(db *gorm.DB, ns []*Notification, needCal
 		sqls = append(sqls, statement.SQL.String())
 		args = append(args, statement.Vars...)
 
+		if indexes := receiverIndexesOf(*n, now); len(indexes) > 0 {
+			statement = gormSession.Table(receiverIndexTable(dst.Table)).Create(&indexes).Statement
+			sqls = append(sqls, statement.SQL.String())
+			args = append(args, statement.Vars...)
+		}
+
 		if needCallback {
 			dst = nd.cbLogShardingStrategy.Shard(n.BizId, n.BizKey)
 			statement = gormSession.Table(dst.Table).Create(&CallbackLog{
@@ -243,8 +295,10 @@ func (nd *NotifShardingDAO) BatchUpdateStatus(ctx context.Context, successNs, fa
 	dbMap := make(map[string]map[string]*modifyIds)
 	for i := range successNs {
 		n := successNs[i]
-		notifDst := nd.notifShardingStrategy.ShardWithId(n.Id)
-		callbackDst := nd.cbLogShardingStrategy.ShardWithId(n.Id)
+		notifDst, callbackDst, err := nd.locate(ctx, n.Id)
+		if err != nil {
+			return err
+		}
 
 		tableMap, ok := dbMap[notifDst.DB]
 		if !ok {
@@ -267,8 +321,10 @@ func (nd *NotifShardingDAO) BatchUpdateStatus(ctx context.Context, successNs, fa
 
 	for i := range failureNs {
 		n := failureNs[i]
-		notifDst := nd.notifShardingStrategy.ShardWithId(n.Id)
-		callbackDst := nd.cbLogShardingStrategy.ShardWithId(n.Id)
+		notifDst, callbackDst, err := nd.locate(ctx, n.Id)
+		if err != nil {
+			return err
+		}
 
 		tableMap, ok := dbMap[notifDst.DB]
 		if !ok {
@@ -278,7 +334,7 @@ func (nd *NotifShardingDAO) BatchUpdateStatus(ctx context.Context, successNs, fa
 
 		modifyId, ok := tableMap[notifDst.Table]
 		if ok {
-			modifyId.successIds = append(modifyId.successIds, n.Id)
+			modifyId.failureIds = append(modifyId.failureIds, n.Id)
 		} else {
 			modifyId = &modifyIds{
 				callbackTable: callbackDst.Table,
@@ -315,40 +371,45 @@ func (nd *NotifShardingDAO) batchMark(tx *gorm.DB, tbMap map[string]*modifyIds)
 		m := tbMap[tb]
 		if len(m.successIds) > 0 {
 			notifSQL := fmt.Sprintf(
-				"UPDATE %s SET `version` = `version` + 1, `status` = '%s', `update_at` = %d WHERE `id` IN (%s)",
+				"UPDATE %s SET `version` = `version` + 1, `status` = '%s', `updated_at` = %d WHERE `id` IN (%s)",
 				tb, domain.SendStatusSuccess.String(), now, m.successToString(),
 			)
 			cbLogSQL := fmt.Sprintf(
-				"UPDATE %s SET `status` = '%s', `update_at` = %d WHERE `notification_id` IN (%s)",
-				tb, domain.CallbackStatusPending.String(), now, m.successToString(),
+				"UPDATE %s SET `status` = '%s', `updated_at` = %d WHERE `notification_id` IN (%s)",
+				m.callbackTable, domain.CallbackStatusPending.String(), now, m.successToString(),
 			)
 			sqls = append(sqls, notifSQL, cbLogSQL)
 		}
 		if len(m.failureIds) > 0 {
 			notifSQL := fmt.Sprintf(
-				"UPDATE %s SET `version` = `version` + 1, `status` = '%s', `update_at` = %d WHERE `id` IN (%s)",
-				tb, domain.SendStatusFailure.String(), now, m.successToString(),
+				"UPDATE %s SET `version` = `version` + 1, `status` = '%s', `updated_at` = %d WHERE `id` IN (%s)",
+				tb, domain.SendStatusFailure.String(), now, m.failureToString(),
 			)
 			sqls = append(sqls, notifSQL)
 		}
 	}
 
-	if len(sqls) == 0 {
+	if len(sqls) > 0 {
 		sql := strings.Join(sqls, "; ")
 		return tx.Exec(sql).Error
 	}
 	return nil
 }
 
-func (nd *NotifShardingDAO) GetById(ctx context.Context, id uint64) (Notification, error) {
+func (nd *NotifShardingDAO) GetById(ctx context.Context, id uint64) (_ Notification, err error) {
 	dst := nd.notifShardingStrategy.ShardWithId(id)
-	db, ok := nd.dbs.Load(dst.DB)
-	if !ok {
-		return Notification{}, fmt.Errorf("failed to load db: %s", dst.DB)
+
+	ctx, span := nd.startSpan(ctx, "NotificationDAO.GetById", dst)
+	defer func() { tracing.End(span, err) }()
+
+	n, err := nd.first(ctx, dst, "id = ?", id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// 迁移期间新布局未命中时读取旧布局
+		if prevDst, ok := previousShardWithId(nd.notifShardingStrategy, id); ok {
+			n, err = nd.first(ctx, prevDst, "id = ?", id)
+		}
 	}
 
-	var n Notification
-	err := db.WithContext(ctx).Table(dst.Table).Where("id = ?", id).First(&n).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return Notification{}, fmt.Errorf("%w: id = %d", errs.ErrNotificationNotFound, id)
@@ -359,26 +420,136 @@ func (nd *NotifShardingDAO) GetById(ctx context.Context, id uint64) (Notificatio
 }
 
 func (nd *NotifShardingDAO) GetByKey(ctx context.Context, bizId uint64, bizKey string) (Notification, error) {
-	dst := nd.notifShardingStrategy.Shard(bizId, bizKey)
+	const query = "`biz_id` = ? AND `biz_key` = ?"
+
+	n, err := nd.first(ctx, nd.notifShardingStrategy.Shard(bizId, bizKey), query, bizId, bizKey)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// 迁移期间新布局未命中时读取旧布局
+		if ms, ok := nd.notifShardingStrategy.(sharding.MigratingStrategy); ok {
+			if prevDst, ok := ms.PreviousShard(bizId, bizKey); ok {
+				n, err = nd.first(ctx, prevDst, query, bizId, bizKey)
+			}
+		}
+	}
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return Notification{}, fmt.Errorf("%w: bizId = %d, bizKey = %s", errs.ErrNotificationNotFound, bizId, bizKey)
+		}
+		return Notification{}, fmt.Errorf("failed to get notification, bizId = %d, bizKey = %s, %w", bizId, bizKey, err)
+	}
+	return n, nil
+}
+
+// first 在指定分片查询一条记录
+func (nd *NotifShardingDAO) first(ctx context.Context, dst sharding.Dst, query string, args ...any) (Notification, error) {
 	db, ok := nd.dbs.Load(dst.DB)
 	if !ok {
 		return Notification{}, fmt.Errorf("failed to load db: %s", dst.DB)
 	}
 
 	var n Notification
-	err := db.WithContext(ctx).Table(dst.Table).
-		Where("`biz_id` = ? AND `biz_key` = ?", bizId, bizKey).
-		First(&n).Error
-	if err != nil {
-		return Notification{}, fmt.Errorf("failed to get notification, bizId = %d, bizKey = %s, %w", bizId, bizKey, err)
+	err := db.WithContext(ctx).Table(dst.Table).Where(query, args...).First(&n).Error
+	return n, err
+}
+
+// locate 获取 id 对应的记录当前所在的 notification 与 callback_log 分片。
+//
+// 未在迁移时直接按当前布局路由。迁移期间记录可能还未回填到新布局，新布局中不存在时使用旧布局，
+// 这样对旧记录的更新落在旧布局上，由回填统一迁移到新布局。
+func (nd *NotifShardingDAO) locate(ctx context.Context, id uint64) (sharding.Dst, sharding.Dst, error) {
+	notifDst := nd.notifShardingStrategy.ShardWithId(id)
+	cbLogDst := nd.cbLogShardingStrategy.ShardWithId(id)
+
+	prevNotifDst, ok := previousShardWithId(nd.notifShardingStrategy, id)
+	if !ok {
+		return notifDst, cbLogDst, nil
 	}
-	return n, nil
+
+	db, ok := nd.dbs.Load(notifDst.DB)
+	if !ok {
+		return sharding.Dst{}, sharding.Dst{}, fmt.Errorf("failed to load db: %s", notifDst.DB)
+	}
+	var cnt int64
+	if err := db.WithContext(ctx).Table(notifDst.Table).Where("id = ?", id).Limit(1).Count(&cnt).Error; err != nil {
+		return sharding.Dst{}, sharding.Dst{}, err
+	}
+	if cnt > 0 {
+		return notifDst, cbLogDst, nil
+	}
+
+	if prevCbLogDst, ok := previousShardWithId(nd.cbLogShardingStrategy, id); ok {
+		cbLogDst = prevCbLogDst
+	}
+	return prevNotifDst, cbLogDst, nil
+}
+
+// cbLogDstIn 返回位于消息分片 dst 中的消息 id 的回调记录分片。
+//
+// dst 为迁移期间旧布局下的分片时，回调记录也在旧布局下，与 locate 的选择一致。
+func (nd *NotifShardingDAO) cbLogDstIn(dst sharding.Dst, id uint64) sharding.Dst {
+	if nd.notifShardingStrategy.ShardWithId(id) != dst {
+		if prevNotifDst, ok := previousShardWithId(nd.notifShardingStrategy, id); ok && prevNotifDst == dst {
+			if prevCbLogDst, ok := previousShardWithId(nd.cbLogShardingStrategy, id); ok {
+				return prevCbLogDst
+			}
+		}
+	}
+	return nd.cbLogShardingStrategy.ShardWithId(id)
 }
 
+// previousShardWithId 迁移期间 id 在旧布局下的分片，策略不支持迁移、未在迁移或新旧分片相同时返回 false
+func previousShardWithId(strategy sharding.Strategy, id uint64) (sharding.Dst, bool) {
+	ms, ok := strategy.(sharding.MigratingStrategy)
+	if !ok {
+		return sharding.Dst{}, false
+	}
+	return ms.PreviousShardWithId(id)
+}
+
+// GetMapByIds 依次从当前布局、迁移期间的旧布局以及归档表中查找，前一步未命中的 id 在下一步中查找
 func (nd *NotifShardingDAO) GetMapByIds(ctx context.Context, ids []uint64) (map[uint64]Notification, error) {
+	current := func(id uint64) (sharding.Dst, bool) {
+		return nd.notifShardingStrategy.ShardWithId(id), true
+	}
+	previous := func(id uint64) (sharding.Dst, bool) {
+		return previousShardWithId(nd.notifShardingStrategy, id)
+	}
+	archived := func(dstFn func(id uint64) (sharding.Dst, bool)) func(id uint64) (sharding.Dst, bool) {
+		return func(id uint64) (sharding.Dst, bool) {
+			dst, ok := dstFn(id)
+			return archiveDst(dst), ok
+		}
+	}
+
+	notifMap := make(map[uint64]Notification, len(ids))
+	missing := ids
+	for _, dstFn := range []func(id uint64) (sharding.Dst, bool){current, previous, archived(current), archived(previous)} {
+		if err := nd.findByIds(ctx, missing, dstFn, notifMap); err != nil {
+			return notifMap, err
+		}
+
+		missing = slice.FilterMap(missing, func(_ int, id uint64) (uint64, bool) {
+			_, ok := notifMap[id]
+			return id, !ok
+		})
+		if len(missing) == 0 {
+			break
+		}
+	}
+	return notifMap, nil
+}
+
+// findByIds 按 dstFn 计算的分片分组查询，结果写入 notifMap，dstFn 返回 false 的 id 跳过
+func (nd *NotifShardingDAO) findByIds(
+	ctx context.Context, ids []uint64, dstFn func(id uint64) (sharding.Dst, bool), notifMap map[uint64]Notification,
+) error {
 	idMap := make(map[[2]string][]uint64, len(ids))
 	for _, id := range ids {
-		dst := nd.notifShardingStrategy.ShardWithId(id)
+		dst, ok := dstFn(id)
+		if !ok {
+			continue
+		}
 
 		key := [2]string{dst.DB, dst.Table}
 		val, ok := idMap[key]
@@ -391,7 +562,6 @@ func (nd *NotifShardingDAO) GetMapByIds(ctx context.Context, ids []uint64) (map[
 		idMap[key] = val
 	}
 
-	notifMap := make(map[uint64]Notification, len(idMap))
 	mu := new(sync.RWMutex)
 
 	// 广播查找
@@ -418,13 +588,18 @@ func (nd *NotifShardingDAO) GetMapByIds(ctx context.Context, ids []uint64) (map[
 			return err
 		})
 	}
-	return notifMap, eg.Wait()
+	return eg.Wait()
 }
 
-func (nd *NotifShardingDAO) MarkSuccess(ctx context.Context, n Notification) error {
+func (nd *NotifShardingDAO) MarkSuccess(ctx context.Context, n Notification) (err error) {
 	now := time.Now().UnixMilli()
-	dst := nd.notifShardingStrategy.ShardWithId(n.Id)
-	cbLogDst := nd.cbLogShardingStrategy.ShardWithId(n.Id)
+	dst, cbLogDst, err := nd.locate(ctx, n.Id)
+	if err != nil {
+		return err
+	}
+
+	ctx, span := nd.startSpan(ctx, "NotificationDAO.MarkSuccess", dst)
+	defer func() { tracing.End(span, err) }()
 
 	db, ok := nd.dbs.Load(dst.DB)
 	if !ok {
@@ -434,9 +609,9 @@ func (nd *NotifShardingDAO) MarkSuccess(ctx context.Context, n Notification) err
 	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		err := tx.Table(dst.Table).Model(&Notification{}).Where("id = ?", n.Id).
 			Updates(map[string]any{
-				"status":    n.Status,
-				"update_at": now,
-				"version":   gorm.Expr("`version` + 1"),
+				"status":     n.Status,
+				"updated_at": now,
+				"version":    gorm.Expr("`version` + 1"),
 			}).Error
 		if err != nil {
 			return err
@@ -445,15 +620,22 @@ func (nd *NotifShardingDAO) MarkSuccess(ctx context.Context, n Notification) err
 		// 标记 callback log 状态为 pending（可发送）
 		return tx.Table(cbLogDst.Table).Model(&CallbackLog{}).Where("notification_id = ?", n.Id).
 			Updates(map[string]any{
-				"status":    domain.CallbackStatusPending,
-				"update_at": now,
+				"status":     domain.CallbackStatusPending,
+				"updated_at": now,
 			}).Error
 	})
 }
 
-func (nd *NotifShardingDAO) MarkFailure(ctx context.Context, n Notification) error {
+func (nd *NotifShardingDAO) MarkFailure(ctx context.Context, n Notification) (err error) {
 	now := time.Now().UnixMilli()
-	dst := nd.notifShardingStrategy.ShardWithId(n.Id)
+	dst, _, err := nd.locate(ctx, n.Id)
+	if err != nil {
+		return err
+	}
+
+	ctx, span := nd.startSpan(ctx, "NotificationDAO.MarkFailure", dst)
+	defer func() { tracing.End(span, err) }()
+
 	db, ok := nd.dbs.Load(dst.DB)
 	if !ok {
 		return fmt.Errorf("failed to load db: %s", dst.DB)
@@ -462,15 +644,22 @@ func (nd *NotifShardingDAO) MarkFailure(ctx context.Context, n Notification) err
 	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		return tx.Table(dst.Table).Model(&Notification{}).Where("id = ?", n.Id).
 			Updates(map[string]any{
-				"status":    n.Status,
-				"update_at": now,
-				"version":   gorm.Expr("`version` + 1"),
+				"status":     n.Status,
+				"updated_at": now,
+				"version":    gorm.Expr("`version` + 1"),
 			}).Error
 	})
 }
 
-func (nd *NotifShardingDAO) CompareAndSwapStatus(ctx context.Context, n Notification) error {
-	dst := nd.notifShardingStrategy.ShardWithId(n.Id)
+func (nd *NotifShardingDAO) CompareAndSwapStatus(ctx context.Context, n Notification) (err error) {
+	dst, _, err := nd.locate(ctx, n.Id)
+	if err != nil {
+		return err
+	}
+
+	ctx, span := nd.startSpan(ctx, "NotificationDAO.CompareAndSwapStatus", dst)
+	defer func() { tracing.End(span, err) }()
+
 	db, ok := nd.dbs.Load(dst.DB)
 	if !ok {
 		return fmt.Errorf("failed to load db: %s", dst.DB)
@@ -479,23 +668,297 @@ func (nd *NotifShardingDAO) CompareAndSwapStatus(ctx context.Context, n Notifica
 	res := db.WithContext(ctx).Table(dst.Table).
 		Where("`id` = ? AND `version` = ?", n.Id, n.Version).
 		Updates(map[string]any{
-			"status":    n.Status,
-			"version":   gorm.Expr("`version` + 1"),
-			"update_at": time.Now().UnixMilli(),
+			"status":     n.Status,
+			"version":    gorm.Expr("`version` + 1"),
+			"updated_at": time.Now().UnixMilli(),
 		})
 	if res.Error != nil {
 		return res.Error
 	}
 
-	if res.RowsAffected < 0 {
-		return fmt.Errorf("%w: failed to concurrent competition", res.Error)
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("%w: id = %d, version = %d", errs.ErrNotificationVersionConflict, n.Id, n.Version)
 	}
 	return nil
 }
 
-func (nd *NotifShardingDAO) FindReady(ctx context.Context, offset int, limit int) ([]Notification, error) {
-	//TODO implement me
-	panic("implement me")
+func (nd *NotifShardingDAO) Reschedule(ctx context.Context, n Notification) (err error) {
+	dst, _, err := nd.locate(ctx, n.Id)
+	if err != nil {
+		return err
+	}
+
+	ctx, span := nd.startSpan(ctx, "NotificationDAO.Reschedule", dst)
+	defer func() { tracing.End(span, err) }()
+
+	db, ok := nd.dbs.Load(dst.DB)
+	if !ok {
+		return fmt.Errorf("failed to load db: %s", dst.DB)
+	}
+
+	res := db.WithContext(ctx).Table(dst.Table).
+		Where("`id` = ? AND `version` = ?", n.Id, n.Version).
+		Updates(map[string]any{
+			"status":         n.Status,
+			"schedule_strat": n.ScheduleStrat,
+			"schedule_end":   n.ScheduleEnd,
+			"version":        gorm.Expr("`version` + 1"),
+			"updated_at":     time.Now().UnixMilli(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("%w: id = %d, version = %d", errs.ErrNotificationVersionConflict, n.Id, n.Version)
+	}
+	return nil
+}
+
+// startSpan 开启数据库操作 span，记录分库分表信息
+func (nd *NotifShardingDAO) startSpan(ctx context.Context, name string, dst sharding.Dst) (context.Context, trace.Span) {
+	return tracing.Start(ctx, name, tracing.AttrShardDB.String(dst.DB), tracing.AttrShardTable.String(dst.Table))
+}
+
+// ExistingKeys 按分片分组查询，迁移期间同时查询旧布局
+func (nd *NotifShardingDAO) ExistingKeys(ctx context.Context, bizId uint64, bizKeys []string) ([]string, error) {
+	groups := make(map[sharding.Dst][]string)
+	for _, bizKey := range bizKeys {
+		dst := nd.notifShardingStrategy.Shard(bizId, bizKey)
+		groups[dst] = append(groups[dst], bizKey)
+		if ms, ok := nd.notifShardingStrategy.(sharding.MigratingStrategy); ok {
+			if prevDst, ok := ms.PreviousShard(bizId, bizKey); ok && prevDst != dst {
+				groups[prevDst] = append(groups[prevDst], bizKey)
+			}
+		}
+	}
+
+	var res []string
+	for dst, keys := range groups {
+		db, ok := nd.dbs.Load(dst.DB)
+		if !ok {
+			return nil, fmt.Errorf("failed to load db: %s", dst.DB)
+		}
+
+		var existing []string
+		err := db.WithContext(ctx).Table(dst.Table).
+			Where("biz_id = ? AND biz_key IN ?", bizId, keys).
+			Pluck("biz_key", &existing).Error
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, existing...)
+	}
+	return res, nil
+}
+
+func (nd *NotifShardingDAO) CountByCampaign(ctx context.Context, campaignId uint64) (map[string]int64, error) {
+	type statusCnt struct {
+		Status string
+		Cnt    int64
+	}
+
+	mu := new(sync.Mutex)
+	res := make(map[string]int64)
+
+	var eg errgroup.Group
+	for _, dst := range nd.notifShardingStrategy.BroadCast() {
+		eg.Go(func() error {
+			db, ok := nd.dbs.Load(dst.DB)
+			if !ok {
+				return fmt.Errorf("failed to load db: %s", dst.DB)
+			}
+
+			var cnts []statusCnt
+			err := db.WithContext(ctx).Table(dst.Table).
+				Select("status", "COUNT(*) AS cnt").
+				Where("campaign_id = ?", campaignId).
+				Group("status").
+				Scan(&cnts).Error
+			if err != nil {
+				return err
+			}
+
+			mu.Lock()
+			for _, cnt := range cnts {
+				res[cnt.Status] += cnt.Cnt
+			}
+			mu.Unlock()
+			return nil
+		})
+	}
+	return res, eg.Wait()
+}
+
+func (nd *NotifShardingDAO) FindPendingByCampaign(ctx context.Context, campaignId uint64, limit int) ([]Notification, error) {
+	mu := new(sync.Mutex)
+	res := make([]Notification, 0)
+
+	var eg errgroup.Group
+	for _, dst := range nd.notifShardingStrategy.BroadCast() {
+		eg.Go(func() error {
+			db, ok := nd.dbs.Load(dst.DB)
+			if !ok {
+				return fmt.Errorf("failed to load db: %s", dst.DB)
+			}
+
+			var ns []Notification
+			err := db.WithContext(ctx).Table(dst.Table).
+				Where("campaign_id = ? AND status = ?", campaignId, domain.SendStatusPending).
+				Limit(limit).
+				Find(&ns).Error
+			if err != nil {
+				return err
+			}
+
+			mu.Lock()
+			res = append(res, ns...)
+			mu.Unlock()
+			return nil
+		})
+	}
+	return res, eg.Wait()
+}
+
+// ReadyBacklog 业务方在一个优先级下的待发送消息数
+type ReadyBacklog struct {
+	BizId    uint64
+	Priority int8
+	Cnt      int64
+}
+
+func (nd *NotifShardingDAO) CountReady(ctx context.Context) (_ []ReadyBacklog, err error) {
+	dst, ok := sharding.DstFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("%w: sharding dst not found in context", errs.ErrInvalidParam)
+	}
+
+	ctx, span := nd.startSpan(ctx, "NotificationDAO.CountReady", dst)
+	defer func() { tracing.End(span, err) }()
+
+	db, ok := nd.dbs.Load(dst.DB)
+	if !ok {
+		return nil, fmt.Errorf("failed to load db: %s", dst.DB)
+	}
+
+	now := time.Now().UnixMilli()
+	var backlogs []ReadyBacklog
+	err = db.WithContext(ctx).Table(dst.Table).
+		Select("biz_id", "priority", "COUNT(*) AS cnt").
+		Where("status = ? AND schedule_strat <= ? AND schedule_end > ?", domain.SendStatusPending, now, now).
+		Group("biz_id, priority").
+		Scan(&backlogs).Error
+	return backlogs, err
+}
+
+func (nd *NotifShardingDAO) FindReady(
+	ctx context.Context, priority int8, bizId uint64, offset int, limit int,
+) (_ []Notification, err error) {
+	dst, ok := sharding.DstFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("%w: sharding dst not found in context", errs.ErrInvalidParam)
+	}
+
+	ctx, span := nd.startSpan(ctx, "NotificationDAO.FindReady", dst)
+	defer func() { tracing.End(span, err) }()
+
+	db, ok := nd.dbs.Load(dst.DB)
+	if !ok {
+		return nil, fmt.Errorf("failed to load db: %s", dst.DB)
+	}
+
+	// 只发送处于时间窗口 [schedule_strat, schedule_end) 中的消息，窗口结束后由过期任务处理
+	now := time.Now().UnixMilli()
+	var ns []Notification
+	err = db.WithContext(ctx).Table(dst.Table).
+		Where("status = ? AND priority = ? AND biz_id = ? AND schedule_strat <= ? AND schedule_end > ?",
+			domain.SendStatusPending, priority, bizId, now, now).
+		Order("schedule_strat").
+		Offset(offset).
+		Limit(limit).
+		Find(&ns).Error
+	return ns, err
+}
+
+func (nd *NotifShardingDAO) FindExpired(
+	ctx context.Context, dst sharding.Dst, startId uint64, before int64, limit int,
+) ([]Notification, error) {
+	db, ok := nd.dbs.Load(dst.DB)
+	if !ok {
+		return nil, fmt.Errorf("failed to load db: %s", dst.DB)
+	}
+
+	var ns []Notification
+	err := db.WithContext(ctx).Table(dst.Table).
+		Select("id", "biz_id", "channel", "schedule_end").
+		Where("id > ? AND status = ? AND schedule_end <= ?", startId, domain.SendStatusPending, before).
+		Order("id").
+		Limit(limit).
+		Find(&ns).Error
+	return ns, err
+}
+
+// Expire 过期同一分片中的消息。
+//
+// 在事务中先锁定仍为待发送且已过期的记录，避免与调度器、取消等状态变更并发，
+// 再更新消息状态并将回调记录标记为待发送，由回调任务通知业务方。
+func (nd *NotifShardingDAO) Expire(ctx context.Context, dst sharding.Dst, ids []uint64, before int64) (_ []Notification, err error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	ctx, span := nd.startSpan(ctx, "NotificationDAO.Expire", dst)
+	defer func() { tracing.End(span, err) }()
+
+	db, ok := nd.dbs.Load(dst.DB)
+	if !ok {
+		return nil, fmt.Errorf("failed to load db: %s", dst.DB)
+	}
+	var expired []Notification
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Table(dst.Table).
+			Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+			Where("id IN ? AND status = ? AND schedule_end <= ?", ids, domain.SendStatusPending, before).
+			Find(&expired).Error
+		if err != nil || len(expired) == 0 {
+			return err
+		}
+
+		now := time.Now().UnixMilli()
+		expiredIds := slice.Map(expired, func(_ int, n Notification) uint64 { return n.Id })
+		err = tx.Table(dst.Table).Where("id IN ?", expiredIds).
+			Updates(map[string]any{
+				"status":     domain.SendStatusExpired,
+				"version":    gorm.Expr("`version` + 1"),
+				"updated_at": now,
+			}).Error
+		if err != nil {
+			return err
+		}
+
+		// 回调记录与消息位于同一布局下，按 dst 所在的布局逐条确定回调记录的分表
+		cbLogIds := make(map[string][]uint64)
+		for _, id := range expiredIds {
+			table := nd.cbLogDstIn(dst, id).Table
+			cbLogIds[table] = append(cbLogIds[table], id)
+		}
+		for table, notifIds := range cbLogIds {
+			err = tx.Table(table).Where("notification_id IN ?", notifIds).
+				Updates(map[string]any{
+					"status":        domain.CallbackStatusPending,
+					"next_retry_at": now,
+					"updated_at":    now,
+				}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return expired, nil
 }
 
 func NewNotifShardingDAO(
@@ -0,0 +1,145 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/JrMarcco/easy-kit/xsync"
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/pkg/sharding"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const archiveTableSuffix = "_archive"
+
+// terminalStatuses 终态，终态的消息不会再被调度发送
+var terminalStatuses = []string{
+	domain.SendStatusSuccess.String(),
+	domain.SendStatusFailure.String(),
+	domain.SendStatusCancel.String(),
+	domain.SendStatusExpired.String(),
+}
+
+// ArchiveTable 分表对应的归档表，与分表位于同一个库中
+func ArchiveTable(table string) string {
+	return table + archiveTableSuffix
+}
+
+// cbLogArchiveColumns 归档 callback_log 时复制的字段。
+//
+// 不复制自增 id，归档表中的 id 由归档表分配，在线扩容迁移归档记录时同样不保留 id，
+// 保留 id 会与迁移到同一张归档表中的记录主键冲突。
+var cbLogArchiveColumns = []string{"notification_id", "retried_times", "next_retry_at", "status", "created_at", "updated_at"}
+
+func archiveDst(dst sharding.Dst) sharding.Dst {
+	dst.Table = ArchiveTable(dst.Table)
+	return dst
+}
+
+type NotifArchiveDAO interface {
+	// FindTerminal 按 id 顺序查找分片中 id 大于 startId 且 updated_at 早于 before 的终态消息
+	FindTerminal(ctx context.Context, dst sharding.Dst, startId uint64, before int64, limit int) ([]Notification, error)
+	// Archive 将分片中仍为终态的消息及其回调记录移动到归档表，返回归档的消息数
+	Archive(ctx context.Context, dst sharding.Dst, ids []uint64) (int, error)
+}
+
+var _ NotifArchiveDAO = (*NotifArchiveShardingDAO)(nil)
+
+// NotifArchiveShardingDAO NotifArchiveDAO 的分库分表实现
+type NotifArchiveShardingDAO struct {
+	dbs *xsync.Map[string, *gorm.DB]
+
+	cbLogShardingStrategy sharding.Strategy
+}
+
+func (d *NotifArchiveShardingDAO) FindTerminal(
+	ctx context.Context, dst sharding.Dst, startId uint64, before int64, limit int,
+) ([]Notification, error) {
+	db, ok := d.dbs.Load(dst.DB)
+	if !ok {
+		return nil, fmt.Errorf("failed to load db: %s", dst.DB)
+	}
+
+	var ns []Notification
+	err := db.WithContext(ctx).Table(dst.Table).
+		Select("id", "biz_id", "status", "updated_at").
+		Where("id > ? AND status IN ? AND updated_at < ?", startId, terminalStatuses, before).
+		Order("id").
+		Limit(limit).
+		Find(&ns).Error
+	return ns, err
+}
+
+// Archive 归档同一分片中的消息。
+//
+// 在事务中先锁定仍为终态的记录，避免与重发等状态变更并发，再将消息与回调记录一起移动到归档表。
+func (d *NotifArchiveShardingDAO) Archive(ctx context.Context, dst sharding.Dst, ids []uint64) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	db, ok := d.dbs.Load(dst.DB)
+	if !ok {
+		return 0, fmt.Errorf("failed to load db: %s", dst.DB)
+	}
+	// 同一分片中的消息，回调记录也位于同一张分表中
+	cbLogTable := d.cbLogShardingStrategy.ShardWithId(ids[0]).Table
+
+	var archived []uint64
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Table(dst.Table).
+			Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+			Where("id IN ? AND status IN ?", ids, terminalStatuses).
+			Pluck("id", &archived).Error
+		if err != nil || len(archived) == 0 {
+			return err
+		}
+
+		if err = moveToArchive(tx, dst.Table, "id", nil, archived); err != nil {
+			return err
+		}
+		return moveToArchive(tx, cbLogTable, "notification_id", cbLogArchiveColumns, archived)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(archived), nil
+}
+
+// moveToArchive 在事务中将 table 中 column 取值在 vals 中的记录移动到归档表，columns 为空时复制全部字段。
+//
+// 先删除归档表中的同名记录，保证重复归档时不会因主键冲突失败。
+//
+//goland:noinspection SqlNoDataSourceInspection
+func moveToArchive(tx *gorm.DB, table, column string, columns []string, vals []uint64) error {
+	archive := ArchiveTable(table)
+	insert := fmt.Sprintf("INSERT INTO `%s` SELECT * FROM `%s` WHERE `%s` IN ?", archive, table, column)
+	if len(columns) > 0 {
+		cols := "`" + strings.Join(columns, "`, `") + "`"
+		insert = fmt.Sprintf("INSERT INTO `%s` (%s) SELECT %s FROM `%s` WHERE `%s` IN ?", archive, cols, cols, table, column)
+	}
+
+	sqls := []string{
+		fmt.Sprintf("DELETE FROM `%s` WHERE `%s` IN ?", archive, column),
+		insert,
+		fmt.Sprintf("DELETE FROM `%s` WHERE `%s` IN ?", table, column),
+	}
+	for _, sql := range sqls {
+		if err := tx.Exec(sql, vals).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func NewNotifArchiveShardingDAO(
+	dbs *xsync.Map[string, *gorm.DB],
+	cbLogShardingStrategy sharding.Strategy,
+) *NotifArchiveShardingDAO {
+	return &NotifArchiveShardingDAO{
+		dbs:                   dbs,
+		cbLogShardingStrategy: cbLogShardingStrategy,
+	}
+}
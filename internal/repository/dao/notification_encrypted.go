@@ -0,0 +1,227 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/JrMarcco/jotify/internal/pkg/envelope"
+	"github.com/JrMarcco/jotify/internal/pkg/sharding"
+)
+
+var _ NotificationDAO = (*EncryptedNotifDAO)(nil)
+
+// EncryptedNotifDAO 加密接收者与模板参数的 NotificationDAO 装饰器。
+//
+// 写入时使用业务方的数据密钥加密，读取时解密，未加密的历史数据原样返回。
+// 调度、回调与查询都通过 NotificationDAO 读取消息，对上层透明。
+// encrypt 为 false 时只解密不加密，关闭加密后已加密的消息仍可读取。
+type EncryptedNotifDAO struct {
+	notifDAO NotificationDAO
+	cipher   *envelope.Cipher
+	encrypt  bool
+}
+
+func (e *EncryptedNotifDAO) Create(ctx context.Context, n Notification) (Notification, error) {
+	if err := e.encryptOne(ctx, &n); err != nil {
+		return Notification{}, err
+	}
+	res, err := e.notifDAO.Create(ctx, n)
+	if err != nil {
+		return Notification{}, err
+	}
+	return res, e.decryptOne(ctx, &res)
+}
+
+func (e *EncryptedNotifDAO) CreateWithCallback(ctx context.Context, entity Notification) (Notification, error) {
+	if err := e.encryptOne(ctx, &entity); err != nil {
+		return Notification{}, err
+	}
+	res, err := e.notifDAO.CreateWithCallback(ctx, entity)
+	if err != nil {
+		return Notification{}, err
+	}
+	return res, e.decryptOne(ctx, &res)
+}
+
+func (e *EncryptedNotifDAO) BatchCreate(ctx context.Context, ns []Notification) ([]Notification, error) {
+	ns, err := e.encryptAll(ctx, ns)
+	if err != nil {
+		return nil, err
+	}
+	res, err := e.notifDAO.BatchCreate(ctx, ns)
+	if err != nil {
+		return res, err
+	}
+	return res, e.decryptAll(ctx, res)
+}
+
+func (e *EncryptedNotifDAO) BatchCreateWithCallback(ctx context.Context, ns []Notification) ([]Notification, error) {
+	ns, err := e.encryptAll(ctx, ns)
+	if err != nil {
+		return nil, err
+	}
+	res, err := e.notifDAO.BatchCreateWithCallback(ctx, ns)
+	if err != nil {
+		return res, err
+	}
+	return res, e.decryptAll(ctx, res)
+}
+
+func (e *EncryptedNotifDAO) BatchUpdateStatus(ctx context.Context, successNs, failureNs []Notification) error {
+	return e.notifDAO.BatchUpdateStatus(ctx, successNs, failureNs)
+}
+
+func (e *EncryptedNotifDAO) GetById(ctx context.Context, id uint64) (Notification, error) {
+	n, err := e.notifDAO.GetById(ctx, id)
+	if err != nil {
+		return Notification{}, err
+	}
+	return n, e.decryptOne(ctx, &n)
+}
+
+func (e *EncryptedNotifDAO) GetByKey(ctx context.Context, bizId uint64, bizKey string) (Notification, error) {
+	n, err := e.notifDAO.GetByKey(ctx, bizId, bizKey)
+	if err != nil {
+		return Notification{}, err
+	}
+	return n, e.decryptOne(ctx, &n)
+}
+
+func (e *EncryptedNotifDAO) GetMapByIds(ctx context.Context, ids []uint64) (map[uint64]Notification, error) {
+	nm, err := e.notifDAO.GetMapByIds(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for id, n := range nm {
+		if err = e.decryptOne(ctx, &n); err != nil {
+			return nil, err
+		}
+		nm[id] = n
+	}
+	return nm, nil
+}
+
+func (e *EncryptedNotifDAO) MarkSuccess(ctx context.Context, n Notification) error {
+	return e.notifDAO.MarkSuccess(ctx, n)
+}
+
+func (e *EncryptedNotifDAO) MarkFailure(ctx context.Context, n Notification) error {
+	return e.notifDAO.MarkFailure(ctx, n)
+}
+
+func (e *EncryptedNotifDAO) CompareAndSwapStatus(ctx context.Context, n Notification) error {
+	return e.notifDAO.CompareAndSwapStatus(ctx, n)
+}
+
+func (e *EncryptedNotifDAO) Reschedule(ctx context.Context, n Notification) error {
+	return e.notifDAO.Reschedule(ctx, n)
+}
+
+func (e *EncryptedNotifDAO) CountReady(ctx context.Context) ([]ReadyBacklog, error) {
+	return e.notifDAO.CountReady(ctx)
+}
+
+func (e *EncryptedNotifDAO) FindReady(
+	ctx context.Context, priority int8, bizId uint64, offset int, limit int,
+) ([]Notification, error) {
+	ns, err := e.notifDAO.FindReady(ctx, priority, bizId, offset, limit)
+	if err != nil {
+		return ns, err
+	}
+	return ns, e.decryptAll(ctx, ns)
+}
+
+// FindExpired 只查询不加密的字段，无需解密
+func (e *EncryptedNotifDAO) FindExpired(
+	ctx context.Context, dst sharding.Dst, startId uint64, before int64, limit int,
+) ([]Notification, error) {
+	return e.notifDAO.FindExpired(ctx, dst, startId, before, limit)
+}
+
+func (e *EncryptedNotifDAO) Expire(ctx context.Context, dst sharding.Dst, ids []uint64, before int64) ([]Notification, error) {
+	ns, err := e.notifDAO.Expire(ctx, dst, ids, before)
+	if err != nil {
+		return nil, err
+	}
+	return ns, e.decryptAll(ctx, ns)
+}
+
+// ExistingKeys 只查询 biz_key，无需解密
+func (e *EncryptedNotifDAO) ExistingKeys(ctx context.Context, bizId uint64, bizKeys []string) ([]string, error) {
+	return e.notifDAO.ExistingKeys(ctx, bizId, bizKeys)
+}
+
+func (e *EncryptedNotifDAO) CountByCampaign(ctx context.Context, campaignId uint64) (map[string]int64, error) {
+	return e.notifDAO.CountByCampaign(ctx, campaignId)
+}
+
+func (e *EncryptedNotifDAO) FindPendingByCampaign(ctx context.Context, campaignId uint64, limit int) ([]Notification, error) {
+	ns, err := e.notifDAO.FindPendingByCampaign(ctx, campaignId, limit)
+	if err != nil {
+		return nil, err
+	}
+	return ns, e.decryptAll(ctx, ns)
+}
+
+func (e *EncryptedNotifDAO) encryptAll(ctx context.Context, ns []Notification) ([]Notification, error) {
+	if !e.encrypt {
+		return ns, nil
+	}
+
+	// 复制一份，避免修改调用方的切片
+	encrypted := make([]Notification, len(ns))
+	copy(encrypted, ns)
+	for i := range encrypted {
+		if err := e.encryptOne(ctx, &encrypted[i]); err != nil {
+			return nil, err
+		}
+	}
+	return encrypted, nil
+}
+
+func (e *EncryptedNotifDAO) encryptOne(ctx context.Context, n *Notification) error {
+	if !e.encrypt {
+		return nil
+	}
+
+	receivers, err := e.cipher.Encrypt(ctx, n.BizId, n.Receivers)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt receivers: %w", err)
+	}
+	tplParams, err := e.cipher.Encrypt(ctx, n.BizId, n.TplParams)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt template params: %w", err)
+	}
+	n.Receivers, n.TplParams = receivers, tplParams
+	return nil
+}
+
+func (e *EncryptedNotifDAO) decryptAll(ctx context.Context, ns []Notification) error {
+	for i := range ns {
+		if err := e.decryptOne(ctx, &ns[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *EncryptedNotifDAO) decryptOne(ctx context.Context, n *Notification) error {
+	receivers, err := e.cipher.Decrypt(ctx, n.BizId, n.Receivers)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt receivers of notification %d: %w", n.Id, err)
+	}
+	tplParams, err := e.cipher.Decrypt(ctx, n.BizId, n.TplParams)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt template params of notification %d: %w", n.Id, err)
+	}
+	n.Receivers, n.TplParams = receivers, tplParams
+	return nil
+}
+
+func NewEncryptedNotifDAO(notifDAO NotificationDAO, cipher *envelope.Cipher, encrypt bool) *EncryptedNotifDAO {
+	return &EncryptedNotifDAO{
+		notifDAO: notifDAO,
+		cipher:   cipher,
+		encrypt:  encrypt,
+	}
+}
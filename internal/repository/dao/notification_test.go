@@ -0,0 +1,244 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/JrMarcco/easy-kit/xsync"
+	"github.com/JrMarcco/jotify/internal/pkg/sharding"
+	"github.com/JrMarcco/jotify/internal/pkg/snowflake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+const fakeDriverName = "jotify_fake"
+
+var (
+	registerFakeDriver sync.Once
+	fakeDBs            sync.Map // dsn -> *fakeDB
+	fakeDSNSeq         atomic.Int64
+)
+
+// fakeDB 记录执行的语句，查询返回 rows 中预设的结果
+type fakeDB struct {
+	mu    sync.Mutex
+	execs []string
+	// rows 按语句前缀返回查询结果
+	rows func(query string) ([]string, [][]driver.Value)
+}
+
+func (d *fakeDB) executed() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.execs...)
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(dsn string) (driver.Conn, error) {
+	db, ok := fakeDBs.Load(dsn)
+	if !ok {
+		return nil, fmt.Errorf("unknown dsn %s", dsn)
+	}
+	return &fakeConn{db: db.(*fakeDB)}, nil
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, driver.ErrSkip
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return fakeTx{}, nil
+}
+
+func (c *fakeConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	return fakeTx{}, nil
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	c.db.mu.Lock()
+	c.db.execs = append(c.db.execs, query)
+	c.db.mu.Unlock()
+	return driver.RowsAffected(1), nil
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	var cols []string
+	var vals [][]driver.Value
+	if c.db.rows != nil {
+		cols, vals = c.db.rows(query)
+	}
+	return &fakeRows{cols: cols, vals: vals}, nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeRows struct {
+	cols []string
+	vals [][]driver.Value
+	idx  int
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.cols
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.idx >= len(r.vals) {
+		return io.EOF
+	}
+	copy(dest, r.vals[r.idx])
+	r.idx++
+	return nil
+}
+
+// newFakeGormDB 创建使用 fakeDB 的 gorm.DB，不连接真实数据库
+func newFakeGormDB(t *testing.T, db *fakeDB) *gorm.DB {
+	t.Helper()
+
+	registerFakeDriver.Do(func() {
+		sql.Register(fakeDriverName, fakeDriver{})
+	})
+	dsn := fmt.Sprintf("fake_%d", fakeDSNSeq.Add(1))
+	fakeDBs.Store(dsn, db)
+	t.Cleanup(func() { fakeDBs.Delete(dsn) })
+
+	gdb, err := gorm.Open(mysql.New(mysql.Config{
+		DriverName:                fakeDriverName,
+		DSN:                       dsn,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{SkipDefaultTransaction: true})
+	require.NoError(t, err)
+	return gdb
+}
+
+func TestNotifShardingDAO_Expire(t *testing.T) {
+	t.Parallel()
+
+	previous := sharding.Layout{Version: 1, DBSharding: 2, TableSharding: 2}
+	current := sharding.Layout{Version: 2, DBSharding: 2, TableSharding: 4}
+	rt := sharding.RoutingTable{Current: current, Previous: &previous}
+	notifStrategy, err := sharding.NewVersionedStrategy("jotify", "notification", rt)
+	require.NoError(t, err)
+	cbLogStrategy, err := sharding.NewVersionedStrategy("jotify", "callback_log", rt)
+	require.NoError(t, err)
+
+	// 找到迁移前后位于不同分表的 id
+	g := snowflake.NewGenerator()
+	var id uint64
+	for i := 0; ; i++ {
+		id = g.NextId(uint64(i), "biz_key")
+		if _, ok := notifStrategy.PreviousShardWithId(id); ok {
+			break
+		}
+	}
+	prevNotifDst, _ := notifStrategy.PreviousShardWithId(id)
+	prevCbLogDst, ok := cbLogStrategy.PreviousShardWithId(id)
+	require.True(t, ok)
+
+	tcs := []struct {
+		name       string
+		dst        sharding.Dst
+		wantCbLog  string
+		notWantTbl string
+	}{
+		{
+			name:       "current layout",
+			dst:        notifStrategy.ShardWithId(id),
+			wantCbLog:  cbLogStrategy.ShardWithId(id).Table,
+			notWantTbl: prevCbLogDst.Table,
+		}, {
+			name:       "previous layout",
+			dst:        prevNotifDst,
+			wantCbLog:  prevCbLogDst.Table,
+			notWantTbl: cbLogStrategy.ShardWithId(id).Table,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			now := time.Now().UnixMilli()
+			fdb := &fakeDB{rows: func(query string) ([]string, [][]driver.Value) {
+				if !strings.Contains(query, "FOR UPDATE") {
+					return nil, nil
+				}
+				return []string{"id", "biz_id", "channel", "receivers", "tpl_id", "created_at"},
+					[][]driver.Value{{int64(id), int64(1), "sms", `["13800000000"]`, int64(1), now}}
+			}}
+			gdb := newFakeGormDB(t, fdb)
+			dbs := &xsync.Map[string, *gorm.DB]{}
+			for _, dst := range notifStrategy.BroadCast() {
+				dbs.Store(dst.DB, gdb)
+			}
+
+			nd := NewNotifShardingDAO(dbs, notifStrategy, cbLogStrategy, g)
+			expired, err := nd.Expire(t.Context(), tc.dst, []uint64{id}, now)
+			require.NoError(t, err)
+			require.Len(t, expired, 1)
+			// 返回完整的记录，供退还配额与释放频控计数
+			assert.Equal(t, `["13800000000"]`, expired[0].Receivers)
+			assert.Equal(t, uint64(1), expired[0].TplId)
+
+			execs := fdb.executed()
+			require.Len(t, execs, 2)
+			assert.Contains(t, execs[0], "UPDATE `"+tc.dst.Table+"`")
+			assert.Contains(t, execs[1], "UPDATE `"+tc.wantCbLog+"`")
+			assert.NotContains(t, execs[1], "`"+tc.notWantTbl+"`")
+		})
+	}
+}
+
+func TestNotifShardingDAO_Expire_NothingExpired(t *testing.T) {
+	t.Parallel()
+
+	strategy, err := sharding.NewVersionedStrategy("jotify", "notification", sharding.RoutingTable{
+		Current: sharding.Layout{Version: 1, DBSharding: 2, TableSharding: 2},
+	})
+	require.NoError(t, err)
+	cbLogStrategy, err := sharding.NewVersionedStrategy("jotify", "callback_log", sharding.RoutingTable{
+		Current: sharding.Layout{Version: 1, DBSharding: 2, TableSharding: 2},
+	})
+	require.NoError(t, err)
+
+	fdb := &fakeDB{}
+	gdb := newFakeGormDB(t, fdb)
+	dbs := &xsync.Map[string, *gorm.DB]{}
+	for _, dst := range strategy.BroadCast() {
+		dbs.Store(dst.DB, gdb)
+	}
+
+	g := snowflake.NewGenerator()
+	id := g.NextId(1, "biz_key")
+	nd := NewNotifShardingDAO(dbs, strategy, cbLogStrategy, g)
+	expired, err := nd.Expire(t.Context(), strategy.ShardWithId(id), []uint64{id}, time.Now().UnixMilli())
+	require.NoError(t, err)
+	assert.Empty(t, expired)
+	assert.Empty(t, fdb.executed())
+}
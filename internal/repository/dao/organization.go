@@ -0,0 +1,192 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/errs"
+	"github.com/JrMarcco/jotify/internal/pkg/xsql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Organization 组织实体
+type Organization struct {
+	Id           uint64
+	Name         string
+	ChannelConf  xsql.JsonColumn[domain.ChannelConf]
+	RateLimit    int32
+	DeliveryConf xsql.JsonColumn[domain.DeliveryConf]
+	CreatedAt    int64
+	UpdatedAt    int64
+}
+
+func (o Organization) TableName() string {
+	return "organization"
+}
+
+// OrgQuotaAlloc 组织配额分配实体
+type OrgQuotaAlloc struct {
+	BizId     uint64
+	Channel   string
+	OrgId     uint64
+	Cap       int64
+	Allocated int64
+	CreatedAt int64
+	UpdatedAt int64
+}
+
+func (a OrgQuotaAlloc) TableName() string {
+	return "org_quota_alloc"
+}
+
+type OrganizationDAO interface {
+	// Create 已存在同名组织时返回 errs.ErrDuplicateOrganization
+	Create(ctx context.Context, o Organization) (Organization, error)
+	GetById(ctx context.Context, id uint64) (Organization, error)
+	// Update 更新组织的默认配置
+	Update(ctx context.Context, o Organization) error
+
+	// FindMembers 查询组织的成员业务方
+	FindMembers(ctx context.Context, orgId uint64) ([]BizConf, error)
+	// SetMember 将业务方加入组织，orgId 为 0 时将业务方移出组织
+	SetMember(ctx context.Context, bizId uint64, orgId uint64) error
+
+	GetAlloc(ctx context.Context, bizId uint64, channel string) (OrgQuotaAlloc, error)
+	// FindAllocs 查询组织在渠道上的全部分配
+	FindAllocs(ctx context.Context, orgId uint64, channel string) ([]OrgQuotaAlloc, error)
+	// SetCap 设置业务方在渠道上的配额余额上限
+	SetCap(ctx context.Context, a OrgQuotaAlloc) error
+	// AddAllocated 累加业务方在渠道上分配的配额，收回时 delta 为负数
+	AddAllocated(ctx context.Context, a OrgQuotaAlloc, delta int64) error
+}
+
+var _ OrganizationDAO = (*DefaultOrganizationDAO)(nil)
+
+type DefaultOrganizationDAO struct {
+	db *gorm.DB
+}
+
+func (d *DefaultOrganizationDAO) Create(ctx context.Context, o Organization) (Organization, error) {
+	now := time.Now().UnixMilli()
+	o.CreatedAt, o.UpdatedAt = now, now
+
+	res := d.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&o)
+	if res.Error != nil {
+		return Organization{}, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return Organization{}, fmt.Errorf("%w: name = %s", errs.ErrDuplicateOrganization, o.Name)
+	}
+	return o, nil
+}
+
+func (d *DefaultOrganizationDAO) GetById(ctx context.Context, id uint64) (Organization, error) {
+	var o Organization
+	err := d.db.WithContext(ctx).Where("id = ?", id).First(&o).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return Organization{}, fmt.Errorf("%w: id = %d", errs.ErrOrganizationNotFound, id)
+	}
+	return o, err
+}
+
+func (d *DefaultOrganizationDAO) Update(ctx context.Context, o Organization) error {
+	res := d.db.WithContext(ctx).Model(&Organization{}).
+		Where("id = ?", o.Id).
+		Updates(map[string]any{
+			"channel_conf":  o.ChannelConf,
+			"rate_limit":    o.RateLimit,
+			"delivery_conf": o.DeliveryConf,
+			"updated_at":    time.Now().UnixMilli(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("%w: id = %d", errs.ErrOrganizationNotFound, o.Id)
+	}
+	return nil
+}
+
+func (d *DefaultOrganizationDAO) FindMembers(ctx context.Context, orgId uint64) ([]BizConf, error) {
+	var bcs []BizConf
+	err := d.db.WithContext(ctx).
+		Where("owner_type = ? AND owner_id = ?", domain.OwnerTypeOrganization, orgId).
+		Order("id").
+		Find(&bcs).Error
+	return bcs, err
+}
+
+func (d *DefaultOrganizationDAO) SetMember(ctx context.Context, bizId uint64, orgId uint64) error {
+	ownerType := domain.OwnerTypeOrganization
+	if orgId == 0 {
+		ownerType = domain.OwnerTypePerson
+	}
+
+	res := d.db.WithContext(ctx).Model(&BizConf{}).
+		Where("id = ?", bizId).
+		Updates(map[string]any{
+			"owner_type": ownerType,
+			"owner_id":   orgId,
+			"updated_at": time.Now().UnixMilli(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("%w: biz id = %d", errs.ErrBizConfNotFound, bizId)
+	}
+	return nil
+}
+
+func (d *DefaultOrganizationDAO) GetAlloc(ctx context.Context, bizId uint64, channel string) (OrgQuotaAlloc, error) {
+	var a OrgQuotaAlloc
+	err := d.db.WithContext(ctx).Where("biz_id = ? AND channel = ?", bizId, channel).First(&a).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// 未设置上限的业务方不限制配额余额
+		return OrgQuotaAlloc{BizId: bizId, Channel: channel}, nil
+	}
+	return a, err
+}
+
+func (d *DefaultOrganizationDAO) FindAllocs(ctx context.Context, orgId uint64, channel string) ([]OrgQuotaAlloc, error) {
+	var as []OrgQuotaAlloc
+	err := d.db.WithContext(ctx).
+		Where("org_id = ? AND channel = ?", orgId, channel).
+		Order("biz_id").
+		Find(&as).Error
+	return as, err
+}
+
+func (d *DefaultOrganizationDAO) SetCap(ctx context.Context, a OrgQuotaAlloc) error {
+	now := time.Now().UnixMilli()
+	a.CreatedAt, a.UpdatedAt = now, now
+	return d.db.WithContext(ctx).Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]any{
+			"org_id":     a.OrgId,
+			"cap":        a.Cap,
+			"updated_at": now,
+		}),
+	}).Create(&a).Error
+}
+
+func (d *DefaultOrganizationDAO) AddAllocated(ctx context.Context, a OrgQuotaAlloc, delta int64) error {
+	now := time.Now().UnixMilli()
+	a.Allocated, a.CreatedAt, a.UpdatedAt = delta, now, now
+	return d.db.WithContext(ctx).Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]any{
+			"org_id":     a.OrgId,
+			"allocated":  gorm.Expr("`allocated` + ?", delta),
+			"updated_at": now,
+		}),
+	}).Create(&a).Error
+}
+
+func NewDefaultOrganizationDAO(db *gorm.DB) *DefaultOrganizationDAO {
+	return &DefaultOrganizationDAO{
+		db: db,
+	}
+}
@@ -3,6 +3,7 @@ package dao
 import (
 	"context"
 
+	"github.com/JrMarcco/jotify/internal/domain"
 	"gorm.io/gorm"
 )
 
@@ -33,6 +34,10 @@ func (p Provider) TableName() string {
 
 type ProviderDAO interface {
 	GetByNameAndTplInfo(ctx context.Context, name string, tplId uint64, tplVersionId uint64, tplChannel string) ([]ChannelTplProvider, error)
+	// GetByTplInfo 获取模板版本在所有供应商侧的模板
+	GetByTplInfo(ctx context.Context, tplId uint64, tplVersionId uint64, tplChannel string) ([]ChannelTplProvider, error)
+	// SumQpsLimit 统计渠道可用供应商的 QPS 上限之和
+	SumQpsLimit(ctx context.Context, channel string) (int64, error)
 }
 
 var _ ProviderDAO = (*DefaultProviderDAO)(nil)
@@ -52,6 +57,23 @@ func (d *DefaultProviderDAO) GetByNameAndTplInfo(ctx context.Context, name strin
 	return providers, err
 }
 
+func (d *DefaultProviderDAO) GetByTplInfo(ctx context.Context, tplId uint64, tplVersionId uint64, tplChannel string) ([]ChannelTplProvider, error) {
+	var providers []ChannelTplProvider
+	err := d.db.WithContext(ctx).Model(&ChannelTplProvider{}).
+		Where("tpl_id = ? AND tpl_version_id = ? AND tpl_channel = ?", tplId, tplVersionId, tplChannel).
+		Find(&providers).Error
+	return providers, err
+}
+
+func (d *DefaultProviderDAO) SumQpsLimit(ctx context.Context, channel string) (int64, error) {
+	var sum int64
+	err := d.db.WithContext(ctx).Model(&Provider{}).
+		Select("COALESCE(SUM(qps_limit), 0)").
+		Where("channel = ? AND status = ?", channel, domain.ProviderStatusActive).
+		Scan(&sum).Error
+	return sum, err
+}
+
 func NewDefaultProviderDAO(db *gorm.DB) *DefaultProviderDAO {
 	return &DefaultProviderDAO{
 		db: db,
@@ -0,0 +1,80 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReceiverLocale 接收者语言偏好实体
+type ReceiverLocale struct {
+	BizId        uint64
+	ReceiverHash string
+	Locale       string
+	CreatedAt    int64
+	UpdatedAt    int64
+}
+
+func (r ReceiverLocale) TableName() string {
+	return "receiver_locale"
+}
+
+type ReceiverLocaleDAO interface {
+	// BatchUpsert 批量写入，接收者已有语言偏好时更新
+	BatchUpsert(ctx context.Context, ls []ReceiverLocale) error
+	// Delete 删除接收者的语言偏好，bizId 为 0 时删除全部业务方的记录
+	Delete(ctx context.Context, bizId uint64, receiverHash string) error
+	FindByHashes(ctx context.Context, bizId uint64, receiverHashes []string) ([]ReceiverLocale, error)
+}
+
+var _ ReceiverLocaleDAO = (*DefaultReceiverLocaleDAO)(nil)
+
+type DefaultReceiverLocaleDAO struct {
+	db *gorm.DB
+}
+
+func (d *DefaultReceiverLocaleDAO) BatchUpsert(ctx context.Context, ls []ReceiverLocale) error {
+	if len(ls) == 0 {
+		return nil
+	}
+
+	now := time.Now().UnixMilli()
+	for i := range ls {
+		ls[i].CreatedAt, ls[i].UpdatedAt = now, now
+	}
+
+	const batchSize = 500
+	return d.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			DoUpdates: clause.AssignmentColumns([]string{"locale", "updated_at"}),
+		}).
+		CreateInBatches(&ls, batchSize).Error
+}
+
+func (d *DefaultReceiverLocaleDAO) Delete(ctx context.Context, bizId uint64, receiverHash string) error {
+	db := d.db.WithContext(ctx).Where("receiver_hash = ?", receiverHash)
+	if bizId > 0 {
+		db = db.Where("biz_id = ?", bizId)
+	}
+	return db.Delete(&ReceiverLocale{}).Error
+}
+
+func (d *DefaultReceiverLocaleDAO) FindByHashes(ctx context.Context, bizId uint64, receiverHashes []string) ([]ReceiverLocale, error) {
+	var ls []ReceiverLocale
+	if len(receiverHashes) == 0 {
+		return ls, nil
+	}
+
+	err := d.db.WithContext(ctx).
+		Where("biz_id = ? AND receiver_hash IN ?", bizId, receiverHashes).
+		Find(&ls).Error
+	return ls, err
+}
+
+func NewDefaultReceiverLocaleDAO(db *gorm.DB) *DefaultReceiverLocaleDAO {
+	return &DefaultReceiverLocaleDAO{
+		db: db,
+	}
+}
@@ -0,0 +1,138 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/errs"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RecurringSchedule 周期发送计划实体
+type RecurringSchedule struct {
+	Id             uint64
+	BizId          uint64
+	BizKey         string
+	Receivers      string
+	Channel        string
+	TplId          uint64
+	TplVersionId   uint64
+	TplParams      string
+	Priority       int8
+	Cron           string
+	Timezone       string
+	EndAt          int64
+	MaxOccurrences int32
+	Occurrences    int32
+	NextRunAt      int64
+	Status         string
+	Version        int32
+	CreatedAt      int64
+	UpdatedAt      int64
+}
+
+func (s RecurringSchedule) TableName() string {
+	return "recurring_schedule"
+}
+
+type RecurringScheduleDAO interface {
+	// Create 业务方已存在相同 biz_key 的计划时返回 errs.ErrDuplicateRecurringSchedule
+	Create(ctx context.Context, s RecurringSchedule) error
+	GetByKey(ctx context.Context, bizId uint64, bizKey string) (RecurringSchedule, error)
+	// List 按 id 升序分页查询业务方的计划
+	List(ctx context.Context, bizId uint64, offset int, limit int) ([]RecurringSchedule, error)
+	// FindDue 按触发时间升序查询 next_run_at 不晚于 before 的 active 计划
+	FindDue(ctx context.Context, before int64, limit int) ([]RecurringSchedule, error)
+	// CompareAndSwap 按版本号更新触发次数、下一次触发时间与状态
+	CompareAndSwap(ctx context.Context, s RecurringSchedule) error
+	Delete(ctx context.Context, bizId uint64, bizKey string) error
+}
+
+var _ RecurringScheduleDAO = (*DefaultRecurringScheduleDAO)(nil)
+
+type DefaultRecurringScheduleDAO struct {
+	db *gorm.DB
+}
+
+func (d *DefaultRecurringScheduleDAO) Create(ctx context.Context, s RecurringSchedule) error {
+	now := time.Now().UnixMilli()
+	s.Version, s.CreatedAt, s.UpdatedAt = 1, now, now
+
+	res := d.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&s)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("%w: bizId = %d, bizKey = %s", errs.ErrDuplicateRecurringSchedule, s.BizId, s.BizKey)
+	}
+	return nil
+}
+
+func (d *DefaultRecurringScheduleDAO) GetByKey(ctx context.Context, bizId uint64, bizKey string) (RecurringSchedule, error) {
+	var s RecurringSchedule
+	err := d.db.WithContext(ctx).Where("biz_id = ? AND biz_key = ?", bizId, bizKey).First(&s).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return RecurringSchedule{}, fmt.Errorf("%w: bizId = %d, bizKey = %s", errs.ErrRecurringScheduleNotFound, bizId, bizKey)
+	}
+	return s, err
+}
+
+func (d *DefaultRecurringScheduleDAO) List(ctx context.Context, bizId uint64, offset int, limit int) ([]RecurringSchedule, error) {
+	var ss []RecurringSchedule
+	err := d.db.WithContext(ctx).
+		Where("biz_id = ?", bizId).
+		Order("id").Offset(offset).Limit(limit).
+		Find(&ss).Error
+	return ss, err
+}
+
+func (d *DefaultRecurringScheduleDAO) FindDue(ctx context.Context, before int64, limit int) ([]RecurringSchedule, error) {
+	var ss []RecurringSchedule
+	err := d.db.WithContext(ctx).
+		Where("status = ? AND next_run_at > 0 AND next_run_at <= ?", domain.RecurringStatusActive, before).
+		Order("next_run_at").Limit(limit).
+		Find(&ss).Error
+	return ss, err
+}
+
+func (d *DefaultRecurringScheduleDAO) CompareAndSwap(ctx context.Context, s RecurringSchedule) error {
+	res := d.db.WithContext(ctx).Model(&RecurringSchedule{}).
+		Where("id = ? AND version = ?", s.Id, s.Version).
+		Updates(map[string]any{
+			"occurrences": s.Occurrences,
+			"next_run_at": s.NextRunAt,
+			"status":      s.Status,
+			"version":     gorm.Expr("`version` + 1"),
+			"updated_at":  time.Now().UnixMilli(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("%w: recurring schedule id = %d, version = %d", errs.ErrNotificationVersionConflict, s.Id, s.Version)
+	}
+	return nil
+}
+
+func (d *DefaultRecurringScheduleDAO) Delete(ctx context.Context, bizId uint64, bizKey string) error {
+	res := d.db.WithContext(ctx).
+		Where("biz_id = ? AND biz_key = ?", bizId, bizKey).
+		Delete(&RecurringSchedule{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("%w: bizId = %d, bizKey = %s", errs.ErrRecurringScheduleNotFound, bizId, bizKey)
+	}
+	return nil
+}
+
+func NewDefaultRecurringScheduleDAO(db *gorm.DB) *DefaultRecurringScheduleDAO {
+	return &DefaultRecurringScheduleDAO{
+		db: db,
+	}
+}
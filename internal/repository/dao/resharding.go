@@ -0,0 +1,309 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/JrMarcco/easy-kit/slice"
+	"github.com/JrMarcco/easy-kit/xsync"
+	"github.com/JrMarcco/jotify/internal/pkg/sharding"
+	"gorm.io/gorm"
+)
+
+// ReshardingDAO 在线扩容的数据回填，将旧布局中的 notification 及其 callback_log、接收者索引（包括归档表）迁移到新布局。
+//
+// 迁移单条记录时先写入新分片，再按 version 从旧分片删除：
+// 删除失败说明复制后记录在旧分片上被更新，重新复制后再次删除，保证新分片中的数据不比旧分片旧。
+// 归档记录不会再被修改，按批复制后直接删除。
+type ReshardingDAO struct {
+	dbs *xsync.Map[string, *gorm.DB]
+
+	notifShardingStrategy *sharding.VersionedStrategy
+	cbLogShardingStrategy *sharding.VersionedStrategy
+}
+
+// BackfillResult 单个旧分片的回填结果
+type BackfillResult struct {
+	DB      string `json:"db"`
+	Table   string `json:"table"`
+	Scanned int    `json:"scanned"`
+	Moved   int    `json:"moved"`
+}
+
+// Backfill 回填旧布局的全部分片，未在迁移时返回错误
+func (d *ReshardingDAO) Backfill(ctx context.Context, batchSize int) ([]BackfillResult, error) {
+	notifSrcs := d.notifShardingStrategy.PreviousBroadCast()
+	cbLogSrcs := d.cbLogShardingStrategy.PreviousBroadCast()
+	if len(notifSrcs) == 0 {
+		return nil, errors.New("routing table is not migrating")
+	}
+	if len(notifSrcs) != len(cbLogSrcs) {
+		return nil, errors.New("notification and callback_log routing tables mismatch")
+	}
+
+	results := make([]BackfillResult, 0, len(notifSrcs))
+	for i := range notifSrcs {
+		res, err := d.backfillShard(ctx, notifSrcs[i], cbLogSrcs[i], batchSize)
+		results = append(results, res)
+		if err != nil {
+			return results, err
+		}
+
+		res, err = d.backfillArchive(ctx, notifSrcs[i], cbLogSrcs[i], batchSize)
+		results = append(results, res)
+		if err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+// backfillShard 按 id 顺序扫描旧分片，将路由到其他分片的记录迁移过去
+func (d *ReshardingDAO) backfillShard(
+	ctx context.Context, notifSrc, cbLogSrc sharding.Dst, batchSize int,
+) (BackfillResult, error) {
+	res := BackfillResult{DB: notifSrc.DB, Table: notifSrc.Table}
+
+	srcDB, ok := d.dbs.Load(notifSrc.DB)
+	if !ok {
+		return res, fmt.Errorf("failed to load db: %s", notifSrc.DB)
+	}
+
+	lastId := uint64(0)
+	for {
+		var ns []Notification
+		err := srcDB.WithContext(ctx).Table(notifSrc.Table).
+			Where("id > ?", lastId).
+			Order("id").
+			Limit(batchSize).
+			Find(&ns).Error
+		if err != nil {
+			return res, err
+		}
+		if len(ns) == 0 {
+			return res, nil
+		}
+
+		for _, n := range ns {
+			lastId = n.Id
+			res.Scanned++
+
+			if d.notifShardingStrategy.ShardWithId(n.Id) == notifSrc {
+				continue
+			}
+			if err = d.move(ctx, srcDB, notifSrc, cbLogSrc, n); err != nil {
+				return res, fmt.Errorf("failed to move notification %d: %w", n.Id, err)
+			}
+			res.Moved++
+		}
+	}
+}
+
+func (d *ReshardingDAO) move(ctx context.Context, srcDB *gorm.DB, notifSrc, cbLogSrc sharding.Dst, n Notification) error {
+	notifDst := d.notifShardingStrategy.ShardWithId(n.Id)
+	cbLogDst := d.cbLogShardingStrategy.ShardWithId(n.Id)
+
+	dstDB, ok := d.dbs.Load(notifDst.DB)
+	if !ok {
+		return fmt.Errorf("failed to load db: %s", notifDst.DB)
+	}
+
+	for {
+		var cbLogs []CallbackLog
+		err := srcDB.WithContext(ctx).Table(cbLogSrc.Table).Where("notification_id = ?", n.Id).Find(&cbLogs).Error
+		if err != nil {
+			return err
+		}
+		var indexes []ReceiverIndex
+		err = srcDB.WithContext(ctx).Table(receiverIndexTable(notifSrc.Table)).Where("notification_id = ?", n.Id).Find(&indexes).Error
+		if err != nil {
+			return err
+		}
+
+		// 写入新分片，新分片中已有更新的版本时保留新分片的数据
+		err = dstDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			var existing Notification
+			err := tx.Table(notifDst.Table).Where("id = ?", n.Id).First(&existing).Error
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				if err = tx.Table(notifDst.Table).Create(&n).Error; err != nil {
+					return err
+				}
+			case err != nil:
+				return err
+			case existing.Version >= n.Version:
+				return nil
+			default:
+				if err = tx.Table(notifDst.Table).Where("id = ?", n.Id).Select("*").Updates(&n).Error; err != nil {
+					return err
+				}
+			}
+
+			if err = tx.Table(cbLogDst.Table).Where("notification_id = ?", n.Id).Delete(&CallbackLog{}).Error; err != nil {
+				return err
+			}
+			if len(cbLogs) > 0 {
+				if err = tx.Table(cbLogDst.Table).Create(&cbLogs).Error; err != nil {
+					return err
+				}
+			}
+			return copyReceiverIndexes(tx, receiverIndexTable(notifDst.Table), []uint64{n.Id}, indexes)
+		})
+		if err != nil {
+			return err
+		}
+
+		// 按 version 从旧分片删除
+		var deleted bool
+		err = srcDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			res := tx.Table(notifSrc.Table).Where("id = ? AND version = ?", n.Id, n.Version).Delete(&Notification{})
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				return nil
+			}
+
+			deleted = true
+			if err := tx.Table(cbLogSrc.Table).Where("notification_id = ?", n.Id).Delete(&CallbackLog{}).Error; err != nil {
+				return err
+			}
+			return tx.Table(receiverIndexTable(notifSrc.Table)).Where("notification_id = ?", n.Id).Delete(&ReceiverIndex{}).Error
+		})
+		if err != nil || deleted {
+			return err
+		}
+
+		// 复制后旧分片中的记录被更新，重新读取后再次迁移
+		err = srcDB.WithContext(ctx).Table(notifSrc.Table).Where("id = ?", n.Id).First(&n).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// backfillArchive 按 id 顺序扫描旧分片的归档表，将路由到其他分片的记录按批迁移到对应的归档表
+func (d *ReshardingDAO) backfillArchive(
+	ctx context.Context, notifSrc, cbLogSrc sharding.Dst, batchSize int,
+) (BackfillResult, error) {
+	notifArchive, cbLogArchive := archiveDst(notifSrc), archiveDst(cbLogSrc)
+	res := BackfillResult{DB: notifArchive.DB, Table: notifArchive.Table}
+
+	srcDB, ok := d.dbs.Load(notifArchive.DB)
+	if !ok {
+		return res, fmt.Errorf("failed to load db: %s", notifArchive.DB)
+	}
+
+	lastId := uint64(0)
+	for {
+		var ns []Notification
+		err := srcDB.WithContext(ctx).Table(notifArchive.Table).
+			Where("id > ?", lastId).
+			Order("id").
+			Limit(batchSize).
+			Find(&ns).Error
+		if err != nil {
+			return res, err
+		}
+		if len(ns) == 0 {
+			return res, nil
+		}
+		lastId = ns[len(ns)-1].Id
+		res.Scanned += len(ns)
+
+		grouped := make(map[sharding.Dst][]Notification)
+		for _, n := range ns {
+			if dst := d.notifShardingStrategy.ShardWithId(n.Id); dst != notifSrc {
+				grouped[dst] = append(grouped[dst], n)
+			}
+		}
+		for _, group := range grouped {
+			if err = d.moveArchived(ctx, srcDB, notifArchive, cbLogArchive, receiverIndexTable(notifSrc.Table), group); err != nil {
+				return res, fmt.Errorf("failed to move archived notifications: %w", err)
+			}
+			res.Moved += len(group)
+		}
+	}
+}
+
+// moveArchived 将同一目标分片的归档记录复制到新分片的归档表后从旧分片删除，重复执行时覆盖新分片中的记录
+func (d *ReshardingDAO) moveArchived(
+	ctx context.Context, srcDB *gorm.DB, notifSrc, cbLogSrc sharding.Dst, indexSrc string, ns []Notification,
+) error {
+	ids := slice.Map(ns, func(_ int, n Notification) uint64 { return n.Id })
+	liveDst := d.notifShardingStrategy.ShardWithId(ids[0])
+	notifDst := archiveDst(liveDst)
+	cbLogDst := archiveDst(d.cbLogShardingStrategy.ShardWithId(ids[0]))
+
+	dstDB, ok := d.dbs.Load(notifDst.DB)
+	if !ok {
+		return fmt.Errorf("failed to load db: %s", notifDst.DB)
+	}
+
+	var cbLogs []CallbackLog
+	if err := srcDB.WithContext(ctx).Table(cbLogSrc.Table).Where("notification_id IN ?", ids).Find(&cbLogs).Error; err != nil {
+		return err
+	}
+	var indexes []ReceiverIndex
+	if err := srcDB.WithContext(ctx).Table(indexSrc).Where("notification_id IN ?", ids).Find(&indexes).Error; err != nil {
+		return err
+	}
+
+	err := dstDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table(notifDst.Table).Where("id IN ?", ids).Delete(&Notification{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Table(notifDst.Table).Create(&ns).Error; err != nil {
+			return err
+		}
+		if err := tx.Table(cbLogDst.Table).Where("notification_id IN ?", ids).Delete(&CallbackLog{}).Error; err != nil {
+			return err
+		}
+		if len(cbLogs) > 0 {
+			if err := tx.Table(cbLogDst.Table).Create(&cbLogs).Error; err != nil {
+				return err
+			}
+		}
+		return copyReceiverIndexes(tx, receiverIndexTable(liveDst.Table), ids, indexes)
+	})
+	if err != nil {
+		return err
+	}
+
+	return srcDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table(notifSrc.Table).Where("id IN ?", ids).Delete(&Notification{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Table(cbLogSrc.Table).Where("notification_id IN ?", ids).Delete(&CallbackLog{}).Error; err != nil {
+			return err
+		}
+		return tx.Table(indexSrc).Where("notification_id IN ?", ids).Delete(&ReceiverIndex{}).Error
+	})
+}
+
+// copyReceiverIndexes 用 indexes 覆盖目标索引表中 ids 对应的接收者索引
+func copyReceiverIndexes(tx *gorm.DB, table string, ids []uint64, indexes []ReceiverIndex) error {
+	if err := tx.Table(table).Where("notification_id IN ?", ids).Delete(&ReceiverIndex{}).Error; err != nil {
+		return err
+	}
+	if len(indexes) > 0 {
+		return tx.Table(table).Create(&indexes).Error
+	}
+	return nil
+}
+
+func NewReshardingDAO(
+	dbs *xsync.Map[string, *gorm.DB],
+	notifShardingStrategy *sharding.VersionedStrategy,
+	cbLogShardingStrategy *sharding.VersionedStrategy,
+) *ReshardingDAO {
+	return &ReshardingDAO{
+		dbs:                   dbs,
+		notifShardingStrategy: notifShardingStrategy,
+		cbLogShardingStrategy: cbLogShardingStrategy,
+	}
+}
@@ -0,0 +1,95 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Suppression 退订名单实体
+type Suppression struct {
+	Id           uint64
+	BizId        uint64
+	ReceiverHash string
+	Channel      string
+	Reason       string
+	ExpireAt     int64
+	CreatedAt    int64
+	UpdatedAt    int64
+}
+
+func (s Suppression) TableName() string {
+	return "suppression"
+}
+
+type SuppressionDAO interface {
+	// BatchUpsert 批量写入，接收者已在名单中时更新原因与过期时间
+	BatchUpsert(ctx context.Context, ss []Suppression) error
+	Delete(ctx context.Context, bizId uint64, receiverHash string, channel string) error
+	// FindActive 查找业务方与全局名单中未过期的记录
+	FindActive(ctx context.Context, bizId uint64, channel string, receiverHashes []string) ([]Suppression, error)
+	// List 按 id 升序分页查询业务方的名单，receiverHash 不为空时只查询该接收者
+	List(ctx context.Context, bizId uint64, receiverHash string, offset int, limit int) ([]Suppression, error)
+}
+
+var _ SuppressionDAO = (*DefaultSuppressionDAO)(nil)
+
+type DefaultSuppressionDAO struct {
+	db *gorm.DB
+}
+
+func (d *DefaultSuppressionDAO) BatchUpsert(ctx context.Context, ss []Suppression) error {
+	if len(ss) == 0 {
+		return nil
+	}
+
+	now := time.Now().UnixMilli()
+	for i := range ss {
+		ss[i].CreatedAt, ss[i].UpdatedAt = now, now
+	}
+
+	const batchSize = 500
+	return d.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			DoUpdates: clause.AssignmentColumns([]string{"reason", "expire_at", "updated_at"}),
+		}).
+		CreateInBatches(&ss, batchSize).Error
+}
+
+func (d *DefaultSuppressionDAO) Delete(ctx context.Context, bizId uint64, receiverHash string, channel string) error {
+	return d.db.WithContext(ctx).
+		Where("biz_id = ? AND receiver_hash = ? AND channel = ?", bizId, receiverHash, channel).
+		Delete(&Suppression{}).Error
+}
+
+func (d *DefaultSuppressionDAO) FindActive(ctx context.Context, bizId uint64, channel string, receiverHashes []string) ([]Suppression, error) {
+	var ss []Suppression
+	if len(receiverHashes) == 0 {
+		return ss, nil
+	}
+
+	err := d.db.WithContext(ctx).
+		Where("receiver_hash IN ? AND channel = ? AND biz_id IN ?", receiverHashes, channel, []uint64{0, bizId}).
+		Where("expire_at = 0 OR expire_at > ?", time.Now().UnixMilli()).
+		Find(&ss).Error
+	return ss, err
+}
+
+func (d *DefaultSuppressionDAO) List(ctx context.Context, bizId uint64, receiverHash string, offset int, limit int) ([]Suppression, error) {
+	var ss []Suppression
+
+	db := d.db.WithContext(ctx).Where("biz_id = ?", bizId)
+	if receiverHash != "" {
+		db = db.Where("receiver_hash = ?", receiverHash)
+	}
+	err := db.Order("id").Offset(offset).Limit(limit).Find(&ss).Error
+	return ss, err
+}
+
+func NewDefaultSuppressionDAO(db *gorm.DB) *DefaultSuppressionDAO {
+	return &DefaultSuppressionDAO{
+		db: db,
+	}
+}
@@ -4,9 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
+	"github.com/JrMarcco/jotify/internal/domain"
 	"github.com/JrMarcco/jotify/internal/errs"
+	"github.com/JrMarcco/jotify/internal/pkg/xsql"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // ChannelTpl 渠道模板
@@ -52,6 +56,7 @@ type ChannelTplProvider struct {
 	Id              uint64
 	TplId           uint64
 	TplVersionId    uint64
+	Locale          string
 	ProviderId      uint64
 	ProviderName    string
 	ProviderChannel string
@@ -68,12 +73,66 @@ func (c ChannelTplProvider) TableName() string {
 	return "channel_tpl_provider"
 }
 
+// ChannelTplVariant 渠道模板版本语言变体
+type ChannelTplVariant struct {
+	Id           uint64
+	TplId        uint64
+	TplVersionId uint64
+	Locale       string
+	Signature    string
+	Content      string
+	AuditId      uint64
+	AuditorId    uint64
+	AuditAt      int64
+	AuditStatus  string
+	RejectReason string
+	CreatedAt    int64
+	UpdatedAt    int64
+}
+
+func (c ChannelTplVariant) TableName() string {
+	return "channel_tpl_variant"
+}
+
+// ChannelTplAudit 渠道模板版本审核记录
+type ChannelTplAudit struct {
+	Id           uint64
+	TplId        uint64
+	TplVersionId uint64
+	TplVariantId uint64
+	Stage        string
+	Status       string
+	Reasons      xsql.JsonColumn[[]string]
+	AuditorId    uint64
+	CreatedAt    int64
+}
+
+func (c ChannelTplAudit) TableName() string {
+	return "channel_tpl_audit"
+}
+
 var _ ChannelTplDAO = (*DefaultChannelTplDAO)(nil)
 
 type ChannelTplDAO interface {
 	GetById(ctx context.Context, id uint64) (ChannelTpl, error)
 	GetVersionsById(ctx context.Context, versionId uint64) (ChannelTplVersion, error)
 	GetVersionsByIds(ctx context.Context, versionIds []uint64) ([]ChannelTplVersion, error)
+
+	// FindVersionsByAuditStatus 按 id 升序查询 id 大于 startId 的指定审核状态的版本
+	FindVersionsByAuditStatus(ctx context.Context, status string, startId uint64, limit int) ([]ChannelTplVersion, error)
+	// Audit 记录审核结果并更新版本（TplVariantId 不为 0 时为语言变体）的审核状态，
+	// 当前的审核状态不为 from 时返回 errs.ErrTplAuditConflict
+	Audit(ctx context.Context, from string, a ChannelTplAudit, rejectReason string) (ChannelTplAudit, error)
+	// FindAudits 按时间顺序查询版本及其语言变体的审核记录
+	FindAudits(ctx context.Context, versionId uint64) ([]ChannelTplAudit, error)
+
+	// SaveVariant 保存语言变体，版本已有该语言的变体时替换签名与内容并重置为待审核
+	SaveVariant(ctx context.Context, v ChannelTplVariant) (ChannelTplVariant, error)
+	GetVariantById(ctx context.Context, id uint64) (ChannelTplVariant, error)
+	// FindVariants 查询版本的全部语言变体
+	FindVariants(ctx context.Context, versionId uint64) ([]ChannelTplVariant, error)
+	// FindVariantsByAuditStatus 按 id 升序查询 id 大于 startId 的指定审核状态的语言变体
+	FindVariantsByAuditStatus(ctx context.Context, status string, startId uint64, limit int) ([]ChannelTplVariant, error)
 }
 
 type DefaultChannelTplDAO struct {
@@ -115,6 +174,138 @@ func (d *DefaultChannelTplDAO) GetVersionsByIds(ctx context.Context, versionIds
 	return versions, nil
 }
 
+func (d *DefaultChannelTplDAO) FindVersionsByAuditStatus(
+	ctx context.Context, status string, startId uint64, limit int,
+) ([]ChannelTplVersion, error) {
+	var versions []ChannelTplVersion
+	err := d.db.WithContext(ctx).
+		Where("audit_status = ? AND id > ?", status, startId).
+		Order("id").
+		Limit(limit).
+		Find(&versions).Error
+	return versions, err
+}
+
+func (d *DefaultChannelTplDAO) Audit(
+	ctx context.Context, from string, a ChannelTplAudit, rejectReason string,
+) (ChannelTplAudit, error) {
+	now := time.Now().UnixMilli()
+	a.CreatedAt = now
+
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&a).Error; err != nil {
+			return err
+		}
+
+		updates := map[string]any{
+			"audit_id":      a.Id,
+			"auditor_id":    a.AuditorId,
+			"audit_at":      now,
+			"audit_status":  a.Status,
+			"reject_reason": rejectReason,
+			"updated_at":    now,
+		}
+
+		var res *gorm.DB
+		if a.TplVariantId > 0 {
+			res = tx.Model(&ChannelTplVariant{}).
+				Where("id = ? AND audit_status = ?", a.TplVariantId, from).
+				Updates(updates)
+		} else {
+			updates["last_review_at"] = now
+			res = tx.Model(&ChannelTplVersion{}).
+				Where("id = ? AND audit_status = ?", a.TplVersionId, from).
+				Updates(updates)
+		}
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return fmt.Errorf(
+				"%w: version id = %d, variant id = %d, expected status = %s",
+				errs.ErrTplAuditConflict, a.TplVersionId, a.TplVariantId, from,
+			)
+		}
+		return nil
+	})
+	if err != nil {
+		return ChannelTplAudit{}, err
+	}
+	return a, nil
+}
+
+func (d *DefaultChannelTplDAO) FindAudits(ctx context.Context, versionId uint64) ([]ChannelTplAudit, error) {
+	var audits []ChannelTplAudit
+	err := d.db.WithContext(ctx).
+		Where("tpl_version_id = ?", versionId).
+		Order("id").
+		Find(&audits).Error
+	return audits, err
+}
+
+func (d *DefaultChannelTplDAO) SaveVariant(ctx context.Context, v ChannelTplVariant) (ChannelTplVariant, error) {
+	now := time.Now().UnixMilli()
+	v.AuditStatus = string(domain.AuditStatusPending)
+	v.CreatedAt = now
+	v.UpdatedAt = now
+
+	err := d.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "tpl_version_id"}, {Name: "locale"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"signature":     v.Signature,
+			"content":       v.Content,
+			"audit_id":      0,
+			"auditor_id":    0,
+			"audit_at":      0,
+			"audit_status":  v.AuditStatus,
+			"reject_reason": "",
+			"updated_at":    now,
+		}),
+	}).Create(&v).Error
+	if err != nil {
+		return ChannelTplVariant{}, err
+	}
+
+	// 替换已有变体时 MySQL 不回填 id，重新查询保存后的变体
+	var saved ChannelTplVariant
+	err = d.db.WithContext(ctx).
+		Where("tpl_version_id = ? AND locale = ?", v.TplVersionId, v.Locale).
+		First(&saved).Error
+	return saved, err
+}
+
+func (d *DefaultChannelTplDAO) GetVariantById(ctx context.Context, id uint64) (ChannelTplVariant, error) {
+	var variant ChannelTplVariant
+	if err := d.db.WithContext(ctx).Where("id = ?", id).First(&variant).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ChannelTplVariant{}, fmt.Errorf("%w", errs.ErrChannelTplVariantNotFound)
+		}
+		return ChannelTplVariant{}, err
+	}
+	return variant, nil
+}
+
+func (d *DefaultChannelTplDAO) FindVariants(ctx context.Context, versionId uint64) ([]ChannelTplVariant, error) {
+	var variants []ChannelTplVariant
+	err := d.db.WithContext(ctx).
+		Where("tpl_version_id = ?", versionId).
+		Order("id").
+		Find(&variants).Error
+	return variants, err
+}
+
+func (d *DefaultChannelTplDAO) FindVariantsByAuditStatus(
+	ctx context.Context, status string, startId uint64, limit int,
+) ([]ChannelTplVariant, error) {
+	var variants []ChannelTplVariant
+	err := d.db.WithContext(ctx).
+		Where("audit_status = ? AND id > ?", status, startId).
+		Order("id").
+		Limit(limit).
+		Find(&variants).Error
+	return variants, err
+}
+
 func NewDefaultChannelTplDAO(db *gorm.DB) *DefaultChannelTplDAO {
 	return &DefaultChannelTplDAO{
 		db: db,
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/JrMarcco/jotify/internal/pkg/envelope"
+	"github.com/JrMarcco/jotify/internal/repository/dao"
+	"gorm.io/gorm"
+)
+
+var _ envelope.DataKeyStore = (*DefaultDataKeyRepo)(nil)
+
+// DefaultDataKeyRepo 基于 biz_data_key 表的数据密钥存储
+type DefaultDataKeyRepo struct {
+	dataKeyDAO dao.DataKeyDAO
+}
+
+func (d *DefaultDataKeyRepo) Latest(ctx context.Context, bizId uint64) (envelope.DataKey, error) {
+	entity, err := d.dataKeyDAO.GetLatest(ctx, bizId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return envelope.DataKey{}, fmt.Errorf("%w: biz id = %d", envelope.ErrDataKeyNotFound, bizId)
+		}
+		return envelope.DataKey{}, err
+	}
+	return d.toDomain(entity), nil
+}
+
+func (d *DefaultDataKeyRepo) Get(ctx context.Context, bizId uint64, version uint32) (envelope.DataKey, error) {
+	entity, err := d.dataKeyDAO.Get(ctx, bizId, version)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return envelope.DataKey{}, fmt.Errorf("%w: biz id = %d, version = %d", envelope.ErrDataKeyNotFound, bizId, version)
+		}
+		return envelope.DataKey{}, err
+	}
+	return d.toDomain(entity), nil
+}
+
+func (d *DefaultDataKeyRepo) Create(ctx context.Context, key envelope.DataKey) error {
+	err := d.dataKeyDAO.Create(ctx, d.toEntity(key))
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return fmt.Errorf("%w: biz id = %d, version = %d", envelope.ErrDataKeyConflict, key.BizId, key.Version)
+	}
+	return err
+}
+
+func (d *DefaultDataKeyRepo) FindNotWrappedBy(ctx context.Context, masterKeyId string, limit int) ([]envelope.DataKey, error) {
+	entities, err := d.dataKeyDAO.FindNotWrappedBy(ctx, masterKeyId, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]envelope.DataKey, 0, len(entities))
+	for _, entity := range entities {
+		keys = append(keys, d.toDomain(entity))
+	}
+	return keys, nil
+}
+
+func (d *DefaultDataKeyRepo) Rewrap(ctx context.Context, key envelope.DataKey, prevMasterKeyId string) error {
+	return d.dataKeyDAO.CompareAndSwapWrappedKey(ctx, d.toEntity(key), prevMasterKeyId)
+}
+
+func (d *DefaultDataKeyRepo) toEntity(key envelope.DataKey) dao.BizDataKey {
+	return dao.BizDataKey{
+		BizId:       key.BizId,
+		Version:     key.Version,
+		MasterKeyId: key.MasterKeyId,
+		WrappedKey:  key.WrappedKey,
+	}
+}
+
+func (d *DefaultDataKeyRepo) toDomain(entity dao.BizDataKey) envelope.DataKey {
+	return envelope.DataKey{
+		BizId:       entity.BizId,
+		Version:     entity.Version,
+		MasterKeyId: entity.MasterKeyId,
+		WrappedKey:  entity.WrappedKey,
+	}
+}
+
+func NewDefaultDataKeyRepo(dataKeyDAO dao.DataKeyDAO) *DefaultDataKeyRepo {
+	return &DefaultDataKeyRepo{
+		dataKeyDAO: dataKeyDAO,
+	}
+}
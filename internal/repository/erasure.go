@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/errs"
+	"github.com/JrMarcco/jotify/internal/pkg/envelope"
+	"github.com/JrMarcco/jotify/internal/pkg/privacy"
+	"github.com/JrMarcco/jotify/internal/pkg/xsql"
+	"github.com/JrMarcco/jotify/internal/repository/dao"
+	"gorm.io/gorm"
+)
+
+// RedactFunc 根据消息当前的接收者与模板参数返回擦除后的取值
+type RedactFunc func(receivers []string, params map[string]string) ([]string, map[string]string)
+
+type ErasureRepo interface {
+	// FindNotificationIds 通过接收者索引查找消息 id，bizId 为 0 时查找全部业务方
+	FindNotificationIds(ctx context.Context, bizId uint64, receiverHash string) ([]uint64, error)
+	Redact(ctx context.Context, id uint64, fn RedactFunc) error
+	DeleteReceiverIndex(ctx context.Context, receiverHash string, ids []uint64) error
+
+	CreateReceipt(ctx context.Context, receipt domain.ErasureReceipt) (domain.ErasureReceipt, error)
+	GetReceipt(ctx context.Context, id uint64) (domain.ErasureReceipt, error)
+}
+
+var _ ErasureRepo = (*DefaultErasureRepo)(nil)
+
+type DefaultErasureRepo struct {
+	erasureDAO dao.ErasureDAO
+	receiptDAO dao.ErasureReceiptDAO
+	cipher     *envelope.Cipher
+	hasher     *privacy.Hasher
+}
+
+func (d *DefaultErasureRepo) FindNotificationIds(ctx context.Context, bizId uint64, receiverHash string) ([]uint64, error) {
+	indexes, err := d.erasureDAO.FindByReceiverHash(ctx, bizId, receiverHash)
+	if err != nil {
+		return nil, err
+	}
+
+	// 迁移期间新旧布局中可能存在同一条索引
+	ids := make([]uint64, 0, len(indexes))
+	seen := make(map[uint64]struct{}, len(indexes))
+	for _, index := range indexes {
+		if _, ok := seen[index.NotificationId]; ok {
+			continue
+		}
+		seen[index.NotificationId] = struct{}{}
+		ids = append(ids, index.NotificationId)
+	}
+	return ids, nil
+}
+
+// Redact 擦除消息的个人数据，加密保存的消息擦除后重新加密
+func (d *DefaultErasureRepo) Redact(ctx context.Context, id uint64, fn RedactFunc) error {
+	return d.erasureDAO.Redact(ctx, id, func(n *dao.Notification) error {
+		encrypted := envelope.IsEncrypted(n.Receivers) || envelope.IsEncrypted(n.TplParams)
+
+		receiversVal, err := d.cipher.Decrypt(ctx, n.BizId, n.Receivers)
+		if err != nil {
+			return err
+		}
+		paramsVal, err := d.cipher.Decrypt(ctx, n.BizId, n.TplParams)
+		if err != nil {
+			return err
+		}
+
+		var receivers []string
+		if err = json.Unmarshal([]byte(receiversVal), &receivers); err != nil {
+			return fmt.Errorf("failed to unmarshal receivers of notification %d: %w", id, err)
+		}
+		var params map[string]string
+		if err = json.Unmarshal([]byte(paramsVal), &params); err != nil {
+			return fmt.Errorf("failed to unmarshal template params of notification %d: %w", id, err)
+		}
+
+		receivers, params = fn(receivers, params)
+
+		receiversBytes, err := json.Marshal(receivers)
+		if err != nil {
+			return err
+		}
+		paramsBytes, err := json.Marshal(params)
+		if err != nil {
+			return err
+		}
+		receiversVal, paramsVal = string(receiversBytes), string(paramsBytes)
+
+		if encrypted {
+			if receiversVal, err = d.cipher.Encrypt(ctx, n.BizId, receiversVal); err != nil {
+				return err
+			}
+			if paramsVal, err = d.cipher.Encrypt(ctx, n.BizId, paramsVal); err != nil {
+				return err
+			}
+		}
+		n.Receivers, n.TplParams = receiversVal, paramsVal
+		n.ReceiversHash = d.hasher.HashAll(receivers)
+		return nil
+	})
+}
+
+func (d *DefaultErasureRepo) DeleteReceiverIndex(ctx context.Context, receiverHash string, ids []uint64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return d.erasureDAO.DeleteReceiverIndex(ctx, receiverHash, ids)
+}
+
+func (d *DefaultErasureRepo) CreateReceipt(ctx context.Context, receipt domain.ErasureReceipt) (domain.ErasureReceipt, error) {
+	receipt.CreatedAt = time.Now().UnixMilli()
+	entity, err := d.receiptDAO.Create(ctx, dao.ErasureReceipt{
+		BizId:           receipt.BizId,
+		ReceiverHash:    receipt.ReceiverHash,
+		NotificationIds: xsql.JsonColumn[[]uint64]{Val: receipt.NotificationIds, Valid: true},
+		OperatorBizId:   receipt.OperatorBizId,
+		CreatedAt:       receipt.CreatedAt,
+	})
+	if err != nil {
+		return domain.ErasureReceipt{}, err
+	}
+	return d.toDomain(entity), nil
+}
+
+func (d *DefaultErasureRepo) GetReceipt(ctx context.Context, id uint64) (domain.ErasureReceipt, error) {
+	entity, err := d.receiptDAO.GetById(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErasureReceipt{}, fmt.Errorf("%w: id = %d", errs.ErrErasureReceiptNotFound, id)
+		}
+		return domain.ErasureReceipt{}, err
+	}
+	return d.toDomain(entity), nil
+}
+
+func (d *DefaultErasureRepo) toDomain(entity dao.ErasureReceipt) domain.ErasureReceipt {
+	ids := entity.NotificationIds.Val
+	if ids == nil {
+		ids = []uint64{}
+	}
+	return domain.ErasureReceipt{
+		Id:              entity.Id,
+		BizId:           entity.BizId,
+		ReceiverHash:    entity.ReceiverHash,
+		NotificationIds: ids,
+		OperatorBizId:   entity.OperatorBizId,
+		CreatedAt:       entity.CreatedAt,
+	}
+}
+
+func NewDefaultErasureRepo(
+	erasureDAO dao.ErasureDAO, receiptDAO dao.ErasureReceiptDAO, cipher *envelope.Cipher, hasher *privacy.Hasher,
+) *DefaultErasureRepo {
+	return &DefaultErasureRepo{
+		erasureDAO: erasureDAO,
+		receiptDAO: receiptDAO,
+		cipher:     cipher,
+		hasher:     hasher,
+	}
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/pkg/privacy"
+	"github.com/JrMarcco/jotify/internal/repository/cache"
+)
+
+type FrequencyRepo interface {
+	// Acquire 为消息的每个接收者在适用的频控规则下按 at 所在的周期计数，返回达到上限的接收者，这些接收者不计数
+	Acquire(ctx context.Context, n domain.Notification, bizType domain.BizType, conf *domain.DeliveryConf, at time.Time) ([]string, error)
+	// Release 释放 Acquire 的计数，at 为计数的时间，n 中只应包含计数成功的接收者
+	Release(ctx context.Context, n domain.Notification, bizType domain.BizType, conf *domain.DeliveryConf, at time.Time) error
+}
+
+var _ FrequencyRepo = (*DefaultFrequencyRepo)(nil)
+
+type DefaultFrequencyRepo struct {
+	frequencyCache cache.FrequencyCache
+	hasher         *privacy.Hasher
+}
+
+func (d *DefaultFrequencyRepo) Acquire(
+	ctx context.Context, n domain.Notification, bizType domain.BizType, conf *domain.DeliveryConf, at time.Time,
+) ([]string, error) {
+	groups := d.buildParams(n, bizType, conf, at)
+	if len(groups) == 0 {
+		return nil, nil
+	}
+
+	oks, err := d.frequencyCache.Acquire(ctx, groups)
+	if err != nil {
+		return nil, err
+	}
+
+	var capped []string
+	for i, ok := range oks {
+		if !ok {
+			capped = append(capped, n.Receivers[i])
+		}
+	}
+	return capped, nil
+}
+
+func (d *DefaultFrequencyRepo) Release(
+	ctx context.Context, n domain.Notification, bizType domain.BizType, conf *domain.DeliveryConf, at time.Time,
+) error {
+	// 按计数时的周期释放，周期已结束的计数已过期，释放不会小于 0
+	var params []cache.FrequencyParam
+	for _, group := range d.buildParams(n, bizType, conf, at) {
+		params = append(params, group...)
+	}
+	return d.frequencyCache.Release(ctx, params)
+}
+
+// buildParams 按接收者分组构造计数参数，没有适用的频控规则时返回 nil
+func (d *DefaultFrequencyRepo) buildParams(
+	n domain.Notification, bizType domain.BizType, conf *domain.DeliveryConf, now time.Time,
+) [][]cache.FrequencyParam {
+	if conf == nil {
+		return nil
+	}
+	caps := conf.CapsOf(bizType, n.Channel)
+	if len(caps) == 0 {
+		return nil
+	}
+
+	groups := make([][]cache.FrequencyParam, 0, len(n.Receivers))
+	for _, receiver := range n.Receivers {
+		hash := d.hasher.Hash(receiver)
+		local := now.In(conf.LocationOf(receiver))
+
+		group := make([]cache.FrequencyParam, 0, len(caps))
+		for _, fc := range caps {
+			// 未指定渠道的规则在全部渠道之间共享计数
+			channel := fc.Channel.String()
+			if channel == "" {
+				channel = "all"
+			}
+			group = append(group, cache.FrequencyParam{
+				Key: fmt.Sprintf(
+					"freq:%d:%s:%s:%s:%d:%s",
+					n.BizId, bizType, channel, fc.Period, fc.Period.Start(local).Unix(), hash,
+				),
+				Limit: fc.Limit,
+				TTL:   fc.Period.Duration(),
+			})
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+func NewDefaultFrequencyRepo(frequencyCache cache.FrequencyCache, hasher *privacy.Hasher) *DefaultFrequencyRepo {
+	return &DefaultFrequencyRepo{
+		frequencyCache: frequencyCache,
+		hasher:         hasher,
+	}
+}
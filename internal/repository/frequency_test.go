@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/pkg/calendar"
+	"github.com/JrMarcco/jotify/internal/pkg/privacy"
+	"github.com/JrMarcco/jotify/internal/repository/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ cache.FrequencyCache = (*fakeFrequencyCache)(nil)
+
+// fakeFrequencyCache 按 key 计数，记录每次调用的参数
+type fakeFrequencyCache struct {
+	counts   map[string]int32
+	acquired [][]cache.FrequencyParam
+	released []cache.FrequencyParam
+}
+
+func newFakeFrequencyCache() *fakeFrequencyCache {
+	return &fakeFrequencyCache{counts: make(map[string]int32)}
+}
+
+func (c *fakeFrequencyCache) Acquire(_ context.Context, groups [][]cache.FrequencyParam) ([]bool, error) {
+	c.acquired = groups
+	oks := make([]bool, 0, len(groups))
+	for _, group := range groups {
+		ok := true
+		for _, param := range group {
+			if c.counts[param.Key] >= param.Limit {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			for _, param := range group {
+				c.counts[param.Key]++
+			}
+		}
+		oks = append(oks, ok)
+	}
+	return oks, nil
+}
+
+func (c *fakeFrequencyCache) Release(_ context.Context, params []cache.FrequencyParam) error {
+	c.released = append(c.released, params...)
+	for _, param := range params {
+		if c.counts[param.Key] > 0 {
+			c.counts[param.Key]--
+		}
+	}
+	return nil
+}
+
+func TestDefaultFrequencyRepo_Acquire(t *testing.T) {
+	t.Parallel()
+
+	hasher, err := privacy.NewHasher("key")
+	require.NoError(t, err)
+
+	at := time.Date(2026, 10, 19, 15, 30, 0, 0, time.UTC)
+	conf := &domain.DeliveryConf{
+		Timezone:          "Asia/Shanghai",
+		ReceiverTimezones: map[string]string{"+1": "America/New_York"},
+		FrequencyCaps: []domain.FrequencyCap{
+			{BizType: domain.BizTypePromotion, Period: calendar.PeriodDay, Limit: 2},
+			{BizType: domain.BizTypePromotion, Channel: domain.ChannelSMS, Period: calendar.PeriodHour, Limit: 1},
+			{BizType: domain.BizTypeNotification, Period: calendar.PeriodDay, Limit: 1},
+		},
+	}
+
+	tcs := []struct {
+		name       string
+		bizType    domain.BizType
+		channel    domain.Channel
+		receivers  []string
+		rounds     int
+		wantCapped []string
+		wantGroups int
+		wantKeys   int
+	}{
+		{
+			name:       "hourly cap reached in second round",
+			bizType:    domain.BizTypePromotion,
+			channel:    domain.ChannelSMS,
+			receivers:  []string{"13800000000", "+12025550100"},
+			rounds:     2,
+			wantCapped: []string{"13800000000", "+12025550100"},
+			wantGroups: 2,
+			wantKeys:   2,
+		}, {
+			name:       "channel cap not applied to email",
+			bizType:    domain.BizTypePromotion,
+			channel:    domain.ChannelEmail,
+			receivers:  []string{"foo@example.com"},
+			rounds:     2,
+			wantGroups: 1,
+			wantKeys:   1,
+		}, {
+			name:       "verify code not counted",
+			bizType:    domain.BizTypeVerifyCode,
+			channel:    domain.ChannelSMS,
+			receivers:  []string{"13800000000"},
+			rounds:     3,
+			wantGroups: 0,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fc := newFakeFrequencyCache()
+			repo := NewDefaultFrequencyRepo(fc, hasher)
+			n := domain.Notification{BizId: 1, Channel: tc.channel, Receivers: tc.receivers}
+
+			var capped []string
+			for range tc.rounds {
+				var err error
+				capped, err = repo.Acquire(t.Context(), n, tc.bizType, conf, at)
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tc.wantCapped, capped)
+
+			require.Len(t, fc.acquired, tc.wantGroups)
+			for _, group := range fc.acquired {
+				assert.Len(t, group, tc.wantKeys)
+			}
+		})
+	}
+}
+
+func TestDefaultFrequencyRepo_Acquire_Keys(t *testing.T) {
+	t.Parallel()
+
+	hasher, err := privacy.NewHasher("key")
+	require.NoError(t, err)
+
+	// 上海时间 2026-10-20 01:30，纽约时间 2026-10-19 13:30
+	at := time.Date(2026, 10, 19, 17, 30, 0, 0, time.UTC)
+	conf := &domain.DeliveryConf{
+		Timezone:          "Asia/Shanghai",
+		ReceiverTimezones: map[string]string{"+1": "America/New_York"},
+		FrequencyCaps: []domain.FrequencyCap{
+			{BizType: domain.BizTypePromotion, Period: calendar.PeriodDay, Limit: 2},
+			{BizType: domain.BizTypePromotion, Channel: domain.ChannelSMS, Period: calendar.PeriodHour, Limit: 1},
+		},
+	}
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	require.NoError(t, err)
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	fc := newFakeFrequencyCache()
+	repo := NewDefaultFrequencyRepo(fc, hasher)
+	n := domain.Notification{BizId: 1, Channel: domain.ChannelSMS, Receivers: []string{"13800000000", "+12025550100"}}
+	_, err = repo.Acquire(t.Context(), n, domain.BizTypePromotion, conf, at)
+	require.NoError(t, err)
+
+	want := [][]cache.FrequencyParam{
+		{
+			{
+				Key: fmt.Sprintf(
+					"freq:1:%s:all:day:%d:%s", domain.BizTypePromotion,
+					time.Date(2026, 10, 20, 0, 0, 0, 0, shanghai).Unix(), hasher.Hash("13800000000"),
+				),
+				Limit: 2,
+				TTL:   24 * time.Hour,
+			}, {
+				Key: fmt.Sprintf(
+					"freq:1:%s:sms:hour:%d:%s", domain.BizTypePromotion,
+					time.Date(2026, 10, 20, 1, 0, 0, 0, shanghai).Unix(), hasher.Hash("13800000000"),
+				),
+				Limit: 1,
+				TTL:   time.Hour,
+			},
+		}, {
+			{
+				Key: fmt.Sprintf(
+					"freq:1:%s:all:day:%d:%s", domain.BizTypePromotion,
+					time.Date(2026, 10, 19, 0, 0, 0, 0, newYork).Unix(), hasher.Hash("+12025550100"),
+				),
+				Limit: 2,
+				TTL:   24 * time.Hour,
+			}, {
+				Key: fmt.Sprintf(
+					"freq:1:%s:sms:hour:%d:%s", domain.BizTypePromotion,
+					time.Date(2026, 10, 19, 13, 0, 0, 0, newYork).Unix(), hasher.Hash("+12025550100"),
+				),
+				Limit: 1,
+				TTL:   time.Hour,
+			},
+		},
+	}
+	assert.Equal(t, want, fc.acquired)
+
+	// 按计数时间释放同一组 key
+	require.NoError(t, repo.Release(t.Context(), n, domain.BizTypePromotion, conf, at))
+	assert.Equal(t, append(want[0], want[1]...), fc.released)
+	for _, count := range fc.counts {
+		assert.Zero(t, count)
+	}
+}
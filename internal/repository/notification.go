@@ -9,6 +9,8 @@ import (
 	"github.com/JrMarcco/easy-kit/slice"
 	"github.com/JrMarcco/easy-kit/xmap"
 	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/pkg/privacy"
+	"github.com/JrMarcco/jotify/internal/pkg/sharding"
 	"github.com/JrMarcco/jotify/internal/repository/cache"
 	"github.com/JrMarcco/jotify/internal/repository/cache/redis"
 	"github.com/JrMarcco/jotify/internal/repository/dao"
@@ -24,12 +26,31 @@ type NotificationRepo interface {
 	MarkSuccess(ctx context.Context, n domain.Notification) error
 	MarkFailure(ctx context.Context, n domain.Notification) error
 
+	GetById(ctx context.Context, id uint64) (domain.Notification, error)
 	GetMapByIds(ctx context.Context, ids []uint64) (map[uint64]domain.Notification, error)
 	GetByKey(ctx context.Context, bizId uint64, bizKey string) (domain.Notification, error)
 
 	CompareAndSwapStatus(ctx context.Context, n domain.Notification) error
-
-	FindReady(ctx context.Context, offset int, limit int) ([]domain.Notification, error)
+	Cancel(ctx context.Context, n domain.Notification) error
+	MarkSending(ctx context.Context, n domain.Notification) error
+	// Defer 推迟发送，将状态更新为 pending，n.ScheduledStart 与 n.ScheduledEnd 为新的发送时间窗口
+	Defer(ctx context.Context, n domain.Notification) error
+
+	// CountReady 按业务方与优先级统计上下文中分片内处于发送时间窗口中的待发送消息数
+	CountReady(ctx context.Context) ([]domain.ReadyBacklog, error)
+	// FindReady 按计划发送时间顺序查找上下文中分片内处于发送时间窗口中、优先级为 priority 且属于 bizId 的待发送消息
+	FindReady(ctx context.Context, priority domain.Priority, bizId uint64, offset int, limit int) ([]domain.Notification, error)
+	// FindExpired 按 id 顺序查找分片中 id 大于 startId 且发送时间窗口在 before 之前结束的待发送消息，只包含 id、biz_id 与渠道
+	FindExpired(ctx context.Context, dst sharding.Dst, startId uint64, before time.Time, limit int) ([]domain.Notification, error)
+	// Expire 将仍为待发送且已过期的消息标记为过期并退还配额，返回被标记的消息
+	Expire(ctx context.Context, dst sharding.Dst, ids []uint64, before time.Time) ([]domain.Notification, error)
+
+	// ExistingKeys 返回 bizKeys 中业务方已创建消息的 biz_key
+	ExistingKeys(ctx context.Context, bizId uint64, bizKeys []string) (map[string]struct{}, error)
+	// CountByCampaign 按状态统计群发活动的消息数
+	CountByCampaign(ctx context.Context, campaignId uint64) (map[domain.SendStatus]int64, error)
+	// FindPendingByCampaign 查找群发活动的待发送消息，每个分片最多 limit 条
+	FindPendingByCampaign(ctx context.Context, campaignId uint64, limit int) ([]domain.Notification, error)
 }
 
 const (
@@ -41,7 +62,14 @@ var _ NotificationRepo = (*DefaultNotifRepo)(nil)
 type DefaultNotifRepo struct {
 	notifDAO   dao.NotificationDAO
 	quotaCache *redis.QuotaRedisCache
-	logger     *zap.Logger
+	hasher     *privacy.Hasher
+
+	// 取消或过期的消息未实际发送，释放创建前的频控计数
+	frequencyRepo FrequencyRepo
+	bizConfRepo   BizConfRepo
+	tplRepo       ChannelTplRepo
+
+	logger *zap.Logger
 }
 
 func (d *DefaultNotifRepo) Create(ctx context.Context, n domain.Notification) (domain.Notification, error) {
@@ -203,6 +231,14 @@ func (d *DefaultNotifRepo) MarkFailure(ctx context.Context, n domain.Notificatio
 	})
 }
 
+func (d *DefaultNotifRepo) GetById(ctx context.Context, id uint64) (domain.Notification, error) {
+	n, err := d.notifDAO.GetById(ctx, id)
+	if err != nil {
+		return domain.Notification{}, err
+	}
+	return d.toDomain(n), nil
+}
+
 func (d *DefaultNotifRepo) GetMapByIds(ctx context.Context, ids []uint64) (map[uint64]domain.Notification, error) {
 	entityMap, err := d.notifDAO.GetMapByIds(ctx, ids)
 	if err != nil {
@@ -229,8 +265,204 @@ func (d *DefaultNotifRepo) CompareAndSwapStatus(ctx context.Context, n domain.No
 	return d.notifDAO.CompareAndSwapStatus(ctx, d.toEntity(n))
 }
 
-func (d *DefaultNotifRepo) FindReady(ctx context.Context, offset int, limit int) ([]domain.Notification, error) {
-	ns, err := d.notifDAO.FindReady(ctx, offset, limit)
+// Cancel 取消消息发送并退还配额与频控计数，n.Version 为读取消息时的版本号
+func (d *DefaultNotifRepo) Cancel(ctx context.Context, n domain.Notification) error {
+	n.Status = domain.SendStatusCancel
+	if err := d.notifDAO.CompareAndSwapStatus(ctx, d.toEntity(n)); err != nil {
+		return err
+	}
+
+	err := d.quotaCache.Incr(ctx, cache.QuotaParam{
+		BizId:   n.BizId,
+		Channel: n.Channel,
+		Quota:   defaultQuota,
+	})
+	if err != nil {
+		// 消息已取消，配额退还失败不影响取消结果
+		d.logger.Error(
+			"[jotify] failed to refund quota",
+			zap.Error(err),
+			zap.Uint64("biz_id", n.BizId),
+			zap.String("channel", string(n.Channel)),
+		)
+	}
+	d.releaseFrequency(ctx, []domain.Notification{n})
+	return nil
+}
+
+// MarkSending 重新发送前扣减配额并将状态更新为 sending，n.Version 为读取消息时的版本号
+func (d *DefaultNotifRepo) MarkSending(ctx context.Context, n domain.Notification) error {
+	quotaParam := cache.QuotaParam{
+		BizId:   n.BizId,
+		Channel: n.Channel,
+		Quota:   defaultQuota,
+	}
+	if err := d.quotaCache.Decr(ctx, quotaParam); err != nil {
+		return err
+	}
+
+	n.Status = domain.SendStatusSending
+	if err := d.notifDAO.CompareAndSwapStatus(ctx, d.toEntity(n)); err != nil {
+		// 状态更新失败则退还配额
+		if refundErr := d.quotaCache.Incr(ctx, quotaParam); refundErr != nil {
+			d.logger.Error(
+				"[jotify] failed to refund quota",
+				zap.Error(refundErr),
+				zap.Uint64("biz_id", n.BizId),
+				zap.String("channel", string(n.Channel)),
+			)
+		}
+		return err
+	}
+	return nil
+}
+
+// Defer 推迟发送，创建时扣减的配额保持不变，过期时由 Expire 退还，n.Version 为读取消息时的版本号
+func (d *DefaultNotifRepo) Defer(ctx context.Context, n domain.Notification) error {
+	n.Status = domain.SendStatusPending
+	return d.notifDAO.Reschedule(ctx, d.toEntity(n))
+}
+
+func (d *DefaultNotifRepo) CountReady(ctx context.Context) ([]domain.ReadyBacklog, error) {
+	backlogs, err := d.notifDAO.CountReady(ctx)
+	return slice.Map(backlogs, func(_ int, src dao.ReadyBacklog) domain.ReadyBacklog {
+		return domain.ReadyBacklog{
+			BizId:    src.BizId,
+			Priority: domain.Priority(src.Priority),
+			Count:    int(src.Cnt),
+		}
+	}), err
+}
+
+func (d *DefaultNotifRepo) FindReady(
+	ctx context.Context, priority domain.Priority, bizId uint64, offset int, limit int,
+) ([]domain.Notification, error) {
+	ns, err := d.notifDAO.FindReady(ctx, int8(priority), bizId, offset, limit)
+	return slice.Map(ns, func(_ int, src dao.Notification) domain.Notification {
+		return d.toDomain(src)
+	}), err
+}
+
+func (d *DefaultNotifRepo) FindExpired(
+	ctx context.Context, dst sharding.Dst, startId uint64, before time.Time, limit int,
+) ([]domain.Notification, error) {
+	ns, err := d.notifDAO.FindExpired(ctx, dst, startId, before.UnixMilli(), limit)
+	return slice.Map(ns, func(_ int, src dao.Notification) domain.Notification {
+		return d.toDomain(src)
+	}), err
+}
+
+// Expire 过期消息未实际发送，退还创建时扣减的配额与频控计数
+func (d *DefaultNotifRepo) Expire(
+	ctx context.Context, dst sharding.Dst, ids []uint64, before time.Time,
+) ([]domain.Notification, error) {
+	entities, err := d.notifDAO.Expire(ctx, dst, ids, before.UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+
+	ns := slice.Map(entities, func(_ int, src dao.Notification) domain.Notification {
+		return d.toDomain(src)
+	})
+	if len(ns) == 0 {
+		return ns, nil
+	}
+
+	if err = d.quotaCache.BatchIncr(ctx, d.buildQuotaParams(ns)); err != nil {
+		// 消息已过期，配额退还失败不影响过期结果
+		d.logger.Error("[jotify] failed to batch refund quota", zap.Error(err))
+	}
+	d.releaseFrequency(ctx, ns)
+	return ns, nil
+}
+
+// releaseFrequency 按消息创建时间所在的周期释放频控计数，失败时只记录日志。
+//
+// 释放按当前的频控规则计算，规则在创建后被修改时可能释放其他规则的计数，计数不会小于 0。
+func (d *DefaultNotifRepo) releaseFrequency(ctx context.Context, ns []domain.Notification) {
+	bizTypes := make(map[uint64]domain.BizType)
+	confs := make(map[uint64]*domain.DeliveryConf)
+	for _, n := range ns {
+		if len(n.Receivers) == 0 {
+			continue
+		}
+
+		bizType, ok := bizTypes[n.Template.Id]
+		if !ok {
+			tpl, err := d.tplRepo.GetById(ctx, n.Template.Id)
+			if err != nil {
+				d.logger.Error(
+					"[jotify] failed to get template when releasing frequency counts",
+					zap.Error(err),
+					zap.Uint64("tpl_id", n.Template.Id),
+				)
+				continue
+			}
+			bizType = tpl.BizType
+			bizTypes[n.Template.Id] = bizType
+		}
+		// 验证码消息不受频控限制
+		if bizType == domain.BizTypeVerifyCode {
+			continue
+		}
+
+		conf, ok := confs[n.BizId]
+		if !ok {
+			bizConf, err := d.bizConfRepo.GetById(ctx, n.BizId)
+			if err != nil {
+				d.logger.Error(
+					"[jotify] failed to get biz conf when releasing frequency counts",
+					zap.Error(err),
+					zap.Uint64("biz_id", n.BizId),
+				)
+				continue
+			}
+			conf = bizConf.DeliveryConf
+			confs[n.BizId] = conf
+		}
+		if conf == nil {
+			continue
+		}
+
+		if err := d.frequencyRepo.Release(ctx, n, bizType, conf, n.CreatedAt); err != nil {
+			d.logger.Error(
+				"[jotify] failed to release frequency counts",
+				zap.Error(err),
+				zap.Uint64("biz_id", n.BizId),
+				zap.String("biz_key", n.BizKey),
+			)
+		}
+	}
+}
+
+func (d *DefaultNotifRepo) ExistingKeys(ctx context.Context, bizId uint64, bizKeys []string) (map[string]struct{}, error) {
+	keys, err := d.notifDAO.ExistingKeys(ctx, bizId, bizKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		res[key] = struct{}{}
+	}
+	return res, nil
+}
+
+func (d *DefaultNotifRepo) CountByCampaign(ctx context.Context, campaignId uint64) (map[domain.SendStatus]int64, error) {
+	counts, err := d.notifDAO.CountByCampaign(ctx, campaignId)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[domain.SendStatus]int64, len(counts))
+	for status, cnt := range counts {
+		res[domain.SendStatus(status)] = cnt
+	}
+	return res, nil
+}
+
+func (d *DefaultNotifRepo) FindPendingByCampaign(ctx context.Context, campaignId uint64, limit int) ([]domain.Notification, error) {
+	ns, err := d.notifDAO.FindPendingByCampaign(ctx, campaignId, limit)
 	return slice.Map(ns, func(_ int, src dao.Notification) domain.Notification {
 		return d.toDomain(src)
 	}), err
@@ -239,19 +471,29 @@ func (d *DefaultNotifRepo) FindReady(ctx context.Context, offset int, limit int)
 func (d *DefaultNotifRepo) toEntity(n domain.Notification) dao.Notification {
 	tplParams, _ := n.MarshalTemplateParams()
 	receivers, _ := n.MarshalReceivers()
+	traceCtx, _ := n.MarshalTraceCtx()
 	return dao.Notification{
 		Id:            n.Id,
 		BizId:         n.BizId,
 		BizKey:        n.BizKey,
 		Receivers:     receivers,
+		ReceiversHash: d.hasher.HashAll(n.Receivers),
 		Channel:       n.Channel.String(),
 		TplId:         n.Template.Id,
 		TplVersionId:  n.Template.VersionId,
 		TplParams:     tplParams,
+		Locale:        n.Locale,
 		Status:        n.Status.String(),
+		Priority:      int8(n.Priority),
+		CampaignId:    n.CampaignId,
 		ScheduleStrat: n.ScheduledStart.UnixMilli(),
 		ScheduleEnd:   n.ScheduledEnd.UnixMilli(),
+		EndFixed:      n.EndFixed,
 		Version:       n.Version,
+		TraceCtx:      traceCtx,
+		ReceiverHashes: slice.Map(n.Receivers, func(_ int, receiver string) string {
+			return d.hasher.Hash(receiver)
+		}),
 	}
 }
 
@@ -268,6 +510,11 @@ func (d *DefaultNotifRepo) toDomain(entity dao.Notification) domain.Notification
 	var receivers []string
 	_ = json.Unmarshal([]byte(entity.Receivers), &receivers)
 
+	var traceCtx map[string]string
+	if entity.TraceCtx != "" {
+		_ = json.Unmarshal([]byte(entity.TraceCtx), &traceCtx)
+	}
+
 	return domain.Notification{
 		Id:        entity.Id,
 		BizId:     entity.BizId,
@@ -279,17 +526,35 @@ func (d *DefaultNotifRepo) toDomain(entity dao.Notification) domain.Notification
 			VersionId: entity.TplVersionId,
 			Params:    tplParams,
 		},
+		Locale:         entity.Locale,
 		Status:         domain.SendStatus(entity.Status),
+		Priority:       domain.Priority(entity.Priority),
+		CampaignId:     entity.CampaignId,
 		ScheduledStart: time.UnixMilli(entity.ScheduleStrat),
 		ScheduledEnd:   time.UnixMilli(entity.ScheduleEnd),
+		EndFixed:       entity.EndFixed,
 		Version:        entity.Version,
+		CreatedAt:      time.UnixMilli(entity.CreatedAt),
+		TraceCtx:       traceCtx,
 	}
 }
 
-func NewDefaultNotifRepo(notifDAO dao.NotificationDAO, quotaCache *redis.QuotaRedisCache, logger *zap.Logger) *DefaultNotifRepo {
+func NewDefaultNotifRepo(
+	notifDAO dao.NotificationDAO,
+	quotaCache *redis.QuotaRedisCache,
+	hasher *privacy.Hasher,
+	frequencyRepo FrequencyRepo,
+	bizConfRepo BizConfRepo,
+	tplRepo ChannelTplRepo,
+	logger *zap.Logger,
+) *DefaultNotifRepo {
 	return &DefaultNotifRepo{
-		notifDAO:   notifDAO,
-		quotaCache: quotaCache,
-		logger:     logger,
+		notifDAO:      notifDAO,
+		quotaCache:    quotaCache,
+		hasher:        hasher,
+		frequencyRepo: frequencyRepo,
+		bizConfRepo:   bizConfRepo,
+		tplRepo:       tplRepo,
+		logger:        logger,
 	}
 }
@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/pkg/calendar"
+	"github.com/JrMarcco/jotify/internal/pkg/privacy"
+	"github.com/JrMarcco/jotify/internal/pkg/sharding"
+	"github.com/JrMarcco/jotify/internal/repository/cache/redis"
+	"github.com/JrMarcco/jotify/internal/repository/dao"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeNotifDAO 取消总是成功，过期返回预设的消息
+type fakeNotifDAO struct {
+	dao.NotificationDAO
+	expired []dao.Notification
+}
+
+func (d *fakeNotifDAO) CompareAndSwapStatus(context.Context, dao.Notification) error {
+	return nil
+}
+
+func (d *fakeNotifDAO) Expire(context.Context, sharding.Dst, []uint64, int64) ([]dao.Notification, error) {
+	return d.expired, nil
+}
+
+// fakeQuotaClient 配额脚本总是执行成功
+type fakeQuotaClient struct {
+	goredis.Cmdable
+}
+
+func (c *fakeQuotaClient) Eval(context.Context, string, []string, ...any) *goredis.Cmd {
+	return goredis.NewCmdResult(int64(1), nil)
+}
+
+type fakeBizConfRepo struct {
+	BizConfRepo
+	conf domain.BizConf
+}
+
+func (r *fakeBizConfRepo) GetById(context.Context, uint64) (domain.BizConf, error) {
+	return r.conf, nil
+}
+
+type fakeTplRepo struct {
+	ChannelTplRepo
+	bizTypes map[uint64]domain.BizType
+}
+
+func (r *fakeTplRepo) GetById(_ context.Context, id uint64) (domain.ChannelTpl, error) {
+	return domain.ChannelTpl{Id: id, BizType: r.bizTypes[id]}, nil
+}
+
+func TestDefaultNotifRepo_ReleaseFrequency(t *testing.T) {
+	t.Parallel()
+
+	hasher, err := privacy.NewHasher("key")
+	require.NoError(t, err)
+
+	createdAt := time.Now()
+	conf := domain.BizConf{DeliveryConf: &domain.DeliveryConf{
+		FrequencyCaps: []domain.FrequencyCap{
+			{BizType: domain.BizTypePromotion, Period: calendar.PeriodDay, Limit: 1},
+		},
+	}}
+	tplRepo := &fakeTplRepo{bizTypes: map[uint64]domain.BizType{
+		1: domain.BizTypePromotion,
+		2: domain.BizTypeVerifyCode,
+	}}
+	receivers, err := json.Marshal([]string{"13800000000"})
+	require.NoError(t, err)
+
+	tcs := []struct {
+		name string
+		// 取消或过期模板为 tplId 的消息
+		run   func(t *testing.T, repo *DefaultNotifRepo, notifDAO *fakeNotifDAO, tplId uint64)
+		tplId uint64
+		// 释放后是否可以再次计数
+		wantReleased bool
+	}{
+		{
+			name: "cancel releases",
+			run: func(t *testing.T, repo *DefaultNotifRepo, _ *fakeNotifDAO, tplId uint64) {
+				err := repo.Cancel(t.Context(), domain.Notification{
+					Id:        1,
+					BizId:     1,
+					Receivers: []string{"13800000000"},
+					Channel:   domain.ChannelSMS,
+					Template:  domain.Template{Id: tplId},
+					CreatedAt: createdAt,
+				})
+				require.NoError(t, err)
+			},
+			tplId:        1,
+			wantReleased: true,
+		}, {
+			name: "expire releases",
+			run: func(t *testing.T, repo *DefaultNotifRepo, notifDAO *fakeNotifDAO, tplId uint64) {
+				notifDAO.expired = []dao.Notification{{
+					Id:        1,
+					BizId:     1,
+					Receivers: string(receivers),
+					Channel:   string(domain.ChannelSMS),
+					TplId:     tplId,
+					CreatedAt: createdAt.UnixMilli(),
+				}}
+				ns, err := repo.Expire(t.Context(), sharding.Dst{}, []uint64{1}, time.Now())
+				require.NoError(t, err)
+				require.Len(t, ns, 1)
+			},
+			tplId:        1,
+			wantReleased: true,
+		}, {
+			name: "verify code not released",
+			run: func(t *testing.T, repo *DefaultNotifRepo, _ *fakeNotifDAO, tplId uint64) {
+				err := repo.Cancel(t.Context(), domain.Notification{
+					Id:        1,
+					BizId:     1,
+					Receivers: []string{"13800000000"},
+					Channel:   domain.ChannelSMS,
+					Template:  domain.Template{Id: tplId},
+					CreatedAt: createdAt,
+				})
+				require.NoError(t, err)
+			},
+			tplId: 2,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fc := newFakeFrequencyCache()
+			frequencyRepo := NewDefaultFrequencyRepo(fc, hasher)
+			notifDAO := &fakeNotifDAO{}
+			repo := NewDefaultNotifRepo(
+				notifDAO,
+				redis.NewQuotaRedisCache(&fakeQuotaClient{}, zap.NewNop()),
+				hasher,
+				frequencyRepo,
+				&fakeBizConfRepo{conf: conf},
+				tplRepo,
+				zap.NewNop(),
+			)
+
+			// 创建时计数，达到每天一条的上限
+			n := domain.Notification{BizId: 1, Channel: domain.ChannelSMS, Receivers: []string{"13800000000"}}
+			capped, err := frequencyRepo.Acquire(t.Context(), n, domain.BizTypePromotion, conf.DeliveryConf, createdAt)
+			require.NoError(t, err)
+			require.Empty(t, capped)
+
+			tc.run(t, repo, notifDAO, tc.tplId)
+
+			capped, err = frequencyRepo.Acquire(t.Context(), n, domain.BizTypePromotion, conf.DeliveryConf, createdAt)
+			require.NoError(t, err)
+			if tc.wantReleased {
+				assert.Empty(t, capped)
+				return
+			}
+			assert.Equal(t, []string{"13800000000"}, capped)
+			assert.Empty(t, fc.released)
+		})
+	}
+}
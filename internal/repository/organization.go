@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/pkg/xsql"
+	"github.com/JrMarcco/jotify/internal/repository/cache"
+	"github.com/JrMarcco/jotify/internal/repository/dao"
+	"go.uber.org/zap"
+)
+
+type OrganizationRepo interface {
+	Create(ctx context.Context, o domain.Organization) (domain.Organization, error)
+	GetById(ctx context.Context, id uint64) (domain.Organization, error)
+	// Update 更新组织的默认配置，成员业务方在配置缓存过期后生效
+	Update(ctx context.Context, o domain.Organization) error
+
+	// FindMemberIds 查询组织的成员业务方 id
+	FindMemberIds(ctx context.Context, orgId uint64) ([]uint64, error)
+	// SetMember 将业务方加入组织，orgId 为 0 时将业务方移出组织
+	SetMember(ctx context.Context, bizId uint64, orgId uint64) error
+
+	// TopUp 向组织配额池增加配额，返回增加后的余额
+	TopUp(ctx context.Context, orgId uint64, channel domain.Channel, quota int64) (int64, error)
+	// Pool 返回组织配额池余额
+	Pool(ctx context.Context, orgId uint64, channel domain.Channel) (int64, error)
+	// Balances 返回业务方的配额余额
+	Balances(ctx context.Context, bizIds []uint64, channel domain.Channel) (map[uint64]int64, error)
+
+	GetAlloc(ctx context.Context, bizId uint64, channel domain.Channel) (domain.OrgQuotaAlloc, error)
+	// FindAllocs 查询组织在渠道上的全部分配
+	FindAllocs(ctx context.Context, orgId uint64, channel domain.Channel) ([]domain.OrgQuotaAlloc, error)
+	// SetCap 设置业务方在渠道上的配额余额上限
+	SetCap(ctx context.Context, a domain.OrgQuotaAlloc) error
+	// Allocate 从组织配额池向业务方分配 quota，quota 为负数时从业务方收回，a.Cap 为业务方配额余额上限
+	Allocate(ctx context.Context, a domain.OrgQuotaAlloc, quota int64) error
+}
+
+var _ OrganizationRepo = (*DefaultOrganizationRepo)(nil)
+
+type DefaultOrganizationRepo struct {
+	orgDAO        dao.OrganizationDAO
+	orgQuotaCache cache.OrgQuotaCache
+	logger        *zap.Logger
+}
+
+func (d *DefaultOrganizationRepo) Create(ctx context.Context, o domain.Organization) (domain.Organization, error) {
+	entity, err := d.orgDAO.Create(ctx, d.toEntity(o))
+	if err != nil {
+		return domain.Organization{}, err
+	}
+	return d.toDomain(entity), nil
+}
+
+func (d *DefaultOrganizationRepo) GetById(ctx context.Context, id uint64) (domain.Organization, error) {
+	entity, err := d.orgDAO.GetById(ctx, id)
+	if err != nil {
+		return domain.Organization{}, err
+	}
+	return d.toDomain(entity), nil
+}
+
+func (d *DefaultOrganizationRepo) Update(ctx context.Context, o domain.Organization) error {
+	return d.orgDAO.Update(ctx, d.toEntity(o))
+}
+
+func (d *DefaultOrganizationRepo) FindMemberIds(ctx context.Context, orgId uint64) ([]uint64, error) {
+	bcs, err := d.orgDAO.FindMembers(ctx, orgId)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint64, 0, len(bcs))
+	for _, bc := range bcs {
+		ids = append(ids, bc.Id)
+	}
+	return ids, nil
+}
+
+func (d *DefaultOrganizationRepo) SetMember(ctx context.Context, bizId uint64, orgId uint64) error {
+	return d.orgDAO.SetMember(ctx, bizId, orgId)
+}
+
+func (d *DefaultOrganizationRepo) TopUp(ctx context.Context, orgId uint64, channel domain.Channel, quota int64) (int64, error) {
+	return d.orgQuotaCache.TopUp(ctx, orgId, channel, quota)
+}
+
+func (d *DefaultOrganizationRepo) Pool(ctx context.Context, orgId uint64, channel domain.Channel) (int64, error) {
+	return d.orgQuotaCache.Pool(ctx, orgId, channel)
+}
+
+func (d *DefaultOrganizationRepo) Balances(ctx context.Context, bizIds []uint64, channel domain.Channel) (map[uint64]int64, error) {
+	return d.orgQuotaCache.Balances(ctx, bizIds, channel)
+}
+
+func (d *DefaultOrganizationRepo) GetAlloc(ctx context.Context, bizId uint64, channel domain.Channel) (domain.OrgQuotaAlloc, error) {
+	entity, err := d.orgDAO.GetAlloc(ctx, bizId, channel.String())
+	if err != nil {
+		return domain.OrgQuotaAlloc{}, err
+	}
+	return d.toDomainAlloc(entity), nil
+}
+
+func (d *DefaultOrganizationRepo) FindAllocs(ctx context.Context, orgId uint64, channel domain.Channel) ([]domain.OrgQuotaAlloc, error) {
+	entities, err := d.orgDAO.FindAllocs(ctx, orgId, channel.String())
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]domain.OrgQuotaAlloc, 0, len(entities))
+	for _, entity := range entities {
+		res = append(res, d.toDomainAlloc(entity))
+	}
+	return res, nil
+}
+
+func (d *DefaultOrganizationRepo) SetCap(ctx context.Context, a domain.OrgQuotaAlloc) error {
+	return d.orgDAO.SetCap(ctx, d.toEntityAlloc(a))
+}
+
+// Allocate 先在 redis 中转移配额，再记录累计分配的配额，记录失败时撤回转移
+func (d *DefaultOrganizationRepo) Allocate(ctx context.Context, a domain.OrgQuotaAlloc, quota int64) error {
+	if err := d.orgQuotaCache.Transfer(ctx, a.OrgId, a.BizId, a.Channel, quota, a.Cap); err != nil {
+		return err
+	}
+
+	err := d.orgDAO.AddAllocated(ctx, d.toEntityAlloc(a), quota)
+	if err == nil {
+		return nil
+	}
+	// 撤回时不限制余额上限
+	if revertErr := d.orgQuotaCache.Transfer(ctx, a.OrgId, a.BizId, a.Channel, -quota, 0); revertErr != nil {
+		d.logger.Error(
+			"[jotify] failed to revert organization quota transfer",
+			zap.Error(revertErr),
+			zap.Uint64("org_id", a.OrgId),
+			zap.Uint64("biz_id", a.BizId),
+			zap.String("channel", a.Channel.String()),
+			zap.Int64("quota", quota),
+		)
+	}
+	return err
+}
+
+func (d *DefaultOrganizationRepo) toEntity(o domain.Organization) dao.Organization {
+	entity := dao.Organization{
+		Id:        o.Id,
+		Name:      o.Name,
+		RateLimit: o.RateLimit,
+	}
+	if o.ChannelConf != nil {
+		entity.ChannelConf = xsql.JsonColumn[domain.ChannelConf]{Val: *o.ChannelConf, Valid: true}
+	}
+	if o.DeliveryConf != nil {
+		entity.DeliveryConf = xsql.JsonColumn[domain.DeliveryConf]{Val: *o.DeliveryConf, Valid: true}
+	}
+	return entity
+}
+
+func (d *DefaultOrganizationRepo) toDomain(entity dao.Organization) domain.Organization {
+	o := domain.Organization{
+		Id:        entity.Id,
+		Name:      entity.Name,
+		RateLimit: entity.RateLimit,
+		CreatedAt: entity.CreatedAt,
+		UpdatedAt: entity.UpdatedAt,
+	}
+	if entity.ChannelConf.Valid {
+		o.ChannelConf = &entity.ChannelConf.Val
+	}
+	if entity.DeliveryConf.Valid {
+		o.DeliveryConf = &entity.DeliveryConf.Val
+	}
+	return o
+}
+
+func (d *DefaultOrganizationRepo) toEntityAlloc(a domain.OrgQuotaAlloc) dao.OrgQuotaAlloc {
+	return dao.OrgQuotaAlloc{
+		BizId:     a.BizId,
+		Channel:   a.Channel.String(),
+		OrgId:     a.OrgId,
+		Cap:       a.Cap,
+		Allocated: a.Allocated,
+	}
+}
+
+func (d *DefaultOrganizationRepo) toDomainAlloc(entity dao.OrgQuotaAlloc) domain.OrgQuotaAlloc {
+	return domain.OrgQuotaAlloc{
+		OrgId:     entity.OrgId,
+		BizId:     entity.BizId,
+		Channel:   domain.Channel(entity.Channel),
+		Cap:       entity.Cap,
+		Allocated: entity.Allocated,
+		CreatedAt: entity.CreatedAt,
+		UpdatedAt: entity.UpdatedAt,
+	}
+}
+
+func NewDefaultOrganizationRepo(
+	orgDAO dao.OrganizationDAO, orgQuotaCache cache.OrgQuotaCache, logger *zap.Logger,
+) *DefaultOrganizationRepo {
+	return &DefaultOrganizationRepo{
+		orgDAO:        orgDAO,
+		orgQuotaCache: orgQuotaCache,
+		logger:        logger,
+	}
+}
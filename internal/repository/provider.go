@@ -9,6 +9,10 @@ import (
 
 type ProviderRepo interface {
 	GetByNameAndTplInfo(ctx context.Context, name string, tplId uint64, tplVersionId uint64, tplChannel string) ([]domain.ChannelTplProvider, error)
+	// GetByTplInfo 获取模板版本在所有供应商侧的模板
+	GetByTplInfo(ctx context.Context, tplId uint64, tplVersionId uint64, tplChannel string) ([]domain.ChannelTplProvider, error)
+	// ChannelQps 返回渠道可用供应商的 QPS 上限之和
+	ChannelQps(ctx context.Context, channel domain.Channel) (int64, error)
 }
 
 var _ ProviderRepo = (*DefaultProviderRepo)(nil)
@@ -30,11 +34,29 @@ func (d *DefaultProviderRepo) GetByNameAndTplInfo(ctx context.Context, name stri
 	return res, nil
 }
 
+func (d *DefaultProviderRepo) GetByTplInfo(ctx context.Context, tplId uint64, tplVersionId uint64, tplChannel string) ([]domain.ChannelTplProvider, error) {
+	providers, err := d.providerDAO.GetByTplInfo(ctx, tplId, tplVersionId, tplChannel)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]domain.ChannelTplProvider, 0, len(providers))
+	for _, provider := range providers {
+		res = append(res, d.toDomainChannelTplProvider(provider))
+	}
+	return res, nil
+}
+
+func (d *DefaultProviderRepo) ChannelQps(ctx context.Context, channel domain.Channel) (int64, error) {
+	return d.providerDAO.SumQpsLimit(ctx, channel.String())
+}
+
 func (d *DefaultProviderRepo) toDomainChannelTplProvider(entity dao.ChannelTplProvider) domain.ChannelTplProvider {
 	return domain.ChannelTplProvider{
 		Id:              entity.Id,
 		TplId:           entity.TplId,
 		TplVersionId:    entity.TplVersionId,
+		Locale:          entity.Locale,
 		ProviderId:      entity.ProviderId,
 		ProviderName:    entity.ProviderName,
 		ProviderChannel: domain.Channel(entity.ProviderChannel),
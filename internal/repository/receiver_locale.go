@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/JrMarcco/easy-kit/slice"
+	"github.com/JrMarcco/easy-kit/xmap"
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/pkg/privacy"
+	"github.com/JrMarcco/jotify/internal/repository/dao"
+)
+
+type ReceiverLocaleRepo interface {
+	BatchSave(ctx context.Context, ls []domain.ReceiverLocale) error
+	// Delete 删除接收者的语言偏好，bizId 为 0 时删除全部业务方的记录
+	Delete(ctx context.Context, bizId uint64, receiver string) error
+	// DeleteByHash 按接收者哈希删除语言偏好，bizId 为 0 时删除全部业务方的记录
+	DeleteByHash(ctx context.Context, bizId uint64, receiverHash string) error
+	// FindLocales 返回有语言偏好的接收者的语言，key 为请求中的接收者
+	FindLocales(ctx context.Context, bizId uint64, receivers []string) (map[string]string, error)
+}
+
+var _ ReceiverLocaleRepo = (*DefaultReceiverLocaleRepo)(nil)
+
+type DefaultReceiverLocaleRepo struct {
+	localeDAO dao.ReceiverLocaleDAO
+	hasher    *privacy.Hasher
+}
+
+func (d *DefaultReceiverLocaleRepo) BatchSave(ctx context.Context, ls []domain.ReceiverLocale) error {
+	return d.localeDAO.BatchUpsert(ctx, slice.Map(ls, func(_ int, l domain.ReceiverLocale) dao.ReceiverLocale {
+		return dao.ReceiverLocale{
+			BizId:        l.BizId,
+			ReceiverHash: d.hasher.Hash(l.Receiver),
+			Locale:       l.Locale,
+		}
+	}))
+}
+
+func (d *DefaultReceiverLocaleRepo) Delete(ctx context.Context, bizId uint64, receiver string) error {
+	return d.localeDAO.Delete(ctx, bizId, d.hasher.Hash(receiver))
+}
+
+func (d *DefaultReceiverLocaleRepo) DeleteByHash(ctx context.Context, bizId uint64, receiverHash string) error {
+	return d.localeDAO.Delete(ctx, bizId, receiverHash)
+}
+
+func (d *DefaultReceiverLocaleRepo) FindLocales(ctx context.Context, bizId uint64, receivers []string) (map[string]string, error) {
+	// 不同写法的接收者可能得到相同的哈希
+	hashes := make(map[string][]string, len(receivers))
+	for _, receiver := range receivers {
+		hash := d.hasher.Hash(receiver)
+		hashes[hash] = append(hashes[hash], receiver)
+	}
+
+	entities, err := d.localeDAO.FindByHashes(ctx, bizId, xmap.Keys(hashes))
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]string, len(entities))
+	for _, entity := range entities {
+		for _, receiver := range hashes[entity.ReceiverHash] {
+			res[receiver] = entity.Locale
+		}
+	}
+	return res, nil
+}
+
+func NewDefaultReceiverLocaleRepo(localeDAO dao.ReceiverLocaleDAO, hasher *privacy.Hasher) *DefaultReceiverLocaleRepo {
+	return &DefaultReceiverLocaleRepo{
+		localeDAO: localeDAO,
+		hasher:    hasher,
+	}
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/pkg/envelope"
+	"github.com/JrMarcco/jotify/internal/repository/dao"
+)
+
+type RecurringRepo interface {
+	Create(ctx context.Context, s domain.RecurringSchedule) error
+	GetByKey(ctx context.Context, bizId uint64, bizKey string) (domain.RecurringSchedule, error)
+	List(ctx context.Context, bizId uint64, offset int, limit int) ([]domain.RecurringSchedule, error)
+	// FindDue 查询 before 之前需要触发的计划
+	FindDue(ctx context.Context, before time.Time, limit int) ([]domain.RecurringSchedule, error)
+	// CompareAndSwap 按版本号更新触发次数、下一次触发时间与状态，s.Version 为读取计划时的版本号
+	CompareAndSwap(ctx context.Context, s domain.RecurringSchedule) error
+	Delete(ctx context.Context, bizId uint64, bizKey string) error
+}
+
+var _ RecurringRepo = (*DefaultRecurringRepo)(nil)
+
+// DefaultRecurringRepo 周期发送计划仓储，与消息相同，接收者与模板参数使用业务方的数据密钥加密保存
+type DefaultRecurringRepo struct {
+	recurringDAO dao.RecurringScheduleDAO
+	cipher       *envelope.Cipher
+	encrypt      bool
+}
+
+func (d *DefaultRecurringRepo) Create(ctx context.Context, s domain.RecurringSchedule) error {
+	entity, err := d.toEntity(ctx, s)
+	if err != nil {
+		return err
+	}
+	return d.recurringDAO.Create(ctx, entity)
+}
+
+func (d *DefaultRecurringRepo) GetByKey(ctx context.Context, bizId uint64, bizKey string) (domain.RecurringSchedule, error) {
+	entity, err := d.recurringDAO.GetByKey(ctx, bizId, bizKey)
+	if err != nil {
+		return domain.RecurringSchedule{}, err
+	}
+	return d.toDomain(ctx, entity)
+}
+
+func (d *DefaultRecurringRepo) List(ctx context.Context, bizId uint64, offset int, limit int) ([]domain.RecurringSchedule, error) {
+	entities, err := d.recurringDAO.List(ctx, bizId, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return d.toDomains(ctx, entities)
+}
+
+func (d *DefaultRecurringRepo) FindDue(ctx context.Context, before time.Time, limit int) ([]domain.RecurringSchedule, error) {
+	entities, err := d.recurringDAO.FindDue(ctx, before.UnixMilli(), limit)
+	if err != nil {
+		return nil, err
+	}
+	return d.toDomains(ctx, entities)
+}
+
+func (d *DefaultRecurringRepo) CompareAndSwap(ctx context.Context, s domain.RecurringSchedule) error {
+	return d.recurringDAO.CompareAndSwap(ctx, dao.RecurringSchedule{
+		Id:          s.Id,
+		Occurrences: s.Occurrences,
+		NextRunAt:   unixMilliOrZero(s.NextRunAt),
+		Status:      s.Status.String(),
+		Version:     s.Version,
+	})
+}
+
+func (d *DefaultRecurringRepo) Delete(ctx context.Context, bizId uint64, bizKey string) error {
+	return d.recurringDAO.Delete(ctx, bizId, bizKey)
+}
+
+func (d *DefaultRecurringRepo) toEntity(ctx context.Context, s domain.RecurringSchedule) (dao.RecurringSchedule, error) {
+	receivers, err := json.Marshal(s.Receivers)
+	if err != nil {
+		return dao.RecurringSchedule{}, err
+	}
+	tplParams, err := json.Marshal(s.Template.Params)
+	if err != nil {
+		return dao.RecurringSchedule{}, err
+	}
+
+	entity := dao.RecurringSchedule{
+		Id:             s.Id,
+		BizId:          s.BizId,
+		BizKey:         s.BizKey,
+		Receivers:      string(receivers),
+		Channel:        s.Channel.String(),
+		TplId:          s.Template.Id,
+		TplVersionId:   s.Template.VersionId,
+		TplParams:      string(tplParams),
+		Priority:       int8(s.Priority),
+		Cron:           s.Cron,
+		Timezone:       s.Timezone,
+		EndAt:          unixMilliOrZero(s.EndAt),
+		MaxOccurrences: s.MaxOccurrences,
+		Occurrences:    s.Occurrences,
+		NextRunAt:      unixMilliOrZero(s.NextRunAt),
+		Status:         s.Status.String(),
+	}
+	if !d.encrypt {
+		return entity, nil
+	}
+
+	if entity.Receivers, err = d.cipher.Encrypt(ctx, s.BizId, entity.Receivers); err != nil {
+		return dao.RecurringSchedule{}, err
+	}
+	if entity.TplParams, err = d.cipher.Encrypt(ctx, s.BizId, entity.TplParams); err != nil {
+		return dao.RecurringSchedule{}, err
+	}
+	return entity, nil
+}
+
+func (d *DefaultRecurringRepo) toDomains(ctx context.Context, entities []dao.RecurringSchedule) ([]domain.RecurringSchedule, error) {
+	res := make([]domain.RecurringSchedule, 0, len(entities))
+	for _, entity := range entities {
+		s, err := d.toDomain(ctx, entity)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, s)
+	}
+	return res, nil
+}
+
+func (d *DefaultRecurringRepo) toDomain(ctx context.Context, entity dao.RecurringSchedule) (domain.RecurringSchedule, error) {
+	receiversVal, err := d.cipher.Decrypt(ctx, entity.BizId, entity.Receivers)
+	if err != nil {
+		return domain.RecurringSchedule{}, err
+	}
+	paramsVal, err := d.cipher.Decrypt(ctx, entity.BizId, entity.TplParams)
+	if err != nil {
+		return domain.RecurringSchedule{}, err
+	}
+
+	var receivers []string
+	_ = json.Unmarshal([]byte(receiversVal), &receivers)
+	var tplParams map[string]string
+	_ = json.Unmarshal([]byte(paramsVal), &tplParams)
+
+	return domain.RecurringSchedule{
+		Id:        entity.Id,
+		BizId:     entity.BizId,
+		BizKey:    entity.BizKey,
+		Receivers: receivers,
+		Channel:   domain.Channel(entity.Channel),
+		Template: domain.Template{
+			Id:        entity.TplId,
+			VersionId: entity.TplVersionId,
+			Params:    tplParams,
+		},
+		Priority:       domain.Priority(entity.Priority),
+		Cron:           entity.Cron,
+		Timezone:       entity.Timezone,
+		EndAt:          timeOrZero(entity.EndAt),
+		MaxOccurrences: entity.MaxOccurrences,
+		Occurrences:    entity.Occurrences,
+		NextRunAt:      timeOrZero(entity.NextRunAt),
+		Status:         domain.RecurringStatus(entity.Status),
+		Version:        entity.Version,
+		CreatedAt:      entity.CreatedAt,
+		UpdatedAt:      entity.UpdatedAt,
+	}, nil
+}
+
+// unixMilliOrZero 零值时间保存为 0
+func unixMilliOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
+
+func timeOrZero(ms int64) time.Time {
+	if ms == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
+
+func NewDefaultRecurringRepo(recurringDAO dao.RecurringScheduleDAO, cipher *envelope.Cipher, encrypt bool) *DefaultRecurringRepo {
+	return &DefaultRecurringRepo{
+		recurringDAO: recurringDAO,
+		cipher:       cipher,
+		encrypt:      encrypt,
+	}
+}
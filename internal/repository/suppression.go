@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/JrMarcco/easy-kit/slice"
+	"github.com/JrMarcco/easy-kit/xmap"
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/pkg/privacy"
+	"github.com/JrMarcco/jotify/internal/repository/dao"
+)
+
+type SuppressionRepo interface {
+	BatchSave(ctx context.Context, ss []domain.Suppression) error
+	Delete(ctx context.Context, bizId uint64, channel domain.Channel, receiver string) error
+	// FindSuppressed 返回在业务方或全局名单中的接收者，key 为请求中的接收者
+	FindSuppressed(ctx context.Context, bizId uint64, channel domain.Channel, receivers []string) (map[string]domain.Suppression, error)
+	List(ctx context.Context, bizId uint64, receiver string, offset int, limit int) ([]domain.Suppression, error)
+}
+
+var _ SuppressionRepo = (*DefaultSuppressionRepo)(nil)
+
+type DefaultSuppressionRepo struct {
+	suppressionDAO dao.SuppressionDAO
+	hasher         *privacy.Hasher
+}
+
+func (d *DefaultSuppressionRepo) BatchSave(ctx context.Context, ss []domain.Suppression) error {
+	return d.suppressionDAO.BatchUpsert(ctx, slice.Map(ss, func(_ int, s domain.Suppression) dao.Suppression {
+		// 退订链接中只有接收者哈希
+		receiverHash := s.ReceiverHash
+		if receiverHash == "" {
+			receiverHash = d.hasher.Hash(s.Receiver)
+		}
+		return dao.Suppression{
+			BizId:        s.BizId,
+			ReceiverHash: receiverHash,
+			Channel:      s.Channel.String(),
+			Reason:       s.Reason.String(),
+			ExpireAt:     s.ExpireAt,
+		}
+	}))
+}
+
+func (d *DefaultSuppressionRepo) Delete(ctx context.Context, bizId uint64, channel domain.Channel, receiver string) error {
+	return d.suppressionDAO.Delete(ctx, bizId, d.hasher.Hash(receiver), channel.String())
+}
+
+func (d *DefaultSuppressionRepo) FindSuppressed(
+	ctx context.Context, bizId uint64, channel domain.Channel, receivers []string,
+) (map[string]domain.Suppression, error) {
+	// 不同写法的接收者可能得到相同的哈希
+	hashes := make(map[string][]string, len(receivers))
+	for _, receiver := range receivers {
+		hash := d.hasher.Hash(receiver)
+		hashes[hash] = append(hashes[hash], receiver)
+	}
+
+	entities, err := d.suppressionDAO.FindActive(ctx, bizId, channel.String(), xmap.Keys(hashes))
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]domain.Suppression, len(entities))
+	for _, entity := range entities {
+		for _, receiver := range hashes[entity.ReceiverHash] {
+			// 业务方名单优先于全局名单
+			if existing, ok := res[receiver]; ok && existing.BizId != 0 {
+				continue
+			}
+			s := d.toDomain(entity)
+			s.Receiver = receiver
+			res[receiver] = s
+		}
+	}
+	return res, nil
+}
+
+func (d *DefaultSuppressionRepo) List(ctx context.Context, bizId uint64, receiver string, offset int, limit int) ([]domain.Suppression, error) {
+	receiverHash := ""
+	if receiver != "" {
+		receiverHash = d.hasher.Hash(receiver)
+	}
+
+	entities, err := d.suppressionDAO.List(ctx, bizId, receiverHash, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(entities, func(_ int, entity dao.Suppression) domain.Suppression {
+		return d.toDomain(entity)
+	}), nil
+}
+
+func (d *DefaultSuppressionRepo) toDomain(entity dao.Suppression) domain.Suppression {
+	return domain.Suppression{
+		Id:           entity.Id,
+		BizId:        entity.BizId,
+		ReceiverHash: entity.ReceiverHash,
+		Channel:      domain.Channel(entity.Channel),
+		Reason:       domain.SuppressionReason(entity.Reason),
+		ExpireAt:     entity.ExpireAt,
+		CreatedAt:    entity.CreatedAt,
+		UpdatedAt:    entity.UpdatedAt,
+	}
+}
+
+func NewDefaultSuppressionRepo(suppressionDAO dao.SuppressionDAO, hasher *privacy.Hasher) *DefaultSuppressionRepo {
+	return &DefaultSuppressionRepo{
+		suppressionDAO: suppressionDAO,
+		hasher:         hasher,
+	}
+}
@@ -4,12 +4,33 @@ import (
 	"context"
 
 	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/pkg/xsql"
 	"github.com/JrMarcco/jotify/internal/repository/dao"
 )
 
 type ChannelTplRepo interface {
 	GetById(ctx context.Context, id uint64) (domain.ChannelTpl, error)
 	GetVersionByVersionId(ctx context.Context, id uint64) (domain.ChannelTplVersion, error)
+
+	// FindVersionsByAuditStatus 按 id 升序查询 id 大于 startId 的指定审核状态的版本
+	FindVersionsByAuditStatus(
+		ctx context.Context, status domain.AuditStatus, startId uint64, limit int,
+	) ([]domain.ChannelTplVersion, error)
+	// Audit 记录审核结果并更新版本（TplVariantId 不为 0 时为语言变体）的审核状态，
+	// 当前的审核状态不为 from 时返回 errs.ErrTplAuditConflict
+	Audit(ctx context.Context, from domain.AuditStatus, a domain.TplAudit) (domain.TplAudit, error)
+	// FindAudits 按时间顺序查询版本及其语言变体的审核记录
+	FindAudits(ctx context.Context, versionId uint64) ([]domain.TplAudit, error)
+
+	// SaveVariant 保存语言变体，版本已有该语言的变体时替换签名与内容并重置为待审核
+	SaveVariant(ctx context.Context, v domain.ChannelTplVariant) (domain.ChannelTplVariant, error)
+	GetVariantById(ctx context.Context, id uint64) (domain.ChannelTplVariant, error)
+	// FindVariants 查询版本的全部语言变体
+	FindVariants(ctx context.Context, versionId uint64) ([]domain.ChannelTplVariant, error)
+	// FindVariantsByAuditStatus 按 id 升序查询 id 大于 startId 的指定审核状态的语言变体
+	FindVariantsByAuditStatus(
+		ctx context.Context, status domain.AuditStatus, startId uint64, limit int,
+	) ([]domain.ChannelTplVariant, error)
 }
 
 var _ ChannelTplRepo = (*DefaultChannelTplRepo)(nil)
@@ -34,6 +55,138 @@ func (d *DefaultChannelTplRepo) GetVersionByVersionId(ctx context.Context, versi
 	return d.toDomainVersion(version), nil
 }
 
+func (d *DefaultChannelTplRepo) FindVersionsByAuditStatus(
+	ctx context.Context, status domain.AuditStatus, startId uint64, limit int,
+) ([]domain.ChannelTplVersion, error) {
+	entities, err := d.tplDAO.FindVersionsByAuditStatus(ctx, status.String(), startId, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]domain.ChannelTplVersion, 0, len(entities))
+	for _, entity := range entities {
+		versions = append(versions, d.toDomainVersion(entity))
+	}
+	return versions, nil
+}
+
+func (d *DefaultChannelTplRepo) Audit(ctx context.Context, from domain.AuditStatus, a domain.TplAudit) (domain.TplAudit, error) {
+	entity, err := d.tplDAO.Audit(ctx, from.String(), d.toEntityAudit(a), a.RejectReason())
+	if err != nil {
+		return domain.TplAudit{}, err
+	}
+	return d.toDomainAudit(entity), nil
+}
+
+func (d *DefaultChannelTplRepo) FindAudits(ctx context.Context, versionId uint64) ([]domain.TplAudit, error) {
+	entities, err := d.tplDAO.FindAudits(ctx, versionId)
+	if err != nil {
+		return nil, err
+	}
+
+	audits := make([]domain.TplAudit, 0, len(entities))
+	for _, entity := range entities {
+		audits = append(audits, d.toDomainAudit(entity))
+	}
+	return audits, nil
+}
+
+func (d *DefaultChannelTplRepo) SaveVariant(ctx context.Context, v domain.ChannelTplVariant) (domain.ChannelTplVariant, error) {
+	entity, err := d.tplDAO.SaveVariant(ctx, dao.ChannelTplVariant{
+		TplId:        v.TplId,
+		TplVersionId: v.TplVersionId,
+		Locale:       v.Locale,
+		Signature:    v.Signature,
+		Content:      v.Content,
+	})
+	if err != nil {
+		return domain.ChannelTplVariant{}, err
+	}
+	return d.toDomainVariant(entity), nil
+}
+
+func (d *DefaultChannelTplRepo) GetVariantById(ctx context.Context, id uint64) (domain.ChannelTplVariant, error) {
+	entity, err := d.tplDAO.GetVariantById(ctx, id)
+	if err != nil {
+		return domain.ChannelTplVariant{}, err
+	}
+	return d.toDomainVariant(entity), nil
+}
+
+func (d *DefaultChannelTplRepo) FindVariants(ctx context.Context, versionId uint64) ([]domain.ChannelTplVariant, error) {
+	entities, err := d.tplDAO.FindVariants(ctx, versionId)
+	if err != nil {
+		return nil, err
+	}
+	return d.toDomainVariants(entities), nil
+}
+
+func (d *DefaultChannelTplRepo) FindVariantsByAuditStatus(
+	ctx context.Context, status domain.AuditStatus, startId uint64, limit int,
+) ([]domain.ChannelTplVariant, error) {
+	entities, err := d.tplDAO.FindVariantsByAuditStatus(ctx, status.String(), startId, limit)
+	if err != nil {
+		return nil, err
+	}
+	return d.toDomainVariants(entities), nil
+}
+
+func (d *DefaultChannelTplRepo) toDomainVariants(entities []dao.ChannelTplVariant) []domain.ChannelTplVariant {
+	variants := make([]domain.ChannelTplVariant, 0, len(entities))
+	for _, entity := range entities {
+		variants = append(variants, d.toDomainVariant(entity))
+	}
+	return variants
+}
+
+func (d *DefaultChannelTplRepo) toDomainVariant(entity dao.ChannelTplVariant) domain.ChannelTplVariant {
+	return domain.ChannelTplVariant{
+		Id:           entity.Id,
+		TplId:        entity.TplId,
+		TplVersionId: entity.TplVersionId,
+		Locale:       entity.Locale,
+		Signature:    entity.Signature,
+		Content:      entity.Content,
+		AuditId:      entity.AuditId,
+		AuditorId:    entity.AuditorId,
+		AuditAt:      entity.AuditAt,
+		AuditStatus:  domain.AuditStatus(entity.AuditStatus),
+		RejectReason: entity.RejectReason,
+		CreateAt:     entity.CreatedAt,
+		UpdateAt:     entity.UpdatedAt,
+	}
+}
+
+func (d *DefaultChannelTplRepo) toEntityAudit(a domain.TplAudit) dao.ChannelTplAudit {
+	reasons := a.Reasons
+	if reasons == nil {
+		reasons = []string{}
+	}
+	return dao.ChannelTplAudit{
+		TplId:        a.TplId,
+		TplVersionId: a.TplVersionId,
+		TplVariantId: a.TplVariantId,
+		Stage:        a.Stage.String(),
+		Status:       a.Status.String(),
+		Reasons:      xsql.JsonColumn[[]string]{Val: reasons, Valid: true},
+		AuditorId:    a.AuditorId,
+	}
+}
+
+func (d *DefaultChannelTplRepo) toDomainAudit(entity dao.ChannelTplAudit) domain.TplAudit {
+	return domain.TplAudit{
+		Id:           entity.Id,
+		TplId:        entity.TplId,
+		TplVersionId: entity.TplVersionId,
+		TplVariantId: entity.TplVariantId,
+		Stage:        domain.AuditStage(entity.Stage),
+		Status:       domain.AuditStatus(entity.Status),
+		Reasons:      entity.Reasons.Val,
+		AuditorId:    entity.AuditorId,
+		CreatedAt:    entity.CreatedAt,
+	}
+}
+
 func (d *DefaultChannelTplRepo) toDomainVersion(entity dao.ChannelTplVersion) domain.ChannelTplVersion {
 	return domain.ChannelTplVersion{
 		Id:           entity.Id,
@@ -0,0 +1,169 @@
+package archive
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/JrMarcco/dlock"
+	"github.com/JrMarcco/jotify/internal/pkg/job"
+	"github.com/JrMarcco/jotify/internal/pkg/metrics"
+	"github.com/JrMarcco/jotify/internal/pkg/sharding"
+	"github.com/JrMarcco/jotify/internal/repository"
+	"github.com/JrMarcco/jotify/internal/repository/dao"
+	"go.uber.org/zap"
+)
+
+// Archiver 消息归档服务接口
+type Archiver interface {
+	// Start 启动归档任务，context.Context 被取消时退出
+	Start(ctx context.Context) error
+}
+
+var _ Archiver = (*ShardingArchiver)(nil)
+
+// ShardingArchiver 分库分表归档任务。
+//
+// 按分表抢占分布式锁，每隔 interval 扫描一遍分表，将超过业务方保留期的终态消息（success、failure、cancel、expired）
+// 及其回调记录按批移动到同库的归档表中。归档后的消息仍可以通过 id 查询。
+// 在线扩容期间暂停归档，避免与回填并发移动同一条记录。
+type ShardingArchiver struct {
+	archiveDAO  dao.NotifArchiveDAO
+	bizConfRepo repository.BizConfRepo
+	strategy    sharding.Strategy
+
+	interval         time.Duration
+	batchSize        int
+	defaultRetention time.Duration
+	minRetention     time.Duration
+
+	mu      sync.Mutex
+	cursors map[sharding.Dst]*cursor
+
+	job    *job.ShardingLoopJob
+	logger *zap.Logger
+}
+
+// cursor 单个分表的扫描进度，分表的分布式锁被其他实例抢占后从头开始扫描
+type cursor struct {
+	startId   uint64
+	nextRunAt time.Time
+}
+
+func (a *ShardingArchiver) Start(ctx context.Context) error {
+	go func() {
+		_ = a.job.Run(ctx)
+	}()
+	return nil
+}
+
+// loop 归档当前分表，单次调用受 job 的超时限制，未扫描完的分表在下一次调用时从 cursor 继续
+func (a *ShardingArchiver) loop(ctx context.Context) error {
+	dst, _ := sharding.DstFromContext(ctx)
+
+	if ms, ok := a.strategy.(sharding.MigratingStrategy); ok && ms.Migrating() {
+		job.WaitUntil(ctx, time.Now().Add(a.interval))
+		return nil
+	}
+
+	c := a.cursorOf(dst)
+	if time.Now().Before(c.nextRunAt) {
+		job.WaitUntil(ctx, c.nextRunAt)
+		return nil
+	}
+
+	now := time.Now()
+	cutoffs := make(map[uint64]int64)
+	for {
+		ns, err := a.archiveDAO.FindTerminal(ctx, dst, c.startId, now.Add(-a.minRetention).UnixMilli(), a.batchSize)
+		if err != nil {
+			return err
+		}
+		if len(ns) == 0 {
+			// 本轮扫描完成
+			c.startId, c.nextRunAt = 0, now.Add(a.interval)
+			return nil
+		}
+
+		ids := make([]uint64, 0, len(ns))
+		for _, n := range ns {
+			if n.UpdatedAt < a.cutoff(ctx, n.BizId, now, cutoffs) {
+				ids = append(ids, n.Id)
+			}
+		}
+
+		cnt, err := a.archiveDAO.Archive(ctx, dst, ids)
+		if err != nil {
+			return err
+		}
+		metrics.ArchivedNotifications.WithLabelValues(dst.DB, dst.Table).Add(float64(cnt))
+		c.startId = ns[len(ns)-1].Id
+	}
+}
+
+// cutoff 获取业务方的归档截止时间，updated_at 早于截止时间的终态消息可以归档。
+//
+// 获取业务配置失败时返回 0，本轮不归档该业务方的消息。
+func (a *ShardingArchiver) cutoff(ctx context.Context, bizId uint64, now time.Time, cutoffs map[uint64]int64) int64 {
+	if val, ok := cutoffs[bizId]; ok {
+		return val
+	}
+
+	retention := a.defaultRetention
+	bizConf, err := a.bizConfRepo.GetById(ctx, bizId)
+	if err != nil {
+		a.logger.Warn("[jotify] failed to get biz conf, skip archiving", zap.Error(err), zap.Uint64("biz_id", bizId))
+		cutoffs[bizId] = 0
+		return 0
+	}
+	if bizConf.RetentionDays > 0 {
+		retention = time.Duration(bizConf.RetentionDays) * 24 * time.Hour
+	}
+
+	val := now.Add(-max(retention, a.minRetention)).UnixMilli()
+	cutoffs[bizId] = val
+	return val
+}
+
+func (a *ShardingArchiver) cursorOf(dst sharding.Dst) *cursor {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	c, ok := a.cursors[dst]
+	if !ok {
+		c = &cursor{}
+		a.cursors[dst] = c
+	}
+	return c
+}
+
+func NewShardingArchiver(
+	dclient dlock.Dclient,
+	archiveDAO dao.NotifArchiveDAO,
+	bizConfRepo repository.BizConfRepo,
+	strategy sharding.Strategy,
+	resourceSemaphore job.ResourceSemaphore,
+	interval time.Duration,
+	batchSize int,
+	defaultRetention time.Duration,
+	minRetention time.Duration,
+	logger *zap.Logger,
+) *ShardingArchiver {
+	const jobBaseKey = "jotify_notification_archiver"
+
+	archiver := &ShardingArchiver{
+		archiveDAO:       archiveDAO,
+		bizConfRepo:      bizConfRepo,
+		strategy:         strategy,
+		interval:         interval,
+		batchSize:        batchSize,
+		defaultRetention: defaultRetention,
+		minRetention:     minRetention,
+		cursors:          make(map[sharding.Dst]*cursor),
+		logger:           logger,
+	}
+	archiver.job = job.NewShardingLoopJob(
+		jobBaseKey, resourceSemaphore, strategy, dclient, logger, archiver.loop,
+	)
+	return archiver
+}
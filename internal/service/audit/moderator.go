@@ -0,0 +1,136 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/JrMarcco/dlock"
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/errs"
+	"github.com/JrMarcco/jotify/internal/pkg/job"
+	"github.com/JrMarcco/jotify/internal/repository"
+	"go.uber.org/zap"
+)
+
+// Moderator 模板自动审核任务接口
+type Moderator interface {
+	// Start 启动自动审核任务，context.Context 被取消时退出
+	Start(ctx context.Context) error
+}
+
+var _ Moderator = (*DefaultModerator)(nil)
+
+// DefaultModerator 模板自动审核任务。
+//
+// 抢占分布式锁后每隔 interval 扫描一轮待审核（pending）的版本与语言变体并执行自动审核，
+// 审核失败的版本与变体保持 pending，在下一轮重新审核。
+type DefaultModerator struct {
+	tplRepo  repository.ChannelTplRepo
+	auditSvc Service
+
+	interval  time.Duration
+	batchSize int
+
+	job    *job.LoopJob
+	logger *zap.Logger
+}
+
+func (m *DefaultModerator) Start(ctx context.Context) error {
+	go func() {
+		_ = m.job.Run(ctx)
+	}()
+	return nil
+}
+
+// loop 审核一轮全部待审核的版本与语言变体后等待 interval
+func (m *DefaultModerator) loop(ctx context.Context) error {
+	start := time.Now()
+
+	if err := m.auditVersions(ctx); err != nil {
+		return err
+	}
+	if err := m.auditVariants(ctx); err != nil {
+		return err
+	}
+
+	job.WaitUntil(ctx, start.Add(m.interval))
+	return nil
+}
+
+func (m *DefaultModerator) auditVersions(ctx context.Context) error {
+	var startId uint64
+	for {
+		versions, err := m.tplRepo.FindVersionsByAuditStatus(ctx, domain.AuditStatusPending, startId, m.batchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, version := range versions {
+			// 状态冲突说明版本已被其他方式审核
+			if _, err = m.auditSvc.Audit(ctx, version.Id); err != nil && !errors.Is(err, errs.ErrTplAuditConflict) {
+				m.logger.Error(
+					"[jotify] failed to audit template version",
+					zap.Error(err),
+					zap.Uint64("tpl_id", version.ChannelTplId),
+					zap.Uint64("version_id", version.Id),
+				)
+			}
+		}
+
+		if len(versions) < m.batchSize {
+			return nil
+		}
+		startId = versions[len(versions)-1].Id
+	}
+}
+
+func (m *DefaultModerator) auditVariants(ctx context.Context) error {
+	var startId uint64
+	for {
+		variants, err := m.tplRepo.FindVariantsByAuditStatus(ctx, domain.AuditStatusPending, startId, m.batchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, variant := range variants {
+			// 状态冲突说明变体已被其他方式审核或重新提交
+			if _, err = m.auditSvc.AuditVariant(ctx, variant.Id); err != nil && !errors.Is(err, errs.ErrTplAuditConflict) {
+				m.logger.Error(
+					"[jotify] failed to audit template variant",
+					zap.Error(err),
+					zap.Uint64("tpl_id", variant.TplId),
+					zap.Uint64("version_id", variant.TplVersionId),
+					zap.Uint64("variant_id", variant.Id),
+					zap.String("locale", variant.Locale),
+				)
+			}
+		}
+
+		if len(variants) < m.batchSize {
+			return nil
+		}
+		startId = variants[len(variants)-1].Id
+	}
+}
+
+func NewDefaultModerator(
+	dclient dlock.Dclient,
+	tplRepo repository.ChannelTplRepo,
+	auditSvc Service,
+	interval time.Duration,
+	batchSize int,
+	logger *zap.Logger,
+) *DefaultModerator {
+	const jobKey = "jotify_template_moderator"
+
+	moderator := &DefaultModerator{
+		tplRepo:   tplRepo,
+		auditSvc:  auditSvc,
+		interval:  interval,
+		batchSize: batchSize,
+		logger:    logger,
+	}
+	moderator.job = job.NewLoopJob(jobKey, dclient, logger, moderator.loop)
+	return moderator
+}
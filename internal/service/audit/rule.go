@@ -0,0 +1,159 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/pkg/sensitive"
+)
+
+var _ Stage = (*RuleStage)(nil)
+
+// urlPattern 模板内容中的链接
+var urlPattern = regexp.MustCompile(`(?i)https?://[^\s"'<>()\[\]{}，。；！？]+`)
+
+// RuleConf 规则审核配置
+type RuleConf struct {
+	BannedWords      []string       // 命中即拒绝的词，不区分大小写
+	ReviewWords      []string       // 命中时转人工审核的词，不区分大小写
+	UrlAllowHosts    []string       // 允许的链接域名（包含子域名），其他链接转人工审核
+	SignaturePattern string         // 签名格式，短信模板必须有签名，其他渠道有签名时校验
+	MaxLength        map[string]int // 各渠道签名与内容的总长度上限（字符数），未配置的渠道不限制
+}
+
+// RuleStage 内置的规则审核阶段，检查违禁词、链接白名单、签名格式与长度。
+//
+// 命中违禁词或发送时使用的敏感词、签名格式错误或超出长度时拒绝，命中待审词或链接不在白名单内时转人工审核。
+type RuleStage struct {
+	filter        *sensitive.Filter
+	bannedWords   []string
+	reviewWords   []string
+	urlAllowHosts []string
+	signature     *regexp.Regexp
+	maxLength     map[domain.Channel]int
+}
+
+func (r *RuleStage) Name() string {
+	return "rule"
+}
+
+func (r *RuleStage) Check(_ context.Context, tpl domain.ChannelTpl, version domain.ChannelTplVersion) (Verdict, error) {
+	text := strings.ToLower(strings.Join([]string{tpl.Name, version.Signature, version.Content}, "\n"))
+
+	var rejects []string
+	for _, word := range r.bannedWords {
+		if strings.Contains(text, word) {
+			rejects = append(rejects, fmt.Sprintf("banned word %q", word))
+		}
+	}
+	if r.filter != nil {
+		for _, word := range r.sensitiveWords(text) {
+			rejects = append(rejects, fmt.Sprintf("sensitive word %q", word))
+		}
+	}
+	if reason, ok := r.checkSignature(tpl.Channel, version.Signature); !ok {
+		rejects = append(rejects, reason)
+	}
+	if limit, ok := r.maxLength[tpl.Channel]; ok && limit > 0 {
+		if length := utf8.RuneCountInString(version.Signature + version.Content); length > limit {
+			rejects = append(rejects, fmt.Sprintf("length %d exceeds limit %d", length, limit))
+		}
+	}
+	if len(rejects) > 0 {
+		return Verdict{Status: domain.AuditStatusRejected, Reasons: rejects}, nil
+	}
+
+	var reviews []string
+	for _, word := range r.reviewWords {
+		if strings.Contains(text, word) {
+			reviews = append(reviews, fmt.Sprintf("review word %q", word))
+		}
+	}
+	for _, link := range urlPattern.FindAllString(version.Content, -1) {
+		if !r.allowUrl(link) {
+			reviews = append(reviews, fmt.Sprintf("url %q not in allow list", link))
+		}
+	}
+	if len(reviews) > 0 {
+		return Verdict{Status: domain.AuditStatusInPreview, Reasons: reviews}, nil
+	}
+	return Verdict{Status: domain.AuditStatusApproved}, nil
+}
+
+// sensitiveWords 返回文本中命中的敏感词，不重复
+func (r *RuleStage) sensitiveWords(text string) []string {
+	var words []string
+	for _, match := range r.filter.Matcher().Find(text) {
+		if !slices.Contains(words, match.Word) {
+			words = append(words, match.Word)
+		}
+	}
+	return words
+}
+
+func (r *RuleStage) checkSignature(channel domain.Channel, signature string) (string, bool) {
+	if signature == "" {
+		if channel.IsSMS() {
+			return "missing signature", false
+		}
+		return "", true
+	}
+	if r.signature != nil && !r.signature.MatchString(signature) {
+		return fmt.Sprintf("invalid signature %q", signature), false
+	}
+	return "", true
+}
+
+// allowUrl 链接域名是否为白名单中的域名或其子域名，域名中含有模板参数等无法解析的链接不允许
+func (r *RuleStage) allowUrl(link string) bool {
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+
+	host := strings.ToLower(u.Hostname())
+	for _, allowed := range r.urlAllowHosts {
+		if host == allowed || strings.HasSuffix(host, "."+allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewRuleStage 构造规则审核阶段，filter 为发送时过滤模板参数使用的敏感词，为空时不检查
+func NewRuleStage(conf RuleConf, filter *sensitive.Filter) (*RuleStage, error) {
+	r := &RuleStage{
+		filter:        filter,
+		bannedWords:   lowerAll(conf.BannedWords),
+		reviewWords:   lowerAll(conf.ReviewWords),
+		urlAllowHosts: lowerAll(conf.UrlAllowHosts),
+		maxLength:     make(map[domain.Channel]int, len(conf.MaxLength)),
+	}
+	if conf.SignaturePattern != "" {
+		pattern, err := regexp.Compile(conf.SignaturePattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid signature pattern: %w", err)
+		}
+		r.signature = pattern
+	}
+	for channel, limit := range conf.MaxLength {
+		r.maxLength[domain.Channel(channel)] = limit
+	}
+	return r, nil
+}
+
+func lowerAll(words []string) []string {
+	res := make([]string, 0, len(words))
+	for _, word := range words {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			res = append(res, word)
+		}
+	}
+	return res
+}
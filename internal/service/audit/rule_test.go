@@ -0,0 +1,130 @@
+package audit
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/pkg/sensitive"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuleStage_Check(t *testing.T) {
+	t.Parallel()
+
+	stage, err := NewRuleStage(RuleConf{
+		BannedWords:      []string{"Casino"},
+		ReviewWords:      []string{"贷款"},
+		UrlAllowHosts:    []string{"jotify.com"},
+		SignaturePattern: `^【.{2,12}】$`,
+		MaxLength:        map[string]int{domain.ChannelSMS.String(): 20},
+	}, sensitive.NewFilter([]string{"赌博"}))
+	require.NoError(t, err)
+
+	tcs := []struct {
+		name        string
+		channel     domain.Channel
+		signature   string
+		content     string
+		wantStatus  domain.AuditStatus
+		wantReasons []string
+	}{
+		{
+			name:       "approved",
+			channel:    domain.ChannelSMS,
+			signature:  "【jotify】",
+			content:    "验证码 ${code}",
+			wantStatus: domain.AuditStatusApproved,
+		}, {
+			name:        "banned word",
+			channel:     domain.ChannelSMS,
+			signature:   "【jotify】",
+			content:     "visit CASINO",
+			wantStatus:  domain.AuditStatusRejected,
+			wantReasons: []string{`banned word "casino"`},
+		}, {
+			name:        "sensitive word",
+			channel:     domain.ChannelSMS,
+			signature:   "【jotify】",
+			content:     "网上赌博",
+			wantStatus:  domain.AuditStatusRejected,
+			wantReasons: []string{`sensitive word "赌博"`},
+		}, {
+			name:        "missing sms signature",
+			channel:     domain.ChannelSMS,
+			content:     "验证码 ${code}",
+			wantStatus:  domain.AuditStatusRejected,
+			wantReasons: []string{"missing signature"},
+		}, {
+			name:       "email without signature",
+			channel:    domain.ChannelEmail,
+			content:    "welcome",
+			wantStatus: domain.AuditStatusApproved,
+		}, {
+			name:        "invalid signature",
+			channel:     domain.ChannelSMS,
+			signature:   "jotify",
+			content:     "验证码 ${code}",
+			wantStatus:  domain.AuditStatusRejected,
+			wantReasons: []string{`invalid signature "jotify"`},
+		}, {
+			name:        "length limit",
+			channel:     domain.ChannelSMS,
+			signature:   "【jotify】",
+			content:     strings.Repeat("码", 13),
+			wantStatus:  domain.AuditStatusRejected,
+			wantReasons: []string{"length 21 exceeds limit 20"},
+		}, {
+			name:       "length limit only for configured channel",
+			channel:    domain.ChannelEmail,
+			content:    strings.Repeat("码", 100),
+			wantStatus: domain.AuditStatusApproved,
+		}, {
+			name:        "review word",
+			channel:     domain.ChannelSMS,
+			signature:   "【jotify】",
+			content:     "低息贷款",
+			wantStatus:  domain.AuditStatusInPreview,
+			wantReasons: []string{`review word "贷款"`},
+		}, {
+			name:       "allowed url",
+			channel:    domain.ChannelEmail,
+			content:    "see https://m.jotify.com/a?b=1",
+			wantStatus: domain.AuditStatusApproved,
+		}, {
+			name:        "url outside allow list",
+			channel:     domain.ChannelEmail,
+			content:     "see https://jotify.com.evil.io/a",
+			wantStatus:  domain.AuditStatusInPreview,
+			wantReasons: []string{`url "https://jotify.com.evil.io/a" not in allow list`},
+		}, {
+			name:        "reject before review",
+			channel:     domain.ChannelEmail,
+			content:     "casino https://evil.io",
+			wantStatus:  domain.AuditStatusRejected,
+			wantReasons: []string{`banned word "casino"`},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			tpl := domain.ChannelTpl{Name: "tpl", Channel: tc.channel}
+			version := domain.ChannelTplVersion{Signature: tc.signature, Content: tc.content}
+			v, err := stage.Check(context.Background(), tpl, version)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantStatus, v.Status)
+			assert.Equal(t, tc.wantReasons, v.Reasons)
+		})
+	}
+}
+
+func TestNewRuleStage_InvalidSignaturePattern(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewRuleStage(RuleConf{SignaturePattern: "["}, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,229 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/errs"
+	"github.com/JrMarcco/jotify/internal/pkg/metrics"
+	"github.com/JrMarcco/jotify/internal/repository"
+)
+
+//go:generate mockgen -source=./service.go -destination=./mock/service.mock.go -package=auditmock -typed Service
+
+// Service 模板审核服务接口。
+//
+// 版本的语言变体与默认内容经过相同的审核流程，变体审核时使用替换为变体签名与内容的版本。
+type Service interface {
+	// Audit 对待审核（pending）的版本执行自动审核，结果为通过、拒绝或转人工审核
+	Audit(ctx context.Context, versionId uint64) (domain.TplAudit, error)
+	// Queue 按 id 升序查询 id 大于 startId 的等待人工审核的版本
+	Queue(ctx context.Context, startId uint64, limit int) ([]domain.ChannelTplVersion, error)
+	// Review 人工审核等待人工审核的版本，拒绝时必须填写原因
+	Review(ctx context.Context, versionId uint64, auditorId uint64, approved bool, reason string) (domain.TplAudit, error)
+	// History 查询版本及其语言变体的审核记录
+	History(ctx context.Context, versionId uint64) ([]domain.TplAudit, error)
+
+	// SubmitVariant 提交版本的语言变体，版本已有该语言的变体时替换签名与内容，提交后等待自动审核
+	SubmitVariant(ctx context.Context, variant domain.ChannelTplVariant) (domain.ChannelTplVariant, error)
+	// AuditVariant 对待审核（pending）的语言变体执行自动审核
+	AuditVariant(ctx context.Context, variantId uint64) (domain.TplAudit, error)
+	// VariantQueue 按 id 升序查询 id 大于 startId 的等待人工审核的语言变体
+	VariantQueue(ctx context.Context, startId uint64, limit int) ([]domain.ChannelTplVariant, error)
+	// ReviewVariant 人工审核等待人工审核的语言变体，拒绝时必须填写原因
+	ReviewVariant(ctx context.Context, variantId uint64, auditorId uint64, approved bool, reason string) (domain.TplAudit, error)
+}
+
+var _ Service = (*DefaultService)(nil)
+
+type DefaultService struct {
+	tplRepo  repository.ChannelTplRepo
+	pipeline *Pipeline
+}
+
+func (d *DefaultService) Audit(ctx context.Context, versionId uint64) (domain.TplAudit, error) {
+	version, err := d.tplRepo.GetVersionByVersionId(ctx, versionId)
+	if err != nil {
+		return domain.TplAudit{}, err
+	}
+	if !version.AuditStatus.IsPending() {
+		return domain.TplAudit{}, fmt.Errorf(
+			"%w: version id = %d, status = %s", errs.ErrTplAuditConflict, versionId, version.AuditStatus,
+		)
+	}
+
+	tpl, err := d.tplRepo.GetById(ctx, version.ChannelTplId)
+	if err != nil {
+		return domain.TplAudit{}, err
+	}
+
+	v, err := d.pipeline.Run(ctx, tpl, version)
+	if err != nil {
+		return domain.TplAudit{}, err
+	}
+
+	return d.record(ctx, domain.AuditStatusPending, domain.TplAudit{
+		TplId:        tpl.Id,
+		TplVersionId: version.Id,
+		Stage:        domain.AuditStageRule,
+		Status:       v.Status,
+		Reasons:      v.Reasons,
+	})
+}
+
+func (d *DefaultService) Queue(ctx context.Context, startId uint64, limit int) ([]domain.ChannelTplVersion, error) {
+	return d.tplRepo.FindVersionsByAuditStatus(ctx, domain.AuditStatusInPreview, startId, limit)
+}
+
+func (d *DefaultService) Review(
+	ctx context.Context, versionId uint64, auditorId uint64, approved bool, reason string,
+) (domain.TplAudit, error) {
+	reason, err := checkReason(approved, reason)
+	if err != nil {
+		return domain.TplAudit{}, err
+	}
+
+	version, err := d.tplRepo.GetVersionByVersionId(ctx, versionId)
+	if err != nil {
+		return domain.TplAudit{}, err
+	}
+	if !version.AuditStatus.IsInPreview() {
+		return domain.TplAudit{}, fmt.Errorf(
+			"%w: version id = %d, status = %s", errs.ErrTplAuditConflict, versionId, version.AuditStatus,
+		)
+	}
+
+	return d.record(ctx, domain.AuditStatusInPreview, manualAudit(domain.TplAudit{
+		TplId:        version.ChannelTplId,
+		TplVersionId: version.Id,
+		AuditorId:    auditorId,
+	}, approved, reason))
+}
+
+func (d *DefaultService) History(ctx context.Context, versionId uint64) ([]domain.TplAudit, error) {
+	if _, err := d.tplRepo.GetVersionByVersionId(ctx, versionId); err != nil {
+		return nil, err
+	}
+	return d.tplRepo.FindAudits(ctx, versionId)
+}
+
+func (d *DefaultService) SubmitVariant(
+	ctx context.Context, variant domain.ChannelTplVariant,
+) (domain.ChannelTplVariant, error) {
+	if err := variant.Validate(); err != nil {
+		return domain.ChannelTplVariant{}, err
+	}
+
+	version, err := d.tplRepo.GetVersionByVersionId(ctx, variant.TplVersionId)
+	if err != nil {
+		return domain.ChannelTplVariant{}, err
+	}
+	variant.TplId = version.ChannelTplId
+	return d.tplRepo.SaveVariant(ctx, variant)
+}
+
+func (d *DefaultService) AuditVariant(ctx context.Context, variantId uint64) (domain.TplAudit, error) {
+	variant, err := d.tplRepo.GetVariantById(ctx, variantId)
+	if err != nil {
+		return domain.TplAudit{}, err
+	}
+	if !variant.AuditStatus.IsPending() {
+		return domain.TplAudit{}, fmt.Errorf(
+			"%w: variant id = %d, status = %s", errs.ErrTplAuditConflict, variantId, variant.AuditStatus,
+		)
+	}
+
+	version, err := d.tplRepo.GetVersionByVersionId(ctx, variant.TplVersionId)
+	if err != nil {
+		return domain.TplAudit{}, err
+	}
+	tpl, err := d.tplRepo.GetById(ctx, version.ChannelTplId)
+	if err != nil {
+		return domain.TplAudit{}, err
+	}
+
+	v, err := d.pipeline.Run(ctx, tpl, version.WithVariant(variant))
+	if err != nil {
+		return domain.TplAudit{}, err
+	}
+
+	return d.record(ctx, domain.AuditStatusPending, domain.TplAudit{
+		TplId:        tpl.Id,
+		TplVersionId: version.Id,
+		TplVariantId: variant.Id,
+		Stage:        domain.AuditStageRule,
+		Status:       v.Status,
+		Reasons:      v.Reasons,
+	})
+}
+
+func (d *DefaultService) VariantQueue(ctx context.Context, startId uint64, limit int) ([]domain.ChannelTplVariant, error) {
+	return d.tplRepo.FindVariantsByAuditStatus(ctx, domain.AuditStatusInPreview, startId, limit)
+}
+
+func (d *DefaultService) ReviewVariant(
+	ctx context.Context, variantId uint64, auditorId uint64, approved bool, reason string,
+) (domain.TplAudit, error) {
+	reason, err := checkReason(approved, reason)
+	if err != nil {
+		return domain.TplAudit{}, err
+	}
+
+	variant, err := d.tplRepo.GetVariantById(ctx, variantId)
+	if err != nil {
+		return domain.TplAudit{}, err
+	}
+	if !variant.AuditStatus.IsInPreview() {
+		return domain.TplAudit{}, fmt.Errorf(
+			"%w: variant id = %d, status = %s", errs.ErrTplAuditConflict, variantId, variant.AuditStatus,
+		)
+	}
+
+	return d.record(ctx, domain.AuditStatusInPreview, manualAudit(domain.TplAudit{
+		TplId:        variant.TplId,
+		TplVersionId: variant.TplVersionId,
+		TplVariantId: variant.Id,
+		AuditorId:    auditorId,
+	}, approved, reason))
+}
+
+// checkReason 人工审核拒绝时必须填写原因
+func checkReason(approved bool, reason string) (string, error) {
+	reason = strings.TrimSpace(reason)
+	if !approved && reason == "" {
+		return "", fmt.Errorf("%w: reject reason should not be empty", errs.ErrInvalidParam)
+	}
+	return reason, nil
+}
+
+// manualAudit 填充人工审核记录的阶段、结果与原因
+func manualAudit(a domain.TplAudit, approved bool, reason string) domain.TplAudit {
+	a.Stage = domain.AuditStageManual
+	a.Status = domain.AuditStatusApproved
+	if !approved {
+		a.Status = domain.AuditStatusRejected
+	}
+	if reason != "" {
+		a.Reasons = []string{reason}
+	}
+	return a
+}
+
+// record 保存审核记录，版本的审核状态已被并发修改时返回 errs.ErrTplAuditConflict
+func (d *DefaultService) record(ctx context.Context, from domain.AuditStatus, a domain.TplAudit) (domain.TplAudit, error) {
+	a, err := d.tplRepo.Audit(ctx, from, a)
+	if err != nil {
+		return domain.TplAudit{}, err
+	}
+	metrics.TplAudits.WithLabelValues(a.Stage.String(), a.Status.String()).Inc()
+	return a, nil
+}
+
+func NewDefaultService(tplRepo repository.ChannelTplRepo, pipeline *Pipeline) *DefaultService {
+	return &DefaultService{
+		tplRepo:  tplRepo,
+		pipeline: pipeline,
+	}
+}
@@ -0,0 +1,57 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+)
+
+// Stage 自动审核阶段，多个阶段按顺序组成审核流水线
+type Stage interface {
+	Name() string
+	// Check 审核模板版本，返回 approved、rejected 或 in_preview（转人工审核）
+	Check(ctx context.Context, tpl domain.ChannelTpl, version domain.ChannelTplVersion) (Verdict, error)
+}
+
+// Verdict 审核阶段的结果
+type Verdict struct {
+	Status  domain.AuditStatus
+	Reasons []string
+}
+
+// Pipeline 自动审核流水线。
+//
+// 依次执行各阶段：任一阶段拒绝时立即拒绝；有阶段转人工审核时继续执行后续阶段（后续阶段仍可能拒绝），
+// 最终转人工审核并汇总原因；全部阶段通过时自动通过。
+type Pipeline struct {
+	stages []Stage
+}
+
+func (p *Pipeline) Run(ctx context.Context, tpl domain.ChannelTpl, version domain.ChannelTplVersion) (Verdict, error) {
+	var reviews []string
+	for _, stage := range p.stages {
+		v, err := stage.Check(ctx, tpl, version)
+		if err != nil {
+			return Verdict{}, fmt.Errorf("audit stage %s failed: %w", stage.Name(), err)
+		}
+
+		switch v.Status {
+		case domain.AuditStatusRejected:
+			return v, nil
+		case domain.AuditStatusInPreview:
+			reviews = append(reviews, v.Reasons...)
+		}
+	}
+
+	if len(reviews) > 0 {
+		return Verdict{Status: domain.AuditStatusInPreview, Reasons: reviews}, nil
+	}
+	return Verdict{Status: domain.AuditStatusApproved}, nil
+}
+
+func NewPipeline(stages ...Stage) *Pipeline {
+	return &Pipeline{
+		stages: stages,
+	}
+}
@@ -0,0 +1,103 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStage 返回固定结果并记录调用次数的审核阶段
+type fakeStage struct {
+	name    string
+	verdict Verdict
+	err     error
+	calls   int
+}
+
+func (s *fakeStage) Name() string {
+	return s.name
+}
+
+func (s *fakeStage) Check(context.Context, domain.ChannelTpl, domain.ChannelTplVersion) (Verdict, error) {
+	s.calls++
+	return s.verdict, s.err
+}
+
+func TestPipeline_Run(t *testing.T) {
+	t.Parallel()
+
+	approved := func(name string) *fakeStage {
+		return &fakeStage{name: name, verdict: Verdict{Status: domain.AuditStatusApproved}}
+	}
+	review := func(name, reason string) *fakeStage {
+		return &fakeStage{name: name, verdict: Verdict{Status: domain.AuditStatusInPreview, Reasons: []string{reason}}}
+	}
+	reject := func(name, reason string) *fakeStage {
+		return &fakeStage{name: name, verdict: Verdict{Status: domain.AuditStatusRejected, Reasons: []string{reason}}}
+	}
+
+	tcs := []struct {
+		name      string
+		stages    []*fakeStage
+		want      Verdict
+		wantErr   bool
+		wantCalls []int
+	}{
+		{
+			name:      "all approved",
+			stages:    []*fakeStage{approved("a"), approved("b")},
+			want:      Verdict{Status: domain.AuditStatusApproved},
+			wantCalls: []int{1, 1},
+		}, {
+			name:      "reject short circuits",
+			stages:    []*fakeStage{approved("a"), reject("b", "banned"), review("c", "url")},
+			want:      Verdict{Status: domain.AuditStatusRejected, Reasons: []string{"banned"}},
+			wantCalls: []int{1, 1, 0},
+		}, {
+			name:      "review then reject",
+			stages:    []*fakeStage{review("a", "url"), reject("b", "banned")},
+			want:      Verdict{Status: domain.AuditStatusRejected, Reasons: []string{"banned"}},
+			wantCalls: []int{1, 1},
+		}, {
+			name:      "reviews merged",
+			stages:    []*fakeStage{review("a", "url"), approved("b"), review("c", "word")},
+			want:      Verdict{Status: domain.AuditStatusInPreview, Reasons: []string{"url", "word"}},
+			wantCalls: []int{1, 1, 1},
+		}, {
+			name:      "stage error",
+			stages:    []*fakeStage{{name: "a", err: errors.New("timeout")}, approved("b")},
+			wantErr:   true,
+			wantCalls: []int{1, 0},
+		}, {
+			name: "no stages",
+			want: Verdict{Status: domain.AuditStatusApproved},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			stages := make([]Stage, 0, len(tc.stages))
+			for _, s := range tc.stages {
+				stages = append(stages, s)
+			}
+
+			v, err := NewPipeline(stages...).Run(context.Background(), domain.ChannelTpl{}, domain.ChannelTplVersion{})
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.want, v)
+			}
+
+			for i, s := range tc.stages {
+				assert.Equal(t, tc.wantCalls[i], s.calls, "stage %s", s.name)
+			}
+		})
+	}
+}
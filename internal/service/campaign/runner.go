@@ -0,0 +1,294 @@
+package campaign
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/JrMarcco/dlock"
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/errs"
+	"github.com/JrMarcco/jotify/internal/pkg/job"
+	"github.com/JrMarcco/jotify/internal/pkg/metrics"
+	"github.com/JrMarcco/jotify/internal/pkg/pacing"
+	"github.com/JrMarcco/jotify/internal/repository"
+	"github.com/JrMarcco/jotify/internal/service/notification"
+	"github.com/JrMarcco/jotify/internal/service/sendstrategy"
+	"go.uber.org/zap"
+)
+
+// Runner 群发活动展开服务接口
+type Runner interface {
+	// Start 启动展开任务，context.Context 被取消时退出
+	Start(ctx context.Context) error
+}
+
+var _ Runner = (*DefaultRunner)(nil)
+
+// DefaultRunner 群发活动展开任务。
+//
+// 抢占分布式锁后每隔 interval 处理 running 与 canceling 的活动：
+//   - running：未结束的消息少于 maxInflight 时，按序号展开下一批 chunkSize 个接收者并创建消息，
+//     消息的 biz_key 由活动的 biz_key 与接收者序号派生，进度保存失败后重新展开不会重复创建；
+//     发送时间窗口结束时仍未展开的接收者记为 skipped，全部展开且消息都已结束后活动变为 finished。
+//     开启匀速发送的活动每轮只展开发送计划中下一个 interval 内应发送的接收者，详见 quota。
+//   - canceling：分批取消已创建但尚未发送的消息，全部取消后活动变为 canceled。
+//
+// 活动结束后删除保存的接收者。
+type DefaultRunner struct {
+	campaignRepo repository.CampaignRepo
+	notifRepo    repository.NotificationRepo
+	sendSvc      notification.SendService
+	pacer        *sendstrategy.Pacer
+
+	interval    time.Duration
+	chunkSize   int
+	maxInflight int64
+	batchSize   int
+
+	job    *job.LoopJob
+	logger *zap.Logger
+}
+
+func (r *DefaultRunner) Start(ctx context.Context) error {
+	go func() {
+		_ = r.job.Run(ctx)
+	}()
+	return nil
+}
+
+// loop 处理一轮全部进行中的活动后等待 interval
+func (r *DefaultRunner) loop(ctx context.Context) error {
+	start := time.Now()
+
+	var startId uint64
+	for {
+		cs, err := r.campaignRepo.FindActive(ctx, startId, r.batchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, c := range cs {
+			switch c.Status {
+			case domain.CampaignStatusRunning:
+				err = r.run(ctx, c)
+			case domain.CampaignStatusCanceling:
+				err = r.cancel(ctx, c)
+			}
+			if err != nil {
+				r.logger.Error(
+					"[jotify] failed to process campaign",
+					zap.Error(err),
+					zap.Uint64("biz_id", c.BizId),
+					zap.String("biz_key", c.BizKey),
+					zap.String("status", c.Status.String()),
+				)
+			}
+		}
+
+		if len(cs) < r.batchSize {
+			break
+		}
+		startId = cs[len(cs)-1].Id
+	}
+
+	job.WaitUntil(ctx, start.Add(r.interval))
+	return nil
+}
+
+// run 统计活动的发送进度并展开下一批接收者
+func (r *DefaultRunner) run(ctx context.Context, c domain.Campaign) error {
+	counts, err := r.notifRepo.CountByCampaign(ctx, c.Id)
+	if err != nil {
+		return err
+	}
+	inflight := c.Progress.Apply(counts)
+
+	now := time.Now()
+	switch {
+	case c.Progress.Expanded >= c.Progress.Total:
+		if inflight > 0 {
+			break
+		}
+		c.Status = domain.CampaignStatusFinished
+	case c.WindowClosed(now):
+		skipped := c.Progress.Total - c.Progress.Expanded
+		c.Progress.Skipped += skipped
+		c.Progress.Expanded = c.Progress.Total
+		metrics.CampaignReceivers.WithLabelValues("skipped").Add(float64(skipped))
+	case inflight < r.maxInflight:
+		plan, limit, err := r.quota(ctx, c, inflight, now)
+		if err != nil {
+			return err
+		}
+		if limit <= 0 {
+			break
+		}
+		if err = r.expand(ctx, &c, now, plan, int(inflight), limit); err != nil {
+			return err
+		}
+	}
+
+	if err = r.campaignRepo.CompareAndSwap(ctx, c); err != nil {
+		return err
+	}
+	if c.Status.Terminal() {
+		return r.cleanup(ctx, c)
+	}
+	return nil
+}
+
+// quota 返回本轮最多展开的接收者数，开启匀速发送时同时返回剩余发送量的计划。
+//
+// 未结束的消息与未展开的接收者每轮按原曲线在剩余的时间窗口内重新规划，本轮展开到计划中下一个 interval 结束时应发送的数量。
+// 供应商发送慢或任务停顿导致落后于计划时，落后的发送量分摊到剩余时段，速率随之提高，但不超过速率上限。
+func (r *DefaultRunner) quota(
+	ctx context.Context, c domain.Campaign, inflight int64, now time.Time,
+) (*pacing.Plan, int, error) {
+	if !c.StrategyConfig.Pacing.Enabled() {
+		return nil, r.chunkSize, nil
+	}
+	if start, _ := c.StrategyConfig.CalcTimeWindow(); now.Before(start) {
+		return nil, 0, nil
+	}
+
+	plan, err := r.pacer.Plan(ctx, c.BizId, c.Channel, c.StrategyConfig, int(c.Progress.Total))
+	if err != nil {
+		return nil, 0, err
+	}
+	rest := plan.Rest(now, int(c.Progress.Total-c.Progress.Expanded+inflight))
+	due := int(math.Ceil(rest.Due(now.Add(r.interval)))) - int(inflight)
+	return &rest, min(due, r.chunkSize), nil
+}
+
+// expand 将下一批最多 limit 个接收者展开为消息，已创建过消息的接收者（上一次展开后保存进度失败）不再重复创建。
+//
+// plan 不为空时，第 k 个接收者的消息从计划中第 offset + k 个发送量的时间开始发送。
+func (r *DefaultRunner) expand(
+	ctx context.Context, c *domain.Campaign, now time.Time, plan *pacing.Plan, offset int, limit int,
+) error {
+	rs, err := r.campaignRepo.FindReceivers(ctx, *c, c.Progress.Expanded, limit)
+	if err != nil {
+		return err
+	}
+	if len(rs) == 0 {
+		// 接收者总数与已保存的接收者不一致，视为已全部展开
+		c.Progress.Expanded = c.Progress.Total
+		return nil
+	}
+
+	keys := make([]string, 0, len(rs))
+	for _, receiver := range rs {
+		keys = append(keys, c.ReceiverKey(receiver.Seq))
+	}
+	existing, err := r.notifRepo.ExistingKeys(ctx, c.BizId, keys)
+	if err != nil {
+		return err
+	}
+
+	ns := make([]domain.Notification, 0, len(rs))
+	for k, receiver := range rs {
+		n := c.Notification(receiver, now)
+		if _, ok := existing[n.BizKey]; ok {
+			continue
+		}
+		if plan != nil {
+			_, end := n.StrategyConfig.CalcTimeWindow()
+			n.StrategyConfig = domain.SendStrategyConf{
+				Type:  domain.SendStrategyTimeWindow,
+				Start: plan.At(offset + k),
+				End:   end,
+			}
+		}
+		ns = append(ns, n)
+	}
+
+	var resp domain.BatchAsyncSendResp
+	if len(ns) > 0 {
+		if resp, err = r.sendSvc.BatchAsyncSend(ctx, ns); err != nil {
+			return fmt.Errorf("failed to send campaign receivers after seq %d: %w", c.Progress.Expanded, err)
+		}
+	}
+
+	accepted := int64(len(existing) + len(resp.NotificationIds))
+	suppressed := int64(len(resp.Suppressed))
+	c.Progress.Accepted += accepted
+	c.Progress.Suppressed += suppressed
+	c.Progress.Expanded = rs[len(rs)-1].Seq
+
+	metrics.CampaignReceivers.WithLabelValues("accepted").Add(float64(accepted))
+	metrics.CampaignReceivers.WithLabelValues("suppressed").Add(float64(suppressed))
+	return nil
+}
+
+// cancel 取消一批活动已创建的待发送消息，没有待发送消息时活动变为 canceled
+func (r *DefaultRunner) cancel(ctx context.Context, c domain.Campaign) error {
+	ns, err := r.notifRepo.FindPendingByCampaign(ctx, c.Id, r.chunkSize)
+	if err != nil {
+		return err
+	}
+	if len(ns) > 0 {
+		for _, n := range ns {
+			// 版本号冲突说明消息已开始发送或已被取消
+			if err = r.notifRepo.Cancel(ctx, n); err != nil && !errors.Is(err, errs.ErrNotificationVersionConflict) {
+				return err
+			}
+		}
+		return nil
+	}
+
+	counts, err := r.notifRepo.CountByCampaign(ctx, c.Id)
+	if err != nil {
+		return err
+	}
+	c.Progress.Apply(counts)
+	c.Status = domain.CampaignStatusCanceled
+	if err = r.campaignRepo.CompareAndSwap(ctx, c); err != nil {
+		return err
+	}
+	return r.cleanup(ctx, c)
+}
+
+// cleanup 删除已结束活动的接收者
+func (r *DefaultRunner) cleanup(ctx context.Context, c domain.Campaign) error {
+	for {
+		deleted, err := r.campaignRepo.DeleteReceivers(ctx, c.Id, r.chunkSize)
+		if err != nil {
+			return err
+		}
+		if deleted < int64(r.chunkSize) {
+			return nil
+		}
+	}
+}
+
+func NewDefaultRunner(
+	dclient dlock.Dclient,
+	campaignRepo repository.CampaignRepo,
+	notifRepo repository.NotificationRepo,
+	sendSvc notification.SendService,
+	pacer *sendstrategy.Pacer,
+	interval time.Duration,
+	chunkSize int,
+	maxInflight int64,
+	batchSize int,
+	logger *zap.Logger,
+) *DefaultRunner {
+	const jobKey = "jotify_campaign_runner"
+
+	runner := &DefaultRunner{
+		campaignRepo: campaignRepo,
+		notifRepo:    notifRepo,
+		sendSvc:      sendSvc,
+		pacer:        pacer,
+		interval:     interval,
+		chunkSize:    chunkSize,
+		maxInflight:  maxInflight,
+		batchSize:    batchSize,
+		logger:       logger,
+	}
+	runner.job = job.NewLoopJob(jobKey, dclient, logger, runner.loop)
+	return runner
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/JrMarcco/jotify/internal/domain"
 	"github.com/JrMarcco/jotify/internal/errs"
+	"github.com/JrMarcco/jotify/internal/pkg/tracing"
 )
 
 //go:generate mockgen -source=./types.go -destination=./mock/channel.mock.go -package=channelmock -typed Channel
@@ -23,10 +24,21 @@ type Dispatcher struct {
 }
 
 func (d *Dispatcher) Send(ctx context.Context, n domain.Notification) (domain.SendResp, error) {
-	if channel, ok := d.channels[n.Channel]; ok {
-		return channel.Send(ctx, n)
+	ctx, span := tracing.Start(ctx, "Channel.Send",
+		tracing.AttrChannel.String(n.Channel.String()),
+		tracing.AttrNotificationId.Int64(int64(n.Id)),
+	)
+
+	channel, ok := d.channels[n.Channel]
+	if !ok {
+		err := fmt.Errorf("%w", errs.ErrInvalidChannel)
+		tracing.End(span, err)
+		return domain.SendResp{}, err
 	}
-	return domain.SendResp{}, fmt.Errorf("%w", errs.ErrInvalidChannel)
+
+	resp, err := channel.Send(ctx, n)
+	tracing.End(span, err)
+	return resp, err
 }
 
 func NewDispatcher(channels map[domain.Channel]Channel) *Dispatcher {
@@ -0,0 +1,112 @@
+package expiry
+
+import (
+	"context"
+	"time"
+
+	"github.com/JrMarcco/dlock"
+	"github.com/JrMarcco/easy-kit/slice"
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/pkg/job"
+	"github.com/JrMarcco/jotify/internal/pkg/metrics"
+	"github.com/JrMarcco/jotify/internal/pkg/sharding"
+	"github.com/JrMarcco/jotify/internal/repository"
+	"github.com/JrMarcco/jotify/internal/service/notification/callback"
+	"go.uber.org/zap"
+)
+
+// Sweeper 过期消息清理服务接口
+type Sweeper interface {
+	// Start 启动清理任务，context.Context 被取消时退出
+	Start(ctx context.Context) error
+}
+
+var _ Sweeper = (*ShardingSweeper)(nil)
+
+// ShardingSweeper 分库分表过期消息清理任务。
+//
+// 按分表抢占分布式锁，每隔 interval 扫描一遍分表，将发送时间窗口已结束仍为 pending 的消息标记为 expired，
+// 退还配额并回调通知业务方。调度器只发送窗口内的消息，过期的消息不会被延迟发出。
+// 在线扩容期间暂停清理，避免回调记录所在的分表与消息不一致。
+type ShardingSweeper struct {
+	notifRepo   repository.NotificationRepo
+	callbackSvc callback.Service
+	strategy    sharding.Strategy
+
+	interval  time.Duration
+	batchSize int
+
+	job    *job.ShardingLoopJob
+	logger *zap.Logger
+}
+
+func (s *ShardingSweeper) Start(ctx context.Context) error {
+	go func() {
+		_ = s.job.Run(ctx)
+	}()
+	return nil
+}
+
+// loop 清理当前分表中截至本轮开始时已过期的消息，清理完成后等待 interval
+func (s *ShardingSweeper) loop(ctx context.Context) error {
+	dst, _ := sharding.DstFromContext(ctx)
+
+	if ms, ok := s.strategy.(sharding.MigratingStrategy); ok && ms.Migrating() {
+		job.WaitUntil(ctx, time.Now().Add(s.interval))
+		return nil
+	}
+
+	now := time.Now()
+	startId := uint64(0)
+	for {
+		ns, err := s.notifRepo.FindExpired(ctx, dst, startId, now, s.batchSize)
+		if err != nil {
+			return err
+		}
+		if len(ns) == 0 {
+			job.WaitUntil(ctx, now.Add(s.interval))
+			return nil
+		}
+
+		ids := slice.Map(ns, func(_ int, n domain.Notification) uint64 { return n.Id })
+		expired, err := s.notifRepo.Expire(ctx, dst, ids, now)
+		if err != nil {
+			return err
+		}
+		metrics.ExpiredNotifications.WithLabelValues(dst.DB, dst.Table).Add(float64(len(expired)))
+
+		for _, n := range expired {
+			// 回调失败时回调记录保持待发送，由回调重试处理
+			if err = s.callbackSvc.SendByNotification(ctx, n); err != nil {
+				s.logger.Warn("[jotify] failed to send expired callback", zap.Error(err), zap.Uint64("notification_id", n.Id))
+			}
+		}
+		startId = ns[len(ns)-1].Id
+	}
+}
+
+func NewShardingSweeper(
+	dclient dlock.Dclient,
+	notifRepo repository.NotificationRepo,
+	callbackSvc callback.Service,
+	strategy sharding.Strategy,
+	resourceSemaphore job.ResourceSemaphore,
+	interval time.Duration,
+	batchSize int,
+	logger *zap.Logger,
+) *ShardingSweeper {
+	const jobBaseKey = "jotify_notification_expiry_sweeper"
+
+	sweeper := &ShardingSweeper{
+		notifRepo:   notifRepo,
+		callbackSvc: callbackSvc,
+		strategy:    strategy,
+		interval:    interval,
+		batchSize:   batchSize,
+		logger:      logger,
+	}
+	sweeper.job = job.NewShardingLoopJob(
+		jobBaseKey, resourceSemaphore, strategy, dclient, logger, sweeper.loop,
+	)
+	return sweeper
+}
@@ -0,0 +1,114 @@
+package expiry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/pkg/sharding"
+	"github.com/JrMarcco/jotify/internal/repository"
+	"github.com/JrMarcco/jotify/internal/service/notification/callback"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeNotifRepo 分表中的待发送消息，id 有序
+type fakeNotifRepo struct {
+	repository.NotificationRepo
+	pending []domain.Notification
+	expired []uint64
+}
+
+func (r *fakeNotifRepo) FindExpired(
+	_ context.Context, _ sharding.Dst, startId uint64, before time.Time, limit int,
+) ([]domain.Notification, error) {
+	var ns []domain.Notification
+	for _, n := range r.pending {
+		if n.Id > startId && !n.ScheduledEnd.After(before) && len(ns) < limit {
+			ns = append(ns, n)
+		}
+	}
+	return ns, nil
+}
+
+func (r *fakeNotifRepo) Expire(_ context.Context, _ sharding.Dst, ids []uint64, _ time.Time) ([]domain.Notification, error) {
+	var ns []domain.Notification
+	for _, id := range ids {
+		// id 为偶数的消息已被并发发送，不再过期
+		if id%2 == 0 {
+			continue
+		}
+		r.expired = append(r.expired, id)
+		ns = append(ns, domain.Notification{Id: id})
+	}
+	return ns, nil
+}
+
+type fakeCallbackSvc struct {
+	callback.Service
+	sent []uint64
+}
+
+func (s *fakeCallbackSvc) SendByNotification(_ context.Context, n domain.Notification) error {
+	s.sent = append(s.sent, n.Id)
+	return nil
+}
+
+func TestShardingSweeper_Loop(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	notifRepo := &fakeNotifRepo{pending: []domain.Notification{
+		{Id: 1, ScheduledEnd: now.Add(-time.Hour)},
+		{Id: 2, ScheduledEnd: now.Add(-time.Minute)},
+		{Id: 3, ScheduledEnd: now.Add(-time.Second)},
+		{Id: 4, ScheduledEnd: now.Add(time.Hour)},
+		{Id: 5, ScheduledEnd: now.Add(-time.Second)},
+	}}
+	callbackSvc := &fakeCallbackSvc{}
+	strategy := sharding.NewHashStrategy("jotify", "notification", 1, 1)
+
+	s := &ShardingSweeper{
+		notifRepo:   notifRepo,
+		callbackSvc: callbackSvc,
+		strategy:    strategy,
+		interval:    time.Millisecond,
+		batchSize:   2,
+		logger:      zap.NewNop(),
+	}
+
+	ctx := sharding.ContextWitDst(t.Context(), strategy.BroadCast()[0])
+	require.NoError(t, s.loop(ctx))
+
+	// 窗口未结束的消息不过期，已被并发发送的消息不回调
+	assert.Equal(t, []uint64{1, 3, 5}, notifRepo.expired)
+	assert.Equal(t, []uint64{1, 3, 5}, callbackSvc.sent)
+}
+
+func TestShardingSweeper_Loop_Migrating(t *testing.T) {
+	t.Parallel()
+
+	previous := sharding.Layout{Version: 1, DBSharding: 1, TableSharding: 1}
+	strategy, err := sharding.NewVersionedStrategy("jotify", "notification", sharding.RoutingTable{
+		Current:  sharding.Layout{Version: 2, DBSharding: 1, TableSharding: 2},
+		Previous: &previous,
+	})
+	require.NoError(t, err)
+
+	notifRepo := &fakeNotifRepo{pending: []domain.Notification{{Id: 1, ScheduledEnd: time.Now().Add(-time.Hour)}}}
+	s := &ShardingSweeper{
+		notifRepo:   notifRepo,
+		callbackSvc: &fakeCallbackSvc{},
+		strategy:    strategy,
+		interval:    time.Millisecond,
+		batchSize:   2,
+		logger:      zap.NewNop(),
+	}
+
+	// 在线扩容期间暂停清理
+	ctx := sharding.ContextWitDst(t.Context(), strategy.BroadCast()[0])
+	require.NoError(t, s.loop(ctx))
+	assert.Empty(t, notifRepo.expired)
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/JrMarcco/jotify/internal/errs"
 	grpcpkg "github.com/JrMarcco/jotify/internal/pkg/grpc"
 	"github.com/JrMarcco/jotify/internal/pkg/retry"
+	"github.com/JrMarcco/jotify/internal/pkg/tracing"
 	"github.com/JrMarcco/jotify/internal/repository"
 	"go.uber.org/zap"
 )
@@ -164,7 +165,13 @@ func (d *DefaultService) SendByNotifications(ctx context.Context, ns []domain.No
 	return err
 }
 
-func (d *DefaultService) sendCallback(ctx context.Context, n domain.Notification) (*clientv1.SendResultNotifyResponse, error) {
+func (d *DefaultService) sendCallback(ctx context.Context, n domain.Notification) (_ *clientv1.SendResultNotifyResponse, err error) {
+	ctx, span := tracing.StartWithLinks(ctx, "Callback.SendResultNotify", tracing.Links(n.TraceCtx),
+		tracing.AttrNotificationId.Int64(int64(n.Id)),
+		tracing.AttrBizId.Int64(int64(n.BizId)),
+	)
+	defer func() { tracing.End(span, err) }()
+
 	conf, err := d.getConf(ctx, n.BizId)
 	if err != nil {
 		d.logger.Warn("[jotify] failed to get biz conf", zap.Uint64("biz_id", n.BizId), zap.Error(err))
@@ -237,6 +244,9 @@ func (d *DefaultService) getStatus(n domain.Notification) notificationv1.SendSta
 		status = notificationv1.SendStatus_SUCCESS
 	case domain.SendStatusFailure:
 		status = notificationv1.SendStatus_FAILURE
+	case domain.SendStatusExpired:
+		// api 中没有过期状态，过期视为发送失败通知业务方
+		status = notificationv1.SendStatus_FAILURE
 	case domain.SendStatusPrepare:
 		status = notificationv1.SendStatus_PREPARE
 	case domain.SendStatusPending:
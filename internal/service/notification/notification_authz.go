@@ -0,0 +1,341 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/errs"
+	"github.com/JrMarcco/jotify/internal/pkg/auth"
+	"github.com/JrMarcco/jotify/internal/pkg/client"
+	"github.com/JrMarcco/jotify/internal/repository"
+)
+
+// 授权装饰器，将请求绑定到 token 中的业务 id 并校验授权范围。
+//
+// 业务 id 与授权范围由 jwt 拦截器（或 HTTP 网关）写入 context.Context，
+// 拥有 admin 授权范围的 token 可以跨业务方访问，其余 token 只能访问自己业务方的消息。
+
+var _ SendService = (*AuthzSendService)(nil)
+
+type AuthzSendService struct {
+	svc SendService
+}
+
+func (a *AuthzSendService) Send(ctx context.Context, n domain.Notification) (domain.SendResp, error) {
+	if err := bindBizId(ctx, auth.ScopeSend, &n); err != nil {
+		return domain.SendResp{}, err
+	}
+	return a.svc.Send(ctx, n)
+}
+
+func (a *AuthzSendService) AsyncSend(ctx context.Context, n domain.Notification) (domain.SendResp, error) {
+	if err := bindBizId(ctx, auth.ScopeSend, &n); err != nil {
+		return domain.SendResp{}, err
+	}
+	return a.svc.AsyncSend(ctx, n)
+}
+
+func (a *AuthzSendService) BatchSend(ctx context.Context, ns []domain.Notification) (domain.BatchSendResp, error) {
+	for i := range ns {
+		if err := bindBizId(ctx, auth.ScopeSend, &ns[i]); err != nil {
+			return domain.BatchSendResp{}, err
+		}
+	}
+	return a.svc.BatchSend(ctx, ns)
+}
+
+func (a *AuthzSendService) BatchAsyncSend(ctx context.Context, ns []domain.Notification) (domain.BatchAsyncSendResp, error) {
+	for i := range ns {
+		if err := bindBizId(ctx, auth.ScopeSend, &ns[i]); err != nil {
+			return domain.BatchAsyncSendResp{}, err
+		}
+	}
+	return a.svc.BatchAsyncSend(ctx, ns)
+}
+
+func NewAuthzSendService(svc SendService) *AuthzSendService {
+	return &AuthzSendService{svc: svc}
+}
+
+var _ QueryService = (*AuthzQueryService)(nil)
+
+type AuthzQueryService struct {
+	svc QueryService
+}
+
+// GetByKey 校验查询到的消息属于 token 中的业务方
+func (a *AuthzQueryService) GetByKey(ctx context.Context, bizId uint64, bizKey string) (domain.Notification, error) {
+	if err := auth.CheckScope(ctx, auth.ScopeQuery); err != nil {
+		return domain.Notification{}, err
+	}
+
+	n, err := a.svc.GetByKey(ctx, bizId, bizKey)
+	if err != nil {
+		return domain.Notification{}, err
+	}
+	if err = checkBizId(ctx, auth.ScopeQuery, n.BizId); err != nil {
+		return domain.Notification{}, err
+	}
+	return n, nil
+}
+
+// BatchGetByIds 消息 id 全局唯一，校验查询到的每条消息都属于 token 中的业务方
+func (a *AuthzQueryService) BatchGetByIds(ctx context.Context, bizId uint64, ids []uint64) (map[uint64]domain.Notification, error) {
+	if err := auth.CheckScope(ctx, auth.ScopeQuery); err != nil {
+		return nil, err
+	}
+
+	m, err := a.svc.BatchGetByIds(ctx, bizId, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range m {
+		if err = checkBizId(ctx, auth.ScopeQuery, n.BizId); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func NewAuthzQueryService(svc QueryService) *AuthzQueryService {
+	return &AuthzQueryService{svc: svc}
+}
+
+var _ CancelService = (*AuthzCancelService)(nil)
+
+type AuthzCancelService struct {
+	svc       CancelService
+	notifRepo repository.NotificationRepo
+}
+
+// Cancel 取消前校验消息属于 token 中的业务方
+func (a *AuthzCancelService) Cancel(ctx context.Context, bizId uint64, bizKey string) error {
+	if err := auth.CheckScope(ctx, auth.ScopeSend); err != nil {
+		return err
+	}
+	n, err := a.notifRepo.GetByKey(ctx, bizId, bizKey)
+	if err != nil {
+		return err
+	}
+	if err = checkBizId(ctx, auth.ScopeSend, n.BizId); err != nil {
+		return err
+	}
+	return a.svc.Cancel(ctx, n.BizId, bizKey)
+}
+
+func NewAuthzCancelService(svc CancelService, notifRepo repository.NotificationRepo) *AuthzCancelService {
+	return &AuthzCancelService{
+		svc:       svc,
+		notifRepo: notifRepo,
+	}
+}
+
+var _ ErasureService = (*AuthzErasureService)(nil)
+
+type AuthzErasureService struct {
+	svc ErasureService
+}
+
+func (a *AuthzErasureService) Erase(ctx context.Context, bizId uint64, receiver string) (domain.ErasureReceipt, error) {
+	if err := checkBizId(ctx, auth.ScopeErasure, bizId); err != nil {
+		return domain.ErasureReceipt{}, err
+	}
+	return a.svc.Erase(ctx, bizId, receiver)
+}
+
+func (a *AuthzErasureService) GetReceipt(ctx context.Context, bizId uint64, id uint64) (domain.ErasureReceipt, error) {
+	if err := checkBizId(ctx, auth.ScopeErasure, bizId); err != nil {
+		return domain.ErasureReceipt{}, err
+	}
+	return a.svc.GetReceipt(ctx, bizId, id)
+}
+
+func NewAuthzErasureService(svc ErasureService) *AuthzErasureService {
+	return &AuthzErasureService{svc: svc}
+}
+
+var _ SuppressionService = (*AuthzSuppressionService)(nil)
+
+// AuthzSuppressionService bizId 为 0 的全局名单只有 admin 可以管理
+type AuthzSuppressionService struct {
+	svc SuppressionService
+}
+
+func (a *AuthzSuppressionService) Import(ctx context.Context, bizId uint64, ss []domain.Suppression) error {
+	if err := checkBizId(ctx, auth.ScopeSuppression, bizId); err != nil {
+		return err
+	}
+	return a.svc.Import(ctx, bizId, ss)
+}
+
+func (a *AuthzSuppressionService) Remove(ctx context.Context, bizId uint64, channel domain.Channel, receiver string) error {
+	if err := checkBizId(ctx, auth.ScopeSuppression, bizId); err != nil {
+		return err
+	}
+	return a.svc.Remove(ctx, bizId, channel, receiver)
+}
+
+func (a *AuthzSuppressionService) List(
+	ctx context.Context, bizId uint64, receiver string, offset int, limit int,
+) ([]domain.Suppression, error) {
+	if err := checkBizId(ctx, auth.ScopeSuppression, bizId); err != nil {
+		return nil, err
+	}
+	return a.svc.List(ctx, bizId, receiver, offset, limit)
+}
+
+func NewAuthzSuppressionService(svc SuppressionService) *AuthzSuppressionService {
+	return &AuthzSuppressionService{svc: svc}
+}
+
+var _ RecurringService = (*AuthzRecurringService)(nil)
+
+type AuthzRecurringService struct {
+	svc RecurringService
+}
+
+func (a *AuthzRecurringService) List(ctx context.Context, bizId uint64, offset int, limit int) ([]domain.RecurringSchedule, error) {
+	if err := checkBizId(ctx, auth.ScopeQuery, bizId); err != nil {
+		return nil, err
+	}
+	return a.svc.List(ctx, bizId, offset, limit)
+}
+
+func (a *AuthzRecurringService) Pause(ctx context.Context, bizId uint64, bizKey string) error {
+	if err := checkBizId(ctx, auth.ScopeSend, bizId); err != nil {
+		return err
+	}
+	return a.svc.Pause(ctx, bizId, bizKey)
+}
+
+func (a *AuthzRecurringService) Resume(ctx context.Context, bizId uint64, bizKey string) error {
+	if err := checkBizId(ctx, auth.ScopeSend, bizId); err != nil {
+		return err
+	}
+	return a.svc.Resume(ctx, bizId, bizKey)
+}
+
+func (a *AuthzRecurringService) Delete(ctx context.Context, bizId uint64, bizKey string) error {
+	if err := checkBizId(ctx, auth.ScopeSend, bizId); err != nil {
+		return err
+	}
+	return a.svc.Delete(ctx, bizId, bizKey)
+}
+
+func NewAuthzRecurringService(svc RecurringService) *AuthzRecurringService {
+	return &AuthzRecurringService{svc: svc}
+}
+
+var _ CampaignService = (*AuthzCampaignService)(nil)
+
+type AuthzCampaignService struct {
+	svc CampaignService
+}
+
+func (a *AuthzCampaignService) Create(ctx context.Context, c domain.Campaign) (domain.Campaign, error) {
+	if err := checkBizId(ctx, auth.ScopeSend, c.BizId); err != nil {
+		return domain.Campaign{}, err
+	}
+	return a.svc.Create(ctx, c)
+}
+
+func (a *AuthzCampaignService) AppendReceivers(
+	ctx context.Context, bizId uint64, bizKey string, rs []domain.CampaignReceiver,
+) (int64, error) {
+	if err := checkBizId(ctx, auth.ScopeSend, bizId); err != nil {
+		return 0, err
+	}
+	return a.svc.AppendReceivers(ctx, bizId, bizKey, rs)
+}
+
+func (a *AuthzCampaignService) Get(ctx context.Context, bizId uint64, bizKey string) (domain.Campaign, error) {
+	if err := checkBizId(ctx, auth.ScopeQuery, bizId); err != nil {
+		return domain.Campaign{}, err
+	}
+	return a.svc.Get(ctx, bizId, bizKey)
+}
+
+func (a *AuthzCampaignService) List(ctx context.Context, bizId uint64, offset int, limit int) ([]domain.Campaign, error) {
+	if err := checkBizId(ctx, auth.ScopeQuery, bizId); err != nil {
+		return nil, err
+	}
+	return a.svc.List(ctx, bizId, offset, limit)
+}
+
+func (a *AuthzCampaignService) Start(ctx context.Context, bizId uint64, bizKey string) error {
+	if err := checkBizId(ctx, auth.ScopeSend, bizId); err != nil {
+		return err
+	}
+	return a.svc.Start(ctx, bizId, bizKey)
+}
+
+func (a *AuthzCampaignService) Pause(ctx context.Context, bizId uint64, bizKey string) error {
+	if err := checkBizId(ctx, auth.ScopeSend, bizId); err != nil {
+		return err
+	}
+	return a.svc.Pause(ctx, bizId, bizKey)
+}
+
+func (a *AuthzCampaignService) Resume(ctx context.Context, bizId uint64, bizKey string) error {
+	if err := checkBizId(ctx, auth.ScopeSend, bizId); err != nil {
+		return err
+	}
+	return a.svc.Resume(ctx, bizId, bizKey)
+}
+
+func (a *AuthzCampaignService) Cancel(ctx context.Context, bizId uint64, bizKey string) error {
+	if err := checkBizId(ctx, auth.ScopeSend, bizId); err != nil {
+		return err
+	}
+	return a.svc.Cancel(ctx, bizId, bizKey)
+}
+
+func NewAuthzCampaignService(svc CampaignService) *AuthzCampaignService {
+	return &AuthzCampaignService{svc: svc}
+}
+
+// bindBizId 将消息的业务 id 绑定为 token 中的业务 id。
+//
+// 消息未指定业务 id 时直接使用 token 中的业务 id，指定了其他业务方的 id 时只有 admin 允许。
+func bindBizId(ctx context.Context, scope auth.Scope, n *domain.Notification) error {
+	if err := auth.CheckScope(ctx, scope); err != nil {
+		return err
+	}
+
+	bizId, ok := client.BizIdFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("%w: missing biz id", errs.ErrPermissionDenied)
+	}
+
+	if n.BizId == 0 {
+		n.BizId = bizId
+		return nil
+	}
+	if n.BizId != bizId && !auth.HasScope(ctx, auth.ScopeAdmin) {
+		return fmt.Errorf("%w: cannot access notifications of biz %d", errs.ErrPermissionDenied, n.BizId)
+	}
+	return nil
+}
+
+// checkBizId 校验资源所属的业务 id 与 token 中的业务 id 一致，admin 允许跨业务方访问。
+//
+// 能够先加载资源的场景（消息查询、取消）传入资源上的业务 id，而不是调用方传入的业务 id。
+func checkBizId(ctx context.Context, scope auth.Scope, bizId uint64) error {
+	if err := auth.CheckScope(ctx, scope); err != nil {
+		return err
+	}
+
+	if auth.HasScope(ctx, auth.ScopeAdmin) {
+		return nil
+	}
+
+	ctxBizId, ok := client.BizIdFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("%w: missing biz id", errs.ErrPermissionDenied)
+	}
+	if ctxBizId != bizId {
+		return fmt.Errorf("%w: cannot access notifications of biz %d", errs.ErrPermissionDenied, bizId)
+	}
+	return nil
+}
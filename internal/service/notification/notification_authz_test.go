@@ -0,0 +1,120 @@
+package notification
+
+import (
+	"context"
+	"testing"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/errs"
+	"github.com/JrMarcco/jotify/internal/pkg/auth"
+	"github.com/JrMarcco/jotify/internal/pkg/client"
+	"github.com/JrMarcco/jotify/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAuthzNotifRepo 按 biz key 返回预设的消息，不校验业务 id
+type fakeAuthzNotifRepo struct {
+	repository.NotificationRepo
+	ns map[string]domain.Notification
+}
+
+func (r *fakeAuthzNotifRepo) GetByKey(_ context.Context, _ uint64, bizKey string) (domain.Notification, error) {
+	n, ok := r.ns[bizKey]
+	if !ok {
+		return domain.Notification{}, errs.ErrNotificationNotFound
+	}
+	return n, nil
+}
+
+// fakeQueryService 不按业务方过滤，模拟内层服务遗漏校验
+type fakeQueryService struct {
+	repo *fakeAuthzNotifRepo
+}
+
+func (s *fakeQueryService) GetByKey(ctx context.Context, bizId uint64, bizKey string) (domain.Notification, error) {
+	return s.repo.GetByKey(ctx, bizId, bizKey)
+}
+
+func (s *fakeQueryService) BatchGetByIds(_ context.Context, _ uint64, ids []uint64) (map[uint64]domain.Notification, error) {
+	m := make(map[uint64]domain.Notification)
+	for _, n := range s.repo.ns {
+		for _, id := range ids {
+			if n.Id == id {
+				m[id] = n
+			}
+		}
+	}
+	return m, nil
+}
+
+type fakeCancelService struct {
+	cancelled []string
+}
+
+func (s *fakeCancelService) Cancel(_ context.Context, _ uint64, bizKey string) error {
+	s.cancelled = append(s.cancelled, bizKey)
+	return nil
+}
+
+func TestAuthzService_CrossTenant(t *testing.T) {
+	t.Parallel()
+
+	// 业务方 1 的 token 访问业务方 2 的消息
+	repo := &fakeAuthzNotifRepo{ns: map[string]domain.Notification{
+		"own":   {Id: 1, BizId: 1, BizKey: "own"},
+		"other": {Id: 2, BizId: 2, BizKey: "other"},
+	}}
+
+	tcs := []struct {
+		name    string
+		scopes  []auth.Scope
+		bizKey  string
+		wantErr error
+	}{
+		{
+			name:   "own notification",
+			scopes: []auth.Scope{auth.ScopeSend, auth.ScopeQuery},
+			bizKey: "own",
+		}, {
+			name:    "other tenant rejected",
+			scopes:  []auth.Scope{auth.ScopeSend, auth.ScopeQuery},
+			bizKey:  "other",
+			wantErr: errs.ErrPermissionDenied,
+		}, {
+			name:   "admin allowed",
+			scopes: []auth.Scope{auth.ScopeAdmin},
+			bizKey: "other",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := auth.WithScopes(client.WithBizId(t.Context(), 1), tc.scopes)
+			querySvc := NewAuthzQueryService(&fakeQueryService{repo: repo})
+			cancelSvc := &fakeCancelService{}
+			authzCancelSvc := NewAuthzCancelService(cancelSvc, repo)
+
+			n, err := querySvc.GetByKey(ctx, 1, tc.bizKey)
+			assert.ErrorIs(t, err, tc.wantErr)
+
+			id := repo.ns[tc.bizKey].Id
+			m, err := querySvc.BatchGetByIds(ctx, 1, []uint64{id})
+			assert.ErrorIs(t, err, tc.wantErr)
+
+			err = authzCancelSvc.Cancel(ctx, 1, tc.bizKey)
+			assert.ErrorIs(t, err, tc.wantErr)
+
+			if tc.wantErr != nil {
+				assert.Empty(t, cancelSvc.cancelled)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.bizKey, n.BizKey)
+			assert.Contains(t, m, id)
+			assert.Equal(t, []string{tc.bizKey}, cancelSvc.cancelled)
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/errs"
+	"github.com/JrMarcco/jotify/internal/repository"
+)
+
+//go:generate mockgen -source=./notification_campaign.go -destination=./mock/campaign_service.mock.go -package=notificationmock -typed CampaignService
+
+// CampaignService 群发活动管理。
+//
+// 活动创建后为 draft 状态，可以多次上传接收者，开始后由后台任务分批展开为消息发送。
+// 暂停只停止展开，已创建的消息照常发送；取消会同时取消已创建但尚未发送的消息。
+type CampaignService interface {
+	Create(ctx context.Context, c domain.Campaign) (domain.Campaign, error)
+	// AppendReceivers 为 draft 状态的活动追加接收者，返回追加后的接收者总数
+	AppendReceivers(ctx context.Context, bizId uint64, bizKey string, rs []domain.CampaignReceiver) (int64, error)
+	// Get 查询活动，未结束的活动实时统计发送进度
+	Get(ctx context.Context, bizId uint64, bizKey string) (domain.Campaign, error)
+	List(ctx context.Context, bizId uint64, offset int, limit int) ([]domain.Campaign, error)
+	Start(ctx context.Context, bizId uint64, bizKey string) error
+	Pause(ctx context.Context, bizId uint64, bizKey string) error
+	Resume(ctx context.Context, bizId uint64, bizKey string) error
+	Cancel(ctx context.Context, bizId uint64, bizKey string) error
+}
+
+var _ CampaignService = (*DefaultCampaignService)(nil)
+
+type DefaultCampaignService struct {
+	campaignRepo repository.CampaignRepo
+	notifRepo    repository.NotificationRepo
+}
+
+func (d *DefaultCampaignService) Create(ctx context.Context, c domain.Campaign) (domain.Campaign, error) {
+	if err := c.Validate(); err != nil {
+		return domain.Campaign{}, err
+	}
+
+	c.Status = domain.CampaignStatusDraft
+	return d.campaignRepo.Create(ctx, c)
+}
+
+func (d *DefaultCampaignService) AppendReceivers(
+	ctx context.Context, bizId uint64, bizKey string, rs []domain.CampaignReceiver,
+) (int64, error) {
+	if bizKey == "" {
+		return 0, fmt.Errorf("%w: biz key should not be empty", errs.ErrInvalidParam)
+	}
+	if len(rs) == 0 {
+		return 0, fmt.Errorf("%w: receivers should not be empty", errs.ErrInvalidParam)
+	}
+	for i := range rs {
+		if rs[i].Receiver = strings.TrimSpace(rs[i].Receiver); rs[i].Receiver == "" {
+			return 0, fmt.Errorf("%w: receiver at row %d should not be empty", errs.ErrInvalidParam, i+1)
+		}
+	}
+	return d.campaignRepo.AppendReceivers(ctx, bizId, bizKey, rs)
+}
+
+func (d *DefaultCampaignService) Get(ctx context.Context, bizId uint64, bizKey string) (domain.Campaign, error) {
+	c, err := d.get(ctx, bizId, bizKey)
+	if err != nil {
+		return domain.Campaign{}, err
+	}
+
+	if c.Status.Terminal() || c.Progress.Accepted == 0 {
+		return c, nil
+	}
+	counts, err := d.notifRepo.CountByCampaign(ctx, c.Id)
+	if err != nil {
+		return domain.Campaign{}, err
+	}
+	c.Progress.Apply(counts)
+	return c, nil
+}
+
+func (d *DefaultCampaignService) List(ctx context.Context, bizId uint64, offset int, limit int) ([]domain.Campaign, error) {
+	const maxLimit = 500
+	if offset < 0 || limit <= 0 || limit > maxLimit {
+		return nil, fmt.Errorf("%w: offset should not be negative and limit should be in (0, %d]", errs.ErrInvalidParam, maxLimit)
+	}
+	return d.campaignRepo.List(ctx, bizId, offset, limit)
+}
+
+func (d *DefaultCampaignService) Start(ctx context.Context, bizId uint64, bizKey string) error {
+	return d.update(ctx, bizId, bizKey, func(c *domain.Campaign) (bool, error) {
+		switch c.Status {
+		case domain.CampaignStatusRunning:
+			return false, nil
+		case domain.CampaignStatusDraft:
+		default:
+			return false, fmt.Errorf("%w: status = %s", errs.ErrCampaignConflict, c.Status)
+		}
+
+		if c.Progress.Total == 0 {
+			return false, fmt.Errorf("%w: campaign has no receivers", errs.ErrCampaignConflict)
+		}
+		if err := c.StrategyConfig.Validate(); err != nil {
+			return false, err
+		}
+		c.Status = domain.CampaignStatusRunning
+		return true, nil
+	})
+}
+
+func (d *DefaultCampaignService) Pause(ctx context.Context, bizId uint64, bizKey string) error {
+	return d.update(ctx, bizId, bizKey, transit(domain.CampaignStatusRunning, domain.CampaignStatusPaused))
+}
+
+func (d *DefaultCampaignService) Resume(ctx context.Context, bizId uint64, bizKey string) error {
+	return d.update(ctx, bizId, bizKey, transit(domain.CampaignStatusPaused, domain.CampaignStatusRunning))
+}
+
+// Cancel 由后台任务取消已创建的待发送消息，全部取消后状态变为 canceled
+func (d *DefaultCampaignService) Cancel(ctx context.Context, bizId uint64, bizKey string) error {
+	return d.update(ctx, bizId, bizKey, func(c *domain.Campaign) (bool, error) {
+		switch c.Status {
+		case domain.CampaignStatusCanceling, domain.CampaignStatusCanceled:
+			return false, nil
+		case domain.CampaignStatusDraft, domain.CampaignStatusRunning, domain.CampaignStatusPaused:
+		default:
+			return false, fmt.Errorf("%w: status = %s", errs.ErrCampaignConflict, c.Status)
+		}
+		c.Status = domain.CampaignStatusCanceling
+		return true, nil
+	})
+}
+
+// update 读取活动并按 fn 修改后保存，fn 返回 false 时不保存。
+//
+// 后台任务会持续更新进行中活动的进度，版本号冲突时重新读取后重试。
+func (d *DefaultCampaignService) update(
+	ctx context.Context, bizId uint64, bizKey string, fn func(c *domain.Campaign) (bool, error),
+) error {
+	const maxRetries = 3
+
+	var err error
+	for range maxRetries {
+		var c domain.Campaign
+		if c, err = d.get(ctx, bizId, bizKey); err != nil {
+			return err
+		}
+
+		var changed bool
+		if changed, err = fn(&c); err != nil || !changed {
+			return err
+		}
+
+		if err = d.campaignRepo.CompareAndSwap(ctx, c); !errors.Is(err, errs.ErrCampaignVersionConflict) {
+			return err
+		}
+	}
+	return err
+}
+
+// transit 将状态为 from 的活动变更为 to，已经是 to 时不做修改
+func transit(from domain.CampaignStatus, to domain.CampaignStatus) func(c *domain.Campaign) (bool, error) {
+	return func(c *domain.Campaign) (bool, error) {
+		if c.Status == to {
+			return false, nil
+		}
+		if c.Status != from {
+			return false, fmt.Errorf("%w: status = %s", errs.ErrCampaignConflict, c.Status)
+		}
+		c.Status = to
+		return true, nil
+	}
+}
+
+func (d *DefaultCampaignService) get(ctx context.Context, bizId uint64, bizKey string) (domain.Campaign, error) {
+	if bizKey == "" {
+		return domain.Campaign{}, fmt.Errorf("%w: biz key should not be empty", errs.ErrInvalidParam)
+	}
+	return d.campaignRepo.GetByKey(ctx, bizId, bizKey)
+}
+
+func NewDefaultCampaignService(campaignRepo repository.CampaignRepo, notifRepo repository.NotificationRepo) *DefaultCampaignService {
+	return &DefaultCampaignService{
+		campaignRepo: campaignRepo,
+		notifRepo:    notifRepo,
+	}
+}
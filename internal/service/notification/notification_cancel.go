@@ -0,0 +1,54 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/errs"
+	"github.com/JrMarcco/jotify/internal/repository"
+)
+
+//go:generate mockgen -source=./notification_cancel.go -destination=./mock/cancel_service.mock.go -package=notificationmock -typed CancelService
+
+// CancelService 取消消息发送。
+//
+// 只有还未开始发送的消息（prepare、pending）可以取消，取消后退还配额。
+type CancelService interface {
+	Cancel(ctx context.Context, bizId uint64, bizKey string) error
+}
+
+var _ CancelService = (*DefaultCancelService)(nil)
+
+type DefaultCancelService struct {
+	notifRepo repository.NotificationRepo
+}
+
+func (d *DefaultCancelService) Cancel(ctx context.Context, bizId uint64, bizKey string) error {
+	if bizKey == "" {
+		return fmt.Errorf("%w: biz key should not be empty", errs.ErrInvalidParam)
+	}
+
+	n, err := d.notifRepo.GetByKey(ctx, bizId, bizKey)
+	if err != nil {
+		return err
+	}
+
+	switch n.Status {
+	case domain.SendStatusCancel:
+		// 重复取消直接返回
+		return nil
+	case domain.SendStatusPrepare, domain.SendStatusPending:
+	default:
+		return fmt.Errorf("%w: status = %s", errs.ErrNotificationNotCancelable, n.Status)
+	}
+
+	// 基于版本号更新状态，避免和调度器并发发送
+	return d.notifRepo.Cancel(ctx, n)
+}
+
+func NewDefaultCancelService(notifRepo repository.NotificationRepo) *DefaultCancelService {
+	return &DefaultCancelService{
+		notifRepo: notifRepo,
+	}
+}
@@ -0,0 +1,173 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/errs"
+	"github.com/JrMarcco/jotify/internal/pkg/client"
+	"github.com/JrMarcco/jotify/internal/pkg/privacy"
+	"github.com/JrMarcco/jotify/internal/repository"
+	"go.uber.org/zap"
+)
+
+//go:generate mockgen -source=./notification_erasure.go -destination=./mock/erasure_service.mock.go -package=notificationmock -typed ErasureService
+
+// ErasureService 个人数据擦除。
+//
+// 通过接收者哈希索引查找接收者的全部消息（包括迁移中的旧布局与归档表），将接收者以及包含个人数据的模板参数替换为占位值，
+// 记录擦除回执后删除接收者索引。回执中只保存接收者哈希，核对时使用同一接收者重新计算哈希。
+//
+// 还未开始发送且只发给该接收者的消息会先被取消，发给多个接收者的未发送消息只移除该接收者。
+// 接收者的语言偏好同时被删除。bizId 为 0 时擦除全部业务方的消息。
+type ErasureService interface {
+	Erase(ctx context.Context, bizId uint64, receiver string) (domain.ErasureReceipt, error)
+	GetReceipt(ctx context.Context, bizId uint64, id uint64) (domain.ErasureReceipt, error)
+}
+
+var _ ErasureService = (*DefaultErasureService)(nil)
+
+type DefaultErasureService struct {
+	notifRepo   repository.NotificationRepo
+	erasureRepo repository.ErasureRepo
+	localeRepo  repository.ReceiverLocaleRepo
+	hasher      *privacy.Hasher
+
+	sensitiveParams map[string]struct{} // 包含个人数据的模板参数名，无论取值是否包含接收者都会被擦除
+
+	logger *zap.Logger
+}
+
+func (d *DefaultErasureService) Erase(ctx context.Context, bizId uint64, receiver string) (domain.ErasureReceipt, error) {
+	if strings.TrimSpace(receiver) == "" {
+		return domain.ErasureReceipt{}, fmt.Errorf("%w: receiver should not be empty", errs.ErrInvalidParam)
+	}
+
+	hash := d.hasher.Hash(receiver)
+	ids, err := d.erasureRepo.FindNotificationIds(ctx, bizId, hash)
+	if err != nil {
+		return domain.ErasureReceipt{}, err
+	}
+
+	erased := make([]uint64, 0, len(ids))
+	if len(ids) > 0 {
+		ns, err := d.notifRepo.GetMapByIds(ctx, ids)
+		if err != nil {
+			return domain.ErasureReceipt{}, err
+		}
+
+		for _, id := range ids {
+			n, ok := ns[id]
+			if !ok {
+				continue
+			}
+			if err = d.erase(ctx, n, receiver); err != nil {
+				return domain.ErasureReceipt{}, err
+			}
+			erased = append(erased, id)
+		}
+	}
+
+	// 先记录回执再删除索引，删除失败时重试仍能找到消息
+	operatorBizId, _ := client.BizIdFromContext(ctx)
+	receipt, err := d.erasureRepo.CreateReceipt(ctx, domain.ErasureReceipt{
+		BizId:           bizId,
+		ReceiverHash:    hash,
+		NotificationIds: erased,
+		OperatorBizId:   operatorBizId,
+	})
+	if err != nil {
+		return domain.ErasureReceipt{}, err
+	}
+
+	if err = d.erasureRepo.DeleteReceiverIndex(ctx, hash, ids); err != nil {
+		return domain.ErasureReceipt{}, err
+	}
+	if err = d.localeRepo.DeleteByHash(ctx, bizId, hash); err != nil {
+		return domain.ErasureReceipt{}, err
+	}
+	return receipt, nil
+}
+
+func (d *DefaultErasureService) erase(ctx context.Context, n domain.Notification, receiver string) error {
+	target := privacy.Normalize(receiver)
+
+	unsent := n.Status == domain.SendStatusPrepare || n.Status == domain.SendStatusPending
+	onlyReceiver := true
+	for _, r := range n.Receivers {
+		if privacy.Normalize(r) != target {
+			onlyReceiver = false
+			break
+		}
+	}
+
+	if unsent && onlyReceiver {
+		err := d.notifRepo.Cancel(ctx, n)
+		if err != nil && !errors.Is(err, errs.ErrNotificationVersionConflict) {
+			return err
+		}
+		if err != nil {
+			// 版本冲突说明消息已开始发送，直接擦除
+			d.logger.Warn("[jotify] notification changed before erasure cancel", zap.Uint64("notification_id", n.Id))
+		}
+		unsent = false
+	}
+
+	return d.erasureRepo.Redact(ctx, n.Id, func(receivers []string, params map[string]string) ([]string, map[string]string) {
+		res := make([]string, 0, len(receivers))
+		for _, r := range receivers {
+			switch {
+			case privacy.Normalize(r) != target:
+				res = append(res, r)
+			case !unsent:
+				// 已发送的消息保留占位值，未发送的消息直接移除该接收者
+				res = append(res, privacy.Redacted)
+			}
+		}
+
+		for k, v := range params {
+			if _, ok := d.sensitiveParams[k]; ok || strings.Contains(privacy.Normalize(v), target) {
+				params[k] = privacy.Redacted
+			}
+		}
+		return res, params
+	})
+}
+
+func (d *DefaultErasureService) GetReceipt(ctx context.Context, bizId uint64, id uint64) (domain.ErasureReceipt, error) {
+	receipt, err := d.erasureRepo.GetReceipt(ctx, id)
+	if err != nil {
+		return domain.ErasureReceipt{}, err
+	}
+
+	if bizId != 0 && receipt.BizId != bizId {
+		return domain.ErasureReceipt{}, fmt.Errorf("%w: id = %d", errs.ErrErasureReceiptNotFound, id)
+	}
+	return receipt, nil
+}
+
+func NewDefaultErasureService(
+	notifRepo repository.NotificationRepo,
+	erasureRepo repository.ErasureRepo,
+	localeRepo repository.ReceiverLocaleRepo,
+	hasher *privacy.Hasher,
+	sensitiveParams []string,
+	logger *zap.Logger,
+) *DefaultErasureService {
+	m := make(map[string]struct{}, len(sensitiveParams))
+	for _, param := range sensitiveParams {
+		m[param] = struct{}{}
+	}
+
+	return &DefaultErasureService{
+		notifRepo:       notifRepo,
+		erasureRepo:     erasureRepo,
+		localeRepo:      localeRepo,
+		hasher:          hasher,
+		sensitiveParams: m,
+		logger:          logger,
+	}
+}
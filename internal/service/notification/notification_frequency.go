@@ -0,0 +1,222 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/repository"
+	"go.uber.org/zap"
+)
+
+var _ SendService = (*FrequencySendService)(nil)
+
+// FrequencySendService 频控装饰器，在扣减配额之前移除周期内已达到频控上限的接收者。
+//
+// 业务方为业务类型配置了频控规则时，按接收者计数，验证码消息不受频控限制。
+// 被移除的接收者通过发送结果逐个返回，全部接收者都被移除时不创建消息，发送结果状态为 suppressed。
+// 消息创建失败时释放计数，创建后取消或过期的消息由 repository.NotificationRepo 释放计数。
+type FrequencySendService struct {
+	svc SendService
+
+	frequencyRepo repository.FrequencyRepo
+	bizConfRepo   repository.BizConfRepo
+	tplRepo       repository.ChannelTplRepo
+	logger        *zap.Logger
+}
+
+// capped 按频控规则计数后的消息
+type capped struct {
+	filtered
+	// 非空时已在 acquiredAt 按频控规则为 n 中的接收者计数，创建消息失败时需要释放
+	deliveryConf *domain.DeliveryConf
+	acquiredAt   time.Time
+}
+
+func (s *FrequencySendService) Send(ctx context.Context, n domain.Notification) (domain.SendResp, error) {
+	c, err := s.acquire(ctx, n, make(map[uint64]domain.BizType))
+	if err != nil {
+		return domain.SendResp{}, err
+	}
+	if len(c.n.Receivers) == 0 {
+		return domain.SendResp{Result: domain.SendResult{Status: domain.SendStatusSuppressed, Suppressed: c.suppressed}}, nil
+	}
+
+	resp, err := s.svc.Send(ctx, c.n)
+	if err != nil {
+		s.release(ctx, c)
+	}
+	resp.Result.Suppressed = append(resp.Result.Suppressed, c.suppressed...)
+	return resp, err
+}
+
+func (s *FrequencySendService) AsyncSend(ctx context.Context, n domain.Notification) (domain.SendResp, error) {
+	c, err := s.acquire(ctx, n, make(map[uint64]domain.BizType))
+	if err != nil {
+		return domain.SendResp{}, err
+	}
+	if len(c.n.Receivers) == 0 {
+		return domain.SendResp{Result: domain.SendResult{Status: domain.SendStatusSuppressed, Suppressed: c.suppressed}}, nil
+	}
+
+	resp, err := s.svc.AsyncSend(ctx, c.n)
+	if err != nil {
+		s.release(ctx, c)
+	}
+	resp.Result.Suppressed = append(resp.Result.Suppressed, c.suppressed...)
+	return resp, err
+}
+
+func (s *FrequencySendService) BatchSend(ctx context.Context, ns []domain.Notification) (domain.BatchSendResp, error) {
+	cs, kept, err := s.acquireAll(ctx, ns)
+	if err != nil {
+		return domain.BatchSendResp{}, err
+	}
+
+	var resp domain.BatchSendResp
+	if len(kept) > 0 {
+		if resp, err = s.svc.BatchSend(ctx, kept); err != nil {
+			s.release(ctx, cs...)
+			return resp, err
+		}
+	}
+
+	// 按请求顺序合并结果，全部接收者都被移除的消息没有发送结果
+	results := make([]domain.SendResult, 0, len(ns))
+	next := 0
+	for _, c := range cs {
+		if len(c.n.Receivers) == 0 {
+			results = append(results, domain.SendResult{Status: domain.SendStatusSuppressed, Suppressed: c.suppressed})
+			continue
+		}
+		if next < len(resp.Results) {
+			res := resp.Results[next]
+			res.Suppressed = append(res.Suppressed, c.suppressed...)
+			results = append(results, res)
+			next++
+		}
+	}
+	return domain.BatchSendResp{Results: results}, nil
+}
+
+func (s *FrequencySendService) BatchAsyncSend(ctx context.Context, ns []domain.Notification) (domain.BatchAsyncSendResp, error) {
+	cs, kept, err := s.acquireAll(ctx, ns)
+	if err != nil {
+		return domain.BatchAsyncSendResp{}, err
+	}
+
+	var resp domain.BatchAsyncSendResp
+	if len(kept) > 0 {
+		if resp, err = s.svc.BatchAsyncSend(ctx, kept); err != nil {
+			s.release(ctx, cs...)
+			return resp, err
+		}
+	}
+	for _, c := range cs {
+		resp.Suppressed = append(resp.Suppressed, c.suppressed...)
+	}
+	return resp, nil
+}
+
+// acquireAll 为批量消息计数，返回每条消息的计数结果以及仍有接收者的消息，失败时释放已计数的消息
+func (s *FrequencySendService) acquireAll(ctx context.Context, ns []domain.Notification) ([]capped, []domain.Notification, error) {
+	bizTypes := make(map[uint64]domain.BizType)
+	cs := make([]capped, 0, len(ns))
+	kept := make([]domain.Notification, 0, len(ns))
+	for i := range ns {
+		c, err := s.acquire(ctx, ns[i], bizTypes)
+		if err != nil {
+			s.release(ctx, cs...)
+			return nil, nil, err
+		}
+		cs = append(cs, c)
+		if len(c.n.Receivers) > 0 {
+			kept = append(kept, c.n)
+		}
+	}
+	return cs, kept, nil
+}
+
+// acquire 按业务方的频控规则计数，移除周期内已达到上限的接收者，bizTypes 缓存同一请求中模板的业务类型
+func (s *FrequencySendService) acquire(
+	ctx context.Context, n domain.Notification, bizTypes map[uint64]domain.BizType,
+) (capped, error) {
+	c := capped{filtered: filtered{n: n}}
+	// 周期发送计划在每次触发创建消息时计数
+	if n.StrategyConfig.Type == domain.SendStrategyRecurring || len(n.Receivers) == 0 {
+		return c, nil
+	}
+
+	bizType, ok := bizTypes[n.Template.Id]
+	if !ok {
+		tpl, err := s.tplRepo.GetById(ctx, n.Template.Id)
+		if err != nil {
+			return capped{}, fmt.Errorf("failed to get template %d: %w", n.Template.Id, err)
+		}
+		bizType = tpl.BizType
+		bizTypes[n.Template.Id] = bizType
+	}
+	c.bizType = bizType
+	if bizType == domain.BizTypeVerifyCode {
+		return c, nil
+	}
+
+	bizConf, err := s.bizConfRepo.GetById(ctx, n.BizId)
+	if err != nil {
+		return capped{}, fmt.Errorf("failed to get biz conf %d: %w", n.BizId, err)
+	}
+	if bizConf.DeliveryConf == nil || len(bizConf.DeliveryConf.CapsOf(bizType, n.Channel)) == 0 {
+		return c, nil
+	}
+
+	c.acquiredAt = time.Now()
+	reached, err := s.frequencyRepo.Acquire(ctx, n, bizType, bizConf.DeliveryConf, c.acquiredAt)
+	if err != nil {
+		return capped{}, err
+	}
+	c.deliveryConf = bizConf.DeliveryConf
+
+	reachedSet := make(map[string]struct{}, len(reached))
+	for _, receiver := range reached {
+		reachedSet[receiver] = struct{}{}
+	}
+	c.remove(func(receiver string) (domain.SuppressionReason, bool) {
+		_, ok := reachedSet[receiver]
+		return domain.SuppressionReasonFrequencyCap, ok
+	})
+	return c, nil
+}
+
+// release 释放频控计数，失败时只记录日志
+func (s *FrequencySendService) release(ctx context.Context, cs ...capped) {
+	for _, c := range cs {
+		if c.deliveryConf == nil || len(c.n.Receivers) == 0 {
+			continue
+		}
+		if err := s.frequencyRepo.Release(ctx, c.n, c.bizType, c.deliveryConf, c.acquiredAt); err != nil {
+			s.logger.Error(
+				"[jotify] failed to release frequency counts",
+				zap.Error(err),
+				zap.Uint64("biz_id", c.n.BizId),
+				zap.String("biz_key", c.n.BizKey),
+			)
+		}
+	}
+}
+
+func NewFrequencySendService(
+	svc SendService,
+	frequencyRepo repository.FrequencyRepo,
+	bizConfRepo repository.BizConfRepo,
+	tplRepo repository.ChannelTplRepo,
+	logger *zap.Logger,
+) *FrequencySendService {
+	return &FrequencySendService{
+		svc:           svc,
+		frequencyRepo: frequencyRepo,
+		bizConfRepo:   bizConfRepo,
+		tplRepo:       tplRepo,
+		logger:        logger,
+	}
+}
@@ -0,0 +1,52 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/errs"
+	"github.com/JrMarcco/jotify/internal/repository"
+)
+
+//go:generate mockgen -source=./notification_locale.go -destination=./mock/locale_service.mock.go -package=notificationmock -typed ReceiverLocaleService
+
+// ReceiverLocaleService 接收者语言偏好管理，发送请求没有指定语言时按接收者的语言偏好选择模板的语言变体
+type ReceiverLocaleService interface {
+	// Import 批量导入，接收者已有语言偏好时覆盖
+	Import(ctx context.Context, bizId uint64, ls []domain.ReceiverLocale) error
+	Remove(ctx context.Context, bizId uint64, receiver string) error
+}
+
+var _ ReceiverLocaleService = (*DefaultReceiverLocaleService)(nil)
+
+type DefaultReceiverLocaleService struct {
+	localeRepo repository.ReceiverLocaleRepo
+}
+
+func (d *DefaultReceiverLocaleService) Import(ctx context.Context, bizId uint64, ls []domain.ReceiverLocale) error {
+	if len(ls) == 0 {
+		return fmt.Errorf("%w: receiver locales should not be empty", errs.ErrInvalidParam)
+	}
+	for i := range ls {
+		ls[i].BizId = bizId
+		if err := ls[i].Normalize(); err != nil {
+			return err
+		}
+	}
+	return d.localeRepo.BatchSave(ctx, ls)
+}
+
+func (d *DefaultReceiverLocaleService) Remove(ctx context.Context, bizId uint64, receiver string) error {
+	if strings.TrimSpace(receiver) == "" {
+		return fmt.Errorf("%w: receiver should not be empty", errs.ErrInvalidParam)
+	}
+	return d.localeRepo.Delete(ctx, bizId, receiver)
+}
+
+func NewDefaultReceiverLocaleService(localeRepo repository.ReceiverLocaleRepo) *DefaultReceiverLocaleService {
+	return &DefaultReceiverLocaleService{
+		localeRepo: localeRepo,
+	}
+}
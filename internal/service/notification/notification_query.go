@@ -0,0 +1,56 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/errs"
+	"github.com/JrMarcco/jotify/internal/repository"
+)
+
+//go:generate mockgen -source=./notification_query.go -destination=./mock/query_service.mock.go -package=notificationmock -typed QueryService
+
+// QueryService 消息查询服务，只能查询当前业务方自己的消息。
+type QueryService interface {
+	GetByKey(ctx context.Context, bizId uint64, bizKey string) (domain.Notification, error)
+	BatchGetByIds(ctx context.Context, bizId uint64, ids []uint64) (map[uint64]domain.Notification, error)
+}
+
+var _ QueryService = (*DefaultQueryService)(nil)
+
+type DefaultQueryService struct {
+	notifRepo repository.NotificationRepo
+}
+
+func (d *DefaultQueryService) GetByKey(ctx context.Context, bizId uint64, bizKey string) (domain.Notification, error) {
+	if bizKey == "" {
+		return domain.Notification{}, fmt.Errorf("%w: biz key should not be empty", errs.ErrInvalidParam)
+	}
+	return d.notifRepo.GetByKey(ctx, bizId, bizKey)
+}
+
+func (d *DefaultQueryService) BatchGetByIds(ctx context.Context, bizId uint64, ids []uint64) (map[uint64]domain.Notification, error) {
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%w: ids should not be empty", errs.ErrInvalidParam)
+	}
+
+	m, err := d.notifRepo.GetMapByIds(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	// 过滤掉不属于当前业务方的消息
+	for id, n := range m {
+		if n.BizId != bizId {
+			delete(m, id)
+		}
+	}
+	return m, nil
+}
+
+func NewDefaultQueryService(notifRepo repository.NotificationRepo) *DefaultQueryService {
+	return &DefaultQueryService{
+		notifRepo: notifRepo,
+	}
+}
@@ -0,0 +1,96 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/errs"
+	"github.com/JrMarcco/jotify/internal/repository"
+)
+
+//go:generate mockgen -source=./notification_recurring.go -destination=./mock/recurring_service.mock.go -package=notificationmock -typed RecurringService
+
+// RecurringService 周期发送计划管理，计划通过 recurring 策略的消息创建。
+//
+// 暂停、恢复与删除只影响之后的触发，已创建的消息可以通过 CancelService 取消。
+type RecurringService interface {
+	List(ctx context.Context, bizId uint64, offset int, limit int) ([]domain.RecurringSchedule, error)
+	Pause(ctx context.Context, bizId uint64, bizKey string) error
+	// Resume 恢复暂停的计划，暂停期间的触发不会补发
+	Resume(ctx context.Context, bizId uint64, bizKey string) error
+	Delete(ctx context.Context, bizId uint64, bizKey string) error
+}
+
+var _ RecurringService = (*DefaultRecurringService)(nil)
+
+type DefaultRecurringService struct {
+	recurringRepo repository.RecurringRepo
+}
+
+func (d *DefaultRecurringService) List(ctx context.Context, bizId uint64, offset int, limit int) ([]domain.RecurringSchedule, error) {
+	const maxLimit = 500
+	if offset < 0 || limit <= 0 || limit > maxLimit {
+		return nil, fmt.Errorf("%w: offset should not be negative and limit should be in (0, %d]", errs.ErrInvalidParam, maxLimit)
+	}
+	return d.recurringRepo.List(ctx, bizId, offset, limit)
+}
+
+func (d *DefaultRecurringService) Pause(ctx context.Context, bizId uint64, bizKey string) error {
+	s, err := d.get(ctx, bizId, bizKey)
+	if err != nil {
+		return err
+	}
+
+	switch s.Status {
+	case domain.RecurringStatusPaused:
+		// 重复暂停直接返回
+		return nil
+	case domain.RecurringStatusActive:
+	default:
+		return fmt.Errorf("%w: status = %s", errs.ErrRecurringScheduleConflict, s.Status)
+	}
+
+	s.Status = domain.RecurringStatusPaused
+	return d.recurringRepo.CompareAndSwap(ctx, s)
+}
+
+func (d *DefaultRecurringService) Resume(ctx context.Context, bizId uint64, bizKey string) error {
+	s, err := d.get(ctx, bizId, bizKey)
+	if err != nil {
+		return err
+	}
+
+	switch s.Status {
+	case domain.RecurringStatusActive:
+		return nil
+	case domain.RecurringStatusPaused:
+	default:
+		return fmt.Errorf("%w: status = %s", errs.ErrRecurringScheduleConflict, s.Status)
+	}
+
+	s.Status = domain.RecurringStatusActive
+	s.Advance(time.Now())
+	return d.recurringRepo.CompareAndSwap(ctx, s)
+}
+
+func (d *DefaultRecurringService) Delete(ctx context.Context, bizId uint64, bizKey string) error {
+	if bizKey == "" {
+		return fmt.Errorf("%w: biz key should not be empty", errs.ErrInvalidParam)
+	}
+	return d.recurringRepo.Delete(ctx, bizId, bizKey)
+}
+
+func (d *DefaultRecurringService) get(ctx context.Context, bizId uint64, bizKey string) (domain.RecurringSchedule, error) {
+	if bizKey == "" {
+		return domain.RecurringSchedule{}, fmt.Errorf("%w: biz key should not be empty", errs.ErrInvalidParam)
+	}
+	return d.recurringRepo.GetByKey(ctx, bizId, bizKey)
+}
+
+func NewDefaultRecurringService(recurringRepo repository.RecurringRepo) *DefaultRecurringService {
+	return &DefaultRecurringService{
+		recurringRepo: recurringRepo,
+	}
+}
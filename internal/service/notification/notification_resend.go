@@ -0,0 +1,56 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/errs"
+	"github.com/JrMarcco/jotify/internal/repository"
+	"github.com/JrMarcco/jotify/internal/service/sender"
+)
+
+//go:generate mockgen -source=./notification_resend.go -destination=./mock/resend_service.mock.go -package=notificationmock -typed ResendService
+
+// ResendService 重新发送已失败、已取消或已过期的消息，供运维操作使用。
+//
+// 重新发送会重新扣减配额，并同步调用发送器发送。
+type ResendService interface {
+	Resend(ctx context.Context, id uint64) (domain.SendResp, error)
+}
+
+var _ ResendService = (*DefaultResendService)(nil)
+
+type DefaultResendService struct {
+	notifRepo   repository.NotificationRepo
+	notifSender sender.Sender
+}
+
+func (d *DefaultResendService) Resend(ctx context.Context, id uint64) (domain.SendResp, error) {
+	n, err := d.notifRepo.GetById(ctx, id)
+	if err != nil {
+		return domain.SendResp{}, err
+	}
+
+	switch n.Status {
+	case domain.SendStatusFailure, domain.SendStatusCancel, domain.SendStatusExpired:
+	default:
+		return domain.SendResp{}, fmt.Errorf("%w: status = %s", errs.ErrNotificationNotResendable, n.Status)
+	}
+
+	// 基于版本号更新状态，避免重复发送
+	if err = d.notifRepo.MarkSending(ctx, n); err != nil {
+		return domain.SendResp{}, err
+	}
+	n.Status = domain.SendStatusSending
+	n.Version++
+
+	return d.notifSender.Send(ctx, n)
+}
+
+func NewDefaultResendService(notifRepo repository.NotificationRepo, notifSender sender.Sender) *DefaultResendService {
+	return &DefaultResendService{
+		notifRepo:   notifRepo,
+		notifSender: notifSender,
+	}
+}
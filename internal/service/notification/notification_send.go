@@ -7,6 +7,8 @@ import (
 	"github.com/JrMarcco/jotify/internal/domain"
 	"github.com/JrMarcco/jotify/internal/errs"
 	"github.com/JrMarcco/jotify/internal/pkg/snowflake"
+	"github.com/JrMarcco/jotify/internal/pkg/tracing"
+	"github.com/JrMarcco/jotify/internal/repository"
 	"github.com/JrMarcco/jotify/internal/service/sendstrategy"
 	"golang.org/x/sync/errgroup"
 )
@@ -22,12 +24,20 @@ type SendService interface {
 
 var _ SendService = (*DefaultSendService)(nil)
 
+// DefaultSendService 默认发送服务，校验业务方可以使用模板并为消息分配 id、调度优先级与模板语言变体后交给发送策略处理
 type DefaultSendService struct {
 	idGenerator  *snowflake.Generator
 	sendStrategy sendstrategy.SendStrategy
+	tplRepo      repository.ChannelTplRepo
+	bizConfRepo  repository.BizConfRepo
+	localeRepo   repository.ReceiverLocaleRepo
+	providerRepo repository.ProviderRepo
 }
 
-func (d *DefaultSendService) Send(ctx context.Context, n domain.Notification) (domain.SendResp, error) {
+func (d *DefaultSendService) Send(ctx context.Context, n domain.Notification) (_ domain.SendResp, err error) {
+	ctx, span := tracing.Start(ctx, "SendService.Send", tracing.AttrBizId.Int64(int64(n.BizId)), tracing.AttrBizKey.String(n.BizKey))
+	defer func() { tracing.End(span, err) }()
+
 	resp := domain.SendResp{
 		Result: domain.SendResult{
 			Status: domain.SendStatusFailure,
@@ -37,8 +47,18 @@ func (d *DefaultSendService) Send(ctx context.Context, n domain.Notification) (d
 	if err := n.Validate(); err != nil {
 		return resp, err
 	}
+	tpls := make(map[uint64]domain.ChannelTpl)
+	if err := d.withTemplate(ctx, &n, tpls); err != nil {
+		return resp, err
+	}
+	if err := d.localize(ctx, tpls, &n); err != nil {
+		return resp, err
+	}
 
 	n.Id = d.idGenerator.NextId(n.BizId, n.BizKey)
+	span.SetAttributes(tracing.AttrNotificationId.Int64(int64(n.Id)))
+	n.TraceCtx = tracing.Inject(ctx)
+
 	sendResp, err := d.sendStrategy.Send(ctx, n)
 	if err != nil {
 		return resp, fmt.Errorf("%w: cause of: %w", errs.ErrFailedSendNotification, err)
@@ -46,29 +66,55 @@ func (d *DefaultSendService) Send(ctx context.Context, n domain.Notification) (d
 	return sendResp, nil
 }
 
-func (d *DefaultSendService) AsyncSend(ctx context.Context, n domain.Notification) (domain.SendResp, error) {
-	if err := n.Validate(); err != nil {
+func (d *DefaultSendService) AsyncSend(ctx context.Context, n domain.Notification) (_ domain.SendResp, err error) {
+	ctx, span := tracing.Start(ctx, "SendService.AsyncSend", tracing.AttrBizId.Int64(int64(n.BizId)), tracing.AttrBizKey.String(n.BizKey))
+	defer func() { tracing.End(span, err) }()
+
+	if err = n.Validate(); err != nil {
+		return domain.SendResp{}, err
+	}
+	tpls := make(map[uint64]domain.ChannelTpl)
+	if err = d.withTemplate(ctx, &n, tpls); err != nil {
+		return domain.SendResp{}, err
+	}
+	if err = d.localize(ctx, tpls, &n); err != nil {
 		return domain.SendResp{}, err
 	}
 
 	n.Id = d.idGenerator.NextId(n.BizId, n.BizKey)
+	span.SetAttributes(tracing.AttrNotificationId.Int64(int64(n.Id)))
+
+	// 记录链路信息，调度器实际发送时关联回当前请求
+	n.TraceCtx = tracing.Inject(ctx)
 	// 替换消息发送策略为 Deadline，设置 1 分钟内发出消息
 	n.ReplaceAsyncImmediate()
 	return d.sendStrategy.Send(ctx, n)
 }
 
-func (d *DefaultSendService) BatchSend(ctx context.Context, ns []domain.Notification) (domain.BatchSendResp, error) {
+func (d *DefaultSendService) BatchSend(ctx context.Context, ns []domain.Notification) (_ domain.BatchSendResp, err error) {
+	ctx, span := tracing.Start(ctx, "SendService.BatchSend", tracing.AttrBatchSize.Int(len(ns)))
+	defer func() { tracing.End(span, err) }()
+
 	resp := domain.BatchSendResp{}
 
 	if len(ns) == 0 {
 		return resp, fmt.Errorf("%w: no notifications to send", errs.ErrInvalidParam)
 	}
 
-	for _, n := range ns {
-		if err := n.Validate(); err != nil {
+	traceCtx := tracing.Inject(ctx)
+	tpls := make(map[uint64]domain.ChannelTpl)
+	for i := range ns {
+		if err = ns[i].Validate(); err != nil {
+			return resp, err
+		}
+		if err = d.withTemplate(ctx, &ns[i], tpls); err != nil {
 			return resp, err
 		}
-		n.Id = d.idGenerator.NextId(n.BizId, n.BizKey)
+		ns[i].Id = d.idGenerator.NextId(ns[i].BizId, ns[i].BizKey)
+		ns[i].TraceCtx = traceCtx
+	}
+	if err = d.localize(ctx, tpls, pointers(ns)...); err != nil {
+		return resp, err
 	}
 
 	sendResp, err := d.sendStrategy.BatchSend(ctx, ns)
@@ -80,20 +126,32 @@ func (d *DefaultSendService) BatchSend(ctx context.Context, ns []domain.Notifica
 	return resp, nil
 }
 
-func (d *DefaultSendService) BatchAsyncSend(ctx context.Context, ns []domain.Notification) (domain.BatchAsyncSendResp, error) {
+func (d *DefaultSendService) BatchAsyncSend(ctx context.Context, ns []domain.Notification) (_ domain.BatchAsyncSendResp, err error) {
+	ctx, span := tracing.Start(ctx, "SendService.BatchAsyncSend", tracing.AttrBatchSize.Int(len(ns)))
+	defer func() { tracing.End(span, err) }()
+
 	if len(ns) == 0 {
 		return domain.BatchAsyncSendResp{}, fmt.Errorf("%w: no notifications to send", errs.ErrInvalidParam)
 	}
 
+	traceCtx := tracing.Inject(ctx)
+	tpls := make(map[uint64]domain.ChannelTpl)
 	ids := make([]uint64, 0, len(ns))
-	for _, n := range ns {
-		if err := n.Validate(); err != nil {
+	for i := range ns {
+		if err = ns[i].Validate(); err != nil {
+			return domain.BatchAsyncSendResp{}, err
+		}
+		if err = d.withTemplate(ctx, &ns[i], tpls); err != nil {
 			return domain.BatchAsyncSendResp{}, err
 		}
-		n.Id = d.idGenerator.NextId(n.BizId, n.BizKey)
-		ids = append(ids, n.Id)
+		ns[i].Id = d.idGenerator.NextId(ns[i].BizId, ns[i].BizKey)
+		ids = append(ids, ns[i].Id)
 
-		n.ReplaceAsyncImmediate()
+		ns[i].ReplaceAsyncImmediate()
+		ns[i].TraceCtx = traceCtx
+	}
+	if err = d.localize(ctx, tpls, pointers(ns)...); err != nil {
+		return domain.BatchAsyncSendResp{}, err
 	}
 
 	// 按照发送策略分组发送
@@ -104,27 +162,161 @@ func (d *DefaultSendService) BatchAsyncSend(ctx context.Context, ns []domain.Not
 	}
 
 	// 分组发送
-	eg, ctx := errgroup.WithContext(ctx)
+	eg, egCtx := errgroup.WithContext(ctx)
 	for _, group := range strategyGroup {
 		notifications := group
 		eg.Go(func() error {
-			if _, err := d.sendStrategy.BatchSend(ctx, notifications); err != nil {
+			if _, err := d.sendStrategy.BatchSend(egCtx, notifications); err != nil {
 				return fmt.Errorf("%w: cause of: %w", errs.ErrFailedSendNotification, err)
 			}
 			return nil
 		})
 	}
 
-	if err := eg.Wait(); err != nil {
+	if err = eg.Wait(); err != nil {
 		return domain.BatchAsyncSendResp{}, err
 	}
 
 	return domain.BatchAsyncSendResp{NotificationIds: ids}, nil
 }
 
-func NewDefaultSendService(idGenerator *snowflake.Generator, sendStrategy sendstrategy.SendStrategy) *DefaultSendService {
+// withTemplate 校验业务方可以使用消息的模板，请求未指定优先级时按模板的业务类型确定，tpls 缓存同一请求中的模板
+func (d *DefaultSendService) withTemplate(ctx context.Context, n *domain.Notification, tpls map[uint64]domain.ChannelTpl) error {
+	tpl, ok := tpls[n.Template.Id]
+	if !ok {
+		var err error
+		if tpl, err = d.getTemplate(ctx, n.Template.Id); err != nil {
+			return err
+		}
+		tpls[n.Template.Id] = tpl
+	}
+
+	bc, err := d.bizConfRepo.GetById(ctx, n.BizId)
+	if err != nil {
+		return err
+	}
+	if !tpl.AccessibleBy(bc) {
+		return fmt.Errorf("%w: biz %d cannot use template %d", errs.ErrPermissionDenied, n.BizId, tpl.Id)
+	}
+
+	if n.Priority == domain.PriorityUnspecified {
+		n.Priority = domain.PriorityOf(tpl.BizType)
+	}
+	return nil
+}
+
+// getTemplate 获取模板，已发布的模板同时加载生效版本的语言变体（Versions 中只有生效版本的 id 与变体）
+func (d *DefaultSendService) getTemplate(ctx context.Context, tplId uint64) (domain.ChannelTpl, error) {
+	tpl, err := d.tplRepo.GetById(ctx, tplId)
+	if err != nil {
+		return domain.ChannelTpl{}, fmt.Errorf("failed to get template %d: %w", tplId, err)
+	}
+	if !tpl.Published() {
+		return tpl, nil
+	}
+
+	variants, err := d.tplRepo.FindVariants(ctx, tpl.ActivatedVersionId)
+	if err != nil {
+		return domain.ChannelTpl{}, fmt.Errorf("failed to get variants of template %d: %w", tplId, err)
+	}
+	version := domain.ChannelTplVersion{Id: tpl.ActivatedVersionId, ChannelTplId: tpl.Id, Variants: variants}
+
+	// 变体需要供应商侧有对应语言的模板才能选用
+	if len(variants) > 0 {
+		version.Providers, err = d.providerRepo.GetByTplInfo(ctx, tpl.Id, tpl.ActivatedVersionId, tpl.Channel.String())
+		if err != nil {
+			return domain.ChannelTpl{}, fmt.Errorf("failed to get providers of template %d: %w", tplId, err)
+		}
+	}
+	tpl.Versions = []domain.ChannelTplVersion{version}
+	return tpl, nil
+}
+
+// localize 为消息选择模板生效版本的语言变体并记录在消息上，需要先通过 withTemplate 加载模板。
+//
+// 请求指定了语言时按其回退链（例如 zh-HK → zh → 默认内容）选择，否则使用接收者的语言偏好，
+// 只选用供应商侧有对应语言模板的变体，记录的语言即为实际发送的语言；
+// 接收者的语言偏好不一致时使用默认内容，需要不同语言的接收者应拆分为多条消息发送。
+func (d *DefaultSendService) localize(ctx context.Context, tpls map[uint64]domain.ChannelTpl, ns ...*domain.Notification) error {
+	// 只为没有指定语言且模板有可用变体的消息查询接收者的语言偏好，按业务方批量查询
+	lookup := make(map[uint64][]string)
+	for _, n := range ns {
+		if n.Locale != "" {
+			locale, err := domain.ParseLocale(n.Locale)
+			if err != nil {
+				return err
+			}
+			n.Locale = locale
+			continue
+		}
+		if version := tpls[n.Template.Id].ActivatedVersion(); version != nil && version.HasApprovedVariant() {
+			lookup[n.BizId] = append(lookup[n.BizId], n.Receivers...)
+		}
+	}
+
+	prefs := make(map[uint64]map[string]string, len(lookup))
+	for bizId, receivers := range lookup {
+		locales, err := d.localeRepo.FindLocales(ctx, bizId, receivers)
+		if err != nil {
+			return fmt.Errorf("failed to get receiver locales: %w", err)
+		}
+		prefs[bizId] = locales
+	}
+
+	for _, n := range ns {
+		version := tpls[n.Template.Id].ActivatedVersion()
+		if version == nil {
+			n.Locale = ""
+			continue
+		}
+
+		locale := n.Locale
+		if locale == "" {
+			locale = receiverLocale(n.Receivers, prefs[n.BizId])
+		}
+		n.Locale = version.ResolveLocale(locale)
+	}
+	return nil
+}
+
+// receiverLocale 接收者一致的语言偏好，没有或不一致时返回空字符串
+func receiverLocale(receivers []string, locales map[string]string) string {
+	res := ""
+	for _, receiver := range receivers {
+		locale, ok := locales[receiver]
+		if !ok {
+			continue
+		}
+		if res != "" && res != locale {
+			return ""
+		}
+		res = locale
+	}
+	return res
+}
+
+func pointers(ns []domain.Notification) []*domain.Notification {
+	res := make([]*domain.Notification, 0, len(ns))
+	for i := range ns {
+		res = append(res, &ns[i])
+	}
+	return res
+}
+
+func NewDefaultSendService(
+	idGenerator *snowflake.Generator,
+	sendStrategy sendstrategy.SendStrategy,
+	tplRepo repository.ChannelTplRepo,
+	bizConfRepo repository.BizConfRepo,
+	localeRepo repository.ReceiverLocaleRepo,
+	providerRepo repository.ProviderRepo,
+) *DefaultSendService {
 	return &DefaultSendService{
 		idGenerator:  idGenerator,
 		sendStrategy: sendStrategy,
+		tplRepo:      tplRepo,
+		bizConfRepo:  bizConfRepo,
+		localeRepo:   localeRepo,
+		providerRepo: providerRepo,
 	}
 }
@@ -0,0 +1,211 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/errs"
+	"github.com/JrMarcco/jotify/internal/pkg/metrics"
+	"github.com/JrMarcco/jotify/internal/pkg/sensitive"
+	"github.com/JrMarcco/jotify/internal/repository"
+	"go.uber.org/zap"
+)
+
+// sensitiveMask 敏感词的替换字符
+const sensitiveMask = '*'
+
+var _ SendService = (*SensitiveSendService)(nil)
+
+// SensitiveSendService 敏感词过滤装饰器，模板参数由业务方每次发送时提供，审核通过的模板仍可能被注入违禁内容。
+//
+// 模板参数命中敏感词时按业务方的策略处理（未配置时使用默认策略）：
+//   - reject：单条发送返回 errs.ErrSensitiveContent；批量发送移除该消息，其接收者通过发送结果逐个返回；
+//   - mask：将命中的敏感词逐字符替换为 * 后发送；
+//   - flag：照常发送，记录日志与指标。
+type SensitiveSendService struct {
+	svc SendService
+
+	filter        *sensitive.Filter
+	bizConfRepo   repository.BizConfRepo
+	defaultPolicy domain.SensitivePolicy
+	logger        *zap.Logger
+}
+
+func (s *SensitiveSendService) Send(ctx context.Context, n domain.Notification) (domain.SendResp, error) {
+	n, ok, err := s.check(ctx, n)
+	if err != nil {
+		return domain.SendResp{}, err
+	}
+	if !ok {
+		return domain.SendResp{}, fmt.Errorf("%w: biz key = %s", errs.ErrSensitiveContent, n.BizKey)
+	}
+	return s.svc.Send(ctx, n)
+}
+
+func (s *SensitiveSendService) AsyncSend(ctx context.Context, n domain.Notification) (domain.SendResp, error) {
+	n, ok, err := s.check(ctx, n)
+	if err != nil {
+		return domain.SendResp{}, err
+	}
+	if !ok {
+		return domain.SendResp{}, fmt.Errorf("%w: biz key = %s", errs.ErrSensitiveContent, n.BizKey)
+	}
+	return s.svc.AsyncSend(ctx, n)
+}
+
+func (s *SensitiveSendService) BatchSend(ctx context.Context, ns []domain.Notification) (domain.BatchSendResp, error) {
+	kept, rejected, err := s.checkAll(ctx, ns)
+	if err != nil {
+		return domain.BatchSendResp{}, err
+	}
+
+	var resp domain.BatchSendResp
+	if len(kept) > 0 {
+		if resp, err = s.svc.BatchSend(ctx, kept); err != nil {
+			return resp, err
+		}
+	}
+	if len(rejected) == 0 {
+		return resp, nil
+	}
+
+	// 按请求顺序合并结果，被拒绝的消息没有创建
+	results := make([]domain.SendResult, 0, len(ns))
+	next := 0
+	for i, n := range ns {
+		if rejected[i] {
+			results = append(results, domain.SendResult{Status: domain.SendStatusSuppressed, Suppressed: s.suppressed(n)})
+			continue
+		}
+		if next < len(resp.Results) {
+			results = append(results, resp.Results[next])
+			next++
+		}
+	}
+	return domain.BatchSendResp{Results: results}, nil
+}
+
+func (s *SensitiveSendService) BatchAsyncSend(ctx context.Context, ns []domain.Notification) (domain.BatchAsyncSendResp, error) {
+	kept, rejected, err := s.checkAll(ctx, ns)
+	if err != nil {
+		return domain.BatchAsyncSendResp{}, err
+	}
+
+	var resp domain.BatchAsyncSendResp
+	if len(kept) > 0 {
+		if resp, err = s.svc.BatchAsyncSend(ctx, kept); err != nil {
+			return resp, err
+		}
+	}
+	for i, n := range ns {
+		if rejected[i] {
+			resp.Suppressed = append(resp.Suppressed, s.suppressed(n)...)
+		}
+	}
+	return resp, nil
+}
+
+// checkAll 检查批量消息，返回可以发送的消息以及被拒绝的消息下标
+func (s *SensitiveSendService) checkAll(
+	ctx context.Context, ns []domain.Notification,
+) ([]domain.Notification, map[int]bool, error) {
+	kept := make([]domain.Notification, 0, len(ns))
+	rejected := make(map[int]bool)
+	for i := range ns {
+		n, ok, err := s.check(ctx, ns[i])
+		if err != nil {
+			return nil, nil, err
+		}
+		if !ok {
+			rejected[i] = true
+			continue
+		}
+		kept = append(kept, n)
+	}
+	return kept, rejected, nil
+}
+
+// check 检查消息的模板参数，返回按策略处理后的消息以及是否可以发送
+func (s *SensitiveSendService) check(ctx context.Context, n domain.Notification) (domain.Notification, bool, error) {
+	matcher := s.filter.Matcher()
+	if matcher.Empty() {
+		return n, true, nil
+	}
+
+	var hitKeys []string
+	for key, val := range n.Template.Params {
+		if matcher.Contains(val) {
+			hitKeys = append(hitKeys, key)
+		}
+	}
+	if len(hitKeys) == 0 {
+		return n, true, nil
+	}
+	slices.Sort(hitKeys)
+
+	bc, err := s.bizConfRepo.GetById(ctx, n.BizId)
+	if err != nil {
+		return n, false, err
+	}
+	policy := bc.SensitivePolicyOr(s.defaultPolicy)
+	metrics.SensitiveHits.WithLabelValues(policy.String()).Inc()
+
+	// 只记录命中的参数名，不记录参数内容
+	s.logger.Warn(
+		"[jotify] template params hit sensitive words",
+		zap.Uint64("biz_id", n.BizId),
+		zap.String("biz_key", n.BizKey),
+		zap.Uint64("tpl_id", n.Template.Id),
+		zap.Strings("params", hitKeys),
+		zap.String("policy", policy.String()),
+	)
+
+	switch policy {
+	case domain.SensitivePolicyFlag:
+		return n, true, nil
+	case domain.SensitivePolicyMask:
+		// 复制参数，避免修改调用方（例如群发活动的默认参数）的 map
+		params := maps.Clone(n.Template.Params)
+		for _, key := range hitKeys {
+			params[key], _ = matcher.Mask(params[key], sensitiveMask)
+		}
+		n.Template.Params = params
+		return n, true, nil
+	default:
+		return n, false, nil
+	}
+}
+
+func (s *SensitiveSendService) suppressed(n domain.Notification) []domain.SuppressedReceiver {
+	res := make([]domain.SuppressedReceiver, 0, len(n.Receivers))
+	for _, receiver := range n.Receivers {
+		res = append(res, domain.SuppressedReceiver{
+			BizKey:   n.BizKey,
+			Receiver: receiver,
+			Reason:   domain.SuppressionReasonSensitiveContent,
+		})
+	}
+	return res
+}
+
+func NewSensitiveSendService(
+	svc SendService,
+	filter *sensitive.Filter,
+	bizConfRepo repository.BizConfRepo,
+	defaultPolicy domain.SensitivePolicy,
+	logger *zap.Logger,
+) *SensitiveSendService {
+	if !defaultPolicy.Validate() {
+		defaultPolicy = domain.SensitivePolicyReject
+	}
+	return &SensitiveSendService{
+		svc:           svc,
+		filter:        filter,
+		bizConfRepo:   bizConfRepo,
+		defaultPolicy: defaultPolicy,
+		logger:        logger,
+	}
+}
@@ -0,0 +1,419 @@
+package notification
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/errs"
+	"github.com/JrMarcco/jotify/internal/pkg/metrics"
+	"github.com/JrMarcco/jotify/internal/pkg/privacy"
+	"github.com/JrMarcco/jotify/internal/repository"
+	"go.uber.org/zap"
+)
+
+//go:generate mockgen -source=./notification_suppression.go -destination=./mock/suppression_service.mock.go -package=notificationmock -typed SuppressionService,UnsubscribeService
+
+// SuppressionService 退订名单管理，bizId 为 0 时管理对全部业务方生效的全局名单
+type SuppressionService interface {
+	// Import 批量导入，接收者已在名单中时更新原因与过期时间
+	Import(ctx context.Context, bizId uint64, ss []domain.Suppression) error
+	Remove(ctx context.Context, bizId uint64, channel domain.Channel, receiver string) error
+	List(ctx context.Context, bizId uint64, receiver string, offset int, limit int) ([]domain.Suppression, error)
+}
+
+var _ SuppressionService = (*DefaultSuppressionService)(nil)
+
+type DefaultSuppressionService struct {
+	suppressionRepo repository.SuppressionRepo
+}
+
+func (d *DefaultSuppressionService) Import(ctx context.Context, bizId uint64, ss []domain.Suppression) error {
+	if len(ss) == 0 {
+		return fmt.Errorf("%w: suppressions should not be empty", errs.ErrInvalidParam)
+	}
+	for i := range ss {
+		ss[i].BizId = bizId
+		if err := ss[i].Validate(); err != nil {
+			return err
+		}
+	}
+	return d.suppressionRepo.BatchSave(ctx, ss)
+}
+
+func (d *DefaultSuppressionService) Remove(ctx context.Context, bizId uint64, channel domain.Channel, receiver string) error {
+	if strings.TrimSpace(receiver) == "" || !channel.Validate() {
+		return fmt.Errorf("%w: receiver and channel should be set", errs.ErrInvalidParam)
+	}
+	return d.suppressionRepo.Delete(ctx, bizId, channel, receiver)
+}
+
+func (d *DefaultSuppressionService) List(ctx context.Context, bizId uint64, receiver string, offset int, limit int) ([]domain.Suppression, error) {
+	const maxLimit = 500
+	if offset < 0 || limit <= 0 || limit > maxLimit {
+		return nil, fmt.Errorf("%w: offset should not be negative and limit should be in (0, %d]", errs.ErrInvalidParam, maxLimit)
+	}
+	return d.suppressionRepo.List(ctx, bizId, receiver, offset, limit)
+}
+
+func NewDefaultSuppressionService(suppressionRepo repository.SuppressionRepo) *DefaultSuppressionService {
+	return &DefaultSuppressionService{
+		suppressionRepo: suppressionRepo,
+	}
+}
+
+// UnsubscribeService 根据接收者的退订操作自动维护退订名单。
+//
+// 上行回复（如短信回复 TD）由供应商回调转发，需要携带配置的 inbound token，业务方由 token 绑定的业务方确定；
+// 退订链接中的 token 由发送营销邮件时签发，只包含接收者哈希。
+type UnsubscribeService interface {
+	// HandleReply 处理上行回复，内容为退订关键字时加入退订名单，返回是否加入
+	HandleReply(ctx context.Context, inboundToken string, reply domain.InboundReply) (bool, error)
+	// Unsubscribe 处理退订链接
+	Unsubscribe(ctx context.Context, token string) error
+}
+
+var _ UnsubscribeService = (*DefaultUnsubscribeService)(nil)
+
+type DefaultUnsubscribeService struct {
+	suppressionRepo repository.SuppressionRepo
+	signer          *privacy.Signer
+
+	inboundTokens map[string]uint64   // inbound token 与其绑定的业务方，业务 id 为 0 时按全局退订处理
+	keywords      map[string]struct{} // 大写的退订关键字
+
+	logger *zap.Logger
+}
+
+func (d *DefaultUnsubscribeService) HandleReply(ctx context.Context, inboundToken string, reply domain.InboundReply) (bool, error) {
+	bizId, ok := d.bizIdOf(inboundToken)
+	if !ok {
+		return false, fmt.Errorf("%w: invalid inbound token", errs.ErrPermissionDenied)
+	}
+
+	if reply.Channel == "" {
+		reply.Channel = domain.ChannelSMS
+	}
+	if strings.TrimSpace(reply.Receiver) == "" || !reply.Channel.Validate() {
+		return false, fmt.Errorf("%w: receiver and channel should be set", errs.ErrInvalidParam)
+	}
+
+	if _, ok := d.keywords[strings.ToUpper(strings.TrimSpace(reply.Content))]; !ok {
+		return false, nil
+	}
+
+	err := d.suppressionRepo.BatchSave(ctx, []domain.Suppression{{
+		BizId:    bizId,
+		Receiver: reply.Receiver,
+		Channel:  reply.Channel,
+		Reason:   domain.SuppressionReasonUnsubscribe,
+	}})
+	if err != nil {
+		return false, err
+	}
+
+	metrics.Unsubscribes.WithLabelValues("reply").Inc()
+	d.logger.Info(
+		"[jotify] receiver unsubscribed by inbound reply",
+		zap.Uint64("biz_id", bizId),
+		zap.String("channel", reply.Channel.String()),
+	)
+	return true, nil
+}
+
+// bizIdOf 返回 inbound token 绑定的业务方，逐个比较全部 token，耗时与匹配的位置无关
+func (d *DefaultUnsubscribeService) bizIdOf(inboundToken string) (uint64, bool) {
+	var bizId uint64
+	matched := false
+	for token, id := range d.inboundTokens {
+		if subtle.ConstantTimeCompare([]byte(inboundToken), []byte(token)) == 1 {
+			bizId, matched = id, true
+		}
+	}
+	return bizId, matched
+}
+
+func (d *DefaultUnsubscribeService) Unsubscribe(ctx context.Context, token string) error {
+	payload, err := d.signer.Verify(token)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errs.ErrInvalidParam, err)
+	}
+
+	var p unsubscribePayload
+	if err = json.Unmarshal(payload, &p); err != nil || p.ReceiverHash == "" || !p.Channel.Validate() {
+		return fmt.Errorf("%w: invalid unsubscribe token", errs.ErrInvalidParam)
+	}
+
+	err = d.suppressionRepo.BatchSave(ctx, []domain.Suppression{{
+		BizId:        p.BizId,
+		ReceiverHash: p.ReceiverHash,
+		Channel:      p.Channel,
+		Reason:       domain.SuppressionReasonUnsubscribe,
+	}})
+	if err != nil {
+		return err
+	}
+
+	metrics.Unsubscribes.WithLabelValues("link").Inc()
+	return nil
+}
+
+func NewDefaultUnsubscribeService(
+	suppressionRepo repository.SuppressionRepo,
+	signer *privacy.Signer,
+	inboundTokens map[string]uint64,
+	keywords []string,
+	logger *zap.Logger,
+) *DefaultUnsubscribeService {
+	tokens := make(map[string]uint64, len(inboundTokens))
+	for token, bizId := range inboundTokens {
+		// 空 token 不能通过校验
+		if token != "" {
+			tokens[token] = bizId
+		}
+	}
+
+	keywordSet := make(map[string]struct{}, len(keywords))
+	for _, keyword := range keywords {
+		keywordSet[strings.ToUpper(strings.TrimSpace(keyword))] = struct{}{}
+	}
+	return &DefaultUnsubscribeService{
+		suppressionRepo: suppressionRepo,
+		signer:          signer,
+		inboundTokens:   tokens,
+		keywords:        keywordSet,
+		logger:          logger,
+	}
+}
+
+// unsubscribePayload 退订链接 token 的内容
+type unsubscribePayload struct {
+	BizId        uint64         `json:"b"`
+	Channel      domain.Channel `json:"c"`
+	ReceiverHash string         `json:"h"`
+}
+
+// unsubscribeParam 营销邮件中注入的退订链接模板参数名
+const unsubscribeParam = "unsubscribe_url"
+
+var _ SendService = (*SuppressionSendService)(nil)
+
+// SuppressionSendService 退订名单装饰器，在扣减配额之前移除不应发送的接收者。
+//
+// 营销类消息移除在退订名单中的接收者。
+// 被移除的接收者通过发送结果逐个返回，全部接收者都被移除时不创建消息，发送结果状态为 suppressed。
+// 只有一个接收者的营销邮件会注入带签名的退订链接（模板参数 unsubscribe_url）。
+type SuppressionSendService struct {
+	svc SendService
+
+	suppressionRepo repository.SuppressionRepo
+	tplRepo         repository.ChannelTplRepo
+	hasher          *privacy.Hasher
+	signer          *privacy.Signer
+
+	unsubscribeURL string
+}
+
+// filtered 过滤后的消息
+type filtered struct {
+	n          domain.Notification
+	suppressed []domain.SuppressedReceiver
+	bizType    domain.BizType
+}
+
+func (s *SuppressionSendService) Send(ctx context.Context, n domain.Notification) (domain.SendResp, error) {
+	f, err := s.filter(ctx, n, make(map[uint64]domain.BizType))
+	if err != nil {
+		return domain.SendResp{}, err
+	}
+	if len(f.n.Receivers) == 0 {
+		return domain.SendResp{Result: domain.SendResult{Status: domain.SendStatusSuppressed, Suppressed: f.suppressed}}, nil
+	}
+
+	resp, err := s.svc.Send(ctx, f.n)
+	resp.Result.Suppressed = append(resp.Result.Suppressed, f.suppressed...)
+	return resp, err
+}
+
+func (s *SuppressionSendService) AsyncSend(ctx context.Context, n domain.Notification) (domain.SendResp, error) {
+	f, err := s.filter(ctx, n, make(map[uint64]domain.BizType))
+	if err != nil {
+		return domain.SendResp{}, err
+	}
+	if len(f.n.Receivers) == 0 {
+		return domain.SendResp{Result: domain.SendResult{Status: domain.SendStatusSuppressed, Suppressed: f.suppressed}}, nil
+	}
+
+	resp, err := s.svc.AsyncSend(ctx, f.n)
+	resp.Result.Suppressed = append(resp.Result.Suppressed, f.suppressed...)
+	return resp, err
+}
+
+func (s *SuppressionSendService) BatchSend(ctx context.Context, ns []domain.Notification) (domain.BatchSendResp, error) {
+	fs, kept, err := s.filterAll(ctx, ns)
+	if err != nil {
+		return domain.BatchSendResp{}, err
+	}
+
+	var resp domain.BatchSendResp
+	if len(kept) > 0 {
+		if resp, err = s.svc.BatchSend(ctx, kept); err != nil {
+			return resp, err
+		}
+	}
+
+	// 按请求顺序合并结果，全部接收者都被移除的消息没有发送结果
+	results := make([]domain.SendResult, 0, len(ns))
+	next := 0
+	for _, f := range fs {
+		if len(f.n.Receivers) == 0 {
+			results = append(results, domain.SendResult{Status: domain.SendStatusSuppressed, Suppressed: f.suppressed})
+			continue
+		}
+		if next < len(resp.Results) {
+			res := resp.Results[next]
+			res.Suppressed = append(res.Suppressed, f.suppressed...)
+			results = append(results, res)
+			next++
+		}
+	}
+	return domain.BatchSendResp{Results: results}, nil
+}
+
+func (s *SuppressionSendService) BatchAsyncSend(ctx context.Context, ns []domain.Notification) (domain.BatchAsyncSendResp, error) {
+	fs, kept, err := s.filterAll(ctx, ns)
+	if err != nil {
+		return domain.BatchAsyncSendResp{}, err
+	}
+
+	var resp domain.BatchAsyncSendResp
+	if len(kept) > 0 {
+		if resp, err = s.svc.BatchAsyncSend(ctx, kept); err != nil {
+			return resp, err
+		}
+	}
+	for _, f := range fs {
+		resp.Suppressed = append(resp.Suppressed, f.suppressed...)
+	}
+	return resp, nil
+}
+
+// filterAll 过滤批量消息，返回每条消息的过滤结果以及仍有接收者的消息
+func (s *SuppressionSendService) filterAll(ctx context.Context, ns []domain.Notification) ([]filtered, []domain.Notification, error) {
+	bizTypes := make(map[uint64]domain.BizType)
+	fs := make([]filtered, 0, len(ns))
+	kept := make([]domain.Notification, 0, len(ns))
+	for i := range ns {
+		f, err := s.filter(ctx, ns[i], bizTypes)
+		if err != nil {
+			return nil, nil, err
+		}
+		fs = append(fs, f)
+		if len(f.n.Receivers) > 0 {
+			kept = append(kept, f.n)
+		}
+	}
+	return fs, kept, nil
+}
+
+// filter 移除退订名单中的接收者，bizTypes 缓存同一请求中模板的业务类型
+func (s *SuppressionSendService) filter(
+	ctx context.Context, n domain.Notification, bizTypes map[uint64]domain.BizType,
+) (filtered, error) {
+	if err := n.Validate(); err != nil {
+		return filtered{}, err
+	}
+	// 周期发送计划在每次触发创建消息时过滤
+	if n.StrategyConfig.Type == domain.SendStrategyRecurring {
+		return filtered{n: n}, nil
+	}
+
+	bizType, ok := bizTypes[n.Template.Id]
+	if !ok {
+		tpl, err := s.tplRepo.GetById(ctx, n.Template.Id)
+		if err != nil {
+			return filtered{}, fmt.Errorf("failed to get template %d: %w", n.Template.Id, err)
+		}
+		bizType = tpl.BizType
+		bizTypes[n.Template.Id] = bizType
+	}
+
+	f := filtered{n: n, bizType: bizType}
+	if bizType == domain.BizTypePromotion {
+		found, err := s.suppressionRepo.FindSuppressed(ctx, n.BizId, n.Channel, n.Receivers)
+		if err != nil {
+			return filtered{}, err
+		}
+		f.remove(func(receiver string) (domain.SuppressionReason, bool) {
+			sup, ok := found[receiver]
+			return sup.Reason, ok
+		})
+	}
+
+	if bizType == domain.BizTypePromotion && n.Channel.IsEmail() && len(f.n.Receivers) == 1 && s.unsubscribeURL != "" {
+		f.n.Template.Params = s.withUnsubscribeURL(f.n, f.n.Receivers[0])
+	}
+	return f, nil
+}
+
+// remove 移除 match 命中的接收者并记录原因
+func (f *filtered) remove(match func(receiver string) (domain.SuppressionReason, bool)) {
+	receivers := make([]string, 0, len(f.n.Receivers))
+	for _, receiver := range f.n.Receivers {
+		reason, ok := match(receiver)
+		if !ok {
+			receivers = append(receivers, receiver)
+			continue
+		}
+		f.suppressed = append(f.suppressed, domain.SuppressedReceiver{
+			BizKey:   f.n.BizKey,
+			Receiver: receiver,
+			Reason:   reason,
+		})
+		metrics.SuppressedReceivers.WithLabelValues(f.n.Channel.String(), reason.String()).Inc()
+	}
+	f.n.Receivers = receivers
+}
+
+// withUnsubscribeURL 返回注入退订链接后的模板参数，业务方已经设置时保持不变
+func (s *SuppressionSendService) withUnsubscribeURL(n domain.Notification, receiver string) map[string]string {
+	if _, ok := n.Template.Params[unsubscribeParam]; ok {
+		return n.Template.Params
+	}
+
+	payload, _ := json.Marshal(unsubscribePayload{
+		BizId:        n.BizId,
+		Channel:      n.Channel,
+		ReceiverHash: s.hasher.Hash(receiver),
+	})
+
+	// 复制一份，避免修改调用方的模板参数
+	params := make(map[string]string, len(n.Template.Params)+1)
+	for k, v := range n.Template.Params {
+		params[k] = v
+	}
+	params[unsubscribeParam] = s.unsubscribeURL + "?token=" + url.QueryEscape(s.signer.Sign(payload))
+	return params
+}
+
+func NewSuppressionSendService(
+	svc SendService,
+	suppressionRepo repository.SuppressionRepo,
+	tplRepo repository.ChannelTplRepo,
+	hasher *privacy.Hasher,
+	signer *privacy.Signer,
+	unsubscribeURL string,
+) *SuppressionSendService {
+	return &SuppressionSendService{
+		svc:             svc,
+		suppressionRepo: suppressionRepo,
+		tplRepo:         tplRepo,
+		hasher:          hasher,
+		signer:          signer,
+		unsubscribeURL:  unsubscribeURL,
+	}
+}
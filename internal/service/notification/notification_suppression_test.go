@@ -0,0 +1,100 @@
+package notification
+
+import (
+	"context"
+	"testing"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/errs"
+	"github.com/JrMarcco/jotify/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeSuppressionRepo 记录保存的退订记录
+type fakeSuppressionRepo struct {
+	repository.SuppressionRepo
+	saved []domain.Suppression
+}
+
+func (r *fakeSuppressionRepo) BatchSave(_ context.Context, ss []domain.Suppression) error {
+	r.saved = append(r.saved, ss...)
+	return nil
+}
+
+func TestDefaultUnsubscribeService_HandleReply(t *testing.T) {
+	t.Parallel()
+
+	tokens := map[string]uint64{
+		"biz_token":    1,
+		"shared_token": 0,
+		"":             2,
+	}
+
+	tcs := []struct {
+		name           string
+		token          string
+		reply          domain.InboundReply
+		wantErr        error
+		wantSuppressed bool
+		wantSaved      []domain.Suppression
+	}{
+		{
+			name:           "biz id from token binding",
+			token:          "biz_token",
+			reply:          domain.InboundReply{Receiver: "13800000000", Content: " td "},
+			wantSuppressed: true,
+			wantSaved: []domain.Suppression{{
+				BizId:    1,
+				Receiver: "13800000000",
+				Channel:  domain.ChannelSMS,
+				Reason:   domain.SuppressionReasonUnsubscribe,
+			}},
+		}, {
+			name:           "shared token suppresses globally",
+			token:          "shared_token",
+			reply:          domain.InboundReply{Receiver: "13800000000", Content: "STOP"},
+			wantSuppressed: true,
+			wantSaved: []domain.Suppression{{
+				BizId:    0,
+				Receiver: "13800000000",
+				Channel:  domain.ChannelSMS,
+				Reason:   domain.SuppressionReasonUnsubscribe,
+			}},
+		}, {
+			name:  "not a keyword",
+			token: "biz_token",
+			reply: domain.InboundReply{Receiver: "13800000000", Content: "hello"},
+		}, {
+			name:    "unknown token",
+			token:   "other",
+			reply:   domain.InboundReply{Receiver: "13800000000", Content: "TD"},
+			wantErr: errs.ErrPermissionDenied,
+		}, {
+			name:    "empty token",
+			token:   "",
+			reply:   domain.InboundReply{Receiver: "13800000000", Content: "TD"},
+			wantErr: errs.ErrPermissionDenied,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := &fakeSuppressionRepo{}
+			svc := NewDefaultUnsubscribeService(repo, nil, tokens, []string{"TD", "STOP"}, zap.NewNop())
+
+			suppressed, err := svc.HandleReply(t.Context(), tc.token, tc.reply)
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				assert.Empty(t, repo.saved)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantSuppressed, suppressed)
+			assert.Equal(t, tc.wantSaved, repo.saved)
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package organization
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/errs"
+	"github.com/JrMarcco/jotify/internal/repository"
+)
+
+//go:generate mockgen -source=./service.go -destination=./mock/service.mock.go -package=organizationmock -typed Service
+
+// Service 组织管理服务接口
+type Service interface {
+	Create(ctx context.Context, o domain.Organization) (domain.Organization, error)
+	Get(ctx context.Context, id uint64) (domain.Organization, error)
+	// Update 更新组织的默认配置
+	Update(ctx context.Context, o domain.Organization) error
+
+	// Join 将业务方加入组织
+	Join(ctx context.Context, orgId uint64, bizId uint64) error
+	// Leave 将业务方移出组织，已分配的配额保留在业务方
+	Leave(ctx context.Context, orgId uint64, bizId uint64) error
+
+	// TopUp 向组织配额池增加配额，返回增加后的余额
+	TopUp(ctx context.Context, orgId uint64, channel domain.Channel, quota int64) (int64, error)
+	// SetCap 设置成员业务方在渠道上的配额余额上限，0 表示不限
+	SetCap(ctx context.Context, orgId uint64, bizId uint64, channel domain.Channel, limit int64) error
+	// Allocate 从组织配额池向成员业务方分配配额，分配后业务方配额余额不超过上限
+	Allocate(ctx context.Context, orgId uint64, bizId uint64, channel domain.Channel, quota int64) error
+	// Reclaim 将成员业务方未使用的配额收回组织配额池
+	Reclaim(ctx context.Context, orgId uint64, bizId uint64, channel domain.Channel, quota int64) error
+
+	// Usage 汇总组织在渠道上的配额使用情况
+	Usage(ctx context.Context, orgId uint64, channel domain.Channel) (domain.OrgUsage, error)
+}
+
+var _ Service = (*DefaultService)(nil)
+
+type DefaultService struct {
+	orgRepo     repository.OrganizationRepo
+	bizConfRepo repository.BizConfRepo
+}
+
+func (d *DefaultService) Create(ctx context.Context, o domain.Organization) (domain.Organization, error) {
+	if err := o.Validate(); err != nil {
+		return domain.Organization{}, err
+	}
+	return d.orgRepo.Create(ctx, o)
+}
+
+func (d *DefaultService) Get(ctx context.Context, id uint64) (domain.Organization, error) {
+	return d.orgRepo.GetById(ctx, id)
+}
+
+func (d *DefaultService) Update(ctx context.Context, o domain.Organization) error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+	return d.orgRepo.Update(ctx, o)
+}
+
+func (d *DefaultService) Join(ctx context.Context, orgId uint64, bizId uint64) error {
+	if _, err := d.orgRepo.GetById(ctx, orgId); err != nil {
+		return err
+	}
+	return d.orgRepo.SetMember(ctx, bizId, orgId)
+}
+
+func (d *DefaultService) Leave(ctx context.Context, orgId uint64, bizId uint64) error {
+	if err := d.checkMember(ctx, orgId, bizId); err != nil {
+		return err
+	}
+	return d.orgRepo.SetMember(ctx, bizId, 0)
+}
+
+func (d *DefaultService) TopUp(ctx context.Context, orgId uint64, channel domain.Channel, quota int64) (int64, error) {
+	if err := d.checkQuota(channel, quota); err != nil {
+		return 0, err
+	}
+	if _, err := d.orgRepo.GetById(ctx, orgId); err != nil {
+		return 0, err
+	}
+	return d.orgRepo.TopUp(ctx, orgId, channel, quota)
+}
+
+func (d *DefaultService) SetCap(ctx context.Context, orgId uint64, bizId uint64, channel domain.Channel, limit int64) error {
+	if !channel.Validate() {
+		return fmt.Errorf("%w: invalid channel %q", errs.ErrInvalidParam, channel)
+	}
+	if limit < 0 {
+		return fmt.Errorf("%w: cap should not be negative", errs.ErrInvalidParam)
+	}
+	if err := d.checkMember(ctx, orgId, bizId); err != nil {
+		return err
+	}
+	return d.orgRepo.SetCap(ctx, domain.OrgQuotaAlloc{OrgId: orgId, BizId: bizId, Channel: channel, Cap: limit})
+}
+
+func (d *DefaultService) Allocate(ctx context.Context, orgId uint64, bizId uint64, channel domain.Channel, quota int64) error {
+	// 先校验再转换方向，负数不能被当作收回
+	if err := d.checkQuota(channel, quota); err != nil {
+		return err
+	}
+	return d.transfer(ctx, orgId, bizId, channel, quota)
+}
+
+func (d *DefaultService) Reclaim(ctx context.Context, orgId uint64, bizId uint64, channel domain.Channel, quota int64) error {
+	if err := d.checkQuota(channel, quota); err != nil {
+		return err
+	}
+	return d.transfer(ctx, orgId, bizId, channel, -quota)
+}
+
+// transfer 在组织配额池与成员业务方之间转移配额，quota 为负数时从业务方收回，调用方已校验配额
+func (d *DefaultService) transfer(ctx context.Context, orgId uint64, bizId uint64, channel domain.Channel, quota int64) error {
+	if err := d.checkMember(ctx, orgId, bizId); err != nil {
+		return err
+	}
+
+	a, err := d.orgRepo.GetAlloc(ctx, bizId, channel)
+	if err != nil {
+		return err
+	}
+	a.OrgId = orgId
+	return d.orgRepo.Allocate(ctx, a, quota)
+}
+
+func (d *DefaultService) Usage(ctx context.Context, orgId uint64, channel domain.Channel) (domain.OrgUsage, error) {
+	if !channel.Validate() {
+		return domain.OrgUsage{}, fmt.Errorf("%w: invalid channel %q", errs.ErrInvalidParam, channel)
+	}
+
+	bizIds, err := d.orgRepo.FindMemberIds(ctx, orgId)
+	if err != nil {
+		return domain.OrgUsage{}, err
+	}
+	allocs, err := d.orgRepo.FindAllocs(ctx, orgId, channel)
+	if err != nil {
+		return domain.OrgUsage{}, err
+	}
+	balances, err := d.orgRepo.Balances(ctx, bizIds, channel)
+	if err != nil {
+		return domain.OrgUsage{}, err
+	}
+	pool, err := d.orgRepo.Pool(ctx, orgId, channel)
+	if err != nil {
+		return domain.OrgUsage{}, err
+	}
+
+	allocMap := make(map[uint64]domain.OrgQuotaAlloc, len(allocs))
+	for _, a := range allocs {
+		allocMap[a.BizId] = a
+	}
+
+	usage := domain.OrgUsage{OrgId: orgId, Channel: channel, Pool: pool}
+	for _, bizId := range bizIds {
+		a := allocMap[bizId]
+		remaining := balances[bizId]
+		usage.Add(domain.OrgBizUsage{
+			BizId:     bizId,
+			Cap:       a.Cap,
+			Allocated: a.Allocated,
+			Remaining: remaining,
+			// 加入组织前业务方已有的配额不计入分配，使用量不小于 0
+			Used: max(a.Allocated-remaining, 0),
+		})
+	}
+	return usage, nil
+}
+
+// checkMember 校验业务方属于组织
+func (d *DefaultService) checkMember(ctx context.Context, orgId uint64, bizId uint64) error {
+	bc, err := d.bizConfRepo.GetById(ctx, bizId)
+	if err != nil {
+		return err
+	}
+	if id, ok := bc.OrgId(); !ok || id != orgId {
+		return fmt.Errorf("%w: biz %d is not a member of organization %d", errs.ErrNotOrgMember, bizId, orgId)
+	}
+	return nil
+}
+
+func (d *DefaultService) checkQuota(channel domain.Channel, quota int64) error {
+	if !channel.Validate() {
+		return fmt.Errorf("%w: invalid channel %q", errs.ErrInvalidParam, channel)
+	}
+	if quota <= 0 {
+		return fmt.Errorf("%w: quota should be positive", errs.ErrInvalidParam)
+	}
+	return nil
+}
+
+func NewDefaultService(orgRepo repository.OrganizationRepo, bizConfRepo repository.BizConfRepo) *DefaultService {
+	return &DefaultService{
+		orgRepo:     orgRepo,
+		bizConfRepo: bizConfRepo,
+	}
+}
@@ -0,0 +1,300 @@
+package organization
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/errs"
+	"github.com/JrMarcco/jotify/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeOrgRepo 内存中的组织、配额池与业务方配额，Allocate 的校验与 org_quota_transfer.lua 一致
+type fakeOrgRepo struct {
+	repository.OrganizationRepo
+
+	orgs     map[uint64]domain.Organization
+	members  map[uint64]uint64 // biz id -> org id
+	pools    map[uint64]int64  // org id -> 配额池
+	balances map[uint64]int64  // biz id -> 配额余额
+	allocs   map[uint64]domain.OrgQuotaAlloc
+}
+
+func newFakeOrgRepo() *fakeOrgRepo {
+	return &fakeOrgRepo{
+		orgs:     map[uint64]domain.Organization{1: {Id: 1}, 2: {Id: 2}},
+		members:  make(map[uint64]uint64),
+		pools:    make(map[uint64]int64),
+		balances: make(map[uint64]int64),
+		allocs:   make(map[uint64]domain.OrgQuotaAlloc),
+	}
+}
+
+func (r *fakeOrgRepo) GetById(_ context.Context, id uint64) (domain.Organization, error) {
+	o, ok := r.orgs[id]
+	if !ok {
+		return domain.Organization{}, fmt.Errorf("%w: id = %d", errs.ErrOrganizationNotFound, id)
+	}
+	return o, nil
+}
+
+func (r *fakeOrgRepo) FindMemberIds(_ context.Context, orgId uint64) ([]uint64, error) {
+	var ids []uint64
+	for bizId, id := range r.members {
+		if id == orgId {
+			ids = append(ids, bizId)
+		}
+	}
+	return ids, nil
+}
+
+func (r *fakeOrgRepo) TopUp(_ context.Context, orgId uint64, _ domain.Channel, quota int64) (int64, error) {
+	r.pools[orgId] += quota
+	return r.pools[orgId], nil
+}
+
+func (r *fakeOrgRepo) Pool(_ context.Context, orgId uint64, _ domain.Channel) (int64, error) {
+	return r.pools[orgId], nil
+}
+
+func (r *fakeOrgRepo) Balances(_ context.Context, bizIds []uint64, _ domain.Channel) (map[uint64]int64, error) {
+	res := make(map[uint64]int64, len(bizIds))
+	for _, bizId := range bizIds {
+		if balance := r.balances[bizId]; balance > 0 {
+			res[bizId] = balance
+		}
+	}
+	return res, nil
+}
+
+func (r *fakeOrgRepo) GetAlloc(_ context.Context, bizId uint64, channel domain.Channel) (domain.OrgQuotaAlloc, error) {
+	a, ok := r.allocs[bizId]
+	if !ok {
+		return domain.OrgQuotaAlloc{BizId: bizId, Channel: channel}, nil
+	}
+	return a, nil
+}
+
+func (r *fakeOrgRepo) FindAllocs(_ context.Context, orgId uint64, _ domain.Channel) ([]domain.OrgQuotaAlloc, error) {
+	var res []domain.OrgQuotaAlloc
+	for _, a := range r.allocs {
+		if a.OrgId == orgId {
+			res = append(res, a)
+		}
+	}
+	return res, nil
+}
+
+func (r *fakeOrgRepo) SetCap(_ context.Context, a domain.OrgQuotaAlloc) error {
+	stored := r.allocs[a.BizId]
+	stored.OrgId, stored.BizId, stored.Channel, stored.Cap = a.OrgId, a.BizId, a.Channel, a.Cap
+	r.allocs[a.BizId] = stored
+	return nil
+}
+
+func (r *fakeOrgRepo) Allocate(_ context.Context, a domain.OrgQuotaAlloc, quota int64) error {
+	balance := max(r.balances[a.BizId], 0)
+	switch {
+	case quota > 0 && r.pools[a.OrgId] < quota:
+		return errs.ErrInsufficientQuota
+	case quota > 0 && a.Cap > 0 && balance+quota > a.Cap:
+		return errs.ErrOrgQuotaCapExceeded
+	case quota < 0 && balance < -quota:
+		return errs.ErrInsufficientQuota
+	}
+
+	r.pools[a.OrgId] -= quota
+	r.balances[a.BizId] = balance + quota
+	a.Allocated += quota
+	r.allocs[a.BizId] = a
+	return nil
+}
+
+type fakeBizConfRepo struct {
+	repository.BizConfRepo
+	orgRepo *fakeOrgRepo
+}
+
+func (r *fakeBizConfRepo) GetById(_ context.Context, bizId uint64) (domain.BizConf, error) {
+	bc := domain.BizConf{Id: bizId}
+	if orgId, ok := r.orgRepo.members[bizId]; ok {
+		bc.OwnerType, bc.OwnerId = string(domain.OwnerTypeOrganization), orgId
+	}
+	return bc, nil
+}
+
+// newTestService 组织 1 的配额池有 100，业务方 11 与 12 属于组织 1，业务方 21 属于组织 2
+func newTestService(t *testing.T) (*DefaultService, *fakeOrgRepo) {
+	t.Helper()
+
+	orgRepo := newFakeOrgRepo()
+	orgRepo.members[11], orgRepo.members[12], orgRepo.members[21] = 1, 1, 2
+	svc := NewDefaultService(orgRepo, &fakeBizConfRepo{orgRepo: orgRepo})
+
+	_, err := svc.TopUp(t.Context(), 1, domain.ChannelSMS, 100)
+	require.NoError(t, err)
+	return svc, orgRepo
+}
+
+func TestDefaultService_Transfer(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name        string
+		run         func(ctx context.Context, svc *DefaultService) error
+		wantErr     error
+		wantPool    int64
+		wantBalance map[uint64]int64
+	}{
+		{
+			name: "allocate within cap",
+			run: func(ctx context.Context, svc *DefaultService) error {
+				if err := svc.SetCap(ctx, 1, 11, domain.ChannelSMS, 50); err != nil {
+					return err
+				}
+				return svc.Allocate(ctx, 1, 11, domain.ChannelSMS, 50)
+			},
+			wantPool:    50,
+			wantBalance: map[uint64]int64{11: 50},
+		}, {
+			name: "allocate over cap",
+			run: func(ctx context.Context, svc *DefaultService) error {
+				if err := svc.SetCap(ctx, 1, 11, domain.ChannelSMS, 50); err != nil {
+					return err
+				}
+				if err := svc.Allocate(ctx, 1, 11, domain.ChannelSMS, 30); err != nil {
+					return err
+				}
+				return svc.Allocate(ctx, 1, 11, domain.ChannelSMS, 21)
+			},
+			wantErr:     errs.ErrOrgQuotaCapExceeded,
+			wantPool:    70,
+			wantBalance: map[uint64]int64{11: 30},
+		}, {
+			name: "allocate over pool",
+			run: func(ctx context.Context, svc *DefaultService) error {
+				return svc.Allocate(ctx, 1, 11, domain.ChannelSMS, 101)
+			},
+			wantErr:  errs.ErrInsufficientQuota,
+			wantPool: 100,
+		}, {
+			name: "negative allocation",
+			run: func(ctx context.Context, svc *DefaultService) error {
+				return svc.Allocate(ctx, 1, 11, domain.ChannelSMS, -10)
+			},
+			wantErr:  errs.ErrInvalidParam,
+			wantPool: 100,
+		}, {
+			name: "negative reclaim",
+			run: func(ctx context.Context, svc *DefaultService) error {
+				if err := svc.Allocate(ctx, 1, 11, domain.ChannelSMS, 10); err != nil {
+					return err
+				}
+				return svc.Reclaim(ctx, 1, 11, domain.ChannelSMS, -10)
+			},
+			wantErr:     errs.ErrInvalidParam,
+			wantPool:    90,
+			wantBalance: map[uint64]int64{11: 10},
+		}, {
+			name: "negative cap",
+			run: func(ctx context.Context, svc *DefaultService) error {
+				return svc.SetCap(ctx, 1, 11, domain.ChannelSMS, -1)
+			},
+			wantErr:  errs.ErrInvalidParam,
+			wantPool: 100,
+		}, {
+			name: "reclaim over balance",
+			run: func(ctx context.Context, svc *DefaultService) error {
+				if err := svc.Allocate(ctx, 1, 11, domain.ChannelSMS, 10); err != nil {
+					return err
+				}
+				return svc.Reclaim(ctx, 1, 11, domain.ChannelSMS, 11)
+			},
+			wantErr:     errs.ErrInsufficientQuota,
+			wantPool:    90,
+			wantBalance: map[uint64]int64{11: 10},
+		}, {
+			name: "cross org allocation rejected",
+			run: func(ctx context.Context, svc *DefaultService) error {
+				return svc.Allocate(ctx, 1, 21, domain.ChannelSMS, 10)
+			},
+			wantErr:  errs.ErrNotOrgMember,
+			wantPool: 100,
+		}, {
+			name: "cross org reclaim rejected",
+			run: func(ctx context.Context, svc *DefaultService) error {
+				return svc.Reclaim(ctx, 1, 21, domain.ChannelSMS, 10)
+			},
+			wantErr:  errs.ErrNotOrgMember,
+			wantPool: 100,
+		}, {
+			name: "cross org cap rejected",
+			run: func(ctx context.Context, svc *DefaultService) error {
+				return svc.SetCap(ctx, 2, 11, domain.ChannelSMS, 10)
+			},
+			wantErr:  errs.ErrNotOrgMember,
+			wantPool: 100,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc, orgRepo := newTestService(t)
+			err := tc.run(t.Context(), svc)
+			assert.ErrorIs(t, err, tc.wantErr)
+			if tc.wantErr == nil {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tc.wantPool, orgRepo.pools[1])
+			for _, bizId := range []uint64{11, 12, 21} {
+				assert.Equal(t, tc.wantBalance[bizId], orgRepo.balances[bizId], "balance of biz %d", bizId)
+			}
+		})
+	}
+}
+
+func TestDefaultService_Usage(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	svc, orgRepo := newTestService(t)
+
+	require.NoError(t, svc.SetCap(ctx, 1, 11, domain.ChannelSMS, 40))
+	require.NoError(t, svc.Allocate(ctx, 1, 11, domain.ChannelSMS, 40))
+	require.NoError(t, svc.Allocate(ctx, 1, 12, domain.ChannelSMS, 30))
+	require.NoError(t, svc.Reclaim(ctx, 1, 12, domain.ChannelSMS, 10))
+	// 业务方 11 使用了 15，业务方 12 在加入组织前已有 5
+	orgRepo.balances[11] -= 15
+	orgRepo.balances[12] += 5
+	// 其他组织的成员不计入汇总
+	_, err := svc.TopUp(ctx, 2, domain.ChannelSMS, 10)
+	require.NoError(t, err)
+	require.NoError(t, svc.Allocate(ctx, 2, 21, domain.ChannelSMS, 10))
+
+	usage, err := svc.Usage(ctx, 1, domain.ChannelSMS)
+	require.NoError(t, err)
+
+	assert.Equal(t, uint64(1), usage.OrgId)
+	assert.Equal(t, int64(40), usage.Pool)
+	assert.Equal(t, int64(60), usage.Allocated)
+	assert.Equal(t, int64(50), usage.Remaining)
+	assert.Equal(t, int64(15), usage.Used)
+	assert.ElementsMatch(t, []domain.OrgBizUsage{
+		{BizId: 11, Cap: 40, Allocated: 40, Remaining: 25, Used: 15},
+		// 余额超过累计分配时使用量为 0
+		{BizId: 12, Allocated: 20, Remaining: 25, Used: 0},
+	}, usage.Bizs)
+
+	// 汇总等于各成员之和
+	var allocated, remaining, used int64
+	for _, bu := range usage.Bizs {
+		allocated, remaining, used = allocated+bu.Allocated, remaining+bu.Remaining, used+bu.Used
+	}
+	assert.Equal(t, []int64{allocated, remaining, used}, []int64{usage.Allocated, usage.Remaining, usage.Used})
+}
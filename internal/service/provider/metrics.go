@@ -0,0 +1,50 @@
+package provider
+
+import (
+	"context"
+	"time"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/pkg/metrics"
+)
+
+var _ Provider = (*MetricsProvider)(nil)
+
+// MetricsProvider 记录监控指标的供应商装饰器。
+//
+// 记录每次发送的耗时以及按渠道、供应商、发送状态区分的发送结果。
+type MetricsProvider struct {
+	name    string
+	channel domain.Channel
+	p       Provider
+}
+
+func (mp *MetricsProvider) Send(ctx context.Context, n domain.Notification) (domain.SendResp, error) {
+	start := time.Now()
+	resp, err := mp.p.Send(ctx, n)
+	metrics.ProviderSendDuration.WithLabelValues(mp.channel.String(), mp.name).Observe(time.Since(start).Seconds())
+
+	metrics.SendResultTotal.WithLabelValues(mp.channel.String(), mp.name, resultStatus(resp, err).String()).Inc()
+	return resp, err
+}
+
+// resultStatus 发送结果的状态标签。
+//
+// 供应商可能不返回 error 而在响应中标记发送失败，所以优先使用响应中的状态，未设置状态时视为发送成功。
+func resultStatus(resp domain.SendResp, err error) domain.SendStatus {
+	if err != nil {
+		return domain.SendStatusFailure
+	}
+	if resp.Result.Status == "" {
+		return domain.SendStatusSuccess
+	}
+	return resp.Result.Status
+}
+
+func NewMetricsProvider(name string, channel domain.Channel, p Provider) *MetricsProvider {
+	return &MetricsProvider{
+		name:    name,
+		channel: channel,
+		p:       p,
+	}
+}
@@ -0,0 +1,71 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeProvider struct {
+	resp domain.SendResp
+	err  error
+}
+
+func (p *fakeProvider) Send(context.Context, domain.Notification) (domain.SendResp, error) {
+	return p.resp, p.err
+}
+
+func TestMetricsProvider_Send(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name       string
+		resp       domain.SendResp
+		err        error
+		wantStatus domain.SendStatus
+	}{
+		{
+			name:       "success",
+			resp:       domain.SendResp{Result: domain.SendResult{Status: domain.SendStatusSuccess}},
+			wantStatus: domain.SendStatusSuccess,
+		}, {
+			name:       "status not set",
+			wantStatus: domain.SendStatusSuccess,
+		}, {
+			name:       "error",
+			err:        errors.New("mock error"),
+			wantStatus: domain.SendStatusFailure,
+		}, {
+			name:       "failure status without error",
+			resp:       domain.SendResp{Result: domain.SendResult{Status: domain.SendStatusFailure}},
+			wantStatus: domain.SendStatusFailure,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			// 每个用例使用不同的供应商名，避免计数互相影响
+			name := "metrics_test_" + tc.name
+			mp := NewMetricsProvider(name, domain.ChannelSMS, &fakeProvider{resp: tc.resp, err: tc.err})
+
+			_, err := mp.Send(t.Context(), domain.Notification{})
+			assert.ErrorIs(t, err, tc.err)
+
+			for _, status := range []domain.SendStatus{domain.SendStatusSuccess, domain.SendStatusFailure} {
+				want := 0.0
+				if status == tc.wantStatus {
+					want = 1
+				}
+				counter := metrics.SendResultTotal.WithLabelValues(domain.ChannelSMS.String(), name, status.String())
+				assert.Equal(t, want, testutil.ToFloat64(counter), "status %s", status)
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/JrMarcco/jotify/internal/domain"
 	"github.com/JrMarcco/jotify/internal/errs"
+	"github.com/JrMarcco/jotify/internal/pkg/tracing"
 	"github.com/JrMarcco/jotify/internal/repository"
 	"github.com/JrMarcco/jotify/internal/service/provider/sms/client"
 )
@@ -29,14 +30,28 @@ func (p *Provider) Send(ctx context.Context, n domain.Notification) (domain.Send
 		return domain.SendResp{}, fmt.Errorf("%w: no published templates found", errs.ErrFailedToSendNotification)
 	}
 
+	// 消息记录的语言在创建时已按供应商侧的模板选定，当前供应商没有对应语言的模板时由下一个供应商发送，
+	// 不回退到其他语言，保证实际发送的内容与消息记录的语言一致
+	version, ok := activatedVersion.Localize(n.Locale)
+	providers := version.ProvidersOf(n.Locale)
+	if !ok || len(providers) == 0 {
+		return domain.SendResp{}, fmt.Errorf("%w: locale = %q", errs.ErrNotAvailableProvider, n.Locale)
+	}
+
 	const first = 0
-	providerTplId := activatedVersion.Providers[first].ProviderTplId
+	providerTplId := providers[first].ProviderTplId
+
+	_, span := tracing.Start(ctx, "SmsClient.Send",
+		tracing.AttrProvider.String(p.name),
+		tracing.AttrNotificationId.Int64(int64(n.Id)),
+	)
 	resp, err := p.client.Send(client.SendReq{
 		PhoneNumbers:   n.Receivers,
-		SignName:       activatedVersion.Signature,
+		SignName:       version.Signature,
 		TemplateId:     providerTplId,
 		TemplateParams: n.Template.Params,
 	})
+	tracing.End(span, err)
 	if err != nil {
 		return domain.SendResp{}, fmt.Errorf("%w: %w", errs.ErrFailedToSendNotification, err)
 	}
@@ -83,7 +98,14 @@ func (p *Provider) getTemplate(ctx context.Context, templateId uint64) (domain.C
 		return domain.ChannelTpl{}, fmt.Errorf("%w: template id = %d, version id = %d", errs.ErrNotAvailableProvider, tpl.Id, version.Id)
 	}
 
+	// 获取生效版本的语言变体
+	variants, err := p.tplRepo.FindVariants(ctx, version.Id)
+	if err != nil {
+		return domain.ChannelTpl{}, err
+	}
+
 	version.Providers = providers
+	version.Variants = variants
 	tpl.Versions = []domain.ChannelTplVersion{version}
 	return tpl, nil
 }
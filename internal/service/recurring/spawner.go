@@ -0,0 +1,129 @@
+package recurring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/JrMarcco/dlock"
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/errs"
+	"github.com/JrMarcco/jotify/internal/pkg/job"
+	"github.com/JrMarcco/jotify/internal/pkg/metrics"
+	"github.com/JrMarcco/jotify/internal/repository"
+	"github.com/JrMarcco/jotify/internal/service/notification"
+	"go.uber.org/zap"
+)
+
+// Spawner 周期发送计划触发服务接口
+type Spawner interface {
+	// Start 启动触发任务，context.Context 被取消时退出
+	Start(ctx context.Context) error
+}
+
+var _ Spawner = (*DefaultSpawner)(nil)
+
+// DefaultSpawner 周期发送计划触发任务。
+//
+// 抢占分布式锁后每隔 interval 扫描到期的 active 计划，为每次触发创建一条在 [触发时间, 触发时间 + window] 内发送的消息，
+// 然后推进计划的下一次触发时间。消息的 biz_key 由计划的 biz_key 与触发时间派生，推进失败后重新触发不会重复创建。
+// 超过 window 仍未触发的（例如服务停机）视为错过，不再补发。
+type DefaultSpawner struct {
+	recurringRepo repository.RecurringRepo
+	notifRepo     repository.NotificationRepo
+	sendSvc       notification.SendService
+
+	interval  time.Duration
+	window    time.Duration
+	batchSize int
+
+	job    *job.LoopJob
+	logger *zap.Logger
+}
+
+func (s *DefaultSpawner) Start(ctx context.Context) error {
+	go func() {
+		_ = s.job.Run(ctx)
+	}()
+	return nil
+}
+
+// loop 触发一批到期的计划，没有更多到期计划时等待 interval
+func (s *DefaultSpawner) loop(ctx context.Context) error {
+	now := time.Now()
+	schedules, err := s.recurringRepo.FindDue(ctx, now, s.batchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, schedule := range schedules {
+		if err = s.spawn(ctx, schedule, now); err != nil {
+			metrics.RecurringOccurrences.WithLabelValues("failure").Inc()
+			s.logger.Error(
+				"[jotify] failed to spawn recurring occurrence",
+				zap.Error(err),
+				zap.Uint64("biz_id", schedule.BizId),
+				zap.String("biz_key", schedule.BizKey),
+			)
+		}
+	}
+
+	if len(schedules) < s.batchSize {
+		job.WaitUntil(ctx, now.Add(s.interval))
+	}
+	return nil
+}
+
+// spawn 为计划的本次触发创建消息并推进下一次触发时间
+func (s *DefaultSpawner) spawn(ctx context.Context, schedule domain.RecurringSchedule, now time.Time) error {
+	at := schedule.NextRunAt
+	if at.Add(s.window).Before(now) {
+		metrics.RecurringOccurrences.WithLabelValues("missed").Inc()
+		schedule.Advance(now)
+		return s.recurringRepo.CompareAndSwap(ctx, schedule)
+	}
+
+	n := schedule.Occurrence(at, s.window)
+	_, err := s.notifRepo.GetByKey(ctx, n.BizId, n.BizKey)
+	switch {
+	case err == nil:
+		// 上一次触发已创建消息，但推进计划失败
+	case errors.Is(err, errs.ErrNotificationNotFound):
+		if _, err = s.sendSvc.Send(ctx, n); err != nil {
+			return fmt.Errorf("failed to send occurrence %s: %w", n.BizKey, err)
+		}
+		metrics.RecurringOccurrences.WithLabelValues("spawned").Inc()
+	default:
+		return err
+	}
+
+	schedule.Occurrences++
+	schedule.Advance(at)
+	return s.recurringRepo.CompareAndSwap(ctx, schedule)
+}
+
+func NewDefaultSpawner(
+	dclient dlock.Dclient,
+	recurringRepo repository.RecurringRepo,
+	notifRepo repository.NotificationRepo,
+	sendSvc notification.SendService,
+	interval time.Duration,
+	window time.Duration,
+	batchSize int,
+	logger *zap.Logger,
+) *DefaultSpawner {
+	const jobKey = "jotify_recurring_spawner"
+
+	spawner := &DefaultSpawner{
+		recurringRepo: recurringRepo,
+		notifRepo:     notifRepo,
+		sendSvc:       sendSvc,
+		interval:      interval,
+		window:        window,
+		batchSize:     batchSize,
+		logger:        logger,
+	}
+	spawner.job = job.NewLoopJob(jobKey, dclient, logger, spawner.loop)
+	return spawner
+}
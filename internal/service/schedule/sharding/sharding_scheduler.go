@@ -2,15 +2,23 @@ package sharding
 
 import (
 	"context"
+	"slices"
+	"strconv"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/JrMarcco/dlock"
+	"github.com/JrMarcco/easy-kit/slice"
+	"github.com/JrMarcco/jotify/internal/domain"
 	"github.com/JrMarcco/jotify/internal/errs"
 	"github.com/JrMarcco/jotify/internal/pkg/batch"
 	"github.com/JrMarcco/jotify/internal/pkg/bitring"
 	"github.com/JrMarcco/jotify/internal/pkg/job"
+	"github.com/JrMarcco/jotify/internal/pkg/lane"
+	"github.com/JrMarcco/jotify/internal/pkg/metrics"
 	"github.com/JrMarcco/jotify/internal/pkg/sharding"
+	"github.com/JrMarcco/jotify/internal/pkg/tracing"
 	"github.com/JrMarcco/jotify/internal/repository"
 	"github.com/JrMarcco/jotify/internal/service/schedule"
 	"github.com/JrMarcco/jotify/internal/service/sender"
@@ -19,18 +27,37 @@ import (
 
 var _ schedule.NotifScheduler = (*NotifShardingScheduler)(nil)
 
-// NotifShardingScheduler 通知分库分表调度器
+// NotifShardingScheduler 通知分库分表调度器。
+//
+// 每批次先按优先级权重将批次大小分给各优先级，再在每个优先级内按业务方的调度权重做差额轮询，
+// 避免单个业务方的大量积压占满批次，拖慢其他业务方的发送。
 type NotifShardingScheduler struct {
 	notifRepo   repository.NotificationRepo
+	bizConfRepo repository.BizConfRepo
 	notifSender sender.Sender
 
 	loopInterval time.Duration
+	// weights 与 domain.Priorities 一一对应的各优先级权重
+	weights []int
 
 	batchSize     atomic.Uint64
 	batchAdjuster batch.Adjuster
 
+	// shards 各分表的调度状态
+	mu     sync.Mutex
+	shards map[sharding.Dst]*shardState
+
 	errEvents *bitring.BitRing
 	job       *job.ShardingLoopJob
+	logger    *zap.Logger
+}
+
+// shardState 分表的调度状态，同一时间只有持有分表锁的一个协程访问
+type shardState struct {
+	// drrs 与 domain.Priorities 一一对应，在业务方之间分配各优先级的份额
+	drrs []*lane.DRR
+	// bizIds 上次上报积压指标的业务方
+	bizIds map[uint64]struct{}
 }
 
 // Start 启动调度服务
@@ -42,19 +69,32 @@ func (ss *NotifShardingScheduler) Start(ctx context.Context) error {
 	return nil
 }
 
+// BatchSize 返回当前批次大小
+func (ss *NotifShardingScheduler) BatchSize() uint64 {
+	return ss.batchSize.Load()
+}
+
 func (ss *NotifShardingScheduler) loop(ctx context.Context) error {
+	dst, _ := sharding.DstFromContext(ctx)
 	for {
+		// 单次调用超时后返回，由 job 续约分布式锁后继续调度
+		if ctx.Err() != nil {
+			return nil
+		}
+
 		start := time.Now()
 
 		cnt, sendErr := ss.batchSend(ctx)
 
 		// 记录响应时间
 		respTime := time.Since(start)
+		metrics.SchedulerLoopDuration.WithLabelValues(dst.DB, dst.Table).Observe(respTime.Seconds())
 
 		// 记录错误事件
 		ss.errEvents.Add(sendErr != nil)
 		// 错误事件触发阈值（连续错误事件或错误率）
 		if ss.errEvents.ThresholdTriggering() {
+			metrics.SchedulerErrEventTrips.WithLabelValues(dst.DB, dst.Table).Inc()
 			return errs.ErrEventThresholdExceeded
 		}
 
@@ -65,22 +105,88 @@ func (ss *NotifShardingScheduler) loop(ctx context.Context) error {
 
 		// 没有数据时，等待一段时间再进行下一次调度
 		if cnt == 0 {
-			time.Sleep(ss.loopInterval - respTime)
-			continue
+			job.WaitUntil(ctx, time.Now().Add(ss.loopInterval-respTime))
 		}
 	}
 }
 
-// batchSend 批量发送已就绪的通知
+// batchSend 批量发送处于发送时间窗口中的通知
 // 执行成功会返回发送的通知数量
-func (ss *NotifShardingScheduler) batchSend(ctx context.Context) (int, error) {
+func (ss *NotifShardingScheduler) batchSend(ctx context.Context) (_ int, err error) {
 	const defaultTimeout = 3 * time.Second
 
+	dst, _ := sharding.DstFromContext(ctx)
+	ctx, span := tracing.Start(ctx, "Scheduler.BatchSend",
+		tracing.AttrShardDB.String(dst.DB),
+		tracing.AttrShardTable.String(dst.Table),
+		tracing.AttrBatchSize.Int64(int64(ss.batchSize.Load())),
+	)
+	defer func() { tracing.End(span, err) }()
+
 	loopCtx, cancel := context.WithTimeout(ctx, defaultTimeout)
 	defer cancel()
 
-	const offset = 0
-	notifications, err := ss.notifRepo.FindReady(loopCtx, offset, int(ss.batchSize.Load()))
+	backlogs, err := ss.notifRepo.CountReady(loopCtx)
+	if err != nil {
+		return 0, err
+	}
+
+	state := ss.shardState(dst)
+	ss.reportBacklog(dst, state, backlogs)
+
+	// remaining 各优先级下各业务方未拉取的积压，fetched 已拉取的数量
+	remaining := make([]map[uint64]int, len(domain.Priorities))
+	fetched := make([]map[uint64]int, len(domain.Priorities))
+	for i := range domain.Priorities {
+		remaining[i] = make(map[uint64]int)
+		fetched[i] = make(map[uint64]int)
+	}
+	for _, b := range backlogs {
+		if i := slices.Index(domain.Priorities, b.Priority); i >= 0 {
+			remaining[i][b.BizId] = b.Count
+		}
+	}
+
+	bizWeights := make(map[uint64]int)
+	weightOf := func(bizId uint64) int {
+		if w, ok := bizWeights[bizId]; ok {
+			return w
+		}
+		w := domain.DefaultSchedulingWeight
+		if bizConf, err := ss.bizConfRepo.GetById(loopCtx, bizId); err == nil {
+			w = bizConf.Weight()
+		} else {
+			ss.logger.Warn("[jotify] failed to get biz conf, using default scheduling weight", zap.Error(err), zap.Uint64("biz_id", bizId))
+		}
+		bizWeights[bizId] = w
+		return w
+	}
+
+	// 按优先级从高到低拉取，低优先级至少可以使用按权重分到的份额，
+	// 每个优先级的份额再按业务方权重轮询分配
+	notifications, err := lane.Drain(int(ss.batchSize.Load()), ss.weights,
+		func(i int, _ int, limit int) ([]domain.Notification, error) {
+			priority := domain.Priorities[i]
+			allocs := state.drrs[i].Allocate(limit, remaining[i], weightOf)
+
+			var res []domain.Notification
+			for bizId, n := range allocs {
+				ns, err := ss.notifRepo.FindReady(loopCtx, priority, bizId, fetched[i][bizId], n)
+				if err != nil {
+					return res, err
+				}
+				res = append(res, ns...)
+				fetched[i][bizId] += len(ns)
+				remaining[i][bizId] -= len(ns)
+				if len(ns) < n {
+					// 统计后积压已被发送或过期
+					remaining[i][bizId] = 0
+				}
+			}
+			metrics.SchedulerReadyNotifications.WithLabelValues(priority.String()).Add(float64(len(res)))
+			return res, nil
+		},
+	)
 	if err != nil {
 		return 0, err
 	}
@@ -93,13 +199,52 @@ func (ss *NotifShardingScheduler) batchSend(ctx context.Context) (int, error) {
 	return len(notifications), err
 }
 
+// shardState 返回分表的调度状态，不存在时创建
+func (ss *NotifShardingScheduler) shardState(dst sharding.Dst) *shardState {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	state, ok := ss.shards[dst]
+	if !ok {
+		state = &shardState{
+			drrs: slice.Map(domain.Priorities, func(_ int, _ domain.Priority) *lane.DRR {
+				return lane.NewDRR()
+			}),
+			bizIds: make(map[uint64]struct{}),
+		}
+		ss.shards[dst] = state
+	}
+	return state
+}
+
+// reportBacklog 按业务方上报分表的积压，移除已没有积压的业务方
+func (ss *NotifShardingScheduler) reportBacklog(dst sharding.Dst, state *shardState, backlogs []domain.ReadyBacklog) {
+	counts := make(map[uint64]int, len(backlogs))
+	for _, b := range backlogs {
+		counts[b.BizId] += b.Count
+	}
+
+	for bizId := range state.bizIds {
+		if _, ok := counts[bizId]; !ok {
+			metrics.SchedulerReadyBacklog.DeleteLabelValues(dst.DB, dst.Table, strconv.FormatUint(bizId, 10))
+			delete(state.bizIds, bizId)
+		}
+	}
+	for bizId, cnt := range counts {
+		metrics.SchedulerReadyBacklog.WithLabelValues(dst.DB, dst.Table, strconv.FormatUint(bizId, 10)).Set(float64(cnt))
+		state.bizIds[bizId] = struct{}{}
+	}
+}
+
 func NewNotifShardingScheduler(
 	dclient dlock.Dclient,
 	notifRepo repository.NotificationRepo,
+	bizConfRepo repository.BizConfRepo,
 	notifSender sender.Sender,
 	shardingStrategy sharding.Strategy,
 	resourceSemaphore job.ResourceSemaphore,
 	loopInterval time.Duration,
+	weights map[domain.Priority]int,
 	batchSize uint64,
 	batchAdjuster batch.Adjuster,
 	errEvents *bitring.BitRing,
@@ -107,12 +252,20 @@ func NewNotifShardingScheduler(
 ) *NotifShardingScheduler {
 	const jobBaseKey = "jotify_async_sharding_scheduler"
 
+	laneWeights := slice.Map(domain.Priorities, func(_ int, p domain.Priority) int {
+		return weights[p]
+	})
+
 	scheduler := &NotifShardingScheduler{
 		notifRepo:     notifRepo,
+		bizConfRepo:   bizConfRepo,
 		notifSender:   notifSender,
 		loopInterval:  loopInterval,
+		weights:       laneWeights,
 		batchAdjuster: batchAdjuster,
+		shards:        make(map[sharding.Dst]*shardState),
 		errEvents:     errEvents,
+		logger:        logger,
 	}
 	scheduler.job = job.NewShardingLoopJob(
 		jobBaseKey, resourceSemaphore, shardingStrategy, dclient, logger, scheduler.loop,
@@ -0,0 +1,149 @@
+package sender
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/pkg/calendar"
+	"github.com/JrMarcco/jotify/internal/pkg/metrics"
+	"github.com/JrMarcco/jotify/internal/repository"
+	"go.uber.org/zap"
+)
+
+var _ Sender = (*QuietHoursSender)(nil)
+
+// QuietHoursSender 免打扰时段装饰器。
+//
+// 任一接收者在其时区处于业务方配置的免打扰时段时，消息不发送，
+// 状态改回 pending 并推迟到全部接收者的免打扰时段结束之后，由调度器在推迟后的发送时间窗口内重新发送。验证码消息不受限制。
+type QuietHoursSender struct {
+	sender Sender
+
+	notifRepo   repository.NotificationRepo
+	bizConfRepo repository.BizConfRepo
+	tplRepo     repository.ChannelTplRepo
+
+	logger *zap.Logger
+}
+
+func (q *QuietHoursSender) Send(ctx context.Context, n domain.Notification) (domain.SendResp, error) {
+	until, ok := q.deferUntil(ctx, n, make(map[uint64]domain.BizType))
+	if !ok {
+		return q.sender.Send(ctx, n)
+	}
+
+	if err := q.deferTo(ctx, n, until); err != nil {
+		return domain.SendResp{}, err
+	}
+	return domain.SendResp{
+		Result: domain.SendResult{NotificationId: n.Id, Status: domain.SendStatusPending},
+	}, nil
+}
+
+func (q *QuietHoursSender) BatchSend(ctx context.Context, ns []domain.Notification) (domain.BatchSendResp, error) {
+	bizTypes := make(map[uint64]domain.BizType)
+	sending := make([]domain.Notification, 0, len(ns))
+	var deferred []domain.SendResult
+	for _, n := range ns {
+		until, ok := q.deferUntil(ctx, n, bizTypes)
+		if !ok {
+			sending = append(sending, n)
+			continue
+		}
+
+		if err := q.deferTo(ctx, n, until); err != nil {
+			// 版本冲突说明消息已被其他流程处理，不再发送
+			q.logger.Error("[jotify] failed to defer notification", zap.Error(err), zap.Uint64("notification_id", n.Id))
+			continue
+		}
+		deferred = append(deferred, domain.SendResult{NotificationId: n.Id, Status: domain.SendStatusPending})
+	}
+
+	resp, err := q.sender.BatchSend(ctx, sending)
+	if err != nil {
+		return resp, err
+	}
+	resp.Results = append(resp.Results, deferred...)
+	return resp, nil
+}
+
+// deferUntil 返回消息需要推迟到的时间，无需推迟或无法判断时返回 false，bizTypes 缓存同一批次中模板的业务类型
+func (q *QuietHoursSender) deferUntil(ctx context.Context, n domain.Notification, bizTypes map[uint64]domain.BizType) (time.Time, bool) {
+	bizConf, err := q.bizConfRepo.GetById(ctx, n.BizId)
+	if err != nil {
+		q.logger.Error("[jotify] failed to get biz conf", zap.Error(err), zap.Uint64("biz_id", n.BizId))
+		return time.Time{}, false
+	}
+	conf := bizConf.DeliveryConf
+	if conf == nil || conf.QuietHours == nil {
+		return time.Time{}, false
+	}
+
+	bizType, ok := bizTypes[n.Template.Id]
+	if !ok {
+		tpl, err := q.tplRepo.GetById(ctx, n.Template.Id)
+		if err != nil {
+			q.logger.Error("[jotify] failed to get template", zap.Error(err), zap.Uint64("template_id", n.Template.Id))
+			return time.Time{}, false
+		}
+		bizType = tpl.BizType
+		bizTypes[n.Template.Id] = bizType
+	}
+	if !conf.QuietHours.Applies(bizType) {
+		return time.Time{}, false
+	}
+
+	window, err := calendar.ParseWindow(conf.QuietHours.Start, conf.QuietHours.End)
+	if err != nil {
+		q.logger.Warn("[jotify] invalid quiet hours", zap.Error(err), zap.Uint64("biz_id", n.BizId))
+		return time.Time{}, false
+	}
+
+	now := time.Now()
+	var until time.Time
+	for _, receiver := range n.Receivers {
+		if end, ok := window.End(now.In(conf.LocationOf(receiver))); ok && end.After(until) {
+			until = end
+		}
+	}
+	return until, !until.IsZero()
+}
+
+// deferTo 将消息的发送时间窗口整体推迟到 until 开始，窗口长度不变。
+//
+// 调用方指定了窗口结束时间（time_window 或 deadline）时，推迟后的窗口不超出该结束时间，
+// until 不早于结束时间时窗口为空，调度器不会再发送，由过期任务在窗口结束后标记为过期并退还配额。
+func (q *QuietHoursSender) deferTo(ctx context.Context, n domain.Notification, until time.Time) error {
+	switch {
+	case !n.EndFixed:
+		n.ScheduledStart, n.ScheduledEnd = until, until.Add(n.ScheduledEnd.Sub(n.ScheduledStart))
+	case until.Before(n.ScheduledEnd):
+		n.ScheduledStart = until
+	default:
+		n.ScheduledStart = n.ScheduledEnd
+	}
+	if err := q.notifRepo.Defer(ctx, n); err != nil {
+		return fmt.Errorf("[jotify] failed to defer notification %d: %w", n.Id, err)
+	}
+
+	metrics.QuietHoursDeferred.WithLabelValues(n.Channel.String()).Inc()
+	return nil
+}
+
+func NewQuietHoursSender(
+	sender Sender,
+	notifRepo repository.NotificationRepo,
+	bizConfRepo repository.BizConfRepo,
+	tplRepo repository.ChannelTplRepo,
+	logger *zap.Logger,
+) *QuietHoursSender {
+	return &QuietHoursSender{
+		sender:      sender,
+		notifRepo:   notifRepo,
+		bizConfRepo: bizConfRepo,
+		tplRepo:     tplRepo,
+		logger:      logger,
+	}
+}
@@ -0,0 +1,165 @@
+package sender
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/pkg/calendar"
+	"github.com/JrMarcco/jotify/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeNotifRepo 记录推迟的消息
+type fakeNotifRepo struct {
+	repository.NotificationRepo
+	deferred []domain.Notification
+}
+
+func (r *fakeNotifRepo) Defer(_ context.Context, n domain.Notification) error {
+	r.deferred = append(r.deferred, n)
+	return nil
+}
+
+type fakeBizConfRepo struct {
+	repository.BizConfRepo
+	conf domain.BizConf
+}
+
+func (r *fakeBizConfRepo) GetById(context.Context, uint64) (domain.BizConf, error) {
+	return r.conf, nil
+}
+
+type fakeTplRepo struct {
+	repository.ChannelTplRepo
+	bizType domain.BizType
+}
+
+func (r *fakeTplRepo) GetById(_ context.Context, id uint64) (domain.ChannelTpl, error) {
+	return domain.ChannelTpl{Id: id, BizType: r.bizType}, nil
+}
+
+// fakeSender 记录实际发送的消息
+type fakeSender struct {
+	sent []domain.Notification
+}
+
+func (s *fakeSender) Send(_ context.Context, n domain.Notification) (domain.SendResp, error) {
+	s.sent = append(s.sent, n)
+	return domain.SendResp{Result: domain.SendResult{NotificationId: n.Id, Status: domain.SendStatusSuccess}}, nil
+}
+
+func (s *fakeSender) BatchSend(ctx context.Context, ns []domain.Notification) (domain.BatchSendResp, error) {
+	resp := domain.BatchSendResp{}
+	for _, n := range ns {
+		r, _ := s.Send(ctx, n)
+		resp.Results = append(resp.Results, r.Result)
+	}
+	return resp, nil
+}
+
+func TestQuietHoursSender_Send(t *testing.T) {
+	t.Parallel()
+
+	// 当前时间位于免打扰时段内，时段在两小时后结束
+	now := time.Now().UTC()
+	quietHours := &domain.QuietHours{Start: now.Add(-time.Hour).Format("15:04"), End: now.Add(2 * time.Hour).Format("15:04")}
+	window, err := calendar.ParseWindow(quietHours.Start, quietHours.End)
+	require.NoError(t, err)
+	until, ok := window.End(now)
+	require.True(t, ok)
+
+	tcs := []struct {
+		name      string
+		bizType   domain.BizType
+		start     time.Time
+		end       time.Time
+		endFixed  bool
+		wantSent  bool
+		wantStart time.Time
+		wantEnd   time.Time
+	}{
+		{
+			name:      "default window moves with quiet hours",
+			bizType:   domain.BizTypePromotion,
+			start:     now,
+			end:       now.Add(30 * time.Minute),
+			wantStart: until,
+			wantEnd:   until.Add(30 * time.Minute),
+		}, {
+			name:      "fixed end after quiet hours",
+			bizType:   domain.BizTypePromotion,
+			start:     now,
+			end:       until.Add(time.Hour),
+			endFixed:  true,
+			wantStart: until,
+			wantEnd:   until.Add(time.Hour),
+		}, {
+			name:      "fixed end at quiet hours end",
+			bizType:   domain.BizTypePromotion,
+			start:     now,
+			end:       until,
+			endFixed:  true,
+			wantStart: until,
+			wantEnd:   until,
+		}, {
+			name:      "fixed end inside quiet hours",
+			bizType:   domain.BizTypePromotion,
+			start:     now,
+			end:       until.Add(-time.Minute),
+			endFixed:  true,
+			wantStart: until.Add(-time.Minute),
+			wantEnd:   until.Add(-time.Minute),
+		}, {
+			name:     "verify code not deferred",
+			bizType:  domain.BizTypeVerifyCode,
+			start:    now,
+			end:      now.Add(30 * time.Minute),
+			wantSent: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			notifRepo := &fakeNotifRepo{}
+			sender := &fakeSender{}
+			q := NewQuietHoursSender(
+				sender,
+				notifRepo,
+				&fakeBizConfRepo{conf: domain.BizConf{DeliveryConf: &domain.DeliveryConf{QuietHours: quietHours}}},
+				&fakeTplRepo{bizType: tc.bizType},
+				zap.NewNop(),
+			)
+
+			n := domain.Notification{
+				Id:             1,
+				BizId:          1,
+				Receivers:      []string{"13800000000"},
+				Channel:        domain.ChannelSMS,
+				Template:       domain.Template{Id: 1},
+				ScheduledStart: tc.start,
+				ScheduledEnd:   tc.end,
+				EndFixed:       tc.endFixed,
+			}
+			resp, err := q.Send(context.Background(), n)
+			require.NoError(t, err)
+
+			if tc.wantSent {
+				assert.Len(t, sender.sent, 1)
+				assert.Empty(t, notifRepo.deferred)
+				return
+			}
+
+			assert.Empty(t, sender.sent)
+			assert.Equal(t, domain.SendStatusPending, resp.Result.Status)
+			require.Len(t, notifRepo.deferred, 1)
+			assert.True(t, tc.wantStart.Equal(notifRepo.deferred[0].ScheduledStart), "start %s", notifRepo.deferred[0].ScheduledStart)
+			assert.True(t, tc.wantEnd.Equal(notifRepo.deferred[0].ScheduledEnd), "end %s", notifRepo.deferred[0].ScheduledEnd)
+		})
+	}
+}
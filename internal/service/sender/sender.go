@@ -6,9 +6,11 @@ import (
 	"sync"
 
 	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/pkg/tracing"
 	"github.com/JrMarcco/jotify/internal/repository"
 	"github.com/JrMarcco/jotify/internal/service/channel"
 	"github.com/JrMarcco/jotify/internal/service/notification/callback"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 )
@@ -38,10 +40,13 @@ type DefaultSender struct {
 	logger *zap.Logger
 }
 
-func (ds *DefaultSender) Send(ctx context.Context, n domain.Notification) (domain.SendResp, error) {
+func (ds *DefaultSender) Send(ctx context.Context, n domain.Notification) (_ domain.SendResp, err error) {
+	ctx, span := ds.startSpan(ctx, "Sender.Send", n)
+	defer func() { tracing.End(span, err) }()
+
 	res := domain.SendResult{NotificationId: n.Id}
 
-	_, err := ds.channel.Send(ctx, n)
+	_, err = ds.channel.Send(ctx, n)
 	if err != nil {
 		ds.logger.Error("[jotify] send notification error", zap.Error(err))
 		res.Status = domain.SendStatusFailure
@@ -77,7 +82,9 @@ func (ds *DefaultSender) BatchSend(ctx context.Context, ns []domain.Notification
 		n := ns[i]
 		// TODO: 这里可以考虑做 task pool 来控制 goroutine 数量
 		eg.Go(func() error {
-			_, err := ds.channel.Send(ctx, n)
+			sendCtx, span := ds.startSpan(ctx, "Sender.BatchSend", n)
+			_, err := ds.channel.Send(sendCtx, n)
+			tracing.End(span, err)
 			if err != nil {
 				res := domain.SendResult{
 					NotificationId: n.Id,
@@ -133,6 +140,15 @@ func (ds *DefaultSender) BatchSend(ctx context.Context, ns []domain.Notification
 	}, nil
 }
 
+// startSpan 开启单条消息的发送 span，并通过 link 关联回创建消息时的请求链路
+func (ds *DefaultSender) startSpan(ctx context.Context, name string, n domain.Notification) (context.Context, trace.Span) {
+	return tracing.StartWithLinks(ctx, name, tracing.Links(n.TraceCtx),
+		tracing.AttrNotificationId.Int64(int64(n.Id)),
+		tracing.AttrBizId.Int64(int64(n.BizId)),
+		tracing.AttrChannel.String(n.Channel.String()),
+	)
+}
+
 func (ds *DefaultSender) getNsWithStatus(results []domain.SendResult, nMap map[uint64]domain.Notification) []domain.Notification {
 	ns := make([]domain.Notification, 0, len(results))
 	for _, res := range results {
@@ -144,10 +160,12 @@ func (ds *DefaultSender) getNsWithStatus(results []domain.SendResult, nMap map[u
 	return ns
 }
 
+// batchUpdateStatus 批量更新发送结果，发送失败的消息退还配额
 func (ds *DefaultSender) batchUpdateStatus(ctx context.Context, successNs, failureNs []domain.Notification) error {
-	if len(successNs) > 0 || len(failureNs) > 0 {
+	if len(successNs) == 0 && len(failureNs) == 0 {
+		return nil
 	}
-	return nil
+	return ds.notifRepo.BatchUpdateStatus(ctx, successNs, failureNs)
 }
 
 func NewDefaultSender(
@@ -16,12 +16,14 @@ var _ SendStrategy = (*DefaultSendStrategy)(nil)
 type DefaultSendStrategy struct {
 	notifRepo   repository.NotificationRepo
 	bizConfRepo repository.BizConfRepo
+	pacer       *Pacer
 	logger      *zap.Logger
 }
 
 // Send 单条消息发送
 func (dss *DefaultSendStrategy) Send(ctx context.Context, n domain.Notification) (domain.SendResp, error) {
 	n.SetSendTime()
+	n.Status = domain.SendStatusPending
 
 	created, err := dss.create(ctx, n)
 	if err != nil {
@@ -50,6 +52,16 @@ func (dss *DefaultSendStrategy) BatchSend(ctx context.Context, ns []domain.Notif
 		return domain.BatchSendResp{}, fmt.Errorf("%w: notifications should not be empty", errs.ErrInvalidParam)
 	}
 
+	for i := range ns {
+		ns[i].SetSendTime()
+		ns[i].Status = domain.SendStatusPending
+	}
+	if ns[0].StrategyConfig.Pacing.Enabled() {
+		if err := dss.pace(ctx, ns); err != nil {
+			return domain.BatchSendResp{}, fmt.Errorf("[jotify] pace notifications error: %w", err)
+		}
+	}
+
 	createdNs, err := dss.batchCreate(ctx, ns)
 	if err != nil {
 		return domain.BatchSendResp{}, fmt.Errorf("[jotify] create delayed notifications error: %w", err)
@@ -67,6 +79,19 @@ func (dss *DefaultSendStrategy) BatchSend(ctx context.Context, ns []domain.Notif
 	}, nil
 }
 
+// pace 按发送计划将消息的发送时间窗口开始时间分散到窗口内，业务方与渠道以第一条消息为准
+func (dss *DefaultSendStrategy) pace(ctx context.Context, ns []domain.Notification) error {
+	const first = 0
+	plan, err := dss.pacer.Plan(ctx, ns[first].BizId, ns[first].Channel, ns[first].StrategyConfig, len(ns))
+	if err != nil {
+		return err
+	}
+	for i := range ns {
+		ns[i].ScheduledStart = plan.At(i)
+	}
+	return nil
+}
+
 func (dss *DefaultSendStrategy) batchCreate(ctx context.Context, ns []domain.Notification) ([]domain.Notification, error) {
 	const first = 0
 	if dss.needCallbackLog(ctx, ns[first]) {
@@ -87,11 +112,13 @@ func (dss *DefaultSendStrategy) needCallbackLog(ctx context.Context, n domain.No
 func NewDefaultSendStrategy(
 	notifRepo repository.NotificationRepo,
 	bizConfRepo repository.BizConfRepo,
+	pacer *Pacer,
 	logger *zap.Logger,
 ) *DefaultSendStrategy {
 	return &DefaultSendStrategy{
 		notifRepo:   notifRepo,
 		bizConfRepo: bizConfRepo,
+		pacer:       pacer,
 		logger:      logger,
 	}
 }
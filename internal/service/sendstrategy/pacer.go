@@ -0,0 +1,39 @@
+package sendstrategy
+
+import (
+	"context"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/pkg/pacing"
+	"github.com/JrMarcco/jotify/internal/repository"
+)
+
+// Pacer 为开启匀速发送的消息制定发送计划，速率上限取业务方的每秒限流与渠道供应商的 QPS 上限之和中较小的一个
+type Pacer struct {
+	bizConfRepo  repository.BizConfRepo
+	providerRepo repository.ProviderRepo
+}
+
+// Plan 按发送策略的时间窗口与曲线为业务方在渠道上的 total 个发送量制定计划
+func (p *Pacer) Plan(
+	ctx context.Context, bizId uint64, channel domain.Channel, conf domain.SendStrategyConf, total int,
+) (pacing.Plan, error) {
+	bizConf, err := p.bizConfRepo.GetById(ctx, bizId)
+	if err != nil {
+		return pacing.Plan{}, err
+	}
+	channelQps, err := p.providerRepo.ChannelQps(ctx, channel)
+	if err != nil {
+		return pacing.Plan{}, err
+	}
+
+	start, end := conf.CalcTimeWindow()
+	return pacing.NewPlan(start, end, conf.Pacing.Weights(), total, domain.PacingRate(bizConf.RateLimit, channelQps)), nil
+}
+
+func NewPacer(bizConfRepo repository.BizConfRepo, providerRepo repository.ProviderRepo) *Pacer {
+	return &Pacer{
+		bizConfRepo:  bizConfRepo,
+		providerRepo: providerRepo,
+	}
+}
@@ -0,0 +1,63 @@
+package sendstrategy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/JrMarcco/jotify/internal/domain"
+	"github.com/JrMarcco/jotify/internal/errs"
+	"github.com/JrMarcco/jotify/internal/repository"
+)
+
+// maxRecurringBizKeyLen 周期发送计划 biz_key 的最大长度，为派生消息的触发时间后缀预留空间
+const maxRecurringBizKeyLen = 200
+
+var _ SendStrategy = (*RecurringSendStrategy)(nil)
+
+// RecurringSendStrategy 周期发送策略，只保存周期发送计划，不创建消息也不扣减配额。
+// 每次触发时由 recurring.Spawner 创建消息。
+type RecurringSendStrategy struct {
+	recurringRepo repository.RecurringRepo
+}
+
+func (s *RecurringSendStrategy) Send(ctx context.Context, n domain.Notification) (domain.SendResp, error) {
+	res, err := s.create(ctx, n)
+	if err != nil {
+		return domain.SendResp{}, err
+	}
+	return domain.SendResp{Result: res}, nil
+}
+
+func (s *RecurringSendStrategy) BatchSend(ctx context.Context, ns []domain.Notification) (domain.BatchSendResp, error) {
+	results := make([]domain.SendResult, 0, len(ns))
+	for _, n := range ns {
+		res, err := s.create(ctx, n)
+		if err != nil {
+			return domain.BatchSendResp{}, err
+		}
+		results = append(results, res)
+	}
+	return domain.BatchSendResp{Results: results}, nil
+}
+
+func (s *RecurringSendStrategy) create(ctx context.Context, n domain.Notification) (domain.SendResult, error) {
+	if len(n.BizKey) > maxRecurringBizKeyLen {
+		return domain.SendResult{}, fmt.Errorf("%w: biz key of recurring schedule should not be longer than %d", errs.ErrInvalidParam, maxRecurringBizKeyLen)
+	}
+
+	schedule := domain.RecurringScheduleFrom(n)
+	if schedule.Status == domain.RecurringStatusFinished {
+		return domain.SendResult{}, fmt.Errorf("%w: recurring schedule has no occurrence", errs.ErrInvalidParam)
+	}
+
+	if err := s.recurringRepo.Create(ctx, schedule); err != nil {
+		return domain.SendResult{}, fmt.Errorf("[jotify] create recurring schedule error: %w", err)
+	}
+	return domain.SendResult{ScheduleId: schedule.Id, Status: domain.SendStatusPending}, nil
+}
+
+func NewRecurringSendStrategy(recurringRepo repository.RecurringRepo) *RecurringSendStrategy {
+	return &RecurringSendStrategy{
+		recurringRepo: recurringRepo,
+	}
+}
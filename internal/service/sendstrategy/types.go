@@ -6,15 +6,18 @@ import (
 
 	"github.com/JrMarcco/jotify/internal/domain"
 	"github.com/JrMarcco/jotify/internal/errs"
+	"github.com/JrMarcco/jotify/internal/pkg/metrics"
+	"github.com/JrMarcco/jotify/internal/pkg/tracing"
 )
 
 //go:generate mockgen -source=./types.go -destination=./mock/send_strategy.mock.go -package=sendstrategymock -typed SendStrategy
 
 // SendStrategy 消息发送策略。
 //
-// 目前两种实现：
+// 目前三种实现：
 // DefaultSendStrategy 		默认，使用异步发送（存库等待后续调度执行实际发送）
 // ImmediateSendStrategy	立即发送，即同步
+// RecurringSendStrategy	周期发送，保存周期发送计划
 type SendStrategy interface {
 	Send(ctx context.Context, n domain.Notification) (domain.SendResp, error)
 	BatchSend(ctx context.Context, ns []domain.Notification) (domain.BatchSendResp, error)
@@ -24,12 +27,21 @@ var _ SendStrategy = (*Dispatcher)(nil)
 
 // Dispatcher is a strategy dispatcher that chooses the appropriate strategy based on the notification's strategy configuration.
 type Dispatcher struct {
-	defaultStrategy   *DefaultSendStrategy
-	immediateStrategy *ImmediateSendStrategy
+	defaultStrategy   SendStrategy
+	immediateStrategy SendStrategy
+	recurringStrategy SendStrategy
 }
 
 func (d *Dispatcher) Send(ctx context.Context, n domain.Notification) (domain.SendResp, error) {
-	return d.chooseStrategy(n).Send(ctx, n)
+	ctx, span := tracing.Start(ctx, "SendStrategy.Send",
+		tracing.AttrStrategy.String(n.StrategyConfig.Type.String()),
+		tracing.AttrNotificationId.Int64(int64(n.Id)),
+	)
+
+	resp, err := d.chooseStrategy(n).Send(ctx, n)
+	d.observe(n.StrategyConfig.Type, 1, err)
+	tracing.End(span, err)
+	return resp, err
 }
 
 func (d *Dispatcher) BatchSend(ctx context.Context, ns []domain.Notification) (domain.BatchSendResp, error) {
@@ -37,20 +49,41 @@ func (d *Dispatcher) BatchSend(ctx context.Context, ns []domain.Notification) (d
 		return domain.BatchSendResp{}, fmt.Errorf("%w: no notifications to send", errs.ErrInvalidParam)
 	}
 
-	return d.chooseStrategy(ns[0]).BatchSend(ctx, ns)
+	ctx, span := tracing.Start(ctx, "SendStrategy.BatchSend",
+		tracing.AttrStrategy.String(ns[0].StrategyConfig.Type.String()),
+		tracing.AttrBatchSize.Int(len(ns)),
+	)
+
+	resp, err := d.chooseStrategy(ns[0]).BatchSend(ctx, ns)
+	d.observe(ns[0].StrategyConfig.Type, len(ns), err)
+	tracing.End(span, err)
+	return resp, err
+}
+
+// observe 记录发送策略处理结果指标
+func (d *Dispatcher) observe(strategy domain.SendStrategy, cnt int, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	metrics.SendStrategyTotal.WithLabelValues(strategy.String(), result).Add(float64(cnt))
 }
 
 func (d *Dispatcher) chooseStrategy(n domain.Notification) SendStrategy {
-	if n.StrategyConfig.Type == domain.SendStrategyImmediate {
+	switch n.StrategyConfig.Type {
+	case domain.SendStrategyImmediate:
 		return d.immediateStrategy
+	case domain.SendStrategyRecurring:
+		return d.recurringStrategy
+	default:
+		return d.defaultStrategy
 	}
-
-	return d.defaultStrategy
 }
 
-func NewDispatcher(defaultStrategy *DefaultSendStrategy, immediateStrategy *ImmediateSendStrategy) *Dispatcher {
+func NewDispatcher(defaultStrategy, immediateStrategy, recurringStrategy SendStrategy) *Dispatcher {
 	return &Dispatcher{
 		defaultStrategy:   defaultStrategy,
 		immediateStrategy: immediateStrategy,
+		recurringStrategy: recurringStrategy,
 	}
 }
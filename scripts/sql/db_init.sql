@@ -1 +1,4 @@
-CREATE DATABASE jotify DEFAULT CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci;
+-- 创建基础库与分库，表结构由 jotifyctl migrate up 创建（见 internal/migration/sql）
+CREATE DATABASE IF NOT EXISTS jotify DEFAULT CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci;
+CREATE DATABASE IF NOT EXISTS jotify_0 DEFAULT CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci;
+CREATE DATABASE IF NOT EXISTS jotify_1 DEFAULT CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci;